@@ -0,0 +1,91 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"context"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+
+	"github.com/cloudspannerecosystem/dynamodb-adapter/models"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/pkg/errors"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// defaultBatchGetConcurrency is the number of tables of a BatchGetItem
+// request read at the same time when batch_get_concurrency is not set
+const defaultBatchGetConcurrency = 8
+
+// batchGetResult is the outcome of reading one table of a BatchGetItem request
+type batchGetResult struct {
+	items       interface{}
+	unprocessed []int
+	err         error
+}
+
+// batchGetConcurrency returns the number of tables read at the same time
+func batchGetConcurrency() int {
+	if models.GlobalConfig != nil && models.GlobalConfig.Spanner.BatchGetConcurrency > 0 {
+		return models.GlobalConfig.Spanner.BatchGetConcurrency
+	}
+	return defaultBatchGetConcurrency
+}
+
+// batchGetTables reads the tables of a BatchGetItem request with a bounded
+// pool of workers. The items of all the tables share the response size limit.
+// The results are in the order of tables.
+func batchGetTables(ctx context.Context, batchGetMeta models.BatchGetMeta, tables []string, span trace.Span) []batchGetResult {
+	results := make([]batchGetResult, len(tables))
+	budget := new(atomic.Int64)
+	budget.Store(maxBatchGetResponseSize)
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(batchGetConcurrency(), len(tables)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = batchGetTable(ctx, tables[i], batchGetMeta.RequestItems[tables[i]], budget, span)
+			}
+		}()
+	}
+	for i := range tables {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return results
+}
+
+// batchGetTable reads the items of one table and converts them to DynamoDB
+// JSON. Panics are returned as errors, the handler cannot recover them.
+func batchGetTable(ctx context.Context, tableName string, request models.BatchGetWithProjectionMeta, budget *atomic.Int64, span trace.Span) (result batchGetResult) {
+	defer func() {
+		if e := recover(); e != nil {
+			result = batchGetResult{err: errors.New("ServerInternalError", e, string(debug.Stack()))}
+		}
+	}()
+	request.TableName = tableName
+	items, unprocessed, _, err := batchGetDataSingleTable(ctx, request, budget, span)
+	if err != nil {
+		return batchGetResult{err: err}
+	}
+	output, err := ChangeMaptoDynamoMap(items)
+	if err != nil {
+		return batchGetResult{err: err}
+	}
+	return batchGetResult{items: output["L"], unprocessed: unprocessed}
+}
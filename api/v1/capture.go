@@ -0,0 +1,100 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"bytes"
+	"io"
+	"time"
+
+	"github.com/cloudspannerecosystem/dynamodb-adapter/config"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/models"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/pkg/capture"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// Capture defaults
+const (
+	defaultCaptureDirectory = "captures"
+	defaultCaptureFileMB    = 100
+	defaultCaptureFiles     = 10
+)
+
+// newCaptureWriter opens the capture directory of cfg
+func newCaptureWriter(cfg models.CaptureConfig) (*capture.Writer, error) {
+	dir, sizeMB, files := cfg.Directory, cfg.MaxFileSizeMB, cfg.MaxFiles
+	if dir == "" {
+		dir = defaultCaptureDirectory
+	}
+	if sizeMB <= 0 {
+		sizeMB = defaultCaptureFileMB
+	}
+	if files <= 0 {
+		files = defaultCaptureFiles
+	}
+	return capture.NewWriter(dir, sizeMB*1024*1024, files)
+}
+
+// CaptureHandler returns a middleware recording the requests as the client
+// sent them and the responses as the client received them into w. Credentials
+// are never recorded.
+func CaptureHandler(w *capture.Writer, redact bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			logger.LogError(err)
+			c.Next()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		writer := &teeResponseWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		start := time.Now()
+		c.Next()
+		latency := time.Since(start)
+		c.Writer = writer.ResponseWriter
+
+		err = w.Write(capture.Record{
+			Time:      start.UTC(),
+			Target:    c.GetHeader("X-Amz-Target"),
+			Tenant:    config.TenantFromContext(c.Request.Context()).Name,
+			Request:   capture.Sanitize(body, redact),
+			Status:    c.Writer.Status(),
+			Response:  capture.Sanitize(writer.body.Bytes(), redact),
+			LatencyMs: float64(latency.Microseconds()) / 1000,
+		})
+		if err != nil {
+			logger.LogError(err)
+		}
+	}
+}
+
+// teeResponseWriter keeps a copy of the response body while it is sent
+type teeResponseWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *teeResponseWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *teeResponseWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,76 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cloudspannerecosystem/dynamodb-adapter/config"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/models"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/pkg/capture"
+	"github.com/gin-gonic/gin"
+	"github.com/tj/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func TestCaptureHandler(t *testing.T) {
+	dir := t.TempDir()
+	w, err := capture.NewWriter(dir, 0, 0)
+	assert.NoError(t, err)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/v1", CaptureHandler(w, false), func(c *gin.Context) {
+		// the tenant is the one the tenant middleware resolved
+		c.Request = c.Request.WithContext(config.WithTenant(c.Request.Context(), models.Tenant{Name: "dev"}))
+	}, func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		assert.Equal(t, `{"TableName":"orders"}`, string(body))
+		c.JSON(http.StatusBadRequest, gin.H{"code": "ResourceNotFoundException"})
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/v1", strings.NewReader(`{"TableName":"orders"}`))
+	req.Header.Set("X-Amz-Target", "DynamoDB_20120810.DescribeTable")
+	req.Header.Set("Authorization", "secret")
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.NoError(t, w.Close())
+
+	records, err := capture.ReadRecords(dir)
+	assert.NoError(t, err)
+	assert.Len(t, records, 1)
+	assert.Equal(t, "DescribeTable", records[0].Action())
+	assert.Equal(t, "dev", records[0].Tenant)
+	assert.Equal(t, http.StatusBadRequest, records[0].Status)
+	assert.JSONEq(t, `{"TableName":"orders"}`, string(records[0].Request))
+	assert.JSONEq(t, `{"code":"ResourceNotFoundException"}`, string(records[0].Response))
+}
+
+func TestCaptureRedactsByDefault(t *testing.T) {
+	for doc, redact := range map[string]bool{
+		"enabled: true":                true,
+		"enabled: true\nredact: true":  true,
+		"enabled: true\nredact: false": false,
+	} {
+		var cfg models.CaptureConfig
+		assert.NoError(t, yaml.Unmarshal([]byte(doc), &cfg))
+		assert.Equal(t, redact, cfg.RedactValues(), doc)
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"math/big"
 	"reflect"
 	"regexp"
 	"runtime"
@@ -26,13 +27,13 @@ import (
 	"strconv"
 	"strings"
 
-	"cloud.google.com/go/spanner"
 	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/cloudspannerecosystem/dynamodb-adapter/models"
 	"github.com/cloudspannerecosystem/dynamodb-adapter/pkg/errors"
 	"github.com/cloudspannerecosystem/dynamodb-adapter/pkg/logger"
 	"github.com/cloudspannerecosystem/dynamodb-adapter/service/services"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/storage"
 	"github.com/cloudspannerecosystem/dynamodb-adapter/utils"
 )
 
@@ -41,7 +42,6 @@ var byteSliceType = reflect.TypeOf([]byte(nil))
 var defaultLevel int16 = 1
 var (
 	listRegex             = regexp.MustCompile(`list_append\(([^,]+),\s*([^\)]+)\)`)
-	listIndexRegex        = regexp.MustCompile(`(\w+)\[(\d+)\]`)
 	listUpdateAppendRegex = regexp.MustCompile(`(?i)list_append\(([^)]+),\s*(:\w+)\)`)
 )
 
@@ -113,7 +113,7 @@ func parseActionValue(actionValue string, updateAtrr models.UpdateAttr, assignme
 	expr := parseUpdateExpresstion(actionValue)
 	if expr != nil {
 		actionValue = expr.ActionVal
-		expr.AddValues = make(map[string]float64)
+		expr.AddValues = make(map[string]models.Number)
 	}
 
 	resp := make(map[string]interface{})
@@ -125,7 +125,7 @@ func parseActionValue(actionValue string, updateAtrr models.UpdateAttr, assignme
 	}
 	var v []string
 	for _, p := range pairs {
-		var addValue float64
+		var addValue models.Number
 		status := false
 
 		// Handle addition (e.g., "count + 1")
@@ -135,12 +135,8 @@ func parseActionValue(actionValue string, updateAtrr models.UpdateAttr, assignme
 			p = tokens[0]
 			v1, ok := updateAtrr.ExpressionAttributeMap[tokens[1]]
 			if ok {
-				switch v2 := v1.(type) {
-				case float64:
-					addValue = v2
-					status = true
-				case int64:
-					addValue = float64(v2)
+				if n, isNumber := models.ToNumber(v1); isNumber {
+					addValue = n
 					status = true
 				}
 			}
@@ -152,12 +148,8 @@ func parseActionValue(actionValue string, updateAtrr models.UpdateAttr, assignme
 			tokens[1] = strings.TrimSpace(tokens[1])
 			v1, ok := updateAtrr.ExpressionAttributeMap[tokens[1]]
 			if ok {
-				switch v2 := v1.(type) {
-				case float64:
-					addValue = -v2
-					status = true
-				case int64:
-					addValue = float64(-v2)
+				if n, isNumber := models.ToNumber(v1); isNumber {
+					addValue = n.Neg()
 					status = true
 				}
 			}
@@ -204,33 +196,10 @@ func parseActionValue(actionValue string, updateAtrr models.UpdateAttr, assignme
 			if !ok {
 				continue
 			}
-			// Handle SET with list index, e.g., guid[1] = :new_value
-			matches := listIndexRegex.FindStringSubmatch(field)
-			if len(matches) == 3 {
-				listField := matches[1]
-				index, err := strconv.Atoi(matches[2])
-				if err != nil {
-					continue
-				}
-
-				// Retrieve the old list and modify the specified index
-				oldList, ok := oldRes[listField].([]interface{})
-				if !ok {
-					continue
-				}
-				// Validate index bounds
-				if index < 0 || index > len(oldList) {
-					continue
-				}
-
-				updatedList := make([]interface{}, len(oldList))
-				copy(updatedList, oldList)
-				if index == len(oldList) {
-					updatedList = append(updatedList, value) // Append new value
-				} else if index < len(oldList) {
-					updatedList[index] = value // Modify existing value
-				}
-				resp[listField] = updatedList
+			// Attributes nested in maps and lists, e.g. guid[1] or a.b[3], are
+			// updated in the current value of their attribute when it is written
+			if strings.ContainsAny(field, ".[") {
+				resp[field] = value
 				continue
 			}
 
@@ -277,7 +246,7 @@ func parseActionValue(actionValue string, updateAtrr models.UpdateAttr, assignme
 				switch newValue := tmp.(type) {
 				case []string: // String Set
 					resp[key] = handleStringSet(oldRes, key, newValue, updateAtrr.UpdateExpression)
-				case []float64: // Number Set
+				case []models.Number: // Number Set
 					resp[key] = handleNumberSet(oldRes, key, newValue, updateAtrr.UpdateExpression)
 				case [][]byte: // Binary Set
 					resp[key] = handleByteSet(oldRes, key, newValue, updateAtrr.UpdateExpression)
@@ -311,18 +280,18 @@ func handleStringSet(oldRes map[string]interface{}, key string, newValue []strin
 	}
 }
 
-// handleNumberSet handles set operations for number sets (float64).
-func handleNumberSet(oldRes map[string]interface{}, key string, newValue []float64, updateExpression string) []float64 {
-	if floatSlice, ok := oldRes[key].([]float64); ok {
+// handleNumberSet handles set operations for number sets.
+func handleNumberSet(oldRes map[string]interface{}, key string, newValue []models.Number, updateExpression string) []models.Number {
+	if numberSlice, ok := oldRes[key].([]models.Number); ok {
 		if strings.Contains(updateExpression, "ADD") {
-			return utils.RemoveDuplicatesFloat(append(floatSlice, newValue...))
+			return utils.RemoveDuplicatesNumber(append(numberSlice, newValue...))
 		} else if strings.Contains(updateExpression, "DELETE") {
-			return removeFromSlice(floatSlice, newValue)
+			return removeFromSlice(numberSlice, newValue)
 		} else {
-			return utils.RemoveDuplicatesFloat(newValue)
+			return utils.RemoveDuplicatesNumber(newValue)
 		}
 	} else { // No existing value
-		return utils.RemoveDuplicatesFloat(newValue)
+		return utils.RemoveDuplicatesNumber(newValue)
 	}
 }
 
@@ -481,6 +450,9 @@ func UpdateExpression(ctx context.Context, updateAtrr models.UpdateAttr, svc ser
 		}
 	}
 	logger.LogDebug(updateAtrr.ReturnValues, resp, oldRes)
+	if er != nil {
+		return nil, er
+	}
 
 	var output map[string]interface{}
 	var errOutput error
@@ -683,18 +655,8 @@ func convertFrom(a *dynamodb.AttributeValue, tableName string, level int16) inte
 	}
 
 	if a.N != nil {
-		if strings.ToLower(*a.N) == "infinity" || strings.ToLower(*a.N) == "-infinity" || strings.ToLower(*a.N) == "nan" {
-			panic("N does not support " + *a.N + " type value")
-		}
-		// Number is tricky b/c we don't know which numeric type to use. Here we
-		// simply try the different types from most to least restrictive.
-		if n, err := strconv.ParseInt(*a.N, 10, 64); err == nil {
-			return float64(n)
-		}
-		if n, err := strconv.ParseUint(*a.N, 10, 64); err == nil {
-			return float64(n)
-		}
-		n, err := strconv.ParseFloat(*a.N, 64)
+		// Numbers are kept exact, in the canonical form DynamoDB returns them in
+		n, err := models.ParseNumber(*a.N)
 		if err != nil {
 			panic(err)
 		}
@@ -734,58 +696,45 @@ func convertFrom(a *dynamodb.AttributeValue, tableName string, level int16) inte
 		return a.B
 	}
 	if a.SS != nil {
-		if level > 1 {
-			panic("The Map and List types do not support String Set (SS) values. Please use the List type instead.")
-		} else {
-			uniqueStrings := make(map[string]struct{})
-			for _, v := range a.SS {
-				uniqueStrings[*v] = struct{}{}
-			}
+		uniqueStrings := make(map[string]struct{})
+		for _, v := range a.SS {
+			uniqueStrings[*v] = struct{}{}
+		}
 
-			// Convert map keys to a slice
-			l := make([]string, 0, len(uniqueStrings))
-			for str := range uniqueStrings {
-				l = append(l, str)
-			}
-			return l
+		// Convert map keys to a slice
+		l := make([]string, 0, len(uniqueStrings))
+		for str := range uniqueStrings {
+			l = append(l, str)
 		}
+		return l
 	}
 	if a.NS != nil {
-		if level > 1 {
-			panic("The Map and List types do not support Number Set (NS) values. Please use the List type instead.")
-		} else {
-			l := []float64{}
-			numberMap := make(map[string]struct{})
-			for _, v := range a.NS {
-				if _, exists := numberMap[*v]; !exists {
-					numberMap[*v] = struct{}{}
-					n, err := strconv.ParseFloat(*v, 64)
-					if err != nil {
-						panic(fmt.Sprintf("Invalid number in NS: %s", *v))
-					}
-					l = append(l, n)
-				}
+		l := []models.Number{}
+		numberMap := make(map[models.Number]struct{})
+		for _, v := range a.NS {
+			n, err := models.ParseNumber(*v)
+			if err != nil {
+				panic(err)
+			}
+			if _, exists := numberMap[n]; !exists {
+				numberMap[n] = struct{}{}
+				l = append(l, n)
 			}
-			return l
 		}
+		return l
 	}
 	if a.BS != nil {
-		if level > 1 {
-			panic("The Map and List types do not support Binary Set (BS) values. Please use the List type instead.")
-		} else {
-			// Handle Binary Set
-			binarySet := [][]byte{}
-			binaryMap := make(map[string]struct{})
-			for _, v := range a.BS {
-				key := string(v)
-				if _, exists := binaryMap[key]; !exists {
-					binaryMap[key] = struct{}{}
-					binarySet = append(binarySet, v)
-				}
+		// Handle Binary Set
+		binarySet := [][]byte{}
+		binaryMap := make(map[string]struct{})
+		for _, v := range a.BS {
+			key := string(v)
+			if _, exists := binaryMap[key]; !exists {
+				binaryMap[key] = struct{}{}
+				binarySet = append(binarySet, v)
 			}
-			return binarySet
 		}
-
+		return binarySet
 	}
 	panic(fmt.Sprintf("%#v is not a supported dynamodb.AttributeValue", a))
 }
@@ -903,6 +852,27 @@ func convertMapToDynamoObject(output map[string]interface{}, v reflect.Value) er
 		output["NULL"] = true // Handle NULL directly here
 		return nil
 	}
+	// Exact numbers are strings, slices or structs to reflection
+	var value interface{}
+	if v.CanInterface() {
+		value = v.Interface()
+	}
+	switch n := value.(type) {
+	case models.Number, json.Number, big.Rat:
+		number, ok := models.ToNumber(n)
+		if !ok {
+			return fmt.Errorf("invalid number: %v", n)
+		}
+		output["N"] = number.String()
+		return nil
+	case []models.Number:
+		listVal := make([]string, len(n))
+		for i := range n {
+			listVal[i] = n[i].String()
+		}
+		output["NS"] = listVal
+		return nil
+	}
 	switch v.Kind() {
 	case reflect.Map:
 		return convertMap(output, v)
@@ -939,7 +909,7 @@ func convertMap(output map[string]interface{}, v reflect.Value) error {
 		elem := make(map[string]interface{})
 
 		_ = convertMapToDynamoObject(elem, elemVal)
-		output[keyName] = elem
+		output[keyName] = wrapMap(elem, elemVal)
 
 	}
 	return nil
@@ -991,12 +961,17 @@ func convertSlice(output map[string]interface{}, v reflect.Value) error {
 	default:
 		listVal := make([]map[string]interface{}, 0, v.Len())
 
+		// slices of maps hold items, like the Items of a query, rather than Map attributes
+		isItems := v.Type().Elem().Kind() == reflect.Map
 		for i := 0; i < v.Len(); i++ {
 			elem := make(map[string]interface{})
 			err := convertMapToDynamoObject(elem, v.Index(i))
 			if err != nil {
 				return err
 			}
+			if !isItems {
+				elem = wrapMap(elem, v.Index(i))
+			}
 			listVal = append(listVal, elem)
 		}
 		output["L"] = listVal
@@ -1005,6 +980,14 @@ func convertSlice(output map[string]interface{}, v reflect.Value) error {
 	return nil
 }
 
+// wrapMap wraps the converted value of a Map attribute nested in an item
+func wrapMap(elem map[string]interface{}, v reflect.Value) map[string]interface{} {
+	if valueElem(v).Kind() == reflect.Map {
+		return map[string]interface{}{"M": elem}
+	}
+	return elem
+}
+
 func convertSingle(output map[string]interface{}, v reflect.Value) error {
 	switch v.Kind() {
 	case reflect.Bool:
@@ -1043,12 +1026,12 @@ func convertNumber(output map[string]interface{}, v reflect.Value) error {
 // TransactWriteUpdateExpression is used to update an item in the database
 // with a transaction. If the condition expression fails, the transaction is
 // rolled back.
-func TransactWriteUpdateExpression(ctx context.Context, updateAtrr models.UpdateAttr, txn *spanner.ReadWriteTransaction, svc services.Service) (map[string]interface{}, *spanner.Mutation, error) {
+func TransactWriteUpdateExpression(ctx context.Context, updateAtrr models.UpdateAttr, txn storage.Transaction, svc services.Service) (map[string]interface{}, *storage.Mutation, error) {
 	// replace the placeholder column names with the original column names
 	updateAtrr.ExpressionAttributeNames = ChangeColumnToSpannerExpressionName(updateAtrr.TableName, updateAtrr.ExpressionAttributeNames)
 	// get the old item if it exists
 	var oldRes map[string]interface{}
-	var mut *spanner.Mutation
+	var mut *storage.Mutation
 	if updateAtrr.ReturnValues != "NONE" {
 		oldRes, _, _ = svc.GetWithProjection(ctx, updateAtrr.TableName, updateAtrr.PrimaryKeyMap, "", nil)
 	}
@@ -1126,7 +1109,7 @@ func TransactWriteUpdateExpression(ctx context.Context, updateAtrr models.Update
 //
 //	A map of attribute names to their new values (map[string]interface{}), the action value (map[string]interface{}),
 //	a Spanner mutation object, and an error.
-func TransactWritePerformOperation(ctx context.Context, action string, actionValue string, updateAtrr models.UpdateAttr, oldRes map[string]interface{}, txn *spanner.ReadWriteTransaction, svc services.Service) (map[string]interface{}, map[string]interface{}, *spanner.Mutation, error) {
+func TransactWritePerformOperation(ctx context.Context, action string, actionValue string, updateAtrr models.UpdateAttr, oldRes map[string]interface{}, txn storage.Transaction, svc services.Service) (map[string]interface{}, map[string]interface{}, *storage.Mutation, error) {
 	switch {
 	case action == "DELETE":
 		// perform delete
@@ -347,8 +347,8 @@ func TestConvertDynamoToMap(t *testing.T) {
 				}},
 			},
 			map[string]interface{}{
-				"emp_id":     float64(2),
-				"age":        float64(20),
+				"emp_id":     models.Number("2"),
+				"age":        models.Number("20"),
 				"address":    "Ney York",
 				"first_name": "Catalina",
 				"last_name":  "Smith",
@@ -534,7 +534,7 @@ func TestParseActionValue(t *testing.T) {
 			updateAttr: models.UpdateAttr{
 				UpdateExpression: "ADD tags :newTags",
 				ExpressionAttributeMap: map[string]interface{}{
-					":newTags": []float64{10},
+					":newTags": []models.Number{"10"},
 				},
 				ExpressionAttributeNames: map[string]string{},
 				PrimaryKeyMap: map[string]interface{}{
@@ -542,11 +542,11 @@ func TestParseActionValue(t *testing.T) {
 				},
 			},
 			oldRes: map[string]interface{}{
-				"tags": []float64{20},
+				"tags": []models.Number{"20"},
 			},
 			expectedResult: map[string]interface{}{
 				"id":   "1",
-				"tags": []float64{20, 10},
+				"tags": []models.Number{"20", "10"},
 			},
 			actionValue: "tags :newTags",
 		},
@@ -555,7 +555,7 @@ func TestParseActionValue(t *testing.T) {
 			updateAttr: models.UpdateAttr{
 				UpdateExpression: "DELETE tags :removeTags",
 				ExpressionAttributeMap: map[string]interface{}{
-					":removeTags": []float64{10},
+					":removeTags": []models.Number{"10"},
 				},
 				ExpressionAttributeNames: map[string]string{},
 				PrimaryKeyMap: map[string]interface{}{
@@ -563,11 +563,11 @@ func TestParseActionValue(t *testing.T) {
 				},
 			},
 			oldRes: map[string]interface{}{
-				"tags": []float64{20, 10},
+				"tags": []models.Number{"20", "10"},
 			},
 			expectedResult: map[string]interface{}{
 				"id":   "1",
-				"tags": []float64{20},
+				"tags": []models.Number{"20"},
 			},
 			actionValue: "tags :removeTags",
 		},
@@ -648,9 +648,10 @@ func TestParseActionValue(t *testing.T) {
 			oldRes: map[string]interface{}{
 				"list_type": []interface{}{"John", "Doe"},
 			},
+			// the element is set in the list read in the transaction of the write
 			expectedResult: map[string]interface{}{
-				"id":        "1",
-				"list_type": []interface{}{"John", "Jacob"},
+				"id":           "1",
+				"list_type[1]": "Jacob",
 			},
 			actionValue: "list_type[1] = :newValue",
 		},
@@ -669,8 +670,8 @@ func TestParseActionValue(t *testing.T) {
 				"list_type": []interface{}{"John", "Doe"},
 			},
 			expectedResult: map[string]interface{}{
-				"id":        "1",
-				"list_type": []interface{}{"John", "Doe", "newData"},
+				"id":           "1",
+				"list_type[2]": "newData",
 			},
 			actionValue: "list_type[2] =  :newValue",
 		},
@@ -702,32 +703,32 @@ func (m *MockConfig) GetTableConf(tableName string) (*models.TableConfig, error)
 	return &models.TableConfig{ActualTable: tableName}, nil
 }
 
-func (m *MockService) TransactWritePut(ctx context.Context, tableName string, putObj map[string]interface{}, expr *models.UpdateExpressionCondition, conditionExp string, expressionAttr, oldRes map[string]interface{}, txn *spanner.ReadWriteTransaction) (map[string]interface{}, *spanner.Mutation, error) {
+func (m *MockService) TransactWritePut(ctx context.Context, tableName string, putObj map[string]interface{}, expr *models.UpdateExpressionCondition, conditionExp string, expressionAttr, oldRes map[string]interface{}, txn storage.Transaction) (map[string]interface{}, *storage.Mutation, error) {
 	args := m.Called(ctx, tableName, putObj, expr, conditionExp, expressionAttr, oldRes, txn)
-	return args.Get(0).(map[string]interface{}), args.Get(1).(*spanner.Mutation), args.Error(2)
+	return args.Get(0).(map[string]interface{}), args.Get(1).(*storage.Mutation), args.Error(2)
 }
 
-func (m *MockService) TransactWriteAdd(ctx context.Context, tableName string, primaryKeyMap map[string]interface{}, conditionExpression string, mAttributes map[string]interface{}, expressionAttributeMap map[string]interface{}, expr *models.UpdateExpressionCondition, oldRes map[string]interface{}, txn *spanner.ReadWriteTransaction) (map[string]interface{}, *spanner.Mutation, error) {
+func (m *MockService) TransactWriteAdd(ctx context.Context, tableName string, primaryKeyMap map[string]interface{}, conditionExpression string, mAttributes map[string]interface{}, expressionAttributeMap map[string]interface{}, expr *models.UpdateExpressionCondition, oldRes map[string]interface{}, txn storage.Transaction) (map[string]interface{}, *storage.Mutation, error) {
 	args := m.Called(ctx, tableName, primaryKeyMap, conditionExpression, mAttributes, expressionAttributeMap, expr, oldRes, txn)
-	return args.Get(0).(map[string]interface{}), args.Get(1).(*spanner.Mutation), args.Error(2)
+	return args.Get(0).(map[string]interface{}), args.Get(1).(*storage.Mutation), args.Error(2)
 }
 
-func (m *MockService) TransactWriteRemove(ctx context.Context, tableName string, updateAttr models.UpdateAttr, actionValue string, expr *models.UpdateExpressionCondition, oldRes map[string]interface{}, txn *spanner.ReadWriteTransaction) (map[string]interface{}, *spanner.Mutation, error) {
+func (m *MockService) TransactWriteRemove(ctx context.Context, tableName string, updateAttr models.UpdateAttr, actionValue string, expr *models.UpdateExpressionCondition, oldRes map[string]interface{}, txn storage.Transaction) (map[string]interface{}, *storage.Mutation, error) {
 	args := m.Called(ctx, tableName, updateAttr, actionValue, expr, oldRes, txn)
-	return args.Get(0).(map[string]interface{}), args.Get(1).(*spanner.Mutation), args.Error(2)
+	return args.Get(0).(map[string]interface{}), args.Get(1).(*storage.Mutation), args.Error(2)
 }
 
-func (m *MockService) TransactWriteDel(ctx context.Context, tableName string, primaryKeyMap map[string]interface{}, conditionExpression string, mAttributes map[string]interface{}, expr *models.UpdateExpressionCondition, txn *spanner.ReadWriteTransaction) (map[string]interface{}, *spanner.Mutation, error) {
+func (m *MockService) TransactWriteDel(ctx context.Context, tableName string, primaryKeyMap map[string]interface{}, conditionExpression string, mAttributes map[string]interface{}, expr *models.UpdateExpressionCondition, txn storage.Transaction) (map[string]interface{}, *storage.Mutation, error) {
 	args := m.Called(ctx, tableName, primaryKeyMap, conditionExpression, mAttributes, expr, txn)
-	return args.Get(0).(map[string]interface{}), args.Get(1).(*spanner.Mutation), args.Error(2)
+	return args.Get(0).(map[string]interface{}), args.Get(1).(*storage.Mutation), args.Error(2)
 }
-func (m *MockStorage) SpannerTransactWritePut(ctx context.Context, tableName string, putObj map[string]interface{}, e *models.Eval, expr *models.UpdateExpressionCondition, txn *spanner.ReadWriteTransaction) (map[string]interface{}, *spanner.Mutation, error) {
-	return map[string]interface{}{"Name": "John"}, &spanner.Mutation{}, nil
+func (m *MockStorage) SpannerTransactWritePut(ctx context.Context, tableName string, putObj map[string]interface{}, e *models.Eval, expr *models.UpdateExpressionCondition, txn storage.Transaction) (map[string]interface{}, *storage.Mutation, error) {
+	return map[string]interface{}{"Name": "John"}, &storage.Mutation{}, nil
 }
 
 func TestTransactWriteUpdateExpression(t *testing.T) {
 	ctx := context.Background()
-	mockTxn := &spanner.ReadWriteTransaction{} // Mock transaction
+	mockTxn := storage.SpannerTransaction{ReadWriteTransaction: &spanner.ReadWriteTransaction{}} // Mock transaction
 	updateAttr := models.UpdateAttr{
 		TableName:                "TestTable",
 		PrimaryKeyMap:            map[string]interface{}{"id": 1},
@@ -749,7 +750,7 @@ func TestTransactWriteUpdateExpression(t *testing.T) {
 			"Name": map[string]interface{}{
 				"S": "John",
 			},
-		}, &spanner.Mutation{}, nil)
+		}, &storage.Mutation{}, nil)
 
 	result, mut, err := TransactWriteUpdateExpression(ctx, updateAttr, mockTxn, mockSvc)
 	if err != nil {
@@ -763,8 +764,10 @@ func TestTransactWriteUpdateExpression(t *testing.T) {
 	expected := map[string]interface{}{
 		"Attributes": map[string]interface{}{
 			"Name": map[string]interface{}{
-				"S": map[string]interface{}{
-					"S": "John",
+				"M": map[string]interface{}{
+					"S": map[string]interface{}{
+						"S": "John",
+					},
 				},
 			},
 		},
@@ -778,7 +781,7 @@ func TestTransactWriteUpdateExpression(t *testing.T) {
 
 func TestTransactWriteUpdateAddExpression(t *testing.T) {
 	ctx := context.Background()
-	mockTxn := &spanner.ReadWriteTransaction{} // Mock transaction
+	mockTxn := storage.SpannerTransaction{ReadWriteTransaction: &spanner.ReadWriteTransaction{}} // Mock transaction
 	updateAttr := models.UpdateAttr{
 		TableName:                "TestTable",
 		PrimaryKeyMap:            map[string]interface{}{"id": 1},
@@ -796,7 +799,7 @@ func TestTransactWriteUpdateAddExpression(t *testing.T) {
 		Return(map[string]interface{}{"Name": "Doe", "Age": 20}, map[string]interface{}{}, nil)
 
 	mockSvc.On("TransactWriteAdd", ctx, updateAttr.TableName, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mockTxn).
-		Return(map[string]interface{}{"Age": 21}, &spanner.Mutation{}, nil)
+		Return(map[string]interface{}{"Age": 21}, &storage.Mutation{}, nil)
 
 	result, mut, err := TransactWriteUpdateExpression(ctx, updateAttr, mockTxn, mockSvc)
 	if err != nil {
@@ -823,7 +826,7 @@ func TestTransactWriteUpdateAddExpression(t *testing.T) {
 
 func TestTransactWriteUpdateRemoveExpression(t *testing.T) {
 	ctx := context.Background()
-	mockTxn := &spanner.ReadWriteTransaction{} // Mock transaction
+	mockTxn := storage.SpannerTransaction{ReadWriteTransaction: &spanner.ReadWriteTransaction{}} // Mock transaction
 	updateAttr := models.UpdateAttr{
 		TableName:                "TestTable",
 		PrimaryKeyMap:            map[string]interface{}{"id": 1},
@@ -841,7 +844,7 @@ func TestTransactWriteUpdateRemoveExpression(t *testing.T) {
 		Return(map[string]interface{}{"Name": "Doe", "Age": 20}, map[string]interface{}{}, nil)
 
 	mockSvc.On("TransactWriteRemove", ctx, updateAttr.TableName, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mockTxn).
-		Return(map[string]interface{}{"Name": nil}, &spanner.Mutation{}, nil)
+		Return(map[string]interface{}{"Name": nil}, &storage.Mutation{}, nil)
 
 	result, mut, err := TransactWriteUpdateExpression(ctx, updateAttr, mockTxn, mockSvc)
 	if err != nil {
@@ -868,7 +871,7 @@ func TestTransactWriteUpdateRemoveExpression(t *testing.T) {
 
 func TestTransactWriteUpdateDeleteExpression(t *testing.T) {
 	ctx := context.Background()
-	mockTxn := &spanner.ReadWriteTransaction{} // Mock transaction
+	mockTxn := storage.SpannerTransaction{ReadWriteTransaction: &spanner.ReadWriteTransaction{}} // Mock transaction
 	updateAttr := models.UpdateAttr{
 		TableName:                "TestTable",
 		PrimaryKeyMap:            map[string]interface{}{"id": 1},
@@ -886,7 +889,7 @@ func TestTransactWriteUpdateDeleteExpression(t *testing.T) {
 		Return(map[string]interface{}{"Name": "Doe", "Age": 20}, map[string]interface{}{}, nil)
 
 	mockSvc.On("TransactWriteDel", ctx, updateAttr.TableName, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mockTxn).
-		Return(map[string]interface{}{"Deleted": true}, &spanner.Mutation{}, nil)
+		Return(map[string]interface{}{"Deleted": true}, &storage.Mutation{}, nil)
 
 	result, mut, err := TransactWriteUpdateExpression(ctx, updateAttr, mockTxn, mockSvc)
 	if err != nil {
@@ -0,0 +1,64 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"os"
+	"testing"
+
+	"github.com/cloudspannerecosystem/dynamodb-adapter/apitesting"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/models"
+	otelgo "github.com/cloudspannerecosystem/dynamodb-adapter/otel"
+	"github.com/gin-gonic/gin"
+	"github.com/tj/assert"
+)
+
+// TestConformanceCorpus replays the conformance corpus against the adapter on
+// the memory backend. The reference is DynamoDB Local when
+// DYNAMODB_LOCAL_ENDPOINT is set, e.g. http://localhost:8000, and otherwise the
+// reference responses of the corpus.
+func TestConformanceCorpus(t *testing.T) {
+	setupBatchTables(t, "orders")
+	models.TableDDL["orders"] = map[string]string{"customer": "S", "id": "N", "total": "N", "tags": "SS"}
+	models.TableColumnMap["orders"] = []string{"customer", "id", "total", "tags"}
+	config, proxy := models.GlobalConfig, models.GlobalProxy
+	models.GlobalConfig = &models.Config{}
+	models.GlobalConfig.Spanner.QueryLimit = 5000
+	models.GlobalProxy = &models.Proxy{OtelInst: &otelgo.OpenTelemetry{Config: &otelgo.OTelConfig{}}}
+	t.Cleanup(func() {
+		models.GlobalConfig, models.GlobalProxy = config, proxy
+	})
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	InitDBAPI(r)
+
+	corpus, err := apitesting.LoadCorpus("../../apitesting/testdata/conformance.jsonl")
+	assert.NoError(t, err)
+	reference := apitesting.ConformanceTarget{Name: "dynamodb", Handler: apitesting.RecordedReference(corpus)}
+	if endpoint := os.Getenv("DYNAMODB_LOCAL_ENDPOINT"); endpoint != "" {
+		reference = apitesting.ConformanceTarget{
+			Name: "dynamodb-local",
+			URL:  endpoint,
+			Headers: map[string]string{
+				"Authorization": "AWS4-HMAC-SHA256 Credential=local/20200101/us-east-1/dynamodb/aws4_request, SignedHeaders=host, Signature=0",
+			},
+		}
+	}
+	c := apitesting.Conformance{
+		Adapter:   apitesting.ConformanceTarget{Name: "adapter", Handler: r, URL: "/v1"},
+		Reference: reference,
+	}
+	c.RunTests(t, corpus)
+}
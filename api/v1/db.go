@@ -19,12 +19,13 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
-	"regexp"
+	"sort"
 	"strings"
+	"sync/atomic"
 	"time"
 
-	"cloud.google.com/go/spanner"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 	otelgo "github.com/cloudspannerecosystem/dynamodb-adapter/otel"
 	"go.opentelemetry.io/otel/attribute"
@@ -36,6 +37,7 @@ import (
 	"github.com/cloudspannerecosystem/dynamodb-adapter/pkg/logger"
 	"github.com/cloudspannerecosystem/dynamodb-adapter/service/services"
 	"github.com/cloudspannerecosystem/dynamodb-adapter/storage"
+	translator "github.com/cloudspannerecosystem/dynamodb-adapter/translator/utils"
 	"github.com/cloudspannerecosystem/dynamodb-adapter/utils"
 	"github.com/gin-gonic/gin"
 )
@@ -54,7 +56,30 @@ func InitDBAPI(r *gin.Engine) {
 
 	// Create API handler with dependency injection
 	apiHandler := NewAPIHandler(svc)
-	r.POST("/v1", apiHandler.RouteRequest)
+	handlers := []gin.HandlerFunc{TenantHandler, apiHandler.RouteRequest}
+	if models.GlobalConfig != nil && models.GlobalConfig.Explain.Enabled {
+		token, err := models.GlobalConfig.Explain.AdminToken.Read()
+		if err == nil && token == "" {
+			err = fmt.Errorf("explain.admin_token is not set")
+		}
+		if err != nil {
+			logger.LogError("explain disabled: ", err)
+		} else {
+			handlers = []gin.HandlerFunc{TenantHandler, ExplainHandler, apiHandler.RouteRequest}
+			admin := r.Group("/admin", AdminHandler(token))
+			admin.POST("/explain", TenantHandler, apiHandler.Explain)
+			admin.GET("/explain/:id", ExplainedRequest)
+		}
+	}
+	if models.GlobalConfig != nil && models.GlobalConfig.Capture.Enabled {
+		w, err := newCaptureWriter(models.GlobalConfig.Capture)
+		if err != nil {
+			logger.LogError("request capture disabled: ", err)
+		} else {
+			handlers = append([]gin.HandlerFunc{CaptureHandler(w, models.GlobalConfig.Capture.RedactValues())}, handlers...)
+		}
+	}
+	r.POST("/v1", handlers...)
 }
 
 // RouteRequest - parse X-Amz-Target and call appropiate handler
@@ -83,6 +108,16 @@ func (h *APIHandler) RouteRequest(c *gin.Context) {
 		h.TransactWriteItems(c)
 	case "ExecuteStatement":
 		h.ExecuteStatement(c)
+	case "BatchExecuteStatement":
+		h.BatchExecuteStatement(c)
+	case "ExecuteTransaction":
+		h.ExecuteTransaction(c)
+	case "DescribeTable":
+		h.DescribeTable(c)
+	case "UpdateTable":
+		h.UpdateTable(c)
+	case "ListTables":
+		h.ListTables(c)
 	default:
 		c.JSON(errors.New("ValidationException", "Invalid X-Amz-Target header value of "+amzTarget).
 			HTTPResponse("X-Amz-Target Header not supported"))
@@ -99,6 +134,7 @@ func addParentSpanID(c *gin.Context, span trace.Span) trace.Span {
 			attribute.String("parentSpanId", parentSpanID),
 			attribute.String("traceId", traceID),
 			attribute.String("service-name", serviceName),
+			attribute.String("tenant", config.TenantFromContext(c.Request.Context()).Name),
 		)
 	}
 	return span
@@ -138,6 +174,9 @@ func (h *APIHandler) UpdateMeta(c *gin.Context) {
 	if err = c.ShouldBindJSON(&meta); err != nil {
 		otelgo.AddAnnotation(ctx, "PutItem Validation failed")
 		c.JSON(errors.New("ValidationException", err).HTTPResponse(meta))
+	} else if err = validatePutItem(c.Request.Context(), meta); err != nil {
+		otelgo.AddAnnotation(ctx, "PutItem Validation failed")
+		c.JSON(errors.HTTPResponse(err, meta))
 	} else {
 		otelgo.AddAnnotation(ctx, "PutItem validation passed, processing request")
 		if allow := h.svc.MayIReadOrWrite(meta.TableName, true, "UpdateMeta"); !allow {
@@ -166,12 +205,11 @@ func (h *APIHandler) UpdateMeta(c *gin.Context) {
 		if err != nil {
 			c.JSON(errors.HTTPResponse(err, meta))
 		} else {
-			var output map[string]interface{}
-			if meta.ReturnValues == "NONE" {
-				output = nil
-			} else {
-				output, _ = ChangeMaptoDynamoMap(ChangeResponseToOriginalColumns(meta.TableName, res))
-				output = map[string]interface{}{"Attributes": output}
+			output := map[string]interface{}{}
+			// ReturnValues defaults to NONE, which returns no attributes.
+			if meta.ReturnValues != "" && meta.ReturnValues != "NONE" {
+				attributes, _ := ChangeMaptoDynamoMap(ChangeResponseToOriginalColumns(meta.TableName, res))
+				output["Attributes"] = attributes
 			}
 
 			otelgo.AddAnnotation(ctx, "Successfully processed the PutItem request.")
@@ -181,7 +219,7 @@ func (h *APIHandler) UpdateMeta(c *gin.Context) {
 }
 
 func put(ctx context.Context, tableName string, putObj map[string]interface{}, expr *models.UpdateExpressionCondition, conditionExp string, expressionAttr map[string]interface{}) (map[string]interface{}, error) {
-	tableConf, err := config.GetTableConf(tableName)
+	tableConf, err := config.GetTenantTableConf(ctx, tableName)
 	if err != nil {
 		return nil, err
 	}
@@ -189,7 +227,7 @@ func put(ctx context.Context, tableName string, putObj map[string]interface{}, e
 	pKey := tableConf.PartitionKey
 	var oldResp map[string]interface{}
 
-	oldResp, spannerRow, err := storage.GetStorageInstance().SpannerGet(ctx, tableName, putObj[pKey], putObj[sKey], nil)
+	oldResp, spannerRow, err := services.GetStorage().SpannerGet(ctx, tableConf.ActualTable, putObj[pKey], putObj[sKey], nil)
 	if err != nil {
 		return nil, err
 	}
@@ -250,9 +288,10 @@ func queryResponse(query models.Query, c *gin.Context, svc services.Service) {
 				c.JSON(errors.HTTPResponse(err, "ItemsChangeError"))
 			}
 		}
-		if _, ok := changedOutput["Items"].(map[string]interface{})["L"]; ok {
-			finalResult["Count"] = changedOutput["Count"]
-			finalResult["Items"] = changedOutput["Items"].(map[string]interface{})["L"]
+		finalResult["Count"] = changedOutput["Count"]
+		finalResult["ScannedCount"] = changedOutput["ScannedCount"]
+		if items, ok := changedOutput["Items"].(map[string]interface{}); ok {
+			finalResult["Items"] = items["L"]
 		}
 
 		if _, ok := changedOutput["LastEvaluatedKey"]; ok && changedOutput["LastEvaluatedKey"] != nil {
@@ -301,10 +340,18 @@ func (h *APIHandler) QueryTable(c *gin.Context) {
 	defer models.GlobalProxy.OtelInst.EndSpan(span)
 	addParentSpanID(c, span)
 	defer recordMetrics(ctx, models.GlobalProxy.OtelInst, "Query", startTime, err)
-	var query models.Query
+	// DynamoDB reads a Query forwards unless ScanIndexForward is false.
+	query := models.Query{SortAscending: true}
 	if err := c.ShouldBindJSON(&query); err != nil {
 		otelgo.AddAnnotation(ctx, "Query API Validation failed")
 		c.JSON(errors.New("ValidationException", err).HTTPResponse(query))
+	} else if err := validateExpressions(map[string]string{
+		"KeyConditionExpression": query.RangeExp,
+		"FilterExpression":       query.FilterExp,
+		"ProjectionExpression":   query.ProjectionExpression,
+	}); err != nil {
+		otelgo.AddAnnotation(ctx, "Query API Validation failed")
+		c.JSON(errors.HTTPResponse(err, query))
 	} else {
 		otelgo.AddAnnotation(ctx, "Query API validation passed, processing query")
 		logger.LogInfo(query)
@@ -347,6 +394,8 @@ func (h *APIHandler) GetItemMeta(c *gin.Context) {
 	var getItemMeta models.GetItemMeta
 	if err := c.ShouldBindJSON(&getItemMeta); err != nil {
 		c.JSON(errors.New("ValidationException", err).HTTPResponse(getItemMeta))
+	} else if err := validateGetItem(c.Request.Context(), getItemMeta); err != nil {
+		c.JSON(errors.HTTPResponse(err, getItemMeta))
 	} else {
 		// Add annotation for binding the JSON request
 		otelgo.AddAnnotation(ctx, "Binding GetItemMeta JSON Request")
@@ -376,7 +425,11 @@ func (h *APIHandler) GetItemMeta(c *gin.Context) {
 		getItemMeta.ExpressionAttributeNames = ChangeColumnToSpannerExpressionName(getItemMeta.TableName, getItemMeta.ExpressionAttributeNames)
 		// Add annotation before calling the Get service
 		otelgo.AddAnnotation(ctx, "Calling GetWithProjection Service")
-		res, _, rowErr := h.svc.GetWithProjection(c.Request.Context(), getItemMeta.TableName, getItemMeta.PrimaryKeyMap, getItemMeta.ProjectionExpression, getItemMeta.ExpressionAttributeNames)
+		readCtx := c.Request.Context()
+		if !getItemMeta.ConsistentRead {
+			readCtx = services.WithCachedReads(readCtx)
+		}
+		res, _, rowErr := h.svc.GetWithProjection(readCtx, getItemMeta.TableName, getItemMeta.PrimaryKeyMap, getItemMeta.ProjectionExpression, getItemMeta.ExpressionAttributeNames)
 		if rowErr == nil {
 			// Add annotation for processing the response
 			otelgo.AddAnnotation(ctx, "Changing Response Columns to Original Format")
@@ -431,49 +484,80 @@ func (h *APIHandler) BatchGetItem(c *gin.Context) {
 	if err1 := c.ShouldBindJSON(&batchGetMeta); err1 != nil {
 		otelgo.AddAnnotation(ctx, "Validation failed for BatchGetItem request")
 		c.JSON(errors.New("ValidationException", err1).HTTPResponse(batchGetMeta))
+	} else if err1 := validateBatchGet(c.Request.Context(), batchGetMeta); err1 != nil {
+		otelgo.AddAnnotation(ctx, "Validation failed for BatchGetItem request")
+		c.JSON(errors.HTTPResponse(err1, batchGetMeta))
 	} else {
 		otelgo.AddAnnotation(ctx, "BatchGetItem validation passed, processing batch get request")
-		output := make(map[string]interface{})
-
+		tables := make([]string, 0, len(batchGetMeta.RequestItems))
+		consistentRead := false
 		for k, v := range batchGetMeta.RequestItems {
-			batchGetWithProjectionMeta := v
-			batchGetWithProjectionMeta.TableName = k
-			logger.LogDebug(batchGetWithProjectionMeta)
-			if allow := h.svc.MayIReadOrWrite(batchGetWithProjectionMeta.TableName, false, ""); !allow {
+			if allow := h.svc.MayIReadOrWrite(k, false, ""); !allow {
 				c.JSON(http.StatusOK, []gin.H{})
 				return
 			}
-			var singleOutput interface{}
-			singleOutput, span, err = batchGetDataSingleTable(c.Request.Context(), batchGetWithProjectionMeta, span)
+			tables = append(tables, k)
+			consistentRead = consistentRead || v.ConsistentRead
+		}
+		sort.Strings(tables)
+
+		// consistent reads of several tables are made in one snapshot
+		readCtx := c.Request.Context()
+		if consistentRead && len(tables) > 1 {
+			var release func()
+			readCtx, release, err = services.ReadSnapshot(readCtx, tables)
 			if err != nil {
+				otelgo.AddAnnotation(ctx, "BatchGetItem snapshot failed")
+				c.JSON(errors.HTTPResponse(err, batchGetMeta))
+				return
+			}
+			defer release()
+		}
+
+		output := make(map[string]interface{})
+		unprocessedKeys := make(map[string]interface{})
+		for i, result := range batchGetTables(readCtx, batchGetMeta, tables, span) {
+			k := tables[i]
+			if storage.IsTimeout(result.err) {
+				otelgo.AddAnnotation(ctx, "BatchGetItem data retrieval timed out")
+				unprocessedKeys[k] = unprocessedBatchGetKeys(batchGetMeta.RequestItems[k], nil)
+				continue
+			}
+			if result.err != nil {
 				otelgo.AddAnnotation(ctx, "BatchGetItem data retrieval failed")
-				c.JSON(errors.HTTPResponse(err, batchGetWithProjectionMeta))
+				c.JSON(errors.HTTPResponse(result.err, batchGetMeta.RequestItems[k]))
+				return
 			}
-			currOutput, err := ChangeMaptoDynamoMap(singleOutput)
-			if err != nil {
-				otelgo.AddAnnotation(ctx, "BatchGetItem data transformation failed")
-				c.JSON(errors.HTTPResponse(err, batchGetWithProjectionMeta))
+			if len(result.unprocessed) > 0 {
+				unprocessedKeys[k] = unprocessedBatchGetKeys(batchGetMeta.RequestItems[k], result.unprocessed)
 			}
-			output[k] = currOutput["L"]
+			output[k] = result.items
 		}
 
 		otelgo.AddAnnotation(ctx, "Successfully processed BatchGetItem request")
-		c.JSON(http.StatusOK, map[string]interface{}{"Responses": output})
+		c.JSON(http.StatusOK, map[string]interface{}{"Responses": output, "UnprocessedKeys": unprocessedKeys})
 
 		if time.Since(startTime) > time.Second*1 {
 			go fmt.Println("BatchGetCall", batchGetMeta)
 		}
 	}
 }
-func batchGetDataSingleTable(ctx context.Context, batchGetWithProjectionMeta models.BatchGetWithProjectionMeta, span trace.Span) (interface{}, trace.Span, error) {
+
+// batchGetDataSingleTable reads the items of the keys of one table of a
+// BatchGetItem request while their size fits in budget. It returns the items
+// and the indexes of the keys left unprocessed.
+func batchGetDataSingleTable(ctx context.Context, batchGetWithProjectionMeta models.BatchGetWithProjectionMeta, budget *atomic.Int64, span trace.Span) ([]map[string]interface{}, []int, trace.Span, error) {
 
 	var err1 error
 	batchGetWithProjectionMeta.KeyArray, err1 = ConvertDynamoArrayToMapArray(batchGetWithProjectionMeta.TableName, batchGetWithProjectionMeta.Keys)
 	if err1 != nil {
-		return nil, nil, errors.New("ValidationException", err1.Error())
+		return nil, nil, nil, errors.New("ValidationException", err1.Error())
 	}
 	batchGetWithProjectionMeta.ExpressionAttributeNames = ChangeColumnToSpannerExpressionName(batchGetWithProjectionMeta.TableName, batchGetWithProjectionMeta.ExpressionAttributeNames)
-	res, err2 := services.BatchGetWithProjection(ctx, batchGetWithProjectionMeta.TableName, batchGetWithProjectionMeta.KeyArray, batchGetWithProjectionMeta.ProjectionExpression, batchGetWithProjectionMeta.ExpressionAttributeNames)
+	if !batchGetWithProjectionMeta.ConsistentRead {
+		ctx = services.WithCachedReads(ctx)
+	}
+	res, unprocessed, err2 := services.BatchGetWithProjection(ctx, batchGetWithProjectionMeta.TableName, batchGetWithProjectionMeta.KeyArray, batchGetWithProjectionMeta.ProjectionExpression, batchGetWithProjectionMeta.ExpressionAttributeNames, budget)
 
 	if span != nil {
 		span.SetAttributes(
@@ -484,9 +568,29 @@ func batchGetDataSingleTable(ctx context.Context, batchGetWithProjectionMeta mod
 	}
 
 	if err2 != nil {
-		return nil, span, err2
+		return nil, nil, span, err2
 	}
-	return ChangesArrayResponseToOriginalColumns(batchGetWithProjectionMeta.TableName, res), span, nil
+	return ChangesArrayResponseToOriginalColumns(batchGetWithProjectionMeta.TableName, res), unprocessed, span, nil
+}
+
+// unprocessedBatchGetKeys returns the UnprocessedKeys entry of a table with
+// the keys at indexes, or with all of its keys when indexes is nil
+func unprocessedBatchGetKeys(request models.BatchGetWithProjectionMeta, indexes []int) map[string]interface{} {
+	keys := request.Keys
+	if indexes != nil {
+		keys = make([]map[string]*dynamodb.AttributeValue, len(indexes))
+		for i, index := range indexes {
+			keys[i] = request.Keys[index]
+		}
+	}
+	entry := map[string]interface{}{"Keys": keys}
+	if request.ProjectionExpression != "" {
+		entry["ProjectionExpression"] = request.ProjectionExpression
+	}
+	if len(request.ExpressionAttributeNames) > 0 {
+		entry["ExpressionAttributeNames"] = request.ExpressionAttributeNames
+	}
+	return entry
 }
 
 // DeleteItem  ...
@@ -524,6 +628,9 @@ func (h *APIHandler) DeleteItem(c *gin.Context) {
 
 		otelgo.AddAnnotation(ctx, "Validation failed for DeleteItem request")
 		c.JSON(errors.New("ValidationException", err).HTTPResponse(deleteItem))
+	} else if err := validateDeleteItem(c.Request.Context(), deleteItem); err != nil {
+		otelgo.AddAnnotation(ctx, "Validation failed for DeleteItem request")
+		c.JSON(errors.HTTPResponse(err, deleteItem))
 	} else {
 
 		otelgo.AddAnnotation(ctx, "Validation succeeded for DeleteItem request")
@@ -604,6 +711,11 @@ func (h *APIHandler) Scan(c *gin.Context) {
 	var meta models.ScanMeta
 	if err := c.ShouldBindJSON(&meta); err != nil {
 		c.JSON(errors.New("ValidationException", err).HTTPResponse(meta))
+	} else if err := validateExpressions(map[string]string{
+		"FilterExpression":     meta.FilterExpression,
+		"ProjectionExpression": meta.ProjectionExpression,
+	}); err != nil {
+		c.JSON(errors.HTTPResponse(err, meta))
 	} else {
 		if allow := h.svc.MayIReadOrWrite(meta.TableName, false, ""); !allow {
 			c.JSON(http.StatusOK, gin.H{})
@@ -644,6 +756,8 @@ func (h *APIHandler) Scan(c *gin.Context) {
 				if err != nil {
 					c.JSON(errors.HTTPResponse(err, "LastEvaluatedKeyChangeError"))
 				}
+			} else {
+				delete(changedOutput, "LastEvaluatedKey")
 			}
 			jsonData, _ := json.Marshal(res)
 			c.JSON(http.StatusOK, json.RawMessage(jsonData))
@@ -691,6 +805,10 @@ func (h *APIHandler) Update(c *gin.Context) {
 		otelgo.AddAnnotation(ctx, "Failed to bind JSON")
 		c.JSON(errors.New("ValidationException", err).HTTPResponse(updateAttr))
 		return
+	} else if err := validateUpdateItem(c.Request.Context(), updateAttr); err != nil {
+		otelgo.AddAnnotation(ctx, "Validation failed for UpdateItem request")
+		c.JSON(errors.HTTPResponse(err, updateAttr))
+		return
 	} else {
 		if allow := h.svc.MayIReadOrWrite(updateAttr.TableName, true, "update"); !allow {
 			otelgo.AddAnnotation(ctx, "Permission check failed")
@@ -757,61 +875,32 @@ func (h *APIHandler) BatchWriteItem(c *gin.Context) {
 	var batchWriteItem models.BatchWriteItem
 	var unprocessedBatchWriteItems models.BatchWriteItemResponse
 
-	if err1 := c.ShouldBindJSON(&batchWriteItem); err1 != nil {
+	if err1 := checkRequestSize(c, maxBatchWriteRequestSize, batchWriteRequestTooLarge); err1 != nil {
+		otelgo.AddAnnotation(ctx, "Validation failed for BatchWriteItem request")
+		c.JSON(errors.HTTPResponse(err1, nil))
+	} else if err1 := c.ShouldBindJSON(&batchWriteItem); err1 != nil {
 		otelgo.AddAnnotation(ctx, "Validation failed for BatchWriteItem request")
 		c.JSON(errors.New("ValidationException", err1).HTTPResponse(batchWriteItem))
+	} else if err1 := validateBatchWrite(c.Request.Context(), batchWriteItem); err1 != nil {
+		otelgo.AddAnnotation(ctx, "Validation failed for BatchWriteItem request")
+		c.JSON(errors.HTTPResponse(err1, batchWriteItem))
 	} else {
 		otelgo.AddAnnotation(ctx, "BatchWriteItem validation passed, processing batch write request")
+		unprocessedBatchWriteItems.UnprocessedItems = make(map[string][]models.BatchWriteSubItems)
 		for key, value := range batchWriteItem.RequestItems {
 			if allow := h.svc.MayIReadOrWrite(key, true, "BatchWriteItem"); !allow {
 				c.JSON(http.StatusOK, gin.H{})
 				return
 			}
-			var putData models.BatchMetaUpdate
-			putData.TableName = key
-
-			var deleteData models.BulkDelete
-			deleteData.TableName = key
-
-			for _, v := range value {
-				if v.PutReq.Item != nil {
-					putData.DynamoObject = append(putData.DynamoObject, v.PutReq.Item)
-				}
-
-				if v.DelReq.Key != nil {
-					deleteData.DynamoObject = append(deleteData.DynamoObject, v.DelReq.Key)
-				}
-			}
-
-			if putData.DynamoObject != nil {
-				err = batchUpdateItems(c.Request.Context(), putData)
-				if err != nil {
-					for _, v := range value {
-						if v.PutReq.Item != nil {
-							if unprocessedBatchWriteItems.UnprocessedItems == nil {
-								unprocessedBatchWriteItems.UnprocessedItems = make(map[string][]models.BatchWriteSubItems) // Adjust type as needed
-							}
-
-							// Ensure that the specific key's slice is initialized
-							if _, exists := unprocessedBatchWriteItems.UnprocessedItems[key]; !exists {
-								unprocessedBatchWriteItems.UnprocessedItems[key] = []models.BatchWriteSubItems{} // Instantiate the slice
-							}
-
-							unprocessedBatchWriteItems.UnprocessedItems[key] = append(unprocessedBatchWriteItems.UnprocessedItems[key], v)
-						}
-					}
-				}
+			var unprocessed []models.BatchWriteSubItems
+			unprocessed, err = batchWriteItems(c.Request.Context(), key, value)
+			if err != nil {
+				otelgo.AddAnnotation(ctx, "BatchWriteItem failed")
+				c.JSON(errors.HTTPResponse(err, batchWriteItem))
+				return
 			}
-
-			if deleteData.DynamoObject != nil {
-				err = batchDeleteItems(c.Request.Context(), deleteData)
-				if err != nil {
-					for _, v := range value {
-						if v.DelReq.Key != nil {
-							unprocessedBatchWriteItems.UnprocessedItems[key] = append(unprocessedBatchWriteItems.UnprocessedItems[key], v)
-						}
-					}
-				}
+			if len(unprocessed) > 0 {
+				unprocessedBatchWriteItems.UnprocessedItems[key] = unprocessed
 			}
 		}
 
@@ -825,30 +914,44 @@ func (h *APIHandler) BatchWriteItem(c *gin.Context) {
 	}
 }
 
-func batchDeleteItems(con context.Context, bulkDelete models.BulkDelete) error {
-	var err error
-	bulkDelete.PrimaryKeyMapArray, err = ConvertDynamoArrayToMapArray(bulkDelete.TableName, bulkDelete.DynamoObject)
-	if err != nil {
-		return err
+// batchWriteItems puts and deletes the items of the requests for the table and
+// returns the requests that were not applied
+func batchWriteItems(ctx context.Context, tableName string, requests []models.BatchWriteSubItems) ([]models.BatchWriteSubItems, error) {
+	var putRequests, deleteRequests []models.BatchWriteSubItems
+	var putItems, deleteKeys []map[string]*dynamodb.AttributeValue
+	for _, v := range requests {
+		if v.PutReq.Item != nil {
+			putRequests = append(putRequests, v)
+			putItems = append(putItems, v.PutReq.Item)
+		}
+		if v.DelReq.Key != nil {
+			deleteRequests = append(deleteRequests, v)
+			deleteKeys = append(deleteKeys, v.DelReq.Key)
+		}
+	}
+	if len(putItems) == 0 && len(deleteKeys) == 0 {
+		return nil, nil
 	}
-	err = services.BatchDelete(con, bulkDelete.TableName, bulkDelete.PrimaryKeyMapArray)
+	puts, err := ConvertDynamoArrayToMapArray(tableName, putItems)
 	if err != nil {
-		return err
+		return nil, errors.New("ValidationException", err)
 	}
-	return nil
-}
-
-func batchUpdateItems(con context.Context, batchMetaUpdate models.BatchMetaUpdate) error {
-	var err error
-	batchMetaUpdate.ArrAttrMap, err = ConvertDynamoArrayToMapArray(batchMetaUpdate.TableName, batchMetaUpdate.DynamoObject)
+	deletes, err := ConvertDynamoArrayToMapArray(tableName, deleteKeys)
 	if err != nil {
-		return err
+		return nil, errors.New("ValidationException", err)
 	}
-	err = services.BatchPut(con, batchMetaUpdate.TableName, batchMetaUpdate.ArrAttrMap, nil)
+	failedPuts, failedDeletes, err := services.BatchWrite(ctx, tableName, puts, deletes)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	return nil
+	var unprocessed []models.BatchWriteSubItems
+	for _, i := range failedPuts {
+		unprocessed = append(unprocessed, models.BatchWriteSubItems{PutReq: putRequests[i].PutReq})
+	}
+	for _, i := range failedDeletes {
+		unprocessed = append(unprocessed, models.BatchWriteSubItems{DelReq: deleteRequests[i].DelReq})
+	}
+	return unprocessed, nil
 }
 
 // TransactGetItems to get with projections
@@ -871,6 +974,10 @@ func (h *APIHandler) TransactGetItems(c *gin.Context) {
 		c.JSON(errors.New("ValidationException", err).HTTPResponse(transactGetMeta))
 		return
 	}
+	if err := validateTransactGet(c.Request.Context(), transactGetMeta); err != nil {
+		c.JSON(errors.HTTPResponse(err, transactGetMeta))
+		return
+	}
 	// Iterate over each transact item
 	for _, transactItem := range transactGetMeta.TransactItems {
 		getRequest := transactItem.Get
@@ -963,12 +1070,15 @@ func recordMetrics(ctx context.Context, o *otelgo.OpenTelemetry, method string,
 	if err != nil {
 		status = "failure"
 	}
+	tenant := config.TenantFromContext(ctx).Name
 	o.RecordRequestCountMetric(ctx, otelgo.Attributes{
 		Method: method,
 		Status: status,
+		Tenant: tenant,
 	})
 	o.RecordLatencyMetric(ctx, start, otelgo.Attributes{
 		Method: method,
+		Tenant: tenant,
 	})
 }
 
@@ -1008,7 +1118,7 @@ func (h *APIHandler) ExecuteStatement(c *gin.Context) {
 		otelgo.AddAnnotation(ctx, "Validation failed for ExecuteStatement request")
 		c.JSON(errors.New("ValidationException", err).HTTPResponse(execStmt))
 	} else {
-		execStmt.TableName = extractTableName(execStmt.Statement)
+		execStmt.TableName = partiQLTableName(execStmt.Statement)
 		for _, val := range execStmt.Parameters {
 			execStmt.AttrParams = append(execStmt.AttrParams, convertFrom(val, execStmt.TableName, 1))
 		}
@@ -1038,23 +1148,94 @@ func (h *APIHandler) ExecuteStatement(c *gin.Context) {
 	}
 }
 
-// extractTableName extracts the table name from a PartiQL query.
-func extractTableName(query string) string {
-	patterns := []string{
-		`(?i)FROM\s+"?(\w+)"?`,          // Matches SELECT and DELETE queries
-		`(?i)INSERT\s+INTO\s+"?(\w+)"?`, // Matches INSERT queries
-		`(?i)UPDATE\s+"?(\w+)"?`,        // Matches UPDATE queries
+// partiQLTableName returns the table of a PartiQL statement. Statements that
+// name no table have none.
+func partiQLTableName(statement string) string {
+	table, err := translator.StatementTable(statement)
+	if err != nil {
+		return ""
 	}
+	return table
+}
+
+// partiQLStatement builds the ExecuteStatement of a statement of a batch or
+// a transaction
+func partiQLStatement(ctx context.Context, statement string, parameters []*dynamodb.AttributeValue) models.ExecuteStatement {
+	execStmt := models.ExecuteStatement{Statement: statement, Parameters: parameters}
+	execStmt.TableName = partiQLTableName(statement)
+	for _, val := range parameters {
+		execStmt.AttrParams = append(execStmt.AttrParams, convertFrom(val, execStmt.TableName, 1))
+	}
+	return execStmt
+}
 
-	for _, pattern := range patterns {
-		re := regexp.MustCompile(pattern)
-		matches := re.FindStringSubmatch(query)
-		if len(matches) > 1 {
-			return matches[1]
+// BatchExecuteStatement runs up to 25 PartiQL statements that either all read
+// or all write items. Every statement gets a response holding the item it read
+// or the error it failed with.
+func (h *APIHandler) BatchExecuteStatement(c *gin.Context) {
+	defer PanicHandler(c)
+	defer c.Request.Body.Close()
+
+	var request models.BatchExecuteStatementRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(errors.New("ValidationException", err).HTTPResponse(request))
+		return
+	}
+	if err := validateBatchExecuteStatement(request); err != nil {
+		c.JSON(errors.HTTPResponse(err, request))
+		return
+	}
+	statements := make([]models.ExecuteStatement, len(request.Statements))
+	for i, statement := range request.Statements {
+		statements[i] = partiQLStatement(c.Request.Context(), statement.Statement, statement.Parameters)
+	}
+	responses, err := services.BatchExecuteStatement(c.Request.Context(), statements)
+	if err != nil {
+		c.JSON(errors.HTTPResponse(err, request))
+		return
+	}
+	for i := range responses {
+		if responses[i].Item == nil {
+			continue
+		}
+		responses[i].Item, err = ChangeMaptoDynamoMap(ChangeResponseToOriginalColumns(responses[i].TableName, responses[i].Item))
+		if err != nil {
+			c.JSON(errors.HTTPResponse(err, "ItemsChangeError"))
+			return
 		}
 	}
+	c.JSON(http.StatusOK, gin.H{"Responses": responses})
+}
+
+// ExecuteTransaction runs up to 100 PartiQL INSERT, UPDATE and DELETE
+// statements atomically. A canceled transaction fails with the reason of every
+// statement.
+func (h *APIHandler) ExecuteTransaction(c *gin.Context) {
+	defer PanicHandler(c)
+	defer c.Request.Body.Close()
 
-	return ""
+	var request models.ExecuteTransactionRequest
+	if err := checkRequestSize(c, maxTransactRequestSize, transactRequestTooLarge); err != nil {
+		c.JSON(errors.HTTPResponse(err, nil))
+		return
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(errors.New("ValidationException", err).HTTPResponse(request))
+		return
+	}
+	if err := validateExecuteTransaction(request); err != nil {
+		c.JSON(errors.HTTPResponse(err, request))
+		return
+	}
+	statements := make([]models.ExecuteStatement, len(request.TransactStatements))
+	for i, statement := range request.TransactStatements {
+		statements[i] = partiQLStatement(c.Request.Context(), statement.Statement, statement.Parameters)
+	}
+	if err := services.ExecuteTransaction(c.Request.Context(), statements, request.ClientRequestToken); err != nil {
+		c.JSON(errors.HTTPResponse(err, request))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{})
 }
 
 // TransactWriteItems performs a transactional write operation on a table
@@ -1073,21 +1254,35 @@ func (h *APIHandler) TransactWriteItems(c *gin.Context) {
 	defer c.Request.Body.Close()
 
 	var transactWriteMeta models.TransactWriteItemsRequest
+	if err := checkRequestSize(c, maxTransactRequestSize, transactRequestTooLarge); err != nil {
+		c.JSON(errors.HTTPResponse(err, nil))
+		return
+	}
 	if err := c.ShouldBindJSON(&transactWriteMeta); err != nil {
 		c.JSON(errors.New("ValidationException", err).HTTPResponse(transactWriteMeta))
 		return
 	}
-	storageInstance := storage.GetStorageInstance()
-	spannerClient, _ := storageInstance.GetSpannerClient()
+	if err := validateTransactWrite(c.Request.Context(), transactWriteMeta); err != nil {
+		c.JSON(errors.HTTPResponse(err, transactWriteMeta))
+		return
+	}
+	var tables []string
+	for _, transactItem := range transactWriteMeta.TransactItems {
+		for _, tableName := range []string{transactItem.Put.TableName, transactItem.Update.TableName, transactItem.Delete.TableName, transactItem.ConditionCheck.TableName} {
+			if tableConf, err := config.GetTenantTableConf(c.Request.Context(), tableName); err == nil {
+				tables = append(tables, tableConf.ActualTable)
+			}
+		}
+	}
 	ctx := context.Background()
 	var resp models.TransactWriteItemsOutput
 	var resultItems []map[string]interface{}
 
-	_, _ = spannerClient.ReadWriteTransaction(ctx, func(ctx context.Context, txn *spanner.ReadWriteTransaction) error {
-		var mutations []*spanner.Mutation
+	err := services.GetStorage().ReadWriteTransaction(ctx, tables, func(ctx context.Context, txn storage.Transaction) error {
+		var mutations []*storage.Mutation
 
 		for _, transactItem := range transactWriteMeta.TransactItems {
-			var mut *spanner.Mutation
+			var mut *storage.Mutation
 			var result map[string]interface{}
 			var err error
 
@@ -1125,6 +1320,9 @@ func (h *APIHandler) TransactWriteItems(c *gin.Context) {
 		c.JSON(http.StatusOK, resultItems)
 		return nil
 	})
+	if err != nil && !c.Writer.Written() {
+		c.JSON(errors.HTTPResponse(err, transactWriteMeta))
+	}
 }
 
 // handleConditionCheck takes a ConditionCheckRequest and a ReadWriteTransaction and returns a Mutation and an error.
@@ -1133,7 +1331,7 @@ func (h *APIHandler) TransactWriteItems(c *gin.Context) {
 // After that, it evaluates the condition expression using the EvaluateConditionalExpression function.
 // If the evaluation is false, it returns an error of ConditionalCheckFailedException.
 // If the evaluation is true, it returns nil.
-func handleConditionCheck(c *gin.Context, details models.ConditionCheckRequest, txn *spanner.ReadWriteTransaction) (*spanner.Mutation, error) {
+func handleConditionCheck(c *gin.Context, details models.ConditionCheckRequest, txn storage.Transaction) (*storage.Mutation, error) {
 	var err error
 	var expr *models.UpdateExpressionCondition
 	ctx := context.Background()
@@ -1152,7 +1350,11 @@ func handleConditionCheck(c *gin.Context, details models.ConditionCheckRequest,
 		tmpMap[k] = v
 	}
 	if len(eval.Attributes) > 0 || expr != nil {
-		status, err := storage.EvaluateConditionalExpression(ctx, txn, details.TableName, tmpMap, eval, expr)
+		tableConf, err := config.GetTenantTableConf(c.Request.Context(), details.TableName)
+		if err != nil {
+			return nil, err
+		}
+		status, err := storage.EvaluateConditionalExpression(ctx, txn, tableConf.ActualTable, tmpMap, eval, expr)
 		if err != nil {
 			return nil, err
 		}
@@ -1165,7 +1367,7 @@ func handleConditionCheck(c *gin.Context, details models.ConditionCheckRequest,
 
 // handleWriteOperation processes different write operations (Put, Update, Delete) on a specified table in Spanner
 // using the provided transaction, context, and operation details. It returns a mutation, response map, and error.
-func handleWriteOperation(c *gin.Context, details interface{}, txn *spanner.ReadWriteTransaction, operationType string, svc services.Service) (*spanner.Mutation, map[string]interface{}, error) {
+func handleWriteOperation(c *gin.Context, details interface{}, txn storage.Transaction, operationType string, svc services.Service) (*storage.Mutation, map[string]interface{}, error) {
 	// Initialize variables for operation details and error handling
 	var tableName string
 	var err error
@@ -1219,7 +1421,7 @@ func handleWriteOperation(c *gin.Context, details interface{}, txn *spanner.Read
 		}
 	}
 
-	var mut *spanner.Mutation
+	var mut *storage.Mutation
 	var resp map[string]interface{}
 
 	switch operationType {
@@ -1258,9 +1460,9 @@ func handleWriteOperation(c *gin.Context, details interface{}, txn *spanner.Read
 }
 
 // TransactPut manages a transactional put operation in Spanner, ensuring old data is fetched and conditions are evaluated.
-func TransactPut(ctx context.Context, tableName string, putObj map[string]interface{}, expr *models.UpdateExpressionCondition, conditionExp string, expressionAttr map[string]interface{}, txn *spanner.ReadWriteTransaction, svc services.Service) (map[string]interface{}, *spanner.Mutation, error) {
+func TransactPut(ctx context.Context, tableName string, putObj map[string]interface{}, expr *models.UpdateExpressionCondition, conditionExp string, expressionAttr map[string]interface{}, txn storage.Transaction, svc services.Service) (map[string]interface{}, *storage.Mutation, error) {
 	// Fetch the table configuration to retrieve partition and sort keys
-	tableConf, err := config.GetTableConf(tableName)
+	tableConf, err := config.GetTenantTableConf(ctx, tableName)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -1271,7 +1473,7 @@ func TransactPut(ctx context.Context, tableName string, putObj map[string]interf
 	var oldResp map[string]interface{}
 
 	// Retrieve the existing item from Spanner using partition and sort keys
-	oldResp, _, err = storage.GetStorageInstance().SpannerGet(ctx, tableName, putObj[pKey], putObj[sKey], nil)
+	oldResp, _, err = services.GetStorage().SpannerGet(ctx, tableConf.ActualTable, putObj[pKey], putObj[sKey], nil)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -1285,3 +1487,149 @@ func TransactPut(ctx context.Context, tableName string, putObj map[string]interf
 	// Return the result and the mutation
 	return res, mut, nil
 }
+
+// DescribeTable returns the key schema of a table and the status of its indexes
+// @Description Returns the key schema of a table and the status of its indexes
+// @Summary Describes a table
+// @ID describe-table
+// @Produce  json
+// @Success 200 {object} gin.H
+// @Param requestBody body models.DescribeTableMeta true "Please add request body of type models.DescribeTableMeta"
+// @Failure 500 {object} gin.H "{"errorMessage":"We had a problem with our server. Try again later.","errorCode":"E0001"}"
+// @Router /DescribeTable/ [post]
+// @Failure 401 {object} gin.H "{"errorMessage":"API access not allowed","errorCode": "E0005"}"
+func (h *APIHandler) DescribeTable(c *gin.Context) {
+	startTime := time.Now()
+	ctx := c.Request.Context()
+	var err error
+	defer PanicHandler(c)
+	defer c.Request.Body.Close()
+	otelInstance := models.GlobalProxy.OtelInst
+	if otelInstance == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "OpenTelemetry instance not initialized"})
+		return
+	}
+	ctx, span := otelInstance.StartSpan(ctx, "DescribeTable", []attribute.KeyValue{
+		attribute.String("request.method", c.Request.Method),
+		attribute.String("request.url", c.Request.URL.Path),
+	})
+	defer models.GlobalProxy.OtelInst.EndSpan(span)
+	addParentSpanID(c, span)
+	defer recordMetrics(ctx, models.GlobalProxy.OtelInst, "DescribeTable", startTime, err)
+
+	var meta models.DescribeTableMeta
+	if err := c.ShouldBindJSON(&meta); err != nil {
+		c.JSON(errors.New("ValidationException", err).HTTPResponse(meta))
+		return
+	}
+	if span != nil {
+		span.SetAttributes(attribute.String("table", meta.TableName))
+	}
+	logger.LogDebug(meta)
+	if allow := h.svc.MayIReadOrWrite(meta.TableName, false, ""); !allow {
+		c.JSON(http.StatusOK, gin.H{})
+		return
+	}
+
+	res, err := services.DescribeTable(ctx, meta.TableName)
+	if err != nil {
+		c.JSON(errors.HTTPResponse(err, meta))
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// UpdateTable creates or deletes a global secondary index
+// @Description Creates or deletes a global secondary index. The index is built in the background and its status is reported by DescribeTable.
+// @Summary Updates a table
+// @ID update-table
+// @Produce  json
+// @Success 200 {object} gin.H
+// @Param requestBody body models.UpdateTableMeta true "Please add request body of type models.UpdateTableMeta"
+// @Failure 500 {object} gin.H "{"errorMessage":"We had a problem with our server. Try again later.","errorCode":"E0001"}"
+// @Router /UpdateTable/ [post]
+// @Failure 401 {object} gin.H "{"errorMessage":"API access not allowed","errorCode": "E0005"}"
+func (h *APIHandler) UpdateTable(c *gin.Context) {
+	startTime := time.Now()
+	ctx := c.Request.Context()
+	var err error
+	defer PanicHandler(c)
+	defer c.Request.Body.Close()
+	otelInstance := models.GlobalProxy.OtelInst
+	if otelInstance == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "OpenTelemetry instance not initialized"})
+		return
+	}
+	ctx, span := otelInstance.StartSpan(ctx, "UpdateTable", []attribute.KeyValue{
+		attribute.String("request.method", c.Request.Method),
+		attribute.String("request.url", c.Request.URL.Path),
+	})
+	defer models.GlobalProxy.OtelInst.EndSpan(span)
+	addParentSpanID(c, span)
+	defer recordMetrics(ctx, models.GlobalProxy.OtelInst, "UpdateTable", startTime, err)
+
+	var meta models.UpdateTableMeta
+	if err := c.ShouldBindJSON(&meta); err != nil {
+		c.JSON(errors.New("ValidationException", err).HTTPResponse(meta))
+		return
+	}
+	if span != nil {
+		span.SetAttributes(attribute.String("table", meta.TableName))
+	}
+	logger.LogDebug(meta)
+	if allow := h.svc.MayIReadOrWrite(meta.TableName, true, ""); !allow {
+		c.JSON(http.StatusOK, gin.H{})
+		return
+	}
+
+	res, err := services.UpdateTable(ctx, meta)
+	if err != nil {
+		c.JSON(errors.HTTPResponse(err, meta))
+		return
+	}
+	c.JSON(http.StatusOK, map[string]interface{}{"TableDescription": res["Table"]})
+}
+
+// ListTables lists the tables of the caller's tenant
+// @Description Lists the tables in the namespace of the caller's tenant, in sorted order
+// @Summary Lists tables
+// @ID list-tables
+// @Produce  json
+// @Success 200 {object} gin.H
+// @Param requestBody body models.ListTablesMeta true "Please add request body of type models.ListTablesMeta"
+// @Failure 500 {object} gin.H "{"errorMessage":"We had a problem with our server. Try again later.","errorCode":"E0001"}"
+// @Router /ListTables/ [post]
+// @Failure 401 {object} gin.H "{"errorMessage":"API access not allowed","errorCode": "E0005"}"
+func (h *APIHandler) ListTables(c *gin.Context) {
+	startTime := time.Now()
+	ctx := c.Request.Context()
+	var err error
+	defer PanicHandler(c)
+	defer c.Request.Body.Close()
+	otelInstance := models.GlobalProxy.OtelInst
+	if otelInstance == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "OpenTelemetry instance not initialized"})
+		return
+	}
+	ctx, span := otelInstance.StartSpan(ctx, "ListTables", []attribute.KeyValue{
+		attribute.String("request.method", c.Request.Method),
+		attribute.String("request.url", c.Request.URL.Path),
+	})
+	defer models.GlobalProxy.OtelInst.EndSpan(span)
+	addParentSpanID(c, span)
+	defer recordMetrics(ctx, models.GlobalProxy.OtelInst, "ListTables", startTime, err)
+
+	var meta models.ListTablesMeta
+	if err := c.ShouldBindJSON(&meta); err != nil && err != io.EOF {
+		c.JSON(errors.New("ValidationException", err).HTTPResponse(meta))
+		return
+	}
+	logger.LogDebug(meta)
+
+	res, err := services.ListTables(ctx, meta)
+	if err != nil {
+		c.JSON(errors.HTTPResponse(err, meta))
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/cloudspannerecosystem/dynamodb-adapter/models"
+	otelgo "github.com/cloudspannerecosystem/dynamodb-adapter/otel"
 	"github.com/cloudspannerecosystem/dynamodb-adapter/service/services"
 	"github.com/cloudspannerecosystem/dynamodb-adapter/storage"
 
@@ -136,3 +137,173 @@ func TestTransactGetItems_ValidRequestWithMultipleItems(t *testing.T) {
 	mockSvc.AssertNumberOfCalls(t, "TransactGetProjectionCols", 2)
 	mockSvc.AssertExpectations(t)
 }
+
+func setupBatchTables(t *testing.T, tables ...string) *storage.MemoryStorage {
+	dbConfigMap, tableDDL, tableColumnMap := models.DbConfigMap, models.TableDDL, models.TableColumnMap
+	models.DbConfigMap = map[string]models.TableConfig{}
+	models.TableDDL = map[string]map[string]string{}
+	models.TableColumnMap = map[string][]string{}
+	s := storage.NewMemoryStorage()
+	for _, table := range tables {
+		models.DbConfigMap[table] = models.TableConfig{PartitionKey: "customer", SortKey: "id", ActualTable: table}
+		models.TableDDL[table] = map[string]string{"customer": "S", "id": "N", "status": "S"}
+		models.TableColumnMap[table] = []string{"customer", "id", "status"}
+		s.CreateTable(table, "customer", "id")
+	}
+	services.SetStorage(s)
+	services.SetServiceInstance(services.NewService(s))
+	t.Cleanup(func() {
+		models.DbConfigMap, models.TableDDL, models.TableColumnMap = dbConfigMap, tableDDL, tableColumnMap
+		services.SetStorage(nil)
+	})
+	return s
+}
+
+func TestBatchWriteItemsUnprocessed(t *testing.T) {
+	setupBatchTables(t, "orders")
+
+	// the put without its sort key is the only item left unprocessed
+	unprocessed, err := batchWriteItems(context.Background(), "orders", []models.BatchWriteSubItems{
+		{PutReq: models.BatchPutItem{Item: orderKey("alice", "1")}},
+		{PutReq: models.BatchPutItem{Item: map[string]*dynamodb.AttributeValue{"customer": {S: aws.String("bob")}}}},
+		{DelReq: models.BatchDeleteItem{Key: orderKey("alice", "2")}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(unprocessed))
+	data, err := json.Marshal(unprocessed)
+	assert.NoError(t, err)
+	var requests []map[string]json.RawMessage
+	assert.NoError(t, json.Unmarshal(data, &requests))
+	_, hasPut := requests[0]["PutRequest"]
+	_, hasDelete := requests[0]["DeleteRequest"]
+	assert.True(t, hasPut)
+	assert.False(t, hasDelete)
+	var retried []models.BatchWriteSubItems
+	assert.NoError(t, json.Unmarshal(data, &retried))
+	assert.Equal(t, "bob", *retried[0].PutReq.Item["customer"].S)
+
+	entry := unprocessedBatchGetKeys(models.BatchGetWithProjectionMeta{
+		Keys:                 []map[string]*dynamodb.AttributeValue{orderKey("alice", "1"), orderKey("alice", "2")},
+		ProjectionExpression: "id",
+	}, []int{1})
+	assert.Equal(t, map[string]interface{}{
+		"Keys":                 []map[string]*dynamodb.AttributeValue{orderKey("alice", "2")},
+		"ProjectionExpression": "id",
+	}, entry)
+}
+
+func TestBatchGetTables(t *testing.T) {
+	tables := []string{"a", "b", "c", "d"}
+	setupBatchTables(t, tables...)
+	request := models.BatchGetMeta{RequestItems: map[string]models.BatchGetWithProjectionMeta{}}
+	for _, table := range tables {
+		_, err := batchWriteItems(context.Background(), table, []models.BatchWriteSubItems{
+			{PutReq: models.BatchPutItem{Item: orderKey("alice", "1")}},
+		})
+		assert.NoError(t, err)
+		request.RequestItems[table] = models.BatchGetWithProjectionMeta{
+			Keys:                 []map[string]*dynamodb.AttributeValue{orderKey("alice", "1"), orderKey("alice", "2")},
+			ProjectionExpression: "id",
+		}
+	}
+	request.RequestItems["missing"] = models.BatchGetWithProjectionMeta{Keys: []map[string]*dynamodb.AttributeValue{orderKey("alice", "1")}}
+
+	results := batchGetTables(context.Background(), request, append(tables, "missing"), nil)
+	assert.Equal(t, 5, len(results))
+	for _, result := range results[:4] {
+		assert.NoError(t, result.err)
+		assert.Equal(t, []map[string]interface{}{{"id": map[string]interface{}{"N": "1"}}}, result.items)
+	}
+	assert.Error(t, results[4].err)
+}
+
+func TestPartiQLTableName(t *testing.T) {
+	for query, table := range map[string]string{
+		`SELECT * FROM orders WHERE id = 1`:                                 "orders",
+		`SELECT * FROM "orders"."by_status" WHERE status = 'x'`:             "orders",
+		`SELECT * FROM orders.by_status WHERE status = 'x'`:                 "orders",
+		`INSERT INTO "orders" VALUE {'id': 1, 'note': 'moved from legacy'}`: "orders",
+		`UPDATE orders SET note = 'copied from legacy' WHERE id = 1`:        "orders",
+		`DELETE FROM "orders" WHERE id = 1`:                                 "orders",
+		`SELECT * FROM "my-orders" WHERE id = 1`:                            "my-orders",
+	} {
+		assert.Equal(t, table, partiQLTableName(query), query)
+	}
+	assert.Equal(t, "", partiQLTableName(`SELECT 'from orders'`))
+}
+
+func orderRequest(t *testing.T, body string) (*gin.Context, *httptest.ResponseRecorder) {
+	config, proxy := models.GlobalConfig, models.GlobalProxy
+	models.GlobalConfig = &models.Config{Spanner: models.SpannerConfig{QueryLimit: 5000}}
+	models.GlobalProxy = &models.Proxy{OtelInst: &otelgo.OpenTelemetry{Config: &otelgo.OTelConfig{}}}
+	t.Cleanup(func() {
+		models.GlobalConfig, models.GlobalProxy = config, proxy
+	})
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1", bytes.NewBufferString(body))
+	return c, recorder
+}
+
+func orderHandler() *APIHandler {
+	svc := new(MockService)
+	svc.On("MayIReadOrWrite", mock.Anything, mock.Anything, mock.Anything).Return(true)
+	return NewAPIHandler(svc)
+}
+
+func TestQueryTableScanIndexForward(t *testing.T) {
+	setupBatchTables(t, "orders")
+	_, err := batchWriteItems(context.Background(), "orders", []models.BatchWriteSubItems{
+		{PutReq: models.BatchPutItem{Item: orderKey("alice", "2")}},
+		{PutReq: models.BatchPutItem{Item: orderKey("alice", "1")}},
+		{PutReq: models.BatchPutItem{Item: orderKey("alice", "3")}},
+	})
+	assert.NoError(t, err)
+
+	for body, ids := range map[string][]string{
+		`{"TableName":"orders","KeyConditionExpression":"customer = :c","ExpressionAttributeValues":{":c":{"S":"alice"}}}`:                          {"1", "2", "3"},
+		`{"TableName":"orders","KeyConditionExpression":"customer = :c","ExpressionAttributeValues":{":c":{"S":"alice"}},"ScanIndexForward":true}`:  {"1", "2", "3"},
+		`{"TableName":"orders","KeyConditionExpression":"customer = :c","ExpressionAttributeValues":{":c":{"S":"alice"}},"ScanIndexForward":false}`: {"3", "2", "1"},
+	} {
+		c, recorder := orderRequest(t, body)
+		orderHandler().QueryTable(c)
+		assert.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+		var res struct {
+			Items []struct {
+				ID struct{ N string } `json:"id"`
+			}
+		}
+		assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &res))
+		var got []string
+		for _, item := range res.Items {
+			got = append(got, item.ID.N)
+		}
+		assert.Equal(t, ids, got, body)
+	}
+}
+
+func TestScanLastEvaluatedKey(t *testing.T) {
+	setupBatchTables(t, "orders")
+	_, err := batchWriteItems(context.Background(), "orders", []models.BatchWriteSubItems{
+		{PutReq: models.BatchPutItem{Item: orderKey("alice", "1")}},
+		{PutReq: models.BatchPutItem{Item: orderKey("alice", "2")}},
+	})
+	assert.NoError(t, err)
+
+	// a page that ends the table carries no LastEvaluatedKey, not a null one
+	c, recorder := orderRequest(t, `{"TableName":"orders"}`)
+	orderHandler().Scan(c)
+	assert.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	var res map[string]json.RawMessage
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &res))
+	assert.Contains(t, res, "Items")
+	assert.NotContains(t, res, "LastEvaluatedKey")
+
+	c, recorder = orderRequest(t, `{"TableName":"orders","Limit":1}`)
+	orderHandler().Scan(c)
+	assert.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	res = nil
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &res))
+	assert.Contains(t, res, "LastEvaluatedKey")
+}
@@ -0,0 +1,187 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/cloudspannerecosystem/dynamodb-adapter/models"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/pkg/errors"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/pkg/logger"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/service/services"
+	"github.com/gin-gonic/gin"
+)
+
+// ExplainHeader is the request header asking for the Spanner statements of a
+// request, with "plan" their SQL, parameters and plans, with "stats" their
+// execution statistics as well. The response carries the ExplainIDHeader the
+// statements are then read with from /admin/explain/:id.
+const ExplainHeader = "X-Dynamodb-Adapter-Explain"
+
+// ExplainIDHeader is the response header holding the explain id of a request
+// sent with the ExplainHeader
+const ExplainIDHeader = "X-Dynamodb-Adapter-Explain-Id"
+
+// AdminTokenHeader is the request header carrying the admin token of the
+// requests to /admin
+const AdminTokenHeader = "X-Dynamodb-Adapter-Admin-Token"
+
+// maxExplainedRequests is the number of explained requests kept for
+// /admin/explain/:id
+const maxExplainedRequests = 1000
+
+// explainActions are the actions whose statements can be explained, those
+// which only read
+var explainActions = map[string]bool{"Query": true, "Scan": true, "ExecuteStatement": true}
+
+// explainedRequests holds the statements of the latest requests sent with the
+// ExplainHeader by explain id
+type explainedRequests struct {
+	mu         sync.Mutex
+	ids        []string
+	statements map[string][]services.ExplainedStatement
+}
+
+var explained = &explainedRequests{statements: map[string][]services.ExplainedStatement{}}
+
+func (r *explainedRequests) add(id string, statements []services.ExplainedStatement) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.ids) >= maxExplainedRequests {
+		delete(r.statements, r.ids[0])
+		r.ids = r.ids[1:]
+	}
+	r.ids = append(r.ids, id)
+	r.statements[id] = statements
+}
+
+func (r *explainedRequests) get(id string) ([]services.ExplainedStatement, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	statements, ok := r.statements[id]
+	return statements, ok
+}
+
+// ExplainHandler records the Spanner statements of the requests sent with the
+// ExplainHeader, and returns the id they are read with in the ExplainIDHeader.
+// The statements hold parameter values, so they are only served to admins.
+func ExplainHandler(c *gin.Context) {
+	mode := c.GetHeader(ExplainHeader)
+	if mode == "" {
+		c.Next()
+		return
+	}
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		logger.LogError(err)
+		c.Next()
+		return
+	}
+	explainID := hex.EncodeToString(id)
+	explain := &services.Explain{Stats: strings.EqualFold(mode, "stats")}
+	c.Request = c.Request.WithContext(services.WithExplain(c.Request.Context(), explain))
+	c.Writer.Header().Set(ExplainIDHeader, explainID)
+	c.Next()
+	explained.add(explainID, explain.Statements())
+}
+
+// AdminHandler only lets through the requests carrying token in the
+// AdminTokenHeader
+func AdminHandler(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" || subtle.ConstantTimeCompare([]byte(c.GetHeader(AdminTokenHeader)), []byte(token)) != 1 {
+			c.JSON(errors.New("AccessDeniedException", "The request does not carry the admin token").HTTPResponse(c.Request.URL.Path))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// ExplainedRequest returns the statements of the request explained with the
+// id of the path
+func ExplainedRequest(c *gin.Context) {
+	statements, ok := explained.get(c.Param("id"))
+	if !ok {
+		c.JSON(errors.New("ResourceNotFoundException", "No explained request with id "+c.Param("id")).HTTPResponse(c.Param("id")))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"Statements": statements})
+}
+
+// Explain runs a Query, Scan or PartiQL SELECT request sent as to /v1 and
+// returns the Spanner statements it ran with their plans, and with
+// ?stats=true their execution statistics, along with the response of the
+// request.
+func (h *APIHandler) Explain(c *gin.Context) {
+	_, action, _ := strings.Cut(c.GetHeader("X-Amz-Target"), ".")
+	if !explainActions[action] {
+		c.JSON(errors.New("ValidationException", "only Query, Scan and ExecuteStatement requests can be explained").HTTPResponse(action))
+		return
+	}
+	if action == "ExecuteStatement" {
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.JSON(errors.New("ValidationException", err).HTTPResponse(action))
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		var statement models.ExecuteStatement
+		if err := json.Unmarshal(body, &statement); err != nil || !services.IsReadStatement(statement) {
+			c.JSON(errors.New("ValidationException", "only SELECT statements can be explained").HTTPResponse(statement.Statement))
+			return
+		}
+	}
+
+	explain := &services.Explain{Stats: c.Query("stats") == "true"}
+	c.Request = c.Request.WithContext(services.WithExplain(c.Request.Context(), explain))
+	writer := &bufferedResponseWriter{ResponseWriter: c.Writer}
+	c.Writer = writer
+	h.RouteRequest(c)
+	c.Writer = writer.ResponseWriter
+
+	response := json.RawMessage(writer.body.Bytes())
+	if !json.Valid(response) {
+		response = nil
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"Statements": explain.Statements(),
+		"Status":     c.Writer.Status(),
+		"Response":   response,
+	})
+}
+
+// bufferedResponseWriter holds back the response body, so that the response
+// of an explained request can be returned along with its statements
+type bufferedResponseWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *bufferedResponseWriter) Write(b []byte) (int, error) {
+	return w.body.Write(b)
+}
+
+func (w *bufferedResponseWriter) WriteString(s string) (int, error) {
+	return w.body.WriteString(s)
+}
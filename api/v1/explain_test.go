@@ -0,0 +1,153 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cloudspannerecosystem/dynamodb-adapter/models"
+	otelgo "github.com/cloudspannerecosystem/dynamodb-adapter/otel"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/service/services"
+	"github.com/gin-gonic/gin"
+	"github.com/tj/assert"
+)
+
+func setupExplain(t *testing.T) *gin.Engine {
+	setupBatchTables(t, "orders")
+	config, proxy := models.GlobalConfig, models.GlobalProxy
+	models.GlobalConfig = &models.Config{Explain: models.ExplainConfig{Enabled: true, AdminToken: models.Secret{Value: "admin"}}}
+	models.GlobalProxy = &models.Proxy{OtelInst: &otelgo.OpenTelemetry{Config: &otelgo.OTelConfig{}}}
+	t.Cleanup(func() {
+		models.GlobalConfig, models.GlobalProxy = config, proxy
+	})
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	InitDBAPI(r)
+	return r
+}
+
+func explainRequest(path, target, body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set("X-Amz-Target", "DynamoDB_20120810."+target)
+	if strings.HasPrefix(path, "/admin") {
+		req.Header.Set(AdminTokenHeader, "admin")
+	}
+	return req
+}
+
+func TestExplain(t *testing.T) {
+	r := setupExplain(t)
+	statement := `{"Statement":"SELECT * FROM orders WHERE customer = ?","Parameters":[{"S":"alice"}]}`
+
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, explainRequest("/admin/explain", "ExecuteStatement", statement))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	var res struct {
+		Statements []services.ExplainedStatement
+		Status     int
+		Response   map[string]interface{}
+	}
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &res))
+	assert.Equal(t, http.StatusOK, res.Status)
+	assert.Contains(t, res.Response, "Items")
+	assert.Len(t, res.Statements, 1)
+	assert.Equal(t, "orders", res.Statements[0].TableName)
+	assert.Contains(t, res.Statements[0].SQL, "customer")
+	assert.Equal(t, "", res.Statements[0].Error)
+
+	// statistics are those of the statement the request ran
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, explainRequest("/admin/explain?stats=true", "ExecuteStatement", statement))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &res))
+	assert.Contains(t, res.Response, "Items")
+	assert.Len(t, res.Statements, 1)
+	assert.Equal(t, "", res.Statements[0].Error)
+
+	// only reads are run
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, explainRequest("/admin/explain", "ExecuteStatement", `{"Statement":"DELETE FROM orders WHERE customer = 'alice' AND id = 1"}`))
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, explainRequest("/admin/explain", "PutItem", `{"TableName":"orders"}`))
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+}
+
+func TestExplainHeader(t *testing.T) {
+	r := setupExplain(t)
+	statement := `{"Statement":"SELECT * FROM orders WHERE customer = ?","Parameters":[{"S":"alice"}]}`
+
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, explainRequest("/v1", "ExecuteStatement", statement))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Empty(t, recorder.Header().Get(ExplainIDHeader))
+
+	req := explainRequest("/v1", "ExecuteStatement", statement)
+	req.Header.Set(ExplainHeader, "plan")
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	// the header only holds the explain id, the statements are served to admins
+	id := recorder.Header().Get(ExplainIDHeader)
+	assert.Len(t, id, 32)
+
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/admin/explain/"+id, nil))
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "AccessDeniedException")
+
+	req = httptest.NewRequest(http.MethodGet, "/admin/explain/"+id, nil)
+	req.Header.Set(AdminTokenHeader, "admin")
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	var res struct{ Statements []services.ExplainedStatement }
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &res))
+	assert.Len(t, res.Statements, 1)
+	assert.Equal(t, "orders", res.Statements[0].TableName)
+	assert.Equal(t, "alice", res.Statements[0].Params["customer"])
+
+	req = httptest.NewRequest(http.MethodGet, "/admin/explain/unknown", nil)
+	req.Header.Set(AdminTokenHeader, "admin")
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, req)
+	assert.Contains(t, recorder.Body.String(), "ResourceNotFoundException")
+}
+
+func TestExplainAdminToken(t *testing.T) {
+	r := setupExplain(t)
+	statement := `{"Statement":"SELECT * FROM orders WHERE customer = ?","Parameters":[{"S":"alice"}]}`
+	for _, token := range []string{"", "wrong"} {
+		req := explainRequest("/admin/explain", "ExecuteStatement", statement)
+		req.Header.Set(AdminTokenHeader, token)
+		recorder := httptest.NewRecorder()
+		r.ServeHTTP(recorder, req)
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), "AccessDeniedException")
+	}
+
+	// explain stays disabled without an admin token
+	models.GlobalConfig.Explain.AdminToken = models.Secret{}
+	r = gin.New()
+	InitDBAPI(r)
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, explainRequest("/admin/explain", "ExecuteStatement", statement))
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
@@ -0,0 +1,75 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"net"
+	"net/http"
+	"regexp"
+
+	"github.com/cloudspannerecosystem/dynamodb-adapter/config"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/models"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/pkg/errors"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// accessKeyPattern matches the access key id of a SigV4 Authorization header
+var accessKeyPattern = regexp.MustCompile(`Credential=([^/,\s]+)/`)
+
+// TenantHandler resolves the tenant of a request and adds it to the request
+// context. Table names are not changed in the request: the services move the
+// tables a client names into the tenant's namespace where they look them up,
+// see config.GetTenantTableConf.
+func TenantHandler(c *gin.Context) {
+	if models.GlobalConfig == nil || len(models.GlobalConfig.Tenancy.Tenants) == 0 {
+		c.Next()
+		return
+	}
+
+	tenant, err := config.ResolveTenant(c.GetHeader(config.TenantHeader()), accessKeyID(c.Request), hostName(c.Request.Host))
+	if err != nil {
+		c.JSON(errors.HTTPResponse(err, c.GetHeader(config.TenantHeader())))
+		c.Abort()
+		return
+	}
+	c.Request = c.Request.WithContext(config.WithTenant(c.Request.Context(), tenant))
+	target := c.GetHeader("X-Amz-Target")
+
+	c.Next()
+
+	if status := c.Writer.Status(); status >= http.StatusBadRequest {
+		logger.LogErrorW("request failed", "tenant", tenant.Name, "target", target, "status", status)
+	} else {
+		logger.LogDebugW("request", "tenant", tenant.Name, "target", target, "status", status)
+	}
+}
+
+// accessKeyID returns the access key id the request was signed with
+func accessKeyID(r *http.Request) string {
+	matches := accessKeyPattern.FindStringSubmatch(r.Header.Get("Authorization"))
+	if len(matches) > 1 {
+		return matches[1]
+	}
+	return ""
+}
+
+// hostName strips the port from the Host header
+func hostName(host string) string {
+	if name, _, err := net.SplitHostPort(host); err == nil {
+		return name
+	}
+	return host
+}
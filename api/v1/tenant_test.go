@@ -0,0 +1,109 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cloudspannerecosystem/dynamodb-adapter/config"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/models"
+	otelgo "github.com/cloudspannerecosystem/dynamodb-adapter/otel"
+	"github.com/gin-gonic/gin"
+	"github.com/tj/assert"
+)
+
+func setupTenantRouter(t *testing.T) *gin.Engine {
+	setupBatchTables(t, "orders", "dev_orders")
+	config, proxy := models.GlobalConfig, models.GlobalProxy
+	models.GlobalConfig = &models.Config{Tenancy: models.TenancyConfig{Tenants: []models.Tenant{
+		{Name: "dev", Prefix: "dev_", AccessKeys: []string{"AKIDDEV"}},
+		{Name: "qa", Prefix: "qa_"},
+	}}}
+	models.GlobalConfig.Spanner.QueryLimit = 5000
+	models.GlobalProxy = &models.Proxy{OtelInst: &otelgo.OpenTelemetry{Config: &otelgo.OTelConfig{}}}
+	t.Cleanup(func() {
+		models.GlobalConfig, models.GlobalProxy = config, proxy
+	})
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	InitDBAPI(r)
+	return r
+}
+
+func tenantRequest(r *gin.Engine, action, accessKey, tenant, body string) (int, map[string]interface{}) {
+	req := httptest.NewRequest(http.MethodPost, "/v1", strings.NewReader(body))
+	req.Header.Set("X-Amz-Target", "DynamoDB_20120810."+action)
+	if accessKey != "" {
+		req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+accessKey+"/20240101/us-east-1/dynamodb/aws4_request, SignedHeaders=host, Signature=abc")
+	}
+	if tenant != "" {
+		req.Header.Set(config.DefaultTenantHeader, tenant)
+	}
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, req)
+	var res map[string]interface{}
+	_ = json.Unmarshal(recorder.Body.Bytes(), &res)
+	return recorder.Code, res
+}
+
+func TestTenantTables(t *testing.T) {
+	r := setupTenantRouter(t)
+
+	code, _ := tenantRequest(r, "PutItem", "", "", `{"TableName": "orders", "Item": {"customer": {"S": "alice"}, "id": {"N": "1"}, "status": {"S": "default"}}}`)
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = tenantRequest(r, "PutItem", "AKIDDEV", "", `{"TableName": "orders", "Item": {"customer": {"S": "alice"}, "id": {"N": "1"}, "status": {"S": "dev"}}}`)
+	assert.Equal(t, http.StatusOK, code)
+
+	key := `{"TableName": "orders", "Key": {"customer": {"S": "alice"}, "id": {"N": "1"}}}`
+	_, res := tenantRequest(r, "GetItem", "AKIDDEV", "", key)
+	assert.Equal(t, map[string]interface{}{"S": "dev"}, res["Item"].(map[string]interface{})["status"])
+	_, res = tenantRequest(r, "GetItem", "AKIDOTHER", "", key)
+	assert.Equal(t, map[string]interface{}{"S": "default"}, res["Item"].(map[string]interface{})["status"])
+
+	_, res = tenantRequest(r, "BatchGetItem", "AKIDDEV", "", `{"RequestItems": {"orders": {"Keys": [{"customer": {"S": "alice"}, "id": {"N": "1"}}]}}}`)
+	assert.Contains(t, res["Responses"], "orders")
+
+	_, res = tenantRequest(r, "ExecuteStatement", "AKIDDEV", "", `{"Statement": "SELECT status FROM orders WHERE customer = 'alice'"}`)
+	assert.Equal(t, []interface{}{map[string]interface{}{"status": map[string]interface{}{"S": "dev"}}}, res["Items"])
+
+	_, res = tenantRequest(r, "DescribeTable", "AKIDDEV", "", `{"TableName": "orders"}`)
+	assert.Equal(t, "orders", res["Table"].(map[string]interface{})["TableName"])
+
+	// qa has no orders table
+	code, _ = tenantRequest(r, "GetItem", "", "qa", key)
+	assert.Equal(t, http.StatusBadRequest, code)
+}
+
+func TestTenantHeader(t *testing.T) {
+	r := setupTenantRouter(t)
+	key := `{"TableName": "orders", "Key": {"customer": {"S": "alice"}, "id": {"N": "1"}}}`
+
+	// the tenant bound to an access key cannot be changed with the header
+	code, res := tenantRequest(r, "GetItem", "AKIDDEV", "qa", key)
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, "AccessDeniedException", res["code"])
+
+	code, _ = tenantRequest(r, "GetItem", "AKIDDEV", "dev", key)
+	assert.Equal(t, http.StatusOK, code)
+
+	code, res = tenantRequest(r, "GetItem", "", "prod", key)
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, "UnrecognizedClientException", res["code"])
+}
@@ -0,0 +1,344 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/config"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/models"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/pkg/errors"
+	"github.com/gin-gonic/gin"
+)
+
+// DynamoDB request limits
+const (
+	maxBatchWriteItems       = 25
+	maxBatchGetKeys          = 100
+	maxBatchGetResponseSize  = 16 * 1024 * 1024
+	maxTransactItems         = 100
+	maxBatchStatements       = 25
+	maxClientRequestToken    = 36
+	maxBatchWriteRequestSize = 16 * 1024 * 1024
+	maxTransactRequestSize   = 4 * 1024 * 1024
+	maxExpressionSize        = 4 * 1024
+	maxPartitionKeySize      = 2048
+	maxSortKeySize           = 1024
+)
+
+// Messages of requests over the size limit
+const (
+	batchWriteRequestTooLarge = "Request size exceeded 16777216 bytes"
+	transactRequestTooLarge   = "Transaction request cannot be larger than 4 MB"
+)
+
+// keyAttributes returns the attribute names clients use for the partition and
+// sort key of tableName. ok is false for tables without configuration, which
+// are reported by the operation itself.
+func keyAttributes(ctx context.Context, tableName string) (pKey, sKey string, ok bool) {
+	tableConf, err := config.GetTenantTableConf(ctx, tableName)
+	if err != nil {
+		return "", "", false
+	}
+	return originalColumn(tableConf.PartitionKey), originalColumn(tableConf.SortKey), true
+}
+
+// originalColumn returns the name clients use for the Spanner column col
+func originalColumn(col string) string {
+	if original, ok := models.OriginalColResponse[col]; ok {
+		return original
+	}
+	return col
+}
+
+// validateKey checks the key attributes of item, which is a key or a whole
+// item, for empty values and the key size limits
+func validateKey(ctx context.Context, tableName string, item map[string]*dynamodb.AttributeValue) error {
+	pKey, sKey, ok := keyAttributes(ctx, tableName)
+	if !ok {
+		return nil
+	}
+	for _, name := range []string{pKey, sKey} {
+		v := item[name]
+		if name == "" || v == nil {
+			continue
+		}
+		if v.S != nil && *v.S == "" {
+			return errors.New("ValidationException", "One or more parameter values are not valid. The AttributeValue for a key attribute cannot contain an empty string value. Key: "+name)
+		}
+		if v.B != nil && len(v.B) == 0 {
+			return errors.New("ValidationException", "One or more parameter values are not valid. The AttributeValue for a key attribute cannot contain an empty binary value. Key: "+name)
+		}
+	}
+	if v := item[pKey]; v != nil && models.AttributeSize(v) > maxPartitionKeySize {
+		return errors.New("ValidationException", fmt.Sprintf("One or more parameter values were invalid: Size of hashkey has exceeded the maximum size limit of%d bytes", maxPartitionKeySize))
+	}
+	if v := item[sKey]; sKey != "" && v != nil && models.AttributeSize(v) > maxSortKeySize {
+		return errors.New("ValidationException", fmt.Sprintf("One or more parameter values were invalid: Aggregated size of all range keys has exceeded the size limit of %d bytes", maxSortKeySize))
+	}
+	return nil
+}
+
+// validateItem checks an item written by PutItem, BatchWriteItem or
+// TransactWriteItems
+func validateItem(ctx context.Context, tableName string, item map[string]*dynamodb.AttributeValue) error {
+	if models.ItemSize(item) > models.MaxItemSize {
+		return errors.New("ValidationException", "Item size has exceeded the maximum allowed size")
+	}
+	return validateKey(ctx, tableName, item)
+}
+
+// validateExpressions checks the lengths of the expressions of a request,
+// keyed by their parameter name
+func validateExpressions(expressions map[string]string) error {
+	names := make([]string, 0, len(expressions))
+	for name := range expressions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if expression := expressions[name]; len(expression) > maxExpressionSize {
+			return errors.New("ValidationException", fmt.Sprintf("Invalid %s: Expression size has exceeded the maximum allowed size; expression size: %d", name, len(expression)))
+		}
+	}
+	return nil
+}
+
+// validatePutItem checks the limits of a PutItem request
+func validatePutItem(ctx context.Context, meta models.Meta) error {
+	if err := validateItem(ctx, meta.TableName, meta.Item); err != nil {
+		return err
+	}
+	return validateExpressions(map[string]string{"ConditionExpression": meta.ConditionExpression})
+}
+
+// validateGetItem checks the limits of a GetItem request
+func validateGetItem(ctx context.Context, getItemMeta models.GetItemMeta) error {
+	if err := validateKey(ctx, getItemMeta.TableName, getItemMeta.Key); err != nil {
+		return err
+	}
+	return validateExpressions(map[string]string{"ProjectionExpression": getItemMeta.ProjectionExpression})
+}
+
+// validateDeleteItem checks the limits of a DeleteItem request
+func validateDeleteItem(ctx context.Context, deleteItem models.Delete) error {
+	if err := validateKey(ctx, deleteItem.TableName, deleteItem.Key); err != nil {
+		return err
+	}
+	return validateExpressions(map[string]string{"ConditionExpression": deleteItem.ConditionExpression})
+}
+
+// validateUpdateItem checks the limits of an UpdateItem request
+func validateUpdateItem(ctx context.Context, updateAttr models.UpdateAttr) error {
+	if err := validateKey(ctx, updateAttr.TableName, updateAttr.Key); err != nil {
+		return err
+	}
+	return validateExpressions(map[string]string{
+		"UpdateExpression":    updateAttr.UpdateExpression,
+		"ConditionExpression": updateAttr.ConditionExpression,
+	})
+}
+
+// keyString identifies the item of tableName with the key attributes of item,
+// for finding requests on the same item
+func keyString(ctx context.Context, tableName string, item map[string]*dynamodb.AttributeValue) string {
+	pKey, sKey, _ := keyAttributes(ctx, tableName)
+	var sb strings.Builder
+	sb.WriteString(tableName)
+	for _, name := range []string{pKey, sKey} {
+		sb.WriteByte(0)
+		v := item[name]
+		switch {
+		case name == "" || v == nil:
+		case v.S != nil:
+			sb.WriteString("S" + *v.S)
+		case v.N != nil:
+			n, err := models.ParseNumber(*v.N)
+			if err != nil {
+				sb.WriteString("N" + *v.N)
+			} else {
+				sb.WriteString("N" + n.String())
+			}
+		case v.B != nil:
+			sb.WriteString("B" + string(v.B))
+		}
+	}
+	return sb.String()
+}
+
+// validateBatchWrite checks the limits of a BatchWriteItem request
+func validateBatchWrite(ctx context.Context, batchWriteItem models.BatchWriteItem) error {
+	count := 0
+	keys := map[string]struct{}{}
+	for tableName, requests := range batchWriteItem.RequestItems {
+		count += len(requests)
+		if count > maxBatchWriteItems {
+			return errors.New("ValidationException", "Too many items requested for the BatchWriteItem call")
+		}
+		for _, request := range requests {
+			item := request.DelReq.Key
+			if request.PutReq.Item != nil {
+				item = request.PutReq.Item
+				if err := validateItem(ctx, tableName, item); err != nil {
+					return err
+				}
+			} else if err := validateKey(ctx, tableName, item); err != nil {
+				return err
+			}
+			key := keyString(ctx, tableName, item)
+			if _, ok := keys[key]; ok {
+				return errors.New("ValidationException", "Provided list of item keys contains duplicates")
+			}
+			keys[key] = struct{}{}
+		}
+	}
+	return nil
+}
+
+// validateBatchGet checks the limits of a BatchGetItem request
+func validateBatchGet(ctx context.Context, batchGetMeta models.BatchGetMeta) error {
+	count := 0
+	for tableName, request := range batchGetMeta.RequestItems {
+		count += len(request.Keys)
+		if count > maxBatchGetKeys {
+			return errors.New("ValidationException", "Too many items requested for the BatchGetItem call")
+		}
+		keys := map[string]struct{}{}
+		for _, key := range request.Keys {
+			if err := validateKey(ctx, tableName, key); err != nil {
+				return err
+			}
+			s := keyString(ctx, tableName, key)
+			if _, ok := keys[s]; ok {
+				return errors.New("ValidationException", "Provided list of item keys contains duplicates")
+			}
+			keys[s] = struct{}{}
+		}
+		if err := validateExpressions(map[string]string{"ProjectionExpression": request.ProjectionExpression}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateTransactWrite checks the limits of a TransactWriteItems request
+func validateTransactWrite(ctx context.Context, transactWriteMeta models.TransactWriteItemsRequest) error {
+	if len(transactWriteMeta.TransactItems) > maxTransactItems {
+		return errors.New("ValidationException", fmt.Sprintf("1 validation error detected: Value at 'transactItems' failed to satisfy constraint: Member must have length less than or equal to %d", maxTransactItems))
+	}
+	keys := map[string]struct{}{}
+	for _, transactItem := range transactWriteMeta.TransactItems {
+		var tableName string
+		var key map[string]*dynamodb.AttributeValue
+		var expressions map[string]string
+		switch {
+		case transactItem.ConditionCheck.Key != nil:
+			tableName, key = transactItem.ConditionCheck.TableName, transactItem.ConditionCheck.Key
+			expressions = map[string]string{"ConditionExpression": transactItem.ConditionCheck.ConditionExpression}
+		case transactItem.Put.Item != nil:
+			tableName, key = transactItem.Put.TableName, transactItem.Put.Item
+			if models.ItemSize(key) > models.MaxItemSize {
+				return errors.New("ValidationException", "Item size has exceeded the maximum allowed size")
+			}
+			expressions = map[string]string{"ConditionExpression": transactItem.Put.ConditionExpression}
+		case transactItem.Update.Key != nil:
+			tableName, key = transactItem.Update.TableName, transactItem.Update.Key
+			expressions = map[string]string{
+				"UpdateExpression":    transactItem.Update.UpdateExpression,
+				"ConditionExpression": transactItem.Update.ConditionExpression,
+			}
+		case transactItem.Delete.Key != nil:
+			tableName, key = transactItem.Delete.TableName, transactItem.Delete.Key
+			expressions = map[string]string{"ConditionExpression": transactItem.Delete.ConditionExpression}
+		default:
+			continue
+		}
+		if err := validateKey(ctx, tableName, key); err != nil {
+			return err
+		}
+		if err := validateExpressions(expressions); err != nil {
+			return err
+		}
+		s := keyString(ctx, tableName, key)
+		if _, ok := keys[s]; ok {
+			return errors.New("ValidationException", "Transaction request cannot include multiple operations on one item")
+		}
+		keys[s] = struct{}{}
+	}
+	return nil
+}
+
+// validateTransactGet checks the limits of a TransactGetItems request
+func validateTransactGet(ctx context.Context, transactGetMeta models.TransactGetItemsRequest) error {
+	if len(transactGetMeta.TransactItems) > maxTransactItems {
+		return errors.New("ValidationException", fmt.Sprintf("1 validation error detected: Value at 'transactItems' failed to satisfy constraint: Member must have length less than or equal to %d", maxTransactItems))
+	}
+	for _, transactItem := range transactGetMeta.TransactItems {
+		if err := validateKey(ctx, transactItem.Get.TableName, transactItem.Get.Keys); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateBatchExecuteStatement checks the limits of a BatchExecuteStatement
+// request
+func validateBatchExecuteStatement(request models.BatchExecuteStatementRequest) error {
+	if len(request.Statements) == 0 {
+		return errors.New("ValidationException", "1 validation error detected: Value at 'statements' failed to satisfy constraint: Member must have length greater than or equal to 1")
+	}
+	if len(request.Statements) > maxBatchStatements {
+		return errors.New("ValidationException", fmt.Sprintf("1 validation error detected: Value at 'statements' failed to satisfy constraint: Member must have length less than or equal to %d", maxBatchStatements))
+	}
+	return nil
+}
+
+// validateExecuteTransaction checks the limits of an ExecuteTransaction
+// request
+func validateExecuteTransaction(request models.ExecuteTransactionRequest) error {
+	if len(request.TransactStatements) == 0 {
+		return errors.New("ValidationException", "1 validation error detected: Value at 'transactStatements' failed to satisfy constraint: Member must have length greater than or equal to 1")
+	}
+	if len(request.TransactStatements) > maxTransactItems {
+		return errors.New("ValidationException", fmt.Sprintf("1 validation error detected: Value at 'transactStatements' failed to satisfy constraint: Member must have length less than or equal to %d", maxTransactItems))
+	}
+	if len(request.ClientRequestToken) > maxClientRequestToken {
+		return errors.New("ValidationException", fmt.Sprintf("1 validation error detected: Value at 'clientRequestToken' failed to satisfy constraint: Member must have length less than or equal to %d", maxClientRequestToken))
+	}
+	return nil
+}
+
+// checkRequestSize reads the body of the request to check it against limit.
+// The body is restored for binding.
+func checkRequestSize(c *gin.Context, limit int64, message string) error {
+	if c.Request.ContentLength > limit {
+		return errors.New("ValidationException", message)
+	}
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, limit+1))
+	if err != nil {
+		return errors.New("ValidationException", err)
+	}
+	if int64(len(body)) > limit {
+		return errors.New("ValidationException", message)
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	return nil
+}
@@ -0,0 +1,149 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/models"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/pkg/errors"
+	"github.com/gin-gonic/gin"
+	"github.com/tj/assert"
+)
+
+func setupValidationTables(t *testing.T) {
+	original := models.DbConfigMap
+	models.DbConfigMap = map[string]models.TableConfig{
+		"orders": {PartitionKey: "customer", SortKey: "id"},
+	}
+	t.Cleanup(func() { models.DbConfigMap = original })
+}
+
+func orderKey(customer, id string) map[string]*dynamodb.AttributeValue {
+	return map[string]*dynamodb.AttributeValue{
+		"customer": {S: aws.String(customer)},
+		"id":       {N: aws.String(id)},
+	}
+}
+
+func assertValidationError(t *testing.T, err error, message string) {
+	e, ok := err.(*errors.Error)
+	assert.True(t, ok, err)
+	if ok {
+		assert.Equal(t, "ValidationException", e.ErrorCode)
+		assert.Equal(t, message, strings.TrimSpace(e.ErrorMessage))
+	}
+}
+
+func TestValidateItem(t *testing.T) {
+	setupValidationTables(t)
+	ctx := context.Background()
+
+	item := orderKey("alice", "1")
+	assert.NoError(t, validateItem(ctx, "orders", item))
+	item["blob"] = &dynamodb.AttributeValue{B: make([]byte, models.MaxItemSize)}
+	assertValidationError(t, validateItem(ctx, "orders", item), "Item size has exceeded the maximum allowed size")
+
+	assertValidationError(t, validateKey(ctx, "orders", orderKey("", "1")),
+		"One or more parameter values are not valid. The AttributeValue for a key attribute cannot contain an empty string value. Key: customer")
+	assertValidationError(t, validateKey(ctx, "orders", orderKey(strings.Repeat("a", 2049), "1")),
+		"One or more parameter values were invalid: Size of hashkey has exceeded the maximum size limit of2048 bytes")
+	assert.NoError(t, validateKey(ctx, "orders", orderKey(strings.Repeat("a", 2048), "1")))
+	assert.NoError(t, validateKey(ctx, "unknown", orderKey("", "1")))
+
+	assertValidationError(t, validateUpdateItem(ctx, models.UpdateAttr{
+		TableName:        "orders",
+		Key:              orderKey("alice", "1"),
+		UpdateExpression: "SET a = :v" + strings.Repeat(" ", maxExpressionSize),
+	}), "Invalid UpdateExpression: Expression size has exceeded the maximum allowed size; expression size: 4106")
+}
+
+func TestValidateBatches(t *testing.T) {
+	setupValidationTables(t)
+	ctx := context.Background()
+
+	var requests []models.BatchWriteSubItems
+	for i := 0; i < maxBatchWriteItems; i++ {
+		requests = append(requests, models.BatchWriteSubItems{PutReq: models.BatchPutItem{Item: orderKey("alice", strconv.Itoa(i))}})
+	}
+	batchWrite := models.BatchWriteItem{RequestItems: map[string][]models.BatchWriteSubItems{"orders": requests}}
+	assert.NoError(t, validateBatchWrite(ctx, batchWrite))
+
+	batchWrite.RequestItems["orders"] = append(requests, models.BatchWriteSubItems{DelReq: models.BatchDeleteItem{Key: orderKey("bob", "1")}})
+	assertValidationError(t, validateBatchWrite(ctx, batchWrite), "Too many items requested for the BatchWriteItem call")
+
+	// 1 and 1.0 are the same key
+	batchWrite.RequestItems["orders"] = []models.BatchWriteSubItems{
+		{PutReq: models.BatchPutItem{Item: orderKey("alice", "1")}},
+		{DelReq: models.BatchDeleteItem{Key: orderKey("alice", "1.0")}},
+	}
+	assertValidationError(t, validateBatchWrite(ctx, batchWrite), "Provided list of item keys contains duplicates")
+
+	var keys []map[string]*dynamodb.AttributeValue
+	for i := 0; i <= maxBatchGetKeys; i++ {
+		keys = append(keys, orderKey("alice", strconv.Itoa(i)))
+	}
+	batchGet := models.BatchGetMeta{RequestItems: map[string]models.BatchGetWithProjectionMeta{"orders": {Keys: keys}}}
+	assertValidationError(t, validateBatchGet(ctx, batchGet), "Too many items requested for the BatchGetItem call")
+	batchGet.RequestItems["orders"] = models.BatchGetWithProjectionMeta{Keys: []map[string]*dynamodb.AttributeValue{orderKey("alice", "1"), orderKey("alice", "1")}}
+	assertValidationError(t, validateBatchGet(ctx, batchGet), "Provided list of item keys contains duplicates")
+
+	transactWrite := models.TransactWriteItemsRequest{TransactItems: []models.TransactWriteItem{
+		{Put: models.PutItemRequest{TableName: "orders", Item: orderKey("alice", "1")}},
+		{Delete: models.DeleteItemRequest{TableName: "orders", Key: orderKey("alice", "2")}},
+	}}
+	assert.NoError(t, validateTransactWrite(ctx, transactWrite))
+	transactWrite.TransactItems = append(transactWrite.TransactItems, models.TransactWriteItem{
+		ConditionCheck: models.ConditionCheckRequest{TableName: "orders", Key: orderKey("alice", "2")},
+	})
+	assertValidationError(t, validateTransactWrite(ctx, transactWrite), "Transaction request cannot include multiple operations on one item")
+
+	batchStatements := models.BatchExecuteStatementRequest{Statements: make([]models.BatchStatementRequest, maxBatchStatements)}
+	assert.NoError(t, validateBatchExecuteStatement(batchStatements))
+	batchStatements.Statements = append(batchStatements.Statements, models.BatchStatementRequest{})
+	assertValidationError(t, validateBatchExecuteStatement(batchStatements), "1 validation error detected: Value at 'statements' failed to satisfy constraint: Member must have length less than or equal to 25")
+	transaction := models.ExecuteTransactionRequest{TransactStatements: make([]models.ParameterizedStatement, 1), ClientRequestToken: strings.Repeat("t", maxClientRequestToken+1)}
+	assertValidationError(t, validateExecuteTransaction(transaction), "1 validation error detected: Value at 'clientRequestToken' failed to satisfy constraint: Member must have length less than or equal to 36")
+	transaction.TransactStatements = nil
+	assertValidationError(t, validateExecuteTransaction(transaction), "1 validation error detected: Value at 'transactStatements' failed to satisfy constraint: Member must have length greater than or equal to 1")
+}
+
+func TestCheckRequestSize(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	newContext := func(body string, contentLength int64) *gin.Context {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodPost, "/v1", strings.NewReader(body))
+		c.Request.ContentLength = contentLength
+		return c
+	}
+
+	c := newContext(`{"a": 1}`, 8)
+	assert.NoError(t, checkRequestSize(c, 8, transactRequestTooLarge))
+	body, err := io.ReadAll(c.Request.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"a": 1}`, string(body))
+
+	assertValidationError(t, checkRequestSize(newContext(`{"a": 10}`, 9), 8, transactRequestTooLarge), transactRequestTooLarge)
+	// chunked bodies have no length
+	assertValidationError(t, checkRequestSize(newContext(`{"a": 10}`, -1), 8, transactRequestTooLarge), transactRequestTooLarge)
+}
@@ -0,0 +1,639 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apitesting
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// ConformanceRequest is one entry of a conformance corpus. A corpus is a
+// JSONL file with one request per line, e.g.
+//
+//	{"name": "put order", "action": "PutItem", "request": {"TableName": "orders", "Item": {...}}}
+//
+// Setup requests are sent to both targets but their responses are not compared,
+// which is useful for statements such as CreateTable that only the reference understands.
+// Reference holds the response expected from the reference, which
+// RecordedReference answers with when the reference is not running.
+type ConformanceRequest struct {
+	Name      string            `json:"name"`
+	Action    string            `json:"action"`
+	Request   json.RawMessage   `json:"request"`
+	Setup     bool              `json:"setup,omitempty"`
+	Reference *RecordedResponse `json:"reference,omitempty"`
+}
+
+// RecordedResponse is a response expected from the reference
+type RecordedResponse struct {
+	Status int             `json:"status"`
+	Body   json.RawMessage `json:"body"`
+}
+
+// ConformanceTarget is a DynamoDB compatible endpoint the corpus is replayed against.
+// Only one of Handler and URL should be provided.
+type ConformanceTarget struct {
+	// Name used in reports, e.g. "adapter" or "dynamodb-local"
+	Name string
+	// URL of the endpoint including the path, e.g. "http://localhost:9050/v1"
+	URL string
+	// Handler is served with httptest when set, e.g. the adapter's *gin.Engine
+	Handler http.Handler
+	// Headers are added to every request, e.g. an Authorization header for DynamoDB Local
+	Headers map[string]string
+}
+
+// Conformance replays a corpus against the adapter and a reference implementation
+// (DynamoDB Local or a stand-in) and reports every semantic difference.
+// Usage:
+//
+//	corpus, err := apitesting.LoadCorpus("testdata/conformance.jsonl")
+//	c := apitesting.Conformance{
+//		Adapter:   apitesting.ConformanceTarget{Name: "adapter", Handler: r},
+//		Reference: apitesting.ConformanceTarget{Name: "dynamodb-local", URL: "http://localhost:8000"},
+//	}
+//	c.RunTests(t, corpus)
+type Conformance struct {
+	Adapter   ConformanceTarget
+	Reference ConformanceTarget
+	// IgnoreFields are response fields dropped before comparison, at any depth.
+	// DefaultIgnoreFields is used when empty.
+	IgnoreFields []string
+	// UnorderedPaths lists, per action, the response paths whose lists are compared
+	// without regard to order. "*" matches any map key. DefaultUnorderedPaths is used when nil.
+	UnorderedPaths map[string][]string
+	// Client used to send the requests, http.DefaultClient when nil
+	Client *http.Client
+}
+
+// DefaultIgnoreFields are fields which differ between implementations without any semantic meaning
+var DefaultIgnoreFields = []string{
+	"ConsumedCapacity", "ItemCollectionMetrics", "ResponseMetadata",
+	"TableArn", "TableId", "IndexArn", "CreationDateTime", "LatestStreamArn", "LatestStreamLabel",
+	"ProvisionedThroughput", "TableSizeBytes", "IndexSizeBytes", "ItemCount",
+}
+
+// itemFields hold user attributes, so ignored fields are not applied below them
+var itemFields = map[string]bool{
+	"Item": true, "Items": true, "Attributes": true, "Key": true, "Keys": true,
+	"LastEvaluatedKey": true, "Responses": true, "UnprocessedKeys": true, "UnprocessedItems": true,
+}
+
+// DefaultUnorderedPaths are the response lists whose order DynamoDB does not define
+var DefaultUnorderedPaths = map[string][]string{
+	"Scan":             {"Items"},
+	"ExecuteStatement": {"Items"},
+	"BatchGetItem":     {"Responses.*", "UnprocessedKeys.*.Keys"},
+	"BatchWriteItem":   {"UnprocessedItems.*"},
+	"ListTables":       {"TableNames"},
+	"DescribeTable":    {"Table.AttributeDefinitions", "Table.GlobalSecondaryIndexes", "Table.LocalSecondaryIndexes"},
+}
+
+// ConformanceResponse is a recorded response of one target
+type ConformanceResponse struct {
+	Status int
+	Body   interface{}
+}
+
+// Difference is a single semantic difference between the adapter and the reference response
+type Difference struct {
+	Path      string
+	Adapter   interface{}
+	Reference interface{}
+}
+
+func (d Difference) String() string {
+	path := d.Path
+	if path == "" {
+		path = "<body>"
+	}
+	return fmt.Sprintf("%s: adapter=%s reference=%s", path, formatValue(d.Adapter), formatValue(d.Reference))
+}
+
+// ConformanceResult holds the outcome of replaying one corpus request
+type ConformanceResult struct {
+	Request     ConformanceRequest
+	Adapter     ConformanceResponse
+	Reference   ConformanceResponse
+	Differences []Difference
+}
+
+// ConformanceReport holds the results of a corpus run
+type ConformanceReport struct {
+	Results []ConformanceResult
+}
+
+// missing marks a value which is absent in one of the responses
+type missing struct{}
+
+func (missing) String() string { return "<missing>" }
+
+var corpusNumber = regexp.MustCompile(`^([+-]?)(\d*)(?:\.(\d*))?(?:[eE]([+-]?\d+))?$`)
+
+// LoadCorpus reads a JSONL corpus, skipping blank lines and lines starting with "#"
+func LoadCorpus(path string) ([]ConformanceRequest, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadCorpus(f)
+}
+
+// ReadCorpus reads a JSONL corpus from r
+func ReadCorpus(r io.Reader) ([]ConformanceRequest, error) {
+	var corpus []ConformanceRequest
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		var req ConformanceRequest
+		if err := json.Unmarshal([]byte(text), &req); err != nil {
+			return nil, fmt.Errorf("corpus line %d: %v", line, err)
+		}
+		if req.Action == "" {
+			return nil, fmt.Errorf("corpus line %d: action is required", line)
+		}
+		if req.Name == "" {
+			req.Name = fmt.Sprintf("%d-%s", line, req.Action)
+		}
+		corpus = append(corpus, req)
+	}
+	return corpus, scanner.Err()
+}
+
+// Run replays the corpus in order against both targets and compares the normalized responses
+func (c *Conformance) Run(ctx context.Context, corpus []ConformanceRequest) (*ConformanceReport, error) {
+	adapterURL, closeAdapter := c.Adapter.endpoint()
+	defer closeAdapter()
+	referenceURL, closeReference := c.Reference.endpoint()
+	defer closeReference()
+
+	report := &ConformanceReport{}
+	for _, req := range corpus {
+		adapterResp, err := c.send(ctx, c.Adapter, adapterURL, req)
+		if err != nil {
+			return report, fmt.Errorf("%s: %s: %v", req.Name, c.Adapter.name("adapter"), err)
+		}
+		referenceResp, err := c.send(ctx, c.Reference, referenceURL, req)
+		if err != nil {
+			return report, fmt.Errorf("%s: %s: %v", req.Name, c.Reference.name("reference"), err)
+		}
+		if req.Setup {
+			continue
+		}
+		report.Results = append(report.Results, ConformanceResult{
+			Request:     req,
+			Adapter:     adapterResp,
+			Reference:   referenceResp,
+			Differences: c.Compare(req.Action, adapterResp, referenceResp),
+		})
+	}
+	return report, nil
+}
+
+// RecordedReference returns a reference answering the requests of the corpus,
+// sent in order, with their recorded responses, so the corpus runs without
+// DynamoDB Local. Setup requests without a recorded response are answered
+// with an empty object.
+func RecordedReference(corpus []ConformanceRequest) http.Handler {
+	var mu sync.Mutex
+	next := 0
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		action := strings.TrimPrefix(r.Header.Get("X-Amz-Target"), "DynamoDB_20120810.")
+		if next >= len(corpus) || corpus[next].Action != action {
+			http.Error(w, "request "+action+" is not the next one of the corpus", http.StatusInternalServerError)
+			return
+		}
+		req := corpus[next]
+		next++
+		recorded := req.Reference
+		if recorded == nil {
+			if !req.Setup {
+				http.Error(w, "no response recorded for "+req.Name, http.StatusInternalServerError)
+				return
+			}
+			recorded = &RecordedResponse{Status: http.StatusOK, Body: json.RawMessage("{}")}
+		}
+		w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+		w.WriteHeader(recorded.Status)
+		_, _ = w.Write(recorded.Body)
+	})
+}
+
+// Compare returns the semantic differences between an adapter and a reference response to action
+func (c *Conformance) Compare(action string, adapter, reference ConformanceResponse) []Difference {
+	var diffs []Difference
+	if adapter.Status != reference.Status {
+		diffs = append(diffs, Difference{Path: "HTTPStatus", Adapter: adapter.Status, Reference: reference.Status})
+	}
+	return append(diffs, diffValues("", c.normalize(action, adapter), c.normalize(action, reference))...)
+}
+
+// DecodeResponse decodes a response body for comparison. Bodies which are not JSON are kept as a string.
+func DecodeResponse(status int, body []byte) ConformanceResponse {
+	out := ConformanceResponse{Status: status}
+	if len(bytes.TrimSpace(body)) == 0 {
+		return out
+	}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	if err := dec.Decode(&out.Body); err != nil {
+		out.Body = string(body)
+	}
+	return out
+}
+
+// RunTests replays the corpus and reports every difference as a failed sub test per request
+func (c *Conformance) RunTests(t *testing.T, corpus []ConformanceRequest) {
+	report, err := c.Run(context.Background(), corpus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, result := range report.Results {
+		result := result
+		t.Run(result.Request.Action+"/"+result.Request.Name, func(t *testing.T) {
+			for _, d := range result.Differences {
+				t.Error(d.String())
+			}
+		})
+	}
+	t.Log("\n" + report.String())
+}
+
+// Failed returns true if any request produced a difference
+func (r *ConformanceReport) Failed() bool {
+	for _, result := range r.Results {
+		if len(result.Differences) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// ByAction groups the results by DynamoDB action
+func (r *ConformanceReport) ByAction() map[string][]ConformanceResult {
+	actions := map[string][]ConformanceResult{}
+	for _, result := range r.Results {
+		actions[result.Request.Action] = append(actions[result.Request.Action], result)
+	}
+	return actions
+}
+
+// String renders a per action summary followed by every difference
+func (r *ConformanceReport) String() string {
+	actions := r.ByAction()
+	names := make([]string, 0, len(actions))
+	for action := range actions {
+		names = append(names, action)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, action := range names {
+		failed := 0
+		for _, result := range actions[action] {
+			if len(result.Differences) > 0 {
+				failed++
+			}
+		}
+		fmt.Fprintf(&b, "%s: %d requests, %d with differences\n", action, len(actions[action]), failed)
+		for _, result := range actions[action] {
+			for _, d := range result.Differences {
+				fmt.Fprintf(&b, "    %s: %s\n", result.Request.Name, d)
+			}
+		}
+	}
+	return b.String()
+}
+
+func (target ConformanceTarget) name(def string) string {
+	if target.Name != "" {
+		return target.Name
+	}
+	return def
+}
+
+func (target ConformanceTarget) endpoint() (string, func()) {
+	if target.Handler == nil {
+		return target.URL, func() {}
+	}
+	server := httptest.NewServer(target.Handler)
+	return server.URL + target.URL, server.Close
+}
+
+func (c *Conformance) send(ctx context.Context, target ConformanceTarget, url string, req ConformanceRequest) (ConformanceResponse, error) {
+	body := req.Request
+	if len(body) == 0 {
+		body = json.RawMessage("{}")
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return ConformanceResponse{}, err
+	}
+	httpReq.Header.Set("Content-Type", "application/x-amz-json-1.0")
+	httpReq.Header.Set("X-Amz-Target", "DynamoDB_20120810."+req.Action)
+	for k, v := range target.Headers {
+		httpReq.Header.Set(k, v)
+	}
+	client := c.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return ConformanceResponse{}, err
+	}
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return ConformanceResponse{}, err
+	}
+	return DecodeResponse(resp.StatusCode, raw), nil
+}
+
+// normalize drops volatile fields, canonicalizes numbers and sets, sorts
+// unordered lists and reduces error bodies to their exception name
+func (c *Conformance) normalize(action string, resp ConformanceResponse) interface{} {
+	if resp.Status >= http.StatusBadRequest {
+		return map[string]interface{}{"__type": errorType(resp.Body)}
+	}
+	ignore := c.IgnoreFields
+	if len(ignore) == 0 {
+		ignore = DefaultIgnoreFields
+	}
+	ignored := map[string]bool{}
+	for _, f := range ignore {
+		ignored[f] = true
+	}
+	v := normalizeValue(resp.Body, ignored)
+
+	unordered := c.UnorderedPaths
+	if unordered == nil {
+		unordered = DefaultUnorderedPaths
+	}
+	for _, path := range unordered[action] {
+		sortPath(v, strings.Split(path, "."))
+	}
+	return v
+}
+
+// errorType extracts the exception name from a DynamoDB ("__type": "...#Name")
+// or adapter ("code": "Name") error body
+func errorType(body interface{}) string {
+	m, ok := body.(map[string]interface{})
+	if !ok {
+		return fmt.Sprint(body)
+	}
+	for _, key := range []string{"__type", "code"} {
+		if s, ok := m[key].(string); ok {
+			return s[strings.LastIndex(s, "#")+1:]
+		}
+	}
+	return ""
+}
+
+func normalizeValue(v interface{}, ignored map[string]bool) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if len(v) == 1 {
+			for k, av := range v {
+				if attr, ok := normalizeAttribute(k, av); ok {
+					return map[string]interface{}{k: attr}
+				}
+			}
+		}
+		out := make(map[string]interface{}, len(v))
+		for k, val := range v {
+			if ignored[k] {
+				continue
+			}
+			if itemFields[k] {
+				out[k] = normalizeValue(val, nil)
+				continue
+			}
+			out[k] = normalizeValue(val, ignored)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, val := range v {
+			out[i] = normalizeValue(val, ignored)
+		}
+		return out
+	case json.Number:
+		return normalizeNumber(v.String())
+	}
+	return v
+}
+
+// normalizeAttribute canonicalizes the scalar and set members of an AttributeValue
+func normalizeAttribute(typ string, v interface{}) (interface{}, bool) {
+	switch typ {
+	case "N":
+		s, ok := v.(string)
+		if !ok {
+			return nil, false
+		}
+		return normalizeNumber(s), true
+	case "NS", "SS", "BS":
+		list, ok := v.([]interface{})
+		if !ok {
+			return nil, false
+		}
+		out := make([]string, 0, len(list))
+		for _, item := range list {
+			s, ok := item.(string)
+			if !ok {
+				return nil, false
+			}
+			if typ == "NS" {
+				s = normalizeNumber(s)
+			}
+			out = append(out, s)
+		}
+		sort.Strings(out)
+		sorted := make([]interface{}, len(out))
+		for i, s := range out {
+			sorted[i] = s
+		}
+		return sorted, true
+	}
+	return nil, false
+}
+
+// normalizeNumber renders a decimal number in a canonical form so "1.50",
+// "+1.5" and "15E-1" compare equal. Strings which are not numbers are returned unchanged.
+func normalizeNumber(s string) string {
+	m := corpusNumber.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil || m[2]+m[3] == "" {
+		return s
+	}
+	exp := 0
+	if m[4] != "" {
+		e, err := strconv.Atoi(m[4])
+		if err != nil {
+			return s
+		}
+		exp = e
+	}
+	digits := strings.TrimLeft(m[2]+m[3], "0")
+	exp -= len(m[3])
+	if digits == "" {
+		return "0"
+	}
+	trimmed := strings.TrimRight(digits, "0")
+	exp += len(digits) - len(trimmed)
+	digits = trimmed
+
+	sign := ""
+	if m[1] == "-" {
+		sign = "-"
+	}
+	switch {
+	case exp >= 0 && len(digits)+exp <= 40:
+		return sign + digits + strings.Repeat("0", exp)
+	case exp < 0 && -exp < len(digits):
+		return sign + digits[:len(digits)+exp] + "." + digits[len(digits)+exp:]
+	case exp < 0 && -exp-len(digits) <= 40:
+		return sign + "0." + strings.Repeat("0", -exp-len(digits)) + digits
+	}
+	return sign + digits + "E" + strconv.Itoa(exp)
+}
+
+func sortPath(v interface{}, path []string) {
+	if len(path) == 0 {
+		return
+	}
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return
+	}
+	keys := []string{path[0]}
+	if path[0] == "*" {
+		keys = keys[:0]
+		for k := range m {
+			keys = append(keys, k)
+		}
+	}
+	for _, k := range keys {
+		if len(path) > 1 {
+			sortPath(m[k], path[1:])
+			continue
+		}
+		if list, ok := m[k].([]interface{}); ok {
+			sort.SliceStable(list, func(i, j int) bool {
+				return canonicalJSON(list[i]) < canonicalJSON(list[j])
+			})
+		}
+	}
+}
+
+func canonicalJSON(v interface{}) string {
+	// encoding/json sorts map keys, which makes the output canonical
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+func diffValues(path string, a, b interface{}) []Difference {
+	switch av := a.(type) {
+	case map[string]interface{}:
+		bv, ok := b.(map[string]interface{})
+		if !ok {
+			break
+		}
+		keys := map[string]bool{}
+		for k := range av {
+			keys[k] = true
+		}
+		for k := range bv {
+			keys[k] = true
+		}
+		sorted := make([]string, 0, len(keys))
+		for k := range keys {
+			sorted = append(sorted, k)
+		}
+		sort.Strings(sorted)
+
+		var diffs []Difference
+		for _, k := range sorted {
+			x, inA := av[k]
+			y, inB := bv[k]
+			switch {
+			case !inA:
+				diffs = append(diffs, Difference{Path: joinPath(path, k), Adapter: missing{}, Reference: y})
+			case !inB:
+				diffs = append(diffs, Difference{Path: joinPath(path, k), Adapter: x, Reference: missing{}})
+			default:
+				diffs = append(diffs, diffValues(joinPath(path, k), x, y)...)
+			}
+		}
+		return diffs
+	case []interface{}:
+		bv, ok := b.([]interface{})
+		if !ok {
+			break
+		}
+		if len(av) != len(bv) {
+			return []Difference{{Path: path + ".length", Adapter: len(av), Reference: len(bv)}}
+		}
+		var diffs []Difference
+		for i := range av {
+			diffs = append(diffs, diffValues(fmt.Sprintf("%s[%d]", path, i), av[i], bv[i])...)
+		}
+		return diffs
+	}
+	if canonicalJSON(a) == canonicalJSON(b) {
+		return nil
+	}
+	return []Difference{{Path: path, Adapter: a, Reference: b}}
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func formatValue(v interface{}) string {
+	if m, ok := v.(missing); ok {
+		return m.String()
+	}
+	return canonicalJSON(v)
+}
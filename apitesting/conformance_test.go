@@ -0,0 +1,132 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apitesting
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// stubTarget answers every action with a fixed response body
+func stubTarget(responses map[string]string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		action := strings.TrimPrefix(r.Header.Get("X-Amz-Target"), "DynamoDB_20120810.")
+		body, ok := responses[action]
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			body = `{"__type":"com.amazonaws.dynamodb.v20120810#UnknownOperationException"}`
+		}
+		_, _ = w.Write([]byte(body))
+	})
+}
+
+func TestLoadCorpus(t *testing.T) {
+	corpus, err := LoadCorpus("testdata/conformance.jsonl")
+	assert.NoError(t, err)
+	assert.Len(t, corpus, 8)
+	assert.Equal(t, "CreateTable", corpus[0].Action)
+	assert.True(t, corpus[0].Setup)
+
+	_, err = ReadCorpus(strings.NewReader(`{"name": "no action"}`))
+	assert.Error(t, err)
+}
+
+func TestConformanceRun(t *testing.T) {
+	corpus, err := LoadCorpus("testdata/conformance.jsonl")
+	assert.NoError(t, err)
+
+	reference := stubTarget(map[string]string{
+		"CreateTable": `{"TableDescription":{"TableName":"orders"}}`,
+		"DeleteTable": `{"TableDescription":{"TableName":"orders"}}`,
+		"PutItem":     `{}`,
+		"GetItem":     `{"Item":{"customer":{"S":"alice"},"id":{"N":"1"},"total":{"N":"10.5"},"tags":{"SS":["gift","new"]}}}`,
+		"Query":       `{"Items":[{"id":{"N":"1"}},{"id":{"N":"2"}}],"Count":2,"ScannedCount":2,"ConsumedCapacity":{"CapacityUnits":1}}`,
+		"Scan":        `{"Items":[{"id":{"N":"1"}},{"id":{"N":"2"}}],"Count":2,"ScannedCount":2}`,
+	})
+	// The adapter formats numbers and orders sets and scans differently, and
+	// answers the conditional put with its own error shape
+	adapter := stubTarget(map[string]string{
+		"PutItem": `{}`,
+		"GetItem": `{"Item":{"customer":{"S":"alice"},"id":{"N":"1.0"},"total":{"N":"1.05E1"},"tags":{"SS":["new","gift"]}}}`,
+		"Query":   `{"Items":[{"id":{"N":"2"}},{"id":{"N":"1"}}],"Count":2}`,
+		"Scan":    `{"Items":[{"id":{"N":"2"}},{"id":{"N":"1"}}],"Count":2,"ScannedCount":2}`,
+	})
+	reference = conditionalPut(reference, `{"__type":"com.amazonaws.dynamodb.v20120810#ConditionalCheckFailedException","message":"The conditional request failed"}`)
+	adapter = conditionalPut(adapter, `{"code":"ConditionalCheckFailedException","message":"\n"}`)
+
+	c := Conformance{
+		Adapter:   ConformanceTarget{Name: "adapter", Handler: adapter, URL: "/v1"},
+		Reference: ConformanceTarget{Name: "dynamodb-local", Handler: reference},
+	}
+	report, err := c.Run(context.Background(), corpus)
+	assert.NoError(t, err)
+	assert.Len(t, report.Results, 6)
+	assert.True(t, report.Failed())
+
+	actions := report.ByAction()
+	for _, result := range actions["PutItem"] {
+		assert.Empty(t, result.Differences, result.Request.Name)
+	}
+	assert.Empty(t, actions["GetItem"][0].Differences)
+	assert.Empty(t, actions["Scan"][0].Differences)
+	assert.Equal(t, []Difference{
+		{Path: "Items[0].id.N", Adapter: "2", Reference: "1"},
+		{Path: "Items[1].id.N", Adapter: "1", Reference: "2"},
+		{Path: "ScannedCount", Adapter: missing{}, Reference: "2"},
+	}, actions["Query"][0].Differences)
+	assert.Contains(t, report.String(), "Query: 1 requests, 1 with differences")
+	assert.Contains(t, report.String(), "ScannedCount: adapter=<missing> reference=\"2\"")
+}
+
+// conditionalPut answers PutItem requests carrying a ConditionExpression with body
+func conditionalPut(next http.Handler, body string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Amz-Target") == "DynamoDB_20120810.PutItem" {
+			buf, _ := io.ReadAll(r.Body)
+			r.Body = io.NopCloser(bytes.NewReader(buf))
+			if strings.Contains(string(buf), "ConditionExpression") {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(body))
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func TestNormalizeNumber(t *testing.T) {
+	tests := map[string]string{
+		"1":         "1",
+		"1.0":       "1",
+		"+10.50":    "10.5",
+		"1.05E1":    "10.5",
+		"-0.000":    "0",
+		"0.0012":    "0.0012",
+		"-12e-4":    "-0.0012",
+		"1E+3":      "1000",
+		"007":       "7",
+		"1E100":     "1E100",
+		"not a num": "not a num",
+	}
+	for in, want := range tests {
+		assert.Equal(t, want, normalizeNumber(in), in)
+	}
+}
@@ -21,6 +21,7 @@ import (
 	"log"
 	"os"
 	"regexp"
+	"sort"
 	"strings"
 
 	"cloud.google.com/go/spanner"
@@ -31,6 +32,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	dynamodbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/cloudspannerecosystem/dynamodb-adapter/models"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/storage"
 	"github.com/cloudspannerecosystem/dynamodb-adapter/utils"
 	"gopkg.in/yaml.v3"
 )
@@ -38,22 +40,6 @@ import (
 // Define a global variable for reading files (mockable for tests)
 var readFile = os.ReadFile
 
-// DDL statement to create the DynamoDB adapter table in Spanner
-var (
-	adapterTableDDL = `
-	CREATE TABLE dynamodb_adapter_table_ddl (
-		column STRING(MAX) NOT NULL,
-		tableName STRING(MAX) NOT NULL,
-		dynamoDataType STRING(MAX) NOT NULL,
-		originalColumn STRING(MAX) NOT NULL,
-		partitionKey STRING(MAX),
-		sortKey STRING(MAX),
-		spannerIndexName STRING(MAX),
-		actualTable STRING(MAX),
-		spannerDataType STRING(MAX)
-	) PRIMARY KEY (tableName, column)`
-)
-
 // Entry point for the application
 func main() {
 	// Parse command-line arguments for dry-run mode
@@ -65,6 +51,8 @@ func main() {
 	if err != nil {
 		log.Fatalf("Error loading configuration: %v", err)
 	}
+	// Table routing is resolved from the global config
+	models.GlobalConfig = config
 
 	// Build the Spanner database name
 	databaseName := fmt.Sprintf(
@@ -105,7 +93,7 @@ func loadConfig(filename string) (*models.Config, error) {
 // Run in dry-run mode to output DDL and insert queries without making changes
 func runDryRun(config *models.Config) {
 	fmt.Println("-- Spanner DDL to create the adapter table --")
-	fmt.Println(adapterTableDDL + ";")
+	fmt.Println(storage.AdapterTableDDL + ";")
 
 	client := createDynamoClient()
 	tables, err := listDynamoTables(client)
@@ -121,11 +109,84 @@ func runDryRun(config *models.Config) {
 		ddl := generateTableDDL(tableName, client, config.Spanner.DynamoQueryLimit)
 		fmt.Printf("-- DDL for table: %s --\n%s\n", tableName, ddl+";")
 
+		// Generate and print secondary index DDL
+		for _, indexDDL := range generateIndexDDLs(tableName, client, config.Spanner.DynamoQueryLimit) {
+			fmt.Printf("-- Index DDL for table: %s --\n%s\n", tableName, indexDDL+";")
+		}
+
 		// Generate and print insert queries
 		generateInsertQueries(tableName, client, config.Spanner.DynamoQueryLimit)
 	}
 }
 
+// Generate CREATE INDEX statements for the secondary indexes of a DynamoDB table
+func generateIndexDDLs(tableName string, client *dynamodb.Client, limit int32) []string {
+	attributes, partitionKey, sortKey, err := fetchTableAttributes(client, tableName, limit)
+	if err != nil {
+		log.Printf("Failed to fetch attributes for table %s: %v", tableName, err)
+		return nil
+	}
+	indexes, err := fetchTableIndexes(client, tableName)
+	if err != nil {
+		log.Printf("Failed to fetch indexes for table %s: %v", tableName, err)
+		return nil
+	}
+
+	var columns []string
+	for column := range attributes {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	tableConf := models.TableConfig{PartitionKey: partitionKey, SortKey: sortKey, ActualTable: tableName}
+	var ddls []string
+	for _, indexConf := range indexes {
+		if _, ok := attributes[indexConf.PartitionKey]; !ok {
+			log.Printf("Skipping index %s of table %s: key attribute %s not found", indexConf.SpannerIndexName, tableName, indexConf.PartitionKey)
+			continue
+		}
+		ddls = append(ddls, utils.GenerateIndexDDL(tableConf, indexConf, columns))
+	}
+	return ddls
+}
+
+// fetchTableIndexes returns the key schema and projection of every global and
+// local secondary index of a DynamoDB table.
+func fetchTableIndexes(client *dynamodb.Client, tableName string) ([]models.TableConfig, error) {
+	output, err := client.DescribeTable(context.TODO(), &dynamodb.DescribeTableInput{
+		TableName: aws.String(tableName),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe table %s: %w", tableName, err)
+	}
+
+	toIndexConf := func(name *string, keySchema []dynamodbtypes.KeySchemaElement, projection *dynamodbtypes.Projection) models.TableConfig {
+		indexConf := models.TableConfig{SpannerIndexName: aws.ToString(name), DDBIndexName: aws.ToString(name), ActualTable: tableName}
+		for _, keyElement := range keySchema {
+			switch keyElement.KeyType {
+			case dynamodbtypes.KeyTypeHash:
+				indexConf.PartitionKey = aws.ToString(keyElement.AttributeName)
+			case dynamodbtypes.KeyTypeRange:
+				indexConf.SortKey = aws.ToString(keyElement.AttributeName)
+			}
+		}
+		if projection != nil {
+			indexConf.ProjectionType = string(projection.ProjectionType)
+			indexConf.NonKeyAttributes = projection.NonKeyAttributes
+		}
+		return indexConf
+	}
+
+	var indexes []models.TableConfig
+	for _, gsi := range output.Table.GlobalSecondaryIndexes {
+		indexes = append(indexes, toIndexConf(gsi.IndexName, gsi.KeySchema, gsi.Projection))
+	}
+	for _, lsi := range output.Table.LocalSecondaryIndexes {
+		indexes = append(indexes, toIndexConf(lsi.IndexName, lsi.KeySchema, lsi.Projection))
+	}
+	return indexes, nil
+}
+
 // Generate DDL statement for a specific DynamoDB table
 func generateTableDDL(tableName string, client *dynamodb.Client, limit int32) string {
 	attributes, partitionKey, sortKey, err := fetchTableAttributes(client, tableName, limit)
@@ -180,7 +241,7 @@ func executeSetup(ctx context.Context, adminClient *Admindatabase.DatabaseAdminC
 	}
 
 	// Create the adapter table
-	if err := createTable(ctx, adminClient, databaseName, adapterTableDDL); err != nil {
+	if err := createTable(ctx, adminClient, databaseName, storage.AdapterTableDDL); err != nil {
 		log.Fatalf("Failed to create adapter table: %v", err)
 	}
 
@@ -192,27 +253,41 @@ func executeSetup(ctx context.Context, adminClient *Admindatabase.DatabaseAdminC
 	}
 
 	for _, tableName := range tables {
+		// Tables routed to another database are created there, their metadata stays in the default database
+		tableDatabase, _ := storage.TableDatabase(tableName)
+		if tableDatabase != databaseName {
+			if err := createDatabase(ctx, adminClient, tableDatabase); err != nil {
+				log.Printf("Failed to create database %s for table %s: %v", tableDatabase, tableName, err)
+				continue
+			}
+		}
+
 		// Generate and apply table-specific DDL
 		ddl := generateTableDDL(tableName, client, config.Spanner.DynamoQueryLimit)
-		if err := createTable(ctx, adminClient, databaseName, ddl); err != nil {
+		if err := createTable(ctx, adminClient, tableDatabase, ddl); err != nil {
 			log.Printf("Failed to create table %s: %v", tableName, err)
 			continue
 		}
 
 		// Migrate table metadata to Spanner
-		err := migrateDynamoTableToSpanner(ctx, databaseName, tableName, client, config)
+		err := migrateDynamoTableToSpanner(ctx, databaseName, tableDatabase, tableName, client, config)
 		if err != nil {
 			log.Printf("Error migrating table %s: %v", tableName, err)
 		}
+
+		// Create the secondary indexes of the table
+		if indexDDLs := generateIndexDDLs(tableName, client, config.Spanner.DynamoQueryLimit); len(indexDDLs) > 0 {
+			if err := applySpannerDDL(ctx, tableDatabase, indexDDLs); err != nil {
+				log.Printf("Failed to create indexes for table %s: %v", tableName, err)
+			}
+		}
 	}
 
 	fmt.Println("Initial setup complete.")
 }
 
-// migrateDynamoTableToSpanner migrates a DynamoDB table schema and metadata to Spanner.
-func migrateDynamoTableToSpanner(ctx context.Context, db, tableName string, client *dynamodb.Client, config *models.Config) error {
-	models.SpannerTableMap[tableName] = config.Spanner.InstanceID
-
+// migrateDynamoTableToSpanner migrates a DynamoDB table schema to tableDB and its metadata to db.
+func migrateDynamoTableToSpanner(ctx context.Context, db, tableDB, tableName string, client *dynamodb.Client, config *models.Config) error {
 	// Fetch table attributes and keys from DynamoDB
 	attributes, partitionKey, sortKey, err := fetchTableAttributes(client, tableName, int32(config.Spanner.DynamoQueryLimit))
 	if err != nil {
@@ -220,7 +295,7 @@ func migrateDynamoTableToSpanner(ctx context.Context, db, tableName string, clie
 	}
 
 	// Fetch the current Spanner schema for the table
-	spannerSchema, err := fetchSpannerSchema(ctx, db, tableName)
+	spannerSchema, err := fetchSpannerSchema(ctx, tableDB, tableName)
 	if err != nil {
 		return fmt.Errorf("failed to fetch Spanner schema for table %s: %v", tableName, err)
 	}
@@ -234,7 +309,7 @@ func migrateDynamoTableToSpanner(ctx context.Context, db, tableName string, clie
 		}
 	}
 	if len(ddlStatements) > 0 {
-		if err := applySpannerDDL(ctx, db, ddlStatements); err != nil {
+		if err := applySpannerDDL(ctx, tableDB, ddlStatements); err != nil {
 			return fmt.Errorf("failed to apply DDL to table %s: %v", tableName, err)
 		}
 		log.Printf("Schema updated for table %s in Spanner.", tableName)
@@ -250,7 +325,7 @@ func migrateDynamoTableToSpanner(ctx context.Context, db, tableName string, clie
 
 	// Apply DDL to drop removed columns
 	if len(dropColumnStatements) > 0 {
-		if err := applySpannerDDL(ctx, db, dropColumnStatements); err != nil {
+		if err := applySpannerDDL(ctx, tableDB, dropColumnStatements); err != nil {
 			return fmt.Errorf("failed to apply DROP COLUMN DDL to table %s: %v", tableName, err)
 		}
 		log.Printf("Removed columns from table %s in Spanner.", tableName)
@@ -78,6 +78,8 @@ func loadConfig(filename string) (*models.Config, error) {
 
 // GetTableConf returns table configuration from global map object
 func GetTableConf(tableName string) (models.TableConfig, error) {
+	models.DbConfigMapMu.RLock()
+	defer models.DbConfigMapMu.RUnlock()
 	tableConf, ok := models.DbConfigMap[tableName]
 	if !ok {
 		return models.TableConfig{}, errors.New("ResourceNotFoundException", tableName)
@@ -93,3 +95,33 @@ func GetTableConf(tableName string) (models.TableConfig, error) {
 	}
 	return models.TableConfig{}, errors.New("ResourceNotFoundException", tableName)
 }
+
+// TableNames returns the names of the tables in the global map object
+func TableNames() []string {
+	models.DbConfigMapMu.RLock()
+	defer models.DbConfigMapMu.RUnlock()
+	tableNames := make([]string, 0, len(models.DbConfigMap))
+	for tableName := range models.DbConfigMap {
+		tableNames = append(tableNames, tableName)
+	}
+	return tableNames
+}
+
+// LoadBootstrapTables reads the tables seeded by the bootstrap from a YAML or JSON file
+func LoadBootstrapTables(filename string) (map[string]models.BootstrapTable, error) {
+	data, err := readFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tables file: %w", err)
+	}
+	// JSON documents are valid YAML, so both formats are read by the YAML decoder
+	var tables map[string]models.BootstrapTable
+	if err := yaml.Unmarshal(data, &tables); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal tables file: %w", err)
+	}
+	for name, table := range tables {
+		if table.PartitionKey == "" {
+			return nil, fmt.Errorf("table %s has no partitionKey", name)
+		}
+	}
+	return tables, nil
+}
@@ -15,6 +15,7 @@
 package config
 
 import (
+	"os"
 	"testing"
 
 	"github.com/cloudspannerecosystem/dynamodb-adapter/models"
@@ -90,3 +91,19 @@ func TestGetTableConf(t *testing.T) {
 		assert.Equal(t, got, tc.want)
 	}
 }
+
+func TestLoadBootstrapTables(t *testing.T) {
+	tables, err := LoadBootstrapTables("../examples/adapter/config-files/staging/tables.json")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "PK", tables["Customer_Order"].PartitionKey)
+	assert.Equal(t, "SK", tables["Customer_Order"].SortKey)
+	assert.Equal(t, "N", tables["Customer_Order"].AttributeTypes["order_amount"])
+	assert.Equal(t, "customer_id", tables["Customer_Order"].Indices["By_customer"].PartitionKey)
+
+	readFile = func(string) ([]byte, error) {
+		return []byte("orders:\n  sortKey: created\n"), nil
+	}
+	defer func() { readFile = os.ReadFile }()
+	_, err = LoadBootstrapTables("tables.yaml")
+	assert.NotEqual(t, nil, err)
+}
@@ -0,0 +1,129 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/cloudspannerecosystem/dynamodb-adapter/models"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/pkg/errors"
+)
+
+// DefaultTenantHeader is the request header read when tenancy.header is not set
+const DefaultTenantHeader = "X-Tenant-Id"
+
+type tenantKey struct{}
+
+// TenantHeader returns the name of the request header carrying the tenant
+func TenantHeader() string {
+	if models.GlobalConfig == nil || models.GlobalConfig.Tenancy.Header == "" {
+		return DefaultTenantHeader
+	}
+	return models.GlobalConfig.Tenancy.Header
+}
+
+// ResolveTenant finds the tenant of a request from the AWS access key id, the
+// tenant header or the host name, in that order. A tenant bound to the access
+// key cannot be overridden by the header: a header naming another tenant is
+// rejected, as is one naming an unknown tenant. Requests matching none of them
+// use the default, empty namespace.
+func ResolveTenant(name, accessKey, host string) (models.Tenant, error) {
+	if models.GlobalConfig == nil {
+		return models.Tenant{}, nil
+	}
+	tenants := models.GlobalConfig.Tenancy.Tenants
+	if accessKey != "" {
+		for _, tenant := range tenants {
+			if slices.Contains(tenant.AccessKeys, accessKey) {
+				if name != "" && name != tenant.Name {
+					return models.Tenant{}, errors.New("AccessDeniedException", "Access key is not allowed to use tenant "+name)
+				}
+				return tenant, nil
+			}
+		}
+	}
+	if name != "" {
+		for _, tenant := range tenants {
+			if tenant.Name == name {
+				return tenant, nil
+			}
+		}
+		return models.Tenant{}, errors.New("UnrecognizedClientException", "Unknown tenant "+name)
+	}
+	if host != "" {
+		for _, tenant := range tenants {
+			if slices.ContainsFunc(tenant.Hosts, func(h string) bool { return strings.EqualFold(h, host) }) {
+				return tenant, nil
+			}
+		}
+	}
+	return models.Tenant{}, nil
+}
+
+// WithTenant returns a copy of ctx carrying tenant
+func WithTenant(ctx context.Context, tenant models.Tenant) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// TenantFromContext returns the tenant of the request, or the default tenant
+func TenantFromContext(ctx context.Context) models.Tenant {
+	tenant, _ := ctx.Value(tenantKey{}).(models.Tenant)
+	return tenant
+}
+
+// TenantTableName returns the stored name of a table of tenant
+func TenantTableName(tenant models.Tenant, tableName string) string {
+	return tenant.Prefix + tableName + tenant.Suffix
+}
+
+// TableName returns the stored name of a table named by a client, in the
+// namespace of the tenant of ctx
+func TableName(ctx context.Context, tableName string) string {
+	return TenantTableName(TenantFromContext(ctx), tableName)
+}
+
+// GetTenantTableConf returns the config of a table named by a client, in the
+// namespace of the tenant of ctx. Its ActualTable is the stored table, which
+// is what the table is named as from there on.
+func GetTenantTableConf(ctx context.Context, tableName string) (models.TableConfig, error) {
+	tableConf, err := GetTableConf(TableName(ctx, tableName))
+	if err != nil {
+		return models.TableConfig{}, errors.New("ResourceNotFoundException", tableName)
+	}
+	return tableConf, nil
+}
+
+// TenantLogicalName returns the table name seen by tenant for a stored table,
+// and false when the table is outside of the tenant's namespace.
+func TenantLogicalName(tenant models.Tenant, tableName string) (string, bool) {
+	if !tenantOwns(tenant, tableName) {
+		return tableName, false
+	}
+	if tenant.Prefix == "" && tenant.Suffix == "" && models.GlobalConfig != nil {
+		for _, other := range models.GlobalConfig.Tenancy.Tenants {
+			if (other.Prefix != "" || other.Suffix != "") && tenantOwns(other, tableName) {
+				return tableName, false
+			}
+		}
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(tableName, tenant.Prefix), tenant.Suffix), true
+}
+
+func tenantOwns(tenant models.Tenant, tableName string) bool {
+	return len(tableName) > len(tenant.Prefix)+len(tenant.Suffix) &&
+		strings.HasPrefix(tableName, tenant.Prefix) && strings.HasSuffix(tableName, tenant.Suffix)
+}
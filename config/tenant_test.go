@@ -0,0 +1,111 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cloudspannerecosystem/dynamodb-adapter/models"
+	"gopkg.in/go-playground/assert.v1"
+)
+
+func setupTenants(t *testing.T) {
+	original := models.GlobalConfig
+	models.GlobalConfig = &models.Config{Tenancy: models.TenancyConfig{Tenants: []models.Tenant{
+		{Name: "dev", Prefix: "dev-", AccessKeys: []string{"AKIDDEV"}, Hosts: []string{"dev.example.com"}},
+		{Name: "qa", Suffix: "_qa", Hosts: []string{"qa.example.com"}},
+	}}}
+	t.Cleanup(func() { models.GlobalConfig = original })
+}
+
+func TestResolveTenant(t *testing.T) {
+	setupTenants(t)
+
+	tests := []struct {
+		testName  string
+		header    string
+		accessKey string
+		host      string
+		want      string
+		wantErr   bool
+	}{
+		{"header", "qa", "AKIDOTHER", "dev.example.com", "qa", false},
+		{"access key", "", "AKIDDEV", "qa.example.com", "dev", false},
+		{"header naming the tenant of the access key", "dev", "AKIDDEV", "", "dev", false},
+		{"header overriding the tenant of the access key", "qa", "AKIDDEV", "", "", true},
+		{"host", "", "AKIDOTHER", "QA.example.com", "qa", false},
+		{"default", "", "AKIDOTHER", "localhost", "", false},
+		{"unknown tenant", "prod", "", "", "", true},
+	}
+
+	for _, tc := range tests {
+		tenant, err := ResolveTenant(tc.header, tc.accessKey, tc.host)
+		assert.Equal(t, tc.wantErr, err != nil)
+		assert.Equal(t, tc.want, tenant.Name)
+	}
+}
+
+func TestTenantLogicalName(t *testing.T) {
+	setupTenants(t)
+	dev, qa := models.GlobalConfig.Tenancy.Tenants[0], models.GlobalConfig.Tenancy.Tenants[1]
+
+	tests := []struct {
+		testName  string
+		tenant    models.Tenant
+		tableName string
+		want      string
+		wantOK    bool
+	}{
+		{"prefix", dev, "dev-orders", "orders", true},
+		{"suffix", qa, "orders_qa", "orders", true},
+		{"other tenant", dev, "orders_qa", "orders_qa", false},
+		{"default tenant", models.Tenant{}, "orders", "orders", true},
+		{"default tenant excludes namespaces", models.Tenant{}, "dev-orders", "dev-orders", false},
+	}
+
+	for _, tc := range tests {
+		got, ok := TenantLogicalName(tc.tenant, tc.tableName)
+		assert.Equal(t, tc.want, got)
+		assert.Equal(t, tc.wantOK, ok)
+		if ok {
+			assert.Equal(t, tc.tableName, TenantTableName(tc.tenant, got))
+		}
+	}
+}
+
+func TestGetTenantTableConf(t *testing.T) {
+	setupTenants(t)
+	originalConfigMap := models.DbConfigMap
+	models.DbConfigMap = map[string]models.TableConfig{
+		"orders":     {PartitionKey: "id"},
+		"dev-orders": {PartitionKey: "order_id"},
+	}
+	t.Cleanup(func() { models.DbConfigMap = originalConfigMap })
+
+	tableConf, err := GetTenantTableConf(context.Background(), "orders")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "orders", tableConf.ActualTable)
+
+	ctx := WithTenant(context.Background(), models.GlobalConfig.Tenancy.Tenants[0])
+	tableConf, err = GetTenantTableConf(ctx, "orders")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "dev-orders", tableConf.ActualTable)
+	assert.Equal(t, "order_id", tableConf.PartitionKey)
+
+	ctx = WithTenant(context.Background(), models.GlobalConfig.Tenancy.Tenants[1])
+	_, err = GetTenantTableConf(ctx, "orders")
+	assert.NotEqual(t, nil, err)
+}
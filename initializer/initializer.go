@@ -18,8 +18,10 @@ package initializer
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/cloudspannerecosystem/dynamodb-adapter/config"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/models"
 	"github.com/cloudspannerecosystem/dynamodb-adapter/service/services"
 	"github.com/cloudspannerecosystem/dynamodb-adapter/service/spanner"
 	"github.com/cloudspannerecosystem/dynamodb-adapter/storage"
@@ -33,6 +35,9 @@ func InitAll(filepath string) error {
 	if err != nil {
 		return err
 	}
+	if err = bootstrap(); err != nil {
+		return err
+	}
 	err = spanner.ParseDDL(true)
 	if err != nil {
 		return err
@@ -40,3 +45,29 @@ func InitAll(filepath string) error {
 	services.StartConfigManager()
 	return nil
 }
+
+// bootstrap creates the adapter metadata tables and seeds them from the tables
+// file when it is enabled in the configuration. The memory backend starts out
+// empty, so its metadata tables are always created.
+func bootstrap() error {
+	conf := models.GlobalConfig.Bootstrap
+	memory := models.GlobalConfig.Storage.Backend == models.StorageBackendMemory
+	if !conf.Enabled && !memory {
+		return nil
+	}
+	tables := map[string]models.BootstrapTable{}
+	if conf.Enabled && conf.TablesFile != "" {
+		var err error
+		tables, err = config.LoadBootstrapTables(conf.TablesFile)
+		if err != nil {
+			return err
+		}
+	}
+	if memory {
+		return storage.GetMemoryStorageInstance().Bootstrap(context.Background(), tables)
+	}
+	if err := storage.GetStorageInstance().Bootstrap(context.Background(), tables); err != nil {
+		return fmt.Errorf("failed to bootstrap Spanner: %w", err)
+	}
+	return nil
+}
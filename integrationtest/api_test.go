@@ -124,7 +124,7 @@ var (
 			"employee": {},
 		},
 	}
-	TestGetBatch2Output = `{"Responses":{"employee":[]}}`
+	TestGetBatch2Output = `{"Responses":{"employee":[]},"UnprocessedKeys":{}}`
 
 	TestGetBatch3Name = "3: Keys present for 1 table"
 	TestGetBatch3     = models.BatchGetMeta{
@@ -138,7 +138,7 @@ var (
 			},
 		},
 	}
-	TestGetBatch3Output = `{"Responses":{"employee":[{"address":{"S":"Shamli"},"age":{"N":"10"},"emp_id":{"N":"1"},"first_name":{"S":"Marc"},"last_name":{"S":"Richards"},"phone_numbers":{"SS":["+1111111111","+1222222222"]},"profile_pics":{"BS":["U29tZUJ5dGVzRGF0YTE=","U29tZUJ5dGVzRGF0YTI="]},"salaries":{"NS":["1000.5","2000.75"]}},{"address":{"S":"Pune"},"age":{"N":"30"},"emp_id":{"N":"3"},"first_name":{"S":"Alice"},"last_name":{"S":"Trentor"},"phone_numbers":{"SS":["+1444444444","+1555555555"]},"profile_pics":{"BS":["U29tZUJ5dGVzRGF0YTQ=","U29tZUJ5dGVzRGF0YTU="]},"salaries":{"NS":["4000.25","5000.5","6000.75"]}},{"address":{"S":"London"},"age":{"N":"50"},"emp_id":{"N":"5"},"first_name":{"S":"David"},"last_name":{"S":"Lomond"},"phone_numbers":{"SS":["+1777777777","+1888888888","+1999999999"]},"profile_pics":{"BS":["U29tZUJ5dGVzRGF0YTc=","U29tZUJ5dGVzRGF0YTg="]},"salaries":{"NS":["9000.5"]}}]}}`
+	TestGetBatch3Output = `{"Responses":{"employee":[{"address":{"S":"Shamli"},"age":{"N":"10"},"emp_id":{"N":"1"},"first_name":{"S":"Marc"},"last_name":{"S":"Richards"},"phone_numbers":{"SS":["+1111111111","+1222222222"]},"profile_pics":{"BS":["U29tZUJ5dGVzRGF0YTE=","U29tZUJ5dGVzRGF0YTI="]},"salaries":{"NS":["1000.5","2000.75"]}},{"address":{"S":"Pune"},"age":{"N":"30"},"emp_id":{"N":"3"},"first_name":{"S":"Alice"},"last_name":{"S":"Trentor"},"phone_numbers":{"SS":["+1444444444","+1555555555"]},"profile_pics":{"BS":["U29tZUJ5dGVzRGF0YTQ=","U29tZUJ5dGVzRGF0YTU="]},"salaries":{"NS":["4000.25","5000.5","6000.75"]}},{"address":{"S":"London"},"age":{"N":"50"},"emp_id":{"N":"5"},"first_name":{"S":"David"},"last_name":{"S":"Lomond"},"phone_numbers":{"SS":["+1777777777","+1888888888","+1999999999"]},"profile_pics":{"BS":["U29tZUJ5dGVzRGF0YTc=","U29tZUJ5dGVzRGF0YTg="]},"salaries":{"NS":["9000.5"]}}]},"UnprocessedKeys":{}}`
 
 	TestGetBatch4Name = "4: Keys present for 2 table"
 	TestGetBatch4     = models.BatchGetMeta{
@@ -158,7 +158,7 @@ var (
 			},
 		},
 	}
-	TestGetBatch4Output = `{"Responses":{"department":[{"d_id":{"N":"100"},"d_name":{"S":"Engineering"},"d_specialization":{"S":"CSE, ECE, Civil"}},{"d_id":{"N":"300"},"d_name":{"S":"Culture"},"d_specialization":{"S":"History"}}],"employee":[{"address":{"S":"Shamli"},"age":{"N":"10"},"emp_id":{"N":"1"},"first_name":{"S":"Marc"},"last_name":{"S":"Richards"},"phone_numbers":{"SS":["+1111111111","+1222222222"]},"profile_pics":{"BS":["U29tZUJ5dGVzRGF0YTE=","U29tZUJ5dGVzRGF0YTI="]},"salaries":{"NS":["1000.5","2000.75"]}},{"address":{"S":"Pune"},"age":{"N":"30"},"emp_id":{"N":"3"},"first_name":{"S":"Alice"},"last_name":{"S":"Trentor"},"phone_numbers":{"SS":["+1444444444","+1555555555"]},"profile_pics":{"BS":["U29tZUJ5dGVzRGF0YTQ=","U29tZUJ5dGVzRGF0YTU="]},"salaries":{"NS":["4000.25","5000.5","6000.75"]}},{"address":{"S":"London"},"age":{"N":"50"},"emp_id":{"N":"5"},"first_name":{"S":"David"},"last_name":{"S":"Lomond"},"phone_numbers":{"SS":["+1777777777","+1888888888","+1999999999"]},"profile_pics":{"BS":["U29tZUJ5dGVzRGF0YTc=","U29tZUJ5dGVzRGF0YTg="]},"salaries":{"NS":["9000.5"]}}]}}`
+	TestGetBatch4Output = `{"Responses":{"department":[{"d_id":{"N":"100"},"d_name":{"S":"Engineering"},"d_specialization":{"S":"CSE, ECE, Civil"}},{"d_id":{"N":"300"},"d_name":{"S":"Culture"},"d_specialization":{"S":"History"}}],"employee":[{"address":{"S":"Shamli"},"age":{"N":"10"},"emp_id":{"N":"1"},"first_name":{"S":"Marc"},"last_name":{"S":"Richards"},"phone_numbers":{"SS":["+1111111111","+1222222222"]},"profile_pics":{"BS":["U29tZUJ5dGVzRGF0YTE=","U29tZUJ5dGVzRGF0YTI="]},"salaries":{"NS":["1000.5","2000.75"]}},{"address":{"S":"Pune"},"age":{"N":"30"},"emp_id":{"N":"3"},"first_name":{"S":"Alice"},"last_name":{"S":"Trentor"},"phone_numbers":{"SS":["+1444444444","+1555555555"]},"profile_pics":{"BS":["U29tZUJ5dGVzRGF0YTQ=","U29tZUJ5dGVzRGF0YTU="]},"salaries":{"NS":["4000.25","5000.5","6000.75"]}},{"address":{"S":"London"},"age":{"N":"50"},"emp_id":{"N":"5"},"first_name":{"S":"David"},"last_name":{"S":"Lomond"},"phone_numbers":{"SS":["+1777777777","+1888888888","+1999999999"]},"profile_pics":{"BS":["U29tZUJ5dGVzRGF0YTc=","U29tZUJ5dGVzRGF0YTg="]},"salaries":{"NS":["9000.5"]}}]},"UnprocessedKeys":{}}`
 
 	TestGetBatch5Name = "5: ProjectionExpression without ExpressionAttributeNames for 1 table"
 	TestGetBatch5     = models.BatchGetMeta{
@@ -173,7 +173,7 @@ var (
 			},
 		},
 	}
-	TestGetBatch5Output = `{"Responses":{"employee":[{"address":{"S":"Shamli"},"emp_id":{"N":"1"},"first_name":{"S":"Marc"},"last_name":{"S":"Richards"},"phone_numbers":{"SS":["+1111111111","+1222222222"]},"profile_pics":{"BS":["U29tZUJ5dGVzRGF0YTE=","U29tZUJ5dGVzRGF0YTI="]}},{"address":{"S":"Pune"},"emp_id":{"N":"3"},"first_name":{"S":"Alice"},"last_name":{"S":"Trentor"},"phone_numbers":{"SS":["+1444444444","+1555555555"]},"profile_pics":{"BS":["U29tZUJ5dGVzRGF0YTQ=","U29tZUJ5dGVzRGF0YTU="]}},{"address":{"S":"London"},"emp_id":{"N":"5"},"first_name":{"S":"David"},"last_name":{"S":"Lomond"},"phone_numbers":{"SS":["+1777777777","+1888888888","+1999999999"]},"profile_pics":{"BS":["U29tZUJ5dGVzRGF0YTc=","U29tZUJ5dGVzRGF0YTg="]}}]}}`
+	TestGetBatch5Output = `{"Responses":{"employee":[{"address":{"S":"Shamli"},"emp_id":{"N":"1"},"first_name":{"S":"Marc"},"last_name":{"S":"Richards"},"phone_numbers":{"SS":["+1111111111","+1222222222"]},"profile_pics":{"BS":["U29tZUJ5dGVzRGF0YTE=","U29tZUJ5dGVzRGF0YTI="]}},{"address":{"S":"Pune"},"emp_id":{"N":"3"},"first_name":{"S":"Alice"},"last_name":{"S":"Trentor"},"phone_numbers":{"SS":["+1444444444","+1555555555"]},"profile_pics":{"BS":["U29tZUJ5dGVzRGF0YTQ=","U29tZUJ5dGVzRGF0YTU="]}},{"address":{"S":"London"},"emp_id":{"N":"5"},"first_name":{"S":"David"},"last_name":{"S":"Lomond"},"phone_numbers":{"SS":["+1777777777","+1888888888","+1999999999"]},"profile_pics":{"BS":["U29tZUJ5dGVzRGF0YTc=","U29tZUJ5dGVzRGF0YTg="]}}]},"UnprocessedKeys":{}}`
 
 	TestGetBatch6Name = "6: ProjectionExpression without ExpressionAttributeNames for 2 table"
 	TestGetBatch6     = models.BatchGetMeta{
@@ -195,7 +195,7 @@ var (
 			},
 		},
 	}
-	TestGetBatch6Output = `{"Responses":{"department":[{"d_id":{"N":"100"},"d_name":{"S":"Engineering"},"d_specialization":{"S":"CSE, ECE, Civil"}},{"d_id":{"N":"300"},"d_name":{"S":"Culture"},"d_specialization":{"S":"History"}}],"employee":[{"address":{"S":"Shamli"},"emp_id":{"N":"1"},"first_name":{"S":"Marc"},"last_name":{"S":"Richards"},"phone_numbers":{"SS":["+1111111111","+1222222222"]},"profile_pics":{"BS":["U29tZUJ5dGVzRGF0YTE=","U29tZUJ5dGVzRGF0YTI="]}},{"address":{"S":"Pune"},"emp_id":{"N":"3"},"first_name":{"S":"Alice"},"last_name":{"S":"Trentor"},"phone_numbers":{"SS":["+1444444444","+1555555555"]},"profile_pics":{"BS":["U29tZUJ5dGVzRGF0YTQ=","U29tZUJ5dGVzRGF0YTU="]}},{"address":{"S":"London"},"emp_id":{"N":"5"},"first_name":{"S":"David"},"last_name":{"S":"Lomond"},"phone_numbers":{"SS":["+1777777777","+1888888888","+1999999999"]},"profile_pics":{"BS":["U29tZUJ5dGVzRGF0YTc=","U29tZUJ5dGVzRGF0YTg="]}}]}}`
+	TestGetBatch6Output = `{"Responses":{"department":[{"d_id":{"N":"100"},"d_name":{"S":"Engineering"},"d_specialization":{"S":"CSE, ECE, Civil"}},{"d_id":{"N":"300"},"d_name":{"S":"Culture"},"d_specialization":{"S":"History"}}],"employee":[{"address":{"S":"Shamli"},"emp_id":{"N":"1"},"first_name":{"S":"Marc"},"last_name":{"S":"Richards"},"phone_numbers":{"SS":["+1111111111","+1222222222"]},"profile_pics":{"BS":["U29tZUJ5dGVzRGF0YTE=","U29tZUJ5dGVzRGF0YTI="]}},{"address":{"S":"Pune"},"emp_id":{"N":"3"},"first_name":{"S":"Alice"},"last_name":{"S":"Trentor"},"phone_numbers":{"SS":["+1444444444","+1555555555"]},"profile_pics":{"BS":["U29tZUJ5dGVzRGF0YTQ=","U29tZUJ5dGVzRGF0YTU="]}},{"address":{"S":"London"},"emp_id":{"N":"5"},"first_name":{"S":"David"},"last_name":{"S":"Lomond"},"phone_numbers":{"SS":["+1777777777","+1888888888","+1999999999"]},"profile_pics":{"BS":["U29tZUJ5dGVzRGF0YTc=","U29tZUJ5dGVzRGF0YTg="]}}]},"UnprocessedKeys":{}}`
 
 	TestGetBatch7Name = "7: ProjectionExpression with ExpressionAttributeNames for 1 table"
 	TestGetBatch7     = models.BatchGetMeta{
@@ -214,7 +214,7 @@ var (
 			},
 		},
 	}
-	TestGetBatch7Output = `{"Responses":{"employee":[{"address":{"S":"Shamli"},"emp_id":{"N":"1"},"first_name":{"S":"Marc"},"last_name":{"S":"Richards"},"phone_numbers":{"SS":["+1111111111","+1222222222"]},"profile_pics":{"BS":["U29tZUJ5dGVzRGF0YTE=","U29tZUJ5dGVzRGF0YTI="]}},{"address":{"S":"Pune"},"emp_id":{"N":"3"},"first_name":{"S":"Alice"},"last_name":{"S":"Trentor"},"phone_numbers":{"SS":["+1444444444","+1555555555"]},"profile_pics":{"BS":["U29tZUJ5dGVzRGF0YTQ=","U29tZUJ5dGVzRGF0YTU="]}},{"address":{"S":"London"},"emp_id":{"N":"5"},"first_name":{"S":"David"},"last_name":{"S":"Lomond"},"phone_numbers":{"SS":["+1777777777","+1888888888","+1999999999"]},"profile_pics":{"BS":["U29tZUJ5dGVzRGF0YTc=","U29tZUJ5dGVzRGF0YTg="]}}]}}`
+	TestGetBatch7Output = `{"Responses":{"employee":[{"address":{"S":"Shamli"},"emp_id":{"N":"1"},"first_name":{"S":"Marc"},"last_name":{"S":"Richards"},"phone_numbers":{"SS":["+1111111111","+1222222222"]},"profile_pics":{"BS":["U29tZUJ5dGVzRGF0YTE=","U29tZUJ5dGVzRGF0YTI="]}},{"address":{"S":"Pune"},"emp_id":{"N":"3"},"first_name":{"S":"Alice"},"last_name":{"S":"Trentor"},"phone_numbers":{"SS":["+1444444444","+1555555555"]},"profile_pics":{"BS":["U29tZUJ5dGVzRGF0YTQ=","U29tZUJ5dGVzRGF0YTU="]}},{"address":{"S":"London"},"emp_id":{"N":"5"},"first_name":{"S":"David"},"last_name":{"S":"Lomond"},"phone_numbers":{"SS":["+1777777777","+1888888888","+1999999999"]},"profile_pics":{"BS":["U29tZUJ5dGVzRGF0YTc=","U29tZUJ5dGVzRGF0YTg="]}}]},"UnprocessedKeys":{}}`
 
 	TestGetBatch8Name = "8: ProjectionExpression with ExpressionAttributeNames for 2 table"
 	TestGetBatch8     = models.BatchGetMeta{
@@ -244,7 +244,7 @@ var (
 			},
 		},
 	}
-	TestGetBatch8Output = `{"Responses":{"department":[{"d_id":{"N":"100"},"d_name":{"S":"Engineering"},"d_specialization":{"S":"CSE, ECE, Civil"}},{"d_id":{"N":"300"},"d_name":{"S":"Culture"},"d_specialization":{"S":"History"}}],"employee":[{"address":{"S":"Shamli"},"emp_id":{"N":"1"},"first_name":{"S":"Marc"},"last_name":{"S":"Richards"},"phone_numbers":{"SS":["+1111111111","+1222222222"]},"profile_pics":{"BS":["U29tZUJ5dGVzRGF0YTE=","U29tZUJ5dGVzRGF0YTI="]}},{"address":{"S":"Pune"},"emp_id":{"N":"3"},"first_name":{"S":"Alice"},"last_name":{"S":"Trentor"},"phone_numbers":{"SS":["+1444444444","+1555555555"]},"profile_pics":{"BS":["U29tZUJ5dGVzRGF0YTQ=","U29tZUJ5dGVzRGF0YTU="]}},{"address":{"S":"London"},"emp_id":{"N":"5"},"first_name":{"S":"David"},"last_name":{"S":"Lomond"},"phone_numbers":{"SS":["+1777777777","+1888888888","+1999999999"]},"profile_pics":{"BS":["U29tZUJ5dGVzRGF0YTc=","U29tZUJ5dGVzRGF0YTg="]}}]}}`
+	TestGetBatch8Output = `{"Responses":{"department":[{"d_id":{"N":"100"},"d_name":{"S":"Engineering"},"d_specialization":{"S":"CSE, ECE, Civil"}},{"d_id":{"N":"300"},"d_name":{"S":"Culture"},"d_specialization":{"S":"History"}}],"employee":[{"address":{"S":"Shamli"},"emp_id":{"N":"1"},"first_name":{"S":"Marc"},"last_name":{"S":"Richards"},"phone_numbers":{"SS":["+1111111111","+1222222222"]},"profile_pics":{"BS":["U29tZUJ5dGVzRGF0YTE=","U29tZUJ5dGVzRGF0YTI="]}},{"address":{"S":"Pune"},"emp_id":{"N":"3"},"first_name":{"S":"Alice"},"last_name":{"S":"Trentor"},"phone_numbers":{"SS":["+1444444444","+1555555555"]},"profile_pics":{"BS":["U29tZUJ5dGVzRGF0YTQ=","U29tZUJ5dGVzRGF0YTU="]}},{"address":{"S":"London"},"emp_id":{"N":"5"},"first_name":{"S":"David"},"last_name":{"S":"Lomond"},"phone_numbers":{"SS":["+1777777777","+1888888888","+1999999999"]},"profile_pics":{"BS":["U29tZUJ5dGVzRGF0YTc=","U29tZUJ5dGVzRGF0YTg="]}}]},"UnprocessedKeys":{}}`
 
 	TestGetBatch9Name = "9: ProjectionExpression but ExpressionAttributeNames not present"
 	TestGetBatch9     = models.BatchGetMeta{
@@ -259,7 +259,7 @@ var (
 			},
 		},
 	}
-	TestGetBatch9Output = `{"Responses":{"employee":[{"address":{"S":"Shamli"},"first_name":{"S":"Marc"},"profile_pics":{"BS":["U29tZUJ5dGVzRGF0YTE=","U29tZUJ5dGVzRGF0YTI="]}},{"address":{"S":"Pune"},"first_name":{"S":"Alice"},"profile_pics":{"BS":["U29tZUJ5dGVzRGF0YTQ=","U29tZUJ5dGVzRGF0YTU="]}},{"address":{"S":"London"},"first_name":{"S":"David"},"profile_pics":{"BS":["U29tZUJ5dGVzRGF0YTc=","U29tZUJ5dGVzRGF0YTg="]}}]}}`
+	TestGetBatch9Output = `{"Responses":{"employee":[{"address":{"S":"Shamli"},"first_name":{"S":"Marc"},"profile_pics":{"BS":["U29tZUJ5dGVzRGF0YTE=","U29tZUJ5dGVzRGF0YTI="]}},{"address":{"S":"Pune"},"first_name":{"S":"Alice"},"profile_pics":{"BS":["U29tZUJ5dGVzRGF0YTQ=","U29tZUJ5dGVzRGF0YTU="]}},{"address":{"S":"London"},"first_name":{"S":"David"},"profile_pics":{"BS":["U29tZUJ5dGVzRGF0YTc=","U29tZUJ5dGVzRGF0YTg="]}}]},"UnprocessedKeys":{}}`
 
 	TestGetBatch10Name = "10: Wrong Keys"
 	TestGetBatch10     = models.BatchGetMeta{
@@ -295,8 +295,8 @@ var (
 			},
 		},
 	}
-	TestGetBatchForListOutput = `{"Responses":{"test_table":[{"category":{"S":"category"},"id":{"S":"testing"},"list_type":{"L":[{"S":"John Doe"},{"S":"62536"},{"BOOL":true}]},"rank_list":{"S":"rank_list"},"updated_at":{"S":"2024-12-04T11:02:02Z"}},{"category":{"S":"category1"},"id":{"S":"id"},"list_type":{"L":[{"S":"string_value"},{"S":"12345"},{"BOOL":true},{"L":[{"N":"1"},{"N":"2"},{"N":"3"}]},{"S":"testing"}]},"rank_list":{"S":"rank_list1"},"updated_at":{"S":"2024-12-04T11:02:02Z"}},{"category":{"S":"category2"},"id":{"S":"id2"},"list_type":{"L":[{"S":"test"},{"S":"dummy_value"},{"S":"62536"}]},"rank_list":{"S":"rank_list2"},"updated_at":{"S":"2024-12-04T11:02:02Z"}}]}}`
-	TestGetBatch11Output      = `{"Responses":{"mapdynamo":[{"address":{"M":{"active":{"BOOL":true},"additional_details":{"M":{"additional_details_2":{"M":{"landmark_field":{"S":"near water tank road"},"landmark_field_number":{"N":"1001"}}},"apartment_number":{"S":"5B"},"landmark":{"S":"Near Central Park"},"landmark notes":{"B":"YmluYXJ5X2RhdGE="}}},"mobilenumber":{"N":"9035599089"},"notes":{"B":"YmluYXJ5X2RhdGE="},"permanent_address":{"S":"789 Elm St, Springfield, SP"},"present_address":{"S":"101 Maple Ave, Metropolis, MP"}}},"contact_ranking_list":{"S":"1,2,3"},"context":{"S":"user-profile"},"guid":{"S":"123e4567-e89b-12d3-a456-value001"},"name":{"S":"Jane Smith"}}]}}`
+	TestGetBatchForListOutput = `{"Responses":{"test_table":[{"category":{"S":"category"},"id":{"S":"testing"},"list_type":{"L":[{"S":"John Doe"},{"S":"62536"},{"BOOL":true}]},"rank_list":{"S":"rank_list"},"updated_at":{"S":"2024-12-04T11:02:02Z"}},{"category":{"S":"category1"},"id":{"S":"id"},"list_type":{"L":[{"S":"string_value"},{"S":"12345"},{"BOOL":true},{"L":[{"N":"1"},{"N":"2"},{"N":"3"}]},{"S":"testing"}]},"rank_list":{"S":"rank_list1"},"updated_at":{"S":"2024-12-04T11:02:02Z"}},{"category":{"S":"category2"},"id":{"S":"id2"},"list_type":{"L":[{"S":"test"},{"S":"dummy_value"},{"S":"62536"}]},"rank_list":{"S":"rank_list2"},"updated_at":{"S":"2024-12-04T11:02:02Z"}}]},"UnprocessedKeys":{}}`
+	TestGetBatch11Output      = `{"Responses":{"mapdynamo":[{"address":{"M":{"active":{"BOOL":true},"additional_details":{"M":{"additional_details_2":{"M":{"landmark_field":{"S":"near water tank road"},"landmark_field_number":{"N":"1001"}}},"apartment_number":{"S":"5B"},"landmark":{"S":"Near Central Park"},"landmark notes":{"B":"YmluYXJ5X2RhdGE="}}},"mobilenumber":{"N":"9035599089"},"notes":{"B":"YmluYXJ5X2RhdGE="},"permanent_address":{"S":"789 Elm St, Springfield, SP"},"present_address":{"S":"101 Maple Ave, Metropolis, MP"}}},"contact_ranking_list":{"S":"1,2,3"},"context":{"S":"user-profile"},"guid":{"S":"123e4567-e89b-12d3-a456-value001"},"name":{"S":"Jane Smith"}}]},"UnprocessedKeys":{}}`
 )
 
 // test Data for Query API
@@ -866,7 +866,7 @@ var (
 		},
 	}
 
-	PutItemTestCase2Output = `{"Attributes":{"address":{"S":"Shamli"},"age":{"N":"10"},"emp_id":{"N":"1"},"first_name":{"S":"Marc"},"last_name":{"S":"Richards"},"phone_numbers":{"SS":["+1111111111","+1222222222"]},"profile_pics":{"BS":["U29tZUJ5dGVzRGF0YTE=","U29tZUJ5dGVzRGF0YTI="]},"salaries":{"NS":["1000.5","2000.75"]}}}`
+	PutItemTestCase2Output = `{}`
 
 	PutItemTestCase3Name = "3: ConditionExpression with ExpressionAttributeValues & ExpressionAttributeNames"
 	PutItemTestCase3     = models.Meta{
@@ -883,7 +883,7 @@ var (
 			"#ag": "age",
 		},
 	}
-	PutItemTestCase3Output = `{"Attributes":{"address":{"S":"Shamli"},"age":{"N":"11"},"emp_id":{"N":"1"},"first_name":{"S":"Marc"},"last_name":{"S":"Richards"},"phone_numbers":{"SS":["+1222222222",+1111111111""]},"profile_pics":{"BS":["U29tZUJ5dGVzRGF0YTE=","U29tZUJ5dGVzRGF0YTI="]},"salaries":{"NS":["1000.5","2000.75"]}}}`
+	PutItemTestCase3Output = `{}`
 
 	PutItemTestCase4Name = "4: ConditionExpression with ExpressionAttributeValues"
 	PutItemTestCase4     = models.Meta{
@@ -897,7 +897,7 @@ var (
 			":val2": {N: aws.String("9")},
 		},
 	}
-	PutItemTestCase4Output = `{"Attributes":{"address":{"S":"Shamli"},"age":{"N":"10"},"emp_id":{"N":"1"},"first_name":{"S":"Marc"},"last_name":{"S":"Richards"},"phone_numbers":{"SS":["+1222222222",+1111111111""]},"profile_pics":{"BS":["U29tZUJ5dGVzRGF0YTE=","U29tZUJ5dGVzRGF0YTI="]},"salaries":{"NS":["1000.5","2000.75"]}}}`
+	PutItemTestCase4Output = `{}`
 
 	//400 bad request
 	PutItemTestCase5Name = "5: ConditionExpression without ExpressionAttributeValues"
@@ -1048,8 +1048,8 @@ var (
 			},
 		},
 	}
-	PutItemTestForListOutput = `{"Attributes":{}}`
-	PutItemTestCase10Output  = `{"Attributes":{"address":{"M":{"active":{"BOOL":true},"additional_details":{"M":{"additional_details_2":{"M":{"landmark_field":{"S":"near water tank road"},"landmark_field_number":{"N":"1001"}}},"apartment_number":{"S":"5B"},"landmark":{"S":"Near Central Park"},"landmark notes":{"B":"YmluYXJ5X2RhdGE="}}},"mobilenumber":{"N":"9035599089"},"notes":{"B":"YmluYXJ5X2RhdGE="},"permanent_address":{"S":"789 Elm St, Springfield, SP"},"present_address":{"S":"101 Maple Ave, Metropolis, MP"}}},"contact_ranking_list":{"S":"1,2,3"},"context":{"S":"user-profile"},"guid":{"S":"123e4567-e89b-12d3-a456-value001"},"name":{"S":"Jane Smith"}}}`
+	PutItemTestForListOutput = `{}`
+	PutItemTestCase10Output  = `{}`
 )
 
 // Test Data DeleteItem API
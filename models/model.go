@@ -17,7 +17,12 @@ package models
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
 	"sync"
+	"time"
 
 	"cloud.google.com/go/spanner"
 	"github.com/antonmedv/expr/vm"
@@ -26,12 +31,29 @@ import (
 )
 
 type SpannerConfig struct {
-	ProjectID        string  `yaml:"project_id"`
-	InstanceID       string  `yaml:"instance_id"`
-	DatabaseName     string  `yaml:"database_name"`
-	QueryLimit       int64   `yaml:"query_limit"`
-	DynamoQueryLimit int32   `yaml:"dynamo_query_limit"` //dynamo_query_limit
-	Session          Session `yaml:"Session"`
+	ProjectID        string       `yaml:"project_id"`
+	InstanceID       string       `yaml:"instance_id"`
+	DatabaseName     string       `yaml:"database_name"`
+	QueryLimit       int64        `yaml:"query_limit"`
+	DynamoQueryLimit int32        `yaml:"dynamo_query_limit"` //dynamo_query_limit
+	Session          Session      `yaml:"Session"`
+	TableRouting     []TableRoute `yaml:"table_routing"`
+
+	// BatchGetConcurrency is the number of tables of a BatchGetItem request
+	// read at the same time, 8 when it is not set
+	BatchGetConcurrency int `yaml:"batch_get_concurrency"`
+}
+
+// TableRoute maps tables to a Spanner database other than the default one.
+// Tables are matched by exact name first and then by the longest prefix.
+// Empty ProjectID and InstanceID default to the values of SpannerConfig.
+type TableRoute struct {
+	Tables       []string `yaml:"tables"`
+	TablePrefix  string   `yaml:"table_prefix"`
+	ProjectID    string   `yaml:"project_id"`
+	InstanceID   string   `yaml:"instance_id"`
+	DatabaseName string   `yaml:"database_name"`
+	Session      Session  `yaml:"Session"`
 }
 
 type Session struct {
@@ -67,9 +89,191 @@ type OtelConfig struct {
 }
 
 type Config struct {
-	Spanner   SpannerConfig `yaml:"spanner"`
-	Otel      *OtelConfig   `yaml:"otel"`
-	UserAgent string
+	Spanner        SpannerConfig       `yaml:"spanner"`
+	Otel           *OtelConfig         `yaml:"otel"`
+	Tenancy        TenancyConfig       `yaml:"tenancy"`
+	Bootstrap      BootstrapConfig     `yaml:"bootstrap"`
+	Storage        StorageConfig       `yaml:"storage"`
+	Capture        CaptureConfig       `yaml:"capture"`
+	ItemCache      ItemCacheConfig     `yaml:"item_cache"`
+	PartiQLCache   PartiQLCacheConfig  `yaml:"partiql_cache"`
+	PartiQL        PartiQLConfig       `yaml:"partiql"`
+	Explain        ExplainConfig       `yaml:"explain"`
+	FullTableScans FullTableScanConfig `yaml:"full_table_scans"`
+	UserAgent      string
+}
+
+// StorageConfig selects the storage backend. The memory backend keeps the
+// tables in process memory and needs no Spanner database; its tables are
+// created by the bootstrap.
+type StorageConfig struct {
+	Backend string `yaml:"backend"`
+}
+
+// Storage backends
+const (
+	StorageBackendSpanner = "spanner"
+	StorageBackendMemory  = "memory"
+)
+
+// CaptureConfig records request/response pairs into rotating JSONL files in
+// Directory for later replay. Files are rotated after MaxFileSizeMB and only
+// the newest MaxFiles are kept. Redact replaces the attribute values and the
+// statement and expression literals of the captured bodies with placeholders,
+// and is on unless set to false.
+type CaptureConfig struct {
+	Enabled       bool   `yaml:"enabled"`
+	Directory     string `yaml:"directory"`
+	MaxFileSizeMB int64  `yaml:"max_file_size_mb"`
+	MaxFiles      int    `yaml:"max_files"`
+	Redact        *bool  `yaml:"redact"`
+}
+
+// RedactValues tells whether captured bodies are redacted
+func (c CaptureConfig) RedactValues() bool {
+	return c.Redact == nil || *c.Redact
+}
+
+// ItemCacheConfig enables a read-through cache of the items read by GetItem
+// and BatchGetItem for the tables listed in Tables. Writes made through the
+// adapter invalidate the items they change; other writes are seen once the
+// cached items expire. Strongly consistent reads bypass the cache.
+type ItemCacheConfig struct {
+	Tables []ItemCacheTable `yaml:"tables"`
+}
+
+// PartiQLCacheConfig sizes the cache of translated PartiQL statements.
+// MaxStatements defaults to 1000, and a negative MaxStatements disables the
+// cache.
+type PartiQLCacheConfig struct {
+	MaxStatements int `yaml:"max_statements"`
+}
+
+// PartiQLConfig guards the PartiQL statements run by ExecuteStatement.
+// Aggregate functions are only allowed on the partitions a SELECT names with
+// its partition key, unless AllowFullTableAggregates is set.
+//
+// NextTokenKey signs the NextTokens of SELECTs, so the adapters sharing it
+// continue each other's statements across restarts. NextTokens signed with
+// PreviousNextTokenKey are accepted too while the key is rotated. Without a
+// key, each adapter signs with a random key of its own.
+type PartiQLConfig struct {
+	AllowFullTableAggregates bool   `yaml:"allow_full_table_aggregates"`
+	NextTokenKey             Secret `yaml:"next_token_key"`
+	PreviousNextTokenKey     Secret `yaml:"previous_next_token_key"`
+}
+
+// Secret is a value given in the config or read from File, such as a mounted
+// secret. Surrounding whitespace is removed from the content of File.
+type Secret struct {
+	Value string `yaml:"value"`
+	File  string `yaml:"file"`
+}
+
+// Read returns the value of the secret
+func (s Secret) Read() (string, error) {
+	if s.File == "" {
+		return s.Value, nil
+	}
+	data, err := os.ReadFile(s.File)
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret: %w", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// ExplainConfig enables the /admin/explain endpoint and the explain header,
+// which return the Spanner SQL, parameters and query plans of the Query, Scan
+// and PartiQL SELECT requests. They are disabled by default, as they return
+// the values of the parameters. The endpoint only serves the requests
+// carrying AdminToken, and explain stays disabled without one.
+type ExplainConfig struct {
+	Enabled    bool   `yaml:"enabled"`
+	AdminToken Secret `yaml:"admin_token"`
+}
+
+// FullTableScanConfig sets what is done with the reads of the tables listed in
+// Tables that filter items without reading a key range: Query and Scan
+// requests with a FilterExpression and no condition on the partition key of
+// the table or index they read, and PartiQL SELECTs with a WHERE clause that
+// does not compare that partition key with = or IN. Other tables are read
+// without a policy.
+type FullTableScanConfig struct {
+	Tables []FullTableScanPolicy `yaml:"tables"`
+}
+
+// FullTableScanPolicy is the policy of the full table reads of Tables. Action
+// is one of the FullTableScan actions. Rate limited reads are rejected beyond
+// PerSecond reads a second, in bursts of up to Burst reads, 1 by default.
+type FullTableScanPolicy struct {
+	Tables    []string `yaml:"tables"`
+	Action    string   `yaml:"action"`
+	PerSecond float64  `yaml:"per_second"`
+	Burst     int      `yaml:"burst"`
+}
+
+// FullTableScan actions
+const (
+	FullTableScanReject    = "reject"
+	FullTableScanWarn      = "warn"
+	FullTableScanRateLimit = "rate_limit"
+)
+
+// ItemCacheTable sets how long the items of Tables are cached and how many
+// are kept per table. Keys without an item are cached for NegativeTTL, which
+// defaults to TTL. MaxItems defaults to 10000.
+type ItemCacheTable struct {
+	Tables      []string      `yaml:"tables"`
+	TTL         time.Duration `yaml:"ttl"`
+	NegativeTTL time.Duration `yaml:"negative_ttl"`
+	MaxItems    int           `yaml:"max_items"`
+}
+
+// BootstrapConfig creates the adapter metadata tables at startup and seeds
+// them with the tables described in TablesFile, a YAML or JSON file in the
+// format of examples/adapter/config-files/staging/tables.json.
+type BootstrapConfig struct {
+	Enabled    bool   `yaml:"enabled"`
+	TablesFile string `yaml:"tables_file"`
+}
+
+// BootstrapTable describes a table created by the bootstrap. Key attributes
+// missing from AttributeTypes are strings. The N and NS attributes listed in
+// StringNumbers are stored in STRING(MAX) columns instead of NUMERIC ones.
+type BootstrapTable struct {
+	PartitionKey   string                    `json:"partitionKey" yaml:"partitionKey"`
+	SortKey        string                    `json:"sortKey" yaml:"sortKey"`
+	AttributeTypes map[string]string         `json:"attributeTypes" yaml:"attributeTypes"`
+	StringNumbers  []string                  `json:"stringNumbers" yaml:"stringNumbers"`
+	Indices        map[string]BootstrapIndex `json:"indices" yaml:"indices"`
+}
+
+// BootstrapIndex describes a secondary index created by the bootstrap. The
+// projection type defaults to ALL.
+type BootstrapIndex struct {
+	PartitionKey     string   `json:"partitionKey" yaml:"partitionKey"`
+	SortKey          string   `json:"sortKey" yaml:"sortKey"`
+	ProjectionType   string   `json:"projectionType" yaml:"projectionType"`
+	NonKeyAttributes []string `json:"nonKeyAttributes" yaml:"nonKeyAttributes"`
+}
+
+// TenancyConfig maps the callers of a shared adapter to table namespaces.
+// Header names the request header carrying the tenant name and defaults to
+// X-Tenant-Id.
+type TenancyConfig struct {
+	Header  string   `yaml:"header"`
+	Tenants []Tenant `yaml:"tenants"`
+}
+
+// Tenant is a table namespace. The tables of a tenant are stored as
+// Prefix + table name + Suffix, and callers are matched to it by name (from
+// the tenant header), by AWS access key id or by host name.
+type Tenant struct {
+	Name       string   `yaml:"name"`
+	Prefix     string   `yaml:"prefix"`
+	Suffix     string   `yaml:"suffix"`
+	AccessKeys []string `yaml:"access_keys"`
+	Hosts      []string `yaml:"hosts"`
 }
 
 type Proxy struct {
@@ -130,6 +334,7 @@ type GetItemMeta struct {
 	ProjectionExpression     string                              `json:"ProjectionExpression"`
 	ExpressionAttributeNames map[string]string                   `json:"ExpressionAttributeNames"`
 	Key                      map[string]*dynamodb.AttributeValue `json:"Key"`
+	ConsistentRead           bool                                `json:"ConsistentRead"`
 }
 
 // BatchGetMeta struct
@@ -144,6 +349,7 @@ type BatchGetWithProjectionMeta struct {
 	ProjectionExpression     string                                `json:"ProjectionExpression"`
 	ExpressionAttributeNames map[string]string                     `json:"ExpressionAttributeNames"`
 	Keys                     []map[string]*dynamodb.AttributeValue `json:"Keys"`
+	ConsistentRead           bool                                  `json:"ConsistentRead"`
 }
 
 // Delete struct
@@ -223,8 +429,18 @@ type TableConfig struct {
 	IsComplement     bool                   `json:"IsComplement,omitempty"`
 	TableSource      string                 `json:"TableSource,omitempty"`
 	ActualTable      string                 `json:"ActualTable,omitempty"`
+	ProjectionType   string                 `json:"ProjectionType,omitempty"`
+	NonKeyAttributes []string               `json:"NonKeyAttributes,omitempty"`
+	IsNullFiltered   bool                   `json:"IsNullFiltered,omitempty"`
 }
 
+// Projection types supported for secondary indexes
+const (
+	ProjectionTypeKeysOnly = "KEYS_ONLY"
+	ProjectionTypeInclude  = "INCLUDE"
+	ProjectionTypeAll      = "ALL"
+)
+
 // BatchWriteItem for Batch Operation
 type BatchWriteItem struct {
 	RequestItems map[string][]BatchWriteSubItems `json:"RequestItems"`
@@ -241,6 +457,22 @@ type BatchWriteSubItems struct {
 	PutReq BatchPutItem    `json:"PutRequest"`
 }
 
+// MarshalJSON writes only the request the entry holds, so unprocessed items
+// can be sent back as they are
+func (b BatchWriteSubItems) MarshalJSON() ([]byte, error) {
+	var out struct {
+		DelReq *BatchDeleteItem `json:"DeleteRequest,omitempty"`
+		PutReq *BatchPutItem    `json:"PutRequest,omitempty"`
+	}
+	if b.DelReq.Key != nil {
+		out.DelReq = &b.DelReq
+	}
+	if b.PutReq.Item != nil {
+		out.PutReq = &b.PutReq
+	}
+	return json.Marshal(out)
+}
+
 // BatchDeleteItem is for BatchWriteSubItems
 type BatchDeleteItem struct {
 	Key map[string]*dynamodb.AttributeValue `json:"Key"`
@@ -253,12 +485,21 @@ type BatchPutItem struct {
 
 var DbConfigMap map[string]TableConfig
 
+// DbConfigMapMu guards DbConfigMap, whose indexes change while requests read
+// it. It is read through config.GetTableConf and config.TableNames.
+var DbConfigMapMu sync.RWMutex
+
 // TableDDL - this contains the DDL
 var TableDDL map[string]map[string]string
 
 // TableColumnMap - this contains the list of columns for the tables
 var TableColumnMap map[string][]string
 
+// SpannerColumnTypes holds the Spanner type of the columns, by table, as
+// recorded in spannerDataType. It decides how numbers are stored: number
+// columns missing from it are FLOAT64, as created by earlier versions.
+var SpannerColumnTypes map[string]map[string]string
+
 // TableColChangeMap for changed columns map
 var TableColChangeMap map[string]struct{}
 
@@ -275,6 +516,7 @@ func init() {
 	TableColumnMap = make(map[string][]string)
 	TableColumnMap["dynamodb_adapter_table_ddl"] = []string{"tableName", "column", "dynamoDataType", "originalColumn", "partitionKey", "sortKey", "spannerIndexName", "actualTable", "spannerDataType"}
 	TableColumnMap["dynamodb_adapter_config_manager"] = []string{"tableName", "config", "cronTime", "uniqueValue", "enabledStream"}
+	SpannerColumnTypes = make(map[string]map[string]string)
 	TableColChangeMap = make(map[string]struct{})
 	ColumnToOriginalCol = make(map[string]string)
 	OriginalColResponse = make(map[string]string)
@@ -295,7 +537,7 @@ type UpdateExpressionCondition struct {
 	Value     []string
 	Condition []string
 	ActionVal string
-	AddValues map[string]float64
+	AddValues map[string]Number
 }
 
 // ConfigControllerModel for Config controller
@@ -312,8 +554,6 @@ type ConfigControllerModel struct {
 // ConfigController object for ConfigControllerModel
 var ConfigController *ConfigControllerModel
 
-// SpannerTableMap for spanner column map
-var SpannerTableMap = make(map[string]string)
 
 func init() {
 	ConfigController = new(ConfigControllerModel)
@@ -475,7 +715,7 @@ type TransactWriteItemsResponse struct {
 
 type ExecuteStatement struct {
 	Limit        int64                      `json:"Limit"`
-	NextToken    int64                      `json:"NextToken"`
+	NextToken    string                     `json:"NextToken"`
 	Parameters   []*dynamodb.AttributeValue `json:"Parameters"`
 	ReturnValues string                     `json:"ReturnValues"`
 	Statement    string                     `json:"Statement"`
@@ -483,8 +723,66 @@ type ExecuteStatement struct {
 	AttrParams   []interface{}              `json:"AttrParams"`
 }
 
+// BatchExecuteStatementRequest represents the input structure for BatchExecuteStatement API.
+type BatchExecuteStatementRequest struct {
+	Statements             []BatchStatementRequest `json:"Statements"`
+	ReturnConsumedCapacity string                  `json:"ReturnConsumedCapacity,omitempty"`
+}
+
+// BatchStatementRequest is a statement of a BatchExecuteStatement request
+type BatchStatementRequest struct {
+	Statement      string                     `json:"Statement"`
+	Parameters     []*dynamodb.AttributeValue `json:"Parameters"`
+	ConsistentRead bool                       `json:"ConsistentRead"`
+}
+
+// BatchStatementResponse is the result of a statement of a BatchExecuteStatement
+// request, the item it read or the error it failed with
+type BatchStatementResponse struct {
+	TableName string                 `json:"TableName,omitempty"`
+	Item      map[string]interface{} `json:"Item,omitempty"`
+	Error     *BatchStatementError   `json:"Error,omitempty"`
+}
+
+// BatchStatementError is the error of a statement of a BatchExecuteStatement request
+type BatchStatementError struct {
+	Code    string `json:"Code"`
+	Message string `json:"Message"`
+}
+
+// ExecuteTransactionRequest represents the input structure for ExecuteTransaction API.
+type ExecuteTransactionRequest struct {
+	TransactStatements     []ParameterizedStatement `json:"TransactStatements"`
+	ClientRequestToken     string                   `json:"ClientRequestToken,omitempty"`
+	ReturnConsumedCapacity string                   `json:"ReturnConsumedCapacity,omitempty"`
+}
+
+// ParameterizedStatement is a statement of an ExecuteTransaction request
+type ParameterizedStatement struct {
+	Statement  string                     `json:"Statement"`
+	Parameters []*dynamodb.AttributeValue `json:"Parameters"`
+}
+
 type ExecuteStatementQuery struct {
 	PartiQl      string
 	Params       map[string]interface{}
 	SQLStatement spanner.Statement
 }
+
+// DescribeTableMeta for DescribeTable request
+type DescribeTableMeta struct {
+	TableName string `json:"TableName"`
+}
+
+// ListTablesMeta for ListTables request
+type ListTablesMeta struct {
+	ExclusiveStartTableName string `json:"ExclusiveStartTableName"`
+	Limit                   int64  `json:"Limit"`
+}
+
+// UpdateTableMeta for UpdateTable request
+type UpdateTableMeta struct {
+	TableName                   string                                 `json:"TableName"`
+	AttributeDefinitions        []*dynamodb.AttributeDefinition        `json:"AttributeDefinitions"`
+	GlobalSecondaryIndexUpdates []*dynamodb.GlobalSecondaryIndexUpdate `json:"GlobalSecondaryIndexUpdates"`
+}
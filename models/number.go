@@ -0,0 +1,263 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/cloudspannerecosystem/dynamodb-adapter/pkg/errors"
+)
+
+// DynamoDB number limits
+const (
+	NumberMaxDigits   = 38
+	NumberMaxExponent = 125
+	NumberMinExponent = -130
+)
+
+// Number is an exact DynamoDB number. Its value is the canonical decimal form
+// DynamoDB responds with: no exponent, no leading or trailing zeros and no
+// plus sign, so equal numbers are equal strings.
+type Number string
+
+var numberPattern = regexp.MustCompile(`^([+-]?)(\d*)(?:\.(\d*))?(?:[eE]([+-]?\d+))?$`)
+
+// ParseNumber parses the N value of an attribute and validates it against the
+// precision and range of DynamoDB numbers
+func ParseNumber(s string) (Number, error) {
+	m := numberPattern.FindStringSubmatch(s)
+	if m == nil || m[2]+m[3] == "" {
+		return "", errors.New("ValidationException", "A value provided cannot be converted into a number", s)
+	}
+	exp := 0
+	if m[4] != "" {
+		e, err := strconv.Atoi(m[4])
+		if err != nil {
+			return "", errors.New("ValidationException", "A value provided cannot be converted into a number", s)
+		}
+		exp = e
+	}
+
+	digits := strings.TrimLeft(m[2]+m[3], "0")
+	exp -= len(m[3])
+	if digits == "" {
+		return "0", nil
+	}
+	trimmed := strings.TrimRight(digits, "0")
+	exp += len(digits) - len(trimmed)
+	digits = trimmed
+
+	if len(digits) > NumberMaxDigits {
+		return "", errors.New("ValidationException", "Attempting to store more than 38 significant digits in a Number", s)
+	}
+	// the exponent of the number in scientific notation
+	if magnitude := exp + len(digits) - 1; magnitude > NumberMaxExponent {
+		return "", errors.New("ValidationException", "Number overflow. Attempting to store a number with magnitude larger than supported range", s)
+	} else if magnitude < NumberMinExponent {
+		return "", errors.New("ValidationException", "Number underflow. Attempting to store a number with magnitude smaller than supported range", s)
+	}
+
+	sign := ""
+	if m[1] == "-" {
+		sign = "-"
+	}
+	switch {
+	case exp >= 0:
+		return Number(sign + digits + strings.Repeat("0", exp)), nil
+	case -exp < len(digits):
+		return Number(sign + digits[:len(digits)+exp] + "." + digits[len(digits)+exp:]), nil
+	}
+	return Number(sign + "0." + strings.Repeat("0", -exp-len(digits)) + digits), nil
+}
+
+// NumberFromFloat64 converts f to the shortest Number that reads back as f.
+// It is used for numbers stored in FLOAT64 columns.
+func NumberFromFloat64(f float64) (Number, error) {
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return "", errors.New("ValidationException", "A value provided cannot be converted into a number", f)
+	}
+	return ParseNumber(strconv.FormatFloat(f, 'g', -1, 64))
+}
+
+// NumberFromRat converts r, e.g. a NUMERIC column or the result of an
+// addition, to a Number. r must have a finite decimal expansion.
+func NumberFromRat(r *big.Rat) (Number, error) {
+	num := new(big.Int).Set(r.Num())
+	denom := new(big.Int).Set(r.Denom())
+	ten := big.NewInt(10)
+	scale := 0
+	for denom.Cmp(big.NewInt(1)) != 0 {
+		if scale > NumberMaxDigits-NumberMinExponent {
+			return "", errors.New("ValidationException", "A value provided cannot be converted into a number", r.String())
+		}
+		num.Mul(num, ten)
+		g := new(big.Int).GCD(nil, nil, num, denom)
+		num.Quo(num, g)
+		denom.Quo(denom, g)
+		scale++
+	}
+	return ParseNumber(num.String() + "E-" + strconv.Itoa(scale))
+}
+
+// ToNumber converts the numeric values of items, which are Numbers or, for
+// values decoded from JSON and FLOAT64 columns, Go numbers, to a Number
+func ToNumber(v interface{}) (Number, bool) {
+	var n Number
+	var err error
+	switch v := v.(type) {
+	case Number:
+		return v, true
+	case float64:
+		n, err = NumberFromFloat64(v)
+	case float32:
+		n, err = NumberFromFloat64(float64(v))
+	case int64:
+		n, err = ParseNumber(strconv.FormatInt(v, 10))
+	case int:
+		n, err = ParseNumber(strconv.Itoa(v))
+	case json.Number:
+		n, err = ParseNumber(v.String())
+	case *big.Rat:
+		n, err = NumberFromRat(v)
+	case big.Rat:
+		n, err = NumberFromRat(&v)
+	default:
+		return "", false
+	}
+	return n, err == nil
+}
+
+// Rat returns the exact value of n
+func (n Number) Rat() *big.Rat {
+	r, ok := new(big.Rat).SetString(string(n))
+	if !ok {
+		return new(big.Rat)
+	}
+	return r
+}
+
+// Float64 returns the nearest float64 to n
+func (n Number) Float64() float64 {
+	f, _ := strconv.ParseFloat(string(n), 64)
+	return f
+}
+
+// Add returns n + o computed exactly. Results outside the range of DynamoDB
+// numbers are a ValidationException.
+func (n Number) Add(o Number) (Number, error) {
+	return NumberFromRat(new(big.Rat).Add(n.Rat(), o.Rat()))
+}
+
+// Neg returns -n
+func (n Number) Neg() Number {
+	switch {
+	case n == "0" || n == "":
+		return n
+	case strings.HasPrefix(string(n), "-"):
+		return n[1:]
+	}
+	return "-" + n
+}
+
+// Cmp compares n and o and returns -1, 0 or +1
+func (n Number) Cmp(o Number) int {
+	if n == o {
+		return 0
+	}
+	return n.Rat().Cmp(o.Rat())
+}
+
+// String returns the canonical decimal form of n
+func (n Number) String() string {
+	return string(n)
+}
+
+// MarshalJSON writes n as a JSON number, keeping all of its digits
+func (n Number) MarshalJSON() ([]byte, error) {
+	if n == "" {
+		return []byte("0"), nil
+	}
+	return []byte(n), nil
+}
+
+// Numbers in STRING columns are written in a form whose string order is the
+// order of the numbers, so that Spanner compares and sorts them as numbers: a
+// letter for the sign, the exponent and the significant digits. The digits of
+// negative numbers are complemented and terminated, so that larger magnitudes
+// sort first.
+const (
+	sortableNegative = "A"
+	sortableZero     = "B"
+	sortablePositive = "C"
+	sortableEnd      = "~"
+	// sortableBias makes the exponents of DynamoDB numbers three digits
+	sortableBias = 500
+)
+
+// SortableString returns n in the form it is written to STRING columns
+func (n Number) SortableString() string {
+	s := strings.TrimPrefix(string(n), "-")
+	integer, fraction, _ := strings.Cut(s, ".")
+	integer = strings.TrimLeft(integer, "0")
+	// n is 0.digits times 10 to the power of exp
+	exp := len(integer)
+	digits := integer + fraction
+	if integer == "" {
+		digits = strings.TrimLeft(fraction, "0")
+		exp = len(digits) - len(fraction)
+	}
+	digits = strings.TrimRight(digits, "0")
+	if digits == "" {
+		return sortableZero
+	}
+	if !strings.HasPrefix(string(n), "-") {
+		return sortablePositive + fmt.Sprintf("%03d", exp+sortableBias) + digits
+	}
+	complement := []byte(digits)
+	for i, d := range complement {
+		complement[i] = '9' - d + '0'
+	}
+	return sortableNegative + fmt.Sprintf("%03d", 999-exp-sortableBias) + string(complement) + sortableEnd
+}
+
+// NumberFromSortableString reads a number of a STRING column. Numbers written
+// as plain decimals are read as well.
+func NumberFromSortableString(s string) (Number, error) {
+	if s == sortableZero {
+		return "0", nil
+	}
+	if len(s) < 5 || (!strings.HasPrefix(s, sortablePositive) && !strings.HasPrefix(s, sortableNegative)) {
+		return ParseNumber(s)
+	}
+	exp, err := strconv.Atoi(s[1:4])
+	if err != nil {
+		return "", errors.New("ValidationException", "A value provided cannot be converted into a number", s)
+	}
+	digits, sign := s[4:], ""
+	if strings.HasPrefix(s, sortableNegative) {
+		complement := []byte(strings.TrimSuffix(digits, sortableEnd))
+		for i, d := range complement {
+			complement[i] = '9' - d + '0'
+		}
+		digits, sign, exp = string(complement), "-", 999-exp
+	}
+	return ParseNumber(sign + "0." + digits + "E" + strconv.Itoa(exp-sortableBias))
+}
@@ -0,0 +1,147 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/cloudspannerecosystem/dynamodb-adapter/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func assertValidationError(t *testing.T, err error, message string) {
+	t.Helper()
+	e, ok := err.(*errors.Error)
+	if assert.True(t, ok, "expected a ValidationException, got %v", err) {
+		assert.Equal(t, "ValidationException", e.ErrorCode)
+		assert.Contains(t, e.ErrorMessage, message)
+	}
+}
+
+func TestParseNumber(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Number
+		wantErr string
+	}{
+		{"0", "0", ""},
+		{"-0.000", "0", ""},
+		{"+42", "42", ""},
+		{"1e6", "1000000", ""},
+		{"1.50", "1.5", ""},
+		{"0012.3400", "12.34", ""},
+		{"-1.5E-3", "-0.0015", ""},
+		{".5", "0.5", ""},
+		{"9007199254740993", "9007199254740993", ""},
+		{"12345678901234567890123456789012345678", "12345678901234567890123456789012345678", ""},
+		{"1E125", Number("1" + strings.Repeat("0", 125)), ""},
+		{"1E-130", Number("0." + strings.Repeat("0", 129) + "1"), ""},
+		{"123456789012345678901234567890123456789", "", "38 significant digits"},
+		{"1E126", "", "Number overflow"},
+		{"1E-131", "", "Number underflow"},
+		{"abc", "", "cannot be converted into a number"},
+		{"NaN", "", "cannot be converted into a number"},
+		{"", "", "cannot be converted into a number"},
+		{"1e", "", "cannot be converted into a number"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.input, func(t *testing.T) {
+			got, err := ParseNumber(tc.input)
+			if tc.wantErr != "" {
+				assertValidationError(t, err, tc.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestNumberArithmetic(t *testing.T) {
+	sum, err := Number("0.1").Add("0.2")
+	assert.NoError(t, err)
+	assert.Equal(t, Number("0.3"), sum)
+
+	sum, err = Number("9007199254740992").Add("1")
+	assert.NoError(t, err)
+	assert.Equal(t, Number("9007199254740993"), sum)
+
+	_, err = Number("9" + strings.Repeat("0", 125)).Add(Number("9" + strings.Repeat("0", 125)))
+	assertValidationError(t, err, "Number overflow")
+
+	assert.Equal(t, Number("-5"), Number("5").Neg())
+	assert.Equal(t, Number("5"), Number("-5").Neg())
+	assert.Equal(t, Number("0"), Number("0").Neg())
+	assert.Equal(t, -1, Number("-10").Cmp("2"))
+	assert.Equal(t, 1, Number("10").Cmp("9.99"))
+}
+
+func TestToNumber(t *testing.T) {
+	tests := []struct {
+		input interface{}
+		want  Number
+	}{
+		{Number("7"), "7"},
+		{float64(1e6), "1000000"},
+		{0.1, "0.1"},
+		{int64(-3), "-3"},
+		{json.Number("2.50"), "2.5"},
+		{big.NewRat(1, 8), "0.125"},
+	}
+	for _, tc := range tests {
+		got, ok := ToNumber(tc.input)
+		assert.True(t, ok)
+		assert.Equal(t, tc.want, got)
+	}
+	_, ok := ToNumber("7")
+	assert.False(t, ok)
+	_, ok = ToNumber(big.NewRat(1, 3))
+	assert.False(t, ok)
+}
+
+func TestNumberMarshalJSON(t *testing.T) {
+	b, err := json.Marshal(map[string]interface{}{"n": Number("12345678901234567890.5")})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"n":12345678901234567890.5}`, string(b))
+}
+
+func TestNumberSortableString(t *testing.T) {
+	// in ascending order
+	numbers := []Number{
+		Number("-1" + strings.Repeat("0", 125)), "-1000", "-123.45", "-123.4", "-1.5", "-1",
+		"-0.0015", Number("-0." + strings.Repeat("0", 129) + "1"), "0",
+		Number("0." + strings.Repeat("0", 129) + "1"), "0.0000000001", "0.1", "1", "1.5", "10", "123.4",
+		"123.45", "12345678901234567890123456789012345678", Number("1" + strings.Repeat("0", 100)),
+	}
+	for i, n := range numbers {
+		s := n.SortableString()
+		back, err := NumberFromSortableString(s)
+		assert.NoError(t, err, s)
+		assert.Equal(t, n, back)
+		if i > 0 {
+			assert.Less(t, numbers[i-1].SortableString(), s, "%s < %s", numbers[i-1], n)
+		}
+	}
+
+	// plain decimals are read as they were written before
+	n, err := NumberFromSortableString("1.50")
+	assert.NoError(t, err)
+	assert.Equal(t, Number("1.5"), n)
+	_, err = NumberFromSortableString("C5x")
+	assert.Error(t, err)
+}
@@ -0,0 +1,140 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// MaxItemSize is the largest size of an item DynamoDB stores
+const MaxItemSize = 400 * 1024
+
+// ItemSize returns the size DynamoDB accounts for an item, the lengths of its
+// attribute names included. Values are attribute values of a request or the
+// values of items read from storage.
+func ItemSize[V any](item map[string]V) int {
+	size := 0
+	for k, v := range item {
+		size += len(k) + AttributeSize(v)
+	}
+	return size
+}
+
+// AttributeSize returns the size DynamoDB accounts for an attribute value
+func AttributeSize(v interface{}) int {
+	switch v := v.(type) {
+	case *dynamodb.AttributeValue:
+		return attributeValueSize(v)
+	case nil, bool:
+		return 1
+	case string:
+		return len(v)
+	case []byte:
+		return len(v)
+	case Number:
+		return v.Size()
+	case []string:
+		size := 0
+		for _, s := range v {
+			size += len(s)
+		}
+		return size
+	case []Number:
+		size := 0
+		for _, n := range v {
+			size += n.Size()
+		}
+		return size
+	case [][]byte:
+		size := 0
+		for _, b := range v {
+			size += len(b)
+		}
+		return size
+	case map[string]interface{}:
+		return collectionSize(len(v), ItemSize(v))
+	case map[string]*dynamodb.AttributeValue:
+		return collectionSize(len(v), ItemSize(v))
+	case []interface{}:
+		return collectionSize(len(v), listSize(v))
+	case []*dynamodb.AttributeValue:
+		return collectionSize(len(v), listSize(v))
+	}
+	if n, ok := ToNumber(v); ok {
+		return n.Size()
+	}
+	return len(fmt.Sprint(v))
+}
+
+// attributeValueSize returns the size of an attribute value of a request by the
+// rules of the value it is stored as
+func attributeValueSize(v *dynamodb.AttributeValue) int {
+	switch {
+	case v == nil:
+		return 0
+	case v.S != nil:
+		return len(*v.S)
+	case v.N != nil:
+		n, err := ParseNumber(*v.N)
+		if err != nil {
+			return len(*v.N)
+		}
+		return n.Size()
+	case v.B != nil:
+		return len(v.B)
+	case v.BOOL != nil, v.NULL != nil:
+		return 1
+	case v.SS != nil:
+		return AttributeSize(aws.StringValueSlice(v.SS))
+	case v.NS != nil:
+		size := 0
+		for _, n := range v.NS {
+			size += attributeValueSize(&dynamodb.AttributeValue{N: n})
+		}
+		return size
+	case v.BS != nil:
+		return AttributeSize(v.BS)
+	case v.M != nil:
+		return AttributeSize(v.M)
+	case v.L != nil:
+		return AttributeSize(v.L)
+	}
+	return 0
+}
+
+// collectionSize returns the size of a map or list: 3 bytes plus 1 byte per
+// element on top of the size of its elements
+func collectionSize(elements, size int) int {
+	return 3 + size + elements
+}
+
+func listSize[V any](list []V) int {
+	size := 0
+	for _, e := range list {
+		size += AttributeSize(e)
+	}
+	return size
+}
+
+// Size returns the size DynamoDB accounts for n: one byte per two significant
+// digits plus one byte
+func (n Number) Size() int {
+	digits := strings.Trim(strings.NewReplacer("-", "", ".", "").Replace(n.String()), "0")
+	return (len(digits)+1)/2 + 1
+}
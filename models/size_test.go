@@ -0,0 +1,50 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestItemSize(t *testing.T) {
+	assert.Equal(t, 2, Number("1").Size())
+	assert.Equal(t, 2, Number("1000").Size())
+	assert.Equal(t, 2, Number("0.001").Size())
+	assert.Equal(t, 3, Number("-12.5").Size())
+	assert.Equal(t, 20, Number("12345678901234567890123456789012345678").Size())
+
+	item := map[string]*dynamodb.AttributeValue{
+		"name": {S: aws.String("abc")},
+		"ok":   {BOOL: aws.Bool(true)},
+		"tags": {SS: aws.StringSlice([]string{"a", "bc"})},
+		"m": {M: map[string]*dynamodb.AttributeValue{
+			"l": {L: []*dynamodb.AttributeValue{{N: aws.String("12")}, {NULL: aws.Bool(true)}}},
+		}},
+	}
+	// name 4+3, ok 2+1, tags 4+3, m 1+3+(1+(3+(2+1)+(1+1))+1)
+	assert.Equal(t, 31, ItemSize(item))
+
+	// items read from storage have the size of the request they were written by
+	assert.Equal(t, 31, ItemSize(map[string]interface{}{
+		"name": "abc",
+		"ok":   true,
+		"tags": []string{"a", "bc"},
+		"m":    map[string]interface{}{"l": []interface{}{Number("12"), nil}},
+	}))
+}
@@ -42,6 +42,7 @@ type Attributes struct {
 	Method    string
 	Status    string
 	QueryType string
+	Tenant    string
 }
 
 var (
@@ -50,6 +51,9 @@ var (
 	attributeKeyStatus    = attribute.Key("status")
 	attributeKeyInstance  = attribute.Key("instanceID")
 	attributeKeyQueryType = attribute.Key("queryType")
+	attributeKeyTenant    = attribute.Key("tenant")
+	attributeKeyTable     = attribute.Key("table")
+	attributeKeyResult    = attribute.Key("result")
 )
 
 // TracerProvider defines the interface for creating traces.
@@ -83,8 +87,12 @@ type OTelConfig struct {
 }
 
 const (
-	requestCountMetric = "spanner/dynamo_adapter/request_count"
-	latencyMetric      = "spanner/dynamo_adapter/roundtrip_latencies"
+	requestCountMetric  = "spanner/dynamo_adapter/request_count"
+	latencyMetric       = "spanner/dynamo_adapter/roundtrip_latencies"
+	cacheMetric         = "spanner/dynamo_adapter/item_cache_lookups"
+	partiQLCacheMetric  = "spanner/dynamo_adapter/partiql_cache_lookups"
+	invalidationMetric  = "spanner/dynamo_adapter/partiql_cache_invalidations"
+	fullTableScanMetric = "spanner/dynamo_adapter/full_table_scans"
 )
 
 // OpenTelemetry provides methods to setup tracing and metrics.
@@ -96,6 +104,10 @@ type OpenTelemetry struct {
 	Meter          metric.Meter
 	requestCount   metric.Int64Counter   // Default noop
 	requestLatency metric.Int64Histogram // Default noop
+	cacheLookups   metric.Int64Counter   // Default noop
+	partiQLLookups metric.Int64Counter   // Default noop
+	invalidations  metric.Int64Counter   // Default noop
+	fullTableScans metric.Int64Counter   // Default noop
 	attributeMap   []attribute.KeyValue
 }
 
@@ -166,6 +178,26 @@ func NewOpenTelemetry(ctx context.Context, config *OTelConfig) (*OpenTelemetry,
 		if err != nil {
 			return otelInst, shutdown, err
 		}
+
+		otelInst.cacheLookups, err = otelInst.Meter.Int64Counter(cacheMetric, metric.WithDescription("Records item cache lookups by table and result"), metric.WithUnit("1"))
+		if err != nil {
+			return otelInst, shutdown, err
+		}
+
+		otelInst.partiQLLookups, err = otelInst.Meter.Int64Counter(partiQLCacheMetric, metric.WithDescription("Records PartiQL translation cache lookups by result"), metric.WithUnit("1"))
+		if err != nil {
+			return otelInst, shutdown, err
+		}
+
+		otelInst.invalidations, err = otelInst.Meter.Int64Counter(invalidationMetric, metric.WithDescription("Records invalidations of the PartiQL translation cache"), metric.WithUnit("1"))
+		if err != nil {
+			return otelInst, shutdown, err
+		}
+
+		otelInst.fullTableScans, err = otelInst.Meter.Int64Counter(fullTableScanMetric, metric.WithDescription("Records full table reads by table and action"), metric.WithUnit("1"))
+		if err != nil {
+			return otelInst, shutdown, err
+		}
 	}
 
 	return otelInst, shutdown, nil
@@ -327,6 +359,7 @@ func (o *OpenTelemetry) RecordLatencyMetric(ctx context.Context, duration time.T
 	attr := o.attributeMap
 	attr = append(attr, attributeKeyMethod.String(attrs.Method))
 	attr = append(attr, attributeKeyQueryType.String(attrs.QueryType))
+	attr = append(attr, attributeKeyTenant.String(attrs.Tenant))
 	o.requestLatency.Record(ctx, int64(time.Since(duration).Milliseconds()), metric.WithAttributes(attr...))
 }
 
@@ -340,9 +373,58 @@ func (o *OpenTelemetry) RecordRequestCountMetric(ctx context.Context, attrs Attr
 	attr = append(attr, attributeKeyMethod.String(attrs.Method))
 	attr = append(attr, attributeKeyQueryType.String(attrs.QueryType))
 	attr = append(attr, attributeKeyStatus.String(attrs.Status))
+	attr = append(attr, attributeKeyTenant.String(attrs.Tenant))
 	o.requestCount.Add(ctx, 1, metric.WithAttributes(attr...))
 }
 
+// RecordCacheMetric counts a lookup of the item cache of the table. result is
+// "hit", "negative_hit" for a cached miss, or "miss".
+func (o *OpenTelemetry) RecordCacheMetric(ctx context.Context, table, result string) {
+	if o == nil || o.Config == nil || !o.Config.MetricsEnabled || o.cacheLookups == nil {
+		return
+	}
+
+	attr := o.attributeMap
+	attr = append(attr, attributeKeyTable.String(table))
+	attr = append(attr, attributeKeyResult.String(result))
+	o.cacheLookups.Add(ctx, 1, metric.WithAttributes(attr...))
+}
+
+// RecordPartiQLCacheMetric counts a lookup of the PartiQL translation cache.
+// result is "hit" or "miss".
+func (o *OpenTelemetry) RecordPartiQLCacheMetric(ctx context.Context, result string) {
+	if o == nil || o.Config == nil || !o.Config.MetricsEnabled || o.partiQLLookups == nil {
+		return
+	}
+
+	attr := o.attributeMap
+	attr = append(attr, attributeKeyResult.String(result))
+	o.partiQLLookups.Add(ctx, 1, metric.WithAttributes(attr...))
+}
+
+// RecordPartiQLCacheInvalidation counts an invalidation of the PartiQL
+// translation cache after the table metadata changed
+func (o *OpenTelemetry) RecordPartiQLCacheInvalidation(ctx context.Context) {
+	if o == nil || o.Config == nil || !o.Config.MetricsEnabled || o.invalidations == nil {
+		return
+	}
+	o.invalidations.Add(ctx, 1, metric.WithAttributes(o.attributeMap...))
+}
+
+// RecordFullTableScanMetric counts a read of the table that does not read a
+// key range. result is the action taken: "rejected", "warned", "throttled" or
+// "allowed" for the reads within the rate limit.
+func (o *OpenTelemetry) RecordFullTableScanMetric(ctx context.Context, table, result string) {
+	if o == nil || o.Config == nil || !o.Config.MetricsEnabled || o.fullTableScans == nil {
+		return
+	}
+
+	attr := o.attributeMap
+	attr = append(attr, attributeKeyTable.String(table))
+	attr = append(attr, attributeKeyResult.String(result))
+	o.fullTableScans.Add(ctx, 1, metric.WithAttributes(attr...))
+}
+
 // AddAnnotation add event to the span of the given ctx.
 func AddAnnotation(ctx context.Context, event string) {
 	span := trace.SpanFromContext(ctx)
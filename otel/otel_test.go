@@ -315,6 +315,10 @@ func TestRecordRequestCountMetric(t *testing.T) {
 	assert.NoErrorf(t, err, "error occurred")
 
 	ot.RecordRequestCountMetric(cyx, Attributes{Method: "handlePrepare"})
+	ot.RecordCacheMetric(cyx, "orders", "hit")
+	ot.RecordPartiQLCacheMetric(cyx, "hit")
+	ot.RecordPartiQLCacheInvalidation(cyx)
+	ot.RecordFullTableScanMetric(cyx, "orders", "rejected")
 
 	assert.NoErrorf(t, err, "error occurred")
 
@@ -329,6 +333,12 @@ func TestRecordRequestCountMetric(t *testing.T) {
 	assert.NoErrorf(t, err, "error occurred")
 
 	ot1.RecordRequestCountMetric(cyx, Attributes{Method: "handlePrepare"})
+	ot1.RecordCacheMetric(cyx, "orders", "miss")
+	var none *OpenTelemetry
+	none.RecordCacheMetric(cyx, "orders", "miss")
+	none.RecordPartiQLCacheMetric(cyx, "miss")
+	none.RecordPartiQLCacheInvalidation(cyx)
+	none.RecordFullTableScanMetric(cyx, "orders", "warned")
 
 	shutdownOpenTelemetryComponents(ds1)
 	assert.NoErrorf(t, err2, "error occurred")
@@ -0,0 +1,349 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package capture records request/response pairs of the adapter into rotating
+// JSONL files and replays them against an adapter endpoint
+package capture
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	filePrefix = "capture-"
+	fileSuffix = ".jsonl"
+	redacted   = "REDACTED"
+)
+
+// Record is a captured request/response pair
+type Record struct {
+	Time      time.Time       `json:"time"`
+	Target    string          `json:"target"`
+	Tenant    string          `json:"tenant,omitempty"`
+	Request   json.RawMessage `json:"request"`
+	Status    int             `json:"status"`
+	Response  json.RawMessage `json:"response"`
+	LatencyMs float64         `json:"latency_ms"`
+}
+
+// Action returns the DynamoDB operation of the record, e.g. PutItem
+func (r Record) Action() string {
+	return r.Target[strings.LastIndex(r.Target, ".")+1:]
+}
+
+// Writer appends records to JSONL files in a directory. A new file is started
+// once the current one exceeds maxBytes, and only the newest maxFiles files are kept.
+type Writer struct {
+	mu       sync.Mutex
+	dir      string
+	maxBytes int64
+	maxFiles int
+	file     *os.File
+	size     int64
+}
+
+// NewWriter creates dir if needed and returns a Writer appending to it.
+// maxBytes and maxFiles of 0 disable rotation and pruning.
+func NewWriter(dir string, maxBytes int64, maxFiles int) (*Writer, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &Writer{dir: dir, maxBytes: maxBytes, maxFiles: maxFiles}, nil
+}
+
+// Write appends rec as one JSON line
+func (w *Writer) Write(rec Record) error {
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil || (w.maxBytes > 0 && w.size+int64(len(line)) > w.maxBytes && w.size > 0) {
+		if err := w.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := w.file.Write(line)
+	w.size += int64(n)
+	return err
+}
+
+// Close closes the current file
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+func (w *Writer) rotate() error {
+	if w.file != nil {
+		if err := w.file.Close(); err != nil {
+			return err
+		}
+	}
+	// The timestamp keeps the files in creation order when sorted by name
+	name := filepath.Join(w.dir, filePrefix+time.Now().UTC().Format("20060102T150405.000000000")+fileSuffix)
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	w.file, w.size = f, info.Size()
+	return w.prune()
+}
+
+// prune removes the oldest capture files beyond maxFiles
+func (w *Writer) prune() error {
+	if w.maxFiles <= 0 {
+		return nil
+	}
+	files, err := captureFiles(w.dir)
+	if err != nil {
+		return err
+	}
+	for len(files) > w.maxFiles {
+		if err := os.Remove(files[0]); err != nil {
+			return err
+		}
+		files = files[1:]
+	}
+	return nil
+}
+
+func captureFiles(dir string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, filePrefix+"*"+fileSuffix))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// ReadRecords reads the records of capture files. Directories are expanded to
+// the capture files they contain, oldest first.
+func ReadRecords(paths ...string) ([]Record, error) {
+	var records []Record
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		files := []string{path}
+		if info.IsDir() {
+			if files, err = captureFiles(path); err != nil {
+				return nil, err
+			}
+		}
+		for _, file := range files {
+			recs, err := readFile(file)
+			if err != nil {
+				return nil, err
+			}
+			records = append(records, recs...)
+		}
+	}
+	return records, nil
+}
+
+func readFile(path string) ([]Record, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var records []Record
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var rec Record
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", path, line, err)
+		}
+		records = append(records, rec)
+	}
+	return records, scanner.Err()
+}
+
+// Sanitize returns body as JSON for a record. Bodies which are not JSON are
+// stored as a JSON string. With redact, every attribute value is replaced by a
+// placeholder of the same type, and the literals of PartiQL statements and
+// expressions by placeholders, so that captures carry no customer data.
+func Sanitize(body []byte, redact bool) json.RawMessage {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var doc interface{}
+	if err := decoder.Decode(&doc); err != nil {
+		quoted, _ := json.Marshal(string(body))
+		return quoted
+	}
+	if !redact {
+		return json.RawMessage(bytes.TrimSpace(body))
+	}
+	sanitized, err := json.Marshal(redactValues(doc))
+	if err != nil {
+		quoted, _ := json.Marshal(string(body))
+		return quoted
+	}
+	return sanitized
+}
+
+func redactValues(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		if len(val) == 1 {
+			for typ, attr := range val {
+				if placeholder, ok := redactAttribute(typ, attr); ok {
+					return map[string]interface{}{typ: placeholder}
+				}
+			}
+		}
+		for key, field := range val {
+			if text, ok := field.(string); ok && (key == "Statement" || strings.HasSuffix(key, "Expression")) {
+				val[key] = redactLiterals(text)
+				continue
+			}
+			val[key] = redactValues(field)
+		}
+	case []interface{}:
+		for i, elem := range val {
+			val[i] = redactValues(elem)
+		}
+	}
+	return v
+}
+
+// redactAttribute replaces the scalar members of an AttributeValue, keeping
+// its type and, for sets, its size. Numbers keep their sign so that range
+// checks behave alike on replay.
+func redactAttribute(typ string, v interface{}) (interface{}, bool) {
+	switch typ {
+	case "S":
+		if _, ok := v.(string); ok {
+			return redacted, true
+		}
+	case "B":
+		// binaries stay valid base64, so redacted requests can be replayed
+		if _, ok := v.(string); ok {
+			return base64.StdEncoding.EncodeToString([]byte(redacted)), true
+		}
+	case "N":
+		if s, ok := v.(string); ok {
+			if strings.HasPrefix(s, "-") {
+				return "-0", true
+			}
+			return "0", true
+		}
+	case "SS", "BS", "NS":
+		list, ok := v.([]interface{})
+		if !ok {
+			return nil, false
+		}
+		out := make([]interface{}, len(list))
+		for i := range list {
+			switch typ {
+			case "NS":
+				out[i] = fmt.Sprint(i)
+			case "BS":
+				out[i] = base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%s-%d", redacted, i)))
+			default:
+				out[i] = fmt.Sprintf("%s-%d", redacted, i)
+			}
+		}
+		return out, true
+	}
+	return nil, false
+}
+
+// redactLiterals replaces the string and number literals of a PartiQL
+// statement or an expression. Quoted names, placeholders such as ? and :v1,
+// and list indexes are kept.
+func redactLiterals(text string) string {
+	var b strings.Builder
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case c == '\'':
+			// '' is a quote inside the literal
+			j := i + 1
+			for ; j < len(text); j++ {
+				if text[j] == '\'' {
+					if j+1 < len(text) && text[j+1] == '\'' {
+						j++
+						continue
+					}
+					break
+				}
+			}
+			b.WriteString("'" + redacted + "'")
+			i = j
+		case c == '"':
+			end := strings.IndexByte(text[i+1:], '"')
+			if end < 0 {
+				b.WriteString(text[i:])
+				return b.String()
+			}
+			b.WriteString(text[i : i+end+2])
+			i += end + 1
+		case isDigit(c) && (i == 0 || !isNameByte(text[i-1])):
+			j := i
+			for j < len(text) && (isDigit(text[j]) || strings.IndexByte(".eE", text[j]) >= 0 ||
+				(strings.IndexByte("+-", text[j]) >= 0 && strings.IndexByte("eE", text[j-1]) >= 0)) {
+				j++
+			}
+			b.WriteByte('0')
+			i = j - 1
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// isNameByte tells whether a digit after c is part of a name, a placeholder
+// or a list index rather than a number literal
+func isNameByte(c byte) bool {
+	return isDigit(c) || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || strings.IndexByte("_:#[.", c) >= 0
+}
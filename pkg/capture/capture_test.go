@@ -0,0 +1,130 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capture
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriterRotatesAndPrunes(t *testing.T) {
+	dir := t.TempDir()
+	w, err := NewWriter(dir, 200, 2)
+	assert.NoError(t, err)
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 6; i++ {
+		assert.NoError(t, w.Write(Record{
+			Time:     start.Add(time.Duration(i) * time.Second),
+			Target:   "DynamoDB_20120810.GetItem",
+			Request:  json.RawMessage(`{"TableName":"orders"}`),
+			Status:   http.StatusOK,
+			Response: json.RawMessage(`{}`),
+		}))
+	}
+	assert.NoError(t, w.Close())
+
+	files, err := filepath.Glob(filepath.Join(dir, "capture-*.jsonl"))
+	assert.NoError(t, err)
+	assert.Len(t, files, 2)
+
+	records, err := ReadRecords(dir)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, records)
+	assert.Less(t, len(records), 6)
+	assert.Equal(t, start.Add(5*time.Second), records[len(records)-1].Time)
+	assert.Equal(t, "GetItem", records[0].Action())
+}
+
+func TestSanitize(t *testing.T) {
+	body := []byte(`{"TableName":"orders","Item":{"id":{"N":"-12.5"},"name":{"S":"alice"},"tags":{"SS":["a","b"]},"info":{"M":{"zip":{"S":"10001"}}}}}`)
+	assert.JSONEq(t, string(body), string(Sanitize(body, false)))
+	assert.JSONEq(t, `{"TableName":"orders","Item":{"id":{"N":"-0"},"name":{"S":"REDACTED"},"tags":{"SS":["REDACTED-0","REDACTED-1"]},"info":{"M":{"zip":{"S":"REDACTED"}}}}}`,
+		string(Sanitize(body, true)))
+	assert.Equal(t, `"not json"`, string(Sanitize([]byte("not json"), true)))
+
+	// redacted binaries are still base64
+	body = []byte(`{"Key":{"id":{"B":"AAE="},"parts":{"BS":["AA==","AQ=="]}}}`)
+	var sanitized struct {
+		Key map[string]struct {
+			B  []byte
+			BS [][]byte
+		}
+	}
+	assert.NoError(t, json.Unmarshal(Sanitize(body, true), &sanitized))
+	assert.Equal(t, []byte("REDACTED"), sanitized.Key["id"].B)
+	assert.Equal(t, [][]byte{[]byte("REDACTED-0"), []byte("REDACTED-1")}, sanitized.Key["parts"].BS)
+
+	// literals of statements and expressions are redacted, names and placeholders kept
+	body = []byte(`{"Statement":"SELECT * FROM \"orders-2\" WHERE customer = 'O''Brien' AND total > -12.5e3 AND tags[1] = ?",` +
+		`"TransactStatements":[{"Statement":"UPDATE orders SET note = 'gift' WHERE id = 42"}],` +
+		`"KeyConditionExpression":"customer = :c1 AND id > 7","ProjectionExpression":"#n1, tags[2]"}`)
+	assert.JSONEq(t, `{"Statement":"SELECT * FROM \"orders-2\" WHERE customer = 'REDACTED' AND total > -0 AND tags[1] = ?",`+
+		`"TransactStatements":[{"Statement":"UPDATE orders SET note = 'REDACTED' WHERE id = 0"}],`+
+		`"KeyConditionExpression":"customer = :c1 AND id > 0","ProjectionExpression":"#n1, tags[2]"}`,
+		string(Sanitize(body, true)))
+}
+
+func TestReplay(t *testing.T) {
+	var inFlight, maxInFlight int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			m := atomic.LoadInt32(&maxInFlight)
+			if n <= m || atomic.CompareAndSwapInt32(&maxInFlight, m, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		body, _ := io.ReadAll(r.Body)
+		_, _ = w.Write([]byte(`{"target":"` + r.Header.Get("X-Amz-Target") + `","tenant":"` + r.Header.Get("X-Tenant-Id") + `","body":` + string(body) + `}`))
+	}))
+	defer server.Close()
+
+	start := time.Now()
+	var records []Record
+	for i := 0; i < 8; i++ {
+		records = append(records, Record{
+			Time:    start.Add(time.Duration(i) * 20 * time.Millisecond),
+			Target:  "DynamoDB_20120810.GetItem",
+			Tenant:  "dev",
+			Request: json.RawMessage(`{"n":` + string(rune('0'+i)) + `}`),
+		})
+	}
+
+	results := Replay(context.Background(), records, ReplayOptions{URL: server.URL, Concurrency: 4, TenantHeader: "X-Tenant-Id"})
+	assert.Len(t, results, 8)
+	for i, result := range results {
+		assert.NoError(t, result.Err)
+		assert.Equal(t, http.StatusOK, result.Status)
+		assert.JSONEq(t, `{"target":"DynamoDB_20120810.GetItem","tenant":"dev","body":{"n":`+string(rune('0'+i))+`}}`, string(result.Response))
+	}
+	assert.Greater(t, atomic.LoadInt32(&maxInFlight), int32(1))
+
+	// The recorded 140ms are replayed 2x faster
+	begin := time.Now()
+	Replay(context.Background(), records, ReplayOptions{URL: server.URL, Concurrency: 8, Speedup: 2})
+	assert.GreaterOrEqual(t, time.Since(begin), 70*time.Millisecond)
+}
@@ -0,0 +1,125 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capture
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ReplayOptions configures a replay
+type ReplayOptions struct {
+	// URL of the adapter endpoint, e.g. "http://localhost:9050/v1"
+	URL string
+	// Concurrency is the number of requests in flight, 1 when not set
+	Concurrency int
+	// Speedup replays the recorded timing this many times faster. With 0 the
+	// requests are sent as fast as the concurrency allows.
+	Speedup float64
+	// Headers are added to every request
+	Headers map[string]string
+	// TenantHeader carries the recorded tenant of a request, if any
+	TenantHeader string
+	// Client used to send the requests, http.DefaultClient when nil
+	Client *http.Client
+}
+
+// ReplayResult is the response of the replayed endpoint to a record
+type ReplayResult struct {
+	Record   Record
+	Status   int
+	Response []byte
+	Latency  time.Duration
+	Err      error
+}
+
+// Replay re-sends the records to opts.URL and returns the results in record order
+func Replay(ctx context.Context, records []Record, opts ReplayOptions) []ReplayResult {
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	client := opts.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	results := make([]ReplayResult, len(records))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range jobs {
+				results[idx] = replayRecord(ctx, client, opts, records[idx])
+			}
+		}()
+	}
+
+	start := time.Now()
+	for i, rec := range records {
+		if opts.Speedup > 0 && len(records) > 0 {
+			offset := time.Duration(float64(rec.Time.Sub(records[0].Time)) / opts.Speedup)
+			if wait := time.Until(start.Add(offset)); wait > 0 {
+				select {
+				case <-time.After(wait):
+				case <-ctx.Done():
+				}
+			}
+		}
+		if ctx.Err() != nil {
+			results[i] = ReplayResult{Record: rec, Err: ctx.Err()}
+			continue
+		}
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return results
+}
+
+func replayRecord(ctx context.Context, client *http.Client, opts ReplayOptions, rec Record) ReplayResult {
+	result := ReplayResult{Record: rec}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, opts.URL, bytes.NewReader(rec.Request))
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.0")
+	req.Header.Set("X-Amz-Target", rec.Target)
+	for k, v := range opts.Headers {
+		req.Header.Set(k, v)
+	}
+	if opts.TenantHeader != "" && rec.Tenant != "" {
+		req.Header.Set(opts.TenantHeader, rec.Tenant)
+	}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	defer resp.Body.Close()
+	result.Response, result.Err = io.ReadAll(resp.Body)
+	result.Latency = time.Since(start)
+	result.Status = resp.StatusCode
+	return result
+}
@@ -33,6 +33,16 @@ var errorMapping = map[string]string{
 type Error struct {
 	ErrorCode    string `json:"errorCode"`
 	ErrorMessage string `json:"message"`
+	// CancellationReasons holds the reason of every item of a canceled
+	// transaction, in the order of the request
+	CancellationReasons []CancellationReason `json:"CancellationReasons,omitempty"`
+}
+
+// CancellationReason is the reason an item of a transaction was canceled.
+// Code is None for the items that did not cancel it.
+type CancellationReason struct {
+	Code    string `json:"Code"`
+	Message string `json:"Message,omitempty"`
 }
 
 // Error - convert error into string
@@ -53,7 +63,7 @@ func New(errorCode string, logMessage ...interface{}) *Error {
 func HTTPResponse(err error, body interface{}) (int, interface{}) {
 	e, ok := err.(*Error)
 	if ok {
-		return http.StatusBadRequest, map[string]interface{}{"code": e.ErrorCode, "message": e.ErrorMessage}
+		return http.StatusBadRequest, e.response()
 	}
 	logger.LogError(err)
 	logger.LogErrorF("body: %+v\n ", body)
@@ -64,7 +74,15 @@ func HTTPResponse(err error, body interface{}) (int, interface{}) {
 func (e Error) HTTPResponse(body interface{}) (int, interface{}) {
 	logger.LogErrorF("body: %+v\n ", body)
 
-	return http.StatusBadRequest, map[string]interface{}{"code": e.ErrorCode, "message": e.ErrorMessage}
+	return http.StatusBadRequest, e.response()
+}
+
+func (e Error) response() map[string]interface{} {
+	response := map[string]interface{}{"code": e.ErrorCode, "message": e.ErrorMessage}
+	if len(e.CancellationReasons) > 0 {
+		response["CancellationReasons"] = e.CancellationReasons
+	}
+	return response
 }
 
 // AssignError - this will assign error
@@ -61,6 +61,16 @@ func LogInfo(message ...interface{}) {
 	logger.Info(message)
 }
 
+// LogDebugW - This is debug level log with key/value fields
+func LogDebugW(message string, keysAndValues ...interface{}) {
+	logger.Debugw(message, keysAndValues...)
+}
+
+// LogErrorW - This is error level log with key/value fields
+func LogErrorW(message string, keysAndValues ...interface{}) {
+	logger.Errorw(message, keysAndValues...)
+}
+
 // LogWarn - This is Warn level log
 func LogWarn(message ...interface{}) {
 	logger.Warn(message)
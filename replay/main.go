@@ -0,0 +1,142 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// replay re-sends captured requests to an adapter endpoint and diffs the
+// responses against the recording.
+//
+//	go run ./replay -endpoint http://localhost:9050/v1 -concurrency 8 -speedup 10 captures/
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cloudspannerecosystem/dynamodb-adapter/apitesting"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/config"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/pkg/capture"
+)
+
+func main() {
+	endpoint := flag.String("endpoint", "http://localhost:9050/v1", "Adapter endpoint to replay the capture against")
+	concurrency := flag.Int("concurrency", 1, "Number of requests in flight")
+	speedup := flag.Float64("speedup", 0, "Replay the recorded timing this many times faster; 0 sends requests as fast as possible")
+	actions := flag.String("actions", "", "Comma separated actions to replay, e.g. GetItem,Query; all when empty")
+	tenantHeader := flag.String("tenant_header", config.DefaultTenantHeader, "Header carrying the recorded tenant")
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		log.Fatalln("usage: replay [flags] <capture file or directory>...")
+	}
+	records, err := capture.ReadRecords(flag.Args()...)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	records = filterActions(records, *actions)
+
+	results := capture.Replay(context.Background(), records, capture.ReplayOptions{
+		URL:          *endpoint,
+		Concurrency:  *concurrency,
+		Speedup:      *speedup,
+		TenantHeader: *tenantHeader,
+	})
+
+	report, latencies, failed := compare(results)
+	fmt.Print(report.String())
+	printLatencies(latencies)
+	if failed > 0 {
+		fmt.Printf("%d requests failed\n", failed)
+	}
+	if report.Failed() || failed > 0 {
+		os.Exit(1)
+	}
+}
+
+func filterActions(records []capture.Record, actions string) []capture.Record {
+	if actions == "" {
+		return records
+	}
+	wanted := map[string]bool{}
+	for _, action := range strings.Split(actions, ",") {
+		wanted[strings.TrimSpace(action)] = true
+	}
+	filtered := records[:0]
+	for _, rec := range records {
+		if wanted[rec.Action()] {
+			filtered = append(filtered, rec)
+		}
+	}
+	return filtered
+}
+
+// compare diffs every replayed response against its recording, the replay
+// taking the adapter side of the report and the recording the reference side
+func compare(results []capture.ReplayResult) (*apitesting.ConformanceReport, map[string][2][]time.Duration, int) {
+	var c apitesting.Conformance
+	report := &apitesting.ConformanceReport{}
+	latencies := map[string][2][]time.Duration{}
+	failed := 0
+	for i, result := range results {
+		rec := result.Record
+		if result.Err != nil {
+			log.Printf("%s #%d: %v", rec.Action(), i+1, result.Err)
+			failed++
+			continue
+		}
+		replayed := apitesting.DecodeResponse(result.Status, result.Response)
+		recorded := apitesting.DecodeResponse(rec.Status, rec.Response)
+		report.Results = append(report.Results, apitesting.ConformanceResult{
+			Request: apitesting.ConformanceRequest{
+				Name:    fmt.Sprintf("#%d %s", i+1, rec.Time.Format(time.RFC3339Nano)),
+				Action:  rec.Action(),
+				Request: rec.Request,
+			},
+			Adapter:     replayed,
+			Reference:   recorded,
+			Differences: c.Compare(rec.Action(), replayed, recorded),
+		})
+		l := latencies[rec.Action()]
+		l[0] = append(l[0], time.Duration(rec.LatencyMs*float64(time.Millisecond)))
+		l[1] = append(l[1], result.Latency)
+		latencies[rec.Action()] = l
+	}
+	return report, latencies, failed
+}
+
+// printLatencies prints the recorded and replayed median latency per action
+func printLatencies(latencies map[string][2][]time.Duration) {
+	actions := make([]string, 0, len(latencies))
+	for action := range latencies {
+		actions = append(actions, action)
+	}
+	sort.Strings(actions)
+	for _, action := range actions {
+		l := latencies[action]
+		fmt.Printf("%s: median latency recorded %v, replayed %v\n", action, median(l[0]), median(l[1]))
+	}
+}
+
+func median(d []time.Duration) time.Duration {
+	if len(d) == 0 {
+		return 0
+	}
+	sorted := append([]time.Duration(nil), d...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted[len(sorted)/2]
+}
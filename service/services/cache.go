@@ -0,0 +1,425 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package services
+
+import (
+	"container/list"
+	"context"
+	"maps"
+	"strings"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/models"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/storage"
+	translator "github.com/cloudspannerecosystem/dynamodb-adapter/translator/utils"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/utils"
+)
+
+// defaultCacheMaxItems is the number of items cached per table when
+// max_items is not set
+const defaultCacheMaxItems = 10000
+
+type cachedReadsKey struct{}
+
+// WithCachedReads returns a context whose GetItem and BatchGetItem reads may
+// be served by the item cache. Strongly consistent reads, and the reads of the
+// current item made by writes, use contexts without it.
+func WithCachedReads(ctx context.Context) context.Context {
+	return context.WithValue(ctx, cachedReadsKey{}, true)
+}
+
+func cachedReads(ctx context.Context) bool {
+	ok, _ := ctx.Value(cachedReadsKey{}).(bool)
+	return ok
+}
+
+var (
+	itemCacheOnce sync.Once
+	itemCacheInst *itemCache
+)
+
+// getItemCache returns the item cache of the config, nil when no table is
+// cached
+func getItemCache() *itemCache {
+	itemCacheOnce.Do(func() {
+		if models.GlobalConfig != nil {
+			itemCacheInst = newItemCache(models.GlobalConfig.ItemCache)
+		}
+	})
+	return itemCacheInst
+}
+
+// itemCache is a read-through cache of the items read by GetItem and
+// BatchGetItem. Every table has its own LRU list of entries; an entry is the
+// item read with a projection, or an empty item for a key without one.
+type itemCache struct {
+	mu     sync.Mutex
+	now    func() time.Time
+	tables map[string]*tableCache
+}
+
+// tableCache holds the entries of one table. generation changes with every
+// write to the table, so a read that overlaps a write does not cache the item
+// it read.
+type tableCache struct {
+	conf       models.ItemCacheTable
+	pKey, sKey string
+	generation uint64
+	lru        *list.List
+	items      map[string]map[string]*list.Element
+}
+
+type cacheEntry struct {
+	key, projection string
+	item            map[string]interface{}
+	expires         time.Time
+}
+
+func newItemCache(conf models.ItemCacheConfig) *itemCache {
+	if len(conf.Tables) == 0 {
+		return nil
+	}
+	c := &itemCache{now: time.Now, tables: map[string]*tableCache{}}
+	for _, tableConf := range conf.Tables {
+		if tableConf.NegativeTTL == 0 {
+			tableConf.NegativeTTL = tableConf.TTL
+		}
+		if tableConf.MaxItems <= 0 {
+			tableConf.MaxItems = defaultCacheMaxItems
+		}
+		for _, table := range tableConf.Tables {
+			c.tables[utils.ChangeTableNameForSpanner(table)] = &tableCache{
+				conf:  tableConf,
+				lru:   list.New(),
+				items: map[string]map[string]*list.Element{},
+			}
+		}
+	}
+	return c
+}
+
+// table returns the cache of the table, nil when it is not cached
+func (c *itemCache) table(tableName string, tableConf models.TableConfig) *tableCache {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t, ok := c.tables[utils.ChangeTableNameForSpanner(tableName)]
+	if !ok {
+		return nil
+	}
+	t.pKey, t.sKey = tableConf.PartitionKey, tableConf.SortKey
+	return t
+}
+
+// get returns a copy of the item cached for the key and projection
+func (c *itemCache) get(t *tableCache, key, projection string) (map[string]interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := t.items[key][projection]
+	if !ok {
+		return nil, false
+	}
+	entry := e.Value.(*cacheEntry)
+	if !c.now().Before(entry.expires) {
+		t.remove(e)
+		return nil, false
+	}
+	t.lru.MoveToFront(e)
+	return maps.Clone(entry.item), true
+}
+
+// generation returns the generation of the table to pass to put
+func (c *itemCache) generation(t *tableCache) uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return t.generation
+}
+
+// put caches the item read for the key and projection, unless the table was
+// written since generation
+func (c *itemCache) put(t *tableCache, generation uint64, key, projection string, item map[string]interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if t.generation != generation {
+		return
+	}
+	ttl := t.conf.TTL
+	if len(item) == 0 {
+		ttl = t.conf.NegativeTTL
+	}
+	if ttl <= 0 {
+		return
+	}
+	if e, ok := t.items[key][projection]; ok {
+		t.remove(e)
+	}
+	if t.items[key] == nil {
+		t.items[key] = map[string]*list.Element{}
+	}
+	t.items[key][projection] = t.lru.PushFront(&cacheEntry{
+		key:        key,
+		projection: projection,
+		item:       maps.Clone(item),
+		expires:    c.now().Add(ttl),
+	})
+	for t.lru.Len() > t.conf.MaxItems {
+		t.remove(t.lru.Back())
+	}
+}
+
+func (t *tableCache) remove(e *list.Element) {
+	entry := t.lru.Remove(e).(*cacheEntry)
+	delete(t.items[entry.key], entry.projection)
+	if len(t.items[entry.key]) == 0 {
+		delete(t.items, entry.key)
+	}
+}
+
+// invalidate drops the entries of the keys of the rows written to the table,
+// or of the whole table when keys is nil
+func (c *itemCache) invalidate(tableName string, keys func(t *tableCache) []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t, ok := c.tables[utils.ChangeTableNameForSpanner(tableName)]
+	if !ok {
+		return
+	}
+	t.generation++
+	if keys == nil || t.pKey == "" {
+		t.lru.Init()
+		t.items = map[string]map[string]*list.Element{}
+		return
+	}
+	for _, key := range keys(t) {
+		for _, e := range t.items[key] {
+			t.remove(e)
+		}
+	}
+}
+
+// invalidateRows drops the entries of the rows, which hold the key columns
+func (c *itemCache) invalidateRows(tableName string, rows ...map[string]interface{}) {
+	c.invalidate(tableName, func(t *tableCache) []string {
+		keys := make([]string, len(rows))
+		for i, row := range rows {
+			keys[i] = keyString(row[t.pKey], row[t.sKey])
+		}
+		return keys
+	})
+}
+
+// invalidateMutations drops the entries of the rows written by mutations
+func (c *itemCache) invalidateMutations(ms []*storage.Mutation) {
+	for _, m := range ms {
+		c.invalidate(m.Table, func(t *tableCache) []string {
+			if m.Columns != nil {
+				return []string{keyString(m.Columns[t.pKey], m.Columns[t.sKey])}
+			}
+			if len(m.Key) == 0 {
+				return nil
+			}
+			var sValue interface{}
+			if len(m.Key) > 1 {
+				sValue = m.Key[1]
+			}
+			return []string{keyString(m.Key[0], sValue)}
+		})
+	}
+}
+
+// recordCacheLookup counts a lookup of the item cache
+func recordCacheLookup(ctx context.Context, tableName string, item map[string]interface{}, hit bool) {
+	if models.GlobalProxy == nil {
+		return
+	}
+	result := "miss"
+	if hit {
+		result = "hit"
+		if len(item) == 0 {
+			result = "negative_hit"
+		}
+	}
+	models.GlobalProxy.OtelInst.RecordCacheMetric(ctx, tableName, result)
+}
+
+// cachedGet reads the item with the key through the item cache of the table
+func cachedGet(ctx context.Context, tableConf models.TableConfig, tableName string, pValue, sValue interface{}, projectionCols []string) (map[string]interface{}, map[string]interface{}, error) {
+	cache := getItemCache()
+	var t *tableCache
+	if cache != nil && cachedReads(ctx) {
+		t = cache.table(tableName, tableConf)
+	}
+	if t == nil {
+		return GetStorage().SpannerGet(ctx, tableName, pValue, sValue, projectionCols)
+	}
+	key, projection := keyString(pValue, sValue), strings.Join(projectionCols, ",")
+	item, hit := cache.get(t, key, projection)
+	recordCacheLookup(ctx, tableName, item, hit)
+	if hit {
+		return item, nil, nil
+	}
+	generation := cache.generation(t)
+	item, row, err := GetStorage().SpannerGet(ctx, tableName, pValue, sValue, projectionCols)
+	if err == nil {
+		cache.put(t, generation, key, projection, item)
+	}
+	return item, row, err
+}
+
+// cachedBatchGet reads the rows with the keys through the item cache of the
+// table, reading the keys that are not cached in one batch. The rows hold the
+// key columns, readCols is empty or includes them.
+func cachedBatchGet(ctx context.Context, tableConf models.TableConfig, tableName string, keyMapArray []map[string]interface{}, readCols []string) ([]map[string]interface{}, error) {
+	cache := getItemCache()
+	var t *tableCache
+	if cache != nil && cachedReads(ctx) {
+		t = cache.table(tableName, tableConf)
+	}
+	projection := strings.Join(readCols, ",")
+	rows := []map[string]interface{}{}
+	missing := keyMapArray
+	if t != nil {
+		missing = nil
+		for _, key := range keyMapArray {
+			item, hit := cache.get(t, itemKey(tableConf, key), projection)
+			recordCacheLookup(ctx, tableName, item, hit)
+			switch {
+			case !hit:
+				missing = append(missing, key)
+			case len(item) > 0:
+				rows = append(rows, item)
+			}
+		}
+		if len(missing) == 0 {
+			return rows, nil
+		}
+	}
+
+	var generation uint64
+	if t != nil {
+		generation = cache.generation(t)
+	}
+	var pValues []interface{}
+	var sValues []interface{}
+	for i := 0; i < len(missing); i++ {
+		pValue := missing[i][tableConf.PartitionKey]
+		if tableConf.SortKey != "" {
+			sValue := missing[i][tableConf.SortKey]
+			sValues = append(sValues, sValue)
+		}
+		pValues = append(pValues, pValue)
+	}
+	read, err := GetStorage().SpannerBatchGet(ctx, tableName, pValues, sValues, readCols)
+	if err != nil {
+		return nil, err
+	}
+	if t != nil {
+		found := make(map[string]map[string]interface{}, len(read))
+		for _, row := range read {
+			found[itemKey(tableConf, row)] = row
+		}
+		for _, key := range missing {
+			k := itemKey(tableConf, key)
+			cache.put(t, generation, k, projection, found[k])
+		}
+	}
+	return append(rows, read...), nil
+}
+
+// cachingStorage drops the cached items changed by the writes made through
+// the storage
+type cachingStorage struct {
+	Storage
+	cache *itemCache
+}
+
+func (s cachingStorage) SpannerPut(ctx context.Context, table string, m map[string]interface{}, eval *models.Eval, expr *models.UpdateExpressionCondition, spannerRow map[string]interface{}) (map[string]interface{}, error) {
+	defer s.cache.invalidateRows(table, m)
+	return s.Storage.SpannerPut(ctx, table, m, eval, expr, spannerRow)
+}
+
+func (s cachingStorage) SpannerAdd(ctx context.Context, table string, m map[string]interface{}, eval *models.Eval, expr *models.UpdateExpressionCondition) (map[string]interface{}, error) {
+	defer s.cache.invalidateRows(table, m)
+	return s.Storage.SpannerAdd(ctx, table, m, eval, expr)
+}
+
+func (s cachingStorage) SpannerDel(ctx context.Context, table string, m map[string]interface{}, eval *models.Eval, expr *models.UpdateExpressionCondition) error {
+	defer s.cache.invalidateRows(table, m)
+	return s.Storage.SpannerDel(ctx, table, m, eval, expr)
+}
+
+func (s cachingStorage) SpannerRemove(ctx context.Context, table string, m map[string]interface{}, eval *models.Eval, expr *models.UpdateExpressionCondition, colsToRemove []string, oldRes map[string]interface{}) error {
+	defer s.cache.invalidateRows(table, m)
+	return s.Storage.SpannerRemove(ctx, table, m, eval, expr, colsToRemove, oldRes)
+}
+
+func (s cachingStorage) SpannerDelete(ctx context.Context, table string, m map[string]interface{}, eval *models.Eval, expr *models.UpdateExpressionCondition) error {
+	defer s.cache.invalidateRows(table, m)
+	return s.Storage.SpannerDelete(ctx, table, m, eval, expr)
+}
+
+func (s cachingStorage) SpannerBatchPut(ctx context.Context, table string, m []map[string]interface{}, spannerRow []map[string]interface{}) error {
+	defer s.cache.invalidateRows(table, m...)
+	return s.Storage.SpannerBatchPut(ctx, table, m, spannerRow)
+}
+
+func (s cachingStorage) SpannerBatchDelete(ctx context.Context, table string, keys []map[string]interface{}) error {
+	defer s.cache.invalidateRows(table, keys...)
+	return s.Storage.SpannerBatchDelete(ctx, table, keys)
+}
+
+func (s cachingStorage) SpannerBatchWrite(ctx context.Context, table string, puts, deletes []map[string]interface{}) ([]int, []int, error) {
+	defer s.cache.invalidateRows(table, deletes...)
+	defer s.cache.invalidateRows(table, puts...)
+	return s.Storage.SpannerBatchWrite(ctx, table, puts, deletes)
+}
+
+func (s cachingStorage) InsertUpdateOrDeleteStatement(ctx context.Context, query *translator.DeleteUpdateQueryMap) (map[string]interface{}, error) {
+	defer s.cache.invalidate(query.Table, nil)
+	return s.Storage.InsertUpdateOrDeleteStatement(ctx, query)
+}
+
+func (s cachingStorage) ReadWriteTransaction(ctx context.Context, tables []string, f func(context.Context, storage.Transaction) error) error {
+	var ms []*storage.Mutation
+	var dml []string
+	defer func() {
+		s.cache.invalidateMutations(ms)
+		for _, table := range dml {
+			s.cache.invalidate(table, nil)
+		}
+	}()
+	return s.Storage.ReadWriteTransaction(ctx, tables, func(ctx context.Context, txn storage.Transaction) error {
+		return f(ctx, recordingTransaction{Transaction: txn, ms: &ms, dml: &dml})
+	})
+}
+
+// recordingTransaction records the mutations buffered in the transaction and
+// the tables its statements write
+type recordingTransaction struct {
+	storage.Transaction
+	ms  *[]*storage.Mutation
+	dml *[]string
+}
+
+func (t recordingTransaction) BufferWrite(ms []*storage.Mutation) error {
+	*t.ms = append(*t.ms, ms...)
+	return t.Transaction.BufferWrite(ms)
+}
+
+func (t recordingTransaction) ExecuteDML(ctx context.Context, table string, stmt spanner.Statement) (int64, error) {
+	*t.dml = append(*t.dml, table)
+	return t.Transaction.ExecuteDML(ctx, table, stmt)
+}
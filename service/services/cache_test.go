@@ -0,0 +1,122 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package services
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cloudspannerecosystem/dynamodb-adapter/models"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/storage"
+	"gopkg.in/go-playground/assert.v1"
+)
+
+// setupItemCache caches the orders table of setupQueryTable. It returns the
+// storage under the cache, whose writes do not invalidate it, and a function
+// moving the clock of the cache.
+func setupItemCache(t *testing.T, conf models.ItemCacheTable, statuses []string) (Storage, func(time.Duration)) {
+	setupQueryTable(t, statuses, 0)
+	conf.Tables = []string{"orders"}
+	cache := newItemCache(models.ItemCacheConfig{Tables: []models.ItemCacheTable{conf}})
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+
+	itemCacheOnce.Do(func() {})
+	original := itemCacheInst
+	itemCacheInst = cache
+	t.Cleanup(func() { itemCacheInst = original })
+	backend := GetStorage()
+	SetStorage(cachingStorage{Storage: backend, cache: cache})
+	return backend, func(d time.Duration) { now = now.Add(d) }
+}
+
+func setStatus(t *testing.T, s Storage, id, status string) {
+	_, err := s.SpannerPut(context.Background(), "orders", map[string]interface{}{
+		"customer": "alice", "id": models.Number(id), "status": status,
+	}, &models.Eval{}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestItemCacheGet(t *testing.T) {
+	backend, advance := setupItemCache(t, models.ItemCacheTable{TTL: time.Minute, NegativeTTL: time.Second}, []string{"open"})
+	svc := &spannerService{}
+	ctx := WithCachedReads(context.Background())
+	get := func(ctx context.Context, id string) interface{} {
+		item, _, err := svc.GetWithProjection(ctx, "orders", map[string]interface{}{"customer": "alice", "id": models.Number(id)}, "status", nil)
+		assert.Equal(t, err, nil)
+		return item["status"]
+	}
+
+	assert.Equal(t, get(ctx, "1"), "open")
+	setStatus(t, backend, "1", "closed")
+	assert.Equal(t, get(ctx, "1"), "open")
+	// strongly consistent reads bypass the cache
+	assert.Equal(t, get(context.Background(), "1"), "closed")
+	advance(time.Minute)
+	assert.Equal(t, get(ctx, "1"), "closed")
+
+	// writes through the service storage invalidate the item
+	setStatus(t, GetStorage(), "1", "open")
+	assert.Equal(t, get(ctx, "1"), "open")
+	err := GetStorage().ReadWriteTransaction(ctx, []string{"orders"}, func(ctx context.Context, txn storage.Transaction) error {
+		return txn.BufferWrite([]*storage.Mutation{{Table: "orders", Columns: map[string]interface{}{
+			"customer": "alice", "id": models.Number("1"), "status": "shipped",
+		}}})
+	})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, get(ctx, "1"), "shipped")
+
+	// missing items are cached for the negative TTL
+	assert.Equal(t, get(ctx, "2"), nil)
+	setStatus(t, backend, "2", "open")
+	assert.Equal(t, get(ctx, "2"), nil)
+	advance(time.Second)
+	assert.Equal(t, get(ctx, "2"), "open")
+}
+
+func TestItemCacheBatchGet(t *testing.T) {
+	backend, _ := setupItemCache(t, models.ItemCacheTable{TTL: time.Minute, MaxItems: 2}, []string{"open", "open", "open"})
+	ctx := WithCachedReads(context.Background())
+	keys := []map[string]interface{}{
+		{"customer": "alice", "id": models.Number("1")},
+		{"customer": "alice", "id": models.Number("2")},
+		{"customer": "alice", "id": models.Number("3")},
+	}
+	statuses := func() []interface{} {
+		budget := new(atomic.Int64)
+		budget.Store(1024)
+		items, _, err := BatchGetWithProjection(ctx, "orders", keys, "status", nil, budget)
+		assert.Equal(t, err, nil)
+		var statuses []interface{}
+		for _, item := range items {
+			statuses = append(statuses, item["status"])
+		}
+		return statuses
+	}
+
+	assert.Equal(t, statuses(), []interface{}{"open", "open", "open"})
+	for _, id := range []string{"1", "2", "3"} {
+		setStatus(t, backend, id, "closed")
+	}
+	// the first item was evicted to keep two items
+	assert.Equal(t, statuses(), []interface{}{"closed", "open", "open"})
+
+	assert.Equal(t, GetStorage().SpannerBatchDelete(ctx, "orders", keys[1:2]), nil)
+	assert.Equal(t, statuses(), []interface{}{"closed", "open"})
+}
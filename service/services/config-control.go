@@ -23,7 +23,6 @@ import (
 	"cloud.google.com/go/spanner"
 	"github.com/cloudspannerecosystem/dynamodb-adapter/models"
 	"github.com/cloudspannerecosystem/dynamodb-adapter/pkg/logger"
-	"github.com/cloudspannerecosystem/dynamodb-adapter/storage"
 	"github.com/robfig/cron"
 )
 
@@ -54,7 +53,7 @@ func fetchConfigData() {
 	logger.LogDebug("Fetching starts")
 	stmt := spanner.Statement{}
 	stmt.SQL = "SELECT * FROM dynamodb_adapter_config_manager"
-	data, err := storage.GetStorageInstance().ExecuteSpannerQuery(ctx, "dynamodb_adapter_config_manager", []string{"tableName", "config", "cronTime", "uniqueValue", "enabledStream"}, false, stmt)
+	data, err := GetStorage().ExecuteSpannerQuery(ctx, "dynamodb_adapter_config_manager", []string{"tableName", "config", "cronTime", "uniqueValue", "enabledStream"}, false, stmt)
 	if err != nil {
 		models.ConfigController.StopConfigManager = true
 		logger.LogDebug(err)
@@ -0,0 +1,105 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"cloud.google.com/go/spanner"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/storage"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+type explainKey struct{}
+
+// Explain records the Spanner statements the Query, Scan and PartiQL SELECT
+// requests run with its context, with the plans Spanner chose for them. With
+// Stats, the statements are run with QueryWithStats for their execution
+// statistics.
+type Explain struct {
+	Stats bool
+
+	mu         sync.Mutex
+	statements []ExplainedStatement
+}
+
+// ExplainedStatement is a statement a request ran: its table and the
+// secondary index it reads, the translated SQL and its bound parameters, and
+// the plan and statistics Spanner returned for it, or the error explaining it
+// failed with.
+type ExplainedStatement struct {
+	TableName string                 `json:"TableName"`
+	IndexName string                 `json:"IndexName,omitempty"`
+	SQL       string                 `json:"SQL"`
+	Params    map[string]interface{} `json:"Params,omitempty"`
+	Plan      json.RawMessage        `json:"Plan,omitempty"`
+	Stats     map[string]interface{} `json:"Stats,omitempty"`
+	Error     string                 `json:"Error,omitempty"`
+}
+
+// WithExplain returns a context whose statements are recorded in e
+func WithExplain(ctx context.Context, e *Explain) context.Context {
+	return context.WithValue(ctx, explainKey{}, e)
+}
+
+// Statements returns the statements recorded, in the order they ran
+func (e *Explain) Statements() []ExplainedStatement {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]ExplainedStatement{}, e.statements...)
+}
+
+// explainStatement prepares the recording of a statement of a request run
+// with WithExplain. The plan of the statement is read without running it, or
+// with Stats the returned context runs it with QueryWithStats, so the plan and
+// statistics are those of the query that ran. The returned function records
+// the statement once the query ran with err. Failing to explain a statement
+// does not fail the request, the error is recorded instead.
+func explainStatement(ctx context.Context, table, index string, stmt spanner.Statement) (context.Context, func(err error)) {
+	e, ok := ctx.Value(explainKey{}).(*Explain)
+	if !ok {
+		return ctx, func(error) {}
+	}
+	explained := ExplainedStatement{TableName: table, IndexName: index, SQL: stmt.SQL, Params: stmt.Params}
+	var res *storage.QueryExplain
+	var explainErr error
+	if e.Stats {
+		ctx = storage.WithQueryStats(ctx, func(r *storage.QueryExplain) { res = r })
+	} else {
+		res, explainErr = GetStorage().ExplainQuery(ctx, table, stmt)
+	}
+	return ctx, func(err error) {
+		if err == nil {
+			err = explainErr
+		}
+		if err == nil && res != nil && res.Plan != nil {
+			explained.Plan, err = protojson.Marshal(res.Plan)
+		}
+		if err != nil {
+			explained.Error = err.Error()
+		} else if res != nil {
+			explained.Stats = res.Stats
+		}
+		e.record(explained)
+	}
+}
+
+func (e *Explain) record(explained ExplainedStatement) {
+	e.mu.Lock()
+	e.statements = append(e.statements, explained)
+	e.mu.Unlock()
+}
@@ -0,0 +1,130 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package services
+
+import (
+	"context"
+	"sync"
+
+	"github.com/cloudspannerecosystem/dynamodb-adapter/models"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/pkg/errors"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/pkg/logger"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/utils"
+	"golang.org/x/time/rate"
+)
+
+var (
+	fullTableScanOnce     sync.Once
+	fullTableScanPolicies map[string]*fullTableScanPolicy
+)
+
+// fullTableScanPolicy is the policy of the full table reads of a table, with
+// the limiter of the rate limited ones
+type fullTableScanPolicy struct {
+	action  string
+	limiter *rate.Limiter
+}
+
+// getFullTableScanPolicies returns the policies of the config by table
+func getFullTableScanPolicies() map[string]*fullTableScanPolicy {
+	fullTableScanOnce.Do(func() {
+		if models.GlobalConfig != nil {
+			fullTableScanPolicies = newFullTableScanPolicies(models.GlobalConfig.FullTableScans)
+		}
+	})
+	return fullTableScanPolicies
+}
+
+func newFullTableScanPolicies(conf models.FullTableScanConfig) map[string]*fullTableScanPolicy {
+	policies := map[string]*fullTableScanPolicy{}
+	for _, policyConf := range conf.Tables {
+		policy := &fullTableScanPolicy{action: policyConf.Action}
+		switch policyConf.Action {
+		case models.FullTableScanReject, models.FullTableScanWarn:
+		case models.FullTableScanRateLimit:
+			burst := policyConf.Burst
+			if burst <= 0 {
+				burst = 1
+			}
+			policy.limiter = rate.NewLimiter(rate.Limit(policyConf.PerSecond), burst)
+		default:
+			logger.LogError("unknown full table scan action ", policyConf.Action, " of tables ", policyConf.Tables)
+			continue
+		}
+		for _, table := range policyConf.Tables {
+			policies[utils.ChangeTableNameForSpanner(table)] = policy
+		}
+	}
+	return policies
+}
+
+// checkFullTableScan applies the policy of the table to a read that filters
+// items without reading a key range. read names the request and missing the
+// condition it lacks, for the message of the rejected reads.
+func checkFullTableScan(ctx context.Context, table, read, missing string) error {
+	policy, ok := getFullTableScanPolicies()[utils.ChangeTableNameForSpanner(table)]
+	if !ok {
+		return nil
+	}
+	var result string
+	var err error
+	message := read + " of table " + table + " reads the whole table, " + missing
+	switch policy.action {
+	case models.FullTableScanReject:
+		result = "rejected"
+		err = errors.New("ValidationException", message)
+	case models.FullTableScanWarn:
+		result = "warned"
+		logger.LogWarn(message)
+	case models.FullTableScanRateLimit:
+		result = "allowed"
+		if !policy.limiter.Allow() {
+			result = "throttled"
+			err = errors.New("ThrottlingException", "Rate of full table reads exceeded: "+message)
+		}
+	}
+	if models.GlobalProxy != nil {
+		models.GlobalProxy.OtelInst.RecordFullTableScanMetric(ctx, table, result)
+	}
+	return err
+}
+
+// comparesKey reports whether a key condition expression compares the
+// partition key pKey, which key conditions only compare with =
+func comparesKey(keyCondition, pKey string) bool {
+	for _, column := range conditionValueColumns(keyCondition) {
+		if column == pKey {
+			return true
+		}
+	}
+	return false
+}
+
+// checkQueryFullTableScan applies the policy of the table to a Query or Scan
+// with a FilterExpression whose key condition does not compare the partition
+// key pKey of the table or index it reads
+func checkQueryFullTableScan(ctx context.Context, table string, query *models.Query, pKey string) error {
+	if query.FilterExp == "" || comparesKey(query.RangeExp, pKey) {
+		return nil
+	}
+	read, key := "Query", "partition key "+pKey
+	if query.RangeExp == "" {
+		read = "Scan"
+	}
+	if query.IndexName != "" {
+		key += " of index " + query.IndexName
+	}
+	return checkFullTableScan(ctx, table, read+" with a FilterExpression", "use a Query with a condition on the "+key+" to read a key range")
+}
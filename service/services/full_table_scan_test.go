@@ -0,0 +1,126 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/models"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/pkg/errors"
+	"gopkg.in/go-playground/assert.v1"
+)
+
+func setFullTableScanPolicy(t *testing.T, policy models.FullTableScanPolicy) {
+	fullTableScanOnce.Do(func() {})
+	policies := fullTableScanPolicies
+	fullTableScanPolicies = newFullTableScanPolicies(models.FullTableScanConfig{Tables: []models.FullTableScanPolicy{policy}})
+	t.Cleanup(func() { fullTableScanPolicies = policies })
+}
+
+func errorCode(err error) string {
+	if e, ok := err.(*errors.Error); ok {
+		return e.ErrorCode
+	}
+	return ""
+}
+
+func TestFullTableScanReject(t *testing.T) {
+	setupQueryTable(t, []string{"open", "closed"}, 0)
+	setFullTableScanPolicy(t, models.FullTableScanPolicy{Tables: []string{"orders"}, Action: models.FullTableScanReject})
+	ctx := context.Background()
+
+	// a Scan with a FilterExpression reads the whole table
+	_, err := Scan(ctx, models.ScanMeta{
+		TableName:              "orders",
+		FilterExpression:       "status = :s",
+		ExpressionAttributeMap: map[string]interface{}{":s": "open"},
+		Limit:                  10,
+	})
+	assert.Equal(t, errorCode(err), "ValidationException")
+	assert.Equal(t, strings.Contains(err.(*errors.Error).ErrorMessage, "partition key customer"), true)
+
+	// Scans without a filter and Queries of a partition are read
+	res, err := Scan(ctx, models.ScanMeta{TableName: "orders", Limit: 10})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, res["Count"], 2)
+	res, _, err = QueryAttributes(ctx, models.Query{
+		TableName:   "orders",
+		RangeExp:    "customer = :c",
+		FilterExp:   "status = :s",
+		RangeValMap: map[string]interface{}{":c": "alice", ":s": "open"},
+		Limit:       10,
+	})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, res["Count"], 1)
+
+	// SELECTs with a WHERE clause must name the partitions they read
+	_, err = ExecuteStatement(ctx, models.ExecuteStatement{
+		TableName:  "orders",
+		Statement:  "SELECT * FROM orders WHERE status = ?",
+		Parameters: []*dynamodb.AttributeValue{{S: aws.String("open")}},
+	})
+	assert.Equal(t, errorCode(err), "ValidationException")
+	assert.Equal(t, strings.Contains(err.(*errors.Error).ErrorMessage, "partition key customer with = or IN"), true)
+	res, err = ExecuteStatement(ctx, models.ExecuteStatement{
+		TableName:  "orders",
+		Statement:  "SELECT * FROM orders WHERE customer = ? AND status = ?",
+		Parameters: []*dynamodb.AttributeValue{{S: aws.String("alice")}, {S: aws.String("open")}},
+	})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(res["Items"].([]map[string]interface{})), 1)
+
+	// so must SELECTs without a WHERE clause
+	_, err = ExecuteStatement(ctx, models.ExecuteStatement{TableName: "orders", Statement: "SELECT * FROM orders"})
+	assert.Equal(t, errorCode(err), "ValidationException")
+	assert.Equal(t, strings.Contains(err.(*errors.Error).ErrorMessage, "partition key customer with = or IN"), true)
+}
+
+func TestFullTableScanWarnAndRateLimit(t *testing.T) {
+	setupQueryTable(t, []string{"open", "closed"}, 0)
+	ctx := context.Background()
+	scan := func() error {
+		_, err := Scan(ctx, models.ScanMeta{
+			TableName:              "orders",
+			FilterExpression:       "status = :s",
+			ExpressionAttributeMap: map[string]interface{}{":s": "open"},
+			Limit:                  10,
+		})
+		return err
+	}
+
+	setFullTableScanPolicy(t, models.FullTableScanPolicy{Tables: []string{"orders"}, Action: models.FullTableScanWarn})
+	assert.Equal(t, scan(), nil)
+	assert.Equal(t, scan(), nil)
+
+	// the burst is read, the reads after it are throttled
+	setFullTableScanPolicy(t, models.FullTableScanPolicy{Tables: []string{"orders"}, Action: models.FullTableScanRateLimit})
+	assert.Equal(t, scan(), nil)
+	assert.Equal(t, errorCode(scan()), "ThrottlingException")
+
+	// unknown actions are ignored
+	setFullTableScanPolicy(t, models.FullTableScanPolicy{Tables: []string{"orders"}, Action: "block"})
+	assert.Equal(t, scan(), nil)
+}
+
+func TestComparesKey(t *testing.T) {
+	assert.Equal(t, comparesKey("customer = :c AND id > :id", "customer"), true)
+	assert.Equal(t, comparesKey("`customer`=:c", "customer"), true)
+	assert.Equal(t, comparesKey("customer_id = :c", "customer"), false)
+	assert.Equal(t, comparesKey("", "customer"), false)
+}
@@ -0,0 +1,582 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"cloud.google.com/go/spanner"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/models"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/pkg/errors"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/pkg/logger"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/storage"
+	translator "github.com/cloudspannerecosystem/dynamodb-adapter/translator/utils"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/utils"
+)
+
+const invalidNextToken = "Invalid NextToken"
+
+// randomNextTokenKey signs the NextTokens of PartiQL statements when no key
+// is configured. It is generated when the adapter starts, so a NextToken is
+// then only valid on the adapter that returned it.
+var randomNextTokenKey = func() []byte {
+	key := make([]byte, sha256.Size)
+	if _, err := rand.Read(key); err != nil {
+		panic(err)
+	}
+	return key
+}()
+
+// nextTokenKeyCache holds the keys read for the PartiQL config they were read
+// for
+var nextTokenKeyCache struct {
+	sync.Mutex
+	config models.PartiQLConfig
+	keys   [][]byte
+}
+
+// nextTokenKeys returns the keys NextTokens are signed with, the first one for
+// new NextTokens
+func nextTokenKeys() ([][]byte, error) {
+	var partiQLConfig models.PartiQLConfig
+	if models.GlobalConfig != nil {
+		partiQLConfig = models.GlobalConfig.PartiQL
+	}
+	nextTokenKeyCache.Lock()
+	defer nextTokenKeyCache.Unlock()
+	if nextTokenKeyCache.keys != nil && nextTokenKeyCache.config == partiQLConfig {
+		return nextTokenKeyCache.keys, nil
+	}
+	if partiQLConfig.NextTokenKey == (models.Secret{}) && partiQLConfig.PreviousNextTokenKey != (models.Secret{}) {
+		return nil, fmt.Errorf("partiql.previous_next_token_key is set without partiql.next_token_key")
+	}
+	var keys [][]byte
+	for _, secret := range []models.Secret{partiQLConfig.NextTokenKey, partiQLConfig.PreviousNextTokenKey} {
+		key, err := secret.Read()
+		if err != nil {
+			return nil, err
+		}
+		if key != "" {
+			keys = append(keys, []byte(key))
+		}
+	}
+	if len(keys) == 0 {
+		keys = [][]byte{randomNextTokenKey}
+	}
+	nextTokenKeyCache.config, nextTokenKeyCache.keys = partiQLConfig, keys
+	return keys, nil
+}
+
+// nextToken is the position a page of a PartiQL SELECT ends at
+type nextToken struct {
+	// Statement is the hash of the statement and its parameters
+	Statement string `json:"s"`
+	// Position holds the values of the ordering columns of the last item
+	// evaluated
+	Position []*dynamodb.AttributeValue `json:"p"`
+}
+
+// statementHash identifies a statement and its parameters, a NextToken only
+// continues the statement it was returned for
+func statementHash(executeStatement models.ExecuteStatement) string {
+	params, _ := json.Marshal(executeStatement.Parameters)
+	h := sha256.New()
+	h.Write([]byte(executeStatement.Statement))
+	h.Write([]byte{0})
+	h.Write(params)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// encodeNextToken returns the opaque NextToken continuing after position.
+// Tokens are signed, so they cannot be altered.
+func encodeNextToken(hash string, position []interface{}) (string, error) {
+	token := nextToken{Statement: hash}
+	for _, v := range position {
+		switch v := v.(type) {
+		case string:
+			token.Position = append(token.Position, &dynamodb.AttributeValue{S: aws.String(v)})
+		case models.Number:
+			token.Position = append(token.Position, &dynamodb.AttributeValue{N: aws.String(v.String())})
+		case []byte:
+			token.Position = append(token.Position, &dynamodb.AttributeValue{B: v})
+		default:
+			return "", fmt.Errorf("unsupported key value %T", v)
+		}
+	}
+	payload, err := json.Marshal(token)
+	if err != nil {
+		return "", err
+	}
+	keys, err := nextTokenKeys()
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(signNextToken(keys[0], payload)), nil
+}
+
+// decodeNextToken returns the position of a NextToken returned for the
+// statement hash
+func decodeNextToken(s, hash string, columns int) ([]interface{}, error) {
+	encoded, signature, ok := strings.Cut(s, ".")
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if !ok || err != nil {
+		return nil, errors.New("ValidationException", invalidNextToken)
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil {
+		return nil, errors.New("ValidationException", invalidNextToken)
+	}
+	keys, err := nextTokenKeys()
+	if err != nil {
+		return nil, err
+	}
+	if !slices.ContainsFunc(keys, func(key []byte) bool { return hmac.Equal(mac, signNextToken(key, payload)) }) {
+		return nil, errors.New("ValidationException", invalidNextToken)
+	}
+	var token nextToken
+	if err := json.Unmarshal(payload, &token); err != nil || token.Statement != hash || len(token.Position) != columns {
+		return nil, errors.New("ValidationException", invalidNextToken)
+	}
+	position := make([]interface{}, len(token.Position))
+	for i, v := range token.Position {
+		switch {
+		case v.S != nil:
+			position[i] = *v.S
+		case v.N != nil:
+			if position[i], err = models.ParseNumber(*v.N); err != nil {
+				return nil, errors.New("ValidationException", invalidNextToken)
+			}
+		case v.B != nil:
+			position[i] = v.B
+		default:
+			return nil, errors.New("ValidationException", invalidNextToken)
+		}
+	}
+	return position, nil
+}
+
+func signNextToken(key, payload []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// tableKeys returns the primary key columns of a table
+func tableKeys(tableConf models.TableConfig) []string {
+	if tableConf.SortKey == "" {
+		return []string{tableConf.PartitionKey}
+	}
+	return []string{tableConf.PartitionKey, tableConf.SortKey}
+}
+
+// partiQLKeyConf returns the keys a SELECT reads items by: those of the
+// secondary index it names, or of the table
+func partiQLKeyConf(queryMap *translator.SelectQueryMap, tableConf models.TableConfig) (models.TableConfig, error) {
+	if queryMap.Index == "" {
+		return tableConf, nil
+	}
+	indexConf, err := getIndexConf(tableConf, queryMap.Index)
+	if err != nil {
+		return models.TableConfig{}, err
+	}
+	if indexConf.PartitionKey == "" {
+		// no index metadata is available, fall back to the table keys
+		indexConf.PartitionKey, indexConf.SortKey = tableConf.PartitionKey, tableConf.SortKey
+	}
+	if indexConf.SpannerIndexName == "" {
+		indexConf.SpannerIndexName = utils.ChangeTableNameForSpanner(queryMap.Index)
+	}
+	return indexConf, nil
+}
+
+// partiQLIndexName returns the Spanner index a SELECT reads, empty when it
+// reads the table
+func partiQLIndexName(queryMap *translator.SelectQueryMap, keyConf models.TableConfig) string {
+	if queryMap.Index == "" {
+		return ""
+	}
+	return keyConf.SpannerIndexName
+}
+
+// partiQLKeys returns the columns the items of a SELECT are ordered by: the
+// keys of the index it reads, then those of the table, which tell apart the
+// items of an index with the same keys
+func partiQLKeys(tableConf, keyConf models.TableConfig) []string {
+	keys := tableKeys(keyConf)
+	for _, key := range tableKeys(tableConf) {
+		if !slices.Contains(keys, key) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// checkPartiQLOrder checks the ORDER BY clause of a SELECT. Like DynamoDB,
+// items are only ordered by the keys of the table or index read, and within
+// the partitions the WHERE clause names.
+func checkPartiQLOrder(queryMap *translator.SelectQueryMap, keyConf models.TableConfig) error {
+	if len(queryMap.Sort) == 0 {
+		return nil
+	}
+	if !partiQLKeyCondition(queryMap, keyConf) {
+		return errors.New("ValidationException", "Must have WHERE clause in the statement when using ORDER BY clause")
+	}
+	for i, sort := range queryMap.Sort {
+		if sort.Column == "" || sort.Column != keyConf.PartitionKey && sort.Column != keyConf.SortKey {
+			return errors.New("ValidationException", "ORDER BY is only supported on the key attributes of the table or index: "+queryMap.OrderBy[i])
+		}
+	}
+	return nil
+}
+
+// partiQLKeyCondition tells whether the WHERE clause of a SELECT reads the
+// partitions of keyConf it names, comparing its partition key with = or IN
+func partiQLKeyCondition(queryMap *translator.SelectQueryMap, keyConf models.TableConfig) bool {
+	return slices.ContainsFunc(queryMap.WhereTerms, func(term translator.WhereTerm) bool {
+		return term.Column == keyConf.PartitionKey && slices.Contains(partitionKeyOperators, term.Operator)
+	})
+}
+
+// checkPartiQLFullTableScan applies the policy of the table to a SELECT that
+// does not name the partitions it reads, with or without a WHERE clause
+func checkPartiQLFullTableScan(ctx context.Context, table string, queryMap *translator.SelectQueryMap, keyConf models.TableConfig) error {
+	if partiQLKeyCondition(queryMap, keyConf) {
+		return nil
+	}
+	key := "partition key " + keyConf.PartitionKey
+	if queryMap.Index != "" {
+		key += " of index " + queryMap.Index
+	}
+	return checkFullTableScan(ctx, table, "SELECT", "the WHERE clause must compare the "+key+" with = or IN to read a key range")
+}
+
+// partiQLOrder is a column a page of a PartiQL SELECT is ordered by
+type partiQLOrder struct {
+	column string
+	desc   bool
+}
+
+// partitionKeyOperators and sortKeyOperators are the conditions on the keys
+// that DynamoDB reads the items of a query with
+var (
+	partitionKeyOperators = []string{"=", "IN"}
+	sortKeyOperators      = []string{"=", "<", "<=", ">", ">=", "BETWEEN", "BEGINS_WITH"}
+)
+
+var partiQLColumnPattern = regexp.MustCompile(`^\w+$`)
+
+// partiQLPageable tells whether a SELECT can be read in pages. Its order has
+// to be the order of the primary key, so statements with their own LIMIT or
+// OFFSET or ordered by other columns are run as written.
+func partiQLPageable(queryMap *translator.SelectQueryMap, tableConf models.TableConfig) bool {
+	if queryMap.Limit != "" || queryMap.Offset != "" {
+		return false
+	}
+	for _, sort := range queryMap.Sort {
+		if sort.Column == "" || sort.Column != tableConf.PartitionKey && sort.Column != tableConf.SortKey {
+			return false
+		}
+	}
+	return true
+}
+
+// partiQLSelectPage returns the statement reading a page of a PartiQL SELECT
+// after position, the columns the page is ordered by and the key columns it
+// reads that the statement does not project. The items are ordered by the
+// ORDER BY columns and then by the keys of keyConf and of the table, and the
+// conditions of the WHERE clause that are not key conditions of keyConf are
+// selected as storage.FilterColumn, so the items they filter out still count
+// as evaluated.
+func partiQLSelectPage(queryMap *translator.SelectQueryMap, tableConf, keyConf models.TableConfig, table string, position []interface{}, limit int64, params map[string]interface{}) (spanner.Statement, []partiQLOrder, []string) {
+	var order []partiQLOrder
+	add := func(column string, desc bool) {
+		if !slices.ContainsFunc(order, func(o partiQLOrder) bool { return o.column == column }) {
+			order = append(order, partiQLOrder{column: column, desc: desc})
+		}
+	}
+	for _, sort := range queryMap.Sort {
+		add(sort.Column, sort.Desc)
+	}
+	keys := partiQLKeys(tableConf, keyConf)
+	for _, key := range keys {
+		add(key, false)
+	}
+
+	var columns, hidden []string
+	projected := map[string]bool{}
+	for _, column := range queryMap.ProjectionColumns {
+		name := strings.Trim(column, `"`)
+		if name == "*" {
+			columns = nil
+			break
+		}
+		projected[name] = true
+		if partiQLColumnPattern.MatchString(name) {
+			column = "`" + name + "`"
+		}
+		columns = append(columns, column)
+	}
+	for _, column := range partiQLPathColumns(queryMap) {
+		hidden = append(hidden, column)
+		columns = append(columns, "`"+column+"`")
+	}
+	if columns == nil {
+		for _, column := range models.TableColumnMap[table] {
+			columns = append(columns, "`"+column+"`")
+		}
+	} else {
+		for _, key := range keys {
+			if !projected[key] {
+				hidden = append(hidden, key)
+				columns = append(columns, "`"+key+"`")
+			}
+		}
+	}
+
+	// a statement naming the partition key is a query, other statements scan
+	// the table
+	isQuery := slices.ContainsFunc(queryMap.WhereTerms, func(term translator.WhereTerm) bool {
+		return term.Column == keyConf.PartitionKey && slices.Contains(partitionKeyOperators, term.Operator)
+	})
+	var conditions, filters []string
+	if queryMap.Index != "" {
+		// items without the index keys are not in the index
+		for _, key := range tableKeys(keyConf) {
+			conditions = append(conditions, "`"+key+"` IS NOT NULL")
+		}
+	}
+	for _, term := range queryMap.WhereTerms {
+		isKey := term.Column == keyConf.PartitionKey && slices.Contains(partitionKeyOperators, term.Operator) ||
+			term.Column == keyConf.SortKey && term.Column != "" && slices.Contains(sortKeyOperators, term.Operator)
+		if isQuery && isKey {
+			conditions = append(conditions, term.Condition)
+		} else {
+			filters = append(filters, term.Condition)
+		}
+	}
+	if len(filters) > 0 {
+		columns = append(columns, "COALESCE(("+strings.Join(filters, " AND ")+"), FALSE) AS "+storage.FilterColumn)
+	}
+	if position != nil {
+		conditions = append(conditions, "("+seekCondition(order, position, params)+")")
+	}
+
+	sql := "SELECT " + strings.Join(columns, ", ") + " FROM " + table
+	if len(conditions) > 0 {
+		sql += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	orderBy := make([]string, len(order))
+	for i, o := range order {
+		orderBy[i] = "`" + o.column + "`"
+		if o.desc {
+			orderBy[i] += " DESC"
+		}
+	}
+	sql += " ORDER BY " + strings.Join(orderBy, ", ")
+	if limit > 0 {
+		// one more item tells whether there is another page
+		sql += " LIMIT " + strconv.FormatInt(limit+1, 10)
+	}
+	return spanner.Statement{SQL: sql, Params: params}, order, hidden
+}
+
+// seekCondition matches the items ordered after position
+func seekCondition(order []partiQLOrder, position []interface{}, params map[string]interface{}) string {
+	names := make([]string, len(order))
+	for i := range order {
+		names[i] = "nextToken" + strconv.Itoa(i)
+		for params[names[i]] != nil {
+			names[i] += "_"
+		}
+		params[names[i]] = columnParam(order[i].column, position[i])
+	}
+	var alternatives []string
+	for i, o := range order {
+		var conditions []string
+		for j := 0; j < i; j++ {
+			conditions = append(conditions, "`"+order[j].column+"` = @"+names[j])
+		}
+		op := ">"
+		if o.desc {
+			op = "<"
+		}
+		conditions = append(conditions, "`"+o.column+"` "+op+" @"+names[i])
+		alternatives = append(alternatives, strings.Join(conditions, " AND "))
+	}
+	return "(" + strings.Join(alternatives, ") OR (") + ")"
+}
+
+// executePartiQLSelectPage reads a page of a PartiQL SELECT. Like DynamoDB,
+// the page ends after Limit items are evaluated or once 1MB of items is read,
+// and it returns a NextToken when there are more items.
+func executePartiQLSelectPage(ctx context.Context, executeStatement models.ExecuteStatement, queryMap *translator.SelectQueryMap, tableConf, keyConf models.TableConfig, params map[string]interface{}) (map[string]interface{}, error) {
+	limit := executeStatement.Limit
+	if limit == 0 && models.GlobalConfig != nil {
+		limit = models.GlobalConfig.Spanner.QueryLimit
+	}
+	table := utils.ChangeTableNameForSpanner(executeStatement.TableName)
+	if queryMap.Index != "" {
+		table += "@{FORCE_INDEX=" + keyConf.SpannerIndexName + "}"
+	}
+	hash := statementHash(executeStatement)
+	var position []interface{}
+	if executeStatement.NextToken != "" {
+		var err error
+		if position, err = decodeNextToken(executeStatement.NextToken, hash, len(partiQLKeys(tableConf, keyConf))); err != nil {
+			return nil, err
+		}
+	}
+
+	stmt, order, hidden := partiQLSelectPage(queryMap, tableConf, keyConf, table, position, limit, params)
+	logger.LogDebug(stmt)
+	queryCtx, explained := explainStatement(ctx, executeStatement.TableName, partiQLIndexName(queryMap, keyConf), stmt)
+	resp, err := GetStorage().ExecuteSpannerQuery(queryCtx, executeStatement.TableName, []string{}, false, stmt)
+	explained(err)
+	if err != nil {
+		return nil, err
+	}
+
+	items := []map[string]interface{}{}
+	scanned, size := 0, 0
+	var last []interface{}
+	for _, row := range resp {
+		if limit > 0 && int64(scanned) == limit || size >= maxPageSize {
+			break
+		}
+		scanned++
+		match, filtered := row[storage.FilterColumn]
+		delete(row, storage.FilterColumn)
+		size += models.ItemSize(row)
+		last = make([]interface{}, len(order))
+		for i, o := range order {
+			last[i] = row[o.column]
+		}
+		projectPartiQLPaths(queryMap, row, hidden)
+		if !filtered || match == true {
+			items = append(items, row)
+		}
+	}
+
+	finalResp := map[string]interface{}{"Items": items}
+	if scanned < len(resp) {
+		token, err := encodeNextToken(hash, last)
+		if err != nil {
+			return nil, err
+		}
+		finalResp["NextToken"] = token
+	}
+	return finalResp, nil
+}
+
+// executePartiQLAggregate runs a SELECT projecting aggregate functions as one
+// Spanner aggregate query, and returns their values in a single item. Unless
+// allow_full_table_aggregates is set, the statement has to name the
+// partitions it aggregates with the partition key of the table or index.
+func executePartiQLAggregate(ctx context.Context, executeStatement models.ExecuteStatement, queryMap *translator.SelectQueryMap, keyConf models.TableConfig, params map[string]interface{}) (map[string]interface{}, error) {
+	if executeStatement.NextToken != "" {
+		return nil, errors.New("ValidationException", invalidNextToken)
+	}
+	if len(queryMap.Sort) > 0 || queryMap.Limit != "" || queryMap.Offset != "" {
+		return nil, errors.New("ValidationException", "ORDER BY, LIMIT and OFFSET are not supported with aggregate functions")
+	}
+	if !partiQLKeyCondition(queryMap, keyConf) && (models.GlobalConfig == nil || !models.GlobalConfig.PartiQL.AllowFullTableAggregates) {
+		return nil, errors.New("ValidationException", "Aggregate functions must have a WHERE clause comparing the partition key "+keyConf.PartitionKey+" with = or IN")
+	}
+
+	table := utils.ChangeTableNameForSpanner(executeStatement.TableName)
+	columns := make([]string, len(queryMap.Aggregates))
+	for i, aggregate := range queryMap.Aggregates {
+		if err := checkPartiQLAggregate(table, aggregate); err != nil {
+			return nil, err
+		}
+		columns[i] = aggregate.SQL() + " AS " + storage.AggregateColumn + strconv.Itoa(i)
+	}
+	sql := "SELECT " + strings.Join(columns, ", ") + " FROM " + table
+	var conditions []string
+	if queryMap.Index != "" {
+		sql += "@{FORCE_INDEX=" + keyConf.SpannerIndexName + "}"
+		// items without the index keys are not in the index
+		for _, key := range tableKeys(keyConf) {
+			conditions = append(conditions, "`"+key+"` IS NOT NULL")
+		}
+	}
+	if queryMap.WhereClause != "" {
+		conditions = append(conditions, "("+queryMap.WhereClause+")")
+	}
+	if len(conditions) > 0 {
+		sql += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	stmt := spanner.Statement{SQL: sql, Params: params}
+	logger.LogDebug(stmt)
+	queryCtx, explained := explainStatement(ctx, executeStatement.TableName, partiQLIndexName(queryMap, keyConf), stmt)
+	resp, err := GetStorage().ExecuteSpannerQuery(queryCtx, executeStatement.TableName, []string{}, false, stmt)
+	explained(err)
+	if err != nil {
+		return nil, err
+	}
+
+	item := make(map[string]interface{})
+	if len(resp) > 0 {
+		for i, aggregate := range queryMap.Aggregates {
+			// like missing attributes, aggregates of no values are left out
+			if v := resp[0][storage.AggregateColumn+strconv.Itoa(i)]; v != nil {
+				item[aggregate.Name] = v
+			}
+		}
+	}
+	return map[string]interface{}{"Items": []map[string]interface{}{item}}, nil
+}
+
+// checkPartiQLAggregate checks that the attribute of an aggregate function
+// has a type the function takes. SUM and AVG take numbers, MIN and MAX take
+// strings, numbers and binaries. Numbers stored as STRING(MAX) are only
+// counted, as Spanner would compare and add them as strings.
+func checkPartiQLAggregate(table string, aggregate translator.Aggregate) error {
+	if aggregate.Column == "" {
+		return nil
+	}
+	attrType, ok := models.TableDDL[table][aggregate.Column]
+	if !ok {
+		return errors.New("ValidationException", "Unknown attribute "+aggregate.Column+" of aggregate function "+aggregate.Function)
+	}
+	var types []string
+	switch aggregate.Function {
+	case "COUNT":
+		return nil
+	case "SUM", "AVG":
+		types = []string{"N"}
+	default:
+		types = []string{"S", "N", "B"}
+	}
+	stringNumber := attrType == "N" && strings.Contains(strings.ToUpper(models.SpannerColumnTypes[table][aggregate.Column]), "STRING")
+	if !slices.Contains(types, attrType) || stringNumber {
+		return errors.New("ValidationException", "Aggregate function "+aggregate.Function+" is not supported on attribute "+aggregate.Column+" of type "+attrType)
+	}
+	return nil
+}
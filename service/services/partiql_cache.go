@@ -0,0 +1,169 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package services
+
+import (
+	"container/list"
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/cloudspannerecosystem/dynamodb-adapter/models"
+)
+
+// defaultPartiQLCacheMaxStatements is the number of translated statements
+// cached when max_statements is not set
+const defaultPartiQLCacheMaxStatements = 1000
+
+var (
+	partiQLCacheOnce sync.Once
+	partiQLCacheInst *partiQLCache
+)
+
+// getPartiQLCache returns the cache of translated PartiQL statements, nil
+// when it is disabled
+func getPartiQLCache() *partiQLCache {
+	partiQLCacheOnce.Do(func() {
+		maxStatements := defaultPartiQLCacheMaxStatements
+		if models.GlobalConfig != nil && models.GlobalConfig.PartiQLCache.MaxStatements != 0 {
+			maxStatements = models.GlobalConfig.PartiQLCache.MaxStatements
+		}
+		if maxStatements > 0 {
+			partiQLCacheInst = newPartiQLCache(maxStatements)
+		}
+	})
+	return partiQLCacheInst
+}
+
+// partiQLCache is an LRU cache of the translations of PartiQL statements,
+// keyed by the statement and the types of its parameters. The translations
+// depend on the column types of the tables, so version changes, and the
+// entries are dropped, whenever the table metadata is loaded.
+type partiQLCache struct {
+	mu            sync.Mutex
+	maxStatements int
+	version       uint64
+	lru           *list.List
+	entries       map[string]*list.Element
+}
+
+type partiQLCacheEntry struct {
+	key         string
+	translation interface{}
+}
+
+func newPartiQLCache(maxStatements int) *partiQLCache {
+	return &partiQLCache{
+		maxStatements: maxStatements,
+		lru:           list.New(),
+		entries:       map[string]*list.Element{},
+	}
+}
+
+// get returns the translation cached for the key and the version to pass to
+// put
+func (c *partiQLCache) get(key string) (interface{}, uint64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, c.version, false
+	}
+	c.lru.MoveToFront(e)
+	return e.Value.(*partiQLCacheEntry).translation, c.version, true
+}
+
+// put caches the translation for the key, unless the table metadata was
+// loaded since version
+func (c *partiQLCache) put(version uint64, key string, translation interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.version != version {
+		return
+	}
+	if e, ok := c.entries[key]; ok {
+		c.lru.Remove(e)
+	}
+	c.entries[key] = c.lru.PushFront(&partiQLCacheEntry{key: key, translation: translation})
+	for c.lru.Len() > c.maxStatements {
+		entry := c.lru.Remove(c.lru.Back()).(*partiQLCacheEntry)
+		delete(c.entries, entry.key)
+	}
+}
+
+func (c *partiQLCache) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.version++
+	c.lru.Init()
+	c.entries = map[string]*list.Element{}
+}
+
+// InvalidatePartiQLCache drops the translated PartiQL statements. It is
+// called whenever the table metadata is loaded or changed.
+func InvalidatePartiQLCache(ctx context.Context) {
+	cache := getPartiQLCache()
+	if cache == nil {
+		return
+	}
+	cache.invalidate()
+	if models.GlobalProxy != nil {
+		models.GlobalProxy.OtelInst.RecordPartiQLCacheInvalidation(ctx)
+	}
+}
+
+// partiQLCacheKey returns the cache key of a statement of the kind. The
+// parameter types are part of it, as the translation of the comparisons of
+// nested attributes depends on them, and so is the table it runs on, which
+// differs between tenants naming the same table.
+func partiQLCacheKey(kind string, executeStatement models.ExecuteStatement) string {
+	types := make([]string, len(executeStatement.Parameters))
+	for i, param := range executeStatement.Parameters {
+		types[i] = attributeType(param)
+	}
+	return kind + "\x00" + executeStatement.TableName + "\x00" + strings.Join(types, ",") + "\x00" + executeStatement.Statement
+}
+
+// translation is a translated statement the cache hands out copies of
+type translation[T any] interface {
+	Clone() T
+}
+
+// translateCached returns the translation of a statement through the cache.
+// Translations are shared by the cache, so callers get a copy of their own.
+func translateCached[T translation[T]](ctx context.Context, kind string, executeStatement models.ExecuteStatement, translate func(string) (T, error)) (T, error) {
+	cache := getPartiQLCache()
+	if cache == nil {
+		return translate(executeStatement.Statement)
+	}
+	key := partiQLCacheKey(kind, executeStatement)
+	cached, version, hit := cache.get(key)
+	if models.GlobalProxy != nil {
+		result := "miss"
+		if hit {
+			result = "hit"
+		}
+		models.GlobalProxy.OtelInst.RecordPartiQLCacheMetric(ctx, result)
+	}
+	if hit {
+		return cached.(T).Clone(), nil
+	}
+	translated, err := translate(executeStatement.Statement)
+	if err != nil {
+		return translated, err
+	}
+	cache.put(version, key, translated)
+	return translated.Clone(), nil
+}
@@ -0,0 +1,169 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package services
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/models"
+	translator "github.com/cloudspannerecosystem/dynamodb-adapter/translator/utils"
+	"gopkg.in/go-playground/assert.v1"
+)
+
+func TestPartiQLCacheTranslations(t *testing.T) {
+	setupQueryTable(t, nil, 0)
+	ctx := context.Background()
+	translations := 0
+	translate := func(statement models.ExecuteStatement) *translator.SelectQueryMap {
+		translatorObj := partiQLTranslator(statement)
+		queryMap, err := translateCached(ctx, "SELECT", statement, func(query string) (*translator.SelectQueryMap, error) {
+			translations++
+			return translatorObj.ToSpannerSelect(query)
+		})
+		assert.Equal(t, err, nil)
+		return queryMap
+	}
+	statement := models.ExecuteStatement{
+		TableName:  "orders",
+		Statement:  "SELECT * FROM orders WHERE customer = ?",
+		Parameters: []*dynamodb.AttributeValue{{S: aws.String("alice")}},
+	}
+
+	first := translate(statement)
+	statement.Parameters = []*dynamodb.AttributeValue{{S: aws.String("bob")}}
+	second := translate(statement)
+	assert.Equal(t, translations, 1)
+	assert.Equal(t, second.SpannerQuery, first.SpannerQuery)
+	// callers get their own copy of the translation
+	assert.Equal(t, first == second, false)
+
+	// parameters of other types are translated again
+	statement.Parameters = []*dynamodb.AttributeValue{{N: aws.String("1")}}
+	translate(statement)
+	assert.Equal(t, translations, 2)
+
+	// loading the table metadata drops the translations
+	InvalidatePartiQLCache(ctx)
+	translate(statement)
+	assert.Equal(t, translations, 3)
+	translate(statement)
+	assert.Equal(t, translations, 3)
+
+	// the same statement of another tenant runs on its own table
+	statement.TableName = "dev_orders"
+	models.TableDDL["dev_orders"] = models.TableDDL["orders"]
+	tenant := translate(statement)
+	assert.Equal(t, translations, 4)
+	assert.Equal(t, tenant.SpannerQuery, "SELECT * FROM dev_orders WHERE `customer` = @customer;")
+}
+
+func TestPartiQLCacheConcurrentHits(t *testing.T) {
+	setupQueryTable(t, nil, 0)
+	ctx := context.Background()
+	update := models.ExecuteStatement{TableName: "orders", Statement: "UPDATE orders SET status = 'closed' WHERE customer = ? AND id = 1"}
+	insert := models.ExecuteStatement{TableName: "orders", Statement: "INSERT INTO orders VALUE {'customer': 'alice', 'id': 3, 'status': 'new'}"}
+	updateTranslator, insertTranslator := partiQLTranslator(update), partiQLTranslator(insert)
+	translateUpdate := func() *translator.DeleteUpdateQueryMap {
+		queryMap, err := translateCached(ctx, "UPDATE", update, updateTranslator.ToSpannerUpdate)
+		assert.Equal(t, err, nil)
+		return queryMap
+	}
+	translateInsert := func() *translator.InsertStatement {
+		statement, err := translateCached(ctx, "INSERT", insert, insertTranslator.ToSpannerInsert)
+		assert.Equal(t, err, nil)
+		return statement
+	}
+	translateUpdate()
+	translateInsert()
+
+	// callers change their copies while others read the cached translations
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			queryMap := translateUpdate()
+			queryMap.WhereParams[0].Name = "changed"
+			queryMap.Actions[0].Value.Literal = "changed"
+			queryMap.WhereTerms[0].Condition = "changed"
+			statement := translateInsert()
+			statement.Item["status"].Literal = "changed"
+			statement.Columns[0] = "changed"
+		}()
+	}
+	wg.Wait()
+
+	queryMap := translateUpdate()
+	assert.NotEqual(t, queryMap.WhereParams[0].Name, "changed")
+	assert.Equal(t, queryMap.Actions[0].Value.Literal, "closed")
+	assert.NotEqual(t, queryMap.WhereTerms[0].Condition, "changed")
+	statement := translateInsert()
+	assert.Equal(t, statement.Item["status"].Literal, "new")
+	assert.NotEqual(t, statement.Columns[0], "changed")
+}
+
+func TestPartiQLCacheEviction(t *testing.T) {
+	c := newPartiQLCache(2)
+	_, version, _ := c.get("a")
+	c.put(version, "a", 1)
+	c.put(version, "b", 2)
+	// a is now the most recently used
+	c.get("a")
+	c.put(version, "c", 3)
+
+	_, _, hit := c.get("b")
+	assert.Equal(t, hit, false)
+	v, _, hit := c.get("a")
+	assert.Equal(t, hit, true)
+	assert.Equal(t, v, 1)
+
+	// translations made before an invalidation are not cached
+	c.invalidate()
+	c.put(version, "d", 4)
+	_, _, hit = c.get("d")
+	assert.Equal(t, hit, false)
+	_, _, hit = c.get("a")
+	assert.Equal(t, hit, false)
+}
+
+func TestExecuteStatementCachedUpdate(t *testing.T) {
+	setupQueryTable(t, []string{"open", "open", "open"}, 0)
+	ctx := context.Background()
+	update := func(id string) {
+		_, err := ExecuteStatement(ctx, models.ExecuteStatement{
+			TableName:  "orders",
+			Statement:  "UPDATE orders SET status = 'closed' WHERE customer = ? AND id = ?",
+			Parameters: []*dynamodb.AttributeValue{{S: aws.String("alice")}, {N: aws.String(id)}},
+		})
+		assert.Equal(t, err, nil)
+	}
+	status := func(id string) interface{} {
+		item, _, err := GetStorage().SpannerGet(ctx, "orders", "alice", models.Number(id), nil)
+		assert.Equal(t, err, nil)
+		return item["status"]
+	}
+
+	// the second update reuses the translation of the first with its own
+	// parameters
+	update("1")
+	update("3")
+	assert.Equal(t, status("1"), "closed")
+	assert.Equal(t, status("2"), "open")
+	assert.Equal(t, status("3"), "closed")
+}
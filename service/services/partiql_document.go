@@ -0,0 +1,358 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package services
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/models"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/pkg/errors"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/storage"
+	translator "github.com/cloudspannerecosystem/dynamodb-adapter/translator/utils"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/utils"
+)
+
+// parameterCountError is returned for statements whose placeholders do not
+// match their parameters
+func parameterCountError() error {
+	return errors.New("ValidationException", "Number of parameters in request and statement don't match.")
+}
+
+// attributeType returns the DynamoDB type of an attribute value
+func attributeType(val *dynamodb.AttributeValue) string {
+	switch {
+	case val == nil:
+		return ""
+	case val.S != nil:
+		return "S"
+	case val.N != nil:
+		return "N"
+	case val.BOOL != nil:
+		return "BOOL"
+	case val.B != nil:
+		return "B"
+	case val.NULL != nil:
+		return "NULL"
+	case val.SS != nil:
+		return "SS"
+	case val.NS != nil:
+		return "NS"
+	case val.BS != nil:
+		return "BS"
+	case val.M != nil:
+		return "M"
+	case val.L != nil:
+		return "L"
+	}
+	return ""
+}
+
+// partiQLParameterType returns the types of the parameters of a statement,
+// the types the nested attributes compared with them are read as
+func partiQLParameterType(parameters []*dynamodb.AttributeValue) func(int) string {
+	return func(index int) string {
+		if index >= len(parameters) {
+			return ""
+		}
+		return attributeType(parameters[index])
+	}
+}
+
+// partiQLParam converts a statement parameter to the value of its attribute
+func partiQLParam(val *dynamodb.AttributeValue) (interface{}, error) {
+	switch attributeType(val) {
+	case "S":
+		return *val.S, nil
+	case "N":
+		return models.ParseNumber(*val.N)
+	case "BOOL":
+		return *val.BOOL, nil
+	case "B":
+		return val.B, nil
+	case "NULL":
+		return nil, nil
+	case "SS":
+		return utils.RemoveDuplicatesString(aws.StringValueSlice(val.SS)), nil
+	case "NS":
+		set := make([]models.Number, len(val.NS))
+		for i, n := range val.NS {
+			var err error
+			if set[i], err = models.ParseNumber(aws.StringValue(n)); err != nil {
+				return nil, err
+			}
+		}
+		return utils.RemoveDuplicatesNumber(set), nil
+	case "BS":
+		return utils.RemoveDuplicatesByteSlice(val.BS), nil
+	case "M":
+		m := make(map[string]interface{}, len(val.M))
+		for k, v := range val.M {
+			var err error
+			if m[k], err = partiQLParam(v); err != nil {
+				return nil, err
+			}
+		}
+		return m, nil
+	case "L":
+		l := make([]interface{}, len(val.L))
+		for i, v := range val.L {
+			var err error
+			if l[i], err = partiQLParam(v); err != nil {
+				return nil, err
+			}
+		}
+		return l, nil
+	}
+	return nil, errors.New("ValidationException", "Supplied AttributeValue is empty, must contain exactly one of the supported datatypes")
+}
+
+// partiQLValue returns the attribute value of a SET action or of an INSERT
+// item. The paths of the value refer to attributes of item.
+func partiQLValue(value *translator.Value, parameters []*dynamodb.AttributeValue, item map[string]interface{}) (interface{}, error) {
+	switch value.Kind {
+	case "S":
+		return value.Literal, nil
+	case "N":
+		return models.ParseNumber(value.Literal)
+	case "BOOL":
+		return strconv.ParseBool(value.Literal)
+	case "NULL":
+		return nil, nil
+	case "PARAM":
+		if value.Placeholder >= len(parameters) {
+			return nil, parameterCountError()
+		}
+		return partiQLParam(parameters[value.Placeholder])
+	case "M":
+		m := make(map[string]interface{}, len(value.Fields))
+		for k, field := range value.Fields {
+			var err error
+			if m[k], err = partiQLValue(field, parameters, item); err != nil {
+				return nil, err
+			}
+		}
+		return m, nil
+	case "L", "BAG", "LIST_APPEND":
+		elems := make([]interface{}, len(value.Elements))
+		for i, elem := range value.Elements {
+			var err error
+			if elems[i], err = partiQLValue(elem, parameters, item); err != nil {
+				return nil, err
+			}
+		}
+		switch value.Kind {
+		case "BAG":
+			return partiQLSet(elems)
+		case "LIST_APPEND":
+			first, ok1 := elems[0].([]interface{})
+			second, ok2 := elems[1].([]interface{})
+			if !ok1 || !ok2 {
+				return nil, errors.New("ValidationException", "An operand in the update expression has an incorrect data type")
+			}
+			return append(slices.Clone(first), second...), nil
+		}
+		return elems, nil
+	case "PATH":
+		v, ok := storage.DocumentValue(item, value.Path)
+		if !ok {
+			return nil, errors.New("ValidationException", "The provided expression refers to an attribute that does not exist in the item")
+		}
+		return v, nil
+	}
+	return nil, errors.New("ValidationException", "Unsupported value: "+value.Kind)
+}
+
+// partiQLSet returns the set of the elements of a bag, which are all
+// strings, numbers or binaries
+func partiQLSet(elems []interface{}) (interface{}, error) {
+	if len(elems) == 0 {
+		return nil, errors.New("ValidationException", "One or more parameter values were invalid: An empty set is not allowed")
+	}
+	switch elems[0].(type) {
+	case string:
+		if set, ok := setOf[string](elems); ok {
+			return utils.RemoveDuplicatesString(set), nil
+		}
+	case models.Number:
+		if set, ok := setOf[models.Number](elems); ok {
+			return utils.RemoveDuplicatesNumber(set), nil
+		}
+	case []byte:
+		if set, ok := setOf[[]byte](elems); ok {
+			return utils.RemoveDuplicatesByteSlice(set), nil
+		}
+	}
+	return nil, errors.New("ValidationException", "The elements of a set must all be strings, numbers or binaries")
+}
+
+func setOf[T any](elems []interface{}) ([]T, bool) {
+	set := make([]T, len(elems))
+	for i, elem := range elems {
+		var ok bool
+		if set[i], ok = elem.(T); !ok {
+			return nil, false
+		}
+	}
+	return set, true
+}
+
+// partiQLItemUpdate tells whether a PartiQL UPDATE is applied to the items it
+// matches instead of running as Spanner DML: it returns items, removes
+// attributes, or sets nested attributes or values other than strings, numbers
+// and booleans
+func partiQLItemUpdate(query *translator.DeleteUpdateQueryMap, parameters []*dynamodb.AttributeValue) bool {
+	if query.Returning != "" {
+		return true
+	}
+	for _, action := range query.Actions {
+		if action.Remove || action.Path != action.Column {
+			return true
+		}
+		if action.Value == nil {
+			continue
+		}
+		switch action.Value.Kind {
+		case "S", "N", "BOOL", "NULL":
+		case "PARAM":
+			if action.Value.Placeholder < len(parameters) {
+				switch attributeType(parameters[action.Value.Placeholder]) {
+				case "S", "N", "BOOL":
+				default:
+					return true
+				}
+			}
+		default:
+			return true
+		}
+	}
+	return false
+}
+
+// updatePartiQLItems applies the SET and REMOVE actions of a PartiQL UPDATE to
+// the items it matches in the transaction and writes the columns they
+// change. Like DynamoDB, the values are those of the items before the update.
+// It returns the items of the RETURNING clause of the statement.
+func updatePartiQLItems(ctx context.Context, txn storage.Transaction, tableConf models.TableConfig, query *translator.DeleteUpdateQueryMap, parameters []*dynamodb.AttributeValue) ([]map[string]interface{}, error) {
+	for _, action := range query.Actions {
+		if action.Column == tableConf.PartitionKey || action.Column == tableConf.SortKey {
+			return nil, errors.New("ValidationException", "Cannot update attribute "+action.Column+". This attribute is part of the key")
+		}
+		if !action.Remove && action.Value == nil {
+			return nil, errors.New("ValidationException", "Unsupported value for attribute "+action.Path)
+		}
+	}
+	items, err := partiQLItems(ctx, txn, tableConf, query)
+	if err != nil {
+		return nil, err
+	}
+	var ms []*storage.Mutation
+	var returned []map[string]interface{}
+	for _, item := range items {
+		values := make([]interface{}, len(query.Actions))
+		for i, action := range query.Actions {
+			if action.Remove {
+				continue
+			}
+			if values[i], err = partiQLValue(action.Value, parameters, item); err != nil {
+				return nil, err
+			}
+			if action.Path == action.Column {
+				if values[i], err = partiQLColumnValue(query.Table, action.Column, values[i]); err != nil {
+					return nil, err
+				}
+			}
+		}
+		var old map[string]interface{}
+		if query.Returning != "" {
+			old = copyDocument(item).(map[string]interface{})
+		}
+		columns := map[string]interface{}{tableConf.PartitionKey: item[tableConf.PartitionKey]}
+		if tableConf.SortKey != "" {
+			columns[tableConf.SortKey] = item[tableConf.SortKey]
+		}
+		for i, action := range query.Actions {
+			if action.Remove {
+				err = storage.RemoveDocumentValue(item, action.Path)
+			} else {
+				err = storage.SetDocumentValue(item, action.Path, values[i])
+			}
+			if err != nil {
+				return nil, err
+			}
+			columns[action.Column] = item[action.Column]
+		}
+		_, m, err := GetStorage().SpannerTransactWritePut(ctx, tableConf.ActualTable, columns, &models.Eval{}, nil, txn, nil)
+		if err != nil {
+			return nil, err
+		}
+		ms = append(ms, m)
+		if query.Returning != "" {
+			returned = append(returned, partiQLReturnedItem(query, old, item))
+		}
+	}
+	return returned, txn.BufferWrite(ms)
+}
+
+// partiQLColumnValue converts a string, number or boolean written to a column
+// to the type of the column
+func partiQLColumnValue(table, column string, v interface{}) (interface{}, error) {
+	switch columnType := partiQLColumnType(table, column); columnType {
+	case "S", "N", "BOOL":
+		if v != nil {
+			return convertType(column, v, columnType)
+		}
+	}
+	return v, nil
+}
+
+// partiQLPathColumns returns the columns a SELECT reads for the nested
+// attributes it projects and does not project itself
+func partiQLPathColumns(queryMap *translator.SelectQueryMap) []string {
+	if slices.ContainsFunc(queryMap.ProjectionColumns, func(column string) bool { return strings.Trim(column, `"`) == "*" }) {
+		return nil
+	}
+	var columns []string
+	for _, path := range queryMap.ProjectionPaths {
+		projected := slices.ContainsFunc(queryMap.ProjectionColumns, func(column string) bool {
+			return strings.Trim(column, "`\"") == path.Column
+		})
+		if !projected && !slices.Contains(columns, path.Column) {
+			columns = append(columns, path.Column)
+		}
+	}
+	return columns
+}
+
+// projectPartiQLPaths sets the nested attributes a SELECT projects in an item
+// and removes the hidden columns the item was read with
+func projectPartiQLPaths(queryMap *translator.SelectQueryMap, item map[string]interface{}, hidden []string) {
+	values := make(map[string]interface{}, len(queryMap.ProjectionPaths))
+	for _, path := range queryMap.ProjectionPaths {
+		if v, ok := storage.DocumentValue(item, path.Path); ok {
+			values[path.Name] = v
+		}
+	}
+	for _, column := range hidden {
+		delete(item, column)
+	}
+	for name, v := range values {
+		item[name] = v
+	}
+}
@@ -0,0 +1,270 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/config"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/models"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/pkg/errors"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/storage"
+	translator "github.com/cloudspannerecosystem/dynamodb-adapter/translator/utils"
+)
+
+// clientRequestTokenTTL is how long the ClientRequestToken of a transaction
+// that committed makes requests with the same token succeed without running
+const clientRequestTokenTTL = 10 * time.Minute
+
+// statementErrorCodes are the error codes of the statements of a batch and
+// the reasons a transaction is canceled for
+var statementErrorCodes = []string{"ConditionalCheckFailed", "DuplicateItem", "ResourceNotFound", "TransactionConflict", "ValidationError"}
+
+// statementError returns the code and the message a statement failed with
+func statementError(err error) (string, string) {
+	e, ok := err.(*errors.Error)
+	if !ok {
+		return "InternalServerError", err.Error()
+	}
+	code := strings.TrimSuffix(e.ErrorCode, "Exception")
+	if code == "Validation" {
+		code = "ValidationError"
+	}
+	if !slices.Contains(statementErrorCodes, code) {
+		code = "InternalServerError"
+	}
+	return code, strings.TrimSpace(e.ErrorMessage)
+}
+
+// IsReadStatement tells whether a PartiQL statement is a SELECT
+func IsReadStatement(statement models.ExecuteStatement) bool {
+	return selectRegex.MatchString(statement.Statement)
+}
+
+// BatchExecuteStatement runs the statements of a batch one by one. Like
+// DynamoDB, a batch either reads or writes items, and a statement failing
+// does not fail the others: its error is returned in its response.
+func BatchExecuteStatement(ctx context.Context, statements []models.ExecuteStatement) ([]models.BatchStatementResponse, error) {
+	for _, statement := range statements[min(1, len(statements)):] {
+		if IsReadStatement(statement) != IsReadStatement(statements[0]) {
+			return nil, errors.New("ValidationException", "Batch must contain either all read or all write statements")
+		}
+	}
+	responses := make([]models.BatchStatementResponse, len(statements))
+	for i, statement := range statements {
+		responses[i].TableName = statement.TableName
+		statement = storedStatement(ctx, statement)
+		var err error
+		if IsReadStatement(statement) {
+			var res map[string]interface{}
+			statement.Limit = 0
+			if res, err = ExecuteStatementForSelect(ctx, statement); err == nil {
+				if items, _ := res["Items"].([]map[string]interface{}); len(items) > 0 {
+					responses[i].Item = items[0]
+				}
+			}
+		} else {
+			_, err = runStatement(ctx, statement)
+		}
+		if err != nil {
+			code, message := statementError(err)
+			responses[i].Error = &models.BatchStatementError{Code: code, Message: message}
+		}
+	}
+	return responses, nil
+}
+
+// ExecuteTransaction runs the INSERT, UPDATE and DELETE statements of a
+// transaction in one Spanner read-write transaction. When a statement fails,
+// the transaction is canceled with a TransactionCanceledException holding the
+// reason of every statement. A transaction that committed with a
+// ClientRequestToken is not run again when the request is repeated, and a
+// request repeated while its transaction runs fails.
+func ExecuteTransaction(ctx context.Context, statements []models.ExecuteStatement, clientRequestToken string) error {
+	if clientRequestToken == "" {
+		return executeTransaction(ctx, statements)
+	}
+	token := requestTokenKey(ctx, clientRequestToken)
+	done, err := requestTokens.reserve(token, transactionHash(statements))
+	if done || err != nil {
+		return err
+	}
+	committed := false
+	defer func() { requestTokens.release(token, committed) }()
+	err = executeTransaction(ctx, statements)
+	committed = err == nil
+	return err
+}
+
+func executeTransaction(ctx context.Context, statements []models.ExecuteStatement) error {
+	// statements are translated before the transaction, so invalid statements
+	// fail the request
+	ops := make([]func(context.Context, storage.Transaction) error, len(statements))
+	var tables []string
+	for i, statement := range statements {
+		statement = storedStatement(ctx, statement)
+		tableConf, err := config.GetTableConf(statement.TableName)
+		if err != nil {
+			return err
+		}
+		tables = append(tables, tableConf.ActualTable)
+		switch {
+		case insertRegex.MatchString(statement.Statement):
+			item, onConflict, err := partiQLInsertItem(ctx, statement)
+			if err != nil {
+				return err
+			}
+			ops[i] = func(ctx context.Context, txn storage.Transaction) error {
+				return insertPartiQLItem(ctx, txn, tableConf, item, onConflict)
+			}
+		case updateRegex.MatchString(statement.Statement), deleteRegex.MatchString(statement.Statement):
+			var query *translator.DeleteUpdateQueryMap
+			if updateRegex.MatchString(statement.Statement) {
+				query, err = partiQLUpdateQuery(ctx, statement)
+			} else {
+				query, err = partiQLDeleteQuery(ctx, statement)
+			}
+			if err != nil {
+				return err
+			}
+			if partiQLItemUpdate(query, statement.Parameters) {
+				ops[i] = func(ctx context.Context, txn storage.Transaction) error {
+					_, err := updatePartiQLItems(ctx, txn, tableConf, query, statement.Parameters)
+					return err
+				}
+				continue
+			}
+			ops[i] = func(ctx context.Context, txn storage.Transaction) error {
+				_, err := txn.ExecuteDML(ctx, tableConf.ActualTable, spanner.Statement{SQL: query.SpannerQuery, Params: query.Params})
+				return err
+			}
+		default:
+			return errors.New("ValidationException", "ExecuteTransaction only supports INSERT, UPDATE and DELETE statements")
+		}
+	}
+
+	var reasons []errors.CancellationReason
+	err := GetStorage().ReadWriteTransaction(ctx, tables, func(ctx context.Context, txn storage.Transaction) error {
+		reasons = nil
+		for i, op := range ops {
+			if err := op(ctx, txn); err != nil {
+				// the statements after the one that failed are not run
+				reasons = make([]errors.CancellationReason, len(ops))
+				for j := range reasons {
+					reasons[j].Code = "None"
+				}
+				reasons[i].Code, reasons[i].Message = statementError(err)
+				return err
+			}
+		}
+		return nil
+	})
+	if reasons != nil {
+		codes := make([]string, len(reasons))
+		for i, reason := range reasons {
+			codes[i] = reason.Code
+		}
+		e := errors.New("TransactionCanceledException", "Transaction cancelled, please refer cancellation reasons for specific reasons ["+strings.Join(codes, ", ")+"]")
+		e.CancellationReasons = reasons
+		return e
+	}
+	return err
+}
+
+// transactionHash identifies the statements of a transaction and their
+// parameters
+func transactionHash(statements []models.ExecuteStatement) string {
+	h := sha256.New()
+	for _, statement := range statements {
+		params, _ := json.Marshal(statement.Parameters)
+		h.Write([]byte(statement.Statement))
+		h.Write([]byte{0})
+		h.Write(params)
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// requestTokenKey returns the key of the ClientRequestToken of a request.
+// Tenants use their own tokens.
+func requestTokenKey(ctx context.Context, token string) string {
+	return config.TenantFromContext(ctx).Name + "\x00" + token
+}
+
+// clientRequestTokens holds the ClientRequestTokens of the transactions that
+// are running or committed, with the hash of their request. Tokens are kept
+// in the memory of the adapter that ran the transaction.
+type clientRequestTokens struct {
+	mu     sync.Mutex
+	tokens map[string]clientRequestToken
+}
+
+type clientRequestToken struct {
+	hash    string
+	running bool
+	expires time.Time
+}
+
+var requestTokens = &clientRequestTokens{tokens: map[string]clientRequestToken{}}
+
+// reserve tells whether a transaction with the token already committed, and
+// otherwise marks it running until release is called. A request repeating a
+// transaction that is still running fails with a
+// TransactionInProgressException, and a token used for a different request
+// with an IdempotentParameterMismatchException.
+func (r *clientRequestTokens) reserve(token, hash string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	for k, t := range r.tokens {
+		if !t.running && now.After(t.expires) {
+			delete(r.tokens, k)
+		}
+	}
+	t, ok := r.tokens[token]
+	switch {
+	case !ok:
+		r.tokens[token] = clientRequestToken{hash: hash, running: true}
+		return false, nil
+	case t.hash != hash:
+		return false, errors.New("IdempotentParameterMismatchException", "The request uses the same client token as a previous, but non-identical request.")
+	case t.running:
+		return false, errors.New("TransactionInProgressException", "The transaction with the given request token is already in progress.")
+	}
+	return true, nil
+}
+
+// release ends the transaction reserved with the token. The token of a
+// transaction that committed is kept for clientRequestTokenTTL, the one of a
+// failed transaction is dropped so the request can be retried.
+func (r *clientRequestTokens) release(token string, committed bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !committed {
+		delete(r.tokens, token)
+		return
+	}
+	t := r.tokens[token]
+	t.running, t.expires = false, time.Now().Add(clientRequestTokenTTL)
+	r.tokens[token] = t
+}
@@ -0,0 +1,148 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package services
+
+import (
+	"context"
+	stderrors "errors"
+	"slices"
+	"strings"
+
+	"cloud.google.com/go/spanner"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/models"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/pkg/errors"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/storage"
+	translator "github.com/cloudspannerecosystem/dynamodb-adapter/translator/utils"
+)
+
+// checkPartiQLKey checks that the WHERE clause of an UPDATE or DELETE
+// compares every key attribute with =, so that it writes a single item
+func checkPartiQLKey(query *translator.DeleteUpdateQueryMap, tableConf models.TableConfig) error {
+	for _, key := range tableKeys(tableConf) {
+		if !slices.ContainsFunc(query.WhereTerms, func(term translator.WhereTerm) bool {
+			return term.Column == key && term.Operator == "="
+		}) {
+			return errors.New("ValidationException", "Where clause does not contain a mandatory equality on all key attributes")
+		}
+	}
+	return nil
+}
+
+// checkPartiQLItemKey checks that the item of an INSERT has its key attributes
+func checkPartiQLItemKey(item map[string]interface{}, tableConf models.TableConfig) error {
+	for _, key := range tableKeys(tableConf) {
+		if item[key] == nil {
+			return errors.New("ValidationException", "One or more parameter values were invalid: Missing the key "+key+" in the item")
+		}
+	}
+	return nil
+}
+
+// insertPartiQLItem writes the item of a PartiQL INSERT in the transaction.
+// Like DynamoDB, an INSERT of an existing item fails, unless its ON CONFLICT
+// clause keeps or replaces the item.
+func insertPartiQLItem(ctx context.Context, txn storage.Transaction, tableConf models.TableConfig, item map[string]interface{}, onConflict string) error {
+	if onConflict != "REPLACE" {
+		key := spanner.Key{item[tableConf.PartitionKey]}
+		if tableConf.SortKey != "" {
+			key = append(key, item[tableConf.SortKey])
+		}
+		_, err := txn.ReadRow(ctx, tableConf.ActualTable, key, []string{tableConf.PartitionKey})
+		if err == nil {
+			if onConflict == "NOTHING" {
+				return nil
+			}
+			return errors.New("DuplicateItemException", "Duplicate primary key exists in table")
+		}
+		if !stderrors.Is(err, spanner.ErrRowNotFound) {
+			return err
+		}
+	}
+	_, mut, err := GetStorage().SpannerTransactWritePut(ctx, tableConf.ActualTable, item, &models.Eval{}, nil, txn, nil)
+	if err != nil {
+		return err
+	}
+	return txn.BufferWrite([]*storage.Mutation{mut})
+}
+
+// partiQLItems reads the items the WHERE clause of an UPDATE or DELETE
+// matches in the transaction
+func partiQLItems(ctx context.Context, txn storage.Transaction, tableConf models.TableConfig, query *translator.DeleteUpdateQueryMap) ([]map[string]interface{}, error) {
+	sql := "SELECT * FROM " + query.Table
+	if query.WhereClause != "" {
+		sql += " WHERE " + query.WhereClause
+	}
+	return txn.Query(ctx, tableConf.ActualTable, spanner.Statement{SQL: sql, Params: query.Params})
+}
+
+// deletePartiQLItems runs a PartiQL DELETE in the transaction and returns the
+// items it deleted
+func deletePartiQLItems(ctx context.Context, txn storage.Transaction, tableConf models.TableConfig, query *translator.DeleteUpdateQueryMap) ([]map[string]interface{}, error) {
+	items, err := partiQLItems(ctx, txn, tableConf, query)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := txn.ExecuteDML(ctx, tableConf.ActualTable, spanner.Statement{SQL: query.SpannerQuery, Params: query.Params}); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// partiQLReturnedItem returns the attributes of an item a RETURNING clause
+// returns: all of them, or the ones the actions of the UPDATE modified, as
+// they were before or after the update
+func partiQLReturnedItem(query *translator.DeleteUpdateQueryMap, old, updated map[string]interface{}) map[string]interface{} {
+	status, age, _ := strings.Cut(query.Returning, " ")
+	item := updated
+	if age == "OLD" {
+		item = old
+	}
+	if status == "ALL" {
+		return item
+	}
+	modified := make(map[string]interface{})
+	for _, action := range query.Actions {
+		if v, ok := item[action.Column]; ok {
+			modified[action.Column] = v
+		}
+	}
+	return modified
+}
+
+// copyDocument returns a deep copy of an attribute value, which the document
+// updates of an item do not change
+func copyDocument(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, field := range v {
+			m[k] = copyDocument(field)
+		}
+		return m
+	case []interface{}:
+		l := make([]interface{}, len(v))
+		for i, elem := range v {
+			l[i] = copyDocument(elem)
+		}
+		return l
+	case []string:
+		return slices.Clone(v)
+	case []models.Number:
+		return slices.Clone(v)
+	case [][]byte:
+		return slices.Clone(v)
+	}
+	return v
+}
@@ -20,9 +20,12 @@ import (
 	"fmt"
 	"hash/fnv"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"unicode"
 
 	"cloud.google.com/go/spanner"
 	"github.com/ahmetb/go-linq"
@@ -36,53 +39,90 @@ import (
 	"github.com/cloudspannerecosystem/dynamodb-adapter/utils"
 )
 
+// Storage is the storage surface used by the services and handlers. It is
+// implemented by the Spanner backed storage.Storage and by storage.MemoryStorage.
 type Storage interface {
-	GetSpannerClient() (*spanner.Client, error)
-	SpannerTransactGetItems(ctx context.Context, tableProjectionCols map[string][]string, pValues map[string]interface{}, sValues map[string]interface{}) ([]map[string]interface{}, error)
-	SpannerTransactWritePut(ctx context.Context, table string, m map[string]interface{}, eval *models.Eval, expr *models.UpdateExpressionCondition, txn *spanner.ReadWriteTransaction, oldRes map[string]interface{}) (map[string]interface{}, *spanner.Mutation, error)
 	SpannerGet(ctx context.Context, tableName string, pKeys, sKeys interface{}, projectionCols []string) (map[string]interface{}, map[string]interface{}, error)
-	TransactWriteSpannerDel(ctx context.Context, table string, m map[string]interface{}, eval *models.Eval, expr *models.UpdateExpressionCondition, txn *spanner.ReadWriteTransaction) (*spanner.Mutation, error)
-	TransactWriteSpannerAdd(ctx context.Context, table string, n map[string]interface{}, eval *models.Eval, expr *models.UpdateExpressionCondition, txn *spanner.ReadWriteTransaction) (map[string]interface{}, *spanner.Mutation, error)
-	TransactWriteSpannerRemove(ctx context.Context, table string, m map[string]interface{}, eval *models.Eval, expr *models.UpdateExpressionCondition, colsToRemove []string, txn *spanner.ReadWriteTransaction) (*spanner.Mutation, error)
+	SpannerBatchGet(ctx context.Context, tableName string, pKeys, sKeys []interface{}, projectionCols []string) ([]map[string]interface{}, error)
+	ExecuteSpannerQuery(ctx context.Context, table string, cols []string, isCountQuery bool, stmt spanner.Statement) ([]map[string]interface{}, error)
+	ExplainQuery(ctx context.Context, table string, stmt spanner.Statement) (*storage.QueryExplain, error)
+	SpannerPut(ctx context.Context, table string, m map[string]interface{}, eval *models.Eval, expr *models.UpdateExpressionCondition, spannerRow map[string]interface{}) (map[string]interface{}, error)
+	SpannerAdd(ctx context.Context, table string, m map[string]interface{}, eval *models.Eval, expr *models.UpdateExpressionCondition) (map[string]interface{}, error)
+	SpannerDel(ctx context.Context, table string, m map[string]interface{}, eval *models.Eval, expr *models.UpdateExpressionCondition) error
+	SpannerRemove(ctx context.Context, table string, m map[string]interface{}, eval *models.Eval, expr *models.UpdateExpressionCondition, colsToRemove []string, oldRes map[string]interface{}) error
+	SpannerDelete(ctx context.Context, table string, m map[string]interface{}, eval *models.Eval, expr *models.UpdateExpressionCondition) error
+	SpannerBatchPut(ctx context.Context, table string, m []map[string]interface{}, spannerRow []map[string]interface{}) error
+	SpannerBatchDelete(ctx context.Context, table string, keys []map[string]interface{}) error
+	SpannerBatchWrite(ctx context.Context, table string, puts, deletes []map[string]interface{}) ([]int, []int, error)
+	InsertUpdateOrDeleteStatement(ctx context.Context, query *translator.DeleteUpdateQueryMap) (map[string]interface{}, error)
+	SpannerIndexSchema(ctx context.Context) ([]storage.IndexColumnSchema, error)
+	ReadWriteTransaction(ctx context.Context, tables []string, f func(context.Context, storage.Transaction) error) error
+	ReadSnapshot(ctx context.Context, tables []string) (context.Context, func(), error)
+	SpannerTransactGetItems(ctx context.Context, tableProjectionCols map[string][]string, pValues map[string]interface{}, sValues map[string]interface{}) ([]map[string]interface{}, error)
+	SpannerTransactWritePut(ctx context.Context, table string, m map[string]interface{}, eval *models.Eval, expr *models.UpdateExpressionCondition, txn storage.Transaction, oldRes map[string]interface{}) (map[string]interface{}, *storage.Mutation, error)
+	TransactWriteSpannerDel(ctx context.Context, table string, m map[string]interface{}, eval *models.Eval, expr *models.UpdateExpressionCondition, txn storage.Transaction) (*storage.Mutation, error)
+	TransactWriteSpannerAdd(ctx context.Context, table string, n map[string]interface{}, eval *models.Eval, expr *models.UpdateExpressionCondition, txn storage.Transaction) (map[string]interface{}, *storage.Mutation, error)
+	TransactWriteSpannerRemove(ctx context.Context, table string, m map[string]interface{}, eval *models.Eval, expr *models.UpdateExpressionCondition, colsToRemove []string, txn storage.Transaction) (*storage.Mutation, error)
+	TransactWriteSpannerDelete(ctx context.Context, table string, m map[string]interface{}, eval *models.Eval, expr *models.UpdateExpressionCondition, txn storage.Transaction) (*storage.Mutation, error)
 }
 type Service interface {
 	MayIReadOrWrite(tableName string, isWrite bool, user string) bool
 	TransactGetItem(ctx context.Context, tableProjectionCols map[string][]string, pValues map[string]interface{}, sValues map[string]interface{}) ([]map[string]interface{}, error)
 	TransactGetProjectionCols(ctx context.Context, transactGetMeta models.GetItemRequest) ([]string, []interface{}, []interface{}, error)
-	TransactWritePut(ctx context.Context, tableName string, putObj map[string]interface{}, expr *models.UpdateExpressionCondition, conditionExp string, expressionAttr, oldRes map[string]interface{}, txn *spanner.ReadWriteTransaction) (map[string]interface{}, *spanner.Mutation, error)
-	TransactWriteDel(ctx context.Context, tableName string, attrMap map[string]interface{}, condExpression string, expressionAttr map[string]interface{}, expr *models.UpdateExpressionCondition, txn *spanner.ReadWriteTransaction) (map[string]interface{}, *spanner.Mutation, error)
-	TransactWriteAdd(ctx context.Context, tableName string, attrMap map[string]interface{}, condExpression string, m, expressionAttr map[string]interface{}, expr *models.UpdateExpressionCondition, oldRes map[string]interface{}, txn *spanner.ReadWriteTransaction) (map[string]interface{}, *spanner.Mutation, error)
-	TransactWriteRemove(ctx context.Context, tableName string, updateAttr models.UpdateAttr, actionValue string, expr *models.UpdateExpressionCondition, oldRes map[string]interface{}, txn *spanner.ReadWriteTransaction) (map[string]interface{}, *spanner.Mutation, error)
+	TransactWritePut(ctx context.Context, tableName string, putObj map[string]interface{}, expr *models.UpdateExpressionCondition, conditionExp string, expressionAttr, oldRes map[string]interface{}, txn storage.Transaction) (map[string]interface{}, *storage.Mutation, error)
+	TransactWriteDel(ctx context.Context, tableName string, attrMap map[string]interface{}, condExpression string, expressionAttr map[string]interface{}, expr *models.UpdateExpressionCondition, txn storage.Transaction) (map[string]interface{}, *storage.Mutation, error)
+	TransactWriteAdd(ctx context.Context, tableName string, attrMap map[string]interface{}, condExpression string, m, expressionAttr map[string]interface{}, expr *models.UpdateExpressionCondition, oldRes map[string]interface{}, txn storage.Transaction) (map[string]interface{}, *storage.Mutation, error)
+	TransactWriteRemove(ctx context.Context, tableName string, updateAttr models.UpdateAttr, actionValue string, expr *models.UpdateExpressionCondition, oldRes map[string]interface{}, txn storage.Transaction) (map[string]interface{}, *storage.Mutation, error)
 	GetWithProjection(ctx context.Context, tableName string, primaryKeyMap map[string]interface{}, projectionExpression string, expressionAttributeNames map[string]string) (map[string]interface{}, map[string]interface{}, error)
 }
 
 type spannerService struct {
-	spannerClient *spanner.Client
-	st            Storage
+	st Storage
 }
 
 var (
 	service Service
 	once    sync.Once
+	st      Storage
 )
 
+// GetStorage returns the storage selected by the storage backend of the config
+func GetStorage() Storage {
+	if st != nil {
+		return st
+	}
+	var backend Storage
+	if models.GlobalConfig != nil && models.GlobalConfig.Storage.Backend == models.StorageBackendMemory {
+		backend = storage.GetMemoryStorageInstance()
+	} else {
+		backend = storage.GetStorageInstance()
+	}
+	if cache := getItemCache(); cache != nil {
+		return cachingStorage{Storage: backend, cache: cache}
+	}
+	return backend
+}
+
+// SetStorage sets the storage instance (for dependency injection)
+func SetStorage(s Storage) {
+	st = s
+	InvalidatePartiQLCache(context.Background())
+}
+
 // SetServiceInstance sets the service instance (for dependency injection)
 func SetServiceInstance(s Service) {
 	service = s
 }
 
+// NewService returns the service running on st
+func NewService(st Storage) Service {
+	return &spannerService{st: st}
+}
+
 func GetServiceInstance() Service {
 	once.Do(func() {
-		storageInstance := storage.GetStorageInstance()
-		spannerClient, err := storageInstance.GetSpannerClient()
-		if err != nil {
-			logger.LogError("Failed to initialize Spanner client: %v", err)
-			panic(err)
-		}
-
 		service = &spannerService{
-			spannerClient: spannerClient,
-			st:            storageInstance,
+			st: GetStorage(),
 		}
 	})
 	return service
@@ -133,7 +173,7 @@ func getSpannerProjections(projectionExpression, table string, expressionAttribu
 
 // Put writes an object to Spanner
 func Put(ctx context.Context, tableName string, putObj map[string]interface{}, expr *models.UpdateExpressionCondition, conditionExp string, expressionAttr, oldRes map[string]interface{}, spannerRow map[string]interface{}) (map[string]interface{}, error) {
-	tableConf, err := config.GetTableConf(tableName)
+	tableConf, err := config.GetTenantTableConf(ctx, tableName)
 	if err != nil {
 		return nil, err
 	}
@@ -143,7 +183,7 @@ func Put(ctx context.Context, tableName string, putObj map[string]interface{}, e
 	if err != nil {
 		return nil, err
 	}
-	newResp, err := storage.GetStorageInstance().SpannerPut(ctx, tableName, putObj, e, expr, spannerRow)
+	newResp, err := GetStorage().SpannerPut(ctx, tableName, putObj, e, expr, spannerRow)
 	if err != nil {
 		return nil, err
 	}
@@ -163,7 +203,7 @@ func Put(ctx context.Context, tableName string, putObj map[string]interface{}, e
 
 // Add checks the expression for converting the data
 func Add(ctx context.Context, tableName string, attrMap map[string]interface{}, condExpression string, m, expressionAttr map[string]interface{}, expr *models.UpdateExpressionCondition, oldRes map[string]interface{}) (map[string]interface{}, error) {
-	tableConf, err := config.GetTableConf(tableName)
+	tableConf, err := config.GetTenantTableConf(ctx, tableName)
 	if err != nil {
 		return nil, err
 	}
@@ -174,7 +214,7 @@ func Add(ctx context.Context, tableName string, attrMap map[string]interface{},
 		return nil, err
 	}
 
-	newResp, err := storage.GetStorageInstance().SpannerAdd(ctx, tableName, m, e, expr)
+	newResp, err := GetStorage().SpannerAdd(ctx, tableName, m, e, expr)
 	if err != nil {
 		return nil, err
 	}
@@ -195,7 +235,7 @@ func Add(ctx context.Context, tableName string, attrMap map[string]interface{},
 // Del checks the expression for saving the data
 func Del(ctx context.Context, tableName string, attrMap map[string]interface{}, condExpression string, expressionAttr map[string]interface{}, expr *models.UpdateExpressionCondition) (map[string]interface{}, error) {
 	logger.LogDebug(expressionAttr)
-	tableConf, err := config.GetTableConf(tableName)
+	tableConf, err := config.GetTenantTableConf(ctx, tableName)
 	if err != nil {
 		return nil, err
 	}
@@ -207,13 +247,13 @@ func Del(ctx context.Context, tableName string, attrMap map[string]interface{},
 		return nil, err
 	}
 
-	err = storage.GetStorageInstance().SpannerDel(ctx, tableName, expressionAttr, e, expr)
+	err = GetStorage().SpannerDel(ctx, tableName, expressionAttr, e, expr)
 	if err != nil {
 		return nil, err
 	}
 	sKey := tableConf.SortKey
 	pKey := tableConf.PartitionKey
-	res, _, err := storage.GetStorageInstance().SpannerGet(ctx, tableName, attrMap[pKey], attrMap[sKey], nil)
+	res, _, err := GetStorage().SpannerGet(ctx, tableName, attrMap[pKey], attrMap[sKey], nil)
 	if err != nil {
 		return nil, err
 	}
@@ -226,7 +266,7 @@ func BatchGet(ctx context.Context, tableName string, keyMapArray []map[string]in
 		var resp = make([]map[string]interface{}, 0)
 		return resp, nil
 	}
-	tableConf, err := config.GetTableConf(tableName)
+	tableConf, err := config.GetTenantTableConf(ctx, tableName)
 	if err != nil {
 		return nil, err
 	}
@@ -242,7 +282,7 @@ func BatchGet(ctx context.Context, tableName string, keyMapArray []map[string]in
 		}
 		pValues = append(pValues, pValue)
 	}
-	return storage.GetStorageInstance().SpannerBatchGet(ctx, tableName, pValues, sValues, nil)
+	return GetStorage().SpannerBatchGet(ctx, tableName, pValues, sValues, nil)
 }
 
 // BatchPut writes bulk records to Spanner
@@ -250,12 +290,12 @@ func BatchPut(ctx context.Context, tableName string, arrAttrMap []map[string]int
 	if len(arrAttrMap) <= 0 {
 		return errors.New("ValidationException")
 	}
-	tableConf, err := config.GetTableConf(tableName)
+	tableConf, err := config.GetTenantTableConf(ctx, tableName)
 	if err != nil {
 		return err
 	}
 	tableName = tableConf.ActualTable
-	err = storage.GetStorageInstance().SpannerBatchPut(ctx, tableName, arrAttrMap, spannerRow)
+	err = GetStorage().SpannerBatchPut(ctx, tableName, arrAttrMap, spannerRow)
 	if err != nil {
 		return err
 	}
@@ -267,7 +307,7 @@ func (s *spannerService) GetWithProjection(ctx context.Context, tableName string
 	if primaryKeyMap == nil {
 		return nil, nil, errors.New("ValidationException")
 	}
-	tableConf, err := config.GetTableConf(tableName)
+	tableConf, err := config.GetTenantTableConf(ctx, tableName)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -280,29 +320,35 @@ func (s *spannerService) GetWithProjection(ctx context.Context, tableName string
 	if tableConf.SortKey != "" {
 		sValue = primaryKeyMap[tableConf.SortKey]
 	}
-	return storage.GetStorageInstance().SpannerGet(ctx, tableName, pValue, sValue, projectionCols)
+	return cachedGet(ctx, tableConf, tableName, pValue, sValue, projectionCols)
 }
 
 // QueryAttributes from Spanner
 func QueryAttributes(ctx context.Context, query models.Query) (map[string]interface{}, string, error) {
-	tableConf, err := config.GetTableConf(query.TableName)
+	tableConf, err := config.GetTenantTableConf(ctx, query.TableName)
 	if err != nil {
 		return nil, "", err
 	}
+	query.TableName = tableConf.ActualTable
 	var sKey string
 	var pKey string
 	tPKey := tableConf.PartitionKey
 	tSKey := tableConf.SortKey
+	// counts read the items too, so they are paged the same way
+	onlyCount := query.OnlyCount
+	query.OnlyCount = false
 	if query.IndexName != "" {
-		conf := tableConf.Indices[query.IndexName]
-		query.IndexName = strings.Replace(query.IndexName, "-", "_", -1)
-
-		if tableConf.ActualTable != query.TableName {
-			query.TableName = tableConf.ActualTable
+		conf, err := getIndexConf(tableConf, query.IndexName)
+		if err != nil {
+			return nil, "", err
 		}
+		query.IndexName = strings.Replace(query.IndexName, "-", "_", -1)
 
 		sKey = conf.SortKey
 		pKey = conf.PartitionKey
+		if err := applyIndexProjection(&query, tableConf, conf); err != nil {
+			return nil, "", err
+		}
 	} else {
 		sKey = tableConf.SortKey
 		pKey = tableConf.PartitionKey
@@ -315,48 +361,126 @@ func QueryAttributes(ctx context.Context, query models.Query) (map[string]interf
 	originalLimit := query.Limit
 	query.Limit = originalLimit + 1
 
-	stmt, cols, isCountQuery, offset, hash, err := createSpannerQuery(&query, tPKey, pKey, sKey)
+	if err := checkQueryFullTableScan(ctx, query.TableName, &query, pKey); err != nil {
+		return nil, "", err
+	}
+	stmt, cols, _, offset, hash, err := createSpannerQuery(&query, tPKey, pKey, sKey)
 	if err != nil {
 		return nil, hash, err
 	}
 	logger.LogDebug(stmt)
-	resp, err := storage.GetStorageInstance().ExecuteSpannerQuery(ctx, query.TableName, cols, isCountQuery, stmt)
+	queryCtx, explained := explainStatement(ctx, query.TableName, query.IndexName, stmt)
+	resp, err := GetStorage().ExecuteSpannerQuery(queryCtx, query.TableName, cols, false, stmt)
+	explained(err)
 	if err != nil {
 		return nil, hash, err
 	}
-	if isCountQuery {
-		return resp[0], hash, nil
+
+	// Like DynamoDB, a page ends after Limit items or once 1MB of items is read,
+	// whether or not they match the FilterExpression
+	items := []map[string]interface{}{}
+	scanned, size := 0, 0
+	for _, row := range resp {
+		if int64(scanned) == originalLimit || size >= maxPageSize {
+			break
+		}
+		scanned++
+		match, filtered := row[storage.FilterColumn]
+		delete(row, storage.FilterColumn)
+		size += models.ItemSize(row)
+		if !filtered || match == true {
+			items = append(items, row)
+		}
 	}
-	finalResp := make(map[string]interface{})
-	length := len(resp)
-	if length == 0 {
-		finalResp["Count"] = 0
-		finalResp["Items"] = []map[string]interface{}{}
-		finalResp["LastEvaluatedKey"] = nil
-		return finalResp, hash, nil
-	}
-	if int64(length) > originalLimit {
-		finalResp["Count"] = length - 1
-		last := resp[length-2]
+
+	finalResp := map[string]interface{}{"Count": len(items), "ScannedCount": scanned, "LastEvaluatedKey": nil}
+	if !onlyCount {
+		finalResp["Items"] = items
+	}
+	if scanned < len(resp) {
+		last := resp[scanned-1]
+		lastEvaluatedKey := map[string]interface{}{"offset": int64(scanned) + offset, pKey: last[pKey], tPKey: last[tPKey]}
 		if sKey != "" {
-			finalResp["LastEvaluatedKey"] = map[string]interface{}{"offset": originalLimit + offset, pKey: last[pKey], tPKey: last[tPKey], sKey: last[sKey], tSKey: last[tSKey]}
-		} else {
-			finalResp["LastEvaluatedKey"] = map[string]interface{}{"offset": originalLimit + offset, pKey: last[pKey], tPKey: last[tPKey]}
+			lastEvaluatedKey[sKey] = last[sKey]
+			lastEvaluatedKey[tSKey] = last[tSKey]
 		}
-		finalResp["Items"] = resp[:length-1]
-	} else {
-		if query.StartFrom != nil && length-1 == 1 {
-			finalResp["Items"] = resp
-		} else {
-			finalResp["Items"] = resp
-		}
-		finalResp["Count"] = length
-		finalResp["Items"] = resp
-		finalResp["LastEvaluatedKey"] = nil
+		finalResp["LastEvaluatedKey"] = lastEvaluatedKey
 	}
 	return finalResp, hash, nil
 }
 
+// maxPageSize is the size of the items read for a page of Query or Scan
+const maxPageSize = 1024 * 1024
+
+// getIndexConf returns the config of the named secondary index. Index names are
+// matched as given and in their Spanner form, where '-' is replaced with '_'.
+func getIndexConf(tableConf models.TableConfig, indexName string) (models.TableConfig, error) {
+	if key, ok := indexKey(tableConf.Indices, indexName); ok {
+		return tableConf.Indices[key], nil
+	}
+	if len(tableConf.Indices) == 0 {
+		// no index metadata is available, fall back to the table keys
+		return models.TableConfig{}, nil
+	}
+	return models.TableConfig{}, errors.New("ValidationException", "The table does not have the specified index: "+indexName)
+}
+
+// indexKey returns the key indexName is configured under. Indexes are keyed by
+// their DynamoDB name, which is matched directly or through the Spanner index
+// name it maps to.
+func indexKey(indices map[string]models.TableConfig, indexName string) (string, bool) {
+	if _, ok := indices[indexName]; ok {
+		return indexName, true
+	}
+	spannerIndexName := utils.ChangeTableNameForSpanner(indexName)
+	for key, indexConf := range indices {
+		if indexConf.SpannerIndexName == spannerIndexName || utils.ChangeTableNameForSpanner(key) == spannerIndexName {
+			return key, true
+		}
+	}
+	return "", false
+}
+
+// applyIndexProjection restricts the columns read through a KEYS_ONLY or INCLUDE
+// index to the projected attributes. Requests that ask for attributes outside of
+// the projection are rejected the same way DynamoDB rejects them.
+func applyIndexProjection(query *models.Query, tableConf, indexConf models.TableConfig) error {
+	if indexConf.ProjectionType == "" || indexConf.ProjectionType == models.ProjectionTypeAll || query.OnlyCount {
+		return nil
+	}
+	if query.Select == "ALL_ATTRIBUTES" {
+		return errors.New("ValidationException", "One or more parameter values were invalid: Select type ALL_ATTRIBUTES is not supported for global secondary index "+query.IndexName+" because its projection type is not ALL")
+	}
+
+	projected := projectedAttributes(tableConf, indexConf)
+	if query.ProjectionExpression == "" {
+		query.ProjectionExpression = strings.Join(projected, ", ")
+		return nil
+	}
+	for _, attr := range strings.Split(query.ProjectionExpression, ",") {
+		attr = strings.TrimSpace(attr)
+		if name, ok := query.ExpressionAttributeNames[attr]; ok {
+			attr = name
+		}
+		if !slices.Contains(projected, attr) {
+			return errors.New("ValidationException", "One or more parameter values were invalid: Attribute "+attr+" is not projected into index "+query.IndexName)
+		}
+	}
+	return nil
+}
+
+// projectedAttributes lists the table keys, the index keys and the non-key
+// attributes stored in the index.
+func projectedAttributes(tableConf, indexConf models.TableConfig) []string {
+	attrs := []string{}
+	for _, attr := range append([]string{tableConf.PartitionKey, tableConf.SortKey, indexConf.PartitionKey, indexConf.SortKey}, indexConf.NonKeyAttributes...) {
+		if attr != "" && !slices.Contains(attrs, attr) {
+			attrs = append(attrs, attr)
+		}
+	}
+	return attrs
+}
+
 func createSpannerQuery(query *models.Query, tPkey, pKey, sKey string) (spanner.Statement, []string, bool, int64, string, error) {
 	stmt := spanner.Statement{}
 	cols, colstr, isCountQuery, err := parseSpannerColumns(query, tPkey, pKey, sKey)
@@ -364,7 +488,16 @@ func createSpannerQuery(query *models.Query, tPkey, pKey, sKey string) (spanner.
 		return stmt, cols, isCountQuery, 0, "", err
 	}
 	tableName := parseSpannerTableName(query)
+	filterExp := query.FilterExp
+	if !isCountQuery {
+		// the filter is applied to the rows read, see parseFilterColumn
+		query.FilterExp = ""
+	}
 	whereCondition, m := parseSpannerCondition(query, pKey, sKey)
+	if !isCountQuery && filterExp != "" {
+		query.FilterExp = filterExp
+		colstr += "," + parseFilterColumn(query, m)
+	}
 	offsetString, offset := parseOffset(query)
 	orderBy := parseSpannerSorting(query, isCountQuery, pKey, sKey)
 	limitClause := parseLimit(query, isCountQuery)
@@ -438,6 +571,15 @@ func parseSpannerColumns(query *models.Query, tPkey, pKey, sKey string) ([]strin
 	return cols, colStr, false, nil
 }
 
+// parseFilterColumn selects whether a row matches the FilterExpression as
+// storage.FilterColumn. Like DynamoDB, the Limit and the page size count the
+// rows read before they are filtered.
+func parseFilterColumn(query *models.Query, params map[string]interface{}) string {
+	condition, expression := createWhereClause("WHERE ", query.FilterExp, "filterExp", query.RangeValMap, params)
+	query.FilterExp = expression
+	return "COALESCE((" + strings.TrimSpace(strings.TrimPrefix(condition, "WHERE ")) + "), FALSE) AS " + storage.FilterColumn
+}
+
 func parseSpannerTableName(query *models.Query) string {
 	tableName := utils.ChangeTableNameForSpanner(query.TableName)
 	if query.IndexName != "" {
@@ -450,8 +592,16 @@ func parseSpannerCondition(query *models.Query, pKey, sKey string) (string, map[
 	params := make(map[string]interface{})
 	whereClause := "WHERE "
 
+	// items without the index keys must not be returned from a sparse index
+	var keyConditions []string
+	if query.IndexName != "" && pKey != "" {
+		keyConditions = append(keyConditions, pKey+" is not null")
+	}
 	if sKey != "" {
-		whereClause += sKey + " is not null "
+		keyConditions = append(keyConditions, sKey+" is not null")
+	}
+	if len(keyConditions) > 0 {
+		whereClause += strings.Join(keyConditions, " AND ") + " "
 	}
 
 	if query.RangeExp != "" {
@@ -475,12 +625,13 @@ func createWhereClause(whereClause string, expression string, queryVar string, R
 	if whereClause != "WHERE " && !strings.HasSuffix(trimmedString, "AND") {
 		whereClause += " AND "
 	}
+	columns := conditionValueColumns(expression)
 	count := 1
 	for k, v := range RangeValueMap {
 		if strings.Contains(expression, k) {
 			str := queryVar + strconv.Itoa(count)
 			expression = strings.ReplaceAll(expression, k, "@"+str)
-			params[str] = v
+			params[str] = columnParam(columns[k], v)
 			count++
 		}
 	}
@@ -492,7 +643,7 @@ func createWhereClause(whereClause string, expression string, queryVar string, R
 		jsonFields := strings.Split(expressionParts[0], ".")
 
 		// Construct new JSON_VALUE expression
-		newExpression := fmt.Sprintf("JSON_VALUE(%s, '$.%s') = %s", jsonFields[0], strings.Join(jsonFields[1:], "."), expressionParts[1])
+		newExpression := fmt.Sprintf("%s = %s", jsonValueExpression(jsonFields[0], jsonFields[1:]), expressionParts[1])
 		whereClause = whereClause + " " + newExpression
 	} else if expression != "" {
 		whereClause = whereClause + expression
@@ -500,6 +651,13 @@ func createWhereClause(whereClause string, expression string, queryVar string, R
 	return whereClause, expression
 }
 
+// jsonValueExpression returns the string at the path of fields in a Map column.
+// Maps are stored as DynamoDB JSON, or as plain JSON when written before.
+func jsonValueExpression(column string, fields []string) string {
+	typedPath := "$.M." + strings.Join(fields, ".M.") + ".S"
+	return fmt.Sprintf("COALESCE(JSON_VALUE(%s, '%s'), JSON_VALUE(%s, '$.%s'))", column, typedPath, column, strings.Join(fields, "."))
+}
+
 func parseOffset(query *models.Query) (string, int64) {
 	logger.LogDebug(query)
 	if query.StartFrom != nil {
@@ -535,35 +693,111 @@ func parseLimit(query *models.Query, isCountQuery bool) string {
 	return " LIMIT " + strconv.FormatInt(query.Limit, 10)
 }
 
-// BatchGetWithProjection from Spanner
-func BatchGetWithProjection(ctx context.Context, tableName string, keyMapArray []map[string]interface{}, projectionExpression string, expressionAttributeNames map[string]string) ([]map[string]interface{}, error) {
+// ReadSnapshot returns a context in which the batch reads of the tables see
+// one snapshot, and the function that releases it
+func ReadSnapshot(ctx context.Context, tableNames []string) (context.Context, func(), error) {
+	tables := make([]string, len(tableNames))
+	for i, tableName := range tableNames {
+		tableConf, err := config.GetTenantTableConf(ctx, tableName)
+		if err != nil {
+			return nil, nil, err
+		}
+		tables[i] = tableConf.ActualTable
+	}
+	return GetStorage().ReadSnapshot(ctx, tables)
+}
+
+// BatchGetWithProjection reads the items with the keys of keyMapArray. Items
+// are returned in the order of their keys while their size fits in budget,
+// which is shared by the tables of a request; the indexes of the keys of the
+// items left out are returned as unprocessed.
+func BatchGetWithProjection(ctx context.Context, tableName string, keyMapArray []map[string]interface{}, projectionExpression string, expressionAttributeNames map[string]string, budget *atomic.Int64) ([]map[string]interface{}, []int, error) {
 	if len(keyMapArray) == 0 {
 		var resp = make([]map[string]interface{}, 0)
-		return resp, nil
+		return resp, nil, nil
 	}
-	tableConf, err := config.GetTableConf(tableName)
+	tableConf, err := config.GetTenantTableConf(ctx, tableName)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	tableName = tableConf.ActualTable
 
 	projectionCols := getSpannerProjections(projectionExpression, tableName, expressionAttributeNames)
-	var pValues []interface{}
-	var sValues []interface{}
-	for i := 0; i < len(keyMapArray); i++ {
-		pValue := keyMapArray[i][tableConf.PartitionKey]
-		if tableConf.SortKey != "" {
-			sValue := keyMapArray[i][tableConf.SortKey]
-			sValues = append(sValues, sValue)
+	// the key columns are read to match the items with their keys
+	readCols := projectionCols
+	var extraCols []string
+	if len(projectionCols) > 0 {
+		readCols = append([]string{}, projectionCols...)
+		for _, col := range []string{tableConf.PartitionKey, tableConf.SortKey} {
+			if col != "" && !slices.Contains(readCols, col) {
+				readCols = append(readCols, col)
+				extraCols = append(extraCols, col)
+			}
 		}
-		pValues = append(pValues, pValue)
 	}
-	return storage.GetStorageInstance().SpannerBatchGet(ctx, tableName, pValues, sValues, projectionCols)
+	rows, err := cachedBatchGet(ctx, tableConf, tableName, keyMapArray, readCols)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rowsByKey := make(map[string]map[string]interface{}, len(rows))
+	for _, row := range rows {
+		rowsByKey[itemKey(tableConf, row)] = row
+	}
+	items := make([]map[string]interface{}, 0, len(rows))
+	var unprocessed []int
+	for i, key := range keyMapArray {
+		row, ok := rowsByKey[itemKey(tableConf, key)]
+		if !ok {
+			continue
+		}
+		for _, col := range extraCols {
+			delete(row, col)
+		}
+		if len(unprocessed) == 0 {
+			size := int64(models.ItemSize(row))
+			if budget.Add(-size) >= 0 {
+				items = append(items, row)
+				continue
+			}
+			budget.Add(size)
+		}
+		unprocessed = append(unprocessed, i)
+	}
+	return items, unprocessed, nil
+}
+
+// itemKey returns a string identifying the key of item, a key or a row
+func itemKey(tableConf models.TableConfig, item map[string]interface{}) string {
+	return keyString(item[tableConf.PartitionKey], item[tableConf.SortKey])
+}
+
+// keyString returns a string identifying the key with the partition and sort
+// key values, nil when the table has no sort key
+func keyString(pValue, sValue interface{}) string {
+	var sb strings.Builder
+	for _, value := range []interface{}{pValue, sValue} {
+		sb.WriteByte(0)
+		switch v := value.(type) {
+		case nil:
+		case string:
+			sb.WriteString("S" + v)
+		case []byte:
+			sb.WriteString("B" + string(v))
+		default:
+			if n, ok := models.ToNumber(v); ok {
+				sb.WriteString("N" + n.String())
+			} else {
+				sb.WriteString(fmt.Sprint(v))
+			}
+		}
+	}
+	return sb.String()
 }
 
 // Delete service
 func Delete(ctx context.Context, tableName string, primaryKeyMap map[string]interface{}, condExpression string, attrMap map[string]interface{}, expr *models.UpdateExpressionCondition) error {
-	tableConf, err := config.GetTableConf(tableName)
+	tableConf, err := config.GetTenantTableConf(ctx, tableName)
 	if err != nil {
 		return err
 	}
@@ -572,24 +806,35 @@ func Delete(ctx context.Context, tableName string, primaryKeyMap map[string]inte
 	if err != nil {
 		return err
 	}
-	return storage.GetStorageInstance().SpannerDelete(ctx, tableName, primaryKeyMap, e, expr)
+	return GetStorage().SpannerDelete(ctx, tableName, primaryKeyMap, e, expr)
 }
 
 // BatchDelete service
 func BatchDelete(ctx context.Context, tableName string, keyMapArray []map[string]interface{}) error {
-	tableConf, err := config.GetTableConf(tableName)
+	tableConf, err := config.GetTenantTableConf(ctx, tableName)
 	if err != nil {
 		return err
 	}
 
 	tableName = tableConf.ActualTable
-	err = storage.GetStorageInstance().SpannerBatchDelete(ctx, tableName, keyMapArray)
+	err = GetStorage().SpannerBatchDelete(ctx, tableName, keyMapArray)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
+// BatchWrite puts and deletes items of the table in batch. Each item is
+// written on its own; the indexes of the puts and deletes that failed are
+// returned so they can be retried.
+func BatchWrite(ctx context.Context, tableName string, puts, deletes []map[string]interface{}) ([]int, []int, error) {
+	tableConf, err := config.GetTenantTableConf(ctx, tableName)
+	if err != nil {
+		return nil, nil, err
+	}
+	return GetStorage().SpannerBatchWrite(ctx, tableConf.ActualTable, puts, deletes)
+}
+
 // Scan service
 func Scan(ctx context.Context, scanData models.ScanMeta) (map[string]interface{}, error) {
 	query := models.Query{}
@@ -618,7 +863,7 @@ func Scan(ctx context.Context, scanData models.ScanMeta) (map[string]interface{}
 func Remove(ctx context.Context, tableName string, updateAttr models.UpdateAttr, actionValue string, expr *models.UpdateExpressionCondition, oldRes map[string]interface{}) (map[string]interface{}, error) {
 	actionValue = strings.ReplaceAll(actionValue, " ", "")
 	colsToRemove := strings.Split(actionValue, ",")
-	tableConf, err := config.GetTableConf(tableName)
+	tableConf, err := config.GetTenantTableConf(ctx, tableName)
 	if err != nil {
 		return nil, err
 	}
@@ -627,7 +872,7 @@ func Remove(ctx context.Context, tableName string, updateAttr models.UpdateAttr,
 	if err != nil {
 		return nil, err
 	}
-	err = storage.GetStorageInstance().SpannerRemove(ctx, tableName, updateAttr.PrimaryKeyMap, e, expr, colsToRemove, oldRes)
+	err = GetStorage().SpannerRemove(ctx, tableName, updateAttr.PrimaryKeyMap, e, expr, colsToRemove, oldRes)
 	if err != nil {
 		return nil, err
 	}
@@ -661,7 +906,7 @@ func Remove(ctx context.Context, tableName string, updateAttr models.UpdateAttr,
 // TransactGetProjectionCols gets the projection columns from the TransactGet request
 func (s *spannerService) TransactGetProjectionCols(ctx context.Context, getRequest models.GetItemRequest) ([]string, []interface{}, []interface{}, error) {
 	// Get the table configuration
-	tableConf, err := config.GetTableConf(getRequest.TableName)
+	tableConf, err := config.GetTenantTableConf(ctx, getRequest.TableName)
 	if err != nil {
 		return nil, nil, nil, err
 	}
@@ -686,14 +931,54 @@ func (s *spannerService) TransactGetProjectionCols(ctx context.Context, getReque
 }
 
 func (s *spannerService) TransactGetItem(ctx context.Context, tableProjectionCols map[string][]string, pValues map[string]interface{}, sValues map[string]interface{}) ([]map[string]interface{}, error) {
+	// The tables are read by their stored names and reported with the names
+	// the client used
+	tableNames := make(map[string]string, len(tableProjectionCols))
+	storedCols := make(map[string][]string, len(tableProjectionCols))
+	storedPValues := make(map[string]interface{}, len(pValues))
+	storedSValues := make(map[string]interface{}, len(sValues))
+	for tableName, projectionCols := range tableProjectionCols {
+		tableConf, err := config.GetTenantTableConf(ctx, tableName)
+		if err != nil {
+			return nil, err
+		}
+		tableNames[tableConf.ActualTable] = tableName
+		storedCols[tableConf.ActualTable] = projectionCols
+		storedPValues[tableConf.ActualTable] = pValues[tableName]
+		storedSValues[tableConf.ActualTable] = sValues[tableName]
+	}
+
 	// Call the SpannerTransactGetItems method on the Storage interface
 	// This method fetches data from Spanner based on the provided table projection columns,
 	// partition key values, and sort key values.
-	return s.st.SpannerTransactGetItems(ctx, tableProjectionCols, pValues, sValues)
+	rows, err := s.st.SpannerTransactGetItems(ctx, storedCols, storedPValues, storedSValues)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		if tableName, ok := row["TableName"].(string); ok {
+			row["TableName"] = tableNames[tableName]
+		}
+	}
+	return rows, nil
 }
 
-// ExecuteStatement service API handler function
+// ExecuteStatement service API handler function. The table of the statement
+// is named as the client names it.
 func ExecuteStatement(ctx context.Context, executeStatement models.ExecuteStatement) (map[string]interface{}, error) {
+	return runStatement(ctx, storedStatement(ctx, executeStatement))
+}
+
+// storedStatement returns executeStatement on the stored table, in the
+// namespace of the tenant of ctx. The ExecuteStatementFor functions take
+// statements on stored tables.
+func storedStatement(ctx context.Context, executeStatement models.ExecuteStatement) models.ExecuteStatement {
+	executeStatement.TableName = config.TableName(ctx, executeStatement.TableName)
+	return executeStatement
+}
+
+// runStatement runs a statement on a stored table
+func runStatement(ctx context.Context, executeStatement models.ExecuteStatement) (map[string]interface{}, error) {
 
 	query := strings.TrimSpace(executeStatement.Statement) // Remove any leading or trailing whitespace
 	queryUpper := strings.ToUpper(query)
@@ -722,60 +1007,133 @@ func ExecuteStatement(ctx context.Context, executeStatement models.ExecuteStatem
 // - spanner.Statement: A Google Cloud Spanner statement ready to be executed.
 // - error: An error object, if an error occurs during translation or parameter conversion.
 func parsePartiQlToSpannerforSelect(ctx context.Context, executeStatement models.ExecuteStatement) (spanner.Statement, error) {
-	stmt := spanner.Statement{}
-	paramMap := make(map[string]interface{})
-	var err error
-
-	translatorObj := translator.Translator{}
-
-	queryMap, err := translatorObj.ToSpannerSelect(executeStatement.Statement)
+	queryMap, params, err := translatePartiQLSelect(ctx, executeStatement)
 	if err != nil {
-		return stmt, err
+		return spanner.Statement{}, err
 	}
+	return spanner.Statement{SQL: queryMap.SpannerQuery, Params: params}, nil
+}
 
-	queryStmt := queryMap.SpannerQuery
-	if executeStatement.Limit != 0 {
-		queryMap.Limit = strconv.FormatInt(executeStatement.Limit, 10)
+// translatePartiQLSelect translates a PartiQL SELECT and returns the query
+// parameters of its WHERE clause
+func translatePartiQLSelect(ctx context.Context, executeStatement models.ExecuteStatement) (*translator.SelectQueryMap, map[string]interface{}, error) {
+	translatorObj := partiQLTranslator(executeStatement)
+	queryMap, err := translateCached(ctx, "SELECT", executeStatement, translatorObj.ToSpannerSelect)
+	if err != nil {
+		return nil, nil, errors.New("ValidationException", err.Error())
+	}
+	params := make(map[string]interface{})
+	if err := partiQLParams(queryMap.WhereParams, executeStatement.Parameters, params); err != nil {
+		return nil, nil, err
 	}
+	return queryMap, params, nil
+}
 
-	err = handleParameters(executeStatement.Parameters, queryMap.Where, &paramMap, &queryStmt)
-	if err != nil {
-		return stmt, err
+// partiQLTranslator returns the translator of a PartiQL statement, which
+// translates it to run on the Spanner table of its TableName
+func partiQLTranslator(executeStatement models.ExecuteStatement) translator.Translator {
+	return translator.Translator{
+		Table:         utils.ChangeTableNameForSpanner(executeStatement.TableName),
+		ColumnType:    partiQLColumnType,
+		ParameterType: partiQLParameterType(executeStatement.Parameters),
 	}
+}
 
-	stmt.SQL = queryMap.SpannerQuery
-	stmt.Params = paramMap
-	return stmt, nil
+// partiQLColumnType returns the DynamoDB type of a column of a table
+func partiQLColumnType(table, column string) string {
+	return models.TableDDL[utils.ChangeTableNameForSpanner(table)][column]
 }
-func handleParameters(parameters []*dynamodb.AttributeValue, whereConditions []translator.Condition, paramMap *map[string]interface{}, queryStmt *string) error {
-	for i, val := range parameters {
-		if val.S != nil {
-			(*paramMap)[whereConditions[i].Column] = *val.S
-			*queryStmt = strings.Replace(*queryStmt, "?", "@val"+strconv.Itoa(i), 1)
-		} else if val.N != nil {
-			floatVal, err := strconv.ParseFloat(*val.N, 64)
-			if err != nil {
-				return fmt.Errorf("failed to convert the string type to the float")
+
+// conditionKeywords are the words of a condition expression which are not
+// attribute names
+var conditionKeywords = map[string]bool{
+	"AND": true, "OR": true, "NOT": true, "BETWEEN": true, "IN": true,
+	"BEGINS_WITH": true, "STARTS_WITH": true, "CONTAINS": true, "SIZE": true,
+	"ATTRIBUTE_EXISTS": true, "ATTRIBUTE_NOT_EXISTS": true, "ATTRIBUTE_TYPE": true,
+}
+
+// conditionValueColumns maps each :value of a condition expression to the
+// attribute it is compared with, the one named last before it or, when the
+// value comes first, the one named after it. Values compared with the size of
+// an attribute are not mapped.
+func conditionValueColumns(expression string) map[string]string {
+	tokens := strings.FieldsFunc(expression, func(r rune) bool {
+		return unicode.IsSpace(r) || strings.ContainsRune("(),=<>!`", r)
+	})
+	columns := map[string]string{}
+	var attribute string
+	var pending []string
+	seen, size := false, false
+	for _, token := range tokens {
+		switch {
+		case strings.HasPrefix(token, ":"):
+			if !seen {
+				pending = append(pending, token)
+			} else if attribute != "" {
+				columns[token] = attribute
 			}
-			(*paramMap)[whereConditions[i].Column] = floatVal
-			*queryStmt = strings.Replace(*queryStmt, "?", "@val"+strconv.Itoa(i), 1)
-		} else if val.BOOL != nil {
-			(*paramMap)[whereConditions[i].Column] = *val.BOOL
-			*queryStmt = strings.Replace(*queryStmt, "?", "@val"+strconv.Itoa(i), 1)
-		} else if val.SS != nil {
-			ss := make([]interface{}, len(val.SS))
-			for index, v := range val.SS {
-				ss[index] = *v
+		case strings.EqualFold(token, "SIZE"):
+			size = true
+		case conditionKeywords[strings.ToUpper(token)]:
+		default:
+			attribute, seen = token, true
+			if size {
+				attribute, size = "", false
 			}
-			(*paramMap)[whereConditions[i].Column] = ss
-			*queryStmt = strings.Replace(*queryStmt, "?", "@val"+strconv.Itoa(i), 1)
-		} else {
-			return fmt.Errorf("unsupported datatype")
+			for _, value := range pending {
+				columns[value] = attribute
+			}
+			pending = nil
+		}
+	}
+	return columns
+}
+
+// columnParam tells storage the column a number parameter belongs to, which
+// decides the type the number is converted to
+func columnParam(column string, value interface{}) interface{} {
+	switch value.(type) {
+	case models.Number, []models.Number, []interface{}:
+		if column != "" {
+			return storage.ColumnParam{Column: column, Value: value}
+		}
+	}
+	return value
+}
+
+// partiQLParams adds the query parameters of the WHERE clause of a PartiQL
+// statement to params. Placeholders take the statement parameter at their
+// index.
+func partiQLParams(whereParams []translator.WhereParam, parameters []*dynamodb.AttributeValue, params map[string]interface{}) error {
+	for _, param := range whereParams {
+		var value interface{}
+		var err error
+		switch {
+		case param.Placeholder < 0:
+			value, err = partiQLLiteral(param)
+		case param.Placeholder < len(parameters):
+			value, err = partiQLParam(parameters[param.Placeholder])
+		default:
+			return parameterCountError()
 		}
+		if err != nil {
+			return err
+		}
+		params[param.Name] = columnParam(param.Column, value)
 	}
 	return nil
 }
 
+func partiQLLiteral(param translator.WhereParam) (interface{}, error) {
+	switch param.Type {
+	case "N":
+		return models.ParseNumber(param.Literal)
+	case "BOOL":
+		return strconv.ParseBool(param.Literal)
+	}
+	return param.Literal, nil
+}
+
 // ExecuteStatementForSelect executes a select statement on a Spanner database, converting a PartiQL statement to a Spanner statement.
 //
 // Parameters:
@@ -783,18 +1141,52 @@ func handleParameters(parameters []*dynamodb.AttributeValue, whereConditions []t
 // - executeStatement: Contains the PartiQL select statement and parameters to be executed.
 //
 // Returns:
-// - map[string]interface{}: A map containing the fetched items under the key "Items".
+// - map[string]interface{}: A map containing the fetched items under the key "Items", and "NextToken" when there are more.
 // - error: An error object, if any issues arise during the execution process.
 func ExecuteStatementForSelect(ctx context.Context, executeStatement models.ExecuteStatement) (map[string]interface{}, error) {
-	spannerStatement, err := parsePartiQlToSpannerforSelect(ctx, executeStatement)
+	if executeStatement.Limit < 0 {
+		return nil, errors.New("ValidationException", "Limit must be greater than or equal to 1")
+	}
+	queryMap, params, err := translatePartiQLSelect(ctx, executeStatement)
 	if err != nil {
 		return nil, err
-
 	}
-	resp, err := storage.GetStorageInstance().ExecuteSpannerQuery(ctx, executeStatement.TableName, []string{}, false, spannerStatement)
+	tableConf, err := config.GetTableConf(executeStatement.TableName)
+	if err != nil {
+		return nil, err
+	}
+	keyConf, err := partiQLKeyConf(queryMap, tableConf)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkPartiQLFullTableScan(ctx, executeStatement.TableName, queryMap, keyConf); err != nil {
+		return nil, err
+	}
+	if len(queryMap.Aggregates) > 0 {
+		return executePartiQLAggregate(ctx, executeStatement, queryMap, keyConf, params)
+	}
+	if err := checkPartiQLOrder(queryMap, keyConf); err != nil {
+		return nil, err
+	}
+	if partiQLPageable(queryMap, keyConf) {
+		return executePartiQLSelectPage(ctx, executeStatement, queryMap, tableConf, keyConf, params)
+	}
+	if executeStatement.NextToken != "" {
+		return nil, errors.New("ValidationException", invalidNextToken)
+	}
+	spannerStatement := spanner.Statement{SQL: queryMap.SpannerQuery, Params: params}
+	queryCtx, explained := explainStatement(ctx, executeStatement.TableName, partiQLIndexName(queryMap, keyConf), spannerStatement)
+	resp, err := GetStorage().ExecuteSpannerQuery(queryCtx, executeStatement.TableName, []string{}, false, spannerStatement)
+	explained(err)
 	if err != nil {
 		return nil, err
 	}
+	if len(queryMap.ProjectionPaths) > 0 {
+		hidden := partiQLPathColumns(queryMap)
+		for _, item := range resp {
+			projectPartiQLPaths(queryMap, item, hidden)
+		}
+	}
 	finalResp := make(map[string]interface{})
 	finalResp["Items"] = resp
 	return finalResp, nil
@@ -811,53 +1203,49 @@ func ExecuteStatementForSelect(ctx context.Context, executeStatement models.Exec
 // - map[string]interface{}: A map containing the result of the insert operation.
 // - error: An error object, if any issues arise during the execution process.
 func ExecuteStatementForInsert(ctx context.Context, executeStatement models.ExecuteStatement) (map[string]interface{}, error) {
-	translatorObj := translator.Translator{}
-	parsedQueryObj, err := translatorObj.ToSpannerInsert(executeStatement.Statement)
+	tableConf, err := config.GetTableConf(executeStatement.TableName)
 	if err != nil {
 		return nil, err
 	}
-
-	colDLL, ok := models.TableDDL[utils.ChangeTableNameForSpanner(executeStatement.TableName)]
-	if !ok {
-		return nil, fmt.Errorf("ResourceNotFoundException: %s", executeStatement.TableName)
+	newMap, onConflict, err := partiQLInsertItem(ctx, executeStatement)
+	if err != nil {
+		return nil, err
 	}
+	return nil, GetStorage().ReadWriteTransaction(ctx, []string{tableConf.ActualTable}, func(ctx context.Context, txn storage.Transaction) error {
+		return insertPartiQLItem(ctx, txn, tableConf, newMap, onConflict)
+	})
+}
 
-	newMap := make(map[string]interface{})
-	attrParams := executeStatement.AttrParams
-
-	columns := parsedQueryObj.Columns
-	values := parsedQueryObj.Values
-
-	// Determine the number of parameters to use and iterate accordingly
-	var paramsCount int
-	if len(attrParams) > 0 {
-		paramsCount = len(attrParams)
-	} else {
-		paramsCount = len(columns)
+// partiQLInsertItem returns the item a PartiQL INSERT statement writes and
+// the action of its ON CONFLICT clause
+func partiQLInsertItem(ctx context.Context, executeStatement models.ExecuteStatement) (map[string]interface{}, string, error) {
+	translatorObj := partiQLTranslator(executeStatement)
+	parsedQueryObj, err := translateCached(ctx, "INSERT", executeStatement, translatorObj.ToSpannerInsert)
+	if err != nil {
+		return nil, "", errors.New("ValidationException", err.Error())
+	}
+	if _, ok := models.TableDDL[utils.ChangeTableNameForSpanner(executeStatement.TableName)]; !ok {
+		return nil, "", fmt.Errorf("ResourceNotFoundException: %s", executeStatement.TableName)
+	}
+	tableConf, err := config.GetTableConf(executeStatement.TableName)
+	if err != nil {
+		return nil, "", err
 	}
 
-	for i := 0; i < paramsCount; i++ {
-		var value interface{}
-		if len(attrParams) > 0 {
-			value = attrParams[i]
-		} else {
-			value = values[i]
-		}
-
-		columnName := columns[i]
-		columnType := colDLL[columnName]
-
-		convertedValue, err := convertType(columnName, value, columnType)
+	newMap := make(map[string]interface{})
+	for columnName, value := range parsedQueryObj.Item {
+		attr, err := partiQLValue(value, executeStatement.Parameters, nil)
 		if err != nil {
-			return nil, err
+			return nil, "", err
+		}
+		if newMap[columnName], err = partiQLColumnValue(executeStatement.TableName, columnName, attr); err != nil {
+			return nil, "", err
 		}
-		newMap[columnName] = convertedValue
 	}
-	result, err := Put(ctx, executeStatement.TableName, newMap, nil, "", nil, nil, nil)
-	if err != nil {
-		return result, err
+	if err := checkPartiQLItemKey(newMap, tableConf); err != nil {
+		return nil, "", err
 	}
-	return result, nil
+	return newMap, parsedQueryObj.OnConflict, nil
 }
 
 // ExecuteStatementForUpdate executes an update statement on a Spanner database by converting a PartiQL update statement
@@ -871,45 +1259,76 @@ func ExecuteStatementForInsert(ctx context.Context, executeStatement models.Exec
 // - map[string]interface{}: A map containing the result of the update operation or nil if successful.
 // - error: An error object, if any issues arise during the execution process.
 func ExecuteStatementForUpdate(ctx context.Context, executeStatement models.ExecuteStatement) (map[string]interface{}, error) {
-	translatorObj := translator.Translator{}
-	parsedQueryObj, err := translatorObj.ToSpannerUpdate(executeStatement.Statement)
+	parsedQueryObj, err := partiQLUpdateQuery(ctx, executeStatement)
 	if err != nil {
 		return nil, err
 	}
-	paramMap := make(map[string]interface{})
-	if len(executeStatement.Parameters) > 0 {
-		j := len(parsedQueryObj.UpdateSetValues)
-		for i, val := range parsedQueryObj.UpdateSetValues {
-			colDLL, ok := models.TableDDL[utils.ChangeTableNameForSpanner(executeStatement.TableName)]
-			if !ok {
-				return nil, fmt.Errorf("ResourceNotFoundException: %s", executeStatement.TableName)
-			}
-			convertedValue, err := convertType(val.Column, executeStatement.AttrParams[i], colDLL[val.Column])
-			if err != nil {
-				return nil, err
-			}
-			paramMap[val.Column] = convertedValue
+	if partiQLItemUpdate(parsedQueryObj, executeStatement.Parameters) {
+		tableConf, err := config.GetTableConf(executeStatement.TableName)
+		if err != nil {
+			return nil, err
+		}
+		var items []map[string]interface{}
+		err = GetStorage().ReadWriteTransaction(ctx, []string{tableConf.ActualTable}, func(ctx context.Context, txn storage.Transaction) error {
+			items, err = updatePartiQLItems(ctx, txn, tableConf, parsedQueryObj, executeStatement.Parameters)
+			return err
+		})
+		if err != nil || parsedQueryObj.Returning == "" {
+			return nil, err
+		}
+		return map[string]interface{}{"Items": items}, nil
+	}
+	res, err := GetStorage().InsertUpdateOrDeleteStatement(ctx, parsedQueryObj)
+	if err != nil {
+		return res, err
+	}
+	return nil, err
+}
 
+// partiQLUpdateQuery translates a PartiQL UPDATE statement and binds its
+// parameters
+func partiQLUpdateQuery(ctx context.Context, executeStatement models.ExecuteStatement) (*translator.DeleteUpdateQueryMap, error) {
+	tableConf, err := config.GetTableConf(executeStatement.TableName)
+	if err != nil {
+		return nil, err
+	}
+	translatorObj := partiQLTranslator(executeStatement)
+	parsedQueryObj, err := translateCached(ctx, "UPDATE", executeStatement, translatorObj.ToSpannerUpdate)
+	if err != nil {
+		return nil, errors.New("ValidationException", err.Error())
+	}
+	if err := checkPartiQLKey(parsedQueryObj, tableConf); err != nil {
+		return nil, err
+	}
+	paramMap := make(map[string]interface{})
+	// the SET values of updates applied to the items are bound to each item
+	if len(executeStatement.Parameters) > 0 && !partiQLItemUpdate(parsedQueryObj, executeStatement.Parameters) {
+		colDLL, ok := models.TableDDL[utils.ChangeTableNameForSpanner(executeStatement.TableName)]
+		if !ok {
+			return nil, fmt.Errorf("ResourceNotFoundException: %s", executeStatement.TableName)
 		}
-		for _, val := range parsedQueryObj.Clauses {
-			colDLL, ok := models.TableDDL[utils.ChangeTableNameForSpanner(executeStatement.TableName)]
-			if !ok {
-				return nil, fmt.Errorf("ResourceNotFoundException: %s", executeStatement.TableName)
+		// the placeholders of the SET clause come first
+		placeholder := 0
+		for _, val := range parsedQueryObj.UpdateSetValues {
+			if val.Value != "?" {
+				continue
+			}
+			if placeholder >= len(executeStatement.AttrParams) {
+				return nil, parameterCountError()
 			}
-			convertedValue, err := convertType(val.Column, executeStatement.AttrParams[j], colDLL[val.Column])
+			convertedValue, err := convertType(val.Column, executeStatement.AttrParams[placeholder], colDLL[val.Column])
 			if err != nil {
 				return nil, err
 			}
-			paramMap[val.Column] = convertedValue
-			j++
+			paramMap[val.Column] = columnParam(val.Column, convertedValue)
+			placeholder++
 		}
-		parsedQueryObj.Params = paramMap
 	}
-	res, err := storage.GetStorageInstance().InsertUpdateOrDeleteStatement(ctx, parsedQueryObj)
-	if err != nil {
-		return res, err
+	if err := partiQLParams(parsedQueryObj.WhereParams, executeStatement.Parameters, paramMap); err != nil {
+		return nil, err
 	}
-	return nil, err
+	parsedQueryObj.Params = paramMap
+	return parsedQueryObj, nil
 }
 
 // ExecuteStatementForDelete executes a delete statement on a Spanner database by converting a PartiQL delete statement
@@ -923,29 +1342,27 @@ func ExecuteStatementForUpdate(ctx context.Context, executeStatement models.Exec
 // - map[string]interface{}: A map containing the result of the delete operation.
 // - error: An error object, if any issues arise during the execution process.
 func ExecuteStatementForDelete(ctx context.Context, executeStatement models.ExecuteStatement) (map[string]interface{}, error) {
-
-	translatorObj := translator.Translator{}
-	parsedQueryObj, err := translatorObj.ToSpannerDelete(executeStatement.Statement)
+	parsedQueryObj, err := partiQLDeleteQuery(ctx, executeStatement)
 	if err != nil {
 		return nil, err
 	}
-	newMap := make(map[string]interface{})
-	if len(executeStatement.AttrParams) > 0 {
-		for i, val := range parsedQueryObj.Clauses {
-			colDLL, ok := models.TableDDL[utils.ChangeTableNameForSpanner(executeStatement.TableName)]
-			if !ok {
-				return nil, fmt.Errorf("ResourceNotFoundException: %s", executeStatement.TableName)
-			}
-			convertedValue, err := convertType(val.Column, executeStatement.AttrParams[i], colDLL[val.Column])
-			if err != nil {
-				return nil, err
-			}
-			newMap[val.Column] = convertedValue
+	if parsedQueryObj.Returning != "" {
+		tableConf, err := config.GetTableConf(executeStatement.TableName)
+		if err != nil {
+			return nil, err
 		}
-		parsedQueryObj.Params = newMap
+		var items []map[string]interface{}
+		err = GetStorage().ReadWriteTransaction(ctx, []string{tableConf.ActualTable}, func(ctx context.Context, txn storage.Transaction) error {
+			items, err = deletePartiQLItems(ctx, txn, tableConf, parsedQueryObj)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"Items": items}, nil
 	}
 
-	res, err := storage.GetStorageInstance().InsertUpdateOrDeleteStatement(ctx, parsedQueryObj)
+	res, err := GetStorage().InsertUpdateOrDeleteStatement(ctx, parsedQueryObj)
 	if err != nil {
 		return nil, err
 	}
@@ -953,6 +1370,29 @@ func ExecuteStatementForDelete(ctx context.Context, executeStatement models.Exec
 	return res, nil
 }
 
+// partiQLDeleteQuery translates a PartiQL DELETE statement and binds its
+// parameters
+func partiQLDeleteQuery(ctx context.Context, executeStatement models.ExecuteStatement) (*translator.DeleteUpdateQueryMap, error) {
+	tableConf, err := config.GetTableConf(executeStatement.TableName)
+	if err != nil {
+		return nil, err
+	}
+	translatorObj := partiQLTranslator(executeStatement)
+	parsedQueryObj, err := translateCached(ctx, "DELETE", executeStatement, translatorObj.ToSpannerDelete)
+	if err != nil {
+		return nil, errors.New("ValidationException", err.Error())
+	}
+	if err := checkPartiQLKey(parsedQueryObj, tableConf); err != nil {
+		return nil, err
+	}
+	paramMap := make(map[string]interface{})
+	if err := partiQLParams(parsedQueryObj.WhereParams, executeStatement.Parameters, paramMap); err != nil {
+		return nil, err
+	}
+	parsedQueryObj.Params = paramMap
+	return parsedQueryObj, nil
+}
+
 func convertType(columnName string, val interface{}, columntype string) (interface{}, error) {
 	switch columntype {
 	case "S":
@@ -960,12 +1400,12 @@ func convertType(columnName string, val interface{}, columntype string) (interfa
 		return utils.TrimSingleQuotes(fmt.Sprintf("%v", val)), nil
 
 	case "N":
-		// Convert to float64
-		floatValue, err := strconv.ParseFloat(fmt.Sprintf("%v", val), 64)
+		// Convert to an exact number
+		number, err := models.ParseNumber(fmt.Sprintf("%v", val))
 		if err != nil {
-			return nil, fmt.Errorf("error converting to float64: %v", err)
+			return nil, fmt.Errorf("error converting to number: %v", err)
 		}
-		return floatValue, nil
+		return number, nil
 
 	case "BOOL":
 		// Convert to boolean
@@ -989,9 +1429,9 @@ func convertType(columnName string, val interface{}, columntype string) (interfa
 }
 
 // TransactWritePut manages a transactional put operation in Spanner, ensuring old data is fetched and conditions are evaluated.
-func (s *spannerService) TransactWritePut(ctx context.Context, tableName string, putObj map[string]interface{}, expr *models.UpdateExpressionCondition, conditionExp string, expressionAttr, oldRes map[string]interface{}, txn *spanner.ReadWriteTransaction) (map[string]interface{}, *spanner.Mutation, error) {
+func (s *spannerService) TransactWritePut(ctx context.Context, tableName string, putObj map[string]interface{}, expr *models.UpdateExpressionCondition, conditionExp string, expressionAttr, oldRes map[string]interface{}, txn storage.Transaction) (map[string]interface{}, *storage.Mutation, error) {
 	// Fetch the table configuration to retrieve partition and sort keys
-	tableConf, err := config.GetTableConf(tableName)
+	tableConf, err := config.GetTenantTableConf(ctx, tableName)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -1030,9 +1470,9 @@ func (s *spannerService) TransactWritePut(ctx context.Context, tableName string,
 }
 
 // TransactWriteDel performs a transactional delete on Spanner
-func (s *spannerService) TransactWriteDel(ctx context.Context, tableName string, attrMap map[string]interface{}, condExpression string, expressionAttr map[string]interface{}, expr *models.UpdateExpressionCondition, txn *spanner.ReadWriteTransaction) (map[string]interface{}, *spanner.Mutation, error) {
+func (s *spannerService) TransactWriteDel(ctx context.Context, tableName string, attrMap map[string]interface{}, condExpression string, expressionAttr map[string]interface{}, expr *models.UpdateExpressionCondition, txn storage.Transaction) (map[string]interface{}, *storage.Mutation, error) {
 	// Fetch the table configuration and update the table name
-	tableConf, err := config.GetTableConf(tableName)
+	tableConf, err := config.GetTenantTableConf(ctx, tableName)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -1062,9 +1502,9 @@ func (s *spannerService) TransactWriteDel(ctx context.Context, tableName string,
 }
 
 // TransactWriteAdd performs a transactional add operation in Spanner, ensuring old data is fetched and conditions are evaluated.
-func (s *spannerService) TransactWriteAdd(ctx context.Context, tableName string, attrMap map[string]interface{}, condExpression string, m, expressionAttr map[string]interface{}, expr *models.UpdateExpressionCondition, oldRes map[string]interface{}, txn *spanner.ReadWriteTransaction) (map[string]interface{}, *spanner.Mutation, error) {
+func (s *spannerService) TransactWriteAdd(ctx context.Context, tableName string, attrMap map[string]interface{}, condExpression string, m, expressionAttr map[string]interface{}, expr *models.UpdateExpressionCondition, oldRes map[string]interface{}, txn storage.Transaction) (map[string]interface{}, *storage.Mutation, error) {
 	// Fetch the table configuration to retrieve the actual table name
-	tableConf, err := config.GetTableConf(tableName)
+	tableConf, err := config.GetTenantTableConf(ctx, tableName)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -1112,10 +1552,10 @@ func (s *spannerService) TransactWriteAdd(ctx context.Context, tableName string,
 // - txn: the transaction
 //
 // It returns a map of the updated response, a mutation, and an error.
-func (s *spannerService) TransactWriteRemove(ctx context.Context, tableName string, updateAttr models.UpdateAttr, actionValue string, expr *models.UpdateExpressionCondition, oldRes map[string]interface{}, txn *spanner.ReadWriteTransaction) (map[string]interface{}, *spanner.Mutation, error) {
+func (s *spannerService) TransactWriteRemove(ctx context.Context, tableName string, updateAttr models.UpdateAttr, actionValue string, expr *models.UpdateExpressionCondition, oldRes map[string]interface{}, txn storage.Transaction) (map[string]interface{}, *storage.Mutation, error) {
 	actionValue = strings.ReplaceAll(actionValue, " ", "")
 	colsToRemove := strings.Split(actionValue, ",")
-	tableConf, err := config.GetTableConf(tableName)
+	tableConf, err := config.GetTenantTableConf(ctx, tableName)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -1147,8 +1587,8 @@ func (s *spannerService) TransactWriteRemove(ctx context.Context, tableName stri
 // It takes the context of the request, the name of the table, the primary key map,
 // the condition expression, the attribute map, the expression, and the transaction.
 // It returns a mutation and an error.
-func TransactWriteDelete(ctx context.Context, tableName string, primaryKeyMap map[string]interface{}, condExpression string, attrMap map[string]interface{}, expr *models.UpdateExpressionCondition, txn *spanner.ReadWriteTransaction) (*spanner.Mutation, error) {
-	tableConf, err := config.GetTableConf(tableName)
+func TransactWriteDelete(ctx context.Context, tableName string, primaryKeyMap map[string]interface{}, condExpression string, attrMap map[string]interface{}, expr *models.UpdateExpressionCondition, txn storage.Transaction) (*storage.Mutation, error) {
+	tableConf, err := config.GetTenantTableConf(ctx, tableName)
 	if err != nil {
 		return nil, err
 	}
@@ -1158,6 +1598,6 @@ func TransactWriteDelete(ctx context.Context, tableName string, primaryKeyMap ma
 		return nil, err
 	}
 	// Call the storage instance to delete the item
-	mut, err := storage.GetStorageInstance().TransactWriteSpannerDelete(ctx, tableName, primaryKeyMap, e, expr, txn)
+	mut, err := GetStorage().TransactWriteSpannerDelete(ctx, tableName, primaryKeyMap, e, expr, txn)
 	return mut, err
 }
@@ -16,14 +16,24 @@ package services
 
 import (
 	"context"
+	"os"
+	"path/filepath"
 	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 
 	"cloud.google.com/go/spanner"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/config"
 	"github.com/cloudspannerecosystem/dynamodb-adapter/models"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/pkg/errors"
 	"github.com/cloudspannerecosystem/dynamodb-adapter/storage"
+	translator "github.com/cloudspannerecosystem/dynamodb-adapter/translator/utils"
 	"github.com/cloudspannerecosystem/dynamodb-adapter/utils"
 	"github.com/stretchr/testify/mock"
 	"gopkg.in/go-playground/assert.v1"
@@ -293,7 +303,7 @@ func Test_createSpannerQuery(t *testing.T) {
 			"first",
 			"second",
 			spanner.Statement{
-				SQL: "SELECT testTable.`first`,testTable.`second` FROM testTable WHERE second is not null  AND fourth > @filterExp1 ORDER BY second DESC  LIMIT 5000 ",
+				SQL: "SELECT testTable.`first`,testTable.`second`,COALESCE((fourth > @filterExp1), FALSE) AS _filter_match FROM testTable WHERE second is not null  ORDER BY second DESC  LIMIT 5000 ",
 				Params: map[string]interface{}{
 					"filterExp1": float64(5),
 				},
@@ -319,7 +329,7 @@ func Test_createSpannerQuery(t *testing.T) {
 			"first",
 			"second",
 			spanner.Statement{
-				SQL: "SELECT testTable.`first`,testTable.`second` FROM testTable WHERE second is not null  AND first > @rangeExp1 AND fourth > @filterExp1 ORDER BY second DESC  LIMIT 5000 ",
+				SQL: "SELECT testTable.`first`,testTable.`second`,COALESCE((fourth > @filterExp1), FALSE) AS _filter_match FROM testTable WHERE second is not null  AND first > @rangeExp1 ORDER BY second DESC  LIMIT 5000 ",
 				Params: map[string]interface{}{
 					"filterExp1": float64(5),
 					"rangeExp1":  float64(4),
@@ -347,7 +357,7 @@ func Test_createSpannerQuery(t *testing.T) {
 			"first",
 			"second",
 			spanner.Statement{
-				SQL: "SELECT testTable.`first`,testTable.`second` FROM testTable WHERE second is not null  AND first > @rangeExp1 AND fourth > @filterExp1 ORDER BY second DESC  LIMIT 100",
+				SQL: "SELECT testTable.`first`,testTable.`second`,COALESCE((fourth > @filterExp1), FALSE) AS _filter_match FROM testTable WHERE second is not null  AND first > @rangeExp1 ORDER BY second DESC  LIMIT 100",
 				Params: map[string]interface{}{
 					"filterExp1": float64(5),
 					"rangeExp1":  float64(4),
@@ -606,6 +616,17 @@ func Test_parseSpannerCondition(t *testing.T) {
 				"rangeExp1":  float64(61),
 			},
 		},
+		{
+			"index query excludes items without index keys",
+			&models.Query{
+				TableName: "testTable",
+				IndexName: "by_third",
+			},
+			"third",
+			"fourth",
+			"WHERE third is not null AND fourth is not null ",
+			make(map[string]interface{}),
+		},
 	}
 
 	for _, tc := range tests {
@@ -615,6 +636,62 @@ func Test_parseSpannerCondition(t *testing.T) {
 	}
 }
 
+func Test_applyIndexProjection(t *testing.T) {
+	tableConf := models.TableConfig{PartitionKey: "first", SortKey: "second"}
+	includeIndex := models.TableConfig{PartitionKey: "third", ProjectionType: models.ProjectionTypeInclude, NonKeyAttributes: []string{"fourth"}}
+	keysOnlyIndex := models.TableConfig{PartitionKey: "third", ProjectionType: models.ProjectionTypeKeysOnly}
+
+	tests := []struct {
+		testName       string
+		query          models.Query
+		indexConf      models.TableConfig
+		wantProjection string
+		wantErr        bool
+	}{
+		{
+			"all projection is not restricted",
+			models.Query{IndexName: "idx"},
+			models.TableConfig{PartitionKey: "third", ProjectionType: models.ProjectionTypeAll},
+			"",
+			false,
+		},
+		{
+			"default select reads projected attributes",
+			models.Query{IndexName: "idx"},
+			includeIndex,
+			"first, second, third, fourth",
+			false,
+		},
+		{
+			"projected attributes through expression names",
+			models.Query{IndexName: "idx", ProjectionExpression: "#f, third", ExpressionAttributeNames: map[string]string{"#f": "fourth"}},
+			includeIndex,
+			"#f, third",
+			false,
+		},
+		{
+			"non projected attribute",
+			models.Query{IndexName: "idx", ProjectionExpression: "fourth"},
+			keysOnlyIndex,
+			"fourth",
+			true,
+		},
+		{
+			"select all attributes",
+			models.Query{IndexName: "idx", Select: "ALL_ATTRIBUTES"},
+			keysOnlyIndex,
+			"",
+			true,
+		},
+	}
+
+	for _, tc := range tests {
+		err := applyIndexProjection(&tc.query, tableConf, tc.indexConf)
+		assert.Equal(t, tc.wantErr, err != nil)
+		assert.Equal(t, tc.wantProjection, tc.query.ProjectionExpression)
+	}
+}
+
 func Test_parseOffset(t *testing.T) {
 	tests := []struct {
 		testName   string
@@ -800,9 +877,9 @@ type MockStorage struct {
 	mock.Mock
 }
 
-func (m *MockStorage) SpannerTransactWritePut(ctx context.Context, tableName string, putObj map[string]interface{}, e *models.Eval, expr *models.UpdateExpressionCondition, txn *spanner.ReadWriteTransaction, oldRes map[string]interface{}) (map[string]interface{}, *spanner.Mutation, error) {
+func (m *MockStorage) SpannerTransactWritePut(ctx context.Context, tableName string, putObj map[string]interface{}, e *models.Eval, expr *models.UpdateExpressionCondition, txn storage.Transaction, oldRes map[string]interface{}) (map[string]interface{}, *storage.Mutation, error) {
 	args := m.Called(ctx, tableName, putObj, e, expr, txn)
-	return args.Get(0).(map[string]interface{}), args.Get(1).(*spanner.Mutation), args.Error(2)
+	return args.Get(0).(map[string]interface{}), args.Get(1).(*storage.Mutation), args.Error(2)
 }
 
 func (m *MockStorage) SpannerGet(ctx context.Context, tableName string, pKeys, sKeys interface{}, projectionCols []string) (map[string]interface{}, map[string]interface{}, error) {
@@ -810,19 +887,19 @@ func (m *MockStorage) SpannerGet(ctx context.Context, tableName string, pKeys, s
 	return args.Get(0).(map[string]interface{}), args.Get(1).(map[string]interface{}), args.Error(2)
 }
 
-func (m *MockStorage) TransactWriteSpannerDel(ctx context.Context, table string, n map[string]interface{}, eval *models.Eval, expr *models.UpdateExpressionCondition, txn *spanner.ReadWriteTransaction) (*spanner.Mutation, error) {
+func (m *MockStorage) TransactWriteSpannerDel(ctx context.Context, table string, n map[string]interface{}, eval *models.Eval, expr *models.UpdateExpressionCondition, txn storage.Transaction) (*storage.Mutation, error) {
 	args := m.Called(ctx, table, n, eval, expr, txn)
-	return args.Get(0).(*spanner.Mutation), args.Error(1)
+	return args.Get(0).(*storage.Mutation), args.Error(1)
 }
 
-func (m *MockStorage) TransactWriteSpannerAdd(ctx context.Context, table string, n map[string]interface{}, eval *models.Eval, expr *models.UpdateExpressionCondition, txn *spanner.ReadWriteTransaction) (map[string]interface{}, *spanner.Mutation, error) {
+func (m *MockStorage) TransactWriteSpannerAdd(ctx context.Context, table string, n map[string]interface{}, eval *models.Eval, expr *models.UpdateExpressionCondition, txn storage.Transaction) (map[string]interface{}, *storage.Mutation, error) {
 	args := m.Called(ctx, table, n, eval, expr, txn)
-	return args.Get(0).(map[string]interface{}), args.Get(1).(*spanner.Mutation), args.Error(2)
+	return args.Get(0).(map[string]interface{}), args.Get(1).(*storage.Mutation), args.Error(2)
 }
 
-func (m *MockStorage) TransactWriteSpannerRemove(ctx context.Context, table string, n map[string]interface{}, eval *models.Eval, expr *models.UpdateExpressionCondition, colsToRemove []string, txn *spanner.ReadWriteTransaction) (*spanner.Mutation, error) {
+func (m *MockStorage) TransactWriteSpannerRemove(ctx context.Context, table string, n map[string]interface{}, eval *models.Eval, expr *models.UpdateExpressionCondition, colsToRemove []string, txn storage.Transaction) (*storage.Mutation, error) {
 	args := m.Called(ctx, table, n, eval, expr, txn)
-	return args.Get(0).(*spanner.Mutation), args.Error(1)
+	return args.Get(0).(*storage.Mutation), args.Error(1)
 }
 
 type MockConfig struct{}
@@ -839,15 +916,87 @@ type MockServices struct {
 	mock.Mock
 }
 
-func (m *MockStorage) GetSpannerClient() (*spanner.Client, error) {
-	args := m.Called()
-	client, _ := args.Get(0).(*spanner.Client)
-	return client, args.Error(1)
+func (m *MockStorage) SpannerBatchGet(ctx context.Context, tableName string, pKeys, sKeys []interface{}, projectionCols []string) ([]map[string]interface{}, error) {
+	args := m.Called(ctx, tableName, pKeys, sKeys, projectionCols)
+	return args.Get(0).([]map[string]interface{}), args.Error(1)
+}
+
+func (m *MockStorage) ExecuteSpannerQuery(ctx context.Context, table string, cols []string, isCountQuery bool, stmt spanner.Statement) ([]map[string]interface{}, error) {
+	args := m.Called(ctx, table, cols, isCountQuery, stmt)
+	return args.Get(0).([]map[string]interface{}), args.Error(1)
+}
+
+func (m *MockStorage) ExplainQuery(ctx context.Context, table string, stmt spanner.Statement) (*storage.QueryExplain, error) {
+	args := m.Called(ctx, table, stmt)
+	return args.Get(0).(*storage.QueryExplain), args.Error(1)
+}
+
+func (m *MockStorage) SpannerPut(ctx context.Context, table string, n map[string]interface{}, eval *models.Eval, expr *models.UpdateExpressionCondition, spannerRow map[string]interface{}) (map[string]interface{}, error) {
+	args := m.Called(ctx, table, n, eval, expr, spannerRow)
+	return args.Get(0).(map[string]interface{}), args.Error(1)
+}
+
+func (m *MockStorage) SpannerAdd(ctx context.Context, table string, n map[string]interface{}, eval *models.Eval, expr *models.UpdateExpressionCondition) (map[string]interface{}, error) {
+	args := m.Called(ctx, table, n, eval, expr)
+	return args.Get(0).(map[string]interface{}), args.Error(1)
+}
+
+func (m *MockStorage) SpannerDel(ctx context.Context, table string, n map[string]interface{}, eval *models.Eval, expr *models.UpdateExpressionCondition) error {
+	return m.Called(ctx, table, n, eval, expr).Error(0)
+}
+
+func (m *MockStorage) SpannerRemove(ctx context.Context, table string, n map[string]interface{}, eval *models.Eval, expr *models.UpdateExpressionCondition, colsToRemove []string, oldRes map[string]interface{}) error {
+	return m.Called(ctx, table, n, eval, expr, colsToRemove, oldRes).Error(0)
+}
+
+func (m *MockStorage) SpannerDelete(ctx context.Context, table string, n map[string]interface{}, eval *models.Eval, expr *models.UpdateExpressionCondition) error {
+	return m.Called(ctx, table, n, eval, expr).Error(0)
+}
+
+func (m *MockStorage) SpannerBatchPut(ctx context.Context, table string, n []map[string]interface{}, spannerRow []map[string]interface{}) error {
+	return m.Called(ctx, table, n, spannerRow).Error(0)
+}
+
+func (m *MockStorage) SpannerBatchDelete(ctx context.Context, table string, keys []map[string]interface{}) error {
+	return m.Called(ctx, table, keys).Error(0)
+}
+
+func (m *MockStorage) ReadSnapshot(ctx context.Context, tables []string) (context.Context, func(), error) {
+	args := m.Called(ctx, tables)
+	release, _ := args.Get(1).(func())
+	return ctx, release, args.Error(2)
+}
+
+func (m *MockStorage) SpannerBatchWrite(ctx context.Context, table string, puts, deletes []map[string]interface{}) ([]int, []int, error) {
+	args := m.Called(ctx, table, puts, deletes)
+	failedPuts, _ := args.Get(0).([]int)
+	failedDeletes, _ := args.Get(1).([]int)
+	return failedPuts, failedDeletes, args.Error(2)
+}
+
+func (m *MockStorage) InsertUpdateOrDeleteStatement(ctx context.Context, query *translator.DeleteUpdateQueryMap) (map[string]interface{}, error) {
+	args := m.Called(ctx, query)
+	res, _ := args.Get(0).(map[string]interface{})
+	return res, args.Error(1)
+}
+
+func (m *MockStorage) SpannerIndexSchema(ctx context.Context) ([]storage.IndexColumnSchema, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]storage.IndexColumnSchema), args.Error(1)
+}
+
+func (m *MockStorage) ReadWriteTransaction(ctx context.Context, tables []string, f func(context.Context, storage.Transaction) error) error {
+	return m.Called(ctx, tables, f).Error(0)
+}
+
+func (m *MockStorage) TransactWriteSpannerDelete(ctx context.Context, table string, n map[string]interface{}, eval *models.Eval, expr *models.UpdateExpressionCondition, txn storage.Transaction) (*storage.Mutation, error) {
+	args := m.Called(ctx, table, n, eval, expr, txn)
+	return args.Get(0).(*storage.Mutation), args.Error(1)
 }
 
 func TestTransactWritePut(t *testing.T) {
 	ctx := context.Background()
-	mockTxn := &spanner.ReadWriteTransaction{}
+	mockTxn := storage.SpannerTransaction{ReadWriteTransaction: &spanner.ReadWriteTransaction{}}
 	utils.CreateConditionExpressionFunc = mockCreateConditionExpression
 
 	// Ensure the mock is reset after the test
@@ -878,7 +1027,7 @@ func TestTransactWritePut(t *testing.T) {
 	storage.SetStorageInstance(mockStorageInstance)
 
 	mockStorage.On("SpannerTransactWritePut", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mockTxn).
-		Return(map[string]interface{}{"Name": "John", "Age": 30}, &spanner.Mutation{}, nil)
+		Return(map[string]interface{}{"Name": "John", "Age": 30}, &storage.Mutation{}, nil)
 
 	svc := &spannerService{
 		st: mockStorage, // Assign the mock storage to the struct field
@@ -898,7 +1047,7 @@ func TestTransactWritePut(t *testing.T) {
 
 func TestTransactWriteDel(t *testing.T) {
 	ctx := context.Background()
-	mockTxn := &spanner.ReadWriteTransaction{}
+	mockTxn := storage.SpannerTransaction{ReadWriteTransaction: &spanner.ReadWriteTransaction{}}
 	utils.CreateConditionExpressionFunc = mockCreateConditionExpression
 
 	defer func() { utils.CreateConditionExpressionFunc = utils.CreateConditionExpression }()
@@ -918,7 +1067,7 @@ func TestTransactWriteDel(t *testing.T) {
 	}
 
 	mockStorage.On("TransactWriteSpannerDel", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mockTxn).
-		Return(&spanner.Mutation{}, nil)
+		Return(&storage.Mutation{}, nil)
 	mockStorage.On("SpannerGet", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
 		Return(map[string]interface{}{"Age": 30, "Name": "John"}, map[string]interface{}{}, nil)
 
@@ -935,7 +1084,7 @@ func TestTransactWriteDel(t *testing.T) {
 
 func TestTransactWriteAdd(t *testing.T) {
 	ctx := context.Background()
-	mockTxn := &spanner.ReadWriteTransaction{}
+	mockTxn := storage.SpannerTransaction{ReadWriteTransaction: &spanner.ReadWriteTransaction{}}
 	utils.CreateConditionExpressionFunc = mockCreateConditionExpression
 	defer func() { utils.CreateConditionExpressionFunc = utils.CreateConditionExpression }()
 
@@ -948,7 +1097,7 @@ func TestTransactWriteAdd(t *testing.T) {
 	mockStorage := new(MockStorage)
 
 	mockStorage.On("TransactWriteSpannerAdd", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mockTxn).
-		Return(map[string]interface{}{"Name": "John"}, &spanner.Mutation{}, nil)
+		Return(map[string]interface{}{"Name": "John"}, &storage.Mutation{}, nil)
 
 	svc := &spannerService{st: mockStorage}
 	result, _, _ := svc.TransactWriteAdd(ctx, tableName, attrMap, conditionExp, attrMap, expressionAttr, expr, oldRes, mockTxn)
@@ -963,7 +1112,7 @@ func TestTransactWriteAdd(t *testing.T) {
 
 func TestTransactWriteRemove(t *testing.T) {
 	ctx := context.Background()
-	mockTxn := &spanner.ReadWriteTransaction{}
+	mockTxn := storage.SpannerTransaction{ReadWriteTransaction: &spanner.ReadWriteTransaction{}}
 	utils.CreateConditionExpressionFunc = mockCreateConditionExpression
 	defer func() { utils.CreateConditionExpressionFunc = utils.CreateConditionExpression }()
 
@@ -975,7 +1124,7 @@ func TestTransactWriteRemove(t *testing.T) {
 	mockStorage := new(MockStorage)
 
 	mockStorage.On("TransactWriteSpannerRemove", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mockTxn).
-		Return(&spanner.Mutation{}, nil)
+		Return(&storage.Mutation{}, nil)
 
 	svc := &spannerService{st: mockStorage}
 	result, _, _ := svc.TransactWriteRemove(ctx, tableName, updateAttr, actionValue, expr, oldRes, mockTxn)
@@ -988,6 +1137,9 @@ func TestTransactWriteRemove(t *testing.T) {
 	mockStorage.AssertExpectations(t)
 }
 func TestParsePartiQlToSpannerforSelect(t *testing.T) {
+	tableDDL := models.TableDDL
+	models.TableDDL = map[string]map[string]string{"my_table": {"column_name": "S"}}
+	t.Cleanup(func() { models.TableDDL = tableDDL })
 
 	// Set up the ExecuteStatement for the test case
 	executeStatement := models.ExecuteStatement{
@@ -1013,7 +1165,7 @@ func TestParsePartiQlToSpannerforSelect(t *testing.T) {
 	}
 
 	// Validate structure of stmt
-	expectedSQL := "SELECT * FROM my_table WHERE column_name = @column_name;"
+	expectedSQL := "SELECT * FROM my_table WHERE `column_name` = @column_name;"
 	if stmt.SQL != expectedSQL {
 		t.Errorf("Expected SQL: %s, but got: %s", expectedSQL, stmt.SQL)
 	}
@@ -1041,9 +1193,9 @@ func TestConvertType(t *testing.T) {
 		expectErr  bool
 	}{
 		{"name", "'Hello World'", "S", "Hello World", false},
-		{"age", "25", "N", 25.0, false},
-		{"weight", "70.5", "N", 70.5, false},
-		{"score", "100.00", "N", 100.0, false},
+		{"age", "25", "N", models.Number("25"), false},
+		{"weight", "70.5", "N", models.Number("70.5"), false},
+		{"score", "100.00", "N", models.Number("100"), false},
 		{"isActive", "true", "BOOL", true, false},
 		{"isEnabled", "false", "BOOL", false, false},
 		{"invalid", "abc", "N", nil, true},
@@ -1065,3 +1217,535 @@ func TestConvertType(t *testing.T) {
 		}
 	}
 }
+
+func setupQueryTable(t *testing.T, statuses []string, payload int) {
+	dbConfigMap, tableDDL, tableColumnMap, globalConfig := models.DbConfigMap, models.TableDDL, models.TableColumnMap, models.GlobalConfig
+	models.DbConfigMap = map[string]models.TableConfig{"orders": {PartitionKey: "customer", SortKey: "id"}}
+	models.TableDDL = map[string]map[string]string{"orders": {"customer": "S", "id": "N", "status": "S", "payload": "S"}}
+	models.TableColumnMap = map[string][]string{"orders": {"customer", "id", "status", "payload"}}
+	models.GlobalConfig = &models.Config{}
+	t.Cleanup(func() {
+		models.DbConfigMap, models.TableDDL, models.TableColumnMap, models.GlobalConfig = dbConfigMap, tableDDL, tableColumnMap, globalConfig
+		SetStorage(nil)
+	})
+
+	s := storage.NewMemoryStorage()
+	s.CreateTable("orders", "customer", "id")
+	SetStorage(s)
+	for i, status := range statuses {
+		_, err := s.SpannerPut(context.Background(), "orders", map[string]interface{}{
+			"customer": "alice", "id": models.Number(strconv.Itoa(i + 1)), "status": status, "payload": strings.Repeat("x", payload),
+		}, &models.Eval{}, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestQueryAttributesLimitBeforeFilter(t *testing.T) {
+	setupQueryTable(t, []string{"open", "closed", "closed", "open", "open"}, 0)
+	query := models.Query{
+		TableName:     "orders",
+		RangeExp:      "customer = :c",
+		FilterExp:     "status = :s",
+		RangeValMap:   map[string]interface{}{":c": "alice", ":s": "open"},
+		SortAscending: true,
+		Limit:         3,
+	}
+
+	res, _, err := QueryAttributes(context.Background(), query)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, res["Count"], 1)
+	assert.Equal(t, res["ScannedCount"], 3)
+	assert.Equal(t, res["LastEvaluatedKey"], map[string]interface{}{"offset": int64(3), "customer": "alice", "id": models.Number("3")})
+
+	query.StartFrom = map[string]interface{}{"offset": float64(3)}
+	res, _, err = QueryAttributes(context.Background(), query)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, res["Count"], 2)
+	assert.Equal(t, res["ScannedCount"], 2)
+	assert.Equal(t, res["LastEvaluatedKey"], nil)
+
+	query.StartFrom = nil
+	query.OnlyCount = true
+	res, _, err = QueryAttributes(context.Background(), query)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, res["Count"], 1)
+	assert.Equal(t, res["ScannedCount"], 3)
+	_, ok := res["Items"]
+	assert.Equal(t, ok, false)
+}
+
+func TestExecuteStatementWhere(t *testing.T) {
+	setupQueryTable(t, []string{"open", "closed", "paid", "open", "closed"}, 0)
+	ids := func(res map[string]interface{}) []string {
+		var ids []string
+		for _, item := range res["Items"].([]map[string]interface{}) {
+			ids = append(ids, item["id"].(models.Number).String())
+		}
+		sort.Strings(ids)
+		return ids
+	}
+
+	res, err := ExecuteStatement(context.Background(), models.ExecuteStatement{
+		TableName:  "orders",
+		Statement:  "SELECT * FROM orders WHERE customer = ? AND (status IN ['paid', 'closed'] OR NOT id BETWEEN 2 AND 5)",
+		Parameters: []*dynamodb.AttributeValue{{S: aws.String("alice")}},
+	})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, ids(res), []string{"1", "2", "3", "5"})
+
+	res, err = ExecuteStatement(context.Background(), models.ExecuteStatement{
+		TableName:  "orders",
+		Statement:  "SELECT * FROM orders WHERE begins_with(status, ?) AND id <> 1",
+		Parameters: []*dynamodb.AttributeValue{{S: aws.String("op")}},
+	})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, ids(res), []string{"4"})
+
+	_, err = ExecuteStatement(context.Background(), models.ExecuteStatement{
+		TableName:  "orders",
+		Statement:  "SELECT * FROM orders WHERE customer = ? AND status = ?",
+		Parameters: []*dynamodb.AttributeValue{{S: aws.String("alice")}},
+	})
+	e, ok := err.(*errors.Error)
+	assert.Equal(t, ok, true)
+	assert.Equal(t, e.ErrorCode, "ValidationException")
+}
+
+func TestExecuteStatementPages(t *testing.T) {
+	setupQueryTable(t, []string{"open", "closed", "closed", "open", "open"}, 0)
+	statement := models.ExecuteStatement{
+		TableName:  "orders",
+		Statement:  "SELECT id FROM orders WHERE customer = ? AND status = 'open'",
+		Parameters: []*dynamodb.AttributeValue{{S: aws.String("alice")}},
+		Limit:      2,
+	}
+
+	// Limit counts the items evaluated, not the items returned
+	var pages [][]map[string]interface{}
+	for {
+		res, err := ExecuteStatement(context.Background(), statement)
+		assert.Equal(t, err, nil)
+		pages = append(pages, res["Items"].([]map[string]interface{}))
+		token, ok := res["NextToken"].(string)
+		if !ok {
+			break
+		}
+		statement.NextToken = token
+	}
+	assert.Equal(t, pages, [][]map[string]interface{}{
+		{{"id": models.Number("1")}},
+		{{"id": models.Number("4")}},
+		{{"id": models.Number("5")}},
+	})
+
+	// tokens only continue the statement they were returned for
+	statement.NextToken = statement.NextToken[:len(statement.NextToken)-2] + "AA"
+	_, err := ExecuteStatement(context.Background(), statement)
+	assert.Equal(t, err.(*errors.Error).ErrorCode, "ValidationException")
+	res, err := ExecuteStatement(context.Background(), models.ExecuteStatement{
+		TableName: "orders", Statement: "SELECT * FROM orders WHERE customer = 'alice' ORDER BY id DESC", Limit: 3,
+	})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(res["Items"].([]map[string]interface{})), 3)
+	_, err = ExecuteStatement(context.Background(), models.ExecuteStatement{
+		TableName: "orders", Statement: "SELECT * FROM orders WHERE customer = 'bob' ORDER BY id DESC", NextToken: res["NextToken"].(string),
+	})
+	assert.Equal(t, err.(*errors.Error).ErrorCode, "ValidationException")
+
+	res, err = ExecuteStatement(context.Background(), models.ExecuteStatement{
+		TableName: "orders", Statement: "SELECT * FROM orders WHERE customer = 'alice' ORDER BY id DESC", NextToken: res["NextToken"].(string),
+	})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, res["Items"].([]map[string]interface{})[0]["id"], models.Number("2"))
+	assert.Equal(t, res["NextToken"], nil)
+}
+
+func TestExecuteStatementPageSize(t *testing.T) {
+	// each item is just over 300KB, so the fourth item ends the page
+	setupQueryTable(t, []string{"open", "open", "open", "open", "open"}, 300*1024)
+	res, err := ExecuteStatement(context.Background(), models.ExecuteStatement{TableName: "orders", Statement: "SELECT * FROM orders"})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(res["Items"].([]map[string]interface{})), 4)
+	res, err = ExecuteStatement(context.Background(), models.ExecuteStatement{
+		TableName: "orders", Statement: "SELECT * FROM orders", NextToken: res["NextToken"].(string),
+	})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(res["Items"].([]map[string]interface{})), 1)
+}
+
+func TestQueryAttributesPageSize(t *testing.T) {
+	// each item is just over 300KB, so the fourth item ends the page
+	setupQueryTable(t, []string{"open", "open", "open", "open", "open"}, 300*1024)
+	res, _, err := QueryAttributes(context.Background(), models.Query{
+		TableName:     "orders",
+		RangeExp:      "customer = :c",
+		RangeValMap:   map[string]interface{}{":c": "alice"},
+		SortAscending: true,
+		Limit:         100,
+	})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, res["Count"], 4)
+	assert.Equal(t, res["ScannedCount"], 4)
+	assert.Equal(t, len(res["Items"].([]map[string]interface{})), 4)
+	assert.Equal(t, res["LastEvaluatedKey"].(map[string]interface{})["offset"], int64(4))
+}
+
+func TestBatchGetWithProjectionMaxSize(t *testing.T) {
+	setupQueryTable(t, []string{"open", "closed", "open"}, 0)
+	keys := []map[string]interface{}{
+		{"customer": "alice", "id": models.Number("3")},
+		{"customer": "alice", "id": models.Number("4")},
+		{"customer": "alice", "id": models.Number("1")},
+		{"customer": "alice", "id": models.Number("2")},
+	}
+
+	// each projected item is 10 bytes, so the item of the last key is left out
+	budget := new(atomic.Int64)
+	budget.Store(25)
+	items, unprocessed, err := BatchGetWithProjection(context.Background(), "orders", keys, "status", nil, budget)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, items, []map[string]interface{}{{"status": "open"}, {"status": "open"}})
+	assert.Equal(t, unprocessed, []int{3})
+	assert.Equal(t, budget.Load(), int64(5))
+
+	budget.Store(1024)
+	items, unprocessed, err = BatchGetWithProjection(context.Background(), "orders", keys, "", nil, budget)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(items), 3)
+	assert.Equal(t, len(unprocessed), 0)
+}
+
+func TestExecuteTransaction(t *testing.T) {
+	setupQueryTable(t, []string{"open", "open"}, 0)
+	status := func(id string) interface{} {
+		item, _, err := GetStorage().SpannerGet(context.Background(), "orders", "alice", models.Number(id), nil)
+		assert.Equal(t, err, nil)
+		return item["status"]
+	}
+	statements := []models.ExecuteStatement{
+		{TableName: "orders", Statement: "INSERT INTO orders VALUE {'customer': 'alice', 'id': 3, 'status': 'new'}"},
+		{TableName: "orders", Statement: "UPDATE orders SET status = 'paid' WHERE customer = ? AND id = 1", Parameters: []*dynamodb.AttributeValue{{S: aws.String("alice")}}},
+		{TableName: "orders", Statement: "DELETE FROM orders WHERE customer = 'alice' AND id = 2"},
+	}
+	err := ExecuteTransaction(context.Background(), statements, "token")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, status("1"), "paid")
+	assert.Equal(t, status("2"), nil)
+	assert.Equal(t, status("3"), "new")
+
+	// a repeated request with the same token is not run again
+	assert.Equal(t, ExecuteTransaction(context.Background(), statements, "token"), nil)
+	e, ok := ExecuteTransaction(context.Background(), statements[1:], "token").(*errors.Error)
+	assert.Equal(t, ok, true)
+	assert.Equal(t, e.ErrorCode, "IdempotentParameterMismatchException")
+	// tenants do not share tokens
+	tenantCtx := config.WithTenant(context.Background(), models.Tenant{Name: "dev"})
+	assert.Equal(t, ExecuteTransaction(tenantCtx, statements[1:], "token"), nil)
+
+	// the item inserted again cancels the transaction and nothing is written
+	e, ok = ExecuteTransaction(context.Background(), []models.ExecuteStatement{
+		{TableName: "orders", Statement: "UPDATE orders SET status = 'closed' WHERE customer = 'alice' AND id = 1"},
+		{TableName: "orders", Statement: "INSERT INTO orders VALUE {'customer': 'alice', 'id': 3, 'status': 'new'}"},
+	}, "").(*errors.Error)
+	assert.Equal(t, ok, true)
+	assert.Equal(t, e.ErrorCode, "TransactionCanceledException")
+	assert.Equal(t, e.CancellationReasons, []errors.CancellationReason{
+		{Code: "None"},
+		{Code: "DuplicateItem", Message: "Duplicate primary key exists in table"},
+	})
+	assert.Equal(t, status("1"), "paid")
+
+	e, ok = ExecuteTransaction(context.Background(), []models.ExecuteStatement{
+		{TableName: "orders", Statement: "SELECT * FROM orders WHERE customer = 'alice'"},
+	}, "").(*errors.Error)
+	assert.Equal(t, ok, true)
+	assert.Equal(t, e.ErrorCode, "ValidationException")
+}
+
+func TestClientRequestTokens(t *testing.T) {
+	tokens := &clientRequestTokens{tokens: map[string]clientRequestToken{}}
+
+	// concurrent requests with the same token run one transaction
+	var reserved atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			done, err := tokens.reserve("token", "hash")
+			if !done && err == nil {
+				reserved.Add(1)
+				return
+			}
+			e, ok := err.(*errors.Error)
+			assert.Equal(t, ok, true)
+			assert.Equal(t, e.ErrorCode, "TransactionInProgressException")
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, reserved.Load(), int64(1))
+	_, err := tokens.reserve("token", "other")
+	assert.Equal(t, err.(*errors.Error).ErrorCode, "IdempotentParameterMismatchException")
+
+	// a failed transaction can be retried, a committed one is not run again
+	tokens.release("token", false)
+	done, err := tokens.reserve("token", "hash")
+	assert.Equal(t, done, false)
+	assert.Equal(t, err, nil)
+	tokens.release("token", true)
+	done, err = tokens.reserve("token", "hash")
+	assert.Equal(t, done, true)
+	assert.Equal(t, err, nil)
+}
+
+func TestBatchExecuteStatement(t *testing.T) {
+	setupQueryTable(t, []string{"open", "closed"}, 0)
+	responses, err := BatchExecuteStatement(context.Background(), []models.ExecuteStatement{
+		{TableName: "orders", Statement: "SELECT * FROM orders WHERE customer = 'alice' AND id = 2"},
+		{TableName: "orders", Statement: "SELECT * FROM orders WHERE customer = 'alice' AND id = 3"},
+		{TableName: "orders", Statement: "SELECT * FROM orders WHERE customer = ? AND id = 1"},
+	})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(responses), 3)
+	assert.Equal(t, responses[0].Item["status"], "closed")
+	assert.Equal(t, responses[0].TableName, "orders")
+	assert.Equal(t, responses[1].Item == nil && responses[1].Error == nil, true)
+	assert.Equal(t, responses[2].Error.Code, "ValidationError")
+
+	_, err = BatchExecuteStatement(context.Background(), []models.ExecuteStatement{
+		{TableName: "orders", Statement: "SELECT * FROM orders WHERE customer = 'alice' AND id = 2"},
+		{TableName: "orders", Statement: "DELETE FROM orders WHERE customer = 'alice' AND id = 2"},
+	})
+	e, ok := err.(*errors.Error)
+	assert.Equal(t, ok, true)
+	assert.Equal(t, e.ErrorCode, "ValidationException")
+}
+
+func TestExecuteStatementDocuments(t *testing.T) {
+	dbConfigMap, tableDDL, tableColumnMap, globalConfig := models.DbConfigMap, models.TableDDL, models.TableColumnMap, models.GlobalConfig
+	models.DbConfigMap = map[string]models.TableConfig{"docs": {PartitionKey: "id"}}
+	models.TableDDL = map[string]map[string]string{"docs": {"id": "S", "info": "M", "tags": "L", "codes": "SS", "sizes": "NS"}}
+	models.TableColumnMap = map[string][]string{"docs": {"id", "info", "tags", "codes", "sizes"}}
+	models.GlobalConfig = &models.Config{}
+	t.Cleanup(func() {
+		models.DbConfigMap, models.TableDDL, models.TableColumnMap, models.GlobalConfig = dbConfigMap, tableDDL, tableColumnMap, globalConfig
+		SetStorage(nil)
+	})
+	s := storage.NewMemoryStorage()
+	s.CreateTable("docs", "id")
+	SetStorage(s)
+	execute := func(statement string, parameters ...*dynamodb.AttributeValue) map[string]interface{} {
+		t.Helper()
+		res, err := ExecuteStatement(context.Background(), models.ExecuteStatement{TableName: "docs", Statement: statement, Parameters: parameters})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+	item := func(id string) map[string]interface{} {
+		item, _, err := GetStorage().SpannerGet(context.Background(), "docs", id, nil, nil)
+		assert.Equal(t, err, nil)
+		return item
+	}
+
+	execute("INSERT INTO docs VALUE {'id': 'a', 'info': {'status': 'open', 'owner': {'name': ?}}, 'tags': ['x', 1], 'codes': <<'p', 'q'>>}",
+		&dynamodb.AttributeValue{S: aws.String("ann")})
+	execute("INSERT INTO docs VALUE {'id': 'b', 'info': ?, 'tags': [], 'sizes': ?}",
+		&dynamodb.AttributeValue{M: map[string]*dynamodb.AttributeValue{"status": {S: aws.String("closed")}, "note": {NULL: aws.Bool(true)}}},
+		&dynamodb.AttributeValue{NS: []*string{aws.String("2"), aws.String("1"), aws.String("2")}})
+	assert.Equal(t, item("a")["info"], map[string]interface{}{"status": "open", "owner": map[string]interface{}{"name": "ann"}})
+	assert.Equal(t, item("a")["codes"], []string{"p", "q"})
+	assert.Equal(t, item("b")["info"], map[string]interface{}{"status": "closed", "note": nil})
+	assert.Equal(t, item("b")["sizes"], []models.Number{"2", "1"})
+
+	res := execute("SELECT id, info.owner.name, tags[0] FROM docs WHERE info.status = ?", &dynamodb.AttributeValue{S: aws.String("open")})
+	assert.Equal(t, res["Items"], []map[string]interface{}{{"id": "a", "name": "ann", "_3": "x"}})
+
+	execute("UPDATE docs SET info.owner = {'name': 'bob', 'ids': <<1, 2>>} SET tags = list_append(tags, [?]) REMOVE info.status WHERE id = 'a'",
+		&dynamodb.AttributeValue{L: []*dynamodb.AttributeValue{{N: aws.String("3")}}})
+	assert.Equal(t, item("a")["info"], map[string]interface{}{"owner": map[string]interface{}{"name": "bob", "ids": []models.Number{"1", "2"}}})
+	assert.Equal(t, item("a")["tags"], []interface{}{"x", models.Number("1"), []interface{}{models.Number("3")}})
+
+	_, err := ExecuteStatement(context.Background(), models.ExecuteStatement{TableName: "docs", Statement: "UPDATE docs REMOVE id.x WHERE id = 'a'"})
+	e, ok := err.(*errors.Error)
+	assert.Equal(t, ok, true)
+	assert.Equal(t, e.ErrorCode, "ValidationException")
+}
+
+func TestExecuteStatementWrites(t *testing.T) {
+	setupQueryTable(t, []string{"open", "open"}, 0)
+	execute := func(statement string) (map[string]interface{}, string) {
+		res, err := ExecuteStatement(context.Background(), models.ExecuteStatement{TableName: "orders", Statement: statement})
+		if err == nil {
+			return res, ""
+		}
+		e, ok := err.(*errors.Error)
+		assert.Equal(t, ok, true)
+		return nil, e.ErrorCode
+	}
+	status := func(id string) interface{} {
+		item, _, err := GetStorage().SpannerGet(context.Background(), "orders", "alice", models.Number(id), nil)
+		assert.Equal(t, err, nil)
+		return item["status"]
+	}
+
+	// an INSERT does not replace an item unless its ON CONFLICT clause does
+	_, code := execute("INSERT INTO orders VALUE {'customer': 'alice', 'id': 1, 'status': 'new'}")
+	assert.Equal(t, code, "DuplicateItemException")
+	_, code = execute("INSERT INTO orders {'customer': 'alice', 'id': 1, 'status': 'new'} ON CONFLICT DO NOTHING")
+	assert.Equal(t, code, "")
+	assert.Equal(t, status("1"), "open")
+	_, code = execute("INSERT INTO orders {'customer': 'alice', 'id': 1, 'status': 'new'} ON CONFLICT DO REPLACE EXCLUDED")
+	assert.Equal(t, code, "")
+	assert.Equal(t, status("1"), "new")
+	_, code = execute("INSERT INTO orders VALUE {'customer': 'alice', 'status': 'new'}")
+	assert.Equal(t, code, "ValidationException")
+
+	// updates and deletes name the full primary key
+	for _, statement := range []string{
+		"UPDATE orders SET status = 'paid' WHERE customer = 'alice'",
+		"UPDATE orders SET status = 'paid' WHERE customer = 'alice' AND id > 1",
+		"UPDATE orders SET status = 'paid' WHERE customer = 'alice' OR id = 1",
+		"DELETE FROM orders WHERE id = 1",
+	} {
+		_, code = execute(statement)
+		assert.Equal(t, code, "ValidationException")
+	}
+	assert.Equal(t, status("1"), "new")
+
+	res, code := execute("UPDATE orders SET status = 'paid' WHERE customer = 'alice' AND id = 1 RETURNING ALL OLD *")
+	assert.Equal(t, code, "")
+	assert.Equal(t, res["Items"], []map[string]interface{}{{"customer": "alice", "id": models.Number("1"), "status": "new", "payload": ""}})
+	res, _ = execute("UPDATE orders SET status = 'closed' WHERE customer = 'alice' AND id = 1 RETURNING MODIFIED NEW *")
+	assert.Equal(t, res["Items"], []map[string]interface{}{{"status": "closed"}})
+	res, _ = execute("UPDATE orders SET payload = 'p' WHERE customer = 'alice' AND id = 1 RETURNING MODIFIED OLD *")
+	assert.Equal(t, res["Items"], []map[string]interface{}{{"payload": ""}})
+	res, _ = execute("UPDATE orders SET payload = 'q' WHERE customer = 'alice' AND id = 1 RETURNING ALL NEW *")
+	assert.Equal(t, res["Items"], []map[string]interface{}{{"customer": "alice", "id": models.Number("1"), "status": "closed", "payload": "q"}})
+
+	res, code = execute("DELETE FROM orders WHERE customer = 'alice' AND id = 2 RETURNING ALL OLD *")
+	assert.Equal(t, code, "")
+	assert.Equal(t, res["Items"], []map[string]interface{}{{"customer": "alice", "id": models.Number("2"), "status": "open", "payload": ""}})
+	assert.Equal(t, status("2"), nil)
+}
+
+func TestExecuteStatementIndex(t *testing.T) {
+	setupQueryTable(t, []string{"open", "closed", "open", "open"}, 0)
+	models.DbConfigMap["orders"] = models.TableConfig{PartitionKey: "customer", SortKey: "id", Indices: map[string]models.TableConfig{
+		"by_status": {PartitionKey: "status", SortKey: "id", SpannerIndexName: "by_status"},
+	}}
+	execute := func(statement, nextToken string, limit int64) (map[string]interface{}, error) {
+		return ExecuteStatement(context.Background(), models.ExecuteStatement{TableName: "orders", Statement: statement, NextToken: nextToken, Limit: limit})
+	}
+	ids := func(res map[string]interface{}) []string {
+		var ids []string
+		for _, item := range res["Items"].([]map[string]interface{}) {
+			ids = append(ids, item["id"].(models.Number).String())
+		}
+		return ids
+	}
+
+	statement := `SELECT id FROM "orders"."by-status" WHERE status = 'open' ORDER BY id DESC`
+	res, err := execute(statement, "", 2)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, ids(res), []string{"4", "3"})
+	res, err = execute(statement, res["NextToken"].(string), 2)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, ids(res), []string{"1"})
+	assert.Equal(t, res["NextToken"], nil)
+
+	for _, statement := range []string{
+		`SELECT * FROM "orders"."by-status" WHERE status = 'open' ORDER BY customer`,
+		`SELECT * FROM "orders"."by-status" WHERE customer = 'alice' ORDER BY id`,
+		`SELECT * FROM "orders" WHERE customer = 'alice' ORDER BY status`,
+		`SELECT * FROM "orders"."by-total" WHERE status = 'open'`,
+	} {
+		_, err = execute(statement, "", 0)
+		e, ok := err.(*errors.Error)
+		assert.Equal(t, ok, true)
+		assert.Equal(t, e.ErrorCode, "ValidationException")
+	}
+}
+
+func TestExecuteStatementAggregates(t *testing.T) {
+	setupQueryTable(t, []string{"open", "closed", "open", "open"}, 0)
+	models.DbConfigMap["orders"] = models.TableConfig{PartitionKey: "customer", SortKey: "id", Indices: map[string]models.TableConfig{
+		"by_status": {PartitionKey: "status", SortKey: "id", SpannerIndexName: "by_status"},
+	}}
+	execute := func(statement string, parameters ...*dynamodb.AttributeValue) (map[string]interface{}, string) {
+		res, err := ExecuteStatement(context.Background(), models.ExecuteStatement{TableName: "orders", Statement: statement, Parameters: parameters})
+		if e, ok := err.(*errors.Error); ok {
+			return nil, e.ErrorCode
+		}
+		assert.Equal(t, err, nil)
+		return res, ""
+	}
+
+	res, code := execute("SELECT COUNT(*) FROM orders WHERE customer = ?", &dynamodb.AttributeValue{S: aws.String("alice")})
+	assert.Equal(t, code, "")
+	assert.Equal(t, res["Items"], []map[string]interface{}{{"_1": models.Number("4")}})
+
+	res, _ = execute("SELECT COUNT(payload) AS n, SUM(id) AS total, AVG(id), MIN(status) AS first, MAX(id) FROM orders WHERE customer = 'alice' AND status = 'open'")
+	assert.Equal(t, res["Items"], []map[string]interface{}{{
+		"n": models.Number("3"), "total": models.Number("8"), "_3": models.Number("2.6666666666666665"), "first": "open", "_5": models.Number("4"),
+	}})
+
+	// aggregates of no values are left out of the item
+	res, _ = execute("SELECT COUNT(*) AS n, SUM(id) AS total FROM orders WHERE customer = 'bob'")
+	assert.Equal(t, res["Items"], []map[string]interface{}{{"n": models.Number("0")}})
+
+	res, _ = execute(`SELECT COUNT(*) FROM "orders"."by_status" WHERE status = 'open'`)
+	assert.Equal(t, res["Items"], []map[string]interface{}{{"_1": models.Number("3")}})
+
+	for _, statement := range []string{
+		"SELECT COUNT(*) FROM orders",
+		"SELECT COUNT(*) FROM orders WHERE status = 'open'",
+		"SELECT COUNT(*) FROM orders WHERE customer = 'alice' ORDER BY id",
+		"SELECT SUM(status) FROM orders WHERE customer = 'alice'",
+		"SELECT MAX(missing) FROM orders WHERE customer = 'alice'",
+	} {
+		_, code = execute(statement)
+		assert.Equal(t, code, "ValidationException")
+	}
+
+	models.GlobalConfig.PartiQL.AllowFullTableAggregates = true
+	res, _ = execute("SELECT COUNT(*) FROM orders WHERE status = 'open'")
+	assert.Equal(t, res["Items"], []map[string]interface{}{{"_1": models.Number("3")}})
+}
+
+func TestConditionValueColumns(t *testing.T) {
+	assert.Equal(t, conditionValueColumns("id = :id AND total BETWEEN :lo AND :hi"), map[string]string{":id": "id", ":lo": "total", ":hi": "total"})
+	assert.Equal(t, conditionValueColumns(":v<total AND begins_with(sk, :prefix)"), map[string]string{":v": "total", ":prefix": "sk"})
+	assert.Equal(t, conditionValueColumns("size(tags) > :n AND total IN (:a, :b)"), map[string]string{":a": "total", ":b": "total"})
+}
+
+func TestNextTokenKeyRotation(t *testing.T) {
+	globalConfig := models.GlobalConfig
+	t.Cleanup(func() { models.GlobalConfig = globalConfig })
+	position := []interface{}{"alice", models.Number("2")}
+
+	models.GlobalConfig = &models.Config{PartiQL: models.PartiQLConfig{NextTokenKey: models.Secret{Value: "old"}}}
+	token, err := encodeNextToken("hash", position)
+	assert.Equal(t, err, nil)
+
+	// a rotated key reads from a file, keeping the previous key valid
+	file := filepath.Join(t.TempDir(), "key")
+	assert.Equal(t, os.WriteFile(file, []byte("new\n"), 0o600), nil)
+	models.GlobalConfig = &models.Config{PartiQL: models.PartiQLConfig{
+		NextTokenKey:         models.Secret{File: file},
+		PreviousNextTokenKey: models.Secret{Value: "old"},
+	}}
+	decoded, err := decodeNextToken(token, "hash", 2)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, decoded, position)
+	rotated, err := encodeNextToken("hash", position)
+	assert.Equal(t, err, nil)
+
+	models.GlobalConfig = &models.Config{PartiQL: models.PartiQLConfig{NextTokenKey: models.Secret{Value: "new"}}}
+	_, err = decodeNextToken(rotated, "hash", 2)
+	assert.Equal(t, err, nil)
+	_, err = decodeNextToken(token, "hash", 2)
+	assert.NotEqual(t, err, nil)
+}
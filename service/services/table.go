@@ -0,0 +1,382 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package services
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/config"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/models"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/pkg/errors"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/pkg/logger"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/storage"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/utils"
+)
+
+// Index statuses reported through DescribeTable
+const (
+	IndexStatusCreating = "CREATING"
+	IndexStatusActive   = "ACTIVE"
+	IndexStatusDeleting = "DELETING"
+)
+
+// indexOperation is a CREATE INDEX or DROP INDEX that is still running on Spanner
+type indexOperation struct {
+	status      string
+	conf        models.TableConfig
+	backfilling bool
+}
+
+var (
+	indexOperationsMu sync.RWMutex
+	// indexOperations holds the running index operations per table and index
+	indexOperations = make(map[string]map[string]*indexOperation)
+	// indexOperationPollInterval is how often a running index operation is polled
+	indexOperationPollInterval = 5 * time.Second
+	// updateDatabaseDdl starts a schema change, it is replaced in tests
+	updateDatabaseDdl = func(ctx context.Context, table string, statements []string) (storage.SchemaOperation, error) {
+		return storage.GetStorageInstance().UpdateDatabaseDdl(ctx, table, statements)
+	}
+)
+
+// maxListTablesLimit is the largest and the default page size of ListTables
+const maxListTablesLimit = 100
+
+// ListTables returns the tables in the namespace of the caller's tenant, by
+// their name without the namespace prefix and suffix, in sorted order.
+func ListTables(ctx context.Context, meta models.ListTablesMeta) (map[string]interface{}, error) {
+	if meta.Limit < 0 || meta.Limit > maxListTablesLimit {
+		return nil, errors.New("ValidationException", "Limit must be between 1 and 100")
+	}
+	limit := meta.Limit
+	if limit == 0 {
+		limit = maxListTablesLimit
+	}
+
+	tenant := config.TenantFromContext(ctx)
+	var tableNames []string
+	for _, tableName := range config.TableNames() {
+		if logicalName, ok := config.TenantLogicalName(tenant, tableName); ok {
+			tableNames = append(tableNames, logicalName)
+		}
+	}
+	sort.Strings(tableNames)
+
+	start := sort.SearchStrings(tableNames, meta.ExclusiveStartTableName)
+	if start < len(tableNames) && tableNames[start] == meta.ExclusiveStartTableName {
+		start++
+	}
+	tableNames = tableNames[start:]
+
+	res := map[string]interface{}{"TableNames": []string{}}
+	if int64(len(tableNames)) > limit {
+		tableNames = tableNames[:limit]
+		res["LastEvaluatedTableName"] = tableNames[limit-1]
+	}
+	if len(tableNames) > 0 {
+		res["TableNames"] = tableNames
+	}
+	return res, nil
+}
+
+// DescribeTable returns the key schema of the table and the status of its
+// secondary indexes, including the ones that are still being created.
+func DescribeTable(ctx context.Context, tableName string) (map[string]interface{}, error) {
+	tableConf, err := config.GetTenantTableConf(ctx, tableName)
+	if err != nil {
+		return nil, err
+	}
+	table := tableConf.ActualTable
+	attributes := []string{tableConf.PartitionKey, tableConf.SortKey}
+
+	indexOperationsMu.RLock()
+	operations := indexOperations[table]
+	var indexes []map[string]interface{}
+	for key, indexConf := range tableConf.Indices {
+		status := IndexStatusActive
+		if op, ok := operations[key]; ok {
+			status = op.status
+		}
+		indexes = append(indexes, describeIndex(key, indexConf, status, false))
+		attributes = append(attributes, indexConf.PartitionKey, indexConf.SortKey)
+	}
+	for key, op := range operations {
+		if op.status != IndexStatusCreating {
+			continue
+		}
+		indexes = append(indexes, describeIndex(key, op.conf, op.status, op.backfilling))
+		attributes = append(attributes, op.conf.PartitionKey, op.conf.SortKey)
+	}
+	tableStatus := "ACTIVE"
+	if len(operations) > 0 {
+		tableStatus = "UPDATING"
+	}
+	indexOperationsMu.RUnlock()
+
+	sort.Slice(indexes, func(i, j int) bool {
+		return indexes[i]["IndexName"].(string) < indexes[j]["IndexName"].(string)
+	})
+
+	description := map[string]interface{}{
+		"TableName":            tableName,
+		"TableStatus":          tableStatus,
+		"KeySchema":            keySchema(tableConf.PartitionKey, tableConf.SortKey),
+		"AttributeDefinitions": attributeDefinitions(table, attributes),
+	}
+	if len(indexes) > 0 {
+		description["GlobalSecondaryIndexes"] = indexes
+	}
+	return map[string]interface{}{"Table": description}, nil
+}
+
+// UpdateTable creates or deletes a global secondary index. The Spanner schema
+// change runs in the background; its progress is reported through DescribeTable
+// and the table config is updated once the operation completes.
+func UpdateTable(ctx context.Context, meta models.UpdateTableMeta) (map[string]interface{}, error) {
+	tableConf, err := config.GetTenantTableConf(ctx, meta.TableName)
+	if err != nil {
+		return nil, err
+	}
+	if len(meta.GlobalSecondaryIndexUpdates) > 1 {
+		return nil, errors.New("LimitExceededException", "Only 1 online index can be created or deleted simultaneously per table")
+	}
+
+	for _, update := range meta.GlobalSecondaryIndexUpdates {
+		if update == nil || (update.Create == nil && update.Delete == nil) {
+			continue
+		}
+		// the lock is held until the operation is registered, so that a
+		// concurrent UpdateTable cannot start a second one for the table
+		indexOperationsMu.Lock()
+		if len(indexOperations[tableConf.ActualTable]) > 0 {
+			indexOperationsMu.Unlock()
+			return nil, errors.New("LimitExceededException", "Only 1 online index can be created or deleted simultaneously per table")
+		}
+		if update.Create != nil {
+			err = createIndex(ctx, tableConf, meta.AttributeDefinitions, update.Create)
+		} else {
+			err = deleteIndex(ctx, tableConf, update.Delete)
+		}
+		indexOperationsMu.Unlock()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return DescribeTable(ctx, meta.TableName)
+}
+
+func createIndex(ctx context.Context, tableConf models.TableConfig, definitions []*dynamodb.AttributeDefinition, create *dynamodb.CreateGlobalSecondaryIndexAction) error {
+	table := tableConf.ActualTable
+	indexName := aws.StringValue(create.IndexName)
+	if _, ok := indexKey(tableConf.Indices, indexName); ok {
+		return errors.New("ValidationException", "Attempting to create an index which already exists: "+indexName)
+	}
+
+	indexConf := models.TableConfig{
+		SpannerIndexName: utils.ChangeTableNameForSpanner(indexName),
+		DDBIndexName:     indexName,
+		ActualTable:      table,
+		IsNullFiltered:   true,
+	}
+	for _, element := range create.KeySchema {
+		switch aws.StringValue(element.KeyType) {
+		case dynamodb.KeyTypeHash:
+			indexConf.PartitionKey = aws.StringValue(element.AttributeName)
+		case dynamodb.KeyTypeRange:
+			indexConf.SortKey = aws.StringValue(element.AttributeName)
+		}
+	}
+	if indexConf.PartitionKey == "" {
+		return errors.New("ValidationException", "No Hash Key specified in schema for index "+indexName)
+	}
+	for _, attr := range []string{indexConf.PartitionKey, indexConf.SortKey} {
+		if attr == "" {
+			continue
+		}
+		columnType, ok := models.TableDDL[table][attr]
+		if !ok {
+			return errors.New("ValidationException", "Index key attribute "+attr+" is not a column of table "+table)
+		}
+		for _, definition := range definitions {
+			if aws.StringValue(definition.AttributeName) == attr && aws.StringValue(definition.AttributeType) != columnType {
+				return errors.New("ValidationException", "Attribute type of "+attr+" does not match the column type "+columnType)
+			}
+		}
+	}
+
+	if create.Projection == nil {
+		return errors.New("ValidationException", "Projection is required for index "+indexName)
+	}
+	indexConf.ProjectionType = aws.StringValue(create.Projection.ProjectionType)
+	switch indexConf.ProjectionType {
+	case models.ProjectionTypeAll, models.ProjectionTypeKeysOnly:
+	case models.ProjectionTypeInclude:
+		indexConf.NonKeyAttributes = aws.StringValueSlice(create.Projection.NonKeyAttributes)
+	default:
+		return errors.New("ValidationException", "Unknown ProjectionType: "+indexConf.ProjectionType)
+	}
+
+	ddl := utils.GenerateIndexDDL(tableConf, indexConf, models.TableColumnMap[table])
+	op, err := updateDatabaseDdl(ctx, table, []string{ddl})
+	if err != nil {
+		return err
+	}
+	startIndexOperation(op, table, indexName, &indexOperation{status: IndexStatusCreating, conf: indexConf})
+	return nil
+}
+
+func deleteIndex(ctx context.Context, tableConf models.TableConfig, del *dynamodb.DeleteGlobalSecondaryIndexAction) error {
+	table := tableConf.ActualTable
+	indexName := aws.StringValue(del.IndexName)
+	key, ok := indexKey(tableConf.Indices, indexName)
+	if !ok {
+		return errors.New("ResourceNotFoundException", "Requested resource not found: Index "+indexName+" does not exist")
+	}
+	indexConf := tableConf.Indices[key]
+	spannerIndexName := indexConf.SpannerIndexName
+	if spannerIndexName == "" {
+		spannerIndexName = utils.ChangeTableNameForSpanner(key)
+	}
+
+	op, err := updateDatabaseDdl(ctx, table, []string{"DROP INDEX " + spannerIndexName})
+	if err != nil {
+		return err
+	}
+	startIndexOperation(op, table, key, &indexOperation{status: IndexStatusDeleting, conf: indexConf})
+	return nil
+}
+
+// startIndexOperation registers an index operation under the DynamoDB name of
+// the index, the caller must hold indexOperationsMu.
+func startIndexOperation(op storage.SchemaOperation, table, key string, indexOp *indexOperation) {
+	if _, ok := indexOperations[table]; !ok {
+		indexOperations[table] = make(map[string]*indexOperation)
+	}
+	indexOperations[table][key] = indexOp
+
+	go watchIndexOperation(op, table, key)
+}
+
+// watchIndexOperation polls the schema change until it is done and then
+// applies the result to the table config.
+func watchIndexOperation(op storage.SchemaOperation, table, key string) {
+	ctx := context.Background()
+	for !op.Done() {
+		time.Sleep(indexOperationPollInterval)
+		if err := op.Poll(ctx); err != nil {
+			if op.Done() {
+				logger.LogError("index operation failed for ", table, ".", key, ": ", err)
+				finishIndexOperation(table, key, false)
+				return
+			}
+			logger.LogWarn("unable to poll index operation for ", table, ".", key, ": ", err)
+			continue
+		}
+		started, percent := op.Progress()
+		indexOperationsMu.Lock()
+		if indexOp, ok := indexOperations[table][key]; ok {
+			indexOp.backfilling = indexOp.status == IndexStatusCreating && started && percent < 100
+		}
+		indexOperationsMu.Unlock()
+	}
+	finishIndexOperation(table, key, true)
+}
+
+func finishIndexOperation(table, key string, succeeded bool) {
+	indexOperationsMu.Lock()
+	defer indexOperationsMu.Unlock()
+	indexOp, ok := indexOperations[table][key]
+	if !ok {
+		return
+	}
+	delete(indexOperations[table], key)
+	if len(indexOperations[table]) == 0 {
+		delete(indexOperations, table)
+	}
+	if !succeeded {
+		return
+	}
+
+	models.DbConfigMapMu.Lock()
+	defer models.DbConfigMapMu.Unlock()
+	tableConf := models.DbConfigMap[table]
+	indices := make(map[string]models.TableConfig, len(tableConf.Indices)+1)
+	for name, conf := range tableConf.Indices {
+		indices[name] = conf
+	}
+	if indexOp.status == IndexStatusCreating {
+		indices[key] = indexOp.conf
+	} else {
+		delete(indices, key)
+	}
+	tableConf.Indices = indices
+	models.DbConfigMap[table] = tableConf
+	InvalidatePartiQLCache(context.Background())
+}
+
+func describeIndex(key string, indexConf models.TableConfig, status string, backfilling bool) map[string]interface{} {
+	name := indexConf.DDBIndexName
+	if name == "" {
+		name = key
+	}
+	projectionType := indexConf.ProjectionType
+	if projectionType == "" {
+		projectionType = models.ProjectionTypeAll
+	}
+	projection := map[string]interface{}{"ProjectionType": projectionType}
+	if projectionType == models.ProjectionTypeInclude {
+		projection["NonKeyAttributes"] = indexConf.NonKeyAttributes
+	}
+	index := map[string]interface{}{
+		"IndexName":   name,
+		"KeySchema":   keySchema(indexConf.PartitionKey, indexConf.SortKey),
+		"Projection":  projection,
+		"IndexStatus": status,
+	}
+	if status == IndexStatusCreating {
+		index["Backfilling"] = backfilling
+	}
+	return index
+}
+
+func keySchema(partitionKey, sortKey string) []map[string]string {
+	schema := []map[string]string{{"AttributeName": partitionKey, "KeyType": dynamodb.KeyTypeHash}}
+	if sortKey != "" {
+		schema = append(schema, map[string]string{"AttributeName": sortKey, "KeyType": dynamodb.KeyTypeRange})
+	}
+	return schema
+}
+
+func attributeDefinitions(table string, attributes []string) []map[string]string {
+	definitions := []map[string]string{}
+	seen := make(map[string]struct{})
+	for _, attr := range attributes {
+		if _, ok := seen[attr]; ok || attr == "" {
+			continue
+		}
+		seen[attr] = struct{}{}
+		attrType := models.TableDDL[table][attr]
+		if attrType != "N" && attrType != "B" {
+			attrType = "S"
+		}
+		definitions = append(definitions, map[string]string{"AttributeName": attr, "AttributeType": attrType})
+	}
+	return definitions
+}
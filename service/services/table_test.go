@@ -0,0 +1,263 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package services
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/config"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/models"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeSchemaOperation struct {
+	mu      sync.Mutex
+	done    bool
+	started bool
+	percent int32
+}
+
+func (f *fakeSchemaOperation) Poll(ctx context.Context) error { return nil }
+
+func (f *fakeSchemaOperation) Done() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.done
+}
+
+func (f *fakeSchemaOperation) Progress() (bool, int32) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.started, f.percent
+}
+
+func (f *fakeSchemaOperation) set(done, started bool, percent int32) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.done, f.started, f.percent = done, started, percent
+}
+
+func setupIndexTable(t *testing.T) (*fakeSchemaOperation, *[]string) {
+	models.DbConfigMap = map[string]models.TableConfig{
+		"orders": {PartitionKey: "id", ActualTable: "orders"},
+	}
+	models.TableDDL["orders"] = map[string]string{"id": "S", "customer": "S", "total": "N"}
+	models.TableColumnMap["orders"] = []string{"id", "customer", "total"}
+
+	op := &fakeSchemaOperation{}
+	var statements []string
+	originalUpdate, originalInterval := updateDatabaseDdl, indexOperationPollInterval
+	updateDatabaseDdl = func(ctx context.Context, table string, ddl []string) (storage.SchemaOperation, error) {
+		statements = append(statements, ddl...)
+		return op, nil
+	}
+	indexOperationPollInterval = time.Millisecond
+	t.Cleanup(func() {
+		updateDatabaseDdl, indexOperationPollInterval = originalUpdate, originalInterval
+	})
+	return op, &statements
+}
+
+func indexStatus(t *testing.T, name string) map[string]interface{} {
+	res, err := DescribeTable(context.Background(), "orders")
+	assert.NoError(t, err)
+	indexes, _ := res["Table"].(map[string]interface{})["GlobalSecondaryIndexes"].([]map[string]interface{})
+	for _, index := range indexes {
+		if index["IndexName"] == name {
+			return index
+		}
+	}
+	return nil
+}
+
+func TestUpdateTableCreateIndex(t *testing.T) {
+	op, statements := setupIndexTable(t)
+
+	_, err := UpdateTable(context.Background(), models.UpdateTableMeta{
+		TableName: "orders",
+		GlobalSecondaryIndexUpdates: []*dynamodb.GlobalSecondaryIndexUpdate{{
+			Create: &dynamodb.CreateGlobalSecondaryIndexAction{
+				IndexName: aws.String("by-customer"),
+				KeySchema: []*dynamodb.KeySchemaElement{{AttributeName: aws.String("customer"), KeyType: aws.String("HASH")}},
+				Projection: &dynamodb.Projection{
+					ProjectionType:   aws.String("INCLUDE"),
+					NonKeyAttributes: aws.StringSlice([]string{"total"}),
+				},
+			},
+		}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"CREATE NULL_FILTERED INDEX by_customer ON orders (customer) STORING (total)"}, *statements)
+	assert.Equal(t, IndexStatusCreating, indexStatus(t, "by-customer")["IndexStatus"])
+
+	op.set(false, true, 40)
+	assert.Eventually(t, func() bool {
+		return indexStatus(t, "by-customer")["Backfilling"] == true
+	}, time.Second, time.Millisecond)
+
+	op.set(true, true, 100)
+	assert.Eventually(t, func() bool {
+		return indexStatus(t, "by-customer")["IndexStatus"] == IndexStatusActive
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, "customer", models.DbConfigMap["orders"].Indices["by-customer"].PartitionKey)
+	assert.Equal(t, models.ProjectionTypeInclude, models.DbConfigMap["orders"].Indices["by-customer"].ProjectionType)
+	assert.Equal(t, "by_customer", models.DbConfigMap["orders"].Indices["by-customer"].SpannerIndexName)
+}
+
+func TestUpdateTableDeleteIndex(t *testing.T) {
+	op, statements := setupIndexTable(t)
+	models.DbConfigMap["orders"] = models.TableConfig{
+		PartitionKey: "id",
+		ActualTable:  "orders",
+		Indices: map[string]models.TableConfig{
+			"by-customer": {PartitionKey: "customer", SpannerIndexName: "customer_idx", ProjectionType: models.ProjectionTypeKeysOnly},
+		},
+	}
+
+	_, err := UpdateTable(context.Background(), models.UpdateTableMeta{
+		TableName: "orders",
+		GlobalSecondaryIndexUpdates: []*dynamodb.GlobalSecondaryIndexUpdate{{
+			Delete: &dynamodb.DeleteGlobalSecondaryIndexAction{IndexName: aws.String("by-customer")},
+		}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"DROP INDEX customer_idx"}, *statements)
+	assert.Equal(t, IndexStatusDeleting, indexStatus(t, "by-customer")["IndexStatus"])
+
+	op.set(true, false, 0)
+	assert.Eventually(t, func() bool {
+		return indexStatus(t, "by-customer") == nil
+	}, time.Second, time.Millisecond)
+	assert.Empty(t, models.DbConfigMap["orders"].Indices)
+}
+
+func TestUpdateTableConcurrent(t *testing.T) {
+	op, statements := setupIndexTable(t)
+
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	for i, name := range []string{"by-customer", "by-total"} {
+		wg.Add(1)
+		go func(i int, name, attribute string) {
+			defer wg.Done()
+			_, errs[i] = UpdateTable(context.Background(), models.UpdateTableMeta{
+				TableName: "orders",
+				GlobalSecondaryIndexUpdates: []*dynamodb.GlobalSecondaryIndexUpdate{{
+					Create: &dynamodb.CreateGlobalSecondaryIndexAction{
+						IndexName:  aws.String(name),
+						KeySchema:  []*dynamodb.KeySchemaElement{{AttributeName: aws.String(attribute), KeyType: aws.String("HASH")}},
+						Projection: &dynamodb.Projection{ProjectionType: aws.String("ALL")},
+					},
+				}},
+			})
+		}(i, name, []string{"customer", "total"}[i])
+	}
+	wg.Wait()
+
+	assert.Len(t, *statements, 1)
+	assert.True(t, (errs[0] == nil) != (errs[1] == nil), "exactly one of the updates should start")
+
+	op.set(true, true, 100)
+	assert.Eventually(t, func() bool {
+		indexOperationsMu.RLock()
+		defer indexOperationsMu.RUnlock()
+		return len(indexOperations["orders"]) == 0
+	}, time.Second, time.Millisecond)
+}
+
+func TestUpdateTableValidation(t *testing.T) {
+	setupIndexTable(t)
+	models.DbConfigMap["orders"] = models.TableConfig{
+		PartitionKey: "id",
+		ActualTable:  "orders",
+		Indices: map[string]models.TableConfig{
+			"by-total": {PartitionKey: "total", SpannerIndexName: "by_total"},
+		},
+	}
+
+	tests := []struct {
+		testName string
+		update   *dynamodb.GlobalSecondaryIndexUpdate
+	}{
+		{
+			"unknown key attribute",
+			&dynamodb.GlobalSecondaryIndexUpdate{Create: &dynamodb.CreateGlobalSecondaryIndexAction{
+				IndexName:  aws.String("by_missing"),
+				KeySchema:  []*dynamodb.KeySchemaElement{{AttributeName: aws.String("missing"), KeyType: aws.String("HASH")}},
+				Projection: &dynamodb.Projection{ProjectionType: aws.String("ALL")},
+			}},
+		},
+		{
+			"missing projection",
+			&dynamodb.GlobalSecondaryIndexUpdate{Create: &dynamodb.CreateGlobalSecondaryIndexAction{
+				IndexName: aws.String("by_customer"),
+				KeySchema: []*dynamodb.KeySchemaElement{{AttributeName: aws.String("customer"), KeyType: aws.String("HASH")}},
+			}},
+		},
+		{
+			"create existing index",
+			&dynamodb.GlobalSecondaryIndexUpdate{Create: &dynamodb.CreateGlobalSecondaryIndexAction{
+				IndexName:  aws.String("by_total"),
+				KeySchema:  []*dynamodb.KeySchemaElement{{AttributeName: aws.String("total"), KeyType: aws.String("HASH")}},
+				Projection: &dynamodb.Projection{ProjectionType: aws.String("ALL")},
+			}},
+		},
+		{
+			"delete unknown index",
+			&dynamodb.GlobalSecondaryIndexUpdate{Delete: &dynamodb.DeleteGlobalSecondaryIndexAction{IndexName: aws.String("by_customer")}},
+		},
+	}
+
+	for _, tc := range tests {
+		_, err := UpdateTable(context.Background(), models.UpdateTableMeta{
+			TableName:                   "orders",
+			GlobalSecondaryIndexUpdates: []*dynamodb.GlobalSecondaryIndexUpdate{tc.update},
+		})
+		assert.Error(t, err, tc.testName)
+	}
+}
+
+func TestListTables(t *testing.T) {
+	originalConfig := models.GlobalConfig
+	models.GlobalConfig = &models.Config{Tenancy: models.TenancyConfig{Tenants: []models.Tenant{{Name: "dev", Prefix: "dev-"}}}}
+	t.Cleanup(func() { models.GlobalConfig = originalConfig })
+	models.DbConfigMap = map[string]models.TableConfig{
+		"orders": {}, "customers": {}, "dev-orders": {}, "dev-items": {}, "dev-customers": {},
+	}
+
+	res, err := ListTables(context.Background(), models.ListTablesMeta{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"customers", "orders"}, res["TableNames"])
+
+	ctx := config.WithTenant(context.Background(), models.GlobalConfig.Tenancy.Tenants[0])
+	res, err = ListTables(ctx, models.ListTablesMeta{Limit: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"customers", "items"}, res["TableNames"])
+	assert.Equal(t, "items", res["LastEvaluatedTableName"])
+
+	res, err = ListTables(ctx, models.ListTablesMeta{ExclusiveStartTableName: "items", Limit: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"orders"}, res["TableNames"])
+	assert.NotContains(t, res, "LastEvaluatedTableName")
+
+	_, err = ListTables(ctx, models.ListTablesMeta{Limit: 101})
+	assert.Error(t, err)
+}
@@ -16,12 +16,14 @@ package spanner
 
 import (
 	"context"
-
+	"sort"
 	"strings"
 
 	"cloud.google.com/go/spanner"
 	"github.com/cloudspannerecosystem/dynamodb-adapter/models"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/service/services"
 	"github.com/cloudspannerecosystem/dynamodb-adapter/storage"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/utils"
 )
 
 // ParseDDL - this will parse DDL of spannerDB and set all the table configs in models
@@ -38,11 +40,12 @@ func ParseDDL(updateDB bool) error {
 	stmt := spanner.Statement{}
 
 	stmt.SQL = "SELECT * FROM dynamodb_adapter_table_ddl"
-	ms, err := storage.GetStorageInstance().ExecuteSpannerQuery(context.Background(), "dynamodb_adapter_table_ddl", []string{"tableName", "column", "dynamoDataType", "originalColumn", "partitionKey", "sortKey", "spannerIndexName", "actualTable", "spannerDataType"}, false, stmt)
+	ms, err := services.GetStorage().ExecuteSpannerQuery(context.Background(), "dynamodb_adapter_table_ddl", []string{"tableName", "column", "dynamoDataType", "originalColumn", "partitionKey", "sortKey", "spannerIndexName", "actualTable", "spannerDataType"}, false, stmt)
 
 	if err != nil {
 		return err
 	}
+	models.DbConfigMapMu.Lock()
 	if models.DbConfigMap == nil {
 		models.DbConfigMap = make(map[string]models.TableConfig)
 	}
@@ -57,11 +60,14 @@ func ParseDDL(updateDB bool) error {
 			partitionKey := ms[i]["partitionKey"].(string)
 			sortKey, _ := ms[i]["sortKey"].(string) // Optional, check if available
 			spannerIndexName, _ := ms[i]["spannerIndexName"].(string)
+			// the indexes configured for the table are kept, setIndexConfigs
+			// merges those of the schema into them
 			models.DbConfigMap[tableName] = models.TableConfig{
 				PartitionKey:     partitionKey,
 				SortKey:          sortKey,
 				SpannerIndexName: spannerIndexName,
 				ActualTable:      tableName,
+				Indices:          models.DbConfigMap[tableName].Indices,
 			}
 
 			if ok {
@@ -79,7 +85,135 @@ func ParseDDL(updateDB bool) error {
 			}
 			models.TableColumnMap[tableName] = append(models.TableColumnMap[tableName], column)
 			models.TableDDL[tableName][column] = dataType
+			if spannerDataType, _ := ms[i]["spannerDataType"].(string); spannerDataType != "" {
+				if _, ok := models.SpannerColumnTypes[tableName]; !ok {
+					models.SpannerColumnTypes[tableName] = make(map[string]string)
+				}
+				models.SpannerColumnTypes[tableName][column] = spannerDataType
+			}
 		}
 	}
+	models.DbConfigMapMu.Unlock()
+
+	indexColumns, err := services.GetStorage().SpannerIndexSchema(context.Background())
+	if err != nil {
+		return err
+	}
+	setIndexConfigs(indexColumns)
+	services.InvalidatePartiQLCache(context.Background())
 	return nil
 }
+
+// setIndexConfigs merges the secondary indexes read from INFORMATION_SCHEMA
+// into the table configs in DbConfigMap. The first two key columns of an index
+// are used as its partition and sort key, and the projection type is derived
+// from the STORING columns: none means KEYS_ONLY, every non-key column means
+// ALL and anything in between is INCLUDE. Indexes already configured keep their
+// name and DynamoDB name, and configured indexes missing from the schema are
+// kept.
+func setIndexConfigs(indexColumns []storage.IndexColumnSchema) {
+	byTable := make(map[string]map[string][]storage.IndexColumnSchema)
+	for _, col := range indexColumns {
+		if _, ok := byTable[col.TableName]; !ok {
+			byTable[col.TableName] = make(map[string][]storage.IndexColumnSchema)
+		}
+		byTable[col.TableName][col.IndexName] = append(byTable[col.TableName][col.IndexName], col)
+	}
+
+	models.DbConfigMapMu.Lock()
+	defer models.DbConfigMapMu.Unlock()
+	for tableName, tableConf := range models.DbConfigMap {
+		indexes, ok := byTable[utils.ChangeTableNameForSpanner(tableName)]
+		if !ok {
+			continue
+		}
+		indices := make(map[string]models.TableConfig, len(tableConf.Indices)+len(indexes))
+		for key, indexConf := range tableConf.Indices {
+			indices[key] = indexConf
+		}
+		for indexName, cols := range indexes {
+			indexConf := buildIndexConfig(tableName, tableConf, indexName, cols)
+			key := configuredIndex(tableConf.Indices, indexName)
+			if key == "" {
+				key = indexName
+			} else {
+				indexConf.DDBIndexName = tableConf.Indices[key].DDBIndexName
+			}
+			indices[key] = indexConf
+		}
+		tableConf.Indices = indices
+		models.DbConfigMap[tableName] = tableConf
+	}
+}
+
+// configuredIndex returns the key of the configured index stored as the
+// Spanner index spannerIndexName, empty when it is not configured
+func configuredIndex(indices map[string]models.TableConfig, spannerIndexName string) string {
+	for key, indexConf := range indices {
+		if indexConf.SpannerIndexName == spannerIndexName || utils.ChangeTableNameForSpanner(key) == spannerIndexName {
+			return key
+		}
+	}
+	return ""
+}
+
+func buildIndexConfig(tableName string, tableConf models.TableConfig, indexName string, cols []storage.IndexColumnSchema) models.TableConfig {
+	sort.SliceStable(cols, func(i, j int) bool {
+		return cols[i].OrdinalPosition < cols[j].OrdinalPosition
+	})
+	indexConf := models.TableConfig{
+		SpannerIndexName: indexName,
+		ActualTable:      tableConf.ActualTable,
+	}
+	var keys []string
+	stored := make(map[string]struct{})
+	for _, col := range cols {
+		indexConf.IsNullFiltered = col.IsNullFiltered
+		if col.OrdinalPosition == 0 {
+			indexConf.NonKeyAttributes = append(indexConf.NonKeyAttributes, col.ColumnName)
+			stored[col.ColumnName] = struct{}{}
+			continue
+		}
+		keys = append(keys, col.ColumnName)
+	}
+	if len(keys) > 0 {
+		indexConf.PartitionKey = keys[0]
+	}
+	if len(keys) > 1 {
+		indexConf.SortKey = keys[1]
+	}
+	sort.Strings(indexConf.NonKeyAttributes)
+
+	switch {
+	case len(stored) == 0:
+		indexConf.ProjectionType = models.ProjectionTypeKeysOnly
+	case storesAllColumns(tableName, []string{tableConf.PartitionKey, tableConf.SortKey, indexConf.PartitionKey, indexConf.SortKey}, stored):
+		indexConf.ProjectionType = models.ProjectionTypeAll
+	default:
+		indexConf.ProjectionType = models.ProjectionTypeInclude
+	}
+	return indexConf
+}
+
+// storesAllColumns reports whether every non-key column of the table is in stored.
+func storesAllColumns(tableName string, keys []string, stored map[string]struct{}) bool {
+	for _, col := range models.TableColumnMap[tableName] {
+		if col == "commit_timestamp" {
+			continue
+		}
+		isKey := false
+		for _, key := range keys {
+			if col == key {
+				isKey = true
+				break
+			}
+		}
+		if isKey {
+			continue
+		}
+		if _, ok := stored[col]; !ok {
+			return false
+		}
+	}
+	return true
+}
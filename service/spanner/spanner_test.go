@@ -0,0 +1,84 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spanner
+
+import (
+	"testing"
+
+	"github.com/cloudspannerecosystem/dynamodb-adapter/models"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetIndexConfigs(t *testing.T) {
+	models.TableColumnMap["orders"] = []string{"id", "customer", "status", "total"}
+	models.DbConfigMap = map[string]models.TableConfig{
+		"orders": {PartitionKey: "id", ActualTable: "orders"},
+	}
+
+	setIndexConfigs([]storage.IndexColumnSchema{
+		{TableName: "orders", IndexName: "by_customer", ColumnName: "total", IsNullFiltered: true},
+		{TableName: "orders", IndexName: "by_customer", ColumnName: "customer", OrdinalPosition: 1, IsNullFiltered: true},
+		{TableName: "orders", IndexName: "by_customer", ColumnName: "status", IsNullFiltered: true},
+		{TableName: "orders", IndexName: "by_status", ColumnName: "status", OrdinalPosition: 1},
+		{TableName: "orders", IndexName: "by_status", ColumnName: "customer", OrdinalPosition: 2},
+		{TableName: "orders", IndexName: "by_status_total", ColumnName: "status", OrdinalPosition: 1},
+		{TableName: "orders", IndexName: "by_status_total", ColumnName: "total"},
+		{TableName: "other", IndexName: "by_other", ColumnName: "other", OrdinalPosition: 1},
+	})
+
+	indices := models.DbConfigMap["orders"].Indices
+	assert.Len(t, indices, 3)
+
+	assert.Equal(t, models.TableConfig{
+		PartitionKey:     "customer",
+		SpannerIndexName: "by_customer",
+		ActualTable:      "orders",
+		ProjectionType:   models.ProjectionTypeAll,
+		NonKeyAttributes: []string{"status", "total"},
+		IsNullFiltered:   true,
+	}, indices["by_customer"])
+
+	assert.Equal(t, "status", indices["by_status"].PartitionKey)
+	assert.Equal(t, "customer", indices["by_status"].SortKey)
+	assert.Equal(t, models.ProjectionTypeKeysOnly, indices["by_status"].ProjectionType)
+
+	assert.Equal(t, models.ProjectionTypeInclude, indices["by_status_total"].ProjectionType)
+	assert.Equal(t, []string{"total"}, indices["by_status_total"].NonKeyAttributes)
+}
+
+func TestSetIndexConfigsMerge(t *testing.T) {
+	models.TableColumnMap["orders"] = []string{"id", "customer", "status"}
+	models.DbConfigMap = map[string]models.TableConfig{
+		"orders": {PartitionKey: "id", ActualTable: "orders", Indices: map[string]models.TableConfig{
+			"by-customer": {PartitionKey: "customer", SpannerIndexName: "by_customer", DDBIndexName: "by-customer"},
+			"by-region":   {PartitionKey: "region", SpannerIndexName: "by_region", DDBIndexName: "by-region"},
+		}},
+	}
+
+	setIndexConfigs([]storage.IndexColumnSchema{
+		{TableName: "orders", IndexName: "by_customer", ColumnName: "customer", OrdinalPosition: 1},
+		{TableName: "orders", IndexName: "by_status", ColumnName: "status", OrdinalPosition: 1},
+	})
+
+	indices := models.DbConfigMap["orders"].Indices
+	assert.Len(t, indices, 3)
+	// the configured index keeps its DynamoDB name and takes the schema of Spanner
+	assert.Equal(t, "by-customer", indices["by-customer"].DDBIndexName)
+	assert.Equal(t, "by_customer", indices["by-customer"].SpannerIndexName)
+	assert.Equal(t, models.ProjectionTypeKeysOnly, indices["by-customer"].ProjectionType)
+	assert.Equal(t, "region", indices["by-region"].PartitionKey)
+	assert.Equal(t, "status", indices["by_status"].PartitionKey)
+}
@@ -0,0 +1,74 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+
+	database "cloud.google.com/go/spanner/admin/database/apiv1"
+	"cloud.google.com/go/spanner/admin/database/apiv1/databasepb"
+	otelgo "github.com/cloudspannerecosystem/dynamodb-adapter/otel"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/pkg/errors"
+)
+
+const UpdateDatabaseDdlAnnotation = "Calling UpdateDatabaseDdl Method"
+
+// SchemaOperation is a long-running schema change started with UpdateDatabaseDdl
+type SchemaOperation interface {
+	// Poll refreshes the state of the operation and returns its error once it has failed
+	Poll(ctx context.Context) error
+	// Done reports whether the operation has finished
+	Done() bool
+	// Progress reports whether the backfill of the statement has started and how far it is
+	Progress() (started bool, percent int32)
+}
+
+type ddlOperation struct {
+	op *database.UpdateDatabaseDdlOperation
+}
+
+func (d ddlOperation) Poll(ctx context.Context) error {
+	return d.op.Poll(ctx)
+}
+
+func (d ddlOperation) Done() bool {
+	return d.op.Done()
+}
+
+func (d ddlOperation) Progress() (bool, int32) {
+	meta, err := d.op.Metadata()
+	if err != nil || meta == nil || len(meta.Progress) == 0 || meta.Progress[0] == nil {
+		return false, 0
+	}
+	return meta.Progress[0].StartTime != nil, meta.Progress[0].ProgressPercent
+}
+
+// UpdateDatabaseDdl - starts the schema change on the database that holds the table
+// and returns without waiting for it to complete
+func (s Storage) UpdateDatabaseDdl(ctx context.Context, table string, statements []string) (SchemaOperation, error) {
+	otelgo.AddAnnotation(ctx, UpdateDatabaseDdlAnnotation)
+	if s.adminClient == nil {
+		return nil, errors.New("InternalServerError", "Spanner admin client is not initialized")
+	}
+	database, _ := TableDatabase(table)
+	op, err := s.adminClient.UpdateDatabaseDdl(ctx, &databasepb.UpdateDatabaseDdlRequest{
+		Database:   database,
+		Statements: statements,
+	})
+	if err != nil {
+		return nil, errors.New("ValidationException", err)
+	}
+	return ddlOperation{op: op}, nil
+}
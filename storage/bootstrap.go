@@ -0,0 +1,283 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"cloud.google.com/go/spanner"
+	"cloud.google.com/go/spanner/admin/database/apiv1/databasepb"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/models"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/pkg/logger"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/utils"
+)
+
+// AdapterTableDDL creates the table holding the metadata of the DynamoDB tables
+const AdapterTableDDL = `CREATE TABLE dynamodb_adapter_table_ddl (
+	column STRING(MAX) NOT NULL,
+	tableName STRING(MAX) NOT NULL,
+	dynamoDataType STRING(MAX) NOT NULL,
+	originalColumn STRING(MAX) NOT NULL,
+	partitionKey STRING(MAX),
+	sortKey STRING(MAX),
+	spannerIndexName STRING(MAX),
+	actualTable STRING(MAX),
+	spannerDataType STRING(MAX)
+) PRIMARY KEY (tableName, column)`
+
+// ConfigManagerTableDDL creates the table read by the config manager
+const ConfigManagerTableDDL = `CREATE TABLE dynamodb_adapter_config_manager (
+	tableName STRING(MAX),
+	config STRING(MAX),
+	cronTime STRING(MAX),
+	enabledStream STRING(MAX),
+	uniqueValue STRING(MAX)
+) PRIMARY KEY (tableName)`
+
+// spannerSchema lists the tables and indexes that exist in a database
+type spannerSchema struct {
+	tables  map[string]struct{}
+	indexes map[string]struct{}
+}
+
+// Bootstrap creates the adapter metadata tables in the default database and
+// the given tables and their indexes in the databases they are routed to,
+// skipping the ones that already exist, and then writes the table metadata.
+func (s Storage) Bootstrap(ctx context.Context, tables map[string]models.BootstrapTable) error {
+	schemas := make(map[string]spannerSchema)
+	for database, session := range databaseSessions() {
+		client, err := s.clientForDatabase(database, session)
+		if err != nil {
+			return err
+		}
+		schema, err := readSpannerSchema(ctx, client)
+		if err != nil {
+			return fmt.Errorf("failed to read the schema of %s: %w", database, err)
+		}
+		schemas[database] = schema
+	}
+
+	statements := bootstrapDDL(tables, schemas)
+	databases := make([]string, 0, len(statements))
+	for database := range statements {
+		databases = append(databases, database)
+	}
+	// The metadata tables live in the default database and are created first
+	sort.Slice(databases, func(i, j int) bool {
+		return databases[i] == databasePath() || (databases[j] != databasePath() && databases[i] < databases[j])
+	})
+	for _, database := range databases {
+		if err := s.applyDDL(ctx, database, statements[database]); err != nil {
+			return fmt.Errorf("failed to bootstrap %s: %w", database, err)
+		}
+	}
+
+	mutations := bootstrapMetadata(tables)
+	if len(mutations) > 0 {
+		client, err := s.clientForDatabase(databasePath(), models.GlobalConfig.Spanner.Session)
+		if err != nil {
+			return err
+		}
+		if _, err := client.Apply(ctx, mutations); err != nil {
+			return fmt.Errorf("failed to write the table metadata: %w", err)
+		}
+	}
+	logger.LogInfo(fmt.Sprintf("Bootstrapped metadata of %d tables", len(tables)))
+	return nil
+}
+
+// bootstrapDDL returns the statements creating the missing tables and indexes, by database
+func bootstrapDDL(tables map[string]models.BootstrapTable, schemas map[string]spannerSchema) map[string][]string {
+	statements := make(map[string][]string)
+	addStatement := func(database, name string, ddl string, existing map[string]struct{}) {
+		if _, ok := existing[name]; ok {
+			return
+		}
+		existing[name] = struct{}{}
+		statements[database] = append(statements[database], ddl)
+	}
+
+	defaultSchema := schemaOf(schemas, databasePath())
+	addStatement(databasePath(), "dynamodb_adapter_table_ddl", AdapterTableDDL, defaultSchema.tables)
+	addStatement(databasePath(), "dynamodb_adapter_config_manager", ConfigManagerTableDDL, defaultSchema.tables)
+
+	for _, tableName := range sortedTableNames(tables) {
+		table := tables[tableName]
+		database, _ := TableDatabase(tableName)
+		schema := schemaOf(schemas, database)
+		spannerTable := utils.ChangeTableNameForSpanner(tableName)
+		attributeTypes := bootstrapAttributeTypes(table)
+
+		columns := make([]string, 0, len(attributeTypes))
+		for column := range attributeTypes {
+			columns = append(columns, column)
+		}
+		sort.Strings(columns)
+		definitions := make([]string, 0, len(columns))
+		for _, column := range columns {
+			definitions = append(definitions, column+" "+bootstrapSpannerType(table, column, attributeTypes[column]))
+		}
+		primaryKey := table.PartitionKey
+		if table.SortKey != "" {
+			primaryKey += ", " + table.SortKey
+		}
+		addStatement(database, spannerTable, fmt.Sprintf("CREATE TABLE %s (\n\t%s\n) PRIMARY KEY (%s)",
+			spannerTable, strings.Join(definitions, ",\n\t"), primaryKey), schema.tables)
+
+		tableConf := models.TableConfig{PartitionKey: table.PartitionKey, SortKey: table.SortKey, ActualTable: tableName}
+		indexNames := make([]string, 0, len(table.Indices))
+		for indexName := range table.Indices {
+			indexNames = append(indexNames, indexName)
+		}
+		sort.Strings(indexNames)
+		for _, indexName := range indexNames {
+			index := table.Indices[indexName]
+			projectionType := index.ProjectionType
+			if projectionType == "" {
+				projectionType = models.ProjectionTypeAll
+			}
+			indexConf := models.TableConfig{
+				PartitionKey:     index.PartitionKey,
+				SortKey:          index.SortKey,
+				SpannerIndexName: indexName,
+				ProjectionType:   projectionType,
+				NonKeyAttributes: index.NonKeyAttributes,
+			}
+			addStatement(database, utils.ChangeTableNameForSpanner(indexName),
+				utils.GenerateIndexDDL(tableConf, indexConf, columns), schema.indexes)
+		}
+	}
+	return statements
+}
+
+// bootstrapMetadata returns the mutations writing the metadata rows of the tables
+func bootstrapMetadata(tables map[string]models.BootstrapTable) []*spanner.Mutation {
+	var mutations []*spanner.Mutation
+	for _, row := range bootstrapMetadataRows(tables) {
+		mutations = append(mutations, spanner.InsertOrUpdateMap("dynamodb_adapter_table_ddl", row))
+	}
+	return mutations
+}
+
+// bootstrapMetadataRows returns the dynamodb_adapter_table_ddl rows of the tables
+func bootstrapMetadataRows(tables map[string]models.BootstrapTable) []map[string]interface{} {
+	var rows []map[string]interface{}
+	for _, tableName := range sortedTableNames(tables) {
+		table := tables[tableName]
+		for column, dataType := range bootstrapAttributeTypes(table) {
+			rows = append(rows, map[string]interface{}{
+				"column":           column,
+				"tableName":        tableName,
+				"dynamoDataType":   dataType,
+				"originalColumn":   column,
+				"partitionKey":     table.PartitionKey,
+				"sortKey":          table.SortKey,
+				"spannerIndexName": column,
+				"actualTable":      tableName,
+				"spannerDataType":  bootstrapSpannerType(table, column, dataType),
+			})
+		}
+	}
+	return rows
+}
+
+// bootstrapAttributeTypes returns the attribute types of the table including its keys
+func bootstrapAttributeTypes(table models.BootstrapTable) map[string]string {
+	attributeTypes := make(map[string]string, len(table.AttributeTypes)+2)
+	for column, dataType := range table.AttributeTypes {
+		attributeTypes[column] = dataType
+	}
+	for _, key := range []string{table.PartitionKey, table.SortKey} {
+		if _, ok := attributeTypes[key]; key != "" && !ok {
+			attributeTypes[key] = "S"
+		}
+	}
+	return attributeTypes
+}
+
+// bootstrapSpannerType returns the Spanner type of a column of the table.
+// Numbers are NUMERIC unless the table lists the column in StringNumbers.
+func bootstrapSpannerType(table models.BootstrapTable, column, dataType string) string {
+	if slices.Contains(table.StringNumbers, column) {
+		switch dataType {
+		case "N":
+			return "STRING(MAX)"
+		case "NS":
+			return "ARRAY<STRING(MAX)>"
+		}
+	}
+	return utils.ConvertDynamoTypeToSpannerType(dataType)
+}
+
+func sortedTableNames(tables map[string]models.BootstrapTable) []string {
+	names := make([]string, 0, len(tables))
+	for name := range tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func schemaOf(schemas map[string]spannerSchema, database string) spannerSchema {
+	schema, ok := schemas[database]
+	if !ok {
+		schema = spannerSchema{tables: make(map[string]struct{}), indexes: make(map[string]struct{})}
+		schemas[database] = schema
+	}
+	return schema
+}
+
+// readSpannerSchema returns the user tables and secondary indexes of a database
+func readSpannerSchema(ctx context.Context, client *spanner.Client) (spannerSchema, error) {
+	schema := spannerSchema{tables: make(map[string]struct{}), indexes: make(map[string]struct{})}
+	stmt := spanner.Statement{SQL: `SELECT t.TABLE_NAME, '' FROM INFORMATION_SCHEMA.TABLES AS t WHERE t.TABLE_SCHEMA = ''
+		UNION ALL
+		SELECT i.TABLE_NAME, i.INDEX_NAME FROM INFORMATION_SCHEMA.INDEXES AS i WHERE i.TABLE_SCHEMA = '' AND i.INDEX_TYPE = 'INDEX'`}
+	err := client.Single().Query(ctx, stmt).Do(func(row *spanner.Row) error {
+		var tableName, indexName string
+		if err := row.Columns(&tableName, &indexName); err != nil {
+			return err
+		}
+		if indexName == "" {
+			schema.tables[tableName] = struct{}{}
+		} else {
+			schema.indexes[indexName] = struct{}{}
+		}
+		return nil
+	})
+	return schema, err
+}
+
+// applyDDL runs the schema change on the database and waits for it to complete
+func (s Storage) applyDDL(ctx context.Context, database string, statements []string) error {
+	if len(statements) == 0 {
+		return nil
+	}
+	if s.adminClient == nil {
+		return fmt.Errorf("spanner admin client is not initialized")
+	}
+	op, err := s.adminClient.UpdateDatabaseDdl(ctx, &databasepb.UpdateDatabaseDdlRequest{
+		Database:   database,
+		Statements: statements,
+	})
+	if err != nil {
+		return err
+	}
+	return op.Wait(ctx)
+}
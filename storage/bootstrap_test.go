@@ -0,0 +1,72 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"testing"
+
+	"github.com/cloudspannerecosystem/dynamodb-adapter/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBootstrapDDL(t *testing.T) {
+	setupRouting(t)
+	tables := map[string]models.BootstrapTable{
+		"Customer-Order": {
+			PartitionKey:   "PK",
+			SortKey:        "SK",
+			AttributeTypes: map[string]string{"customer_id": "S", "total": "N", "refund": "N"},
+			StringNumbers:  []string{"refund"},
+			Indices: map[string]models.BootstrapIndex{
+				"By_customer": {PartitionKey: "customer_id", SortKey: "SK"},
+				"By_total":    {PartitionKey: "total", ProjectionType: models.ProjectionTypeKeysOnly},
+			},
+		},
+		"eu_users": {PartitionKey: "id"},
+	}
+	schemas := map[string]spannerSchema{
+		"projects/proj/instances/main/databases/db": {
+			tables:  map[string]struct{}{"dynamodb_adapter_table_ddl": {}},
+			indexes: map[string]struct{}{"By_total": {}},
+		},
+	}
+
+	statements := bootstrapDDL(tables, schemas)
+	assert.Equal(t, map[string][]string{
+		"projects/proj/instances/main/databases/db": {
+			ConfigManagerTableDDL,
+			"CREATE TABLE Customer_Order (\n\tPK STRING(MAX),\n\tSK STRING(MAX),\n\tcustomer_id STRING(MAX),\n\trefund STRING(MAX),\n\ttotal NUMERIC\n) PRIMARY KEY (PK, SK)",
+			"CREATE NULL_FILTERED INDEX By_customer ON Customer_Order (customer_id, SK) STORING (refund, total)",
+		},
+		"projects/proj/instances/regional/databases/eu": {
+			"CREATE TABLE eu_users (\n\tid STRING(MAX)\n) PRIMARY KEY (id)",
+		},
+	}, statements)
+}
+
+func TestBootstrapMetadata(t *testing.T) {
+	mutations := bootstrapMetadata(map[string]models.BootstrapTable{
+		"orders": {PartitionKey: "id", AttributeTypes: map[string]string{"id": "N", "status": "S"}},
+	})
+	assert.Len(t, mutations, 2)
+
+	spannerTypes := map[string]interface{}{}
+	for _, row := range bootstrapMetadataRows(map[string]models.BootstrapTable{
+		"orders": {PartitionKey: "id", AttributeTypes: map[string]string{"id": "N", "total": "N", "scores": "NS"}, StringNumbers: []string{"id", "scores"}},
+	}) {
+		spannerTypes[row["column"].(string)] = row["spannerDataType"]
+	}
+	assert.Equal(t, map[string]interface{}{"id": "STRING(MAX)", "total": "NUMERIC", "scores": "ARRAY<STRING(MAX)>"}, spannerTypes)
+}
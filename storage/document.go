@@ -0,0 +1,542 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"encoding/base64"
+	stderrors "errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"cloud.google.com/go/spanner"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/config"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/models"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/pkg/errors"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/utils"
+)
+
+// Map and List attributes are stored in JSON columns as DynamoDB JSON: the
+// attribute value wrapped in its type, e.g. {"M": {"tags": {"SS": ["a"]}}}.
+// The sets, binaries and numbers nested in them keep their types that way.
+// Columns written before hold plain JSON and are still read.
+
+// Actions of the updates of nested attributes
+const (
+	documentSet    = "SET"
+	documentAdd    = "ADD"
+	documentDelete = "DELETE"
+	documentRemove = "REMOVE"
+)
+
+// invalidDocumentPathError is returned for paths that do not match the
+// document they update
+func invalidDocumentPathError() error {
+	return errors.New("ValidationException", "The document path provided in the update expression is invalid for update")
+}
+
+// encodeDocument encodes a Map or List attribute, or a value nested in one,
+// as DynamoDB JSON
+func encodeDocument(v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case nil:
+		return map[string]interface{}{"NULL": true}, nil
+	case string:
+		return map[string]interface{}{"S": v}, nil
+	case bool:
+		return map[string]interface{}{"BOOL": v}, nil
+	case []byte:
+		return map[string]interface{}{"B": base64.StdEncoding.EncodeToString(v)}, nil
+	case []string:
+		set := make([]interface{}, len(v))
+		for i := range v {
+			set[i] = v[i]
+		}
+		return map[string]interface{}{"SS": set}, nil
+	case []models.Number:
+		set := make([]interface{}, len(v))
+		for i := range v {
+			set[i] = v[i].String()
+		}
+		return map[string]interface{}{"NS": set}, nil
+	case [][]byte:
+		set := make([]interface{}, len(v))
+		for i := range v {
+			set[i] = base64.StdEncoding.EncodeToString(v[i])
+		}
+		return map[string]interface{}{"BS": set}, nil
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, elem := range v {
+			encoded, err := encodeDocument(elem)
+			if err != nil {
+				return nil, err
+			}
+			m[k] = encoded
+		}
+		return map[string]interface{}{"M": m}, nil
+	case []interface{}:
+		l := make([]interface{}, len(v))
+		for i, elem := range v {
+			encoded, err := encodeDocument(elem)
+			if err != nil {
+				return nil, err
+			}
+			l[i] = encoded
+		}
+		return map[string]interface{}{"L": l}, nil
+	}
+	if n, ok := models.ToNumber(v); ok {
+		return map[string]interface{}{"N": n.String()}, nil
+	}
+	return nil, errors.New("ValidationException", fmt.Sprintf("%T is not a supported attribute value", v))
+}
+
+// decodeDocument decodes a value encoded by encodeDocument
+func decodeDocument(v interface{}) (interface{}, error) {
+	m, ok := v.(map[string]interface{})
+	if !ok || len(m) != 1 {
+		return nil, fmt.Errorf("invalid DynamoDB JSON: %v", v)
+	}
+	for typ, value := range m {
+		switch typ {
+		case "S":
+			if s, ok := value.(string); ok {
+				return s, nil
+			}
+		case "N":
+			if s, ok := value.(string); ok {
+				return models.ParseNumber(s)
+			}
+		case "BOOL":
+			if b, ok := value.(bool); ok {
+				return b, nil
+			}
+		case "NULL":
+			return nil, nil
+		case "B":
+			if s, ok := value.(string); ok {
+				return base64.StdEncoding.DecodeString(s)
+			}
+		case "SS", "NS", "BS":
+			elems, ok := value.([]interface{})
+			if !ok {
+				break
+			}
+			return decodeSet(typ, elems)
+		case "M":
+			fields, ok := value.(map[string]interface{})
+			if !ok {
+				break
+			}
+			decoded := make(map[string]interface{}, len(fields))
+			for k, field := range fields {
+				elem, err := decodeDocument(field)
+				if err != nil {
+					return nil, err
+				}
+				decoded[k] = elem
+			}
+			return decoded, nil
+		case "L":
+			elems, ok := value.([]interface{})
+			if !ok {
+				break
+			}
+			decoded := make([]interface{}, len(elems))
+			for i, e := range elems {
+				elem, err := decodeDocument(e)
+				if err != nil {
+					return nil, err
+				}
+				decoded[i] = elem
+			}
+			return decoded, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid DynamoDB JSON: %v", v)
+}
+
+func decodeSet(typ string, elems []interface{}) (interface{}, error) {
+	strs := make([]string, len(elems))
+	for i, e := range elems {
+		s, ok := e.(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid %s element: %v", typ, e)
+		}
+		strs[i] = s
+	}
+	switch typ {
+	case "NS":
+		numbers := make([]models.Number, len(strs))
+		for i, s := range strs {
+			n, err := models.ParseNumber(s)
+			if err != nil {
+				return nil, err
+			}
+			numbers[i] = n
+		}
+		return numbers, nil
+	case "BS":
+		set := make([][]byte, len(strs))
+		for i, s := range strs {
+			b, err := base64.StdEncoding.DecodeString(s)
+			if err != nil {
+				return nil, err
+			}
+			set[i] = b
+		}
+		return set, nil
+	}
+	return strs, nil
+}
+
+// decodeDocumentColumn decodes the value of a Map or List column. It reports
+// false for columns holding plain JSON, written before values were typed.
+func decodeDocumentColumn(v interface{}) (interface{}, bool) {
+	m, ok := v.(map[string]interface{})
+	if !ok || len(m) != 1 {
+		return nil, false
+	}
+	_, isMap := m["M"].(map[string]interface{})
+	_, isList := m["L"].([]interface{})
+	if !isMap && !isList {
+		return nil, false
+	}
+	decoded, err := decodeDocument(m)
+	if err != nil {
+		return nil, false
+	}
+	return decoded, true
+}
+
+// documentColumnValue encodes the value written to a Map or List column of
+// the table. Values already encoded as JSON text are written as they are.
+func documentColumnValue(table, column string, v interface{}) (interface{}, error) {
+	if t := models.TableDDL[table][column]; t != "M" && t != "L" {
+		return v, nil
+	}
+	switch v.(type) {
+	case nil, string, []byte, spanner.NullJSON:
+		return v, nil
+	}
+	encoded, err := encodeDocument(v)
+	if err != nil {
+		return nil, err
+	}
+	return spanner.NullJSON{Value: encoded, Valid: true}, nil
+}
+
+// pathStep is a step of a document path, a map key or a list index
+type pathStep struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+// parseDocumentPath splits a path like a.b[3].c into its root attribute and
+// the steps into it. It reports false for paths without steps.
+func parseDocumentPath(path string) (string, []pathStep, bool) {
+	end := strings.IndexAny(path, ".[")
+	if end <= 0 {
+		return "", nil, false
+	}
+	root, rest := path[:end], path[end:]
+	var steps []pathStep
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end == -1 {
+				end = len(rest)
+			}
+			if end == 0 {
+				return "", nil, false
+			}
+			steps = append(steps, pathStep{key: rest[:end]})
+			rest = rest[end:]
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end == -1 {
+				return "", nil, false
+			}
+			index, err := strconv.Atoi(rest[1:end])
+			if err != nil || index < 0 {
+				return "", nil, false
+			}
+			steps = append(steps, pathStep{index: index, isIndex: true})
+			rest = rest[end+1:]
+		default:
+			return "", nil, false
+		}
+	}
+	return root, steps, true
+}
+
+// takeDocumentUpdates removes the updates of attributes nested in Map and List
+// columns from m and returns them, keyed by their document path
+func takeDocumentUpdates(table string, m map[string]interface{}) map[string]interface{} {
+	colDDL := models.TableDDL[utils.ChangeTableNameForSpanner(table)]
+	updates := map[string]interface{}{}
+	for k, v := range m {
+		root, _, ok := parseDocumentPath(k)
+		if !ok {
+			continue
+		}
+		if t := colDDL[root]; t != "M" && t != "L" {
+			continue
+		}
+		updates[k] = v
+		delete(m, k)
+	}
+	return updates
+}
+
+// applyDocumentUpdates applies the updates of nested attributes to the values
+// of their columns in the row read through r and sets the updated values in
+// columns, which holds the primary key of the row. The updates are written
+// with the row in the same transaction, so they are applied atomically.
+func applyDocumentUpdates(ctx context.Context, r RowReader, table string, columns, updates map[string]interface{}, action string) error {
+	if len(updates) == 0 {
+		return nil
+	}
+	paths := make([]string, 0, len(updates))
+	var roots []string
+	for path := range updates {
+		paths = append(paths, path)
+		root, _, _ := parseDocumentPath(path)
+		if _, ok := columns[root]; !ok && !slices.Contains(roots, root) {
+			roots = append(roots, root)
+		}
+	}
+
+	tableConf, err := config.GetTableConf(table)
+	if err != nil {
+		return err
+	}
+	key := spanner.Key{columns[tableConf.PartitionKey]}
+	if tableConf.SortKey != "" {
+		key = append(key, columns[tableConf.SortKey])
+	}
+	spannerTable := utils.ChangeTableNameForSpanner(table)
+	row, err := r.ReadRow(ctx, spannerTable, key, roots)
+	if err != nil && !stderrors.Is(err, spanner.ErrRowNotFound) {
+		return errors.New("ResourceNotFoundException", err)
+	}
+	current, _, err := parseRow(row, models.TableDDL[spannerTable])
+	if err != nil {
+		return err
+	}
+	for _, root := range roots {
+		columns[root] = current[root]
+	}
+
+	// parents are updated before the attributes nested in them
+	sort.Strings(paths)
+	for _, path := range paths {
+		root, steps, _ := parseDocumentPath(path)
+		doc, ok, err := updateDocument(columns[root], true, steps, action, updates[path])
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return invalidDocumentPathError()
+		}
+		columns[root] = doc
+	}
+	return nil
+}
+
+// DocumentValue returns the attribute of the item at a path like a.b[3].c
+func DocumentValue(item map[string]interface{}, path string) (interface{}, bool) {
+	root, steps, ok := parseDocumentPath(path)
+	if !ok {
+		v, ok := item[path]
+		return v, ok
+	}
+	v, ok := item[root]
+	for _, step := range steps {
+		if !ok {
+			break
+		}
+		if step.isIndex {
+			list, isList := v.([]interface{})
+			if ok = isList && step.index < len(list); ok {
+				v = list[step.index]
+			}
+			continue
+		}
+		fields, isMap := v.(map[string]interface{})
+		if !isMap {
+			return nil, false
+		}
+		v, ok = fields[step.key]
+	}
+	if !ok {
+		return nil, false
+	}
+	return v, true
+}
+
+// SetDocumentValue sets the attribute of the item at a path like a.b[3].c.
+// Like an update expression, setting a list element past the end of the list
+// appends it.
+func SetDocumentValue(item map[string]interface{}, path string, v interface{}) error {
+	return updateItem(item, path, documentSet, v)
+}
+
+// RemoveDocumentValue removes the attribute of the item at a path like
+// a.b[3].c, and the list element at an index
+func RemoveDocumentValue(item map[string]interface{}, path string) error {
+	return updateItem(item, path, documentRemove, nil)
+}
+
+func updateItem(item map[string]interface{}, path, action string, v interface{}) error {
+	root, steps, ok := parseDocumentPath(path)
+	if !ok {
+		root = path
+	}
+	current, exists := item[root]
+	doc, ok, err := updateDocument(current, exists, steps, action, v)
+	if err != nil {
+		return err
+	}
+	if ok {
+		item[root] = doc
+	} else {
+		delete(item, root)
+	}
+	return nil
+}
+
+// updateDocument applies the action to the attribute at the steps into doc
+// and returns the updated doc and whether it exists
+func updateDocument(doc interface{}, exists bool, steps []pathStep, action string, v interface{}) (interface{}, bool, error) {
+	if len(steps) == 0 {
+		return updateAttribute(doc, exists, action, v)
+	}
+	step := steps[0]
+	if step.isIndex {
+		list, ok := doc.([]interface{})
+		if !ok {
+			return nil, false, invalidDocumentPathError()
+		}
+		if step.index >= len(list) {
+			if len(steps) == 1 && action == documentRemove {
+				return list, true, nil
+			}
+			// setting an element past the end of a list appends it
+			if len(steps) > 1 || action != documentSet {
+				return nil, false, invalidDocumentPathError()
+			}
+			return append(list, v), true, nil
+		}
+		elem, ok, err := updateDocument(list[step.index], true, steps[1:], action, v)
+		if err != nil {
+			return nil, false, err
+		}
+		if !ok {
+			return append(list[:step.index], list[step.index+1:]...), true, nil
+		}
+		list[step.index] = elem
+		return list, true, nil
+	}
+	fields, ok := doc.(map[string]interface{})
+	if !ok {
+		return nil, false, invalidDocumentPathError()
+	}
+	field, fieldExists := fields[step.key]
+	if !fieldExists && len(steps) > 1 {
+		return nil, false, invalidDocumentPathError()
+	}
+	field, ok, err := updateDocument(field, fieldExists, steps[1:], action, v)
+	if err != nil {
+		return nil, false, err
+	}
+	if ok {
+		fields[step.key] = field
+	} else {
+		delete(fields, step.key)
+	}
+	return fields, true, nil
+}
+
+// updateAttribute applies the action to a nested attribute. ADD adds numbers
+// and adds elements to sets, DELETE removes elements from sets and removes
+// the attribute when its set is left empty.
+func updateAttribute(existing interface{}, exists bool, action string, v interface{}) (interface{}, bool, error) {
+	switch action {
+	case documentSet:
+		return v, true, nil
+	case documentRemove:
+		return nil, false, nil
+	}
+	if !exists {
+		return v, action == documentAdd, nil
+	}
+	switch existing := existing.(type) {
+	case []string:
+		if elems, ok := v.([]string); ok {
+			if action == documentAdd {
+				return utils.RemoveDuplicatesString(append(existing, elems...)), true, nil
+			}
+			set := setDifference(existing, elems, func(s string) string { return s })
+			return set, len(set) > 0, nil
+		}
+	case []models.Number:
+		if elems, ok := v.([]models.Number); ok {
+			if action == documentAdd {
+				return utils.RemoveDuplicatesNumber(append(existing, elems...)), true, nil
+			}
+			set := setDifference(existing, elems, func(n models.Number) string { return n.String() })
+			return set, len(set) > 0, nil
+		}
+	case [][]byte:
+		if elems, ok := v.([][]byte); ok {
+			if action == documentAdd {
+				return utils.RemoveDuplicatesByteSlice(append(existing, elems...)), true, nil
+			}
+			set := setDifference(existing, elems, func(b []byte) string { return string(b) })
+			return set, len(set) > 0, nil
+		}
+	default:
+		if action == documentAdd {
+			if _, ok := models.ToNumber(existing); ok {
+				sum, err := addNumbers(existing, v)
+				return sum, true, err
+			}
+		}
+	}
+	return nil, false, errors.New("ValidationException", "An operand in the update expression has an incorrect data type")
+}
+
+// setDifference returns the elements of set that are not in elems
+func setDifference[T any](set, elems []T, key func(T) string) []T {
+	removed := make(map[string]struct{}, len(elems))
+	for _, e := range elems {
+		removed[key(e)] = struct{}{}
+	}
+	var result []T
+	for _, e := range set {
+		if _, ok := removed[key(e)]; !ok {
+			result = append(result, e)
+		}
+	}
+	return result
+}
@@ -0,0 +1,175 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/cloudspannerecosystem/dynamodb-adapter/models"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDocumentRoundTrip(t *testing.T) {
+	doc := map[string]interface{}{
+		"s":    "text",
+		"n":    models.Number("12345678901234567890.5"),
+		"bool": true,
+		"null": nil,
+		"b":    []byte{0, 1},
+		"ss":   []string{"a", "b"},
+		"ns":   []models.Number{"1", "2.5"},
+		"bs":   [][]byte{{0}, {1, 2}},
+		"m":    map[string]interface{}{"tags": []string{"x"}},
+		"l":    []interface{}{"a", models.Number("1"), []interface{}{[]models.Number{"3"}}},
+	}
+	encoded, err := encodeDocument(doc)
+	assert.NoError(t, err)
+
+	// columns are decoded from the JSON Spanner returns
+	data, err := json.Marshal(encoded)
+	assert.NoError(t, err)
+	var decoded interface{}
+	assert.NoError(t, json.Unmarshal(data, &decoded))
+	value, ok := decodeDocumentColumn(decoded)
+	assert.True(t, ok)
+	assert.Equal(t, doc, value)
+
+	_, ok = decodeDocumentColumn(map[string]interface{}{"note": "plain JSON"})
+	assert.False(t, ok)
+
+	_, err = encodeDocument(map[string]interface{}{"c": make(chan int)})
+	assert.Error(t, err)
+}
+
+func TestParseDocumentPath(t *testing.T) {
+	root, steps, ok := parseDocumentPath("a.b[3].c")
+	assert.True(t, ok)
+	assert.Equal(t, "a", root)
+	assert.Equal(t, []pathStep{{key: "b"}, {index: 3, isIndex: true}, {key: "c"}}, steps)
+
+	for _, path := range []string{"a", ".a", "a.", "a[x]", "a[-1]", "a[1", "a..b"} {
+		_, _, ok := parseDocumentPath(path)
+		assert.False(t, ok, path)
+	}
+}
+
+func TestDocumentValues(t *testing.T) {
+	item := map[string]interface{}{
+		"id": "1",
+		"m":  map[string]interface{}{"l": []interface{}{"a", "b", "c"}, "x": models.Number("1")},
+	}
+	v, ok := DocumentValue(item, "m.l[1]")
+	assert.True(t, ok)
+	assert.Equal(t, "b", v)
+	for _, path := range []string{"m.l[3]", "m.y", "id.x", "n"} {
+		_, ok := DocumentValue(item, path)
+		assert.False(t, ok, path)
+	}
+
+	assert.NoError(t, SetDocumentValue(item, "m.y", map[string]interface{}{"z": true}))
+	assert.NoError(t, SetDocumentValue(item, "m.l[5]", "d"))
+	assert.NoError(t, SetDocumentValue(item, "n", []string{"a"}))
+	assert.NoError(t, RemoveDocumentValue(item, "m.l[0]"))
+	assert.NoError(t, RemoveDocumentValue(item, "m.l[9]"))
+	assert.NoError(t, RemoveDocumentValue(item, "m.x"))
+	assert.NoError(t, RemoveDocumentValue(item, "id"))
+	assert.Equal(t, map[string]interface{}{
+		"m": map[string]interface{}{"l": []interface{}{"b", "c", "d"}, "y": map[string]interface{}{"z": true}},
+		"n": []string{"a"},
+	}, item)
+
+	assert.Equal(t, invalidDocumentPathError(), SetDocumentValue(item, "m.w.v", "x"))
+	assert.Equal(t, invalidDocumentPathError(), RemoveDocumentValue(item, "n[0]"))
+}
+
+func TestMemoryStorageNestedUpdates(t *testing.T) {
+	s := setupMemoryStorage(t)
+	models.TableDDL["orders"]["lines"] = "L"
+	models.TableColumnMap["orders"] = append(models.TableColumnMap["orders"], "lines")
+	ctx := context.Background()
+	key := map[string]interface{}{"customer": "alice", "id": models.Number("1")}
+	withKey := func(m map[string]interface{}) map[string]interface{} {
+		for k, v := range key {
+			m[k] = v
+		}
+		return m
+	}
+
+	_, err := s.SpannerPut(ctx, "orders", withKey(map[string]interface{}{
+		"info": map[string]interface{}{
+			"labels": []string{"a"},
+			"sizes":  []models.Number{"1", "2"},
+			"count":  models.Number("1"),
+			"nested": map[string]interface{}{"list": []interface{}{"x", "y"}},
+		},
+		"lines": []interface{}{"first", map[string]interface{}{"bin": [][]byte{{1}}}},
+	}), &models.Eval{}, nil, nil)
+	assert.NoError(t, err)
+
+	_, err = s.SpannerAdd(ctx, "orders", withKey(map[string]interface{}{
+		"info.labels": []string{"b"},
+		"info.count":  models.Number("2"),
+		"info.fresh":  []string{"new"},
+	}), &models.Eval{}, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, s.SpannerDel(ctx, "orders", withKey(map[string]interface{}{
+		"info.sizes": []models.Number{"1", "2"},
+	}), &models.Eval{}, nil))
+	_, err = s.SpannerPut(ctx, "orders", withKey(map[string]interface{}{
+		"info.nested.list[1]": "z",
+		"lines[5]":            models.Number("7"),
+	}), &models.Eval{}, nil, nil)
+	assert.NoError(t, err)
+
+	item, _, err := s.SpannerGet(ctx, "orders", "alice", models.Number("1"), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"labels": []string{"a", "b"},
+		"count":  models.Number("3"),
+		"fresh":  []string{"new"},
+		"nested": map[string]interface{}{"list": []interface{}{"x", "z"}},
+	}, item["info"])
+	assert.Equal(t, []interface{}{"first", map[string]interface{}{"bin": [][]byte{{1}}}, models.Number("7")}, item["lines"])
+
+	for _, path := range []string{"info.missing.x", "info.labels[0]", "info.nested.list[3].x"} {
+		_, err = s.SpannerPut(ctx, "orders", withKey(map[string]interface{}{path: "v"}), &models.Eval{}, nil, nil)
+		e, ok := err.(*errors.Error)
+		if assert.True(t, ok, path) {
+			assert.Equal(t, "ValidationException", e.ErrorCode)
+		}
+	}
+	_, err = s.SpannerAdd(ctx, "orders", withKey(map[string]interface{}{"info.labels": models.Number("1")}), &models.Eval{}, nil)
+	assert.Error(t, err)
+}
+
+func TestMemoryStoragePlainJSONMap(t *testing.T) {
+	s := setupMemoryStorage(t)
+	ctx := context.Background()
+
+	// maps written before they were stored as DynamoDB JSON
+	err := s.ReadWriteTransaction(ctx, []string{"orders"}, func(ctx context.Context, txn Transaction) error {
+		return txn.BufferWrite([]*Mutation{insertOrUpdateMutation("orders", map[string]interface{}{
+			"customer": "alice", "id": models.Number("1"), "info": `{"note": "leave at door", "floor": 2}`,
+		})})
+	})
+	assert.NoError(t, err)
+
+	item, _, err := s.SpannerGet(ctx, "orders", "alice", models.Number("1"), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"note": "leave at door", "floor": models.Number("2")}, item["info"])
+}
@@ -0,0 +1,113 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+
+	database "cloud.google.com/go/spanner/admin/database/apiv1"
+	"cloud.google.com/go/spanner/admin/database/apiv1/databasepb"
+	instance "cloud.google.com/go/spanner/admin/instance/apiv1"
+	"cloud.google.com/go/spanner/admin/instance/apiv1/instancepb"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/pkg/logger"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// emulatorInstanceConfig is the only instance configuration of the emulator
+const emulatorInstanceConfig = "emulator-config"
+
+var databasePathRg = regexp.MustCompile(`^(projects/[^/]+)/instances/([^/]+)/databases/([^/]+)$`)
+
+// EmulatorHost returns the address of the Spanner emulator, or an empty string
+// when the adapter runs against Cloud Spanner. The Spanner clients connect to
+// the emulator without credentials whenever SPANNER_EMULATOR_HOST is set.
+func EmulatorHost() string {
+	return os.Getenv("SPANNER_EMULATOR_HOST")
+}
+
+// prepareEmulator creates the instances and databases of the default and the
+// routed databases on the emulator, which starts out empty.
+func prepareEmulator(ctx context.Context) error {
+	instanceAdmin, err := instance.NewInstanceAdminClient(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create Spanner instance admin client: %w", err)
+	}
+	defer instanceAdmin.Close()
+	databaseAdmin, err := database.NewDatabaseAdminClient(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create Spanner admin client: %w", err)
+	}
+	defer databaseAdmin.Close()
+
+	instances := make(map[string]struct{})
+	for path := range databaseSessions() {
+		matches := databasePathRg.FindStringSubmatch(path)
+		if matches == nil {
+			return fmt.Errorf("invalid database path %s", path)
+		}
+		project, instanceID, databaseID := matches[1], matches[2], matches[3]
+		instancePath := project + "/instances/" + instanceID
+
+		if _, ok := instances[instancePath]; !ok {
+			if err := createEmulatorInstance(ctx, instanceAdmin, project, instanceID); err != nil {
+				return err
+			}
+			instances[instancePath] = struct{}{}
+		}
+
+		op, err := databaseAdmin.CreateDatabase(ctx, &databasepb.CreateDatabaseRequest{
+			Parent:          instancePath,
+			CreateStatement: "CREATE DATABASE `" + databaseID + "`",
+		})
+		if status.Code(err) == codes.AlreadyExists {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to create database %s: %w", path, err)
+		}
+		if _, err := op.Wait(ctx); err != nil {
+			return fmt.Errorf("failed to create database %s: %w", path, err)
+		}
+		logger.LogInfo("Created database " + path + " on the Spanner emulator")
+	}
+	return nil
+}
+
+func createEmulatorInstance(ctx context.Context, client *instance.InstanceAdminClient, project, instanceID string) error {
+	op, err := client.CreateInstance(ctx, &instancepb.CreateInstanceRequest{
+		Parent:     project,
+		InstanceId: instanceID,
+		Instance: &instancepb.Instance{
+			Config:      project + "/instanceConfigs/" + emulatorInstanceConfig,
+			DisplayName: instanceID,
+			NodeCount:   1,
+		},
+	})
+	if status.Code(err) == codes.AlreadyExists {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create instance %s: %w", instanceID, err)
+	}
+	if _, err := op.Wait(ctx); err != nil {
+		return fmt.Errorf("failed to create instance %s: %w", instanceID, err)
+	}
+	logger.LogInfo("Created instance " + instanceID + " on the Spanner emulator")
+	return nil
+}
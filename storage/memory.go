@@ -0,0 +1,1231 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"cloud.google.com/go/spanner"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/models"
+	otelgo "github.com/cloudspannerecosystem/dynamodb-adapter/otel"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/pkg/errors"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/pkg/logger"
+	translator "github.com/cloudspannerecosystem/dynamodb-adapter/translator/utils"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/utils"
+	"google.golang.org/api/iterator"
+)
+
+// MemoryStorage keeps the tables in process memory. It implements the same
+// methods as Storage, stores the column values with the Spanner types of
+// models.TableDDL and evaluates the conditions with the same code, so the
+// adapter can run without a Spanner database, for example in unit tests.
+type MemoryStorage struct {
+	// txnMu serializes the read-write transactions, mu guards the tables
+	txnMu   sync.Mutex
+	mu      sync.RWMutex
+	tables  map[string]*memoryTable
+	indexes []IndexColumnSchema
+}
+
+type memoryTable struct {
+	keys []string
+	rows map[string]*memoryRow
+}
+
+type memoryRow struct {
+	// key holds the plain key values used to order the rows
+	key     []interface{}
+	columns map[string]interface{}
+}
+
+// memoryWrite is a mutation whose values are converted to the column types
+type memoryWrite struct {
+	table    *memoryTable
+	key      string
+	row      *memoryRow
+	isDelete bool
+}
+
+var (
+	memoryStorage     *MemoryStorage
+	memoryStorageOnce sync.Once
+)
+
+// GetMemoryStorageInstance - return the in-memory storage instance
+func GetMemoryStorageInstance() *MemoryStorage {
+	memoryStorageOnce.Do(func() {
+		if memoryStorage == nil {
+			memoryStorage = NewMemoryStorage()
+		}
+	})
+	return memoryStorage
+}
+
+// NewMemoryStorage returns an empty in-memory storage
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{tables: make(map[string]*memoryTable)}
+}
+
+// CreateTable creates an empty table with the given primary key columns.
+// The table is kept if it already exists.
+func (s *MemoryStorage) CreateTable(table string, keys ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.createTable(table, keys)
+}
+
+func (s *MemoryStorage) createTable(table string, keys []string) {
+	if _, ok := s.tables[table]; !ok {
+		s.tables[table] = &memoryTable{keys: keys, rows: make(map[string]*memoryRow)}
+	}
+}
+
+// Bootstrap creates the adapter metadata tables, the given tables and their
+// indexes and then writes the table metadata
+func (s *MemoryStorage) Bootstrap(ctx context.Context, tables map[string]models.BootstrapTable) error {
+	s.mu.Lock()
+	s.createTable("dynamodb_adapter_table_ddl", []string{"tableName", "column"})
+	s.createTable("dynamodb_adapter_config_manager", []string{"tableName"})
+	for _, tableName := range sortedTableNames(tables) {
+		table := tables[tableName]
+		spannerTable := utils.ChangeTableNameForSpanner(tableName)
+		keys := []string{table.PartitionKey}
+		if table.SortKey != "" {
+			keys = append(keys, table.SortKey)
+		}
+		s.createTable(spannerTable, keys)
+		s.addIndexes(spannerTable, table)
+	}
+	s.mu.Unlock()
+
+	rows := bootstrapMetadataRows(tables)
+	err := s.ReadWriteTransaction(ctx, nil, func(ctx context.Context, txn Transaction) error {
+		ms := make([]*Mutation, len(rows))
+		for i, row := range rows {
+			ms[i] = insertOrUpdateMutation("dynamodb_adapter_table_ddl", row)
+		}
+		return txn.BufferWrite(ms)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write the table metadata: %w", err)
+	}
+	logger.LogInfo(fmt.Sprintf("Bootstrapped metadata of %d tables", len(tables)))
+	return nil
+}
+
+// addIndexes records the index columns the same way utils.GenerateIndexDDL
+// declares them, so SpannerIndexSchema reports them like INFORMATION_SCHEMA
+func (s *MemoryStorage) addIndexes(spannerTable string, table models.BootstrapTable) {
+	attributeTypes := bootstrapAttributeTypes(table)
+	indexNames := make([]string, 0, len(table.Indices))
+	for indexName := range table.Indices {
+		indexNames = append(indexNames, indexName)
+	}
+	sort.Strings(indexNames)
+	for _, indexName := range indexNames {
+		spannerIndex := utils.ChangeTableNameForSpanner(indexName)
+		exists := false
+		for _, col := range s.indexes {
+			exists = exists || col.IndexName == spannerIndex
+		}
+		if exists {
+			continue
+		}
+		index := table.Indices[indexName]
+		column := func(name string, position int64) IndexColumnSchema {
+			return IndexColumnSchema{TableName: spannerTable, IndexName: spannerIndex, ColumnName: name, OrdinalPosition: position, IsNullFiltered: true}
+		}
+		keys := map[string]struct{}{table.PartitionKey: {}, table.SortKey: {}, index.PartitionKey: {}, index.SortKey: {}}
+		s.indexes = append(s.indexes, column(index.PartitionKey, 1))
+		if index.SortKey != "" {
+			s.indexes = append(s.indexes, column(index.SortKey, 2))
+		}
+		var storing []string
+		switch index.ProjectionType {
+		case models.ProjectionTypeKeysOnly:
+		case models.ProjectionTypeInclude:
+			for _, attr := range index.NonKeyAttributes {
+				if _, ok := attributeTypes[attr]; ok {
+					storing = append(storing, attr)
+				}
+			}
+		default:
+			for attr := range attributeTypes {
+				storing = append(storing, attr)
+			}
+		}
+		sort.Strings(storing)
+		for _, attr := range storing {
+			if _, ok := keys[attr]; !ok {
+				s.indexes = append(s.indexes, column(attr, 0))
+			}
+		}
+	}
+}
+
+// SpannerIndexSchema - returns the key and STORING columns of the indexes
+// created by Bootstrap
+func (s *MemoryStorage) SpannerIndexSchema(ctx context.Context) ([]IndexColumnSchema, error) {
+	otelgo.AddAnnotation(ctx, SpannerIndexSchemaAnnotation)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]IndexColumnSchema(nil), s.indexes...), nil
+}
+
+// memoryTransaction buffers the writes of a transaction until it commits
+type memoryTransaction struct {
+	s      *MemoryStorage
+	writes []memoryWrite
+}
+
+// ReadRow reads the committed row of the table
+func (t *memoryTransaction) ReadRow(ctx context.Context, table string, key spanner.Key, columns []string) (*spanner.Row, error) {
+	t.s.mu.RLock()
+	defer t.s.mu.RUnlock()
+	return t.s.readRow(table, key, columns)
+}
+
+// BufferWrite converts the mutations to the column types of their tables
+// and buffers them, reporting the errors Spanner reports on commit
+func (t *memoryTransaction) BufferWrite(ms []*Mutation) error {
+	t.s.mu.RLock()
+	defer t.s.mu.RUnlock()
+	for _, m := range ms {
+		w, err := t.s.prepareWrite(m)
+		if err != nil {
+			return err
+		}
+		t.writes = append(t.writes, w)
+	}
+	return nil
+}
+
+// ReadWriteTransaction runs f after the running read-write transactions and
+// applies the writes it buffers when it returns nil. Reads outside of the
+// transaction are not blocked until the writes are applied.
+func (s *MemoryStorage) ReadWriteTransaction(ctx context.Context, tables []string, f func(context.Context, Transaction) error) error {
+	s.txnMu.Lock()
+	defer s.txnMu.Unlock()
+	txn := &memoryTransaction{s: s}
+	if err := f(ctx, txn); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, w := range txn.writes {
+		if w.isDelete {
+			delete(w.table.rows, w.key)
+			continue
+		}
+		if existing, ok := w.table.rows[w.key]; ok {
+			for col, v := range w.row.columns {
+				existing.columns[col] = v
+			}
+		} else {
+			w.table.rows[w.key] = w.row
+		}
+	}
+	return nil
+}
+
+// ReadSnapshot holds back the read-write transactions until the returned
+// function is called, so the reads made in between see one snapshot
+func (s *MemoryStorage) ReadSnapshot(ctx context.Context, tables []string) (context.Context, func(), error) {
+	s.txnMu.Lock()
+	var once sync.Once
+	return ctx, func() { once.Do(s.txnMu.Unlock) }, nil
+}
+
+func (s *MemoryStorage) table(table string) (*memoryTable, error) {
+	t, ok := s.tables[table]
+	if !ok {
+		return nil, fmt.Errorf("table not found: %s", table)
+	}
+	return t, nil
+}
+
+// columnType returns the type of a column of the table. Number columns stored
+// as NUMERIC or STRING have the type of their storage.
+func columnType(table, column string) (string, error) {
+	t, ok := models.TableDDL[table][column]
+	if !ok {
+		return "", fmt.Errorf("column not found in table %s: %s", table, column)
+	}
+	if t == "N" || t == "NS" {
+		switch numberColumnType(table, column) {
+		case numberNumeric:
+			return map[string]string{"N": "NUMERIC", "NS": "ARRAY<NUMERIC>"}[t], nil
+		case numberString:
+			return map[string]string{"N": "S", "NS": "SS"}[t], nil
+		}
+	}
+	return t, nil
+}
+
+// encodeKey converts the key to the key column types and returns its encoding
+// and plain values
+func (s *MemoryStorage) encodeKey(table string, t *memoryTable, key spanner.Key) (string, []interface{}, error) {
+	if len(key) != len(t.keys) {
+		return "", nil, fmt.Errorf("wrong number of key parts for table %s: %v", table, key)
+	}
+	plain := make([]interface{}, len(key))
+	for i, part := range key {
+		colType, err := columnType(table, t.keys[i])
+		if err != nil {
+			return "", nil, err
+		}
+		v, err := memoryValue(colType, part)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid value for key column %s: %w", t.keys[i], err)
+		}
+		plain[i] = plainValue(v)
+	}
+	encoded, err := json.Marshal(plain)
+	if err != nil {
+		return "", nil, err
+	}
+	return string(encoded), plain, nil
+}
+
+func (s *MemoryStorage) prepareWrite(m *Mutation) (memoryWrite, error) {
+	t, err := s.table(m.Table)
+	if err != nil {
+		return memoryWrite{}, err
+	}
+	if m.Columns == nil {
+		encoded, _, err := s.encodeKey(m.Table, t, m.Key)
+		return memoryWrite{table: t, key: encoded, isDelete: true}, err
+	}
+	key := make(spanner.Key, len(t.keys))
+	for i, col := range t.keys {
+		v, ok := m.Columns[col]
+		if !ok {
+			return memoryWrite{}, fmt.Errorf("key column %s of table %s must be set", col, m.Table)
+		}
+		key[i] = v
+	}
+	encoded, plain, err := s.encodeKey(m.Table, t, key)
+	if err != nil {
+		return memoryWrite{}, err
+	}
+	row := &memoryRow{key: plain, columns: make(map[string]interface{}, len(m.Columns))}
+	for col, v := range m.Columns {
+		colType, err := columnType(m.Table, col)
+		if err != nil {
+			return memoryWrite{}, err
+		}
+		if v, err = documentColumnValue(m.Table, col, v); err != nil {
+			return memoryWrite{}, err
+		}
+		if row.columns[col], err = memoryValue(colType, v); err != nil {
+			return memoryWrite{}, fmt.Errorf("invalid value for column %s: %w", col, err)
+		}
+	}
+	return memoryWrite{table: t, key: encoded, row: row}, nil
+}
+
+// readRow returns the row with the requested columns, missing columns are NULL
+func (s *MemoryStorage) readRow(table string, key spanner.Key, columns []string) (*spanner.Row, error) {
+	t, err := s.table(table)
+	if err != nil {
+		return nil, err
+	}
+	encoded, _, err := s.encodeKey(table, t, key)
+	if err != nil {
+		return nil, err
+	}
+	row, ok := t.rows[encoded]
+	if !ok {
+		return nil, fmt.Errorf("row not found(Table: %v, PrimaryKey: %v): %w", table, key, spanner.ErrRowNotFound)
+	}
+	return row.spannerRow(table, columns)
+}
+
+func (r *memoryRow) spannerRow(table string, columns []string) (*spanner.Row, error) {
+	values, err := r.values(table, columns)
+	if err != nil {
+		return nil, err
+	}
+	return spanner.NewRow(columns, values)
+}
+
+// values returns the Spanner values of the columns of the row
+func (r *memoryRow) values(table string, columns []string) ([]interface{}, error) {
+	values := make([]interface{}, len(columns))
+	for i, col := range columns {
+		colType, err := columnType(table, col)
+		if err != nil {
+			return nil, err
+		}
+		v, ok := r.columns[col]
+		if !ok {
+			v, _ = memoryValue(colType, nil)
+		}
+		values[i] = v
+	}
+	return values, nil
+}
+
+// plainValues returns the values of the row for evaluating SQL expressions
+func (r *memoryRow) plainValues() map[string]interface{} {
+	values := make(map[string]interface{}, len(r.columns))
+	for col, v := range r.columns {
+		values[col] = plainValue(v)
+	}
+	return values
+}
+
+// sortedRows returns the rows of the table ordered by primary key
+func (t *memoryTable) sortedRows() []*memoryRow {
+	rows := make([]*memoryRow, 0, len(t.rows))
+	for _, row := range t.rows {
+		rows = append(rows, row)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		for k := range rows[i].key {
+			a, b := rows[i].key[k], rows[j].key[k]
+			if a == nil || b == nil {
+				if (a == nil) != (b == nil) {
+					return a == nil
+				}
+				continue
+			}
+			if c, _ := compareSQLValues(a, b); c != 0 {
+				return c < 0
+			}
+		}
+		return false
+	})
+	return rows
+}
+
+// readRows returns the rows with the given keys ordered by primary key, each once
+func (s *MemoryStorage) readRows(table string, keys []spanner.Key, columns []string) ([]*spanner.Row, error) {
+	t, err := s.table(table)
+	if err != nil {
+		return nil, err
+	}
+	wanted := make(map[string]struct{}, len(keys))
+	for _, key := range keys {
+		encoded, _, err := s.encodeKey(table, t, key)
+		if err != nil {
+			return nil, err
+		}
+		wanted[encoded] = struct{}{}
+	}
+	var rows []*spanner.Row
+	for _, row := range t.sortedRows() {
+		encoded, _ := json.Marshal(row.key)
+		if _, ok := wanted[string(encoded)]; !ok {
+			continue
+		}
+		r, err := row.spannerRow(table, columns)
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, r)
+	}
+	return rows, nil
+}
+
+// rowIterator returns a function with the signature of RowIterator.Next
+func rowIterator(rows []*spanner.Row, err error) func() (*spanner.Row, error) {
+	return func() (*spanner.Row, error) {
+		if err != nil {
+			return nil, err
+		}
+		if len(rows) == 0 {
+			return nil, iterator.Done
+		}
+		r := rows[0]
+		rows = rows[1:]
+		return r, nil
+	}
+}
+
+func batchKeys(pKeys, sKeys []interface{}) []spanner.Key {
+	keys := make([]spanner.Key, len(pKeys))
+	for i := range pKeys {
+		if len(sKeys) == 0 || sKeys[i] == nil {
+			keys[i] = spanner.Key{pKeys[i]}
+		} else {
+			keys[i] = spanner.Key{pKeys[i], sKeys[i]}
+		}
+	}
+	return keys
+}
+
+// SpannerBatchGet - fetch all rows
+func (s *MemoryStorage) SpannerBatchGet(ctx context.Context, tableName string, pKeys, sKeys []interface{}, projectionCols []string) ([]map[string]interface{}, error) {
+	otelgo.AddAnnotation(ctx, SpannerBatchGetAnnotation)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	tableName = utils.ChangeTableNameForSpanner(tableName)
+	if len(projectionCols) == 0 {
+		var ok bool
+		projectionCols, ok = models.TableColumnMap[tableName]
+		if !ok {
+			return nil, errors.New("ResourceNotFoundException", tableName)
+		}
+	}
+	colDDL, ok := models.TableDDL[tableName]
+	if !ok {
+		return nil, errors.New("ResourceNotFoundException", tableName)
+	}
+	s.mu.RLock()
+	rows, err := s.readRows(tableName, batchKeys(pKeys, sKeys), projectionCols)
+	s.mu.RUnlock()
+	if err != nil {
+		return nil, errors.New("ValidationException", err)
+	}
+	allRows := []map[string]interface{}{}
+	for _, r := range rows {
+		singleRow, _, err := parseRow(r, colDDL)
+		if err != nil {
+			return nil, err
+		}
+		if len(singleRow) > 0 {
+			allRows = append(allRows, singleRow)
+		}
+	}
+	return allRows, nil
+}
+
+// SpannerGet - get a single row
+func (s *MemoryStorage) SpannerGet(ctx context.Context, tableName string, pKeys, sKeys interface{}, projectionCols []string) (map[string]interface{}, map[string]interface{}, error) {
+	otelgo.AddAnnotation(ctx, SpannerGetAnnotation)
+	key := spanner.Key{pKeys}
+	if sKeys != nil {
+		key = spanner.Key{pKeys, sKeys}
+	}
+	tableName = utils.ChangeTableNameForSpanner(tableName)
+	if len(projectionCols) == 0 {
+		var ok bool
+		projectionCols, ok = models.TableColumnMap[tableName]
+		if !ok {
+			return nil, nil, errors.New("ResourceNotFoundException", tableName)
+		}
+	}
+	colDDL, ok := models.TableDDL[tableName]
+	if !ok {
+		return nil, nil, errors.New("ResourceNotFoundException", tableName)
+	}
+	s.mu.RLock()
+	row, err := s.readRow(tableName, key, projectionCols)
+	s.mu.RUnlock()
+	if err := errors.AssignError(err); err != nil {
+		return nil, nil, errors.New("ResourceNotFoundException", tableName, key, err)
+	}
+	return parseRow(row, colDDL)
+}
+
+// ExecuteSpannerQuery - runs the query on the in-memory tables
+func (s *MemoryStorage) ExecuteSpannerQuery(ctx context.Context, table string, cols []string, isCountQuery bool, stmt spanner.Statement) ([]map[string]interface{}, error) {
+	otelgo.AddAnnotation(ctx, ExecuteSpannerQueryAnnotation)
+	colDLL, ok := models.TableDDL[utils.ChangeTableNameForSpanner(table)]
+	if !ok {
+		return nil, errors.New("ResourceNotFoundException", table)
+	}
+	stmt, err := spannerParams(utils.ChangeTableNameForSpanner(table), stmt)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	rows, err := s.query(stmt)
+	s.mu.RUnlock()
+	items, err := queryRows(rowIterator(rows, err), colDLL, isCountQuery)
+	if report, ok := ctx.Value(queryStatsKey{}).(func(*QueryExplain)); ok && err == nil {
+		// the memory backend has no query plans or statistics
+		report(&QueryExplain{})
+	}
+	return items, err
+}
+
+// ExplainQuery checks that the query runs on the in-memory tables. The memory
+// backend has no query plans.
+func (s *MemoryStorage) ExplainQuery(ctx context.Context, table string, stmt spanner.Statement) (*QueryExplain, error) {
+	otelgo.AddAnnotation(ctx, ExplainQueryAnnotation)
+	stmt, err := spannerParams(utils.ChangeTableNameForSpanner(table), stmt)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if _, _, err := s.parseStatement(stmt); err != nil {
+		return nil, err
+	}
+	return &QueryExplain{}, nil
+}
+
+// parseStatement parses the statement, resolving its columns in models.TableDDL
+func (s *MemoryStorage) parseStatement(stmt spanner.Statement) (*sqlStatement, *memoryTable, error) {
+	var t *memoryTable
+	parsed, err := parseSQL(stmt.SQL, stmt.Params, func(table string) (func(string) (string, error), error) {
+		var err error
+		if t, err = s.table(table); err != nil {
+			return nil, err
+		}
+		ddl := models.TableDDL[table]
+		return func(name string) (string, error) {
+			if _, ok := ddl[name]; ok {
+				return name, nil
+			}
+			for col := range ddl {
+				if strings.EqualFold(col, name) {
+					return col, nil
+				}
+			}
+			return "", fmt.Errorf("unrecognized name: %s", name)
+		}, nil
+	})
+	return parsed, t, err
+}
+
+// matchingRows returns the rows of the table the WHERE clause selects, ordered by primary key
+func matchingRows(t *memoryTable, where sqlExpr) ([]*memoryRow, []map[string]interface{}, error) {
+	var rows []*memoryRow
+	var values []map[string]interface{}
+	for _, row := range t.sortedRows() {
+		plain := row.plainValues()
+		if where != nil {
+			ok, err := where(plain)
+			if err != nil {
+				return nil, nil, err
+			}
+			if ok != true {
+				continue
+			}
+		}
+		rows = append(rows, row)
+		values = append(values, plain)
+	}
+	return rows, values, nil
+}
+
+func (s *MemoryStorage) query(stmt spanner.Statement) ([]*spanner.Row, error) {
+	parsed, t, err := s.parseStatement(stmt)
+	if err != nil {
+		return nil, err
+	}
+	if parsed.kind != "SELECT" {
+		return nil, fmt.Errorf("%s statements must run in a read-write transaction", parsed.kind)
+	}
+	_, values, err := matchingRows(t, parsed.where)
+	if err != nil {
+		return nil, err
+	}
+	if len(parsed.aggregates) > 0 {
+		r, err := aggregateRow(parsed.aggregates, values)
+		return []*spanner.Row{r}, err
+	}
+	sortSQLRows(values, parsed.orderBy)
+	if parsed.offset >= int64(len(values)) {
+		values = nil
+	} else {
+		values = values[parsed.offset:]
+	}
+	if parsed.limit >= 0 && parsed.limit < int64(len(values)) {
+		values = values[:parsed.limit]
+	}
+
+	columns := parsed.columns
+	if columns == nil {
+		columns = models.TableColumnMap[parsed.table]
+	}
+	names := slices.Clone(columns)
+	for _, c := range parsed.computed {
+		names = append(names, c.column)
+	}
+	rows := make([]*spanner.Row, len(values))
+	for i, plain := range values {
+		row := &memoryRow{columns: make(map[string]interface{}, len(plain))}
+		for col, v := range plain {
+			colType, _ := columnType(parsed.table, col)
+			row.columns[col], _ = memoryValue(colType, v)
+		}
+		rowValues, err := row.values(parsed.table, columns)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range parsed.computed {
+			v, err := c.value(plain)
+			if err != nil {
+				return nil, err
+			}
+			switch v := v.(type) {
+			case nil:
+				rowValues = append(rowValues, spanner.NullBool{})
+			case bool:
+				rowValues = append(rowValues, v)
+			default:
+				return nil, fmt.Errorf("select expression %s must be BOOL, not %T", c.column, v)
+			}
+		}
+		if rows[i], err = spanner.NewRow(names, rowValues); err != nil {
+			return nil, err
+		}
+	}
+	return rows, nil
+}
+
+// InsertUpdateOrDeleteStatement runs a PartiQL UPDATE or DELETE statement
+// translated to SQL on the in-memory tables
+func (s *MemoryStorage) InsertUpdateOrDeleteStatement(ctx context.Context, query *translator.DeleteUpdateQueryMap) (map[string]interface{}, error) {
+	return nil, s.ReadWriteTransaction(ctx, []string{query.Table}, func(ctx context.Context, txn Transaction) error {
+		_, err := txn.ExecuteDML(ctx, query.Table, *buildStmt(query))
+		return err
+	})
+}
+
+// ExecuteDML buffers the writes of an UPDATE or DELETE statement on the
+// committed rows
+func (t *memoryTransaction) ExecuteDML(ctx context.Context, table string, stmt spanner.Statement) (int64, error) {
+	stmt, err := spannerParams(utils.ChangeTableNameForSpanner(table), stmt)
+	if err != nil {
+		return 0, err
+	}
+	t.s.mu.RLock()
+	parsed, mt, err := t.s.parseStatement(stmt)
+	var rows []*memoryRow
+	var values []map[string]interface{}
+	if err == nil {
+		rows, values, err = matchingRows(mt, parsed.where)
+	}
+	t.s.mu.RUnlock()
+	if err != nil {
+		return 0, err
+	}
+	var ms []*Mutation
+	for i, row := range rows {
+		switch parsed.kind {
+		case "DELETE":
+			ms = append(ms, deleteMutation(parsed.table, row.key))
+		case "UPDATE":
+			columns := make(map[string]interface{}, len(mt.keys)+len(parsed.set))
+			for k, col := range mt.keys {
+				columns[col] = row.key[k]
+			}
+			for _, set := range parsed.set {
+				if columns[set.column], err = set.value(values[i]); err != nil {
+					return 0, err
+				}
+			}
+			ms = append(ms, insertOrUpdateMutation(parsed.table, columns))
+		default:
+			return 0, fmt.Errorf("%s statements are not DML", parsed.kind)
+		}
+	}
+	return int64(len(ms)), t.BufferWrite(ms)
+}
+
+// Query runs a SELECT statement on the committed rows
+func (t *memoryTransaction) Query(ctx context.Context, table string, stmt spanner.Statement) ([]map[string]interface{}, error) {
+	spannerTable := utils.ChangeTableNameForSpanner(table)
+	stmt, err := spannerParams(spannerTable, stmt)
+	if err != nil {
+		return nil, err
+	}
+	t.s.mu.RLock()
+	rows, err := t.s.query(stmt)
+	t.s.mu.RUnlock()
+	return queryRows(rowIterator(rows, err), models.TableDDL[spannerTable], false)
+}
+
+// SpannerPut - put a single object
+func (s *MemoryStorage) SpannerPut(ctx context.Context, table string, m map[string]interface{}, eval *models.Eval, expr *models.UpdateExpressionCondition, spannerRow map[string]interface{}) (map[string]interface{}, error) {
+	otelgo.AddAnnotation(ctx, SpannerPutAnnotation)
+	update := map[string]interface{}{}
+	err := s.ReadWriteTransaction(ctx, []string{table}, func(ctx context.Context, t Transaction) error {
+		var columns map[string]interface{}
+		var err error
+		update, columns, err = putColumns(ctx, t, table, m, eval, expr)
+		if err != nil {
+			return err
+		}
+		return t.BufferWrite([]*Mutation{insertOrUpdateMutation(utils.ChangeTableNameForSpanner(table), columns)})
+	})
+	return update, err
+}
+
+// SpannerDelete - this will delete the data
+func (s *MemoryStorage) SpannerDelete(ctx context.Context, table string, m map[string]interface{}, eval *models.Eval, expr *models.UpdateExpressionCondition) error {
+	otelgo.AddAnnotation(ctx, SpannerDeleteAnnotation)
+	return s.ReadWriteTransaction(ctx, []string{table}, func(ctx context.Context, t Transaction) error {
+		key, err := deleteKey(ctx, t, table, m, eval, expr)
+		if err != nil {
+			return err
+		}
+		return t.BufferWrite([]*Mutation{deleteMutation(utils.ChangeTableNameForSpanner(table), key)})
+	})
+}
+
+// SpannerBatchDelete - this delete the data in batch
+func (s *MemoryStorage) SpannerBatchDelete(ctx context.Context, table string, keys []map[string]interface{}) error {
+	otelgo.AddAnnotation(ctx, SpannerBatchDeleteAnnotation)
+	spannerKeys, err := batchDeleteKeys(table, keys)
+	if err != nil {
+		return err
+	}
+	table = utils.ChangeTableNameForSpanner(table)
+	ms := make([]*Mutation, len(spannerKeys))
+	for i, key := range spannerKeys {
+		ms[i] = deleteMutation(table, key)
+	}
+	err = s.ReadWriteTransaction(ctx, []string{table}, func(ctx context.Context, t Transaction) error {
+		return t.BufferWrite(ms)
+	})
+	if err != nil {
+		return errors.New("ResourceNotFoundException", err)
+	}
+	return nil
+}
+
+// SpannerAdd - Add functionality like update attribute
+func (s *MemoryStorage) SpannerAdd(ctx context.Context, table string, m map[string]interface{}, eval *models.Eval, expr *models.UpdateExpressionCondition) (map[string]interface{}, error) {
+	otelgo.AddAnnotation(ctx, SpannerAddAnnotation)
+	updatedObj := map[string]interface{}{}
+	err := s.ReadWriteTransaction(ctx, []string{table}, func(ctx context.Context, t Transaction) error {
+		var columns map[string]interface{}
+		var err error
+		updatedObj, columns, err = addColumns(ctx, t, table, m, eval, expr)
+		if err != nil {
+			return err
+		}
+		err = t.BufferWrite([]*Mutation{insertOrUpdateMutation(utils.ChangeTableNameForSpanner(table), columns)})
+		if err != nil {
+			return errors.New("ResourceNotFoundException", err)
+		}
+		return nil
+	})
+	return updatedObj, err
+}
+
+// SpannerDel - removes the elements of sets like update attribute
+func (s *MemoryStorage) SpannerDel(ctx context.Context, table string, m map[string]interface{}, eval *models.Eval, expr *models.UpdateExpressionCondition) error {
+	otelgo.AddAnnotation(ctx, SpannerDelAnnotation)
+	return s.ReadWriteTransaction(ctx, []string{table}, func(ctx context.Context, t Transaction) error {
+		columns, err := delColumns(ctx, t, table, m, eval, expr)
+		if err != nil {
+			return err
+		}
+		err = t.BufferWrite([]*Mutation{insertOrUpdateMutation(utils.ChangeTableNameForSpanner(table), columns)})
+		if err != nil {
+			return errors.New("ResourceNotFoundException", err)
+		}
+		return nil
+	})
+}
+
+// SpannerRemove - Remove functionality like update attribute
+func (s *MemoryStorage) SpannerRemove(ctx context.Context, table string, m map[string]interface{}, eval *models.Eval, expr *models.UpdateExpressionCondition, colsToRemove []string, oldRes map[string]interface{}) error {
+	otelgo.AddAnnotation(ctx, SpannerRemoveAnnotation)
+	return s.ReadWriteTransaction(ctx, []string{table}, func(ctx context.Context, t Transaction) error {
+		columns, err := removeColumns(ctx, t, table, m, eval, expr, colsToRemove, oldRes)
+		if err != nil {
+			return err
+		}
+		err = t.BufferWrite([]*Mutation{insertOrUpdateMutation(utils.ChangeTableNameForSpanner(table), columns)})
+		if err != nil {
+			return errors.New("ResourceNotFoundException", err)
+		}
+		return nil
+	})
+}
+
+// SpannerBatchPut - this insert or update data in batch
+func (s *MemoryStorage) SpannerBatchPut(ctx context.Context, table string, m []map[string]interface{}, spannerRow []map[string]interface{}) error {
+	otelgo.AddAnnotation(ctx, SpannerBatchPutAnnotation)
+	table = utils.ChangeTableNameForSpanner(table)
+	ddl := models.TableDDL[table]
+	ms := make([]*Mutation, len(m))
+	for i := 0; i < len(m); i++ {
+		if err := batchPutColumns(ddl, m[i]); err != nil {
+			return err
+		}
+		ms[i] = insertOrUpdateMutation(table, m[i])
+	}
+	err := s.ReadWriteTransaction(ctx, []string{table}, func(ctx context.Context, t Transaction) error {
+		return t.BufferWrite(ms)
+	})
+	if err != nil {
+		return errors.New("ResourceNotFoundException", err.Error())
+	}
+	return nil
+}
+
+// SpannerBatchWrite puts and deletes rows of the table in batch, applying
+// every row on its own like Storage.SpannerBatchWrite
+func (s *MemoryStorage) SpannerBatchWrite(ctx context.Context, table string, puts, deletes []map[string]interface{}) ([]int, []int, error) {
+	otelgo.AddAnnotation(ctx, SpannerBatchWriteAnnotation)
+	ms, err := batchWriteMutations(table, puts, deletes)
+	if err != nil {
+		return nil, nil, err
+	}
+	table = utils.ChangeTableNameForSpanner(table)
+	applied := make([]bool, len(ms))
+	for i, m := range ms {
+		err := s.ReadWriteTransaction(ctx, []string{table}, func(ctx context.Context, t Transaction) error {
+			return t.BufferWrite([]*Mutation{m})
+		})
+		if err != nil {
+			logger.LogError("batch write of ", table, " failed: ", err)
+			continue
+		}
+		applied[i] = true
+	}
+	failedPuts, failedDeletes := batchWriteFailures(applied, len(puts))
+	return failedPuts, failedDeletes, nil
+}
+
+// SpannerTransactGetItems reads the items of several tables at a single
+// point in time, see Storage.SpannerTransactGetItems
+func (s *MemoryStorage) SpannerTransactGetItems(ctx context.Context, tableProjectionCols map[string][]string, pValues map[string]interface{}, sValues map[string]interface{}) ([]map[string]interface{}, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	allRows := []map[string]interface{}{}
+	for tableName, projectionCols := range tableProjectionCols {
+		spannerTable := utils.ChangeTableNameForSpanner(tableName)
+		colDDL, ok := models.TableDDL[spannerTable]
+		if !ok {
+			return nil, errors.New("ResourceNotFoundException", tableName)
+		}
+		pKeys, _ := pValues[tableName].([]interface{})
+		sKeys, _ := sValues[tableName].([]interface{})
+		if len(projectionCols) == 0 {
+			projectionCols, ok = models.TableColumnMap[spannerTable]
+			if !ok {
+				return nil, errors.New("ResourceNotFoundException", tableName)
+			}
+		}
+		rows, err := s.readRows(spannerTable, batchKeys(pKeys, sKeys), projectionCols)
+		if err != nil {
+			return nil, errors.New("ValidationException", err)
+		}
+		for _, r := range rows {
+			singleRow, _, err := parseRow(r, colDDL)
+			if err != nil {
+				return nil, err
+			}
+			if len(singleRow) > 0 {
+				allRows = append(allRows, map[string]interface{}{
+					"Item":      singleRow,
+					"TableName": tableName,
+				})
+			}
+		}
+	}
+	return allRows, nil
+}
+
+// SpannerTransactWritePut returns the mutation of a put in a transaction,
+// see Storage.SpannerTransactWritePut
+func (s *MemoryStorage) SpannerTransactWritePut(ctx context.Context, table string, m map[string]interface{}, eval *models.Eval, expr *models.UpdateExpressionCondition, txn Transaction, oldRes map[string]interface{}) (map[string]interface{}, *Mutation, error) {
+	return Storage{}.SpannerTransactWritePut(ctx, table, m, eval, expr, txn, oldRes)
+}
+
+// TransactWriteSpannerDel returns the mutation of a DELETE update in a transaction
+func (s *MemoryStorage) TransactWriteSpannerDel(ctx context.Context, table string, m map[string]interface{}, eval *models.Eval, expr *models.UpdateExpressionCondition, txn Transaction) (*Mutation, error) {
+	return Storage{}.TransactWriteSpannerDel(ctx, table, m, eval, expr, txn)
+}
+
+// TransactWriteSpannerAdd returns the mutation of an ADD update in a transaction
+func (s *MemoryStorage) TransactWriteSpannerAdd(ctx context.Context, table string, m map[string]interface{}, eval *models.Eval, expr *models.UpdateExpressionCondition, txn Transaction) (map[string]interface{}, *Mutation, error) {
+	return Storage{}.TransactWriteSpannerAdd(ctx, table, m, eval, expr, txn)
+}
+
+// TransactWriteSpannerRemove returns the mutation of a REMOVE update in a transaction
+func (s *MemoryStorage) TransactWriteSpannerRemove(ctx context.Context, table string, m map[string]interface{}, eval *models.Eval, expr *models.UpdateExpressionCondition, colsToRemove []string, txn Transaction) (*Mutation, error) {
+	return Storage{}.TransactWriteSpannerRemove(ctx, table, m, eval, expr, colsToRemove, txn)
+}
+
+// TransactWriteSpannerDelete returns the mutation of a delete in a transaction
+func (s *MemoryStorage) TransactWriteSpannerDelete(ctx context.Context, table string, m map[string]interface{}, eval *models.Eval, expr *models.UpdateExpressionCondition, txn Transaction) (*Mutation, error) {
+	return Storage{}.TransactWriteSpannerDelete(ctx, table, m, eval, expr, txn)
+}
+
+// memoryColumnType maps the Spanner types of the metadata tables to the
+// DynamoDB types used in models.TableDDL
+func memoryColumnType(t string) string {
+	switch strings.ToUpper(t) {
+	case "STRING(MAX)":
+		return "S"
+	case "FLOAT64", "INT64":
+		return "N"
+	case "BYTES(MAX)":
+		return "B"
+	case "JSON":
+		return "M"
+	case "ARRAY<STRING(MAX)>":
+		return "SS"
+	case "ARRAY<FLOAT64>":
+		return "NS"
+	case "ARRAY<BYTES(MAX)>":
+		return "BS"
+	}
+	return t
+}
+
+// memoryValue converts a value written to a column of the type to the value
+// Spanner returns for it, rejecting the values Spanner rejects
+func memoryValue(colType string, v interface{}) (interface{}, error) {
+	colType = memoryColumnType(colType)
+	if n, ok := v.(spanner.NullableValue); ok && n.IsNull() {
+		v = nil
+	}
+	switch colType {
+	case "S", "NULL":
+		switch v := v.(type) {
+		case nil:
+			return spanner.NullString{}, nil
+		case string:
+			return spanner.NullString{StringVal: v, Valid: true}, nil
+		case models.Number:
+			return spanner.NullString{StringVal: v.SortableString(), Valid: true}, nil
+		case spanner.NullString:
+			return v, nil
+		}
+	case "NUMERIC":
+		if v == nil {
+			return spanner.NullNumeric{}, nil
+		}
+		if n, ok := v.(spanner.NullNumeric); ok {
+			return n, nil
+		}
+		if n, ok := models.ToNumber(v); ok {
+			if _, err := spannerNumber(numberNumeric, n); err != nil {
+				return nil, err
+			}
+			return spanner.NullNumeric{Numeric: *n.Rat(), Valid: true}, nil
+		}
+	case "ARRAY<NUMERIC>":
+		if v == nil {
+			return []spanner.NullNumeric(nil), nil
+		}
+		if values, ok := toSlice(v); ok {
+			set := make([]spanner.NullNumeric, len(values))
+			for i, value := range values {
+				n, ok := models.ToNumber(value)
+				if !ok {
+					return nil, fmt.Errorf("%T is not a valid element of %s", value, colType)
+				}
+				if _, err := spannerNumber(numberNumeric, n); err != nil {
+					return nil, err
+				}
+				set[i] = spanner.NullNumeric{Numeric: *n.Rat(), Valid: true}
+			}
+			return set, nil
+		}
+	case "N":
+		if v == nil {
+			return spanner.NullFloat64{}, nil
+		}
+		if f, ok := memoryNumber(v); ok {
+			return spanner.NullFloat64{Float64: f, Valid: true}, nil
+		}
+		if f, ok := v.(spanner.NullFloat64); ok {
+			return f, nil
+		}
+	case "BOOL":
+		switch v := v.(type) {
+		case nil:
+			return spanner.NullBool{}, nil
+		case bool:
+			return spanner.NullBool{Bool: v, Valid: true}, nil
+		case spanner.NullBool:
+			return v, nil
+		}
+	case "B":
+		switch v := v.(type) {
+		case nil:
+			return []byte(nil), nil
+		case []byte:
+			return v, nil
+		case string:
+			return []byte(v), nil
+		}
+	case "SS":
+		if v == nil {
+			return []spanner.NullString(nil), nil
+		}
+		if values, ok := toSlice(v); ok {
+			set := make([]spanner.NullString, len(values))
+			for i, value := range values {
+				if n, ok := value.(models.Number); ok {
+					value = n.SortableString()
+				}
+				s, ok := value.(string)
+				if !ok {
+					return nil, fmt.Errorf("%T is not a valid element of %s", value, colType)
+				}
+				set[i] = spanner.NullString{StringVal: s, Valid: true}
+			}
+			return set, nil
+		}
+	case "NS":
+		if v == nil {
+			return []spanner.NullFloat64(nil), nil
+		}
+		if values, ok := toSlice(v); ok {
+			set := make([]spanner.NullFloat64, len(values))
+			for i, value := range values {
+				f, ok := memoryNumber(value)
+				if !ok {
+					return nil, fmt.Errorf("%T is not a valid element of %s", value, colType)
+				}
+				set[i] = spanner.NullFloat64{Float64: f, Valid: true}
+			}
+			return set, nil
+		}
+	case "BS":
+		if v == nil {
+			return [][]byte(nil), nil
+		}
+		if values, ok := toSlice(v); ok {
+			set := make([][]byte, len(values))
+			for i, value := range values {
+				switch value := value.(type) {
+				case []byte:
+					set[i] = value
+				case string:
+					set[i] = []byte(value)
+				default:
+					return nil, fmt.Errorf("%T is not a valid element of %s", value, colType)
+				}
+			}
+			return set, nil
+		}
+	case "L", "M":
+		switch v := v.(type) {
+		case nil:
+			return spanner.NullJSON{}, nil
+		case spanner.NullJSON:
+			return v, nil
+		case string, []byte:
+			var value interface{}
+			data, ok := v.([]byte)
+			if !ok {
+				data = []byte(v.(string))
+			}
+			if err := json.Unmarshal(data, &value); err != nil {
+				return nil, fmt.Errorf("invalid JSON: %w", err)
+			}
+			return spanner.NullJSON{Value: value, Valid: true}, nil
+		default:
+			// Round trip through JSON like Spanner, so reads decode to plain values
+			data, err := json.Marshal(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid JSON: %w", err)
+			}
+			var value interface{}
+			if err := json.Unmarshal(data, &value); err != nil {
+				return nil, err
+			}
+			return spanner.NullJSON{Value: value, Valid: true}, nil
+		}
+	default:
+		return nil, fmt.Errorf("unsupported column type %s", colType)
+	}
+	return nil, fmt.Errorf("%T is not a valid value of %s", v, colType)
+}
+
+func memoryNumber(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case models.Number:
+		return v.Float64(), true
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+// plainValue returns the Go value of a column value, nil for NULL
+func plainValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case spanner.NullString:
+		if v.Valid {
+			return v.StringVal
+		}
+	case spanner.NullFloat64:
+		if v.Valid {
+			return v.Float64
+		}
+	case spanner.NullNumeric:
+		if v.Valid {
+			return new(big.Rat).Set(&v.Numeric)
+		}
+	case spanner.NullBool:
+		if v.Valid {
+			return v.Bool
+		}
+	case []byte:
+		if v != nil {
+			return bytes.Clone(v)
+		}
+	case spanner.NullJSON:
+		if v.Valid {
+			return v.Value
+		}
+	case []spanner.NullString:
+		if v != nil {
+			values := make([]interface{}, len(v))
+			for i := range v {
+				values[i] = v[i].StringVal
+			}
+			return values
+		}
+	case []spanner.NullFloat64:
+		if v != nil {
+			values := make([]interface{}, len(v))
+			for i := range v {
+				values[i] = v[i].Float64
+			}
+			return values
+		}
+	case []spanner.NullNumeric:
+		if v != nil {
+			values := make([]interface{}, len(v))
+			for i := range v {
+				values[i] = new(big.Rat).Set(&v[i].Numeric)
+			}
+			return values
+		}
+	case [][]byte:
+		if v != nil {
+			values := make([]interface{}, len(v))
+			for i := range v {
+				values[i] = v[i]
+			}
+			return values
+		}
+	}
+	return nil
+}
@@ -0,0 +1,1301 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"cloud.google.com/go/spanner"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/models"
+)
+
+// The memory backend runs the SQL the adapter sends to Spanner. Only the
+// subset of GoogleSQL generated by the Query, Scan and PartiQL translations
+// is supported: single table SELECT, UPDATE and DELETE statements with
+// AND/OR/NOT, comparisons, BETWEEN, IN, IS NULL, STARTS_WITH, STRPOS,
+// CHAR_LENGTH, BYTE_LENGTH, ARRAY_LENGTH, JSON_VALUE, JSON_QUERY, BOOL,
+// SAFE_CAST to NUMERIC and COALESCE. Selected expressions must be BOOL, or
+// the aggregates COUNT, SUM, MIN, MAX and AVG.
+
+type sqlTokenKind int
+
+const (
+	sqlEOF sqlTokenKind = iota
+	sqlIdent
+	sqlQuotedIdent
+	sqlString
+	sqlNumber
+	sqlParam
+	sqlSymbol
+)
+
+type sqlToken struct {
+	kind sqlTokenKind
+	text string
+}
+
+// tokenizeSQL splits a statement into tokens. Table hints such as
+// @{FORCE_INDEX=...} are dropped because every read scans the base table.
+func tokenizeSQL(sql string) ([]sqlToken, error) {
+	var tokens []sqlToken
+	for i := 0; i < len(sql); {
+		c := sql[i]
+		switch {
+		case unicode.IsSpace(rune(c)):
+			i++
+		case c == '@' && i+1 < len(sql) && sql[i+1] == '{':
+			end := strings.IndexByte(sql[i:], '}')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated hint at %d", i)
+			}
+			i += end + 1
+		case c == '@':
+			j := i + 1
+			for j < len(sql) && isIdentChar(sql[j]) {
+				j++
+			}
+			tokens = append(tokens, sqlToken{sqlParam, sql[i+1 : j]})
+			i = j
+		case c == '`' || c == '\'' || c == '"':
+			j := i + 1
+			var b strings.Builder
+			for ; j < len(sql) && sql[j] != c; j++ {
+				if sql[j] == '\\' && j+1 < len(sql) {
+					j++
+				}
+				b.WriteByte(sql[j])
+			}
+			if j >= len(sql) {
+				return nil, fmt.Errorf("unterminated literal at %d", i)
+			}
+			kind := sqlString
+			if c == '`' {
+				kind = sqlQuotedIdent
+			}
+			tokens = append(tokens, sqlToken{kind, b.String()})
+			i = j + 1
+		case c >= '0' && c <= '9' || c == '.' && i+1 < len(sql) && sql[i+1] >= '0' && sql[i+1] <= '9':
+			j := i
+			for j < len(sql) && (sql[j] >= '0' && sql[j] <= '9' || sql[j] == '.' || sql[j] == 'e' || sql[j] == 'E' ||
+				(sql[j] == '-' || sql[j] == '+') && (sql[j-1] == 'e' || sql[j-1] == 'E')) {
+				j++
+			}
+			tokens = append(tokens, sqlToken{sqlNumber, sql[i:j]})
+			i = j
+		case isIdentChar(c):
+			j := i
+			for j < len(sql) && isIdentChar(sql[j]) {
+				j++
+			}
+			tokens = append(tokens, sqlToken{sqlIdent, sql[i:j]})
+			i = j
+		default:
+			symbol := string(c)
+			if i+1 < len(sql) {
+				switch two := sql[i : i+2]; two {
+				case "<=", ">=", "<>", "!=":
+					symbol = two
+				}
+			}
+			if !strings.Contains("(),;*=<>!.-", symbol[:1]) {
+				return nil, fmt.Errorf("syntax error: unexpected %q at %d", symbol, i)
+			}
+			tokens = append(tokens, sqlToken{sqlSymbol, symbol})
+			i += len(symbol)
+		}
+	}
+	return append(tokens, sqlToken{kind: sqlEOF}), nil
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// sqlExpr evaluates an expression against a row of plain column values
+type sqlExpr func(row map[string]interface{}) (interface{}, error)
+
+type sqlOrder struct {
+	column string
+	desc   bool
+}
+
+type sqlAssignment struct {
+	column string
+	value  sqlExpr
+}
+
+// sqlStatement is a parsed SELECT, UPDATE or DELETE statement
+type sqlStatement struct {
+	kind  string
+	table string
+	// columns projected by a SELECT, nil for SELECT *
+	columns []string
+	// expressions selected as "expr AS alias", following the columns
+	computed []sqlAssignment
+	// aggregates of a SELECT computed over the rows it selects
+	aggregates []sqlAggregate
+	where      sqlExpr
+	orderBy    []sqlOrder
+	limit      int64
+	offset     int64
+	set        []sqlAssignment
+}
+
+// sqlAggregate is an aggregate function of a select list. arg is nil for
+// COUNT(*).
+type sqlAggregate struct {
+	function string
+	arg      sqlExpr
+	alias    string
+}
+
+var sqlAggregateFunctions = []string{"COUNT", "SUM", "MIN", "MAX", "AVG"}
+
+type sqlParser struct {
+	tokens []sqlToken
+	pos    int
+	params map[string]interface{}
+	// columnOf resolves a column reference of the statement table
+	columnOf func(name string) (string, error)
+}
+
+func (p *sqlParser) peek() sqlToken {
+	return p.tokens[p.pos]
+}
+
+func (p *sqlParser) next() sqlToken {
+	t := p.tokens[p.pos]
+	if t.kind != sqlEOF {
+		p.pos++
+	}
+	return t
+}
+
+// keyword reports whether the next token is the keyword and consumes it
+func (p *sqlParser) keyword(words ...string) bool {
+	for i, w := range words {
+		t := p.tokens[min(p.pos+i, len(p.tokens)-1)]
+		if t.kind != sqlIdent || !strings.EqualFold(t.text, w) {
+			return false
+		}
+	}
+	p.pos += len(words)
+	return true
+}
+
+func (p *sqlParser) symbol(s string) bool {
+	if t := p.peek(); t.kind == sqlSymbol && t.text == s {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *sqlParser) expectSymbol(s string) error {
+	if !p.symbol(s) {
+		return fmt.Errorf("syntax error: expected %q but got %q", s, p.peek().text)
+	}
+	return nil
+}
+
+func (p *sqlParser) identifier() (string, error) {
+	t := p.next()
+	if t.kind != sqlIdent && t.kind != sqlQuotedIdent {
+		return "", fmt.Errorf("syntax error: expected identifier but got %q", t.text)
+	}
+	return t.text, nil
+}
+
+// path reads a possibly table-qualified column reference
+func (p *sqlParser) path() (string, error) {
+	name, err := p.identifier()
+	if err != nil {
+		return "", err
+	}
+	for p.symbol(".") {
+		part, err := p.identifier()
+		if err != nil {
+			return "", err
+		}
+		name += "." + part
+	}
+	return name, nil
+}
+
+// parseSQL parses a statement. The table of the statement is resolved before
+// the column references, which are checked against the columns of the table.
+func parseSQL(sql string, params map[string]interface{}, columnsOf func(table string) (func(name string) (string, error), error)) (*sqlStatement, error) {
+	tokens, err := tokenizeSQL(sql)
+	if err != nil {
+		return nil, err
+	}
+	p := &sqlParser{tokens: tokens, params: params}
+	stmt := &sqlStatement{limit: -1}
+
+	resolve := func(table string) error {
+		stmt.table = table
+		columnOf, err := columnsOf(table)
+		if err != nil {
+			return err
+		}
+		p.columnOf = func(name string) (string, error) {
+			if i := strings.LastIndex(name, "."); i >= 0 && strings.EqualFold(name[:i], table) {
+				name = name[i+1:]
+			}
+			return columnOf(name)
+		}
+		return nil
+	}
+
+	switch {
+	case p.keyword("SELECT"):
+		stmt.kind = "SELECT"
+		err = p.parseSelect(stmt, resolve)
+	case p.keyword("UPDATE"):
+		stmt.kind = "UPDATE"
+		err = p.parseUpdate(stmt, resolve)
+	case p.keyword("DELETE"):
+		stmt.kind = "DELETE"
+		p.keyword("FROM")
+		var table string
+		if table, err = p.identifier(); err == nil {
+			err = resolve(table)
+		}
+		if err == nil && p.keyword("WHERE") {
+			stmt.where, err = p.expr()
+		}
+	default:
+		return nil, fmt.Errorf("syntax error: unsupported statement %q", p.peek().text)
+	}
+	if err != nil {
+		return nil, err
+	}
+	p.symbol(";")
+	if t := p.peek(); t.kind != sqlEOF {
+		return nil, fmt.Errorf("syntax error: unexpected %q", t.text)
+	}
+	if stmt.kind != "SELECT" && stmt.where == nil {
+		return nil, fmt.Errorf("%s must have a WHERE clause", stmt.kind)
+	}
+	return stmt, nil
+}
+
+func (p *sqlParser) parseSelect(stmt *sqlStatement, resolve func(string) error) error {
+	// The select list is read after the FROM clause so its columns can be resolved
+	start := p.pos
+	depth := 0
+	for t := p.peek(); t.kind != sqlEOF && !(depth == 0 && t.kind == sqlIdent && strings.EqualFold(t.text, "FROM")); t = p.peek() {
+		if t.kind == sqlSymbol && t.text == "(" {
+			depth++
+		} else if t.kind == sqlSymbol && t.text == ")" {
+			depth--
+		}
+		p.next()
+	}
+	if !p.keyword("FROM") {
+		return fmt.Errorf("syntax error: expected FROM")
+	}
+	table, err := p.identifier()
+	if err != nil {
+		return err
+	}
+	if err := resolve(table); err != nil {
+		return err
+	}
+	end := p.pos
+	p.pos = start
+	if err := p.parseSelectList(stmt); err != nil {
+		return err
+	}
+	if !p.keyword("FROM") {
+		return fmt.Errorf("syntax error: unexpected %q in select list", p.peek().text)
+	}
+	p.pos = end
+
+	if p.keyword("WHERE") {
+		if stmt.where, err = p.expr(); err != nil {
+			return err
+		}
+	}
+	if p.keyword("ORDER", "BY") {
+		for {
+			name, err := p.path()
+			if err != nil {
+				return err
+			}
+			column, err := p.columnOf(name)
+			if err != nil {
+				return err
+			}
+			order := sqlOrder{column: column}
+			if p.keyword("DESC") {
+				order.desc = true
+			} else {
+				p.keyword("ASC")
+			}
+			stmt.orderBy = append(stmt.orderBy, order)
+			if !p.symbol(",") {
+				break
+			}
+		}
+	}
+	if p.keyword("LIMIT") {
+		if stmt.limit, err = p.count(); err != nil {
+			return err
+		}
+	}
+	if p.keyword("OFFSET") {
+		if stmt.offset, err = p.count(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *sqlParser) parseSelectList(stmt *sqlStatement) error {
+	if p.symbol("*") {
+		return nil
+	}
+	for {
+		if aggregate, ok, err := p.aggregate(); err != nil {
+			return err
+		} else if ok {
+			stmt.aggregates = append(stmt.aggregates, aggregate)
+			if !p.symbol(",") {
+				break
+			}
+			continue
+		}
+		start := p.pos
+		name, err := p.path()
+		if t := p.peek(); err == nil && (t.kind == sqlEOF || t.kind == sqlSymbol && t.text == "," || t.kind == sqlIdent && strings.EqualFold(t.text, "FROM")) {
+			column, err := p.columnOf(name)
+			if err != nil {
+				return err
+			}
+			stmt.columns = append(stmt.columns, column)
+		} else {
+			p.pos = start
+			value, err := p.expr()
+			if err != nil {
+				return err
+			}
+			if !p.keyword("AS") {
+				return fmt.Errorf("syntax error: expected AS after a select expression but got %q", p.peek().text)
+			}
+			alias, err := p.identifier()
+			if err != nil {
+				return err
+			}
+			stmt.computed = append(stmt.computed, sqlAssignment{column: alias, value: value})
+		}
+		if !p.symbol(",") {
+			break
+		}
+	}
+	if len(stmt.aggregates) > 0 && (len(stmt.columns) > 0 || len(stmt.computed) > 0) {
+		return fmt.Errorf("SELECT list expression references a column which is neither grouped nor aggregated")
+	}
+	return nil
+}
+
+// aggregate reads an aggregate function of the select list, named after the
+// function when it has no alias
+func (p *sqlParser) aggregate() (sqlAggregate, bool, error) {
+	t, next := p.peek(), p.tokens[min(p.pos+1, len(p.tokens)-1)]
+	name := strings.ToUpper(t.text)
+	if t.kind != sqlIdent || !slices.Contains(sqlAggregateFunctions, name) || next.kind != sqlSymbol || next.text != "(" {
+		return sqlAggregate{}, false, nil
+	}
+	p.pos += 2
+	aggregate := sqlAggregate{function: name, alias: strings.ToLower(name)}
+	if name != "COUNT" || !p.symbol("*") {
+		var err error
+		if aggregate.arg, err = p.expr(); err != nil {
+			return aggregate, true, err
+		}
+	}
+	if err := p.expectSymbol(")"); err != nil {
+		return aggregate, true, err
+	}
+	if p.keyword("AS") {
+		var err error
+		if aggregate.alias, err = p.identifier(); err != nil {
+			return aggregate, true, err
+		}
+	}
+	return aggregate, true, nil
+}
+
+// aggregateRow computes the aggregates of a select list over the rows. Like
+// Spanner, NULL values are skipped and aggregates other than COUNT are NULL
+// when there is no value.
+func aggregateRow(aggregates []sqlAggregate, rows []map[string]interface{}) (*spanner.Row, error) {
+	names := make([]string, len(aggregates))
+	values := make([]interface{}, len(aggregates))
+	for i, aggregate := range aggregates {
+		names[i] = aggregate.alias
+		var count int64
+		var result interface{}
+		for _, row := range rows {
+			var v interface{} = true
+			if aggregate.arg != nil {
+				var err error
+				if v, err = aggregate.arg(row); err != nil {
+					return nil, err
+				}
+				if v == nil {
+					continue
+				}
+			}
+			count++
+			switch aggregate.function {
+			case "MIN", "MAX":
+				if result == nil {
+					result = v
+					continue
+				}
+				c, err := compareSQLValues(v, result)
+				if err != nil {
+					return nil, err
+				}
+				if aggregate.function == "MIN" && c < 0 || aggregate.function == "MAX" && c > 0 {
+					result = v
+				}
+			case "SUM", "AVG":
+				var err error
+				if result, err = sqlSum(aggregate.function, result, v); err != nil {
+					return nil, err
+				}
+			}
+		}
+		switch {
+		case aggregate.function == "COUNT":
+			values[i] = count
+		case result == nil:
+			values[i] = spanner.NullFloat64{}
+		case aggregate.function == "AVG":
+			if f, ok := result.(float64); ok {
+				values[i] = f / float64(count)
+			} else {
+				values[i] = new(big.Rat).Quo(result.(*big.Rat), new(big.Rat).SetInt64(count))
+			}
+		default:
+			values[i] = result
+		}
+	}
+	return spanner.NewRow(names, values)
+}
+
+// sqlSum adds a FLOAT64 or NUMERIC value to the sum of the values of an
+// aggregate, nil before the first one
+func sqlSum(function string, sum, v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case float64:
+		if s, ok := sum.(float64); ok || sum == nil {
+			return s + v, nil
+		}
+	case *big.Rat:
+		if sum == nil {
+			return new(big.Rat).Set(v), nil
+		}
+		if s, ok := sum.(*big.Rat); ok {
+			return s.Add(s, v), nil
+		}
+	}
+	return nil, fmt.Errorf("no matching signature for aggregate function %s(%T)", function, v)
+}
+
+func (p *sqlParser) parseUpdate(stmt *sqlStatement, resolve func(string) error) error {
+	table, err := p.identifier()
+	if err != nil {
+		return err
+	}
+	if err := resolve(table); err != nil {
+		return err
+	}
+	if !p.keyword("SET") {
+		return fmt.Errorf("syntax error: expected SET")
+	}
+	for {
+		name, err := p.path()
+		if err != nil {
+			return err
+		}
+		column, err := p.columnOf(name)
+		if err != nil {
+			return err
+		}
+		if err := p.expectSymbol("="); err != nil {
+			return err
+		}
+		value, err := p.operand()
+		if err != nil {
+			return err
+		}
+		stmt.set = append(stmt.set, sqlAssignment{column: column, value: value})
+		if !p.symbol(",") {
+			break
+		}
+	}
+	if p.keyword("WHERE") {
+		stmt.where, err = p.expr()
+	}
+	return err
+}
+
+// count reads the non-negative integer of a LIMIT or OFFSET clause
+func (p *sqlParser) count() (int64, error) {
+	t := p.next()
+	var v interface{} = t.text
+	if t.kind == sqlParam {
+		v = p.params[t.text]
+	}
+	n, err := strconv.ParseInt(fmt.Sprint(v), 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("syntax error: invalid LIMIT or OFFSET %v", v)
+	}
+	return n, nil
+}
+
+func (p *sqlParser) expr() (sqlExpr, error) {
+	left, err := p.andExpr()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("OR") {
+		right, err := p.andExpr()
+		if err != nil {
+			return nil, err
+		}
+		left = sqlOr(left, right)
+	}
+	return left, nil
+}
+
+func (p *sqlParser) andExpr() (sqlExpr, error) {
+	left, err := p.notExpr()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("AND") {
+		right, err := p.notExpr()
+		if err != nil {
+			return nil, err
+		}
+		left = sqlAnd(left, right)
+	}
+	return left, nil
+}
+
+func (p *sqlParser) notExpr() (sqlExpr, error) {
+	if p.keyword("NOT") {
+		e, err := p.notExpr()
+		if err != nil {
+			return nil, err
+		}
+		return sqlNot(e), nil
+	}
+	return p.predicate()
+}
+
+func (p *sqlParser) predicate() (sqlExpr, error) {
+	left, err := p.operand()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind == sqlSymbol {
+		switch t.text {
+		case "=", "!=", "<>", "<", ">", "<=", ">=":
+			p.next()
+			right, err := p.operand()
+			if err != nil {
+				return nil, err
+			}
+			return sqlCompare(t.text, left, right), nil
+		}
+	}
+	if p.keyword("IS") {
+		negate := p.keyword("NOT")
+		if !p.keyword("NULL") {
+			return nil, fmt.Errorf("syntax error: expected NULL")
+		}
+		return func(row map[string]interface{}) (interface{}, error) {
+			v, err := left(row)
+			if err != nil {
+				return nil, err
+			}
+			return (v == nil) != negate, nil
+		}, nil
+	}
+	negate := p.keyword("NOT")
+	var e sqlExpr
+	switch {
+	case p.keyword("BETWEEN"):
+		low, err := p.operand()
+		if err != nil {
+			return nil, err
+		}
+		if !p.keyword("AND") {
+			return nil, fmt.Errorf("syntax error: expected AND in BETWEEN")
+		}
+		high, err := p.operand()
+		if err != nil {
+			return nil, err
+		}
+		e = sqlAnd(sqlCompare(">=", left, low), sqlCompare("<=", left, high))
+	case p.keyword("IN"):
+		if e, err = p.inList(left); err != nil {
+			return nil, err
+		}
+	default:
+		if negate {
+			return nil, fmt.Errorf("syntax error: unexpected NOT")
+		}
+		return left, nil
+	}
+	if negate {
+		e = sqlNot(e)
+	}
+	return e, nil
+}
+
+func (p *sqlParser) inList(left sqlExpr) (sqlExpr, error) {
+	var list []sqlExpr
+	if p.keyword("UNNEST") {
+		if err := p.expectSymbol("("); err != nil {
+			return nil, err
+		}
+		array, err := p.operand()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expectSymbol(")"); err != nil {
+			return nil, err
+		}
+		return func(row map[string]interface{}) (interface{}, error) {
+			v, err := array(row)
+			if err != nil {
+				return nil, err
+			}
+			values, ok := toSlice(v)
+			if !ok && v != nil {
+				return nil, fmt.Errorf("UNNEST expects an array but got %T", v)
+			}
+			exprs := make([]sqlExpr, len(values))
+			for i := range values {
+				value := values[i]
+				exprs[i] = func(map[string]interface{}) (interface{}, error) { return value, nil }
+			}
+			return sqlIn(left, exprs)(row)
+		}, nil
+	}
+	if err := p.expectSymbol("("); err != nil {
+		return nil, err
+	}
+	for {
+		e, err := p.operand()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, e)
+		if !p.symbol(",") {
+			break
+		}
+	}
+	if err := p.expectSymbol(")"); err != nil {
+		return nil, err
+	}
+	return sqlIn(left, list), nil
+}
+
+func (p *sqlParser) operand() (sqlExpr, error) {
+	t := p.peek()
+	switch t.kind {
+	case sqlString:
+		p.next()
+		return sqlConst(t.text), nil
+	case sqlNumber:
+		p.next()
+		f, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("syntax error: invalid number %q", t.text)
+		}
+		return sqlConst(f), nil
+	case sqlParam:
+		p.next()
+		v, ok := p.params[t.text]
+		if !ok {
+			return nil, fmt.Errorf("no value for query parameter @%s", t.text)
+		}
+		return sqlConst(normalizeParam(v)), nil
+	case sqlSymbol:
+		switch t.text {
+		case "(":
+			p.next()
+			e, err := p.expr()
+			if err != nil {
+				return nil, err
+			}
+			return e, p.expectSymbol(")")
+		case "-":
+			p.next()
+			e, err := p.operand()
+			if err != nil {
+				return nil, err
+			}
+			return func(row map[string]interface{}) (interface{}, error) {
+				v, err := e(row)
+				switch v := v.(type) {
+				case float64:
+					return -v, err
+				case *big.Rat:
+					return new(big.Rat).Neg(v), err
+				}
+				return nil, err
+			}, nil
+		}
+	case sqlIdent:
+		switch {
+		case p.keyword("NULL"):
+			return sqlConst(nil), nil
+		case p.keyword("TRUE"):
+			return sqlConst(true), nil
+		case p.keyword("FALSE"):
+			return sqlConst(false), nil
+		}
+		if next := p.tokens[min(p.pos+1, len(p.tokens)-1)]; next.kind == sqlSymbol && next.text == "(" {
+			return p.function()
+		}
+	}
+	name, err := p.path()
+	if err != nil {
+		return nil, err
+	}
+	column, err := p.columnOf(name)
+	if err != nil {
+		return nil, err
+	}
+	return func(row map[string]interface{}) (interface{}, error) {
+		return row[column], nil
+	}, nil
+}
+
+func (p *sqlParser) function() (sqlExpr, error) {
+	name := strings.ToUpper(p.next().text)
+	p.next()
+	if name == "SAFE_CAST" {
+		return p.safeCast()
+	}
+	var args []sqlExpr
+	if !p.symbol(")") {
+		for {
+			e, err := p.expr()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, e)
+			if !p.symbol(",") {
+				break
+			}
+		}
+		if err := p.expectSymbol(")"); err != nil {
+			return nil, err
+		}
+	}
+	switch {
+	case name == "STARTS_WITH" && len(args) == 2:
+		return func(row map[string]interface{}) (interface{}, error) {
+			s, prefix, err := evalPair(args[0], args[1], row)
+			if err != nil || s == nil || prefix == nil {
+				return nil, err
+			}
+			str, ok1 := s.(string)
+			pre, ok2 := prefix.(string)
+			if !ok1 || !ok2 {
+				return nil, fmt.Errorf("no matching signature for function STARTS_WITH(%T, %T)", s, prefix)
+			}
+			return strings.HasPrefix(str, pre), nil
+		}, nil
+	case (name == "JSON_VALUE" || name == "JSON_QUERY") && len(args) == 2:
+		return func(row map[string]interface{}) (interface{}, error) {
+			doc, path, err := evalPair(args[0], args[1], row)
+			if err != nil || doc == nil {
+				return nil, err
+			}
+			pathStr, ok := path.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s expects a string path", name)
+			}
+			if name == "JSON_QUERY" {
+				return jsonQuery(doc, pathStr), nil
+			}
+			return jsonValue(doc, pathStr), nil
+		}, nil
+	case name == "BOOL" && len(args) == 1:
+		return func(row map[string]interface{}) (interface{}, error) {
+			v, err := args[0](row)
+			if err != nil || v == nil {
+				return nil, err
+			}
+			b, ok := v.(bool)
+			if !ok {
+				return nil, fmt.Errorf("the provided JSON input is not a boolean")
+			}
+			return b, nil
+		}, nil
+	case name == "COALESCE" && len(args) > 0:
+		return func(row map[string]interface{}) (interface{}, error) {
+			for _, arg := range args {
+				v, err := arg(row)
+				if err != nil || v != nil {
+					return v, err
+				}
+			}
+			return nil, nil
+		}, nil
+	case name == "STRPOS" && len(args) == 2:
+		return func(row map[string]interface{}) (interface{}, error) {
+			s, sub, err := evalPair(args[0], args[1], row)
+			if err != nil || s == nil || sub == nil {
+				return nil, err
+			}
+			switch s := s.(type) {
+			case string:
+				if sub, ok := sub.(string); ok {
+					i := strings.Index(s, sub)
+					if i < 0 {
+						return float64(0), nil
+					}
+					return float64(utf8.RuneCountInString(s[:i]) + 1), nil
+				}
+			case []byte:
+				if sub, ok := sub.([]byte); ok {
+					return float64(bytes.Index(s, sub) + 1), nil
+				}
+			}
+			return nil, fmt.Errorf("no matching signature for function STRPOS(%T, %T)", s, sub)
+		}, nil
+	case (name == "CHAR_LENGTH" || name == "BYTE_LENGTH" || name == "ARRAY_LENGTH") && len(args) == 1:
+		return func(row map[string]interface{}) (interface{}, error) {
+			v, err := args[0](row)
+			if err != nil || v == nil {
+				return nil, err
+			}
+			switch v := v.(type) {
+			case string:
+				if name == "CHAR_LENGTH" {
+					return float64(utf8.RuneCountInString(v)), nil
+				}
+				if name == "BYTE_LENGTH" {
+					return float64(len(v)), nil
+				}
+			case []byte:
+				if name == "BYTE_LENGTH" {
+					return float64(len(v)), nil
+				}
+			default:
+				if values, ok := toSlice(v); ok && name == "ARRAY_LENGTH" {
+					return float64(len(values)), nil
+				}
+			}
+			return nil, fmt.Errorf("no matching signature for function %s(%T)", name, v)
+		}, nil
+	case (name == "LOWER" || name == "UPPER") && len(args) == 1:
+		return func(row map[string]interface{}) (interface{}, error) {
+			v, err := args[0](row)
+			s, ok := v.(string)
+			if err != nil || !ok {
+				return nil, err
+			}
+			if name == "LOWER" {
+				return strings.ToLower(s), nil
+			}
+			return strings.ToUpper(s), nil
+		}, nil
+	}
+	return nil, fmt.Errorf("function not found: %s with %d arguments", name, len(args))
+}
+
+// safeCast parses the arguments of SAFE_CAST, which casts to NUMERIC and
+// returns NULL for values that are not numbers
+func (p *sqlParser) safeCast() (sqlExpr, error) {
+	e, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+	if !p.keyword("AS") {
+		return nil, fmt.Errorf("syntax error: expected AS but got %q", p.peek().text)
+	}
+	if !p.keyword("NUMERIC") {
+		return nil, fmt.Errorf("unsupported cast to %s", p.peek().text)
+	}
+	if err := p.expectSymbol(")"); err != nil {
+		return nil, err
+	}
+	return func(row map[string]interface{}) (interface{}, error) {
+		v, err := e(row)
+		if err != nil {
+			return nil, err
+		}
+		if s, ok := v.(string); ok {
+			if r, ok := new(big.Rat).SetString(strings.TrimSpace(s)); ok {
+				return r, nil
+			}
+			return nil, nil
+		}
+		if r, ok := sqlNumeric(v); ok {
+			return r, nil
+		}
+		return nil, nil
+	}, nil
+}
+
+func evalPair(a, b sqlExpr, row map[string]interface{}) (interface{}, interface{}, error) {
+	va, err := a(row)
+	if err != nil {
+		return nil, nil, err
+	}
+	vb, err := b(row)
+	return va, vb, err
+}
+
+// jsonValue returns the scalar at a JSONPath like $.a."b c"[0] as a string
+func jsonValue(doc interface{}, path string) interface{} {
+	switch v := jsonQuery(doc, path).(type) {
+	case string:
+		return v
+	case float64, bool, json.Number:
+		return fmt.Sprint(v)
+	}
+	return nil
+}
+
+// jsonQuery returns the JSON value at a JSONPath like $.a."b c"[0], nil when
+// there is none
+func jsonQuery(doc interface{}, path string) interface{} {
+	if s, ok := doc.(string); ok {
+		if err := json.Unmarshal([]byte(s), &doc); err != nil {
+			return nil
+		}
+	}
+	path, ok := strings.CutPrefix(path, "$")
+	if !ok {
+		return nil
+	}
+	for path != "" && doc != nil {
+		switch path[0] {
+		case '.':
+			var key string
+			if rest, ok := strings.CutPrefix(path[1:], `"`); ok {
+				end := strings.IndexByte(rest, '"')
+				if end == -1 {
+					return nil
+				}
+				key, path = rest[:end], rest[end+1:]
+			} else {
+				end := strings.IndexAny(path[1:], ".[")
+				if end == -1 {
+					end = len(path) - 1
+				}
+				key, path = path[1:end+1], path[end+1:]
+			}
+			m, ok := doc.(map[string]interface{})
+			if !ok {
+				return nil
+			}
+			doc = m[key]
+		case '[':
+			end := strings.IndexByte(path, ']')
+			if end == -1 {
+				return nil
+			}
+			index, err := strconv.Atoi(path[1:end])
+			path = path[end+1:]
+			list, ok := doc.([]interface{})
+			if err != nil || !ok || index < 0 || index >= len(list) {
+				return nil
+			}
+			doc = list[index]
+		default:
+			return nil
+		}
+	}
+	return doc
+}
+
+func sqlConst(v interface{}) sqlExpr {
+	return func(map[string]interface{}) (interface{}, error) { return v, nil }
+}
+
+// sqlAnd and sqlOr implement the three-valued logic of SQL, NULL is nil
+func sqlAnd(left, right sqlExpr) sqlExpr {
+	return func(row map[string]interface{}) (interface{}, error) {
+		l, err := left(row)
+		if err != nil {
+			return nil, err
+		}
+		if l == false {
+			return false, nil
+		}
+		r, err := right(row)
+		if err != nil {
+			return nil, err
+		}
+		if r == false {
+			return false, nil
+		}
+		if l == nil || r == nil {
+			return nil, nil
+		}
+		return true, nil
+	}
+}
+
+func sqlOr(left, right sqlExpr) sqlExpr {
+	return func(row map[string]interface{}) (interface{}, error) {
+		l, err := left(row)
+		if err != nil {
+			return nil, err
+		}
+		if l == true {
+			return true, nil
+		}
+		r, err := right(row)
+		if err != nil {
+			return nil, err
+		}
+		if r == true {
+			return true, nil
+		}
+		if l == nil || r == nil {
+			return nil, nil
+		}
+		return false, nil
+	}
+}
+
+func sqlNot(e sqlExpr) sqlExpr {
+	return func(row map[string]interface{}) (interface{}, error) {
+		v, err := e(row)
+		if err != nil || v == nil {
+			return nil, err
+		}
+		b, ok := v.(bool)
+		if !ok {
+			return nil, fmt.Errorf("NOT expects a BOOL but got %T", v)
+		}
+		return !b, nil
+	}
+}
+
+func sqlCompare(op string, left, right sqlExpr) sqlExpr {
+	return func(row map[string]interface{}) (interface{}, error) {
+		l, r, err := evalPair(left, right, row)
+		if err != nil || l == nil || r == nil {
+			return nil, err
+		}
+		c, err := compareSQLValues(l, r)
+		if err != nil {
+			return nil, err
+		}
+		switch op {
+		case "=":
+			return c == 0, nil
+		case "!=", "<>":
+			return c != 0, nil
+		case "<":
+			return c < 0, nil
+		case ">":
+			return c > 0, nil
+		case "<=":
+			return c <= 0, nil
+		}
+		return c >= 0, nil
+	}
+}
+
+func sqlIn(left sqlExpr, list []sqlExpr) sqlExpr {
+	return func(row map[string]interface{}) (interface{}, error) {
+		l, err := left(row)
+		if err != nil || l == nil {
+			return nil, err
+		}
+		var result interface{} = false
+		for _, e := range list {
+			v, err := e(row)
+			if err != nil {
+				return nil, err
+			}
+			if v == nil {
+				result = nil
+				continue
+			}
+			c, err := compareSQLValues(l, v)
+			if err != nil {
+				return nil, err
+			}
+			if c == 0 {
+				return true, nil
+			}
+		}
+		return result, nil
+	}
+}
+
+// compareSQLValues orders two non-NULL values of the same type
+func compareSQLValues(a, b interface{}) (int, error) {
+	if ra, ok := sqlNumeric(a); ok {
+		if rb, ok := sqlNumeric(b); ok {
+			return ra.Cmp(rb), nil
+		}
+	}
+	switch a := a.(type) {
+	case string:
+		if b, ok := b.(string); ok {
+			return strings.Compare(a, b), nil
+		}
+	case bool:
+		if b, ok := b.(bool); ok {
+			switch {
+			case a == b:
+				return 0, nil
+			case !a:
+				return -1, nil
+			}
+			return 1, nil
+		}
+	case []byte:
+		if b, ok := b.([]byte); ok {
+			return bytes.Compare(a, b), nil
+		}
+	}
+	return 0, fmt.Errorf("no matching signature for comparing %T and %T", a, b)
+}
+
+// sqlNumeric returns the exact value of a FLOAT64 or NUMERIC value
+func sqlNumeric(v interface{}) (*big.Rat, bool) {
+	switch v := v.(type) {
+	case float64:
+		if math.IsInf(v, 0) || math.IsNaN(v) {
+			return nil, false
+		}
+		return new(big.Rat).SetFloat64(v), true
+	case *big.Rat:
+		return v, true
+	}
+	return nil, false
+}
+
+// normalizeParam converts a query parameter to the plain values of the rows
+func normalizeParam(v interface{}) interface{} {
+	switch v := v.(type) {
+	case int:
+		return float64(v)
+	case int32:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case float32:
+		return float64(v)
+	case big.Rat:
+		return &v
+	}
+	if values, ok := toSlice(v); ok {
+		normalized := make([]interface{}, len(values))
+		for i, value := range values {
+			normalized[i] = normalizeParam(value)
+		}
+		return normalized
+	}
+	return v
+}
+
+// toSlice returns the elements of an array value
+func toSlice(v interface{}) ([]interface{}, bool) {
+	switch v := v.(type) {
+	case []interface{}:
+		return v, true
+	case []string:
+		values := make([]interface{}, len(v))
+		for i := range v {
+			values[i] = v[i]
+		}
+		return values, true
+	case []float64:
+		values := make([]interface{}, len(v))
+		for i := range v {
+			values[i] = v[i]
+		}
+		return values, true
+	case [][]byte:
+		values := make([]interface{}, len(v))
+		for i := range v {
+			values[i] = v[i]
+		}
+		return values, true
+	case []models.Number:
+		values := make([]interface{}, len(v))
+		for i := range v {
+			values[i] = v[i]
+		}
+		return values, true
+	case []*big.Rat:
+		values := make([]interface{}, len(v))
+		for i := range v {
+			values[i] = v[i]
+		}
+		return values, true
+	}
+	return nil, false
+}
+
+// sortSQLRows orders the rows by the ORDER BY columns, NULLs first
+func sortSQLRows(rows []map[string]interface{}, orderBy []sqlOrder) {
+	sort.SliceStable(rows, func(i, j int) bool {
+		for _, order := range orderBy {
+			a, b := rows[i][order.column], rows[j][order.column]
+			var c int
+			switch {
+			case a == nil && b == nil:
+				continue
+			case a == nil:
+				c = -1
+			case b == nil:
+				c = 1
+			default:
+				c, _ = compareSQLValues(a, b)
+			}
+			if c == 0 {
+				continue
+			}
+			if order.desc {
+				return c > 0
+			}
+			return c < 0
+		}
+		return false
+	})
+}
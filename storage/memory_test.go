@@ -0,0 +1,364 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"cloud.google.com/go/spanner"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/models"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/pkg/errors"
+	translator "github.com/cloudspannerecosystem/dynamodb-adapter/translator/utils"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupMemoryStorage(t *testing.T) *MemoryStorage {
+	dbConfigMap, tableDDL, tableColumnMap := models.DbConfigMap, models.TableDDL, models.TableColumnMap
+	models.DbConfigMap = map[string]models.TableConfig{
+		"orders": {PartitionKey: "customer", SortKey: "id", ActualTable: "orders"},
+	}
+	models.TableDDL = map[string]map[string]string{}
+	models.TableColumnMap = map[string][]string{}
+	for table, ddl := range tableDDL {
+		models.TableDDL[table] = ddl
+		models.TableColumnMap[table] = tableColumnMap[table]
+	}
+	models.TableDDL["orders"] = map[string]string{"customer": "S", "id": "N", "status": "S", "total": "N", "tags": "SS", "info": "M"}
+	models.TableColumnMap["orders"] = []string{"customer", "id", "status", "total", "tags", "info"}
+	t.Cleanup(func() {
+		models.DbConfigMap, models.TableDDL, models.TableColumnMap = dbConfigMap, tableDDL, tableColumnMap
+	})
+
+	s := NewMemoryStorage()
+	s.CreateTable("orders", "customer", "id")
+	return s
+}
+
+func putOrder(t *testing.T, s *MemoryStorage, id float64, status string, total float64) {
+	_, err := s.SpannerPut(context.Background(), "orders", map[string]interface{}{
+		"customer": "alice", "id": id, "status": status, "total": total,
+	}, &models.Eval{}, nil, nil)
+	assert.NoError(t, err)
+}
+
+func TestMemoryStoragePutGet(t *testing.T) {
+	s := setupMemoryStorage(t)
+	ctx := context.Background()
+
+	_, err := s.SpannerPut(ctx, "orders", map[string]interface{}{
+		"customer": "alice",
+		"id":       float64(1),
+		"tags":     []string{"new", "gift"},
+		"info":     map[string]interface{}{"note": "leave at door"},
+	}, &models.Eval{}, nil, nil)
+	assert.NoError(t, err)
+
+	item, _, err := s.SpannerGet(ctx, "orders", "alice", float64(1), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "alice", item["customer"])
+	assert.Equal(t, models.Number("1"), item["id"])
+	assert.Equal(t, []string{"new", "gift"}, item["tags"])
+	assert.Nil(t, item["total"])
+	assert.NotNil(t, item["info"])
+
+	item, _, err = s.SpannerGet(ctx, "orders", "alice", float64(2), nil)
+	assert.NoError(t, err)
+	assert.Empty(t, item)
+
+	_, err = s.SpannerPut(ctx, "orders", map[string]interface{}{"customer": "alice", "id": "one"}, &models.Eval{}, nil, nil)
+	assert.Error(t, err)
+}
+
+func TestMemoryStorageConditionalPut(t *testing.T) {
+	s := setupMemoryStorage(t)
+	ctx := context.Background()
+	putOrder(t, s, 1, "open", 10)
+
+	eval, err := utils.CreateConditionExpression("attribute_not_exists(id)", nil)
+	assert.NoError(t, err)
+	_, err = s.SpannerPut(ctx, "orders", map[string]interface{}{"customer": "alice", "id": float64(1), "status": "closed"}, eval, nil, nil)
+	assert.Equal(t, "ConditionalCheckFailedException", err.(*errors.Error).ErrorCode)
+
+	item, _, err := s.SpannerGet(ctx, "orders", "alice", float64(1), []string{"status"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"status": "open"}, item)
+}
+
+func TestMemoryStorageUpdateAndDelete(t *testing.T) {
+	s := setupMemoryStorage(t)
+	ctx := context.Background()
+	putOrder(t, s, 1, "open", 10)
+	putOrder(t, s, 2, "open", 20)
+
+	_, err := s.SpannerAdd(ctx, "orders", map[string]interface{}{"customer": "alice", "id": float64(1), "total": float64(5)}, &models.Eval{}, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, s.SpannerRemove(ctx, "orders", map[string]interface{}{"customer": "alice", "id": float64(1)}, &models.Eval{}, nil, []string{"status"}, map[string]interface{}{}))
+
+	item, _, err := s.SpannerGet(ctx, "orders", "alice", float64(1), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"customer": "alice", "id": models.Number("1"), "status": nil, "total": models.Number("15")}, item)
+
+	assert.NoError(t, s.SpannerDelete(ctx, "orders", map[string]interface{}{"customer": "alice", "id": float64(2)}, &models.Eval{}, nil))
+	items, err := s.SpannerBatchGet(ctx, "orders", []interface{}{"alice", "alice"}, []interface{}{float64(1), float64(2)}, []string{"id"})
+	assert.NoError(t, err)
+	assert.Equal(t, []map[string]interface{}{{"id": models.Number("1")}}, items)
+}
+
+func TestMemoryStorageExactNumbers(t *testing.T) {
+	s := setupMemoryStorage(t)
+	ctx := context.Background()
+	spannerColumnTypes := models.SpannerColumnTypes
+	models.SpannerColumnTypes = map[string]map[string]string{"orders": {"id": "NUMERIC", "total": "NUMERIC"}}
+	t.Cleanup(func() { models.SpannerColumnTypes = spannerColumnTypes })
+
+	// 2^53 + 1 is not a float64
+	id := models.Number("9007199254740993")
+	_, err := s.SpannerPut(ctx, "orders", map[string]interface{}{"customer": "alice", "id": id, "total": models.Number("0.1")}, &models.Eval{}, nil, nil)
+	assert.NoError(t, err)
+	_, err = s.SpannerAdd(ctx, "orders", map[string]interface{}{"customer": "alice", "id": id, "total": models.Number("0.2")}, &models.Eval{}, nil)
+	assert.NoError(t, err)
+
+	item, _, err := s.SpannerGet(ctx, "orders", "alice", id, []string{"id", "total"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"id": id, "total": models.Number("0.3")}, item)
+
+	items, err := s.ExecuteSpannerQuery(ctx, "orders", nil, false, spanner.Statement{
+		SQL:    "SELECT id FROM orders WHERE total = @total AND id > @id",
+		Params: map[string]interface{}{"total": models.Number("0.3"), "id": models.Number("9007199254740992")},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []map[string]interface{}{{"id": id}}, items)
+
+	_, err = s.SpannerPut(ctx, "orders", map[string]interface{}{"customer": "alice", "id": models.Number("1"), "total": models.Number("0.0000000001")}, &models.Eval{}, nil, nil)
+	assert.Error(t, err)
+}
+
+func TestMemoryStorageStringNumbers(t *testing.T) {
+	s := setupMemoryStorage(t)
+	ctx := context.Background()
+	spannerColumnTypes := models.SpannerColumnTypes
+	models.SpannerColumnTypes = map[string]map[string]string{"orders": {"id": "STRING(MAX)", "total": "STRING(MAX)"}}
+	t.Cleanup(func() { models.SpannerColumnTypes = spannerColumnTypes })
+
+	// numbers beyond NUMERIC are stored, and sorted and compared as numbers
+	ids := []models.Number{"1" + models.Number(strings.Repeat("0", 100)), "-2.5", "0.0000000001", "10", "9"}
+	for _, id := range ids {
+		_, err := s.SpannerPut(ctx, "orders", map[string]interface{}{"customer": "alice", "id": id, "total": id}, &models.Eval{}, nil, nil)
+		assert.NoError(t, err)
+	}
+	items, err := s.ExecuteSpannerQuery(ctx, "orders", nil, false, spanner.Statement{
+		SQL:    "SELECT id FROM orders WHERE customer = @customer AND total > @total ORDER BY id",
+		Params: map[string]interface{}{"customer": "alice", "total": ColumnParam{Column: "total", Value: models.Number("0")}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []map[string]interface{}{{"id": ids[2]}, {"id": ids[4]}, {"id": ids[3]}, {"id": ids[0]}}, items)
+}
+
+func TestMemoryStorageQuery(t *testing.T) {
+	s := setupMemoryStorage(t)
+	ctx := context.Background()
+	putOrder(t, s, 3, "open", 30)
+	putOrder(t, s, 1, "open", 10)
+	putOrder(t, s, 2, "closed", 20)
+	assert.NoError(t, s.SpannerBatchPut(ctx, "orders", []map[string]interface{}{{"customer": "bob", "id": float64(1), "total": float64(5)}}, nil))
+
+	items, err := s.ExecuteSpannerQuery(ctx, "orders", nil, false, spanner.Statement{
+		SQL: "SELECT orders.`id`,orders.`total` FROM orders@{FORCE_INDEX=by_status} WHERE customer is not null AND customer = @rangeExp1 AND (status = @filterExp1 OR total BETWEEN 15 AND 25) ORDER BY id DESC LIMIT 2",
+		Params: map[string]interface{}{
+			"rangeExp1":  "alice",
+			"filterExp1": "open",
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []map[string]interface{}{
+		{"id": models.Number("3"), "total": models.Number("30")},
+		{"id": models.Number("2"), "total": models.Number("20")},
+	}, items)
+
+	count, err := s.ExecuteSpannerQuery(ctx, "orders", nil, true, spanner.Statement{
+		SQL:    "SELECT COUNT(customer) AS count FROM orders WHERE customer IN UNNEST(@keys) AND NOT STARTS_WITH(status, 'clo')",
+		Params: map[string]interface{}{"keys": []string{"alice", "carol"}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), count[0]["Count"])
+
+	_, err = s.ExecuteSpannerQuery(ctx, "orders", nil, false, spanner.Statement{SQL: "SELECT missing FROM orders"})
+	assert.Error(t, err)
+}
+
+func TestMemoryStorageStatements(t *testing.T) {
+	s := setupMemoryStorage(t)
+	ctx := context.Background()
+	putOrder(t, s, 1, "open", 10)
+	putOrder(t, s, 2, "open", 20)
+
+	_, err := s.InsertUpdateOrDeleteStatement(ctx, &translator.DeleteUpdateQueryMap{
+		Table:        "orders",
+		SpannerQuery: "UPDATE orders SET `status` = @status WHERE `total` > @total;",
+		Params:       map[string]interface{}{"status": "shipped", "total": float64(15)},
+	})
+	assert.NoError(t, err)
+	_, err = s.InsertUpdateOrDeleteStatement(ctx, &translator.DeleteUpdateQueryMap{
+		Table:        "orders",
+		SpannerQuery: "DELETE FROM orders WHERE `id` = 1;",
+	})
+	assert.NoError(t, err)
+
+	items, err := s.ExecuteSpannerQuery(ctx, "orders", nil, false, spanner.Statement{SQL: "SELECT id, status FROM orders"})
+	assert.NoError(t, err)
+	assert.Equal(t, []map[string]interface{}{{"id": models.Number("2"), "status": "shipped"}}, items)
+}
+
+func TestMemoryStorageItemSize(t *testing.T) {
+	s := setupMemoryStorage(t)
+	ctx := context.Background()
+	putOrder(t, s, 1, "open", 10)
+
+	// an update fails when the item it leaves exceeds 400KB
+	status := strings.Repeat("x", 250*1024)
+	_, err := s.SpannerPut(ctx, "orders", map[string]interface{}{"customer": "alice", "id": float64(1), "status": status}, &models.Eval{}, nil, nil)
+	assert.NoError(t, err)
+	_, err = s.SpannerPut(ctx, "orders", map[string]interface{}{"customer": "alice", "id": float64(1), "tags": []string{status}}, &models.Eval{}, nil, nil)
+	assert.Equal(t, "Item size to update has exceeded the maximum allowed size", strings.TrimSpace(err.(*errors.Error).ErrorMessage))
+	err = s.ReadWriteTransaction(ctx, []string{"orders"}, func(ctx context.Context, txn Transaction) error {
+		_, _, err := s.TransactWriteSpannerAdd(ctx, "orders", map[string]interface{}{"customer": "alice", "id": float64(1), "tags": []string{status}}, &models.Eval{}, nil, txn)
+		return err
+	})
+	assert.Equal(t, "Item size to update has exceeded the maximum allowed size", strings.TrimSpace(err.(*errors.Error).ErrorMessage))
+
+	// removed attributes do not count
+	_, err = s.SpannerPut(ctx, "orders", map[string]interface{}{"customer": "alice", "id": float64(1), "status": nil, "tags": []string{status}}, &models.Eval{}, nil, nil)
+	assert.NoError(t, err)
+
+	_, err = s.SpannerPut(ctx, "orders", map[string]interface{}{"customer": "alice", "id": float64(2), "status": status + status}, &models.Eval{}, nil, nil)
+	assert.Equal(t, "Item size has exceeded the maximum allowed size", strings.TrimSpace(err.(*errors.Error).ErrorMessage))
+}
+
+func TestMemoryStorageTransaction(t *testing.T) {
+	s := setupMemoryStorage(t)
+	ctx := context.Background()
+	putOrder(t, s, 1, "open", 10)
+
+	err := s.ReadWriteTransaction(ctx, []string{"orders"}, func(ctx context.Context, txn Transaction) error {
+		_, put, err := s.SpannerTransactWritePut(ctx, "orders", map[string]interface{}{"customer": "alice", "id": float64(2), "status": "open"}, &models.Eval{}, nil, txn, nil)
+		if err != nil {
+			return err
+		}
+		del, err := s.TransactWriteSpannerDelete(ctx, "orders", map[string]interface{}{"customer": "alice", "id": float64(1)}, &models.Eval{}, nil, txn)
+		if err != nil {
+			return err
+		}
+		return txn.BufferWrite([]*Mutation{put, insertOrUpdateMutation("orders", map[string]interface{}{"customer": "alice", "id": float64(2), "total": float64(7)}), del})
+	})
+	assert.NoError(t, err)
+	items, err := s.SpannerBatchGet(ctx, "orders", []interface{}{"alice", "alice"}, []interface{}{float64(1), float64(2)}, []string{"id", "status", "total"})
+	assert.NoError(t, err)
+	assert.Equal(t, []map[string]interface{}{{"id": models.Number("2"), "status": "open", "total": models.Number("7")}}, items)
+
+	// A failed transaction writes nothing
+	err = s.ReadWriteTransaction(ctx, []string{"orders"}, func(ctx context.Context, txn Transaction) error {
+		if err := txn.BufferWrite([]*Mutation{deleteMutation("orders", spanner.Key{"alice", float64(2)})}); err != nil {
+			return err
+		}
+		return errors.New("ConditionalCheckFailedException")
+	})
+	assert.Error(t, err)
+	item, _, err := s.SpannerGet(ctx, "orders", "alice", float64(2), []string{"id"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"id": models.Number("2")}, item)
+}
+
+func TestMemoryStorageBatchWrite(t *testing.T) {
+	s := setupMemoryStorage(t)
+	ctx := context.Background()
+	putOrder(t, s, 1, "open", 10)
+
+	// the put without its sort key fails on its own
+	failedPuts, failedDeletes, err := s.SpannerBatchWrite(ctx, "orders", []map[string]interface{}{
+		{"customer": "alice", "id": float64(2), "status": "open"},
+		{"customer": "alice", "status": "open"},
+		{"customer": "alice", "id": float64(3), "status": "shipped"},
+	}, []map[string]interface{}{
+		{"customer": "alice", "id": float64(1)},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1}, failedPuts)
+	assert.Empty(t, failedDeletes)
+
+	items, err := s.SpannerBatchGet(ctx, "orders", []interface{}{"alice", "alice", "alice"}, []interface{}{float64(1), float64(2), float64(3)}, []string{"id", "status"})
+	assert.NoError(t, err)
+	assert.Equal(t, []map[string]interface{}{
+		{"id": models.Number("2"), "status": "open"},
+		{"id": models.Number("3"), "status": "shipped"},
+	}, items)
+
+	_, _, err = s.SpannerBatchWrite(ctx, "orders", nil, []map[string]interface{}{{"customer": "alice"}})
+	assert.Error(t, err)
+}
+
+func TestMemoryStorageReadSnapshot(t *testing.T) {
+	s := setupMemoryStorage(t)
+	putOrder(t, s, 1, "open", 10)
+
+	ctx, release, err := s.ReadSnapshot(context.Background(), []string{"orders"})
+	assert.NoError(t, err)
+	written := make(chan struct{})
+	go func() {
+		putOrder(t, s, 1, "shipped", 10)
+		close(written)
+	}()
+	items, err := s.SpannerBatchGet(ctx, "orders", []interface{}{"alice"}, []interface{}{float64(1)}, []string{"status"})
+	assert.NoError(t, err)
+	assert.Equal(t, []map[string]interface{}{{"status": "open"}}, items)
+
+	release()
+	release()
+	<-written
+	items, err = s.SpannerBatchGet(context.Background(), "orders", []interface{}{"alice"}, []interface{}{float64(1)}, []string{"status"})
+	assert.NoError(t, err)
+	assert.Equal(t, []map[string]interface{}{{"status": "shipped"}}, items)
+}
+
+func TestMemoryStorageBootstrap(t *testing.T) {
+	setupMemoryStorage(t)
+	s := NewMemoryStorage()
+	err := s.Bootstrap(context.Background(), map[string]models.BootstrapTable{
+		"Customer-Order": {
+			PartitionKey:   "PK",
+			AttributeTypes: map[string]string{"customer_id": "S", "total": "N"},
+			Indices: map[string]models.BootstrapIndex{
+				"By_customer": {PartitionKey: "customer_id", ProjectionType: models.ProjectionTypeInclude, NonKeyAttributes: []string{"total"}},
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	rows, err := s.ExecuteSpannerQuery(context.Background(), "dynamodb_adapter_table_ddl", nil, false, spanner.Statement{
+		SQL: "SELECT * FROM dynamodb_adapter_table_ddl",
+	})
+	assert.NoError(t, err)
+	assert.Len(t, rows, 3)
+	assert.Equal(t, "Customer-Order", rows[0]["tableName"])
+
+	indexes, err := s.SpannerIndexSchema(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []IndexColumnSchema{
+		{TableName: "Customer_Order", IndexName: "By_customer", ColumnName: "customer_id", OrdinalPosition: 1, IsNullFiltered: true},
+		{TableName: "Customer_Order", IndexName: "By_customer", ColumnName: "total", IsNullFiltered: true},
+	}, indexes)
+}
@@ -0,0 +1,313 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"strings"
+
+	"cloud.google.com/go/spanner"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/config"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/models"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/pkg/errors"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/utils"
+)
+
+// Spanner types numbers are stored as
+const (
+	numberFloat64 = "FLOAT64"
+	numberNumeric = "NUMERIC"
+	numberString  = "STRING"
+)
+
+// NUMERIC columns hold 29 digits before and 9 digits after the decimal point
+const (
+	numericIntegerDigits  = 29
+	numericFractionDigits = 9
+)
+
+// ColumnParam is a query parameter compared with or written to a column. Its
+// numbers are converted to the type the column stores numbers as.
+type ColumnParam struct {
+	Column string
+	Value  interface{}
+}
+
+// MarshalJSON writes the value of the parameter
+func (p ColumnParam) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.Value)
+}
+
+// numberColumnType returns the Spanner type the numbers of a N or NS column
+// are stored as. Columns without a recorded type are FLOAT64.
+func numberColumnType(table, column string) string {
+	t := strings.ToUpper(models.SpannerColumnTypes[table][column])
+	switch {
+	case strings.Contains(t, numberNumeric):
+		return numberNumeric
+	case strings.Contains(t, numberString):
+		return numberString
+	}
+	return numberFloat64
+}
+
+// spannerNumber converts n to the value written to a column storing numbers as colType
+func spannerNumber(colType string, n models.Number) (interface{}, error) {
+	switch colType {
+	case numberNumeric:
+		integer, fraction, _ := strings.Cut(strings.TrimPrefix(n.String(), "-"), ".")
+		if len(integer) > numericIntegerDigits || len(fraction) > numericFractionDigits {
+			return nil, errors.New("ValidationException", "Number "+n.String()+" exceeds the precision of the NUMERIC column, store the attribute in a STRING(MAX) column")
+		}
+		return n.Rat(), nil
+	case numberString:
+		return n.SortableString(), nil
+	}
+	return n.Float64(), nil
+}
+
+// spannerColumnValue converts the numbers of an attribute to the values written
+// to its column and encodes Map and List attributes. Other values are returned
+// unchanged.
+func spannerColumnValue(table, column string, v interface{}) (interface{}, error) {
+	switch models.TableDDL[table][column] {
+	case "M", "L":
+		return documentColumnValue(table, column, v)
+	case "N":
+		if n, ok := models.ToNumber(v); ok {
+			return spannerNumber(numberColumnType(table, column), n)
+		}
+	case "NS":
+		numbers, ok := v.([]models.Number)
+		if !ok {
+			return v, nil
+		}
+		colType := numberColumnType(table, column)
+		switch colType {
+		case numberNumeric:
+			values := make([]*big.Rat, len(numbers))
+			for i, n := range numbers {
+				value, err := spannerNumber(colType, n)
+				if err != nil {
+					return nil, err
+				}
+				values[i] = value.(*big.Rat)
+			}
+			return values, nil
+		case numberString:
+			values := make([]string, len(numbers))
+			for i, n := range numbers {
+				values[i] = n.SortableString()
+			}
+			return values, nil
+		}
+		values := make([]float64, len(numbers))
+		for i, n := range numbers {
+			values[i] = n.Float64()
+		}
+		return values, nil
+	}
+	return v, nil
+}
+
+// spannerColumns converts the values of the columns written to the table
+func spannerColumns(table string, columns map[string]interface{}) (map[string]interface{}, error) {
+	converted := make(map[string]interface{}, len(columns))
+	for column, v := range columns {
+		value, err := spannerColumnValue(table, column, v)
+		if err != nil {
+			return nil, err
+		}
+		converted[column] = value
+	}
+	return converted, nil
+}
+
+// keyColumns returns the partition and sort key columns of the table
+func keyColumns(table string) []string {
+	tableConf, err := config.GetTableConf(table)
+	if err != nil {
+		for _, name := range config.TableNames() {
+			if utils.ChangeTableNameForSpanner(name) == table {
+				tableConf, err = config.GetTableConf(name)
+				break
+			}
+		}
+	}
+	if err != nil {
+		return nil
+	}
+	if tableConf.SortKey == "" {
+		return []string{tableConf.PartitionKey}
+	}
+	return []string{tableConf.PartitionKey, tableConf.SortKey}
+}
+
+// spannerKey converts the numbers of a primary key of the table
+func spannerKey(table string, key spanner.Key) (spanner.Key, error) {
+	columns := keyColumns(table)
+	converted := make(spanner.Key, len(key))
+	for i, part := range key {
+		if i >= len(columns) {
+			converted[i] = part
+			continue
+		}
+		value, err := spannerColumnValue(table, columns[i], part)
+		if err != nil {
+			return nil, err
+		}
+		converted[i] = value
+	}
+	return converted, nil
+}
+
+// spannerKeySet builds the key set of the keys of the table. Keys without a
+// sort key only hold the partition key.
+func spannerKeySet(table string, pKeys, sKeys []interface{}) (spanner.KeySet, error) {
+	keys := make([]spanner.KeySet, 0, len(pKeys))
+	for i := range pKeys {
+		key := spanner.Key{pKeys[i]}
+		if i < len(sKeys) && sKeys[i] != nil {
+			key = append(key, sKeys[i])
+		}
+		key, err := spannerKey(table, key)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return spanner.KeySets(keys...), nil
+}
+
+// spannerMutation converts a buffered write to a Spanner mutation
+func spannerMutation(m *Mutation) (*spanner.Mutation, error) {
+	if m.Columns == nil {
+		key, err := spannerKey(m.Table, m.Key)
+		if err != nil {
+			return nil, err
+		}
+		return spanner.Delete(m.Table, key), nil
+	}
+	columns, err := spannerColumns(m.Table, m.Columns)
+	if err != nil {
+		return nil, err
+	}
+	return spanner.InsertOrUpdateMap(m.Table, columns), nil
+}
+
+func spannerMutations(ms []*Mutation) ([]*spanner.Mutation, error) {
+	mutations := make([]*spanner.Mutation, len(ms))
+	for i, m := range ms {
+		var err error
+		if mutations[i], err = spannerMutation(m); err != nil {
+			return nil, err
+		}
+	}
+	return mutations, nil
+}
+
+// spannerParams converts the number parameters of a query on the table to the
+// type of the column they are compared with, NUMERIC when it is not known
+func spannerParams(table string, stmt spanner.Statement) (spanner.Statement, error) {
+	params := make(map[string]interface{}, len(stmt.Params))
+	for name, v := range stmt.Params {
+		colType := numberNumeric
+		if p, ok := v.(ColumnParam); ok {
+			v = p.Value
+			switch models.TableDDL[table][p.Column] {
+			case "N", "NS":
+				colType = numberColumnType(table, p.Column)
+			}
+		}
+		value, err := spannerParam(colType, v)
+		if err != nil {
+			return stmt, err
+		}
+		params[name] = value
+	}
+	stmt.Params = params
+	return stmt, nil
+}
+
+func spannerParam(colType string, v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case models.Number:
+		if colType == numberNumeric {
+			// comparisons are not limited to the precision of NUMERIC columns
+			return v.Rat(), nil
+		}
+		return spannerNumber(colType, v)
+	case []models.Number:
+		return spannerNumberList(colType, v)
+	case []interface{}:
+		numbers := make([]models.Number, len(v))
+		for i, elem := range v {
+			n, ok := elem.(models.Number)
+			if !ok {
+				return v, nil
+			}
+			numbers[i] = n
+		}
+		return spannerNumberList(colType, numbers)
+	}
+	return v, nil
+}
+
+// spannerNumberList converts a list of numbers to a slice of the type the
+// column stores numbers as, which Spanner encodes as an array
+func spannerNumberList(colType string, numbers []models.Number) (interface{}, error) {
+	switch colType {
+	case numberNumeric:
+		values := make([]*big.Rat, len(numbers))
+		for i, n := range numbers {
+			values[i] = n.Rat()
+		}
+		return values, nil
+	case numberString:
+		values := make([]string, len(numbers))
+		for i, n := range numbers {
+			values[i] = n.SortableString()
+		}
+		return values, nil
+	}
+	values := make([]float64, len(numbers))
+	for i, n := range numbers {
+		values[i] = n.Float64()
+	}
+	return values, nil
+}
+
+// addNumbers adds the number v of an ADD update to the existing value of an
+// attribute. The sum is exact.
+func addNumbers(existing, v interface{}) (models.Number, error) {
+	a, ok := models.ToNumber(existing)
+	if !ok {
+		return "", errors.New("ValidationException", reflect.TypeOf(existing).String())
+	}
+	b, ok := models.ToNumber(v)
+	if !ok {
+		s, isString := v.(string)
+		if !isString {
+			return "", errors.New("ValidationException", reflect.TypeOf(v).String())
+		}
+		var err error
+		if b, err = models.ParseNumber(s); err != nil {
+			return "", err
+		}
+	}
+	return a.Add(b)
+}
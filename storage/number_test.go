@@ -0,0 +1,89 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"math/big"
+	"testing"
+
+	"cloud.google.com/go/spanner"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupNumberColumns(t *testing.T) {
+	tableDDL, spannerColumnTypes := models.TableDDL, models.SpannerColumnTypes
+	models.TableDDL = map[string]map[string]string{
+		"accounts": {"id": "N", "balance": "N", "legacy": "N", "huge": "N", "scores": "NS"},
+	}
+	models.SpannerColumnTypes = map[string]map[string]string{
+		"accounts": {"id": "NUMERIC", "balance": "NUMERIC", "legacy": "FLOAT64", "huge": "STRING(MAX)", "scores": "ARRAY<NUMERIC>"},
+	}
+	t.Cleanup(func() {
+		models.TableDDL, models.SpannerColumnTypes = tableDDL, spannerColumnTypes
+	})
+}
+
+func TestSpannerColumns(t *testing.T) {
+	setupNumberColumns(t)
+
+	columns, err := spannerColumns("accounts", map[string]interface{}{
+		"id":      models.Number("9007199254740993"),
+		"balance": models.Number("0.1"),
+		"legacy":  models.Number("1.5"),
+		"huge":    models.Number("1" + "00000000000000000000000000000000000000000"),
+		"scores":  []models.Number{"1", "2.5"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewRat(9007199254740993, 1), columns["id"])
+	assert.Equal(t, big.NewRat(1, 10), columns["balance"])
+	assert.Equal(t, 1.5, columns["legacy"])
+	assert.Equal(t, models.Number("1"+"00000000000000000000000000000000000000000").SortableString(), columns["huge"])
+	assert.Equal(t, []*big.Rat{big.NewRat(1, 1), big.NewRat(5, 2)}, columns["scores"])
+
+	_, err = spannerColumns("accounts", map[string]interface{}{"balance": models.Number("0.0000000001")})
+	assert.Error(t, err)
+}
+
+func TestSpannerParams(t *testing.T) {
+	setupNumberColumns(t)
+
+	stmt, err := spannerParams("accounts", spanner.Statement{
+		SQL: "SELECT * FROM accounts WHERE legacy > @filterExp1 AND huge = @filterExp2 AND balance BETWEEN @lo AND @hi AND id IN UNNEST(@ids) AND `legacy` IN (@legacy, @legacy_1)",
+		Params: map[string]interface{}{
+			"filterExp1": ColumnParam{Column: "legacy", Value: models.Number("2")},
+			"filterExp2": ColumnParam{Column: "huge", Value: models.Number("7")},
+			"lo":         ColumnParam{Column: "balance", Value: models.Number("0.5")},
+			"hi":         ColumnParam{Column: "balance", Value: models.Number("0.0000000001")},
+			"ids":        ColumnParam{Column: "id", Value: []interface{}{models.Number("1"), models.Number("2")}},
+			"legacy":     ColumnParam{Column: "legacy", Value: models.Number("3")},
+			"legacy_1":   ColumnParam{Column: "legacy", Value: []models.Number{"4", "5"}},
+			"unknown":    models.Number("6"),
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"filterExp1": float64(2),
+		"filterExp2": models.Number("7").SortableString(),
+		"lo":         big.NewRat(1, 2),
+		// comparisons may use more digits than NUMERIC columns hold
+		"hi":       big.NewRat(1, 10000000000),
+		"ids":      []*big.Rat{big.NewRat(1, 1), big.NewRat(2, 1)},
+		"legacy":   float64(3),
+		"legacy_1": []float64{4, 5},
+		// parameters of unknown columns are compared as NUMERIC
+		"unknown": big.NewRat(6, 1),
+	}, stmt.Params)
+}
@@ -0,0 +1,176 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"cloud.google.com/go/spanner"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/models"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/pkg/errors"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/utils"
+)
+
+// TableDatabase returns the path of the Spanner database that holds the table
+// and the session pool settings for its client. Tables without a route are
+// kept in the default database.
+func TableDatabase(table string) (string, models.Session) {
+	conf := models.GlobalConfig.Spanner
+	table = utils.ChangeTableNameForSpanner(table)
+
+	var match *models.TableRoute
+	for i := range conf.TableRouting {
+		route := &conf.TableRouting[i]
+		for _, name := range route.Tables {
+			if utils.ChangeTableNameForSpanner(name) == table {
+				return routeDatabase(route), routeSession(route)
+			}
+		}
+		prefix := utils.ChangeTableNameForSpanner(route.TablePrefix)
+		if prefix != "" && strings.HasPrefix(table, prefix) && (match == nil || len(prefix) > len(match.TablePrefix)) {
+			match = route
+		}
+	}
+	if match != nil {
+		return routeDatabase(match), routeSession(match)
+	}
+	return databasePath(), conf.Session
+}
+
+// databaseSessions returns the default database and every routed database
+// together with the session settings of their clients
+func databaseSessions() map[string]models.Session {
+	databases := map[string]models.Session{databasePath(): models.GlobalConfig.Spanner.Session}
+	for i := range models.GlobalConfig.Spanner.TableRouting {
+		route := &models.GlobalConfig.Spanner.TableRouting[i]
+		if _, ok := databases[routeDatabase(route)]; !ok {
+			databases[routeDatabase(route)] = routeSession(route)
+		}
+	}
+	return databases
+}
+
+func routeDatabase(route *models.TableRoute) string {
+	projectID, instanceID := route.ProjectID, route.InstanceID
+	if projectID == "" {
+		projectID = models.GlobalConfig.Spanner.ProjectID
+	}
+	if instanceID == "" {
+		instanceID = models.GlobalConfig.Spanner.InstanceID
+	}
+	return fmt.Sprintf("projects/%s/instances/%s/databases/%s", projectID, instanceID, route.DatabaseName)
+}
+
+func routeSession(route *models.TableRoute) models.Session {
+	session := models.GlobalConfig.Spanner.Session
+	if route.Session.Min != 0 {
+		session.Min = route.Session.Min
+	}
+	if route.Session.Max != 0 {
+		session.Max = route.Session.Max
+	}
+	if route.Session.GrpcChannels != 0 {
+		session.GrpcChannels = route.Session.GrpcChannels
+	}
+	return session
+}
+
+func databasePath() string {
+	return fmt.Sprintf("projects/%s/instances/%s/databases/%s",
+		models.GlobalConfig.Spanner.ProjectID,
+		models.GlobalConfig.Spanner.InstanceID,
+		models.GlobalConfig.Spanner.DatabaseName,
+	)
+}
+
+// getSpannerClient returns the client of the database that holds the table,
+// creating it with its own session pool on first use.
+func (s Storage) getSpannerClient(table string) (*spanner.Client, error) {
+	database, session := TableDatabase(table)
+	return s.clientForDatabase(database, session)
+}
+
+// clientForDatabase returns the client of the database. Clients are dialed
+// outside the lock, once for all the requests waiting on the database, so a
+// slow database does not hold up the requests to the others.
+func (s Storage) clientForDatabase(database string, session models.Session) (*spanner.Client, error) {
+	if client, ok := s.cachedClient(database); ok {
+		return client, nil
+	}
+	if s.newClient == nil {
+		return nil, errors.New("InternalServerError", "no Spanner client for database "+database)
+	}
+	dial := func() (interface{}, error) {
+		if client, ok := s.cachedClient(database); ok {
+			return client, nil
+		}
+		client, err := s.newClient(context.Background(), database, session)
+		if err != nil {
+			return nil, errors.New("InternalServerError", "failed to create Spanner client for database "+database, err)
+		}
+		if s.clientMu != nil {
+			s.clientMu.Lock()
+			defer s.clientMu.Unlock()
+		}
+		s.spannerClient[database] = client
+		return client, nil
+	}
+	var client interface{}
+	var err error
+	if s.dials != nil {
+		client, err, _ = s.dials.Do(database, dial)
+	} else {
+		client, err = dial()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return client.(*spanner.Client), nil
+}
+
+func (s Storage) cachedClient(database string) (*spanner.Client, bool) {
+	if s.clientMu != nil {
+		s.clientMu.RLock()
+		defer s.clientMu.RUnlock()
+	}
+	client, ok := s.spannerClient[database]
+	return client, ok
+}
+
+// GetSpannerClientForTables returns the client shared by all the tables of a
+// transaction. Spanner transactions cannot span databases, so tables routed to
+// different databases are rejected.
+func (s Storage) GetSpannerClientForTables(tables []string) (*spanner.Client, error) {
+	if len(tables) == 0 {
+		return s.getSpannerClient("")
+	}
+	byDatabase := make(map[string][]string)
+	for _, table := range tables {
+		database, _ := TableDatabase(table)
+		byDatabase[database] = append(byDatabase[database], table)
+	}
+	if len(byDatabase) > 1 {
+		var groups []string
+		for database, names := range byDatabase {
+			groups = append(groups, database+": "+strings.Join(names, ", "))
+		}
+		sort.Strings(groups)
+		return nil, errors.New("ValidationException", "Transactions cannot span tables in different Spanner databases ("+strings.Join(groups, "; ")+")")
+	}
+	return s.getSpannerClient(tables[0])
+}
@@ -0,0 +1,133 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"cloud.google.com/go/spanner"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/models"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/sync/singleflight"
+)
+
+func setupRouting(t *testing.T) {
+	original := models.GlobalConfig
+	models.GlobalConfig = &models.Config{Spanner: models.SpannerConfig{
+		ProjectID:    "proj",
+		InstanceID:   "main",
+		DatabaseName: "db",
+		Session:      models.Session{Min: 10, Max: 100, GrpcChannels: 4},
+		TableRouting: []models.TableRoute{
+			{TablePrefix: "eu_", InstanceID: "regional", DatabaseName: "eu"},
+			{TablePrefix: "eu_archive", InstanceID: "regional", DatabaseName: "archive", Session: models.Session{Max: 10}},
+			{Tables: []string{"eu-orders"}, ProjectID: "other", InstanceID: "orders", DatabaseName: "orders"},
+		},
+	}}
+	t.Cleanup(func() { models.GlobalConfig = original })
+}
+
+func TestTableDatabase(t *testing.T) {
+	setupRouting(t)
+
+	tests := []struct {
+		testName     string
+		table        string
+		wantDatabase string
+		wantSession  models.Session
+	}{
+		{"default database", "users", "projects/proj/instances/main/databases/db", models.Session{Min: 10, Max: 100, GrpcChannels: 4}},
+		{"prefix route", "eu_users", "projects/proj/instances/regional/databases/eu", models.Session{Min: 10, Max: 100, GrpcChannels: 4}},
+		{"longest prefix wins", "eu_archive_2020", "projects/proj/instances/regional/databases/archive", models.Session{Min: 10, Max: 10, GrpcChannels: 4}},
+		{"exact name wins over prefix", "eu_orders", "projects/other/instances/orders/databases/orders", models.Session{Min: 10, Max: 100, GrpcChannels: 4}},
+	}
+
+	for _, tc := range tests {
+		database, session := TableDatabase(tc.table)
+		assert.Equal(t, tc.wantDatabase, database, tc.testName)
+		assert.Equal(t, tc.wantSession, session, tc.testName)
+	}
+}
+
+func TestGetSpannerClientForTables(t *testing.T) {
+	setupRouting(t)
+
+	var created []string
+	s := Storage{
+		spannerClient: make(map[string]*spanner.Client),
+		clientMu:      &sync.RWMutex{},
+		dials:         &singleflight.Group{},
+		newClient: func(ctx context.Context, database string, session models.Session) (*spanner.Client, error) {
+			created = append(created, database)
+			return &spanner.Client{}, nil
+		},
+	}
+
+	client, err := s.GetSpannerClientForTables([]string{"eu_users", "eu_items"})
+	assert.NoError(t, err)
+	assert.NotNil(t, client)
+
+	// the client is created once and reused
+	_, err = s.getSpannerClient("eu_users")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"projects/proj/instances/regional/databases/eu"}, created)
+
+	_, err = s.GetSpannerClientForTables([]string{"users", "eu_users"})
+	assert.Equal(t, "ValidationException", err.Error())
+	assert.Contains(t, err.(*errors.Error).ErrorMessage, "Transactions cannot span tables in different Spanner databases")
+}
+
+func TestClientForDatabaseDialsOutsideLock(t *testing.T) {
+	setupRouting(t)
+	var mu sync.Mutex
+	dialed := map[string]int{}
+	slow := make(chan struct{})
+	s := Storage{
+		spannerClient: make(map[string]*spanner.Client),
+		clientMu:      &sync.RWMutex{},
+		dials:         &singleflight.Group{},
+		newClient: func(ctx context.Context, database string, session models.Session) (*spanner.Client, error) {
+			mu.Lock()
+			dialed[database]++
+			mu.Unlock()
+			if database == "projects/proj/instances/regional/databases/eu" {
+				<-slow
+			}
+			return &spanner.Client{}, nil
+		},
+	}
+
+	// the requests waiting on a slow database share one dial
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := s.getSpannerClient("eu_users")
+			assert.NoError(t, err)
+		}()
+	}
+
+	// and do not hold up the requests to other databases
+	_, err := s.getSpannerClient("users")
+	assert.NoError(t, err)
+	close(slow)
+	wg.Wait()
+	assert.Equal(t, 1, dialed["projects/proj/instances/regional/databases/eu"])
+	assert.Equal(t, 1, dialed["projects/proj/instances/main/databases/db"])
+}
@@ -18,12 +18,10 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	stderrors "errors"
 	"fmt"
-	"log"
-	"math"
 	"reflect"
 	"regexp"
-	"strconv"
 	"strings"
 	"time"
 
@@ -37,7 +35,9 @@ import (
 	"github.com/cloudspannerecosystem/dynamodb-adapter/utils"
 
 	"cloud.google.com/go/spanner"
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
 	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
 )
 
 var base64Regexp = regexp.MustCompile("^([A-Za-z0-9+/]{4})*([A-Za-z0-9+/]{3}=|[A-Za-z0-9+/]{2}==)?$")
@@ -53,19 +53,40 @@ const (
 	SpannerDelAnnotation          = "Calling SpannerDel Method"
 	SpannerRemoveAnnotation       = "Calling SpannerRemove Method"
 	SpannerBatchPutAnnotation     = "Calling SpannerBatchPut Method"
+	SpannerBatchWriteAnnotation   = "Calling SpannerBatchWrite Method"
+	SpannerIndexSchemaAnnotation  = "Calling SpannerIndexSchema Method"
+	ExplainQueryAnnotation        = "Calling ExplainQuery Method"
 )
 
+// FilterColumn is the BOOL column a query selects to tell whether a row
+// matches the FilterExpression of the request. Filtered rows are still read,
+// so they count against the Limit and the page size.
+const FilterColumn = "_filter_match"
+
+// AggregateColumn prefixes the columns a query selects aggregates as, like
+// _aggregate0. They are read as numbers, strings or bytes by their Spanner
+// type rather than by the type of a table column.
+const AggregateColumn = "_aggregate"
+
+// IsTimeout reports whether err is a read or write that ran out of time or
+// could not reach Spanner, which the client can retry
+func IsTimeout(err error) bool {
+	if stderrors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	switch spanner.ErrCode(err) {
+	case codes.DeadlineExceeded, codes.Unavailable:
+		return true
+	}
+	return false
+}
+
 // SpannerBatchGet - fetch all rows
 func (s Storage) SpannerBatchGet(ctx context.Context, tableName string, pKeys, sKeys []interface{}, projectionCols []string) ([]map[string]interface{}, error) {
 	otelgo.AddAnnotation(ctx, SpannerBatchGetAnnotation)
-	var keySet []spanner.KeySet
-
-	for i := range pKeys {
-		if len(sKeys) == 0 || sKeys[i] == nil {
-			keySet = append(keySet, spanner.Key{pKeys[i]})
-		} else {
-			keySet = append(keySet, spanner.Key{pKeys[i], sKeys[i]})
-		}
+	keySet, err := spannerKeySet(utils.ChangeTableNameForSpanner(tableName), pKeys, sKeys)
+	if err != nil {
+		return nil, err
 	}
 	if len(projectionCols) == 0 {
 		var ok bool
@@ -79,8 +100,11 @@ func (s Storage) SpannerBatchGet(ctx context.Context, tableName string, pKeys, s
 		return nil, errors.New("ResourceNotFoundException", tableName)
 	}
 	tableName = utils.ChangeTableNameForSpanner(tableName)
-	client := s.getSpannerClient(tableName)
-	itr := client.Single().Read(ctx, tableName, spanner.KeySets(keySet...), projectionCols)
+	client, err := s.getSpannerClient(tableName)
+	if err != nil {
+		return nil, err
+	}
+	itr := snapshotReader(ctx, client).Read(ctx, tableName, keySet, projectionCols)
 	defer itr.Stop()
 	allRows := []map[string]interface{}{}
 	for {
@@ -89,6 +113,9 @@ func (s Storage) SpannerBatchGet(ctx context.Context, tableName string, pKeys, s
 			if err == iterator.Done {
 				break
 			}
+			if IsTimeout(err) {
+				return nil, err
+			}
 			return nil, errors.New("ValidationException", err)
 		}
 		singleRow, _, err := parseRow(r, colDDL)
@@ -105,11 +132,13 @@ func (s Storage) SpannerBatchGet(ctx context.Context, tableName string, pKeys, s
 // SpannerGet - get with spanner
 func (s Storage) SpannerGet(ctx context.Context, tableName string, pKeys, sKeys interface{}, projectionCols []string) (map[string]interface{}, map[string]interface{}, error) {
 	otelgo.AddAnnotation(ctx, SpannerGetAnnotation)
-	var key spanner.Key
-	if sKeys == nil {
-		key = spanner.Key{pKeys}
-	} else {
-		key = spanner.Key{pKeys, sKeys}
+	key := spanner.Key{pKeys}
+	if sKeys != nil {
+		key = append(key, sKeys)
+	}
+	key, err := spannerKey(utils.ChangeTableNameForSpanner(tableName), key)
+	if err != nil {
+		return nil, nil, err
 	}
 	if len(projectionCols) == 0 {
 		var ok bool
@@ -123,7 +152,10 @@ func (s Storage) SpannerGet(ctx context.Context, tableName string, pKeys, sKeys
 		return nil, nil, errors.New("ResourceNotFoundException", tableName)
 	}
 	tableName = utils.ChangeTableNameForSpanner(tableName)
-	client := s.getSpannerClient(tableName)
+	client, err := s.getSpannerClient(tableName)
+	if err != nil {
+		return nil, nil, err
+	}
 	row, err := client.Single().ReadRow(ctx, tableName, key, projectionCols)
 	if err := errors.AssignError(err); err != nil {
 		return nil, nil, errors.New("ResourceNotFoundException", tableName, key, err)
@@ -141,12 +173,71 @@ func (s Storage) ExecuteSpannerQuery(ctx context.Context, table string, cols []s
 		return nil, errors.New("ResourceNotFoundException", table)
 	}
 
-	itr := s.getSpannerClient(table).Single().WithTimestampBound(spanner.ExactStaleness(time.Second*10)).Query(ctx, stmt)
-
+	stmt, err := spannerParams(utils.ChangeTableNameForSpanner(table), stmt)
+	if err != nil {
+		return nil, err
+	}
+	client, err := s.getSpannerClient(table)
+	if err != nil {
+		return nil, err
+	}
+	txn := client.Single().WithTimestampBound(spanner.ExactStaleness(time.Second * 10))
+	report, withStats := ctx.Value(queryStatsKey{}).(func(*QueryExplain))
+	if !withStats {
+		itr := txn.Query(ctx, stmt)
+		defer itr.Stop()
+		return queryRows(itr.Next, colDLL, isCountQuery)
+	}
+	itr := txn.QueryWithStats(ctx, stmt)
 	defer itr.Stop()
+	rows, err := queryRows(itr.Next, colDLL, isCountQuery)
+	if err != nil {
+		return nil, err
+	}
+	report(&QueryExplain{Plan: itr.QueryPlan, Stats: itr.QueryStats})
+	return rows, nil
+}
+
+// QueryExplain is the plan Spanner chose for a query and, when the query was
+// run with WithQueryStats, its execution statistics
+type QueryExplain struct {
+	Plan  *sppb.QueryPlan
+	Stats map[string]interface{}
+}
+
+type queryStatsKey struct{}
+
+// WithQueryStats returns a context whose queries are run by
+// ExecuteSpannerQuery with QueryWithStats. The plan and execution statistics
+// of each query are passed to report once its rows were read.
+func WithQueryStats(ctx context.Context, report func(*QueryExplain)) context.Context {
+	return context.WithValue(ctx, queryStatsKey{}, report)
+}
+
+// ExplainQuery returns the plan of a query, read with AnalyzeQuery without
+// running it
+func (s Storage) ExplainQuery(ctx context.Context, table string, stmt spanner.Statement) (*QueryExplain, error) {
+	otelgo.AddAnnotation(ctx, ExplainQueryAnnotation)
+	stmt, err := spannerParams(utils.ChangeTableNameForSpanner(table), stmt)
+	if err != nil {
+		return nil, err
+	}
+	client, err := s.getSpannerClient(table)
+	if err != nil {
+		return nil, err
+	}
+	plan, err := client.Single().WithTimestampBound(spanner.ExactStaleness(time.Second*10)).AnalyzeQuery(ctx, stmt)
+	if err != nil {
+		return nil, err
+	}
+	return &QueryExplain{Plan: plan}, nil
+}
+
+// queryRows parses the rows of a query returned by next until it reports iterator.Done
+func queryRows(next func() (*spanner.Row, error), colDLL map[string]string, isCountQuery bool) ([]map[string]interface{}, error) {
 	allRows := []map[string]interface{}{}
 	for {
-		r, err := itr.Next()
+		r, err := next()
 		if err == iterator.Done {
 			break
 		}
@@ -173,87 +264,170 @@ func (s Storage) ExecuteSpannerQuery(ctx context.Context, table string, cols []s
 	return allRows, nil
 }
 
-// SpannerPut - Spanner put insert a single object
-func (s Storage) SpannerPut(ctx context.Context, table string, m map[string]interface{}, eval *models.Eval, expr *models.UpdateExpressionCondition, spannerRow map[string]interface{}) (map[string]interface{}, error) {
-	otelgo.AddAnnotation(ctx, SpannerPutAnnotation)
-	update := map[string]interface{}{}
-	_, err := s.getSpannerClient(table).ReadWriteTransaction(ctx, func(ctx context.Context, t *spanner.ReadWriteTransaction) error {
-		tmpMap := map[string]interface{}{}
-		for k, v := range m {
-			switch v := v.(type) {
-			case []interface{}:
-				// Serialize lists to JSON
-				jsonValue, err := json.Marshal(v)
-				if err != nil {
-					return fmt.Errorf("failed to serialize column %s to JSON: %v", k, err)
-				}
-				tmpMap[k] = string(jsonValue)
-			default:
-				// Assign other types as-is
-				tmpMap[k] = v
-			}
+// IndexColumnSchema describes a single column of a secondary index as reported
+// by INFORMATION_SCHEMA. OrdinalPosition is zero for STORING columns.
+type IndexColumnSchema struct {
+	TableName       string
+	IndexName       string
+	ColumnName      string
+	OrdinalPosition int64
+	IsNullFiltered  bool
+}
+
+// SpannerIndexSchema - reads the key and STORING columns of every secondary index
+// in the default and routed databases. A strong read is used so newly created
+// indexes are visible.
+func (s Storage) SpannerIndexSchema(ctx context.Context) ([]IndexColumnSchema, error) {
+	otelgo.AddAnnotation(ctx, SpannerIndexSchemaAnnotation)
+	stmt := spanner.Statement{
+		SQL: `SELECT i.TABLE_NAME, i.INDEX_NAME, i.IS_NULL_FILTERED, c.COLUMN_NAME, c.ORDINAL_POSITION
+			FROM INFORMATION_SCHEMA.INDEXES AS i
+			JOIN INFORMATION_SCHEMA.INDEX_COLUMNS AS c
+			ON i.TABLE_SCHEMA = c.TABLE_SCHEMA AND i.TABLE_NAME = c.TABLE_NAME AND i.INDEX_NAME = c.INDEX_NAME
+			WHERE i.TABLE_SCHEMA = '' AND i.INDEX_TYPE = 'INDEX'`,
+	}
+
+	var columns []IndexColumnSchema
+	for database, session := range databaseSessions() {
+		client, err := s.clientForDatabase(database, session)
+		if err != nil {
+			return nil, err
 		}
-		if len(eval.Attributes) > 0 || expr != nil {
-			status, err := evaluateConditionalExpression(ctx, t, table, tmpMap, eval, expr)
-			if err != nil {
+		err = client.Single().Query(ctx, stmt).Do(func(r *spanner.Row) error {
+			var col IndexColumnSchema
+			var nullFiltered spanner.NullBool
+			var position spanner.NullInt64
+			if err := r.Columns(&col.TableName, &col.IndexName, &nullFiltered, &col.ColumnName, &position); err != nil {
 				return err
 			}
-			if !status {
-				return errors.New("ConditionalCheckFailedException", eval, expr)
+			col.IsNullFiltered = nullFiltered.Valid && nullFiltered.Bool
+			if position.Valid {
+				col.OrdinalPosition = position.Int64
 			}
+			columns = append(columns, col)
+			return nil
+		})
+		if err != nil {
+			return nil, errors.New("ResourceNotFoundException", err)
 		}
-		table = utils.ChangeTableNameForSpanner(table)
-		for k, v := range tmpMap {
-			update[k] = v
+	}
+	return columns, nil
+}
+
+// SpannerPut - Spanner put insert a single object
+func (s Storage) SpannerPut(ctx context.Context, table string, m map[string]interface{}, eval *models.Eval, expr *models.UpdateExpressionCondition, spannerRow map[string]interface{}) (map[string]interface{}, error) {
+	otelgo.AddAnnotation(ctx, SpannerPutAnnotation)
+	update := map[string]interface{}{}
+	client, err := s.getSpannerClient(table)
+	if err != nil {
+		return nil, err
+	}
+	_, err = client.ReadWriteTransaction(ctx, func(ctx context.Context, txn *spanner.ReadWriteTransaction) error {
+		t := SpannerTransaction{txn}
+		var columns map[string]interface{}
+		var err error
+		update, columns, err = putColumns(ctx, t, table, m, eval, expr)
+		if err != nil {
+			return err
 		}
-		return s.performPutOperation(ctx, t, table, tmpMap, spannerRow)
+		err = t.BufferWrite([]*Mutation{insertOrUpdateMutation(utils.ChangeTableNameForSpanner(table), columns)})
+		if e := errors.AssignError(err); e != nil {
+			return e
+		}
+		return nil
 	})
 	return update, err
 }
 
-// SpannerDelete - this will delete the data
-func (s Storage) SpannerDelete(ctx context.Context, table string, m map[string]interface{}, eval *models.Eval, expr *models.UpdateExpressionCondition) error {
-	otelgo.AddAnnotation(ctx, SpannerDeleteAnnotation)
-	_, err := s.getSpannerClient(table).ReadWriteTransaction(ctx, func(ctx context.Context, t *spanner.ReadWriteTransaction) error {
-		tmpMap := map[string]interface{}{}
-		for k, v := range m {
-			tmpMap[k] = v
-		}
-		if len(eval.Attributes) > 0 || expr != nil {
-			status, err := evaluateConditionalExpression(ctx, t, table, tmpMap, eval, expr)
-			if err != nil {
-				return err
-			}
-			if !status {
-				return errors.New("ConditionalCheckFailedException", tmpMap, expr)
-			}
-		}
-		tableConf, err := config.GetTableConf(table)
+// putColumns evaluates the condition of a put against the row read through r
+// and returns the written item and the column values to write
+func putColumns(ctx context.Context, r RowReader, table string, m map[string]interface{}, eval *models.Eval, expr *models.UpdateExpressionCondition) (map[string]interface{}, map[string]interface{}, error) {
+	update := map[string]interface{}{}
+	tmpMap := map[string]interface{}{}
+	for k, v := range m {
+		tmpMap[k] = v
+	}
+	updates := takeDocumentUpdates(table, tmpMap)
+	if len(eval.Attributes) > 0 || expr != nil {
+		status, err := evaluateConditionalExpression(ctx, r, table, tmpMap, eval, expr)
 		if err != nil {
-			return err
+			return update, nil, err
 		}
-		table = utils.ChangeTableNameForSpanner(table)
-
-		pKey := tableConf.PartitionKey
-		pValue, ok := tmpMap[pKey]
-		if !ok {
-			return errors.New("ResourceNotFoundException", pKey)
+		if !status {
+			return update, nil, errors.New("ConditionalCheckFailedException", eval, expr)
 		}
-		var key spanner.Key
-		sKey := tableConf.SortKey
-		if sKey != "" {
-			sValue, ok := tmpMap[sKey]
-			if !ok {
-				return errors.New("ResourceNotFoundException", pKey)
-			}
-			key = spanner.Key{pValue, sValue}
+	}
+	if err := applyDocumentUpdates(ctx, r, table, tmpMap, updates, documentSet); err != nil {
+		return update, nil, err
+	}
+	if err := checkItemSize(ctx, r, table, tmpMap); err != nil {
+		return update, nil, err
+	}
+	for k, v := range tmpMap {
+		update[k] = v
+	}
+	columns, err := putOperationColumns(utils.ChangeTableNameForSpanner(table), tmpMap)
+	return update, columns, err
+}
 
-		} else {
-			key = spanner.Key{pValue}
+// checkItemSize fails a write of the attributes in m, the key included, when
+// the item it leaves is larger than DynamoDB allows. Attributes written as
+// NULL are removed.
+func checkItemSize(ctx context.Context, r RowReader, table string, m map[string]interface{}) error {
+	tableConf, err := config.GetTableConf(table)
+	if err != nil {
+		return err
+	}
+	key := spanner.Key{m[tableConf.PartitionKey]}
+	if tableConf.SortKey != "" {
+		key = append(key, m[tableConf.SortKey])
+	}
+	spannerTable := utils.ChangeTableNameForSpanner(table)
+	colDDL := models.TableDDL[spannerTable]
+	cols := make([]string, 0, len(colDDL))
+	for col := range colDDL {
+		cols = append(cols, col)
+	}
+	row, err := r.ReadRow(ctx, spannerTable, key, cols)
+	if err != nil && !stderrors.Is(err, spanner.ErrRowNotFound) {
+		return errors.New("ResourceNotFoundException", err)
+	}
+	item, _, err := parseRow(row, colDDL)
+	if err != nil {
+		return err
+	}
+	for k, v := range m {
+		switch v.(type) {
+		case nil, spanner.NullableValue:
+			delete(item, k)
+		default:
+			item[k] = v
 		}
+	}
+	if models.ItemSize(item) <= models.MaxItemSize {
+		return nil
+	}
+	if row == nil {
+		return errors.New("ValidationException", "Item size has exceeded the maximum allowed size")
+	}
+	return errors.New("ValidationException", "Item size to update has exceeded the maximum allowed size")
+}
 
-		mutation := spanner.Delete(table, key)
-		err = t.BufferWrite([]*spanner.Mutation{mutation})
+// SpannerDelete - this will delete the data
+func (s Storage) SpannerDelete(ctx context.Context, table string, m map[string]interface{}, eval *models.Eval, expr *models.UpdateExpressionCondition) error {
+	otelgo.AddAnnotation(ctx, SpannerDeleteAnnotation)
+	client, err := s.getSpannerClient(table)
+	if err != nil {
+		return err
+	}
+	_, err = client.ReadWriteTransaction(ctx, func(ctx context.Context, txn *spanner.ReadWriteTransaction) error {
+		t := SpannerTransaction{txn}
+		key, err := deleteKey(ctx, t, table, m, eval, expr)
+		if err != nil {
+			return err
+		}
+		mutation := deleteMutation(utils.ChangeTableNameForSpanner(table), key)
+		err = t.BufferWrite([]*Mutation{mutation})
 		if e := errors.AssignError(err); e != nil {
 			return e
 		}
@@ -262,54 +436,135 @@ func (s Storage) SpannerDelete(ctx context.Context, table string, m map[string]i
 	return err
 }
 
+// deleteKey evaluates the condition of a delete against the row read through r
+// and returns the primary key of the row to delete
+func deleteKey(ctx context.Context, r RowReader, table string, m map[string]interface{}, eval *models.Eval, expr *models.UpdateExpressionCondition) (spanner.Key, error) {
+	tmpMap := map[string]interface{}{}
+	for k, v := range m {
+		tmpMap[k] = v
+	}
+	if len(eval.Attributes) > 0 || expr != nil {
+		status, err := evaluateConditionalExpression(ctx, r, table, tmpMap, eval, expr)
+		if err != nil {
+			return nil, err
+		}
+		if !status {
+			return nil, errors.New("ConditionalCheckFailedException", tmpMap, expr)
+		}
+	}
+	tableConf, err := config.GetTableConf(table)
+	if err != nil {
+		return nil, err
+	}
+
+	pKey := tableConf.PartitionKey
+	pValue, ok := tmpMap[pKey]
+	if !ok {
+		return nil, errors.New("ResourceNotFoundException", pKey)
+	}
+	sKey := tableConf.SortKey
+	if sKey != "" {
+		sValue, ok := tmpMap[sKey]
+		if !ok {
+			return nil, errors.New("ResourceNotFoundException", pKey)
+		}
+		return spanner.Key{pValue, sValue}, nil
+	}
+	return spanner.Key{pValue}, nil
+}
+
 // SpannerBatchDelete - this delete the data in batch
 func (s Storage) SpannerBatchDelete(ctx context.Context, table string, keys []map[string]interface{}) error {
 	otelgo.AddAnnotation(ctx, SpannerBatchDeleteAnnotation)
-	tableConf, err := config.GetTableConf(table)
+	spannerKeys, err := batchDeleteKeys(table, keys)
 	if err != nil {
 		return err
 	}
 	table = utils.ChangeTableNameForSpanner(table)
+	ms := make([]*spanner.Mutation, len(spannerKeys))
+	for i, key := range spannerKeys {
+		if ms[i], err = spannerMutation(deleteMutation(table, key)); err != nil {
+			return err
+		}
+	}
+	client, err := s.getSpannerClient(table)
+	if err != nil {
+		return err
+	}
+	_, err = client.Apply(ctx, ms)
+	if err != nil {
+		return errors.New("ResourceNotFoundException", err)
+	}
+	return nil
+}
+
+// batchDeleteKeys returns the primary keys of the rows deleted in batch
+func batchDeleteKeys(table string, keys []map[string]interface{}) ([]spanner.Key, error) {
+	tableConf, err := config.GetTableConf(table)
+	if err != nil {
+		return nil, err
+	}
 
 	pKey := tableConf.PartitionKey
-	ms := make([]*spanner.Mutation, len(keys))
+	spannerKeys := make([]spanner.Key, len(keys))
 	sKey := tableConf.SortKey
 	for i := 0; i < len(keys); i++ {
 		m := keys[i]
 		pValue, ok := m[pKey]
 		if !ok {
-			return errors.New("ResourceNotFoundException", pKey)
+			return nil, errors.New("ResourceNotFoundException", pKey)
 		}
-		var key spanner.Key
 		if sKey != "" {
 			sValue, ok := m[sKey]
 			if !ok {
-				return errors.New("ResourceNotFoundException", sKey)
+				return nil, errors.New("ResourceNotFoundException", sKey)
 			}
-			key = spanner.Key{pValue, sValue}
-
+			spannerKeys[i] = spanner.Key{pValue, sValue}
 		} else {
-			key = spanner.Key{pValue}
+			spannerKeys[i] = spanner.Key{pValue}
 		}
-		ms[i] = spanner.Delete(table, key)
 	}
-	_, err = s.getSpannerClient(table).Apply(ctx, ms)
-	if err != nil {
-		return errors.New("ResourceNotFoundException", err)
-	}
-	return nil
+	return spannerKeys, nil
 }
 
 // SpannerAdd - Spanner Add functionality like update attribute
 func (s Storage) SpannerAdd(ctx context.Context, table string, m map[string]interface{}, eval *models.Eval, expr *models.UpdateExpressionCondition) (map[string]interface{}, error) {
 	otelgo.AddAnnotation(ctx, SpannerAddAnnotation)
-	tableConf, err := config.GetTableConf(table)
+	updatedObj := map[string]interface{}{}
+	client, err := s.getSpannerClient(table)
 	if err != nil {
 		return nil, err
 	}
+	_, err = client.ReadWriteTransaction(ctx, func(ctx context.Context, txn *spanner.ReadWriteTransaction) error {
+		t := SpannerTransaction{txn}
+		var columns map[string]interface{}
+		var err error
+		updatedObj, columns, err = addColumns(ctx, t, table, m, eval, expr)
+		if err != nil {
+			return err
+		}
+		mutation := insertOrUpdateMutation(utils.ChangeTableNameForSpanner(table), columns)
+		err = t.BufferWrite([]*Mutation{mutation})
+		if err != nil {
+			return errors.New("ResourceNotFoundException", err)
+		}
+
+		return nil
+	})
+
+	return updatedObj, err
+}
+
+// addColumns evaluates the condition of an ADD update, adds the values of m to
+// the row read through r and returns the updated item and the columns to write
+func addColumns(ctx context.Context, r RowReader, table string, m map[string]interface{}, eval *models.Eval, expr *models.UpdateExpressionCondition) (map[string]interface{}, map[string]interface{}, error) {
+	tableConf, err := config.GetTableConf(table)
+	if err != nil {
+		return nil, nil, err
+	}
 	colDDL, ok := models.TableDDL[utils.ChangeTableNameForSpanner(table)]
 	if !ok {
-		return nil, errors.New("ResourceNotFoundException", table)
+		return nil, nil, errors.New("ResourceNotFoundException", table)
 	}
 	pKey := tableConf.PartitionKey
 	var pValue interface{}
@@ -318,17 +573,16 @@ func (s Storage) SpannerAdd(ctx context.Context, table string, m map[string]inte
 
 	cols := []string{}
 	var key spanner.Key
-	var m1 = make(map[string]interface{})
+	tmpMap := map[string]interface{}{}
 
+	updates := takeDocumentUpdates(table, m)
 	for k, v := range m {
-		m1[k] = v
+		tmpMap[k] = v
 		if k == pKey {
 			pValue = v
-			delete(m, k)
 			continue
 		}
 		if k == sKey {
-			delete(m, k)
 			sValue = v
 			continue
 		}
@@ -340,121 +594,109 @@ func (s Storage) SpannerAdd(ctx context.Context, table string, m map[string]inte
 		key = spanner.Key{pValue}
 	}
 
-	updatedObj := map[string]interface{}{}
-	_, err = s.getSpannerClient(table).ReadWriteTransaction(ctx, func(ctx context.Context, t *spanner.ReadWriteTransaction) error {
-		tmpMap := map[string]interface{}{}
-		for k, v := range m1 {
-			tmpMap[k] = v
+	if len(eval.Attributes) > 0 || expr != nil {
+		status, _ := evaluateConditionalExpression(ctx, r, table, tmpMap, eval, expr)
+		if !status {
+			return nil, nil, errors.New("ConditionalCheckFailedException")
 		}
+	}
+	table = utils.ChangeTableNameForSpanner(table)
 
-		if len(eval.Attributes) > 0 || expr != nil {
-			status, _ := evaluateConditionalExpression(ctx, t, table, tmpMap, eval, expr)
-			if !status {
-				return errors.New("ConditionalCheckFailedException")
-			}
-		}
-		table = utils.ChangeTableNameForSpanner(table)
+	row, err := r.ReadRow(ctx, table, key, cols)
+	if err != nil {
+		return nil, nil, errors.New("ResourceNotFoundException", err)
+	}
+	rs, _, err := parseRow(row, colDDL)
+	if err != nil {
+		return nil, nil, err
+	}
 
-		r, err := t.ReadRow(ctx, table, key, cols)
-		if err != nil {
-			return errors.New("ResourceNotFoundException", err)
-		}
-		rs, _, err := parseRow(r, colDDL)
-		if err != nil {
-			return err
-		}
+	if err := addExistingValues(rs, tmpMap); err != nil {
+		return nil, nil, err
+	}
 
-		for k, v := range tmpMap {
-			if existingVal, ok := rs[k]; ok {
-				switch existingVal := existingVal.(type) {
-				case int64:
-					// Handling int64
-					v2, ok := v.(float64)
-					if !ok {
-						strV, ok := v.(string)
-						if !ok {
-							return errors.New("ValidationException", reflect.TypeOf(v).String())
-						}
-						v2, err = strconv.ParseFloat(strV, 64)
-						if err != nil {
-							return errors.New("ValidationException", reflect.TypeOf(v).String())
-						}
-					}
-					tmpMap[k] = existingVal + int64(v2)
-
-				case float64:
-					// Handling float64
-					v2, ok := v.(float64)
-					if !ok {
-						strV, ok := v.(string)
-						if !ok {
-							return errors.New("ValidationException", reflect.TypeOf(v).String())
-						}
-						v2, err = strconv.ParseFloat(strV, 64)
-						if err != nil {
-							return errors.New("ValidationException", reflect.TypeOf(v).String())
-						}
-					}
-					tmpMap[k] = existingVal + v2
+	// Add partition and sort keys to the updated object
+	tmpMap[pKey] = pValue
+	if sValue != nil {
+		tmpMap[sKey] = sValue
+	}
+	if err := applyDocumentUpdates(ctx, r, tableConf.ActualTable, tmpMap, updates, documentAdd); err != nil {
+		return nil, nil, err
+	}
 
-				default:
-					logger.LogDebug(reflect.TypeOf(v).String())
-				}
+	if err := checkItemSize(ctx, r, tableConf.ActualTable, tmpMap); err != nil {
+		return nil, nil, err
+	}
+	updatedObj := map[string]interface{}{}
+	ddl := models.TableDDL[table]
+	for k, v := range tmpMap {
+		updatedObj[k] = v
+		t, ok := ddl[k]
+		if t == "BYTES(MAX)" && ok {
+			ba, err := json.Marshal(v)
+			if err != nil {
+				return nil, nil, errors.New("ValidationException", err)
 			}
+			tmpMap[k] = ba
 		}
+	}
+	return updatedObj, tmpMap, nil
+}
 
-		// Add partition and sort keys to the updated object
-		tmpMap[pKey] = pValue
-		if sValue != nil {
-			tmpMap[sKey] = sValue
-		}
-
-		ddl := models.TableDDL[table]
-		for k, v := range tmpMap {
-			updatedObj[k] = v
-			t, ok := ddl[k]
-			if t == "BYTES(MAX)" && ok {
-				ba, err := json.Marshal(v)
-				if err != nil {
-					return errors.New("ValidationException", err)
-				}
-				tmpMap[k] = ba
-			}
-			switch v := v.(type) {
-			case []interface{}:
-				// Serialize lists to JSON
-				jsonValue, err := json.Marshal(v)
+// addExistingValues adds the numeric values of tmpMap to the values of the
+// existing row rs
+func addExistingValues(rs, tmpMap map[string]interface{}) error {
+	for k, v := range tmpMap {
+		if existingVal, ok := rs[k]; ok {
+			switch existingVal.(type) {
+			case models.Number, int64, float64:
+				sum, err := addNumbers(existingVal, v)
 				if err != nil {
-					return fmt.Errorf("failed to serialize column %s to JSON: %v", k, err)
+					return err
 				}
-				tmpMap[k] = string(jsonValue)
+				tmpMap[k] = sum
 			default:
-				// Assign other types as-is
-				tmpMap[k] = v
+				logger.LogDebug(reflect.TypeOf(v).String())
 			}
 		}
+	}
+	return nil
+}
+
+func (s Storage) SpannerDel(ctx context.Context, table string, m map[string]interface{}, eval *models.Eval, expr *models.UpdateExpressionCondition) error {
+	otelgo.AddAnnotation(ctx, SpannerDelAnnotation)
+	client, err := s.getSpannerClient(table)
+	if err != nil {
+		return err
+	}
+	_, err = client.ReadWriteTransaction(ctx, func(ctx context.Context, txn *spanner.ReadWriteTransaction) error {
+		t := SpannerTransaction{txn}
+		columns, err := delColumns(ctx, t, table, m, eval, expr)
+		if err != nil {
+			return err
+		}
 
-		mutation := spanner.InsertOrUpdateMap(table, tmpMap)
-		err = t.BufferWrite([]*spanner.Mutation{mutation})
+		// Perform the delete operation by updating the row
+		mutation := insertOrUpdateMutation(utils.ChangeTableNameForSpanner(table), columns)
+		err = t.BufferWrite([]*Mutation{mutation})
 		if err != nil {
 			return errors.New("ResourceNotFoundException", err)
 		}
-
 		return nil
 	})
-
-	return updatedObj, err
+	return err
 }
 
-func (s Storage) SpannerDel(ctx context.Context, table string, m map[string]interface{}, eval *models.Eval, expr *models.UpdateExpressionCondition) error {
-	otelgo.AddAnnotation(ctx, SpannerDelAnnotation)
+// delColumns evaluates the condition of a DELETE update, removes the elements
+// in m from the sets of the row read through r and returns the columns to write
+func delColumns(ctx context.Context, r RowReader, table string, m map[string]interface{}, eval *models.Eval, expr *models.UpdateExpressionCondition) (map[string]interface{}, error) {
 	tableConf, err := config.GetTableConf(table)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	colDDL, ok := models.TableDDL[utils.ChangeTableNameForSpanner(table)]
 	if !ok {
-		return errors.New("ResourceNotFoundException", table)
+		return nil, errors.New("ResourceNotFoundException", table)
 	}
 	pKey := tableConf.PartitionKey
 	var pValue interface{}
@@ -464,20 +706,21 @@ func (s Storage) SpannerDel(ctx context.Context, table string, m map[string]inte
 	cols := []string{}
 	var key spanner.Key
 	var m1 = make(map[string]interface{})
+	tmpMap := map[string]interface{}{}
 
+	updates := takeDocumentUpdates(table, m)
 	// Process primary and secondary keys
 	for k, v := range m {
 		m1[k] = v
 		if k == pKey {
 			pValue = v
-			delete(m, k)
 			continue
 		}
 		if k == sKey {
-			delete(m, k)
 			sValue = v
 			continue
 		}
+		tmpMap[k] = v
 		cols = append(cols, k)
 	}
 	if sValue != nil {
@@ -486,89 +729,106 @@ func (s Storage) SpannerDel(ctx context.Context, table string, m map[string]inte
 		key = spanner.Key{pValue}
 	}
 
-	_, err = s.getSpannerClient(table).ReadWriteTransaction(ctx, func(ctx context.Context, t *spanner.ReadWriteTransaction) error {
-		tmpMap := map[string]interface{}{}
-		for k, v := range m {
-			tmpMap[k] = v
+	// Evaluate conditional expressions
+	if len(eval.Attributes) > 0 || expr != nil {
+		status, _ := evaluateConditionalExpression(ctx, r, table, m1, eval, expr)
+		if !status {
+			return nil, errors.New("ConditionalCheckFailedException")
 		}
+	}
 
-		// Evaluate conditional expressions
-		if len(eval.Attributes) > 0 || expr != nil {
-			status, _ := evaluateConditionalExpression(ctx, t, table, m1, eval, expr)
-			if !status {
-				return errors.New("ConditionalCheckFailedException")
-			}
-		}
+	table = utils.ChangeTableNameForSpanner(table)
 
-		table = utils.ChangeTableNameForSpanner(table)
+	// Read the row
+	row, err := r.ReadRow(ctx, table, key, cols)
+	if err != nil {
+		return nil, errors.New("ResourceNotFoundException", err)
+	}
+	rs, _, err := parseRow(row, colDDL)
+	if err != nil {
+		return nil, err
+	}
 
-		// Read the row
-		r, err := t.ReadRow(ctx, table, key, cols)
-		if err != nil {
-			return errors.New("ResourceNotFoundException", err)
-		}
-		rs, _, err := parseRow(r, colDDL)
-		if err != nil {
-			return err
-		}
+	// Process and merge data for deletion
+	deleteExistingValues(rs, tmpMap)
+	tmpMap[pKey] = pValue
+	if sValue != nil {
+		tmpMap[sKey] = sValue
+	}
+	if err := applyDocumentUpdates(ctx, r, tableConf.ActualTable, tmpMap, updates, documentDelete); err != nil {
+		return nil, err
+	}
 
-		// Process and merge data for deletion
-		for k, v := range tmpMap {
-			v1, ok := rs[k]
-			if ok {
-				switch v1.(type) {
-				case []interface{}:
-					var ifaces1 []interface{}
-					ba, ok := v.([]byte)
-					if ok {
-						err = json.Unmarshal(ba, &ifaces1)
-						if err != nil {
-							logger.LogError(err, string(ba))
-						}
-					} else {
-						ifaces1 = v.([]interface{})
-					}
-					m1 := map[interface{}]struct{}{}
-					ifaces := v1.([]interface{})
-					for i := 0; i < len(ifaces); i++ {
-						m1[reflect.ValueOf(ifaces[i]).Interface()] = struct{}{}
-					}
-					for i := 0; i < len(ifaces1); i++ {
+	ddl := models.TableDDL[table]
 
-						delete(m1, reflect.ValueOf(ifaces1[i]).Interface())
-					}
-					ifaces = []interface{}{}
-					for k := range m1 {
-						ifaces = append(ifaces, k)
-					}
-					tmpMap[k] = ifaces
-				default:
-					logger.LogDebug(reflect.TypeOf(v).String())
-				}
+	// Handle special cases like BYTES(MAX) columns
+	for k, v := range tmpMap {
+		t, ok := ddl[k]
+		if t == "BYTES(MAX)" && ok {
+			ba, err := json.Marshal(v)
+			if err != nil {
+				return nil, errors.New("ValidationException", err)
 			}
+			tmpMap[k] = ba
 		}
-		tmpMap[pKey] = pValue
-		if sValue != nil {
-			tmpMap[sKey] = sValue
-		}
+	}
+	return tmpMap, nil
+}
 
-		ddl := models.TableDDL[table]
+// deleteExistingValues removes the elements of the lists in tmpMap from the
+// lists of the existing row rs
+func deleteExistingValues(rs, tmpMap map[string]interface{}) {
+	for k, v := range tmpMap {
+		v1, ok := rs[k]
+		if ok {
+			switch v1.(type) {
+			case []interface{}:
+				var ifaces1 []interface{}
+				ba, ok := v.([]byte)
+				if ok {
+					err := json.Unmarshal(ba, &ifaces1)
+					if err != nil {
+						logger.LogError(err, string(ba))
+					}
+				} else {
+					ifaces1 = v.([]interface{})
+				}
+				m1 := map[interface{}]struct{}{}
+				ifaces := v1.([]interface{})
+				for i := 0; i < len(ifaces); i++ {
+					m1[reflect.ValueOf(ifaces[i]).Interface()] = struct{}{}
+				}
+				for i := 0; i < len(ifaces1); i++ {
 
-		// Handle special cases like BYTES(MAX) columns
-		for k, v := range tmpMap {
-			t, ok := ddl[k]
-			if t == "BYTES(MAX)" && ok {
-				ba, err := json.Marshal(v)
-				if err != nil {
-					return errors.New("ValidationException", err)
+					delete(m1, reflect.ValueOf(ifaces1[i]).Interface())
+				}
+				ifaces = []interface{}{}
+				for k := range m1 {
+					ifaces = append(ifaces, k)
 				}
-				tmpMap[k] = ba
+				tmpMap[k] = ifaces
+			default:
+				logger.LogDebug(reflect.TypeOf(v).String())
 			}
 		}
+	}
+}
 
-		// Perform the delete operation by updating the row
-		mutation := spanner.InsertOrUpdateMap(table, tmpMap)
-		err = t.BufferWrite([]*spanner.Mutation{mutation})
+// SpannerRemove - Spanner Remove functionality like update attribute
+func (s Storage) SpannerRemove(ctx context.Context, table string, m map[string]interface{}, eval *models.Eval, expr *models.UpdateExpressionCondition, colsToRemove []string, oldRes map[string]interface{}) error {
+	otelgo.AddAnnotation(ctx, SpannerRemoveAnnotation)
+	client, err := s.getSpannerClient(table)
+	if err != nil {
+		return err
+	}
+	_, err = client.ReadWriteTransaction(ctx, func(ctx context.Context, txn *spanner.ReadWriteTransaction) error {
+		t := SpannerTransaction{txn}
+		columns, err := removeColumns(ctx, t, table, m, eval, expr, colsToRemove, oldRes)
+		if err != nil {
+			return err
+		}
+		mutation := insertOrUpdateMutation(utils.ChangeTableNameForSpanner(table), columns)
+		err = t.BufferWrite([]*Mutation{mutation})
 		if err != nil {
 			return errors.New("ResourceNotFoundException", err)
 		}
@@ -577,62 +837,56 @@ func (s Storage) SpannerDel(ctx context.Context, table string, m map[string]inte
 	return err
 }
 
-// SpannerRemove - Spanner Remove functionality like update attribute
-func (s Storage) SpannerRemove(ctx context.Context, table string, m map[string]interface{}, eval *models.Eval, expr *models.UpdateExpressionCondition, colsToRemove []string, oldRes map[string]interface{}) error {
-	otelgo.AddAnnotation(ctx, SpannerRemoveAnnotation)
-	_, err := s.getSpannerClient(table).ReadWriteTransaction(ctx, func(ctx context.Context, t *spanner.ReadWriteTransaction) error {
-		tmpMap := map[string]interface{}{}
-		for k, v := range m {
-			tmpMap[k] = v
-		}
-		if len(eval.Attributes) > 0 || expr != nil {
-			status, _ := evaluateConditionalExpression(ctx, t, table, m, eval, expr)
-			if !status {
-				return errors.New("ConditionalCheckFailedException")
-			}
+// removeColumns evaluates the condition of a REMOVE update and returns the
+// columns to write, with the removed attributes set to NULL
+func removeColumns(ctx context.Context, r RowReader, table string, m map[string]interface{}, eval *models.Eval, expr *models.UpdateExpressionCondition, colsToRemove []string, oldRes map[string]interface{}) (map[string]interface{}, error) {
+	tmpMap := map[string]interface{}{}
+	for k, v := range m {
+		tmpMap[k] = v
+	}
+	if len(eval.Attributes) > 0 || expr != nil {
+		status, _ := evaluateConditionalExpression(ctx, r, table, m, eval, expr)
+		if !status {
+			return nil, errors.New("ConditionalCheckFailedException")
 		}
+	}
 
-		// Process each removal target
-		for _, target := range colsToRemove {
-			if strings.Contains(target, "[") && strings.Contains(target, "]") {
-				// Handle list element removal
-				listAttr, idx := utils.ParseListRemoveTarget(target)
-				if val, ok := oldRes[listAttr]; ok {
-					if list, ok := val.([]interface{}); ok {
-						oldRes[listAttr] = utils.RemoveListElement(list, idx)
-						tmpMap[listAttr] = oldRes[listAttr]
-					}
+	// Process each removal target
+	for _, target := range colsToRemove {
+		if strings.Contains(target, "[") && strings.Contains(target, "]") {
+			// Handle list element removal
+			listAttr, idx := utils.ParseListRemoveTarget(target)
+			if val, ok := oldRes[listAttr]; ok {
+				if list, ok := val.([]interface{}); ok {
+					oldRes[listAttr] = utils.RemoveListElement(list, idx)
+					tmpMap[listAttr] = oldRes[listAttr]
 				}
-			} else {
-				// Direct column removal from oldRes
-				delete(oldRes, target)
 			}
-		}
-		// Handle special cases like BYTES(MAX) columns
-		for k, v := range tmpMap {
-			switch v := v.(type) {
-			case []interface{}:
-				// Serialize lists to JSON
-				jsonValue, err := json.Marshal(v)
-				if err != nil {
-					return fmt.Errorf("failed to serialize column %s to JSON: %v", k, err)
-				}
-				tmpMap[k] = string(jsonValue)
-			default:
-				// Assign other types as-is
-				tmpMap[k] = v
+		} else {
+			// Direct column removal from oldRes
+			delete(oldRes, target)
+			if _, ok := models.TableDDL[utils.ChangeTableNameForSpanner(table)][target]; ok {
+				var null spanner.NullableValue
+				tmpMap[target] = null
 			}
 		}
-
-		table = utils.ChangeTableNameForSpanner(table)
-		mutation := spanner.InsertOrUpdateMap(table, tmpMap)
-		err := t.BufferWrite([]*spanner.Mutation{mutation})
-		if err != nil {
-			return errors.New("ResourceNotFoundException", err)
+	}
+	// Handle special cases like BYTES(MAX) columns
+	for k, v := range tmpMap {
+		switch v := v.(type) {
+		case []interface{}:
+			// Serialize lists to JSON
+			jsonValue, err := json.Marshal(v)
+			if err != nil {
+				return nil, fmt.Errorf("failed to serialize column %s to JSON: %v", k, err)
+			}
+			tmpMap[k] = string(jsonValue)
+		default:
+			// Assign other types as-is
+			tmpMap[k] = v
 		}
-		return nil
-	})
-	return err
+	}
+	return tmpMap, nil
 }
 
 // SpannerBatchPut - this insert or update data in batch
@@ -642,150 +896,149 @@ func (s Storage) SpannerBatchPut(ctx context.Context, table string, m []map[stri
 	ddl := models.TableDDL[utils.ChangeTableNameForSpanner(table)]
 	table = utils.ChangeTableNameForSpanner(table)
 	for i := 0; i < len(m); i++ {
-		for k, v := range m[i] {
-			// t, ok := ddl[k]
-			if strings.Contains(k, ".") {
-				pathfeilds := strings.Split(k, ".")
-				colName := pathfeilds[0]
-				t, ok := ddl[colName]
-				if t == "JSON" || t == "M" && ok {
-
-					var err error
-					// Store the updated JSON in the map
-					m[i][colName], err = updateMapColumnObject(spannerRow[i], colName, k, v)
-					if err != nil {
-						return errors.New("Error updating the Map object:", err)
-					}
-					delete(m[i], k)
-				}
-			} else {
-				t, ok := ddl[k]
-				if t == "BYTES(MAX)" || t == "B" && ok {
-					ba, err := json.Marshal(v)
-					if err != nil {
-						return errors.New("ValidationException", err)
-					}
-					m[i][k] = ba
-				}
-				if t == "M" && ok {
-					ba, err := json.MarshalIndent(v, "", "  ")
-					if err != nil {
-						return errors.New("ValidationException", err)
-					}
-					m[i][k] = string(ba)
-				}
-				if t == "L" && ok {
-					list, ok := v.([]interface{})
-					if !ok {
-						return errors.New("invalid list format")
-					}
-
-					jsonData, err := json.Marshal(list)
-					if err != nil {
-						return fmt.Errorf("error marshaling list to JSON: %w", err)
-					}
-
-					m[i][k] = string(jsonData)
-				}
-
-			}
+		if err := batchPutColumns(ddl, m[i]); err != nil {
+			return err
+		}
+		mutation, err := spannerMutation(insertOrUpdateMutation(table, m[i]))
+		if err != nil {
+			return err
 		}
-		mutations[i] = spanner.InsertOrUpdateMap(table, m[i])
+		mutations[i] = mutation
 	}
-	_, err := s.getSpannerClient(table).Apply(ctx, mutations)
+	client, err := s.getSpannerClient(table)
+	if err != nil {
+		return err
+	}
+	_, err = client.Apply(ctx, mutations)
 	if err != nil {
 		return errors.New("ResourceNotFoundException", err.Error())
 	}
 	return nil
 }
 
-// performPutOperation handles the insertion or update of data in a specified Spanner table.
-// It processes the provided mapping to account for JSON fields and handles it accordingly.
-//
-// Parameters:
-// - ctx: The context for managing timeouts and cancellation signals.
-// - t: A pointer to a ReadWriteTransaction that allows for transaction operations.
-// - table: The name of the table where the data will be inserted or updated.
-// - m: A map containing field name-value pairs to be written to the database.
-// - spannerRow: A map representing the current state of the row in the database, used for reading nested JSON fields.
-//
-// Returns:
-// - An error if the operation fails or nil if the operation succeeds.
-func (s Storage) performPutOperation(ctx context.Context, t *spanner.ReadWriteTransaction, table string, m map[string]interface{}, spannerRow map[string]interface{}) error {
-	ddl := models.TableDDL[table]
-	newMap := m
-	for k, v := range m {
-		if strings.Contains(k, ".") {
-			pathfeilds := strings.Split(k, ".")
-			colName := pathfeilds[0]
-			t, ok := ddl[colName]
-			if t == "M" && ok {
-				var err error
-				newMap[colName], err = updateMapColumnObject(spannerRow, colName, k, v)
-				if err != nil {
-					return errors.New("Error updating the Map:", err)
-				}
-				delete(newMap, k)
-			}
-		} else {
-			t, ok := ddl[k]
-			if t == "B" && ok {
-				ba, err := json.Marshal(v)
-				if err != nil {
-					return errors.New("ValidationException", err)
-				}
-				newMap[k] = ba
-			}
-			if t == "M" && ok {
-				if v == nil {
-					continue
-				}
-				ba, err := json.MarshalIndent(v, "", "  ")
-				if err != nil {
-					return errors.New("ValidationException", err)
-				}
-				newMap[k] = string(ba)
-			}
+// SpannerBatchWrite puts and deletes rows of the table in batch. Every row is
+// written by its own mutation group, which Spanner applies independently, and
+// the indexes of the puts and deletes that were not applied are returned.
+func (s Storage) SpannerBatchWrite(ctx context.Context, table string, puts, deletes []map[string]interface{}) ([]int, []int, error) {
+	otelgo.AddAnnotation(ctx, SpannerBatchWriteAnnotation)
+	ms, err := batchWriteMutations(table, puts, deletes)
+	if err != nil {
+		return nil, nil, err
+	}
+	groups := make([]*spanner.MutationGroup, len(ms))
+	for i, m := range ms {
+		mutation, err := spannerMutation(m)
+		if err != nil {
+			return nil, nil, err
 		}
+		groups[i] = &spanner.MutationGroup{Mutations: []*spanner.Mutation{mutation}}
 	}
-	mutation := spanner.InsertOrUpdateMap(table, newMap)
-
-	mutations := []*spanner.Mutation{mutation}
-
-	err := t.BufferWrite(mutations)
-	if e := errors.AssignError(err); e != nil {
-		return e
+	client, err := s.getSpannerClient(utils.ChangeTableNameForSpanner(table))
+	if err != nil {
+		return nil, nil, err
 	}
-	return nil
+	applied := make([]bool, len(groups))
+	count := 0
+	err = client.BatchWrite(ctx, groups).Do(func(r *sppb.BatchWriteResponse) error {
+		if r.GetStatus().GetCode() != 0 {
+			logger.LogError("batch write of ", table, " failed: ", r.GetStatus().GetMessage())
+			return nil
+		}
+		for _, i := range r.GetIndexes() {
+			applied[i] = true
+			count++
+		}
+		return nil
+	})
+	if err != nil && count == 0 {
+		return nil, nil, errors.New("ResourceNotFoundException", err.Error())
+	}
+	failedPuts, failedDeletes := batchWriteFailures(applied, len(puts))
+	return failedPuts, failedDeletes, nil
 }
 
-// updateMapColumnObject updates the fields in a given JSON object for the Map Datatype
-func updateMapColumnObject(spannerRow map[string]interface{}, colName string, k string, v interface{}) (map[string]interface{}, error) {
-	var data map[string]interface{}
-	jsonData := spannerRow[colName]
+// batchWriteMutations returns the mutations of a batch write, the puts
+// followed by the deletes
+func batchWriteMutations(table string, puts, deletes []map[string]interface{}) ([]*Mutation, error) {
+	var keys []spanner.Key
+	if len(deletes) > 0 {
+		var err error
+		if keys, err = batchDeleteKeys(table, deletes); err != nil {
+			return nil, err
+		}
+	}
+	table = utils.ChangeTableNameForSpanner(table)
+	ddl := models.TableDDL[table]
+	ms := make([]*Mutation, 0, len(puts)+len(keys))
+	for _, row := range puts {
+		if err := batchPutColumns(ddl, row); err != nil {
+			return nil, err
+		}
+		ms = append(ms, insertOrUpdateMutation(table, row))
+	}
+	for _, key := range keys {
+		ms = append(ms, deleteMutation(table, key))
+	}
+	return ms, nil
+}
 
-	// jsonData should be assumed to be a JSON object. If it's already marshaled, just convert it to a string.
-	jsonBytes, err := json.Marshal(jsonData) // Only if jsonData needs to be marshaled
-	if err != nil {
-		log.Fatalf("error marshalling JSON: %v", err)
+// batchWriteFailures splits the mutations of a batch write that were not
+// applied into the indexes of the failed puts and deletes
+func batchWriteFailures(applied []bool, puts int) ([]int, []int) {
+	var failedPuts, failedDeletes []int
+	for i, ok := range applied {
+		switch {
+		case ok:
+		case i < puts:
+			failedPuts = append(failedPuts, i)
+		default:
+			failedDeletes = append(failedDeletes, i-puts)
+		}
 	}
+	return failedPuts, failedDeletes
+}
 
-	// Unmarshal into a map for manipulation
-	if err := json.Unmarshal(jsonBytes, &data); err != nil {
-		log.Fatalf("Error unmarshalling JSON: %v", err)
+// batchPutColumns converts the attributes of a batch put row into the column
+// values written to the table
+func batchPutColumns(ddl map[string]string, row map[string]interface{}) error {
+	for k, v := range row {
+		t, ok := ddl[k]
+		if t == "BYTES(MAX)" || t == "B" && ok {
+			ba, err := json.Marshal(v)
+			if err != nil {
+				return errors.New("ValidationException", err)
+			}
+			row[k] = ba
+		}
 	}
+	return nil
+}
 
-	// Updating the field
-	if updated := utils.UpdateFieldByPath(data, k, v); updated {
-		log.Println("Update successful")
-	} else {
-		log.Println("Update failed: path not found")
+// putOperationColumns converts the attributes of a put into the column values
+// written to the table
+func putOperationColumns(table string, m map[string]interface{}) (map[string]interface{}, error) {
+	ddl := models.TableDDL[table]
+	newMap := m
+	for k, v := range m {
+		t, ok := ddl[k]
+		if t == "B" && ok {
+			ba, err := json.Marshal(v)
+			if err != nil {
+				return nil, errors.New("ValidationException", err)
+			}
+			newMap[k] = ba
+		}
 	}
+	return newMap, nil
+}
 
-	return data, nil
+// RowReader reads a single row by its primary key. It is implemented by Spanner
+// read-write transactions and by the reader of the in-memory storage.
+type RowReader interface {
+	ReadRow(ctx context.Context, table string, key spanner.Key, columns []string) (*spanner.Row, error)
 }
 
-func evaluateConditionalExpression(ctx context.Context, t *spanner.ReadWriteTransaction, table string, m map[string]interface{}, e *models.Eval, expr *models.UpdateExpressionCondition) (bool, error) {
+func evaluateConditionalExpression(ctx context.Context, t RowReader, table string, m map[string]interface{}, e *models.Eval, expr *models.UpdateExpressionCondition) (bool, error) {
 	colDDL, ok := models.TableDDL[utils.ChangeTableNameForSpanner(table)]
 	if !ok {
 		return false, errors.New("ResourceNotFoundException", table)
@@ -849,11 +1102,8 @@ func evaluateConditionalExpression(ctx context.Context, t *spanner.ReadWriteTran
 			if !ok || !tmp {
 				if v1, ok := expr.AddValues[expr.Field[index]]; ok {
 
-					tmp, ok := rowMap[expr.Field[index]].(float64)
-					if ok {
-						m[expr.Field[index]] = tmp + v1
-						err = checkInifinty(m[expr.Field[index]].(float64), expr)
-						if err != nil {
+					if tmp, ok := rowMap[expr.Field[index]]; ok {
+						if m[expr.Field[index]], err = addNumbers(tmp, v1); err != nil {
 							return false, err
 						}
 					}
@@ -862,11 +1112,8 @@ func evaluateConditionalExpression(ctx context.Context, t *spanner.ReadWriteTran
 				}
 			} else {
 				if v1, ok := expr.AddValues[expr.Field[index]]; ok {
-					tmp, ok := m[expr.Field[index]].(float64)
-					if ok {
-						m[expr.Field[index]] = tmp + v1
-						err = checkInifinty(m[expr.Field[index]].(float64), expr)
-						if err != nil {
+					if tmp, ok := m[expr.Field[index]]; ok {
+						if m[expr.Field[index]], err = addNumbers(tmp, v1); err != nil {
 							return false, err
 						}
 					}
@@ -875,14 +1122,10 @@ func evaluateConditionalExpression(ctx context.Context, t *spanner.ReadWriteTran
 			delete(expr.AddValues, expr.Field[index])
 		}
 		for k, v := range expr.AddValues {
-			val, ok := rowMap[k].(float64)
-			if ok {
-				m[k] = val + v
-				err = checkInifinty(m[k].(float64), expr)
-				if err != nil {
+			if val, ok := rowMap[k]; ok {
+				if m[k], err = addNumbers(val, v); err != nil {
 					return false, err
 				}
-
 			} else {
 				m[k] = v
 			}
@@ -967,6 +1210,20 @@ func parseRow(r *spanner.Row, colDDL map[string]string) (map[string]interface{},
 		if k == "" || k == "commit_timestamp" {
 			continue
 		}
+		if k == FilterColumn {
+			var match spanner.NullBool
+			if err := r.Column(i, &match); err != nil {
+				return nil, nil, errors.New("ValidationException", err, k)
+			}
+			singleRow[k] = match.Valid && match.Bool
+			continue
+		}
+		if strings.HasPrefix(k, AggregateColumn) {
+			if err := parseAggregateColumn(r, i, k, singleRow); err != nil {
+				return nil, nil, errors.New("ValidationException", err, k)
+			}
+			continue
+		}
 		v, ok := colDDL[k]
 		if !ok {
 			return nil, nil, errors.New("ResourceNotFoundException", k)
@@ -1004,6 +1261,34 @@ func parseRow(r *spanner.Row, colDDL map[string]string) (map[string]interface{},
 	return singleRow, spannerRow, nil
 }
 
+// parseAggregateColumn parses an aggregate column from a Spanner row by its
+// Spanner type. Numeric values are parsed as numbers.
+func parseAggregateColumn(r *spanner.Row, idx int, col string, row map[string]interface{}) error {
+	switch r.ColumnType(idx).GetCode() {
+	case sppb.TypeCode_STRING:
+		var s spanner.NullString
+		if err := r.Column(idx, &s); err != nil {
+			return err
+		}
+		row[col] = nil
+		if s.Valid {
+			row[col] = s.StringVal
+		}
+		return nil
+	case sppb.TypeCode_BYTES:
+		var b []byte
+		if err := r.Column(idx, &b); err != nil {
+			return err
+		}
+		row[col] = nil
+		if b != nil {
+			row[col] = b
+		}
+		return nil
+	}
+	return parseNumericColumn(r, idx, col, row)
+}
+
 // parseStringColumn parses a string column from a Spanner row.
 //
 // Args:
@@ -1074,7 +1359,8 @@ func parseBytesColumn(r *spanner.Row, idx int, col string, row map[string]interf
 	return nil
 }
 
-// parseNumericColumn parses a numeric (float64) column from a Spanner row.
+// parseNumericColumn parses a number column from a Spanner row. Numbers are
+// stored in NUMERIC, STRING or, for tables created before, FLOAT64 columns.
 //
 // Args:
 //   r: The Spanner row.
@@ -1085,18 +1371,56 @@ func parseBytesColumn(r *spanner.Row, idx int, col string, row map[string]interf
 // Returns:
 //   An error if any occurs during column retrieval.
 
-func parseNumericColumn(r *spanner.Row, idx int, col string, row map[string]interface{}) error {
-	var s spanner.NullFloat64
-	err := r.Column(idx, &s)
-	if err != nil && !strings.Contains(err.Error(), "ambiguous column name") {
-		return err
+func parseNumericColumn(r *spanner.Row, idx int, col string, row map[string]interface{}) error {
+	var n interface{}
+	switch r.ColumnType(idx).GetCode() {
+	case sppb.TypeCode_NUMERIC:
+		var s spanner.NullNumeric
+		if err := r.Column(idx, &s); err != nil {
+			return err
+		}
+		if s.Valid {
+			n = &s.Numeric
+		}
+	case sppb.TypeCode_STRING:
+		var s spanner.NullString
+		if err := r.Column(idx, &s); err != nil {
+			return err
+		}
+		if s.Valid {
+			number, err := models.NumberFromSortableString(s.StringVal)
+			if err != nil {
+				return err
+			}
+			n = number
+		}
+	case sppb.TypeCode_INT64:
+		var s spanner.NullInt64
+		if err := r.Column(idx, &s); err != nil {
+			return err
+		}
+		if s.Valid {
+			n = s.Int64
+		}
+	default:
+		var s spanner.NullFloat64
+		err := r.Column(idx, &s)
+		if err != nil && !strings.Contains(err.Error(), "ambiguous column name") {
+			return err
+		}
+		if s.Valid {
+			n = s.Float64
+		}
 	}
-	if s.IsNull() {
+	if n == nil {
 		row[col] = nil
 		return nil
-	} else {
-		row[col] = s.Float64
 	}
+	number, ok := models.ToNumber(n)
+	if !ok {
+		return errors.New("ValidationException", "A value provided cannot be converted into a number", col)
+	}
+	row[col] = number
 	return nil
 }
 
@@ -1178,7 +1502,7 @@ func parseByteArrayColumn(r *spanner.Row, idx int, col string, row map[string]in
 	return nil
 }
 
-// parseNumberArrayColumn parses a numeric (float64) array column from a Spanner row.
+// parseNumberArrayColumn parses a number set column from a Spanner row.
 //
 // Args:
 //
@@ -1191,24 +1515,62 @@ func parseByteArrayColumn(r *spanner.Row, idx int, col string, row map[string]in
 //
 //	An error if any occurs during column retrieval.
 func parseNumberArrayColumn(r *spanner.Row, idx int, col string, row map[string]interface{}) error {
-	var nums []spanner.NullFloat64
-	err := r.Column(idx, &nums)
-	if err != nil && !strings.Contains(err.Error(), "ambiguous column name") {
-		return err
-	}
-	var temp []float64
-	for _, val := range nums {
-		if val.Valid {
-			temp = append(temp, val.Float64)
+	var nums []interface{}
+	switch r.ColumnType(idx).GetArrayElementType().GetCode() {
+	case sppb.TypeCode_NUMERIC:
+		var vals []spanner.NullNumeric
+		if err := r.Column(idx, &vals); err != nil {
+			return err
+		}
+		for _, val := range vals {
+			if val.Valid {
+				v := val.Numeric
+				nums = append(nums, &v)
+			}
+		}
+	case sppb.TypeCode_STRING:
+		var vals []spanner.NullString
+		if err := r.Column(idx, &vals); err != nil {
+			return err
+		}
+		for _, val := range vals {
+			if val.Valid {
+				number, err := models.NumberFromSortableString(val.StringVal)
+				if err != nil {
+					return err
+				}
+				nums = append(nums, number)
+			}
+		}
+	default:
+		var vals []spanner.NullFloat64
+		err := r.Column(idx, &vals)
+		if err != nil && !strings.Contains(err.Error(), "ambiguous column name") {
+			return err
+		}
+		for _, val := range vals {
+			if val.Valid {
+				nums = append(nums, val.Float64)
+			}
 		}
 	}
-	if len(nums) > 0 {
-		row[col] = temp
+	if len(nums) == 0 {
+		return nil
+	}
+	temp := make([]models.Number, len(nums))
+	for i, val := range nums {
+		n, ok := models.ToNumber(val)
+		if !ok {
+			return errors.New("ValidationException", "A value provided cannot be converted into a number", col)
+		}
+		temp[i] = n
 	}
+	row[col] = temp
 	return nil
 }
 
-// parseMapColumn parses a column of type for JSON data
+// parseMapColumn parses a Map column from a Spanner row. Columns written
+// before Map values were stored as DynamoDB JSON hold plain JSON.
 func parseMapColumn(r *spanner.Row, idx int, col string, row map[string]interface{}, spannerRow map[string]interface{}) error {
 	var s spanner.NullJSON
 	err := r.Column(idx, &s)
@@ -1218,10 +1580,17 @@ func parseMapColumn(r *spanner.Row, idx int, col string, row map[string]interfac
 
 	if !s.IsNull() {
 		var decodedData interface{}
-		if err = json.Unmarshal([]byte(s.String()), &decodedData); err != nil {
+		// numbers are decoded as json.Number so that they keep all of their digits
+		decoder := json.NewDecoder(strings.NewReader(s.String()))
+		decoder.UseNumber()
+		if err = decoder.Decode(&decodedData); err != nil {
 			return errors.New("JSONParseException", err)
 		}
-		row[col] = utils.ParseNestedJSON(decodedData)
+		if decoded, ok := decodeDocumentColumn(decodedData); ok {
+			row[col] = decoded
+		} else {
+			row[col] = utils.ParseNestedJSON(decodedData)
+		}
 		spannerRow[col] = decodedData
 	}
 	return err
@@ -1246,8 +1615,11 @@ func parseListColumn(r *spanner.Row, idx int, col string, row map[string]interfa
 		return err
 	}
 	if !jsonValue.IsNull() {
-		parsed := parseDynamoDBJSON(jsonValue.Value)
-		row[col] = parsed
+		if decoded, ok := decodeDocumentColumn(jsonValue.Value); ok {
+			row[col] = decoded
+			return nil
+		}
+		row[col] = parseDynamoDBJSON(jsonValue.Value)
 	}
 	return nil
 }
@@ -1273,7 +1645,7 @@ func parseDynamoDBJSON(value interface{}) interface{} {
 			case "S": // String
 				return val.(string)
 			case "N": // Number
-				num, _ := strconv.ParseFloat(val.(string), 64)
+				num, _ := models.ParseNumber(val.(string))
 				return num
 			case "BOOL": // Boolean
 				return val.(bool)
@@ -1372,17 +1744,6 @@ func parseNullColumn(r *spanner.Row, idx int, col string, row map[string]interfa
 	return nil
 }
 
-func checkInifinty(value float64, logData interface{}) error {
-	if math.IsInf(value, 1) {
-		return errors.New("ValidationException", "value found is infinity", logData)
-	}
-	if math.IsInf(value, -1) {
-		return errors.New("ValidationException", "value found is infinity", logData)
-	}
-
-	return nil
-}
-
 // SpannerTransactGetItems is a utility function to fetch data for a single TransactGetItems operation.
 // It takes a context, a table name, a map of projection columns, a map of primary keys, and a map of secondary keys.
 // It returns a slice of maps and an error.
@@ -1390,7 +1751,14 @@ func checkInifinty(value float64, logData interface{}) error {
 // It then iterates over the results and parses the Spanner rows into DynamoDB-style rows.
 // Finally, it returns the parsed rows.
 func (s Storage) SpannerTransactGetItems(ctx context.Context, tableProjectionCols map[string][]string, pValues map[string]interface{}, sValues map[string]interface{}) ([]map[string]interface{}, error) {
-	client := s.getSpannerClient("") // Get a generic client
+	tableNames := make([]string, 0, len(tableProjectionCols))
+	for tableName := range tableProjectionCols {
+		tableNames = append(tableNames, tableName)
+	}
+	client, err := s.GetSpannerClientForTables(tableNames)
+	if err != nil {
+		return nil, err
+	}
 	txn := client.ReadOnlyTransaction()
 	defer txn.Close()
 
@@ -1406,14 +1774,9 @@ func (s Storage) SpannerTransactGetItems(ctx context.Context, tableProjectionCol
 		// Get the primary keys, secondary keys, and construct the key set
 		pKeys := pValues[tableName].([]interface{})
 		sKeys := sValues[tableName].([]interface{})
-		var keySet []spanner.KeySet
-
-		for i := range pKeys {
-			if len(sKeys) == 0 || sKeys[i] == nil {
-				keySet = append(keySet, spanner.Key{pKeys[i]})
-			} else {
-				keySet = append(keySet, spanner.Key{pKeys[i], sKeys[i]})
-			}
+		keySet, err := spannerKeySet(utils.ChangeTableNameForSpanner(tableName), pKeys, sKeys)
+		if err != nil {
+			return nil, err
 		}
 		// If no projection columns are specified, then get all columns
 		if len(projectionCols) == 0 {
@@ -1424,7 +1787,7 @@ func (s Storage) SpannerTransactGetItems(ctx context.Context, tableProjectionCol
 			}
 		}
 		// Perform the transaction read operation
-		itr := txn.Read(ctx, tableName, spanner.KeySets(keySet...), projectionCols)
+		itr := txn.Read(ctx, tableName, keySet, projectionCols)
 		defer itr.Stop()
 		// Iterate over the results
 		for {
@@ -1463,113 +1826,31 @@ func (s Storage) SpannerTransactGetItems(ctx context.Context, tableProjectionCol
 //	m: The map containing the data to be inserted or updated.
 //	eval: The evaluation criteria for processing conditional expressions.
 //	expr: The UpdateExpressionCondition to be checked before writing.
-//	txn: The Spanner ReadWriteTransaction.
+//	txn: The read-write transaction.
 //
 // Returns:
 //
 //	A map of updated data, a Spanner mutation, and an error if any occurs.
-func (s Storage) SpannerTransactWritePut(ctx context.Context, table string, m map[string]interface{}, eval *models.Eval, expr *models.UpdateExpressionCondition, txn *spanner.ReadWriteTransaction, oldRes map[string]interface{}) (map[string]interface{}, *spanner.Mutation, error) {
-	// Initialize the update map and mutation
-	update := map[string]interface{}{}
-	var mutation *spanner.Mutation
-
-	// Copy input map to a temporary map for processing
-	tmpMap := map[string]interface{}{}
-	for k, v := range m {
-		tmpMap[k] = v
-	}
-
-	// Evaluate conditional expressions if present
-	if len(eval.Attributes) > 0 || expr != nil {
-		status, err := evaluateConditionalExpression(ctx, txn, table, tmpMap, eval, expr)
-		if err != nil {
-			return m, nil, err
-		}
-		if !status {
-			return m, nil, errors.New("ConditionalCheckFailedException", eval, expr)
-		}
-	}
-
-	// Update table name to match Spanner's naming convention
-	table = utils.ChangeTableNameForSpanner(table)
-
-	// Update the map with processed data
-	for k, v := range tmpMap {
-		update[k] = v
+func (s Storage) SpannerTransactWritePut(ctx context.Context, table string, m map[string]interface{}, eval *models.Eval, expr *models.UpdateExpressionCondition, txn Transaction, oldRes map[string]interface{}) (map[string]interface{}, *Mutation, error) {
+	update, columns, err := putColumns(ctx, txn, table, m, eval, expr)
+	if err != nil {
+		return update, nil, err
 	}
-
-	// Perform the transactional put operation
-	mutation, err := s.performTransactPutOperation(table, tmpMap, oldRes)
-	return update, mutation, err
+	// Create a mutation for the InsertOrUpdateMap operation
+	return update, insertOrUpdateMutation(utils.ChangeTableNameForSpanner(table), columns), nil
 }
 
-// performTransactPutOperation performs a transactional put operation in Spanner.
-//
-// ctx: The context of the transaction.
-//
-// txn: The Spanner ReadWriteTransaction.
-//
-// table: The name of the Spanner table.
-//
-// m: The input map of data to be inserted or updated.
-//
-// Returns:
-//
-//	A Spanner mutation and an error if any occurs.
-func (s Storage) performTransactPutOperation(table string, m map[string]interface{}, oldRes map[string]interface{}) (*spanner.Mutation, error) {
-	ddl := models.TableDDL[table]
-	newMap := m
-	for k, v := range m {
-		if strings.Contains(k, ".") {
-			pathfeilds := strings.Split(k, ".")
-			colName := pathfeilds[0]
-			t, ok := ddl[colName]
-			if t == "M" && ok {
-				var err error
-				newMap[colName], err = updateMapColumnObject(oldRes, colName, k, v)
-				if err != nil {
-					return nil, errors.New("Error updating the Map:", err)
-				}
-				delete(newMap, k)
-			}
-		} else {
-			t, ok := ddl[k]
-			if t == "B" && ok {
-				ba, err := json.Marshal(v)
-				if err != nil {
-					return nil, errors.New("ValidationException", err)
-				}
-				newMap[k] = ba
-			}
-			if t == "M" && ok {
-				if v == nil {
-					continue
-				}
-				ba, err := json.MarshalIndent(v, "", "  ")
-				if err != nil {
-					return nil, errors.New("ValidationException", err)
-				}
-				newMap[k] = string(ba)
-			}
-			if t == "L" && ok {
-				list, ok := v.([]interface{})
-				if !ok {
-					return nil, errors.New("invalid list format")
-				}
-				jsonData, err := json.Marshal(list)
-				if err != nil {
-					return nil, fmt.Errorf("error marshaling list to JSON: %w", err)
-				}
-				newMap[k] = string(jsonData)
-			}
-		}
+func (s Storage) TransactWriteSpannerDel(ctx context.Context, table string, m map[string]interface{}, eval *models.Eval, expr *models.UpdateExpressionCondition, txn Transaction) (*Mutation, error) {
+	columns, err := transactDelColumns(ctx, txn, table, m, eval, expr)
+	if err != nil {
+		return nil, err
 	}
-	// Create a Spanner mutation for the InsertOrUpdateMap operation
-	mutation := spanner.InsertOrUpdateMap(table, newMap)
-	return mutation, nil
+	return insertOrUpdateMutation(utils.ChangeTableNameForSpanner(table), columns), nil
 }
 
-func (s Storage) TransactWriteSpannerDel(ctx context.Context, table string, m map[string]interface{}, eval *models.Eval, expr *models.UpdateExpressionCondition, txn *spanner.ReadWriteTransaction) (*spanner.Mutation, error) {
+// transactDelColumns removes the elements in m from the sets of the row read
+// through r and returns the columns to write
+func transactDelColumns(ctx context.Context, r RowReader, table string, m map[string]interface{}, eval *models.Eval, expr *models.UpdateExpressionCondition) (map[string]interface{}, error) {
 	tableConf, err := config.GetTableConf(table)
 	if err != nil {
 		return nil, err
@@ -1587,6 +1868,7 @@ func (s Storage) TransactWriteSpannerDel(ctx context.Context, table string, m ma
 	var key spanner.Key
 	var m1 = make(map[string]interface{})
 
+	updates := takeDocumentUpdates(table, m)
 	for k, v := range m {
 		m1[k] = v
 		if k == pKey {
@@ -1611,59 +1893,29 @@ func (s Storage) TransactWriteSpannerDel(ctx context.Context, table string, m ma
 		tmpMap[k] = v
 	}
 	if len(eval.Attributes) > 0 || expr != nil {
-		status, _ := evaluateConditionalExpression(ctx, txn, table, m1, eval, expr)
+		status, _ := evaluateConditionalExpression(ctx, r, table, m1, eval, expr)
 		if !status {
 			return nil, errors.New("ConditionalCheckFailedException")
 		}
 	}
 	table = utils.ChangeTableNameForSpanner(table)
 
-	r, err := txn.ReadRow(ctx, table, key, cols)
+	row, err := r.ReadRow(ctx, table, key, cols)
 	if err != nil {
 		return nil, errors.New("ResourceNotFoundException", err)
 	}
-	rs, _, err := parseRow(r, colDDL)
+	rs, _, err := parseRow(row, colDDL)
 	if err != nil {
 		return nil, err
 	}
-	for k, v := range tmpMap {
-		v1, ok := rs[k]
-		if ok {
-			switch v1.(type) {
-			case []interface{}:
-				var ifaces1 []interface{}
-				ba, ok := v.([]byte)
-				if ok {
-					err = json.Unmarshal(ba, &ifaces1)
-					if err != nil {
-						logger.LogError(err, string(ba))
-					}
-				} else {
-					ifaces1 = v.([]interface{})
-				}
-				m1 := map[interface{}]struct{}{}
-				ifaces := v1.([]interface{})
-				for i := 0; i < len(ifaces); i++ {
-					m1[reflect.ValueOf(ifaces[i]).Interface()] = struct{}{}
-				}
-				for i := 0; i < len(ifaces1); i++ {
-
-					delete(m1, reflect.ValueOf(ifaces1[i]).Interface())
-				}
-				ifaces = []interface{}{}
-				for k := range m1 {
-					ifaces = append(ifaces, k)
-				}
-				tmpMap[k] = ifaces
-			default:
-				logger.LogDebug(reflect.TypeOf(v).String())
-			}
-		}
-	}
+	deleteExistingValues(rs, tmpMap)
 	tmpMap[pKey] = pValue
 	if sValue != nil {
 		tmpMap[sKey] = sValue
 	}
+	if err := applyDocumentUpdates(ctx, r, tableConf.ActualTable, tmpMap, updates, documentDelete); err != nil {
+		return nil, err
+	}
 	ddl := models.TableDDL[table]
 
 	for k, v := range tmpMap {
@@ -1676,12 +1928,20 @@ func (s Storage) TransactWriteSpannerDel(ctx context.Context, table string, m ma
 			tmpMap[k] = ba
 		}
 	}
-	mutation := spanner.InsertOrUpdateMap(table, tmpMap)
+	return tmpMap, nil
+}
 
-	return mutation, err
+func (s Storage) TransactWriteSpannerAdd(ctx context.Context, table string, m map[string]interface{}, eval *models.Eval, expr *models.UpdateExpressionCondition, txn Transaction) (map[string]interface{}, *Mutation, error) {
+	updatedObj, columns, err := transactAddColumns(ctx, txn, table, m, eval, expr)
+	if err != nil {
+		return nil, nil, err
+	}
+	return updatedObj, insertOrUpdateMutation(utils.ChangeTableNameForSpanner(table), columns), nil
 }
 
-func (s Storage) TransactWriteSpannerAdd(ctx context.Context, table string, m map[string]interface{}, eval *models.Eval, expr *models.UpdateExpressionCondition, txn *spanner.ReadWriteTransaction) (map[string]interface{}, *spanner.Mutation, error) {
+// transactAddColumns adds the values of m to the row read through r and
+// returns the updated item and the columns to write
+func transactAddColumns(ctx context.Context, r RowReader, table string, m map[string]interface{}, eval *models.Eval, expr *models.UpdateExpressionCondition) (map[string]interface{}, map[string]interface{}, error) {
 	tableConf, err := config.GetTableConf(table)
 	if err != nil {
 		return nil, nil, err
@@ -1699,6 +1959,7 @@ func (s Storage) TransactWriteSpannerAdd(ctx context.Context, table string, m ma
 	var key spanner.Key
 	var m1 = make(map[string]interface{})
 
+	updates := takeDocumentUpdates(table, m)
 	for k, v := range m {
 		m1[k] = v
 		if k == pKey {
@@ -1725,68 +1986,64 @@ func (s Storage) TransactWriteSpannerAdd(ctx context.Context, table string, m ma
 		tmpMap[k] = v
 	}
 	if len(eval.Attributes) > 0 || expr != nil {
-		status, _ := evaluateConditionalExpression(ctx, txn, table, tmpMap, eval, expr)
+		status, _ := evaluateConditionalExpression(ctx, r, table, tmpMap, eval, expr)
 		if !status {
 			return nil, nil, errors.New("ConditionalCheckFailedException")
 		}
 	}
 	table = utils.ChangeTableNameForSpanner(table)
 
-	r, err := txn.ReadRow(ctx, table, key, cols)
+	row, err := r.ReadRow(ctx, table, key, cols)
 	if err != nil {
 		return nil, nil, errors.New("ResourceNotFoundException", err)
 	}
-	rs, _, err := parseRow(r, colDDL)
+	rs, _, err := parseRow(row, colDDL)
 	if err != nil {
 		return nil, nil, err
 	}
+	if err := transactAddExistingValues(rs, tmpMap, m); err != nil {
+		return nil, nil, err
+	}
+	tmpMap[pKey] = pValue
+	if sValue != nil {
+		tmpMap[sKey] = sValue
+	}
+	if err := applyDocumentUpdates(ctx, r, tableConf.ActualTable, tmpMap, updates, documentAdd); err != nil {
+		return nil, nil, err
+	}
+	if err := checkItemSize(ctx, r, tableConf.ActualTable, tmpMap); err != nil {
+		return nil, nil, err
+	}
+	ddl := models.TableDDL[table]
+
+	for k, v := range tmpMap {
+		updatedObj[k] = v
+		t, ok := ddl[k]
+		if t == "BYTES(MAX)" && ok {
+			ba, err := json.Marshal(v)
+			if err != nil {
+				return nil, nil, errors.New("ValidationException", err)
+			}
+			tmpMap[k] = ba
+		}
+	}
+	return updatedObj, tmpMap, nil
+}
+
+// transactAddExistingValues adds the numbers and the set elements of tmpMap
+// to the values of the existing row rs
+func transactAddExistingValues(rs, tmpMap, m map[string]interface{}) error {
+	var err error
 	for k, v := range tmpMap {
 		v1, ok := rs[k]
 		if ok {
 			switch v1.(type) {
-			case int64:
-				v2, ok := v.(float64)
-				if !ok {
-					strV, ok := v.(string)
-					if !ok {
-						return nil, nil, errors.New("ValidationException", reflect.TypeOf(v).String())
-					}
-					v2, err = strconv.ParseFloat(strV, 64)
-					if err != nil {
-						return nil, nil, errors.New("ValidationException", reflect.TypeOf(v).String())
-					}
-					err = checkInifinty(v2, strV)
-					if err != nil {
-						return nil, nil, err
-					}
-				}
-				tmpMap[k] = v1.(int64) + int64(v2)
-				err = checkInifinty(float64(m[k].(int64)), m)
-				if err != nil {
-					return nil, nil, err
-				}
-			case float64:
-				v2, ok := v.(float64)
-				if !ok {
-					strV, ok := v.(string)
-					if !ok {
-						return nil, nil, errors.New("ValidationException", reflect.TypeOf(v).String())
-					}
-					v2, err = strconv.ParseFloat(strV, 64)
-					if err != nil {
-						return nil, nil, errors.New("ValidationException", reflect.TypeOf(v).String())
-					}
-					err = checkInifinty(v2, strV)
-					if err != nil {
-						return nil, nil, err
-					}
-				}
-				tmpMap[k] = v1.(float64) + v2
-				err = checkInifinty(m[k].(float64), m)
+			case models.Number, int64, float64:
+				sum, err := addNumbers(v1, v)
 				if err != nil {
-					return nil, nil, err
+					return err
 				}
-
+				tmpMap[k] = sum
 			case []interface{}:
 				var ifaces1 []interface{}
 				ba, ok := v.([]byte)
@@ -1816,27 +2073,7 @@ func (s Storage) TransactWriteSpannerAdd(ctx context.Context, table string, m ma
 			}
 		}
 	}
-	tmpMap[pKey] = pValue
-	if sValue != nil {
-		tmpMap[sKey] = sValue
-	}
-	ddl := models.TableDDL[table]
-
-	for k, v := range tmpMap {
-		updatedObj[k] = v
-		t, ok := ddl[k]
-		if t == "BYTES(MAX)" && ok {
-			ba, err := json.Marshal(v)
-			if err != nil {
-				return nil, nil, errors.New("ValidationException", err)
-			}
-			tmpMap[k] = ba
-		}
-	}
-
-	mutation := spanner.InsertOrUpdateMap(table, tmpMap)
-
-	return updatedObj, mutation, err
+	return nil
 }
 
 // TransactWriteSpannerRemove - Spanner Remove functionality like update attribute inside a transaction
@@ -1846,14 +2083,22 @@ func (s Storage) TransactWriteSpannerAdd(ctx context.Context, table string, m ma
 // this will return an error.
 //
 // The colsToRemove parameter should contain the names of the columns to be removed.
-func (s Storage) TransactWriteSpannerRemove(ctx context.Context, table string, m map[string]interface{}, eval *models.Eval, expr *models.UpdateExpressionCondition, colsToRemove []string, txn *spanner.ReadWriteTransaction) (*spanner.Mutation, error) {
+func (s Storage) TransactWriteSpannerRemove(ctx context.Context, table string, m map[string]interface{}, eval *models.Eval, expr *models.UpdateExpressionCondition, colsToRemove []string, txn Transaction) (*Mutation, error) {
+	columns, err := transactRemoveColumns(ctx, txn, table, m, eval, expr, colsToRemove)
+	if err != nil {
+		return nil, err
+	}
+	return insertOrUpdateMutation(utils.ChangeTableNameForSpanner(table), columns), nil
+}
 
+// transactRemoveColumns returns the columns to write with the removed columns set to NULL
+func transactRemoveColumns(ctx context.Context, r RowReader, table string, m map[string]interface{}, eval *models.Eval, expr *models.UpdateExpressionCondition, colsToRemove []string) (map[string]interface{}, error) {
 	tmpMap := map[string]interface{}{}
 	for k, v := range m {
 		tmpMap[k] = v
 	}
 	if len(eval.Attributes) > 0 || expr != nil {
-		status, _ := evaluateConditionalExpression(ctx, txn, table, m, eval, expr)
+		status, _ := evaluateConditionalExpression(ctx, r, table, m, eval, expr)
 		if !status {
 			return nil, errors.New("ConditionalCheckFailedException")
 		}
@@ -1862,54 +2107,15 @@ func (s Storage) TransactWriteSpannerRemove(ctx context.Context, table string, m
 	for _, col := range colsToRemove {
 		tmpMap[col] = null
 	}
-	table = utils.ChangeTableNameForSpanner(table)
-	mutation := spanner.InsertOrUpdateMap(table, tmpMap)
-
-	return mutation, nil
+	return tmpMap, nil
 }
 
-func (s Storage) TransactWriteSpannerDelete(ctx context.Context, table string, m map[string]interface{}, eval *models.Eval, expr *models.UpdateExpressionCondition, txn *spanner.ReadWriteTransaction) (*spanner.Mutation, error) {
-
-	tmpMap := map[string]interface{}{}
-	for k, v := range m {
-		tmpMap[k] = v
-	}
-	if len(eval.Attributes) > 0 || expr != nil {
-		status, err := evaluateConditionalExpression(ctx, txn, table, tmpMap, eval, expr)
-		if err != nil {
-			return nil, err
-		}
-		if !status {
-			return nil, errors.New("ConditionalCheckFailedException", tmpMap, expr)
-		}
-	}
-	tableConf, err := config.GetTableConf(table)
+func (s Storage) TransactWriteSpannerDelete(ctx context.Context, table string, m map[string]interface{}, eval *models.Eval, expr *models.UpdateExpressionCondition, txn Transaction) (*Mutation, error) {
+	key, err := deleteKey(ctx, txn, table, m, eval, expr)
 	if err != nil {
 		return nil, err
 	}
-	table = utils.ChangeTableNameForSpanner(table)
-
-	pKey := tableConf.PartitionKey
-	pValue, ok := tmpMap[pKey]
-	if !ok {
-		return nil, errors.New("ResourceNotFoundException", pKey)
-	}
-	var key spanner.Key
-	sKey := tableConf.SortKey
-	if sKey != "" {
-		sValue, ok := tmpMap[sKey]
-		if !ok {
-			return nil, errors.New("ResourceNotFoundException", pKey)
-		}
-		key = spanner.Key{pValue, sValue}
-
-	} else {
-		key = spanner.Key{pValue}
-	}
-
-	mutation := spanner.Delete(table, key)
-
-	return mutation, nil
+	return deleteMutation(utils.ChangeTableNameForSpanner(table), key), nil
 }
 
 // EvaluateConditionalExpression evaluates a conditional expression for a given Spanner transaction.
@@ -1917,7 +2123,7 @@ func (s Storage) TransactWriteSpannerDelete(ctx context.Context, table string, m
 // and updates the map with computed values if conditions are met. It returns a boolean status indicating
 // whether the condition was satisfied and an error if any occurs during processing.
 
-func EvaluateConditionalExpression(ctx context.Context, t *spanner.ReadWriteTransaction, table string, m map[string]interface{}, e *models.Eval, expr *models.UpdateExpressionCondition) (bool, error) {
+func EvaluateConditionalExpression(ctx context.Context, t RowReader, table string, m map[string]interface{}, e *models.Eval, expr *models.UpdateExpressionCondition) (bool, error) {
 	// Retrieve table schema DDL
 	colDDL, ok := models.TableDDL[utils.ChangeTableNameForSpanner(table)]
 	if !ok {
@@ -1994,11 +2200,8 @@ func EvaluateConditionalExpression(ctx context.Context, t *spanner.ReadWriteTran
 			tmp, ok := status.(bool)
 			if !ok || !tmp {
 				if v1, ok := expr.AddValues[expr.Field[index]]; ok {
-					tmp, ok := rowMap[expr.Field[index]].(float64)
-					if ok {
-						m[expr.Field[index]] = tmp + v1
-						err = checkInifinty(m[expr.Field[index]].(float64), expr)
-						if err != nil {
+					if tmp, ok := rowMap[expr.Field[index]]; ok {
+						if m[expr.Field[index]], err = addNumbers(tmp, v1); err != nil {
 							return false, err
 						}
 					}
@@ -2007,11 +2210,8 @@ func EvaluateConditionalExpression(ctx context.Context, t *spanner.ReadWriteTran
 				}
 			} else {
 				if v1, ok := expr.AddValues[expr.Field[index]]; ok {
-					tmp, ok := m[expr.Field[index]].(float64)
-					if ok {
-						m[expr.Field[index]] = tmp + v1
-						err = checkInifinty(m[expr.Field[index]].(float64), expr)
-						if err != nil {
+					if tmp, ok := m[expr.Field[index]]; ok {
+						if m[expr.Field[index]], err = addNumbers(tmp, v1); err != nil {
 							return false, err
 						}
 					}
@@ -2022,11 +2222,8 @@ func EvaluateConditionalExpression(ctx context.Context, t *spanner.ReadWriteTran
 
 		// Apply additional values
 		for k, v := range expr.AddValues {
-			val, ok := rowMap[k].(float64)
-			if ok {
-				m[k] = val + v
-				err = checkInifinty(m[k].(float64), expr)
-				if err != nil {
+			if val, ok := rowMap[k]; ok {
+				if m[k], err = addNumbers(val, v); err != nil {
 					return false, err
 				}
 			} else {
@@ -2060,8 +2257,16 @@ func EvaluateConditionalExpression(ctx context.Context, t *spanner.ReadWriteTran
 // - map[string]interface{}: A map that could potentially hold results for further processing (currently returns nil).
 // - error: An error object, if any error occurs during the transaction execution.
 func (s *Storage) InsertUpdateOrDeleteStatement(ctx context.Context, query *translator.DeleteUpdateQueryMap) (map[string]interface{}, error) {
-	_, err := s.getSpannerClient(query.Table).ReadWriteTransactionWithOptions(ctx, func(ctx context.Context, txn *spanner.ReadWriteTransaction) error {
-		_, err := txn.Update(ctx, *buildStmt(query))
+	stmt, err := spannerParams(utils.ChangeTableNameForSpanner(query.Table), *buildStmt(query))
+	if err != nil {
+		return nil, err
+	}
+	client, err := s.getSpannerClient(query.Table)
+	if err != nil {
+		return nil, err
+	}
+	_, err = client.ReadWriteTransactionWithOptions(ctx, func(ctx context.Context, txn *spanner.ReadWriteTransaction) error {
+		_, err := txn.Update(ctx, stmt)
 		if err != nil {
 			return err
 		}
@@ -18,6 +18,7 @@ import (
 	"testing"
 
 	"cloud.google.com/go/spanner"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/models"
 	translator "github.com/cloudspannerecosystem/dynamodb-adapter/translator/utils"
 )
 
@@ -55,7 +56,7 @@ func Test_parseRow(t *testing.T) {
 				return row
 			}(),
 			colDDL: map[string]string{"intCol": "N"},
-			want:   map[string]interface{}{"intCol": 314.0},
+			want:   map[string]interface{}{"intCol": models.Number("314")},
 		},
 		{
 			name: "ParseFloatValue",
@@ -69,7 +70,7 @@ func Test_parseRow(t *testing.T) {
 				return row
 			}(),
 			colDDL: map[string]string{"floatCol": "N"},
-			want:   map[string]interface{}{"floatCol": 3.14},
+			want:   map[string]interface{}{"floatCol": models.Number("3.14")},
 		},
 		{
 			name: "ParseBoolValue",
@@ -127,7 +128,7 @@ func Test_parseRow(t *testing.T) {
 				return row
 			}(),
 			colDDL: map[string]string{"boolCol": "BOOL", "intCol": "N", "strCol": "S"},
-			want:   map[string]interface{}{"boolCol": true, "intCol": 32.0, "strCol": "my-text"},
+			want:   map[string]interface{}{"boolCol": true, "intCol": models.Number("32"), "strCol": "my-text"},
 		},
 		{
 			name: "ParseStringArray",
@@ -209,7 +210,7 @@ func Test_parseRow(t *testing.T) {
 				return row
 			}(),
 			colDDL:    map[string]string{"numberArrayCol": "NS"},
-			want:      map[string]interface{}{"numberArrayCol": []float64{1.1, 2.2, 3.3}},
+			want:      map[string]interface{}{"numberArrayCol": []models.Number{"1.1", "2.2", "3.3"}},
 			wantError: false,
 		},
 		{
@@ -25,21 +25,30 @@ import (
 	"time"
 
 	"cloud.google.com/go/spanner"
+	database "cloud.google.com/go/spanner/admin/database/apiv1"
 	"github.com/cloudspannerecosystem/dynamodb-adapter/models"
 	otelgo "github.com/cloudspannerecosystem/dynamodb-adapter/otel"
 	"github.com/cloudspannerecosystem/dynamodb-adapter/pkg/logger"
 	"go.opentelemetry.io/otel"
+	"golang.org/x/sync/singleflight"
 	"google.golang.org/api/option"
 	"google.golang.org/grpc"
 )
 
 // Storage object for intracting with storage package
 type Storage struct {
+	// spannerClient holds one client per database path
 	spannerClient map[string]*spanner.Client
+	clientMu      *sync.RWMutex
+	newClient     func(ctx context.Context, database string, session models.Session) (*spanner.Client, error)
+	adminClient   *database.DatabaseAdminClient
+	// dials creates the client of a database once for the requests waiting on it
+	dials *singleflight.Group
 }
 
+// GetSpannerClient returns the client of the default database
 func (s *Storage) GetSpannerClient() (*spanner.Client, error) {
-	return s.getSpannerClient(models.GlobalConfig.Spanner.ProjectID), nil
+	return s.clientForDatabase(databasePath(), models.GlobalConfig.Spanner.Session)
 }
 
 func InitSpannerDriver() *spanner.Client {
@@ -61,6 +70,8 @@ func InitializeDriver(ctx context.Context) error {
 	}
 	storage = &Storage{
 		spannerClient: make(map[string]*spanner.Client),
+		clientMu:      &sync.RWMutex{},
+		dials:         &singleflight.Group{},
 	}
 
 	// OpenTelemetry configuration
@@ -87,24 +98,9 @@ func InitializeDriver(ctx context.Context) error {
 		}
 	}()
 
-	spc := spanner.DefaultSessionPoolConfig
-
-	if models.GlobalConfig.Spanner.Session.Min != 0 {
-		spc.MinOpened = models.GlobalConfig.Spanner.Session.Min
-	}
-
-	if models.GlobalConfig.Spanner.Session.Max != 0 {
-		spc.MaxOpened = models.GlobalConfig.Spanner.Session.Max
-	}
-
-	spc.InactiveTransactionRemovalOptions = spanner.InactiveTransactionRemovalOptions{
-		ActionOnInactiveTransaction: spanner.WarnAndClose,
-	}
-
-	cfg := spanner.ClientConfig{SessionPoolConfig: spc, UserAgent: models.GlobalConfig.UserAgent}
-
 	// If OpenTelemetry is provided, configure instrumentation
-	if models.GlobalConfig.Otel.Enabled && otelInstance != nil {
+	otelEnabled := models.GlobalConfig.Otel.Enabled && otelInstance != nil
+	if otelEnabled {
 		if models.GlobalConfig.Otel.EnabledClientSideMetrics {
 			// Enable OpenTelemetry metrics before injecting meter provider.
 			spanner.EnableOpenTelemetryMetrics()
@@ -114,39 +110,83 @@ func InitializeDriver(ctx context.Context) error {
 
 		// Set up OpenTelemetry traces and metrics
 		otel.SetTracerProvider(otelInstance.TracerProvider)
+	}
 
-		// Add OpenTelemetry instrumentation to Spanner client configuration
-		cfg.OpenTelemetryMeterProvider = otelInstance.MeterProvider
+	// The memory backend keeps its tables in process memory and needs no Spanner clients
+	if models.GlobalConfig.Storage.Backend == models.StorageBackendMemory {
+		logger.LogInfo("Using the in-memory storage backend")
+		setGlobalProxy(otelInstance, shutdownOTel)
+		return nil
 	}
-	// Spanner client initialization
-	database := fmt.Sprintf("projects/%s/instances/%s/databases/%s",
-		models.GlobalConfig.Spanner.ProjectID,
-		models.GlobalConfig.Spanner.InstanceID,
-		models.GlobalConfig.Spanner.DatabaseName,
-	)
-	spannerClient, err := spanner.NewClientWithConfig(ctx, database, cfg,
-		option.WithGRPCConnectionPool(models.GlobalConfig.Spanner.Session.GrpcChannels),
-		option.WithGRPCDialOption(grpc.WithConnectParams(grpc.ConnectParams{
-			MinConnectTimeout: 10 * time.Second,
-		})),
-	)
+
+	// Clients of routed databases are created on first use, each with its own session pool
+	storage.newClient = func(ctx context.Context, database string, session models.Session) (*spanner.Client, error) {
+		spc := spanner.DefaultSessionPoolConfig
+		if session.Min != 0 {
+			spc.MinOpened = session.Min
+		}
+		if session.Max != 0 {
+			spc.MaxOpened = session.Max
+		}
+		spc.InactiveTransactionRemovalOptions = spanner.InactiveTransactionRemovalOptions{
+			ActionOnInactiveTransaction: spanner.WarnAndClose,
+		}
+
+		cfg := spanner.ClientConfig{SessionPoolConfig: spc, UserAgent: models.GlobalConfig.UserAgent}
+		if otelEnabled {
+			// Add OpenTelemetry instrumentation to Spanner client configuration
+			cfg.OpenTelemetryMeterProvider = otelInstance.MeterProvider
+		}
+		client, err := spanner.NewClientWithConfig(ctx, database, cfg,
+			option.WithGRPCConnectionPool(session.GrpcChannels),
+			option.WithGRPCDialOption(grpc.WithConnectParams(grpc.ConnectParams{
+				MinConnectTimeout: 10 * time.Second,
+			})),
+		)
+		if err != nil {
+			return nil, err
+		}
+		logger.LogInfo("Spanner client initialized for " + database)
+		return client, nil
+	}
+
+	// The emulator starts out empty, so its instances and databases are created first
+	if EmulatorHost() != "" {
+		logger.LogInfo("Using the Spanner emulator at " + EmulatorHost())
+		if err = prepareEmulator(ctx); err != nil {
+			return err
+		}
+	}
+
+	// Spanner client initialization for the default database
+	spannerClient, err := storage.newClient(ctx, databasePath(), models.GlobalConfig.Spanner.Session)
 	if err != nil {
 		return fmt.Errorf("failed to create Spanner client: %w", err)
 	}
 
-	storage.spannerClient[models.GlobalConfig.Spanner.InstanceID] = spannerClient
+	storage.spannerClient[databasePath()] = spannerClient
 	logger.LogInfo("Spanner client initialized successfully")
 
+	// Admin client used for schema changes such as creating secondary indexes
+	storage.adminClient, err = database.NewDatabaseAdminClient(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create Spanner admin client: %w", err)
+	}
+
 	if models.GlobalConfig.Otel.Traces.Enabled {
 		if models.GlobalConfig.Otel.Traces.SamplingRatio < 0 || models.GlobalConfig.Otel.Traces.SamplingRatio > 1 {
 			return fmt.Errorf("sampling ratio for Otel Traces should be between 0 and 1]")
 		}
 	}
 
+	setGlobalProxy(otelInstance, shutdownOTel)
+	return nil
+}
+
+func setGlobalProxy(otelInstance *otelgo.OpenTelemetry, shutdownOTel func(context.Context) error) {
 	models.GlobalProxy = &models.Proxy{}
 	models.GlobalProxy.OtelInst = otelInstance
 	models.GlobalProxy.OtelShutdown = shutdownOTel
-	return nil
 }
 
 // Close - This gracefully returns the session pool objects, when driver gets exit signal
@@ -155,9 +195,16 @@ func (s Storage) Close() {
 	signal.Notify(shutdown, syscall.SIGINT, syscall.SIGTERM)
 	<-shutdown
 	logger.LogDebug("Connection Shutdown start")
+	if s.clientMu != nil {
+		s.clientMu.Lock()
+		defer s.clientMu.Unlock()
+	}
 	for _, v := range s.spannerClient {
 		v.Close()
 	}
+	if s.adminClient != nil {
+		s.adminClient.Close()
+	}
 	logger.LogDebug("Connection shutted down")
 }
 
@@ -176,6 +223,3 @@ func SetStorageInstance(s *Storage) {
 	storage = s
 }
 
-func (s Storage) getSpannerClient(_ string) *spanner.Client {
-	return s.spannerClient[models.GlobalConfig.Spanner.InstanceID]
-}
@@ -0,0 +1,156 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+
+	"cloud.google.com/go/spanner"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/models"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/utils"
+)
+
+// Mutation is a write buffered in a read-write transaction. A mutation with
+// Columns inserts or updates the row, one without them deletes the row with Key.
+type Mutation struct {
+	Table   string
+	Columns map[string]interface{}
+	Key     spanner.Key
+}
+
+func insertOrUpdateMutation(table string, columns map[string]interface{}) *Mutation {
+	return &Mutation{Table: table, Columns: columns}
+}
+
+func deleteMutation(table string, key spanner.Key) *Mutation {
+	return &Mutation{Table: table, Key: key}
+}
+
+// Transaction reads rows and buffers the writes of a read-write transaction.
+// The buffered writes are applied when the transaction commits.
+type Transaction interface {
+	RowReader
+	BufferWrite(ms []*Mutation) error
+	// ExecuteDML runs an UPDATE or DELETE statement on the table and returns
+	// the number of rows it changed. It does not see the buffered writes.
+	ExecuteDML(ctx context.Context, table string, stmt spanner.Statement) (int64, error)
+	// Query runs a SELECT statement on the table and returns its rows. It does
+	// not see the buffered writes.
+	Query(ctx context.Context, table string, stmt spanner.Statement) ([]map[string]interface{}, error)
+}
+
+// SpannerTransaction is the Transaction of a Spanner read-write transaction
+type SpannerTransaction struct {
+	*spanner.ReadWriteTransaction
+}
+
+// ReadRow reads the row with key, converting its numbers to the types of the key columns
+func (t SpannerTransaction) ReadRow(ctx context.Context, table string, key spanner.Key, columns []string) (*spanner.Row, error) {
+	key, err := spannerKey(table, key)
+	if err != nil {
+		return nil, err
+	}
+	return t.ReadWriteTransaction.ReadRow(ctx, table, key, columns)
+}
+
+// BufferWrite buffers the mutations in the Spanner transaction
+func (t SpannerTransaction) BufferWrite(ms []*Mutation) error {
+	mutations, err := spannerMutations(ms)
+	if err != nil {
+		return err
+	}
+	return t.ReadWriteTransaction.BufferWrite(mutations)
+}
+
+// ExecuteDML runs the statement in the Spanner transaction
+func (t SpannerTransaction) ExecuteDML(ctx context.Context, table string, stmt spanner.Statement) (int64, error) {
+	stmt, err := spannerParams(utils.ChangeTableNameForSpanner(table), stmt)
+	if err != nil {
+		return 0, err
+	}
+	return t.ReadWriteTransaction.Update(ctx, stmt)
+}
+
+// Query runs the statement in the Spanner transaction
+func (t SpannerTransaction) Query(ctx context.Context, table string, stmt spanner.Statement) ([]map[string]interface{}, error) {
+	spannerTable := utils.ChangeTableNameForSpanner(table)
+	stmt, err := spannerParams(spannerTable, stmt)
+	if err != nil {
+		return nil, err
+	}
+	itr := t.ReadWriteTransaction.Query(ctx, stmt)
+	defer itr.Stop()
+	return queryRows(itr.Next, models.TableDDL[spannerTable], false)
+}
+
+// ReadWriteTransaction runs f in a read-write transaction on the database that
+// holds the tables and commits the writes f buffers when it returns nil
+func (s Storage) ReadWriteTransaction(ctx context.Context, tables []string, f func(context.Context, Transaction) error) error {
+	client, err := s.GetSpannerClientForTables(tables)
+	if err != nil {
+		return err
+	}
+	_, err = client.ReadWriteTransaction(ctx, func(ctx context.Context, txn *spanner.ReadWriteTransaction) error {
+		return f(ctx, SpannerTransaction{txn})
+	})
+	return err
+}
+
+type snapshotKey struct{}
+
+// readSnapshot holds the read-only transactions of the databases read in one
+// snapshot
+type readSnapshot map[*spanner.Client]*spanner.ReadOnlyTransaction
+
+// ReadSnapshot returns a context in which the batch reads of the tables are
+// made in a multi-use read-only transaction of their database, so they see
+// the same snapshot. Tables in different databases cannot share a snapshot
+// and get one per database. The returned function closes the transactions.
+func (s Storage) ReadSnapshot(ctx context.Context, tables []string) (context.Context, func(), error) {
+	snapshot := readSnapshot{}
+	for _, table := range tables {
+		client, err := s.getSpannerClient(table)
+		if err != nil {
+			snapshot.close()
+			return nil, nil, err
+		}
+		if _, ok := snapshot[client]; !ok {
+			snapshot[client] = client.ReadOnlyTransaction()
+		}
+	}
+	return context.WithValue(ctx, snapshotKey{}, snapshot), snapshot.close, nil
+}
+
+func (r readSnapshot) close() {
+	for _, txn := range r {
+		txn.Close()
+	}
+}
+
+// keySetReader reads the rows of a key set
+type keySetReader interface {
+	Read(ctx context.Context, table string, keys spanner.KeySet, columns []string) *spanner.RowIterator
+}
+
+// snapshotReader returns the read-only transaction of the snapshot of ctx on
+// the client's database, or a single-use one when ctx has none
+func snapshotReader(ctx context.Context, client *spanner.Client) keySetReader {
+	if snapshot, ok := ctx.Value(snapshotKey{}).(readSnapshot); ok {
+		if txn, ok := snapshot[client]; ok {
+			return txn
+		}
+	}
+	return client.Single()
+}
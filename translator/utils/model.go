@@ -1,6 +1,9 @@
 package translator
 
 import (
+	"maps"
+	"slices"
+
 	"github.com/cloudspannerecosystem/dynamodb-adapter/third_party/amazon_apache/translator/PartiQLParser/parser"
 	"go.uber.org/zap"
 )
@@ -8,6 +11,23 @@ import (
 type Translator struct {
 	Logger *zap.Logger
 	Debug  bool
+	// Table is the Spanner table statements are translated to run on, the
+	// table they name when it is empty
+	Table string
+	// ColumnType returns the DynamoDB type of a column, which contains, size
+	// and attribute_type depend on. Columns are strings when it is nil.
+	ColumnType func(table, column string) string
+	// ParameterType returns the DynamoDB type of the statement parameter of
+	// the ? at index, the type nested attributes compared with it are read as
+	ParameterType func(index int) string
+}
+
+// table returns the table a statement naming table is translated to run on
+func (t *Translator) table(table string) string {
+	if t.Table != "" {
+		return t.Table
+	}
+	return table
 }
 
 type Condition struct {
@@ -30,10 +50,15 @@ type SelectQueryListener struct {
 	Tables       []string
 	Where        []Condition
 	OrderBy      []string
+	Sort         []SortColumn
 	Limit        string
 	Offset       string
 	LogicStack   []LogicalGroup // Stack to track logical groups
 	CurrentLogic string         // Tracks current logical operator
+	Paths        []ProjectionPath
+	Aggregates   []Aggregate
+	Index        string
+	err          error
 }
 
 // SelectQueryMap represents the mapping of a select query along with its translation details.
@@ -42,15 +67,91 @@ type SelectQueryMap struct {
 	SpannerQuery      string
 	QueryType         string
 	Table             string
+	Index             string // Secondary index of references like "table"."index"
 	ParamKeys         []string
 	ProjectionColumns []string
-	OrderBy           []string // Ensure OrderBy is part of this struct
-	Limit             string   // Ensure Limit is part of this struct
-	Offset            string   // Ensure Offset is part of this struct
+	ProjectionPaths   []ProjectionPath // Nested attributes of the projection
+	Aggregates        []Aggregate      // Aggregate functions of the projection
+	OrderBy           []string         // Ensure OrderBy is part of this struct
+	Sort              []SortColumn     // Columns and directions of OrderBy
+	Limit             string           // Ensure Limit is part of this struct
+	Offset            string           // Ensure Offset is part of this struct
 	Where             []Condition
+	WhereClause       string                 // Translated condition of the WHERE clause
+	WhereTerms        []WhereTerm            // Conditions WhereClause ANDs together
+	WhereParams       []WhereParam           // Query parameters of WhereClause
 	Params            map[string]interface{} // To hold key and the values for parameterised query placeholder
 }
 
+// Clone returns a copy of m that shares nothing with it
+func (m *SelectQueryMap) Clone() *SelectQueryMap {
+	c := *m
+	c.ParamKeys = slices.Clone(m.ParamKeys)
+	c.ProjectionColumns = slices.Clone(m.ProjectionColumns)
+	c.ProjectionPaths = slices.Clone(m.ProjectionPaths)
+	c.Aggregates = slices.Clone(m.Aggregates)
+	c.OrderBy = slices.Clone(m.OrderBy)
+	c.Sort = slices.Clone(m.Sort)
+	c.Where = slices.Clone(m.Where)
+	c.WhereTerms = slices.Clone(m.WhereTerms)
+	c.WhereParams = slices.Clone(m.WhereParams)
+	c.Params = maps.Clone(m.Params)
+	return &c
+}
+
+// ProjectionPath is a nested attribute a SELECT projects, like a.b[0]. The
+// statement reads its Column, and the item returns the attribute at Path,
+// named after its alias or its last key.
+type ProjectionPath struct {
+	Name   string
+	Column string
+	Path   string
+}
+
+// Aggregate is an aggregate function a SELECT projects, like COUNT(*) or
+// SUM(total), named after its alias or its position. Column is empty for
+// COUNT(*).
+type Aggregate struct {
+	Name     string
+	Function string
+	Column   string
+}
+
+// SQL returns the Spanner aggregate function of the aggregate
+func (a Aggregate) SQL() string {
+	if a.Column == "" {
+		return "COUNT(*)"
+	}
+	return a.Function + "(`" + a.Column + "`)"
+}
+
+// SortColumn is an expression of the ORDER BY clause. Column is empty when
+// the expression is not a column.
+type SortColumn struct {
+	Column string
+	Desc   bool
+}
+
+// WhereTerm is one of the conditions a WHERE clause ANDs together. Column and
+// Operator are set when the condition compares a column with values, with
+// Operator one of =, <>, !=, <, <=, >, >=, IN, BETWEEN or BEGINS_WITH.
+type WhereTerm struct {
+	Condition string // Spanner condition
+	Column    string
+	Operator  string
+}
+
+// WhereParam is a query parameter of a translated WHERE clause. Its value is
+// the statement parameter of the ? at Placeholder, or a literal of DynamoDB
+// type Type (S, N or BOOL) when Placeholder is -1.
+type WhereParam struct {
+	Name        string // Parameter name, without the @
+	Column      string // Column the value is compared with, if any
+	Placeholder int
+	Type        string
+	Literal     string
+}
+
 type Clause struct {
 	Column       string
 	Operator     string
@@ -65,9 +166,77 @@ type DeleteUpdateQueryMap struct {
 	Table           string                 // Table involved in the query
 	UpdateSetValues []UpdateSetValue       // Values to be updated
 	Clauses         []Clause               // List of clauses in the update query
+	WhereClause     string                 // Translated condition of the WHERE clause
+	WhereTerms      []WhereTerm            // Conditions WhereClause ANDs together
+	WhereParams     []WhereParam           // Query parameters of WhereClause
+	Returning       string                 // Item values returned: ALL OLD, MODIFIED OLD, ALL NEW or MODIFIED NEW
 	PrimaryKeys     []string               // Primary keys of the table                   // Flag to indicate if local IDs pattern is used
+	Actions         []UpdateAction         // SET and REMOVE actions of an UPDATE, in order
 	Params          map[string]interface{} // To hold key and the values for parameterised query placeholder
 }
+
+// Clone returns a copy of m that shares nothing with it
+func (m *DeleteUpdateQueryMap) Clone() *DeleteUpdateQueryMap {
+	c := *m
+	c.UpdateSetValues = slices.Clone(m.UpdateSetValues)
+	c.Clauses = slices.Clone(m.Clauses)
+	c.WhereTerms = slices.Clone(m.WhereTerms)
+	c.WhereParams = slices.Clone(m.WhereParams)
+	c.PrimaryKeys = slices.Clone(m.PrimaryKeys)
+	c.Actions = slices.Clone(m.Actions)
+	for i := range c.Actions {
+		c.Actions[i].Value = c.Actions[i].Value.Clone()
+	}
+	c.Params = maps.Clone(m.Params)
+	return &c
+}
+
+// UpdateAction is a SET or REMOVE of an UPDATE statement on the attribute at
+// Path, like a.b[0], of Column. Value is nil for REMOVE and for SET
+// expressions that are not values, which only run as Spanner DML.
+type UpdateAction struct {
+	Path   string
+	Column string
+	Remove bool
+	Value  *Value
+}
+
+// Value is a value of a SET action or of the item of an INSERT. Kind is S, N
+// or BOOL for literals, with the literal in Literal, NULL, PARAM for the
+// statement parameter of the ? at Placeholder, M for tuples, with their
+// Fields, L for arrays and BAG for bags, with their Elements, PATH for the
+// attribute of the item at Path and LIST_APPEND for list_append, with its
+// arguments in Elements.
+type Value struct {
+	Kind        string
+	Literal     string
+	Placeholder int
+	Path        string
+	Fields      map[string]*Value
+	Elements    []*Value
+}
+
+// Clone returns a copy of v that shares nothing with it
+func (v *Value) Clone() *Value {
+	if v == nil {
+		return nil
+	}
+	c := *v
+	if v.Fields != nil {
+		c.Fields = make(map[string]*Value, len(v.Fields))
+		for name, field := range v.Fields {
+			c.Fields[name] = field.Clone()
+		}
+	}
+	if v.Elements != nil {
+		c.Elements = make([]*Value, len(v.Elements))
+		for i, elem := range v.Elements {
+			c.Elements[i] = elem.Clone()
+		}
+	}
+	return &c
+}
+
 type UpdateSetValue struct {
 	Column   string
 	Value    string
@@ -78,8 +247,10 @@ type UpdateSetValue struct {
 // Listener for DELETE queries.
 type DeleteQueryListener struct {
 	*parser.BasePartiQLParserListener
-	Table string
-	Where []Condition
+	Table     string
+	Where     []Condition
+	Returning string
+	err       error
 }
 
 type DeleteQueryMap struct {
@@ -94,20 +265,37 @@ type DeleteQueryMap struct {
 }
 
 type InsertStatement struct {
-	PartiQL       string // Original query string
-	SpannerQuery  string
-	Table         string
-	Columns       []string
-	Values        []string
-	OnConflict    string
-	AdditionalMap map[string]interface{} //
-	Params        map[string]interface{} // To hold key and the values for parameterised query placeholder
+	PartiQL      string // Original query string
+	SpannerQuery string
+	Table        string
+	Columns      []string
+	Values       []string
+	OnConflict   string                 // Action of ON CONFLICT: NOTHING or REPLACE
+	Item         map[string]*Value      // Attributes of the inserted item
+	Params       map[string]interface{} // To hold key and the values for parameterised query placeholder
+}
+
+// Clone returns a copy of s that shares nothing with it
+func (s *InsertStatement) Clone() *InsertStatement {
+	c := *s
+	c.Columns = slices.Clone(s.Columns)
+	c.Values = slices.Clone(s.Values)
+	if s.Item != nil {
+		c.Item = make(map[string]*Value, len(s.Item))
+		for name, value := range s.Item {
+			c.Item[name] = value.Clone()
+		}
+	}
+	c.Params = maps.Clone(s.Params)
+	return &c
 }
 
 // Listener for INSERT queries.
 type InsertQueryListener struct {
 	*parser.BasePartiQLParserListener
 	InsertData InsertStatement
+	value      parser.IExprContext
+	err        error
 }
 
 type SetClause struct {
@@ -123,4 +311,9 @@ type UpdateQueryListener struct {
 	SetColumns []string // Storage for column-specific updates.
 	Where      []Condition
 	SetClauses []SetClause
+	Actions    []UpdateAction
+	Returning  string
+	// placeholders holds the index of every ? of the statement
+	placeholders map[*parser.ParameterContext]int
+	err          error
 }
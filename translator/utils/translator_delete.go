@@ -1,6 +1,8 @@
 package translator
 
 import (
+	"fmt"
+
 	"github.com/antlr4-go/antlr/v4"
 	"github.com/cloudspannerecosystem/dynamodb-adapter/third_party/amazon_apache/translator/PartiQLParser/parser"
 )
@@ -13,6 +15,18 @@ func (l *DeleteQueryListener) EnterDeleteCommand(ctx *parser.DeleteCommandContex
 }
 
 // Extracts WHERE conditions for DELETE
+// EnterReturningClause records the RETURNING clause, which only returns the
+// deleted item
+func (l *DeleteQueryListener) EnterReturningClause(ctx *parser.ReturningClauseContext) {
+	var err error
+	if l.Returning, err = returningValues(ctx); err == nil && l.Returning != "ALL OLD" {
+		err = fmt.Errorf("unsupported RETURNING clause for DELETE: %s", treeText(ctx))
+	}
+	if err != nil && l.err == nil {
+		l.err = err
+	}
+}
+
 func (l *DeleteQueryListener) EnterPredicateComparison(ctx *parser.PredicateComparisonContext) {
 	column := ctx.GetLhs().GetText()
 	operator := ctx.GetOp().GetText()
@@ -34,8 +48,13 @@ func (t *Translator) ToSpannerDelete(query string) (*DeleteUpdateQueryMap, error
 	p := parser.NewPartiQLParser(stream)
 
 	// Parse the input query
-	antlr.ParseTreeWalkerDefault.Walk(deleteListener, p.Root())
-	deleteQueryMap.Table = deleteListener.Table
+	root := p.Root()
+	antlr.ParseTreeWalkerDefault.Walk(deleteListener, root)
+	if deleteListener.err != nil {
+		return nil, deleteListener.err
+	}
+	deleteQueryMap.Table = t.table(deleteListener.Table)
+	deleteQueryMap.Returning = deleteListener.Returning
 
 	// Populate deleteQueryMap.Clauses from deleteListener.Where
 	if len(deleteListener.Where) > 0 {
@@ -49,13 +68,18 @@ func (t *Translator) ToSpannerDelete(query string) (*DeleteUpdateQueryMap, error
 	}
 	deleteQueryMap.QueryType = "DELETE"
 	deleteQueryMap.PartiQLQuery = query
-	deleteQueryMap.SpannerQuery = createSpannerDeleteQuery(deleteListener.Table, deleteQueryMap.Clauses)
+	var err error
+	deleteQueryMap.WhereClause, deleteQueryMap.WhereTerms, deleteQueryMap.WhereParams, err = t.translateWhere(root, deleteQueryMap.Table)
+	if err != nil {
+		return nil, err
+	}
+	deleteQueryMap.SpannerQuery = createSpannerDeleteQuery(deleteQueryMap)
 	return deleteQueryMap, nil
 }
 
 // createSpannerDeleteQuery generates the Spanner delete query using Parsed information.
 //
-// It takes the table name and an array of clauses as input and returns the generated query string.
-func createSpannerDeleteQuery(table string, clauses []Clause) string {
-	return "DELETE FROM " + table + buildWhereClause(clauses) + ";"
+// It takes the parsed delete query and returns the generated query string.
+func createSpannerDeleteQuery(deleteQueryMap *DeleteUpdateQueryMap) string {
+	return "DELETE FROM " + deleteQueryMap.Table + whereClause(deleteQueryMap) + ";"
 }
@@ -38,3 +38,13 @@ func TestToSpannerDelete(t *testing.T) {
 		})
 	}
 }
+
+func TestToSpannerDeleteReturning(t *testing.T) {
+	deleteQueryMap, err := (&Translator{}).ToSpannerDelete("DELETE FROM orders WHERE id = 1 RETURNING ALL OLD *")
+	assert.NoError(t, err)
+	assert.Equal(t, "ALL OLD", deleteQueryMap.Returning)
+	assert.Equal(t, "DELETE FROM orders WHERE `id` = @id;", deleteQueryMap.SpannerQuery)
+
+	_, err = (&Translator{}).ToSpannerDelete("DELETE FROM orders WHERE id = 1 RETURNING ALL NEW *")
+	assert.Error(t, err)
+}
@@ -0,0 +1,254 @@
+package translator
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/antlr4-go/antlr/v4"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/third_party/amazon_apache/translator/PartiQLParser/parser"
+)
+
+// Map and List attributes are stored in JSON columns as DynamoDB JSON, so the
+// attribute at a path like a.b[0] is at $.M.b.L[0] in the JSON of column a.
+
+// simpleKeyPattern matches the keys JSONPath does not quote
+var simpleKeyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// attributePath is an attribute nested in a column
+type attributePath struct {
+	column string
+	steps  []pathStep
+}
+
+// pathStep is a step of a path, a map key or a list index
+type pathStep struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+// String returns the path as written in update expressions, like a.b[0]
+func (p attributePath) String() string {
+	path := p.column
+	for _, step := range p.steps {
+		if step.isIndex {
+			path += "[" + strconv.Itoa(step.index) + "]"
+		} else {
+			path += "." + step.key
+		}
+	}
+	return path
+}
+
+// jsonPath returns the JSONPath of the attribute in the DynamoDB JSON of its
+// column
+func (p attributePath) jsonPath() string {
+	path := "$"
+	for _, step := range p.steps {
+		switch {
+		case step.isIndex:
+			path += ".L[" + strconv.Itoa(step.index) + "]"
+		case simpleKeyPattern.MatchString(step.key):
+			path += ".M." + step.key
+		default:
+			path += `.M."` + step.key + `"`
+		}
+	}
+	return path
+}
+
+// name returns the name of the query parameters of the values the attribute
+// is compared with
+func (p attributePath) name() string {
+	parts := []string{p.column}
+	for _, step := range p.steps {
+		if step.isIndex {
+			parts = append(parts, strconv.Itoa(step.index))
+		} else {
+			parts = append(parts, step.key)
+		}
+	}
+	return nonWordPattern.ReplaceAllString(strings.Join(parts, "_"), "_")
+}
+
+// addKey adds a map key step. Keys that cannot be written in paths or in the
+// JSONPath of a query are not supported.
+func (p *attributePath) addKey(key string) error {
+	if key == "" || strings.ContainsAny(key, `.[]'"\`) {
+		return fmt.Errorf("unsupported attribute name in path: %s", key)
+	}
+	p.steps = append(p.steps, pathStep{key: key})
+	return nil
+}
+
+func (p *attributePath) addIndex(text string) error {
+	index, err := strconv.Atoi(text)
+	if err != nil || index < 0 {
+		return fmt.Errorf("invalid list index in path: %s", text)
+	}
+	p.steps = append(p.steps, pathStep{index: index, isIndex: true})
+	return nil
+}
+
+// exprPath returns the nested attribute a value refers to. It reports false
+// when the value is not a path.
+func exprPath(tree antlr.Tree) (attributePath, bool, error) {
+	var ctx *parser.ExprPrimaryPathContext
+	for node := tree; node != nil && ctx == nil; node = onlyChild(node) {
+		ctx, _ = node.(*parser.ExprPrimaryPathContext)
+	}
+	if ctx == nil {
+		return attributePath{}, false, nil
+	}
+	path, ok, err := exprPath(ctx.ExprPrimary())
+	if err != nil {
+		return path, true, err
+	}
+	if !ok {
+		if path.column = columnOf(ctx.ExprPrimary()); path.column == "" {
+			return path, true, fmt.Errorf("unsupported path: %s", treeText(ctx))
+		}
+	}
+	for _, step := range ctx.AllPathStep() {
+		var err error
+		switch step := step.(type) {
+		case *parser.PathStepDotExprContext:
+			err = path.addKey(symbol(step.GetKey()))
+		case *parser.PathStepIndexExprContext:
+			if key, ok := stringLiteral(step.GetKey()); ok {
+				err = path.addKey(key)
+			} else {
+				err = path.addIndex(treeText(step.GetKey()))
+			}
+		default:
+			err = fmt.Errorf("unsupported path: %s", treeText(ctx))
+		}
+		if err != nil {
+			return path, true, err
+		}
+	}
+	return path, true, nil
+}
+
+// simplePath returns the attribute a path of a SET or REMOVE refers to
+func simplePath(ctx parser.IPathSimpleContext) (attributePath, error) {
+	path := attributePath{column: symbol(ctx.SymbolPrimitive())}
+	for _, step := range ctx.AllPathSimpleSteps() {
+		var err error
+		switch step := step.(type) {
+		case *parser.PathSimpleDotSymbolContext:
+			err = path.addKey(symbol(step.GetKey()))
+		case *parser.PathSimpleSymbolContext:
+			err = path.addKey(symbol(step.GetKey()))
+		case *parser.PathSimpleLiteralContext:
+			if key, ok := stringLiteral(step.GetKey()); ok {
+				err = path.addKey(key)
+			} else {
+				err = path.addIndex(treeText(step.GetKey()))
+			}
+		}
+		if err != nil {
+			return path, err
+		}
+	}
+	return path, nil
+}
+
+// symbol returns a name, unquoted
+func symbol(ctx parser.ISymbolPrimitiveContext) string {
+	name := ctx.GetText()
+	if strings.HasPrefix(name, `"`) && len(name) > 1 {
+		name = strings.ReplaceAll(name[1:len(name)-1], `""`, `"`)
+	}
+	return name
+}
+
+// placeholderIndexes returns the index of every ? of a statement
+func placeholderIndexes(root antlr.Tree) map[*parser.ParameterContext]int {
+	placeholders := map[*parser.ParameterContext]int{}
+	var walk func(tree antlr.Tree)
+	walk = func(tree antlr.Tree) {
+		if ctx, ok := tree.(*parser.ParameterContext); ok {
+			placeholders[ctx] = len(placeholders)
+		}
+		for _, child := range tree.GetChildren() {
+			walk(child)
+		}
+	}
+	walk(root)
+	return placeholders
+}
+
+// parseValue parses a value of a SET action or of an INSERT item
+func parseValue(tree antlr.Tree, placeholders map[*parser.ParameterContext]int) (*Value, error) {
+	if path, ok, err := exprPath(tree); ok {
+		if err != nil {
+			return nil, err
+		}
+		return &Value{Kind: "PATH", Path: path.String()}, nil
+	}
+	switch ctx := tree.(type) {
+	case *parser.ParameterContext:
+		return &Value{Kind: "PARAM", Placeholder: placeholders[ctx]}, nil
+	case *parser.VariableIdentifierContext:
+		return &Value{Kind: "PATH", Path: identifier(ctx)}, nil
+	case *parser.LiteralStringContext:
+		value, _ := stringLiteral(ctx)
+		return &Value{Kind: "S", Literal: value}, nil
+	case *parser.LiteralIntegerContext, *parser.LiteralDecimalContext:
+		return &Value{Kind: "N", Literal: treeText(ctx)}, nil
+	case *parser.LiteralTrueContext, *parser.LiteralFalseContext:
+		return &Value{Kind: "BOOL", Literal: strings.ToLower(treeText(ctx))}, nil
+	case *parser.LiteralNullContext:
+		return &Value{Kind: "NULL"}, nil
+	case *parser.ValueExprContext:
+		if ctx.GetSign() != nil {
+			number := treeText(ctx)
+			if _, err := strconv.ParseFloat(number, 64); err != nil {
+				return nil, fmt.Errorf("unsupported value: %s", number)
+			}
+			return &Value{Kind: "N", Literal: number}, nil
+		}
+	case *parser.TupleContext:
+		fields := make(map[string]*Value, len(ctx.AllPair()))
+		for _, pair := range ctx.AllPair() {
+			pair := pair.(*parser.PairContext)
+			key, ok := stringLiteral(pair.GetLhs())
+			if !ok {
+				return nil, fmt.Errorf("the keys of a tuple must be string literals: %s", treeText(pair))
+			}
+			value, err := parseValue(pair.GetRhs(), placeholders)
+			if err != nil {
+				return nil, err
+			}
+			fields[key] = value
+		}
+		return &Value{Kind: "M", Fields: fields}, nil
+	case *parser.ArrayContext:
+		return parseValues("L", ctx.AllExpr(), placeholders)
+	case *parser.BagContext:
+		return parseValues("BAG", ctx.AllExpr(), placeholders)
+	case *parser.FunctionCallContext:
+		if args := ctx.AllExpr(); strings.EqualFold(ctx.FunctionName().GetText(), "list_append") && len(args) == 2 {
+			return parseValues("LIST_APPEND", args, placeholders)
+		}
+		return nil, fmt.Errorf("unsupported function: %s", treeText(ctx))
+	}
+	if child := onlyChild(tree); child != nil {
+		return parseValue(child, placeholders)
+	}
+	return nil, fmt.Errorf("unsupported value: %s", treeText(tree))
+}
+
+func parseValues(kind string, exprs []parser.IExprContext, placeholders map[*parser.ParameterContext]int) (*Value, error) {
+	value := &Value{Kind: kind, Elements: make([]*Value, len(exprs))}
+	for i, expr := range exprs {
+		var err error
+		if value.Elements[i], err = parseValue(expr, placeholders); err != nil {
+			return nil, err
+		}
+	}
+	return value, nil
+}
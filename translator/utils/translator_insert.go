@@ -1,6 +1,7 @@
 package translator
 
 import (
+	"fmt"
 	"strings"
 
 	"github.com/antlr4-go/antlr/v4"
@@ -10,14 +11,20 @@ import (
 // Methods for INSERT Listener
 func (l *InsertQueryListener) EnterInsertStatement(ctx *parser.InsertStatementContext) {
 	l.InsertData.Table = ctx.SymbolPrimitive().GetText()
+	l.value = ctx.GetValue()
 }
 
 func (l *InsertQueryListener) EnterInsertCommandReturning(ctx *parser.InsertCommandReturningContext) {
 	l.InsertData.Table = ctx.PathSimple().GetText()
+	l.value = ctx.GetValue()
+	if ctx.ReturningClause() != nil {
+		l.setError(fmt.Errorf("RETURNING is not supported for INSERT"))
+	}
 }
 
 func (l *InsertQueryListener) EnterInsertStatementLegacy(ctx *parser.InsertStatementLegacyContext) {
 	l.InsertData.Table = ctx.PathSimple().GetText()
+	l.value = ctx.GetValue()
 }
 
 func (l *InsertQueryListener) EnterProjectionItems(ctx *parser.ProjectionItemsContext) {
@@ -32,75 +39,66 @@ func (l *InsertQueryListener) EnterValueList(ctx *parser.ValueListContext) {
 	}
 }
 
-func (l *InsertQueryListener) EnterValueExpr(ctx *parser.ValueExprContext) {
-	jsonText := ctx.GetText()
-	if strings.HasPrefix(jsonText, "{") {
-		l.parseJsonObject(jsonText)
+// EnterOnConflict records the action taken when the item exists. Items only
+// conflict on their primary key, and replacing them cannot be conditional.
+func (l *InsertQueryListener) EnterOnConflict(ctx *parser.OnConflictContext) {
+	action := ctx.ConflictAction().(*parser.ConflictActionContext)
+	switch {
+	case action.NOTHING() != nil:
+		l.InsertData.OnConflict = "NOTHING"
+	case action.REPLACE() != nil && action.DoReplace().(*parser.DoReplaceContext).WHERE() == nil:
+		l.InsertData.OnConflict = "REPLACE"
+	default:
+		l.setError(fmt.Errorf("unsupported ON CONFLICT action: %s", treeText(action)))
 	}
 }
 
-func (l *InsertQueryListener) parseJsonObject(jsonString string) {
-	jsonString = strings.Trim(jsonString, "{}")
-	pairs := strings.Split(jsonString, ",")
-
-	for _, pair := range pairs {
-		parts := strings.Split(pair, ":")
-		if len(parts) == 2 {
-			key := strings.TrimSpace(strings.Trim(parts[0], "'")) // Remove surrounding quotes
-			value := strings.TrimSpace(parts[1])
-
-			if key == "additional_info" {
-				// Logic to parse nested JSON as a map
-				mapValue := make(map[string]interface{})
-				l.additionalMapParser(value, mapValue)
-				l.InsertData.AdditionalMap = mapValue
-			} else {
-				l.InsertData.Columns = append(l.InsertData.Columns, key)
-				l.InsertData.Values = append(l.InsertData.Values, value)
-			}
-		}
-	}
+func (l *InsertQueryListener) EnterOnConflictLegacy(ctx *parser.OnConflictLegacyContext) {
+	l.setError(fmt.Errorf("ON CONFLICT WHERE is not supported: %s", treeText(ctx)))
 }
 
-// Function to parse additional map information recursively
-func (l *InsertQueryListener) additionalMapParser(jsonString string, m map[string]interface{}) {
-	// Implement your logic to parse the map string into a Go map
-	// Note that parsing logic will depend on your expected structure
-	jsonString = strings.Trim(jsonString, "{}")
-	pairs := strings.Split(jsonString, ",")
-
-	for _, pair := range pairs {
-		parts := strings.Split(pair, ":")
-		if len(parts) == 2 {
-			key := strings.TrimSpace(strings.Trim(parts[0], "'"))
-			value := strings.TrimSpace(parts[1])
-
-			// If the value is another JSON object or array, you might need to parse again or handle lists.
-			m[key] = value // For simplicity, putting value directly
-		}
+// setError records the first error of the statement
+func (l *InsertQueryListener) setError(err error) {
+	if l.err == nil {
+		l.err = err
 	}
 }
-func (l *InsertQueryListener) EnterOnConflict(ctx *parser.OnConflictContext) {
-	l.InsertData.OnConflict = ctx.GetText()
-}
 
 func (t *Translator) ToSpannerInsert(query string) (*InsertStatement, error) {
 	insertListener := &InsertQueryListener{}
 	insertStatement := &InsertStatement{}
 	// Lexer and parser setup
-	query = strings.ReplaceAll(query, `?`, `'?'`)
 	lexer := parser.NewPartiQLLexer(antlr.NewInputStream(query))
 	stream := antlr.NewCommonTokenStream(lexer, antlr.TokenDefaultChannel)
 	p := parser.NewPartiQLParser(stream)
-	antlr.ParseTreeWalkerDefault.Walk(insertListener, p.Root())
+	root := p.Root()
+	antlr.ParseTreeWalkerDefault.Walk(insertListener, root)
+	if insertListener.err != nil {
+		return nil, insertListener.err
+	}
 
-	insertStatement.Table = insertListener.InsertData.Table
+	insertStatement.Table = t.table(insertListener.InsertData.Table)
 	for _, column := range insertListener.InsertData.Columns {
 		col := trimSingleQuotes(column)
 		insertStatement.Columns = append(insertStatement.Columns, col)
 	}
 	insertStatement.Values = append(insertStatement.Values, insertListener.InsertData.Values...)
-	insertStatement.AdditionalMap = insertListener.InsertData.AdditionalMap
+	if insertListener.value != nil {
+		// the item is a tuple of the values of its attributes
+		item, err := parseValue(insertListener.value, placeholderIndexes(root))
+		if err != nil {
+			return nil, err
+		}
+		if item.Kind != "M" {
+			return nil, fmt.Errorf("the value of an INSERT must be a tuple: %s", insertListener.value.GetText())
+		}
+		insertStatement.Item = item.Fields
+		for _, pair := range tuplePairs(insertListener.value) {
+			key, _ := stringLiteral(pair.GetLhs())
+			insertStatement.Columns = append(insertStatement.Columns, key)
+			insertStatement.Values = append(insertStatement.Values, pair.GetRhs().GetText())
+		}
+	}
 	insertStatement.OnConflict = insertListener.InsertData.OnConflict
 
 	insertStatement.PartiQL = query
@@ -108,6 +106,20 @@ func (t *Translator) ToSpannerInsert(query string) (*InsertStatement, error) {
 	return insertStatement, nil
 }
 
+// tuplePairs returns the pairs of a tuple
+func tuplePairs(tree antlr.Tree) []*parser.PairContext {
+	for ; tree != nil; tree = onlyChild(tree) {
+		if tuple, ok := tree.(*parser.TupleContext); ok {
+			pairs := make([]*parser.PairContext, len(tuple.AllPair()))
+			for i, pair := range tuple.AllPair() {
+				pairs[i] = pair.(*parser.PairContext)
+			}
+			return pairs
+		}
+	}
+	return nil
+}
+
 func getSpannerInsertQuery(data *InsertStatement) string {
 	var valuePlaceholders []string
 
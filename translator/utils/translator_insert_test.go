@@ -46,3 +46,42 @@ func TestToSpannerInsert(t *testing.T) {
 	// Assertions for AdditionalMap
 	assert.Empty(t, insertStatement.OnConflict) // Check OnConflict if necessary
 }
+
+func TestToSpannerInsertItem(t *testing.T) {
+	insertStatement, err := (&Translator{}).ToSpannerInsert("INSERT INTO orders VALUE {'id': ?, 'info': {'a': [1, 'x']}, 'tags': <<'a'>>, 'gone': NULL}")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"id", "info", "tags", "gone"}, insertStatement.Columns)
+	assert.Equal(t, []string{"?", "{'a':[1,'x']}", "<<'a'>>", "NULL"}, insertStatement.Values)
+	assert.Equal(t, map[string]*Value{
+		"id": {Kind: "PARAM"},
+		"info": {Kind: "M", Fields: map[string]*Value{
+			"a": {Kind: "L", Elements: []*Value{{Kind: "N", Literal: "1"}, {Kind: "S", Literal: "x"}}},
+		}},
+		"tags": {Kind: "BAG", Elements: []*Value{{Kind: "S", Literal: "a"}}},
+		"gone": {Kind: "NULL"},
+	}, insertStatement.Item)
+}
+
+func TestToSpannerInsertOnConflict(t *testing.T) {
+	insertStatement, err := (&Translator{}).ToSpannerInsert("INSERT INTO orders VALUE {'id': 1}")
+	assert.NoError(t, err)
+	assert.Empty(t, insertStatement.OnConflict)
+
+	insertStatement, err = (&Translator{}).ToSpannerInsert("INSERT INTO orders {'id': 1} ON CONFLICT DO NOTHING")
+	assert.NoError(t, err)
+	assert.Equal(t, "NOTHING", insertStatement.OnConflict)
+	assert.Equal(t, map[string]*Value{"id": {Kind: "N", Literal: "1"}}, insertStatement.Item)
+
+	insertStatement, err = (&Translator{}).ToSpannerInsert("INSERT INTO orders {'id': 1} ON CONFLICT DO REPLACE EXCLUDED")
+	assert.NoError(t, err)
+	assert.Equal(t, "REPLACE", insertStatement.OnConflict)
+
+	for _, query := range []string{
+		"INSERT INTO orders {'id': 1} ON CONFLICT DO REPLACE EXCLUDED WHERE id = 1",
+		"INSERT INTO orders VALUE {'id': 1} ON CONFLICT WHERE id = 1 DO NOTHING",
+		"INSERT INTO orders VALUE {'id': 1} RETURNING ALL NEW *",
+	} {
+		_, err = (&Translator{}).ToSpannerInsert(query)
+		assert.Error(t, err, query)
+	}
+}
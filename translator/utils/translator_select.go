@@ -1,6 +1,9 @@
 package translator
 
 import (
+	"fmt"
+	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/antlr4-go/antlr/v4"
@@ -18,18 +21,113 @@ func (l *SelectQueryListener) EnterExprOr(ctx *parser.ExprOrContext) {
 }
 
 func (l *SelectQueryListener) EnterProjectionItems(ctx *parser.ProjectionItemsContext) {
-	for _, proj := range ctx.AllProjectionItem() {
-		l.Columns = append(l.Columns, proj.GetText())
+	for i, proj := range ctx.AllProjectionItem() {
+		if aggregate := aggregateOf(proj.Expr()); aggregate != nil {
+			l.enterAggregate(aggregate, proj, i)
+			continue
+		}
+		path, ok, err := exprPath(proj.Expr())
+		if !ok {
+			l.Columns = append(l.Columns, proj.GetText())
+			continue
+		}
+		if err != nil {
+			l.setError(err)
+			continue
+		}
+		// like PartiQL, attributes without an alias are named after their
+		// last key, or their position
+		name := "_" + strconv.Itoa(i+1)
+		if alias := proj.SymbolPrimitive(); alias != nil {
+			name = symbol(alias)
+		} else if last := path.steps[len(path.steps)-1]; !last.isIndex {
+			name = last.key
+		}
+		l.Paths = append(l.Paths, ProjectionPath{Name: name, Column: path.column, Path: path.String()})
 	}
 }
 
+// enterAggregate records an aggregate function of the projection. Like
+// PartiQL, aggregates without an alias are named after their position.
+func (l *SelectQueryListener) enterAggregate(ctx antlr.Tree, proj parser.IProjectionItemContext, i int) {
+	aggregate := Aggregate{Name: "_" + strconv.Itoa(i+1), Function: "COUNT"}
+	if alias := proj.SymbolPrimitive(); alias != nil {
+		aggregate.Name = symbol(alias)
+	}
+	if base, ok := ctx.(*parser.AggregateBaseContext); ok {
+		aggregate.Function = strings.ToUpper(base.GetFunc_().GetText())
+		if !slices.Contains(aggregateFunctions, aggregate.Function) {
+			l.setError(fmt.Errorf("unsupported aggregate function: %s", aggregate.Function))
+			return
+		}
+		if base.SetQuantifierStrategy() != nil {
+			l.setError(fmt.Errorf("unsupported aggregate: %s", treeText(base)))
+			return
+		}
+		if aggregate.Column = columnOf(base.Expr()); aggregate.Column == "" {
+			l.setError(fmt.Errorf("aggregate functions only take top-level attributes: %s", treeText(base)))
+			return
+		}
+	}
+	l.Aggregates = append(l.Aggregates, aggregate)
+}
+
+// aggregateFunctions are the aggregate functions a SELECT may project
+var aggregateFunctions = []string{"COUNT", "SUM", "MIN", "MAX", "AVG"}
+
+// aggregateOf returns the aggregate function an expression is, nil when it
+// is not one
+func aggregateOf(tree antlr.Tree) antlr.Tree {
+	for node := tree; node != nil; node = onlyChild(node) {
+		switch node.(type) {
+		case *parser.CountAllContext, *parser.AggregateBaseContext:
+			return node
+		}
+	}
+	return nil
+}
+
+func (l *SelectQueryListener) EnterGroupClause(ctx *parser.GroupClauseContext) {
+	l.setError(fmt.Errorf("GROUP BY is not supported"))
+}
+
+// EnterFromClause records the table, and the secondary index of references
+// like "table"."index"
 func (l *SelectQueryListener) EnterFromClause(ctx *parser.FromClauseContext) {
+	path, ok, err := exprPath(ctx.TableReference())
+	switch {
+	case err != nil:
+		l.setError(err)
+	case ok && len(path.steps) == 1 && !path.steps[0].isIndex:
+		l.Tables = append(l.Tables, path.column)
+		l.Index = path.steps[0].key
+		return
+	case ok:
+		l.setError(fmt.Errorf("unsupported table reference: %s", treeText(ctx.TableReference())))
+	}
 	l.Tables = append(l.Tables, ctx.TableReference().GetText())
 }
 
+// setError records the first error of the statement
+func (l *SelectQueryListener) setError(err error) {
+	if l.err == nil {
+		l.err = err
+	}
+}
+
 func (l *SelectQueryListener) EnterOrderByClause(ctx *parser.OrderByClauseContext) {
 	for _, orderSpec := range ctx.AllOrderSortSpec() {
-		l.OrderBy = append(l.OrderBy, orderSpec.GetText())
+		column := SortColumn{Column: columnOf(orderSpec.Expr())}
+		orderBy := column.Column
+		if orderBy == "" {
+			orderBy = orderSpec.Expr().GetText()
+		}
+		if dir := orderSpec.(*parser.OrderSortSpecContext).GetDir(); dir != nil {
+			column.Desc = strings.EqualFold(dir.GetText(), "DESC")
+			orderBy += " " + strings.ToUpper(dir.GetText())
+		}
+		l.OrderBy = append(l.OrderBy, orderBy)
+		l.Sort = append(l.Sort, column)
 	}
 }
 
@@ -100,7 +198,14 @@ func (t *Translator) ToSpannerSelect(query string) (*SelectQueryMap, error) {
 	p := parser.NewPartiQLParser(stream)
 
 	selectListener := &SelectQueryListener{}
-	antlr.ParseTreeWalkerDefault.Walk(selectListener, p.Root())
+	root := p.Root()
+	antlr.ParseTreeWalkerDefault.Walk(selectListener, root)
+	if selectListener.err != nil {
+		return nil, selectListener.err
+	}
+	if len(selectListener.Aggregates) > 0 && (len(selectListener.Columns) > 0 || len(selectListener.Paths) > 0) {
+		return nil, fmt.Errorf("aggregate functions cannot be projected with attributes")
+	}
 
 	// Capture WHERE conditions
 	whereConditions = append(whereConditions, selectListener.Where...)
@@ -110,14 +215,22 @@ func (t *Translator) ToSpannerSelect(query string) (*SelectQueryMap, error) {
 		PartiQLQuery:      query,
 		SpannerQuery:      "",       // TODO: Assign translated Spanner SQL
 		QueryType:         "SELECT", // Assuming SELECT by context
-		Table:             selectListener.Tables[0],
+		Table:             t.table(selectListener.Tables[0]),
+		Index:             selectListener.Index,
 		ParamKeys:         []string{}, // Populate if params are used
 		ProjectionColumns: selectListener.Columns,
+		ProjectionPaths:   selectListener.Paths,
+		Aggregates:        selectListener.Aggregates,
 		Limit:             selectListener.Limit,
 		OrderBy:           selectListener.OrderBy,
+		Sort:              selectListener.Sort,
 		Offset:            selectListener.Offset,
 		Where:             whereConditions,
 	}
+	selectQueryMap.WhereClause, selectQueryMap.WhereTerms, selectQueryMap.WhereParams, err = t.translateWhere(root, selectQueryMap.Table)
+	if err != nil {
+		return nil, err
+	}
 	// Generate Spanner query string
 	selectQueryMap.SpannerQuery, err = formSpannerSelectQuery(selectQueryMap, whereConditions)
 	if err != nil {
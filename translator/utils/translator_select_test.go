@@ -47,3 +47,57 @@ func TestToSpannerSelect(t *testing.T) {
 	// Assertions for OFFSET clause
 	assert.Equal(t, expectedOffset, response.Offset)
 }
+
+func TestToSpannerSelectIndex(t *testing.T) {
+	translator := Translator{}
+	for _, query := range []string{
+		`SELECT * FROM "orders"."by-status" WHERE status = 'open' ORDER BY total DESC`,
+		`SELECT * FROM orders."by-status" WHERE status = 'open' ORDER BY total DESC`,
+	} {
+		response, err := translator.ToSpannerSelect(query)
+		assert.NoError(t, err)
+		assert.Equal(t, "orders", response.Table)
+		assert.Equal(t, "by-status", response.Index)
+		assert.Equal(t, []SortColumn{{Column: "total", Desc: true}}, response.Sort)
+		assert.Equal(t, "SELECT * FROM orders@{FORCE_INDEX=by_status} WHERE `status` = @status ORDER BY total DESC;", response.SpannerQuery)
+	}
+
+	response, err := translator.ToSpannerSelect(`SELECT * FROM "orders" WHERE id = 1`)
+	assert.NoError(t, err)
+	assert.Empty(t, response.Index)
+
+	_, err = translator.ToSpannerSelect(`SELECT * FROM "orders"."a"."b"`)
+	assert.Error(t, err)
+}
+
+func TestToSpannerSelectTable(t *testing.T) {
+	translator := Translator{Table: "dev_my_orders"}
+	response, err := translator.ToSpannerSelect(`SELECT * FROM "my-orders" WHERE id = 1`)
+	assert.NoError(t, err)
+	assert.Equal(t, "dev_my_orders", response.Table)
+	assert.Equal(t, "SELECT * FROM dev_my_orders WHERE `id` = @id;", response.SpannerQuery)
+}
+
+func TestToSpannerSelectAggregates(t *testing.T) {
+	translator := Translator{}
+	response, err := translator.ToSpannerSelect(`SELECT COUNT(*), SUM(total) AS spent, max("status") FROM orders WHERE customer = ?`)
+	assert.NoError(t, err)
+	assert.Equal(t, []Aggregate{
+		{Name: "_1", Function: "COUNT"},
+		{Name: "spent", Function: "SUM", Column: "total"},
+		{Name: "_3", Function: "MAX", Column: "status"},
+	}, response.Aggregates)
+	assert.Empty(t, response.ProjectionColumns)
+	assert.Equal(t, "SELECT COUNT(*) AS `_1`, SUM(`total`) AS `spent`, MAX(`status`) AS `_3` FROM orders WHERE `customer` = @customer;", response.SpannerQuery)
+
+	for _, query := range []string{
+		`SELECT COUNT(*), status FROM orders`,
+		`SELECT COUNT(DISTINCT status) FROM orders`,
+		`SELECT SUM(info.total) FROM orders`,
+		`SELECT EVERY(paid) FROM orders`,
+		`SELECT status, COUNT(*) FROM orders GROUP BY status`,
+	} {
+		_, err := translator.ToSpannerSelect(query)
+		assert.Error(t, err, query)
+	}
+}
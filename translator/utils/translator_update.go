@@ -22,6 +22,40 @@ func (l *UpdateQueryListener) EnterSetCommand(ctx *parser.SetCommandContext) {
 			Operator: "=",
 			Value:    value,
 		})
+		path, err := simplePath(setAssign.PathSimple())
+		if err != nil {
+			l.setError(err)
+			continue
+		}
+		action := UpdateAction{Path: path.String(), Column: path.column}
+		if action.Value, err = parseValue(setAssign.Expr(), l.placeholders); err != nil && len(path.steps) > 0 {
+			// expressions of columns run as Spanner DML
+			l.setError(err)
+		}
+		l.Actions = append(l.Actions, action)
+	}
+}
+
+func (l *UpdateQueryListener) EnterRemoveCommand(ctx *parser.RemoveCommandContext) {
+	path, err := simplePath(ctx.PathSimple())
+	if err != nil {
+		l.setError(err)
+		return
+	}
+	l.Actions = append(l.Actions, UpdateAction{Path: path.String(), Column: path.column, Remove: true})
+}
+
+func (l *UpdateQueryListener) EnterReturningClause(ctx *parser.ReturningClauseContext) {
+	var err error
+	if l.Returning, err = returningValues(ctx); err != nil {
+		l.setError(err)
+	}
+}
+
+// setError records the first error of the statement
+func (l *UpdateQueryListener) setError(err error) {
+	if l.err == nil {
+		l.err = err
 	}
 }
 
@@ -63,12 +97,18 @@ func (t *Translator) ToSpannerUpdate(query string) (*DeleteUpdateQueryMap, error
 	stream := antlr.NewCommonTokenStream(lexer, antlr.TokenDefaultChannel)
 	p := parser.NewPartiQLParser(stream)
 
-	updateListener := &UpdateQueryListener{}
-	antlr.ParseTreeWalkerDefault.Walk(updateListener, p.Root())
+	root := p.Root()
+	updateListener := &UpdateQueryListener{placeholders: placeholderIndexes(root)}
+	antlr.ParseTreeWalkerDefault.Walk(updateListener, root)
+	if updateListener.err != nil {
+		return nil, updateListener.err
+	}
 
-	updateQueryMap.Table = updateListener.Table
+	updateQueryMap.Table = t.table(updateListener.Table)
 	updateQueryMap.PartiQLQuery = query
 	updateQueryMap.QueryType = "UPDATE"
+	updateQueryMap.Actions = updateListener.Actions
+	updateQueryMap.Returning = updateListener.Returning
 	for _, clause := range updateListener.SetClauses {
 		updateQueryMap.UpdateSetValues = append(updateQueryMap.UpdateSetValues, UpdateSetValue{
 			Column:   clause.Column,
@@ -85,12 +125,31 @@ func (t *Translator) ToSpannerUpdate(query string) (*DeleteUpdateQueryMap, error
 			})
 		}
 	}
+	// the SET values are parameters named after their column
+	var setColumns []string
+	for _, clause := range updateListener.SetClauses {
+		setColumns = append(setColumns, clause.Column)
+	}
+	var err error
+	updateQueryMap.WhereClause, updateQueryMap.WhereTerms, updateQueryMap.WhereParams, err = t.translateWhere(root, updateQueryMap.Table, setColumns...)
+	if err != nil {
+		return nil, err
+	}
 	updateQueryMap.SpannerQuery = formSpannerUpdateQuery(updateQueryMap)
 	return updateQueryMap, nil
 }
 
 func formSpannerUpdateQuery(updateQueryMap *DeleteUpdateQueryMap) string {
-	return "UPDATE " + updateQueryMap.Table + buildSetValues(updateQueryMap.UpdateSetValues) + buildWhereClause(updateQueryMap.Clauses) + ";"
+	return "UPDATE " + updateQueryMap.Table + buildSetValues(updateQueryMap.UpdateSetValues) + whereClause(updateQueryMap) + ";"
+}
+
+// whereClause returns the translated WHERE clause of the statement, or the
+// one built from its clauses
+func whereClause(queryMap *DeleteUpdateQueryMap) string {
+	if queryMap.WhereClause != "" {
+		return " WHERE " + queryMap.WhereClause
+	}
+	return buildWhereClause(queryMap.Clauses)
 }
 
 func buildSetValues(updateSetValues []UpdateSetValue) string {
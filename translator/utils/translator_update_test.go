@@ -112,3 +112,49 @@ func TestFormSpannerUpdateQuery(t *testing.T) {
 		})
 	}
 }
+
+func TestToSpannerUpdateActions(t *testing.T) {
+	update, err := (&Translator{}).ToSpannerUpdate("UPDATE orders SET info.x = {'y': <<1, 2>>, 'z': ?}, tags = list_append(tags, [?]) REMOVE info.a[1] WHERE id = ?")
+	assert.NoError(t, err)
+	assert.Equal(t, []UpdateAction{
+		{Path: "info.x", Column: "info", Value: &Value{Kind: "M", Fields: map[string]*Value{
+			"y": {Kind: "BAG", Elements: []*Value{{Kind: "N", Literal: "1"}, {Kind: "N", Literal: "2"}}},
+			"z": {Kind: "PARAM", Placeholder: 0},
+		}}},
+		{Path: "tags", Column: "tags", Value: &Value{Kind: "LIST_APPEND", Elements: []*Value{
+			{Kind: "PATH", Path: "tags"},
+			{Kind: "L", Elements: []*Value{{Kind: "PARAM", Placeholder: 1}}},
+		}}},
+		{Path: "info.a[1]", Column: "info", Remove: true},
+	}, update.Actions)
+	assert.Equal(t, 2, update.WhereParams[0].Placeholder)
+
+	// expressions of columns are left to Spanner DML
+	update, err = (&Translator{}).ToSpannerUpdate("UPDATE orders SET total = total + 1 WHERE id = 1")
+	assert.NoError(t, err)
+	assert.Equal(t, []UpdateAction{{Path: "total", Column: "total"}}, update.Actions)
+
+	_, err = (&Translator{}).ToSpannerUpdate("UPDATE orders SET info.total = total + 1 WHERE id = 1")
+	assert.Error(t, err)
+}
+
+func TestToSpannerUpdateReturning(t *testing.T) {
+	update, err := (&Translator{}).ToSpannerUpdate("UPDATE orders SET status = 'paid' WHERE customer = 'alice' AND id = 1 RETURNING MODIFIED NEW *")
+	assert.NoError(t, err)
+	assert.Equal(t, "MODIFIED NEW", update.Returning)
+	assert.Len(t, update.WhereTerms, 2)
+	assert.Equal(t, "customer", update.WhereTerms[0].Column)
+	assert.Equal(t, "=", update.WhereTerms[0].Operator)
+
+	update, err = (&Translator{}).ToSpannerUpdate("UPDATE orders SET status = 'paid' WHERE id = 1")
+	assert.NoError(t, err)
+	assert.Empty(t, update.Returning)
+
+	for _, query := range []string{
+		"UPDATE orders SET status = 'paid' WHERE id = 1 RETURNING ALL OLD status",
+		"UPDATE orders SET status = 'paid' WHERE id = 1 RETURNING ALL OLD *, ALL NEW *",
+	} {
+		_, err = (&Translator{}).ToSpannerUpdate(query)
+		assert.Error(t, err, query)
+	}
+}
@@ -0,0 +1,635 @@
+package translator
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/antlr4-go/antlr/v4"
+	"github.com/cloudspannerecosystem/dynamodb-adapter/third_party/amazon_apache/translator/PartiQLParser/parser"
+)
+
+// nonWordPattern matches the characters a query parameter name cannot have
+var nonWordPattern = regexp.MustCompile(`\W`)
+
+// whereTranslator translates the condition of a WHERE clause to Spanner SQL.
+// Every value of the condition becomes a query parameter, so literals and ?
+// placeholders are bound the same way.
+type whereTranslator struct {
+	table string
+	// placeholders holds the index of every ? of the statement
+	placeholders  map[*parser.ParameterContext]int
+	columnType    func(table, column string) string
+	parameterType func(index int) string
+	params        []WhereParam
+	names         map[string]int
+	// paths holds the parameter names of nested attributes
+	paths map[string]bool
+}
+
+// translateWhere returns the Spanner condition of the WHERE clause of the
+// statement parsed into root, the conditions it ANDs together and its query
+// parameters, whose names differ from the reserved ones. The condition is
+// empty when the statement has no WHERE clause.
+func (t *Translator) translateWhere(root antlr.Tree, table string, reserved ...string) (string, []WhereTerm, []WhereParam, error) {
+	w := &whereTranslator{
+		table:         strings.Trim(table, `"`),
+		placeholders:  placeholderIndexes(root),
+		columnType:    t.ColumnType,
+		parameterType: t.ParameterType,
+		names:         map[string]int{},
+		paths:         map[string]bool{},
+	}
+	for _, name := range reserved {
+		w.names[name] = 1
+	}
+	var where antlr.Tree
+	var walk func(tree antlr.Tree)
+	walk = func(tree antlr.Tree) {
+		switch ctx := tree.(type) {
+		case *parser.WhereClauseSelectContext:
+			if where == nil {
+				where = ctx.ExprSelect()
+			}
+		case *parser.WhereClauseContext:
+			if where == nil {
+				where = ctx.Expr()
+			}
+		}
+		for _, child := range tree.GetChildren() {
+			walk(child)
+		}
+	}
+	walk(root)
+	if where == nil {
+		return "", nil, nil, nil
+	}
+	terms, err := w.terms(where)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	conditions := make([]string, len(terms))
+	for i, term := range terms {
+		conditions[i] = term.Condition
+	}
+	return strings.Join(conditions, " AND "), terms, w.params, nil
+}
+
+// terms translates the conditions of a WHERE clause that are ANDed together
+func (w *whereTranslator) terms(tree antlr.Tree) ([]WhereTerm, error) {
+	var term WhereTerm
+	var err error
+	switch ctx := tree.(type) {
+	case *parser.AndContext:
+		left, err := w.terms(ctx.ExprAnd())
+		if err != nil {
+			return nil, err
+		}
+		right, err := w.terms(ctx.ExprNot())
+		if err != nil {
+			return nil, err
+		}
+		return append(left, right...), nil
+	case *parser.PredicateComparisonContext:
+		term.Condition, err = w.comparison(ctx)
+		lhs, rhs := columnOf(ctx.GetLhs()), columnOf(ctx.GetRhs())
+		switch op := ctx.GetOp().GetText(); {
+		case lhs != "" && rhs == "":
+			term.Column, term.Operator = lhs, op
+		case lhs == "" && rhs != "":
+			term.Column, term.Operator = rhs, flippedOperators[op]
+		}
+	case *parser.PredicateInContext:
+		term.Condition, err = w.in(ctx)
+		if ctx.NOT() == nil {
+			term.Column, term.Operator = columnOf(ctx.GetLhs()), "IN"
+		}
+	case *parser.PredicateBetweenContext:
+		term.Condition, err = w.between(ctx)
+		if ctx.NOT() == nil {
+			term.Column, term.Operator = columnOf(ctx.GetLhs()), "BETWEEN"
+		}
+	case *parser.FunctionCallContext:
+		term.Condition, err = w.function(ctx)
+		if args := ctx.AllExpr(); err == nil && strings.EqualFold(ctx.FunctionName().GetText(), "begins_with") {
+			term.Column, term.Operator = columnOf(args[0]), "BEGINS_WITH"
+		}
+	case *parser.OrContext, *parser.NotContext, *parser.ExprTermWrappedQueryContext, *parser.PredicateIsContext,
+		*parser.LiteralTrueContext, *parser.LiteralFalseContext:
+		term.Condition, err = w.condition(ctx)
+	default:
+		if child := onlyChild(tree); child != nil {
+			return w.terms(child)
+		}
+		term.Condition, err = w.condition(tree)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if term.Column == "" {
+		term.Operator = ""
+	}
+	return []WhereTerm{term}, nil
+}
+
+// flippedOperators are the comparisons with their operands swapped
+var flippedOperators = map[string]string{
+	"=": "=", "<>": "<>", "!=": "!=", "<": ">", "<=": ">=", ">": "<", ">=": "<=",
+}
+
+// condition translates a boolean expression
+func (w *whereTranslator) condition(tree antlr.Tree) (string, error) {
+	switch ctx := tree.(type) {
+	case *parser.OrContext:
+		return w.binary(ctx.ExprOr(), "OR", ctx.ExprAnd())
+	case *parser.AndContext:
+		return w.binary(ctx.ExprAnd(), "AND", ctx.ExprNot())
+	case *parser.NotContext:
+		operand, err := w.condition(ctx.ExprNot())
+		if err != nil {
+			return "", err
+		}
+		return "NOT " + operand, nil
+	case *parser.ExprTermWrappedQueryContext:
+		inner, err := w.condition(ctx.Expr())
+		if err != nil {
+			return "", err
+		}
+		return "(" + inner + ")", nil
+	case *parser.PredicateComparisonContext:
+		return w.comparison(ctx)
+	case *parser.PredicateInContext:
+		return w.in(ctx)
+	case *parser.PredicateBetweenContext:
+		return w.between(ctx)
+	case *parser.PredicateIsContext:
+		return w.is(ctx)
+	case *parser.FunctionCallContext:
+		return w.function(ctx)
+	case *parser.LiteralTrueContext:
+		return "TRUE", nil
+	case *parser.LiteralFalseContext:
+		return "FALSE", nil
+	}
+	if child := onlyChild(tree); child != nil {
+		return w.condition(child)
+	}
+	return "", fmt.Errorf("unsupported condition: %s", treeText(tree))
+}
+
+func (w *whereTranslator) binary(lhs antlr.Tree, op string, rhs antlr.Tree) (string, error) {
+	left, err := w.condition(lhs)
+	if err != nil {
+		return "", err
+	}
+	right, err := w.condition(rhs)
+	if err != nil {
+		return "", err
+	}
+	return left + " " + op + " " + right, nil
+}
+
+func (w *whereTranslator) comparison(ctx *parser.PredicateComparisonContext) (string, error) {
+	op := ctx.GetOp().GetText()
+	if op == "<>" {
+		op = "!="
+	}
+	column, typ := w.subject(ctx.GetLhs(), ctx.GetRhs())
+	left, err := w.typedOperand(ctx.GetLhs(), column, typ)
+	if err != nil {
+		return "", err
+	}
+	right, err := w.typedOperand(ctx.GetRhs(), column, typ)
+	if err != nil {
+		return "", err
+	}
+	return left + " " + op + " " + right, nil
+}
+
+// in translates IN lists, written in parentheses or brackets
+func (w *whereTranslator) in(ctx *parser.PredicateInContext) (string, error) {
+	var list antlr.Tree = ctx.GetRhs()
+	if list == nil {
+		list = ctx.Expr()
+	}
+	items := listItems(list)
+	column, typ := w.subject(append([]antlr.Tree{ctx.GetLhs()}, items...)...)
+	left, err := w.typedOperand(ctx.GetLhs(), column, typ)
+	if err != nil {
+		return "", err
+	}
+	values := make([]string, len(items))
+	for i, item := range items {
+		if values[i], err = w.typedOperand(item, column, typ); err != nil {
+			return "", err
+		}
+	}
+	condition := left + " IN (" + strings.Join(values, ", ") + ")"
+	if ctx.NOT() != nil {
+		condition = "NOT " + condition
+	}
+	return condition, nil
+}
+
+func (w *whereTranslator) between(ctx *parser.PredicateBetweenContext) (string, error) {
+	trees := []antlr.Tree{ctx.GetLhs(), ctx.GetLower(), ctx.GetUpper()}
+	column, typ := w.subject(trees...)
+	var parts [3]string
+	for i, tree := range trees {
+		var err error
+		if parts[i], err = w.typedOperand(tree, column, typ); err != nil {
+			return "", err
+		}
+	}
+	condition := parts[0] + " BETWEEN " + parts[1] + " AND " + parts[2]
+	if ctx.NOT() != nil {
+		condition = "NOT " + condition
+	}
+	return condition, nil
+}
+
+// is translates IS MISSING and IS NULL. Attributes of a row that are not set
+// are NULL columns, so both are checked with IS NULL, and nested attributes
+// that are not set have no JSON.
+func (w *whereTranslator) is(ctx *parser.PredicateIsContext) (string, error) {
+	switch strings.ToUpper(ctx.Type_().GetText()) {
+	case "MISSING", "NULL":
+	default:
+		return "", fmt.Errorf("unsupported condition: %s", treeText(ctx))
+	}
+	var operand string
+	path, ok, err := w.path(ctx.GetLhs())
+	if ok && err == nil {
+		operand = jsonQuery(path, "")
+	} else if !ok {
+		operand, err = w.operand(ctx.GetLhs(), "")
+	}
+	if err != nil {
+		return "", err
+	}
+	if ctx.NOT() != nil {
+		return operand + " IS NOT NULL", nil
+	}
+	return operand + " IS NULL", nil
+}
+
+// function translates the condition functions of DynamoDB PartiQL
+func (w *whereTranslator) function(ctx *parser.FunctionCallContext) (string, error) {
+	name := strings.ToLower(ctx.FunctionName().GetText())
+	args := ctx.AllExpr()
+	if len(args) != 2 {
+		return "", fmt.Errorf("unsupported condition: %s", treeText(ctx))
+	}
+	if path, ok, err := w.path(args[0]); ok {
+		if err != nil {
+			return "", err
+		}
+		return w.pathFunction(ctx, name, path)
+	}
+	column := columnOf(args[0])
+	if column == "" {
+		return "", fmt.Errorf("the first argument of %s must be an attribute: %s", name, treeText(ctx))
+	}
+	path, err := w.operand(args[0], column)
+	if err != nil {
+		return "", err
+	}
+	switch name {
+	case "begins_with":
+		value, err := w.operand(args[1], column)
+		if err != nil {
+			return "", err
+		}
+		return "STARTS_WITH(" + path + ", " + value + ")", nil
+	case "contains":
+		value, err := w.operand(args[1], column)
+		if err != nil {
+			return "", err
+		}
+		switch w.typeOf(column) {
+		case "SS", "NS", "BS":
+			return value + " IN UNNEST(" + path + ")", nil
+		}
+		return "STRPOS(" + path + ", " + value + ") > 0", nil
+	case "attribute_type":
+		value, ok := stringLiteral(args[1])
+		if !ok {
+			return "", fmt.Errorf("the type of attribute_type must be a string literal: %s", treeText(ctx))
+		}
+		// the type of a column is fixed, so only set columns have it
+		if w.typeOf(column) == value {
+			return path + " IS NOT NULL", nil
+		}
+		return "FALSE", nil
+	}
+	return "", fmt.Errorf("unsupported function: %s", name)
+}
+
+// documentTypes are the DynamoDB types of nested attributes
+var documentTypes = []string{"S", "N", "B", "BOOL", "NULL", "M", "L", "SS", "NS", "BS"}
+
+// pathFunction translates the condition functions of nested attributes.
+// begins_with reads strings, attribute_type checks the type the attribute is
+// wrapped in.
+func (w *whereTranslator) pathFunction(ctx *parser.FunctionCallContext, name string, path attributePath) (string, error) {
+	args := ctx.AllExpr()
+	switch name {
+	case "begins_with":
+		w.paths[path.name()] = true
+		value, err := w.operand(args[1], path.name())
+		if err != nil {
+			return "", err
+		}
+		return "STARTS_WITH(" + pathValue(path, "S") + ", " + value + ")", nil
+	case "attribute_type":
+		value, ok := stringLiteral(args[1])
+		if !ok {
+			return "", fmt.Errorf("the type of attribute_type must be a string literal: %s", treeText(ctx))
+		}
+		if !slices.Contains(documentTypes, value) {
+			return "", fmt.Errorf("invalid attribute type: %s", value)
+		}
+		return jsonQuery(path, value) + " IS NOT NULL", nil
+	}
+	return "", fmt.Errorf("%s is not supported for nested attributes: %s", name, treeText(ctx))
+}
+
+// subject returns the name of the query parameters of the values a
+// condition compares, after the column or the nested attribute they are
+// compared with, and the type nested attributes are read as, the type of the
+// first value that has one
+func (w *whereTranslator) subject(operands ...antlr.Tree) (string, string) {
+	var name, typ string
+	for _, tree := range operands {
+		if name = columnOf(tree); name != "" {
+			break
+		}
+	}
+	for _, tree := range operands {
+		if path, ok, err := exprPath(tree); name == "" && ok && err == nil {
+			name = path.name()
+			w.paths[name] = true
+		}
+		if typ == "" {
+			typ = w.valueType(tree)
+		}
+	}
+	return name, typ
+}
+
+// valueType returns the DynamoDB type of a value, empty when it is not known
+func (w *whereTranslator) valueType(tree antlr.Tree) string {
+	for ; tree != nil; tree = onlyChild(tree) {
+		switch ctx := tree.(type) {
+		case *parser.LiteralStringContext:
+			return "S"
+		case *parser.LiteralIntegerContext, *parser.LiteralDecimalContext:
+			return "N"
+		case *parser.LiteralTrueContext, *parser.LiteralFalseContext:
+			return "BOOL"
+		case *parser.ValueExprContext:
+			if ctx.GetSign() != nil {
+				return "N"
+			}
+		case *parser.ParameterContext:
+			if w.parameterType == nil {
+				return ""
+			}
+			return w.parameterType(w.placeholders[ctx])
+		case *parser.VariableIdentifierContext:
+			return w.typeOf(identifier(ctx))
+		}
+	}
+	return ""
+}
+
+// typedOperand translates a value of a condition, reading nested attributes
+// as values of type typ
+func (w *whereTranslator) typedOperand(tree antlr.Tree, column, typ string) (string, error) {
+	path, ok, err := w.path(tree)
+	if !ok {
+		return w.operand(tree, column)
+	}
+	if err != nil {
+		return "", err
+	}
+	switch typ {
+	case "S", "N", "BOOL":
+		return pathValue(path, typ), nil
+	case "":
+		return "", fmt.Errorf("nested attributes must be compared with a literal, a parameter or an attribute: %s", treeText(tree))
+	}
+	return "", fmt.Errorf("nested attributes can only be compared with strings, numbers and booleans: %s", treeText(tree))
+}
+
+// pathValue reads the nested attribute as a value of a DynamoDB type, NULL
+// when it is not set or has another type
+func pathValue(path attributePath, typ string) string {
+	switch typ {
+	case "N":
+		return "SAFE_CAST(JSON_VALUE(`" + path.column + "`, '" + path.jsonPath() + ".N') AS NUMERIC)"
+	case "BOOL":
+		return "BOOL(" + jsonQuery(path, "BOOL") + ")"
+	}
+	return "JSON_VALUE(`" + path.column + "`, '" + path.jsonPath() + "." + typ + "')"
+}
+
+// jsonQuery returns the JSON of the nested attribute, or of its value of
+// type typ when typ is set
+func jsonQuery(path attributePath, typ string) string {
+	jsonPath := path.jsonPath()
+	if typ != "" {
+		jsonPath += "." + typ
+	}
+	return "JSON_QUERY(`" + path.column + "`, '" + jsonPath + "')"
+}
+
+// operand translates a value of a condition. column is the column the value
+// is compared with, the query parameters of values are named after it.
+func (w *whereTranslator) operand(tree antlr.Tree, column string) (string, error) {
+	switch ctx := tree.(type) {
+	case *parser.VariableIdentifierContext:
+		return w.column(identifier(ctx))
+	case *parser.ExprPrimaryPathContext:
+		return "", fmt.Errorf("nested attributes must be compared with a value: %s", treeText(ctx))
+	case *parser.ParameterContext:
+		return w.param(column, WhereParam{Placeholder: w.placeholders[ctx]}), nil
+	case *parser.LiteralStringContext:
+		value, _ := stringLiteral(ctx)
+		return w.param(column, WhereParam{Placeholder: -1, Type: "S", Literal: value}), nil
+	case *parser.LiteralIntegerContext, *parser.LiteralDecimalContext:
+		return w.param(column, WhereParam{Placeholder: -1, Type: "N", Literal: treeText(ctx)}), nil
+	case *parser.LiteralTrueContext, *parser.LiteralFalseContext:
+		return w.param(column, WhereParam{Placeholder: -1, Type: "BOOL", Literal: strings.ToLower(treeText(ctx))}), nil
+	case *parser.LiteralNullContext:
+		return "NULL", nil
+	case *parser.ValueExprContext:
+		if sign := ctx.GetSign(); sign != nil {
+			number := treeText(ctx)
+			if _, err := strconv.ParseFloat(number, 64); err != nil {
+				return "", fmt.Errorf("unsupported value: %s", number)
+			}
+			return w.param(column, WhereParam{Placeholder: -1, Type: "N", Literal: number}), nil
+		}
+	case *parser.ExprTermWrappedQueryContext:
+		inner, err := w.operand(ctx.Expr(), column)
+		if err != nil {
+			return "", err
+		}
+		return "(" + inner + ")", nil
+	case *parser.FunctionCallContext:
+		return w.size(ctx)
+	}
+	if child := onlyChild(tree); child != nil {
+		return w.operand(child, column)
+	}
+	return "", fmt.Errorf("unsupported value: %s", treeText(tree))
+}
+
+// size translates the size function, the length of strings and binaries and
+// the number of elements of sets
+func (w *whereTranslator) size(ctx *parser.FunctionCallContext) (string, error) {
+	args := ctx.AllExpr()
+	if strings.ToLower(ctx.FunctionName().GetText()) != "size" || len(args) != 1 {
+		return "", fmt.Errorf("unsupported function: %s", treeText(ctx))
+	}
+	column := columnOf(args[0])
+	if column == "" {
+		return "", fmt.Errorf("the argument of size must be an attribute: %s", treeText(ctx))
+	}
+	path, err := w.column(column)
+	if err != nil {
+		return "", err
+	}
+	switch w.typeOf(column) {
+	case "S", "":
+		return "CHAR_LENGTH(" + path + ")", nil
+	case "B":
+		return "BYTE_LENGTH(" + path + ")", nil
+	case "SS", "NS", "BS":
+		return "ARRAY_LENGTH(" + path + ")", nil
+	}
+	return "", fmt.Errorf("size is not supported for attribute %s", column)
+}
+
+// param adds a query parameter and returns its reference
+func (w *whereTranslator) param(column string, param WhereParam) string {
+	name := "param"
+	if column != "" {
+		name = nonWordPattern.ReplaceAllString(column, "_")
+	}
+	if n := w.names[name]; n > 0 {
+		w.names[name]++
+		name += "_" + strconv.Itoa(n)
+	} else {
+		w.names[name] = 1
+	}
+	param.Name = name
+	if !w.paths[column] {
+		param.Column = column
+	}
+	w.params = append(w.params, param)
+	return "@" + name
+}
+
+// column returns the quoted name of a column of the table. Names with a
+// backtick cannot be quoted, and when the column types are known only the
+// columns of the table are accepted, so that identifiers never reach the
+// condition unchecked.
+func (w *whereTranslator) column(name string) (string, error) {
+	if name == "" || strings.Contains(name, "`") || (w.columnType != nil && w.typeOf(name) == "") {
+		return "", fmt.Errorf("unknown attribute: %s", name)
+	}
+	return "`" + name + "`", nil
+}
+
+// path returns the nested attribute a value refers to, see exprPath, after
+// checking its column
+func (w *whereTranslator) path(tree antlr.Tree) (attributePath, bool, error) {
+	path, ok, err := exprPath(tree)
+	if ok && err == nil {
+		_, err = w.column(path.column)
+	}
+	return path, ok, err
+}
+
+func (w *whereTranslator) typeOf(column string) string {
+	if w.columnType == nil {
+		return ""
+	}
+	return w.columnType(w.table, column)
+}
+
+// columnOf returns the column a value refers to, empty when it is not a
+// column
+func columnOf(tree antlr.Tree) string {
+	for ; tree != nil; tree = onlyChild(tree) {
+		if ctx, ok := tree.(*parser.VariableIdentifierContext); ok {
+			return identifier(ctx)
+		}
+	}
+	return ""
+}
+
+// identifier returns the name of a column, unquoted
+func identifier(ctx *parser.VariableIdentifierContext) string {
+	name := ctx.GetIdent().GetText()
+	if ctx.IDENTIFIER_QUOTED() != nil {
+		name = strings.ReplaceAll(name[1:len(name)-1], `""`, `"`)
+	}
+	return name
+}
+
+// stringLiteral returns the value of a string literal
+func stringLiteral(tree antlr.Tree) (string, bool) {
+	for ; tree != nil; tree = onlyChild(tree) {
+		if ctx, ok := tree.(*parser.LiteralStringContext); ok {
+			text := ctx.GetText()
+			return strings.ReplaceAll(text[1:len(text)-1], "''", "'"), true
+		}
+	}
+	return "", false
+}
+
+// onlyChild returns the child of a rule with one child that is a rule, the
+// rules that only wrap another one
+func onlyChild(tree antlr.Tree) antlr.Tree {
+	if tree.GetChildCount() != 1 {
+		return nil
+	}
+	if child, ok := tree.GetChild(0).(antlr.ParserRuleContext); ok {
+		return child
+	}
+	return nil
+}
+
+// listItems returns the values of a list in parentheses or brackets, or the
+// value itself when it is not a list
+func listItems(tree antlr.Tree) []antlr.Tree {
+	var exprs []parser.IExprContext
+	for node := tree; node != nil && exprs == nil; node = onlyChild(node) {
+		switch values := node.(type) {
+		case *parser.ValueListContext:
+			exprs = values.AllExpr()
+		case *parser.ArrayContext:
+			exprs = values.AllExpr()
+		}
+	}
+	if exprs == nil {
+		return []antlr.Tree{tree}
+	}
+	items := make([]antlr.Tree, len(exprs))
+	for i, expr := range exprs {
+		items[i] = expr
+	}
+	return items
+}
+
+func treeText(tree antlr.Tree) string {
+	if ctx, ok := tree.(antlr.ParseTree); ok {
+		return ctx.GetText()
+	}
+	return ""
+}
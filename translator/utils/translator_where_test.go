@@ -0,0 +1,121 @@
+package translator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTranslateWhere(t *testing.T) {
+	translator := Translator{ColumnType: func(table, column string) string {
+		return map[string]string{"customer": "S", "status": "S", "id": "S", "shipped": "S", "a-b": "N", "tags": "SS", "note": "S", "total": "N"}[column]
+	}}
+
+	query := `SELECT * FROM orders WHERE customer = ? AND (status IN ['open', 'paid'] OR NOT total BETWEEN 10 AND -2.5) ` +
+		`AND begins_with(id, 'A') AND contains(tags, ?) AND contains(note, 'x') AND size(note) > 3 ` +
+		`AND attribute_type(total, 'N') AND attribute_type(note, 'N') AND shipped IS NOT MISSING AND "a-b" <> 1`
+	response, err := translator.ToSpannerSelect(query)
+	assert.NoError(t, err)
+	assert.Equal(t, "`customer` = @customer AND (`status` IN (@status, @status_1) OR NOT `total` BETWEEN @total AND @total_1) "+
+		"AND STARTS_WITH(`id`, @id) AND @tags IN UNNEST(`tags`) AND STRPOS(`note`, @note) > 0 AND CHAR_LENGTH(`note`) > @param "+
+		"AND `total` IS NOT NULL AND FALSE AND `shipped` IS NOT NULL AND `a-b` != @a_b", response.WhereClause)
+	assert.Equal(t, []WhereParam{
+		{Name: "customer", Column: "customer", Placeholder: 0},
+		{Name: "status", Column: "status", Placeholder: -1, Type: "S", Literal: "open"},
+		{Name: "status_1", Column: "status", Placeholder: -1, Type: "S", Literal: "paid"},
+		{Name: "total", Column: "total", Placeholder: -1, Type: "N", Literal: "10"},
+		{Name: "total_1", Column: "total", Placeholder: -1, Type: "N", Literal: "-2.5"},
+		{Name: "id", Column: "id", Placeholder: -1, Type: "S", Literal: "A"},
+		{Name: "tags", Column: "tags", Placeholder: 1},
+		{Name: "note", Column: "note", Placeholder: -1, Type: "S", Literal: "x"},
+		{Name: "param", Placeholder: -1, Type: "N", Literal: "3"},
+		{Name: "a_b", Column: "a-b", Placeholder: -1, Type: "N", Literal: "1"},
+	}, response.WhereParams)
+
+	// the parameters of the SET clause keep the names of their columns
+	update, err := translator.ToSpannerUpdate("UPDATE orders SET status = ? WHERE customer = ? AND status = 'open'")
+	assert.NoError(t, err)
+	assert.Equal(t, "`customer` = @customer AND `status` = @status_1", update.WhereClause)
+	assert.Equal(t, 1, update.WhereParams[0].Placeholder)
+
+	for _, query := range []string{
+		"SELECT * FROM orders WHERE info.note = other.note",
+		"SELECT * FROM orders WHERE contains(info.tags, 'x')",
+		"SELECT * FROM orders WHERE exists(customer, 'x')",
+		"SELECT * FROM orders WHERE attribute_type(total, ?)",
+	} {
+		_, err := translator.ToSpannerSelect(query)
+		assert.Error(t, err, query)
+	}
+}
+
+func TestTranslateWherePaths(t *testing.T) {
+	translator := Translator{ParameterType: func(index int) string { return "N" }}
+
+	query := `SELECT id, info.tags[0], info."a-b" AS ab, info.items[1].qty FROM orders WHERE info.status = 'open' ` +
+		`AND info.items[2].qty > ? AND info.flag = true AND info.note IS MISSING AND begins_with(info.name, 'A') ` +
+		`AND attribute_type(info.tags, 'SS') AND info['b'].c IN [1, 2]`
+	response, err := translator.ToSpannerSelect(query)
+	assert.NoError(t, err)
+	assert.Equal(t, "JSON_VALUE(`info`, '$.M.status.S') = @info_status "+
+		"AND SAFE_CAST(JSON_VALUE(`info`, '$.M.items.L[2].M.qty.N') AS NUMERIC) > @info_items_2_qty "+
+		"AND BOOL(JSON_QUERY(`info`, '$.M.flag.BOOL')) = @info_flag AND JSON_QUERY(`info`, '$.M.note') IS NULL "+
+		"AND STARTS_WITH(JSON_VALUE(`info`, '$.M.name.S'), @info_name) AND JSON_QUERY(`info`, '$.M.tags.SS') IS NOT NULL "+
+		"AND SAFE_CAST(JSON_VALUE(`info`, '$.M.b.M.c.N') AS NUMERIC) IN (@info_b_c, @info_b_c_1)", response.WhereClause)
+	assert.Equal(t, []WhereParam{
+		{Name: "info_status", Placeholder: -1, Type: "S", Literal: "open"},
+		{Name: "info_items_2_qty", Placeholder: 0},
+		{Name: "info_flag", Placeholder: -1, Type: "BOOL", Literal: "true"},
+		{Name: "info_name", Placeholder: -1, Type: "S", Literal: "A"},
+		{Name: "info_b_c", Placeholder: -1, Type: "N", Literal: "1"},
+		{Name: "info_b_c_1", Placeholder: -1, Type: "N", Literal: "2"},
+	}, response.WhereParams)
+	assert.Equal(t, []string{"id"}, response.ProjectionColumns)
+	assert.Equal(t, []ProjectionPath{
+		{Name: "_2", Column: "info", Path: "info.tags[0]"},
+		{Name: "ab", Column: "info", Path: "info.a-b"},
+		{Name: "qty", Column: "info", Path: "info.items[1].qty"},
+	}, response.ProjectionPaths)
+	assert.True(t, strings.HasPrefix(response.SpannerQuery, "SELECT id, `info` FROM orders WHERE "))
+}
+
+func TestTranslateWhereTerms(t *testing.T) {
+	response, err := (&Translator{}).ToSpannerSelect(`SELECT * FROM orders WHERE customer IN [?, ?] AND 5 > id AND (status = 'a' OR status = 'b') AND begins_with(note, 'x') ORDER BY id DESC`)
+	assert.NoError(t, err)
+	assert.Equal(t, []WhereTerm{
+		{Condition: "`customer` IN (@customer, @customer_1)", Column: "customer", Operator: "IN"},
+		{Condition: "@id > `id`", Column: "id", Operator: "<"},
+		{Condition: "(`status` = @status OR `status` = @status_1)"},
+		{Condition: "STARTS_WITH(`note`, @note)", Column: "note", Operator: "BEGINS_WITH"},
+	}, response.WhereTerms)
+	assert.Equal(t, []SortColumn{{Column: "id", Desc: true}}, response.Sort)
+}
+
+func TestTranslateWhereIdentifiers(t *testing.T) {
+	translator := Translator{ColumnType: func(table, column string) string {
+		return map[string]string{"id": "S", "note": "S", "info": "M"}[column]
+	}}
+	for _, query := range []string{
+		"DELETE FROM orders WHERE \"id`` IS NOT NULL OR ``id\" = 'a'",
+		"SELECT * FROM orders WHERE \"id` OR TRUE OR `id\" = 'a'",
+		"SELECT * FROM orders WHERE size(\"note`) > 0 OR (`note\") > 0",
+		"SELECT * FROM orders WHERE \"info`\".a = 1",
+		"SELECT * FROM orders WHERE missing = 'a'",
+	} {
+		var err error
+		if strings.HasPrefix(query, "DELETE") {
+			_, err = translator.ToSpannerDelete(query)
+		} else {
+			_, err = translator.ToSpannerSelect(query)
+		}
+		assert.Error(t, err, query)
+	}
+
+	// without column types only the names that cannot be quoted are rejected
+	_, err := (&Translator{}).ToSpannerDelete("DELETE FROM orders WHERE \"id`` IS NOT NULL OR ``id\" = 'a'")
+	assert.Error(t, err)
+	response, err := (&Translator{}).ToSpannerSelect(`SELECT * FROM orders WHERE "a b" = 'a'`)
+	assert.NoError(t, err)
+	assert.Equal(t, "`a b` = @a_b", response.WhereClause)
+}
@@ -2,6 +2,7 @@ package translator
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/antlr4-go/antlr/v4"
@@ -32,6 +33,37 @@ func NewPartiQLParser(partiQL string, isDebug bool) (*parser.PartiQLParser, erro
 	return p, nil
 }
 
+// StatementTable returns the table a PartiQL statement reads or writes: the
+// first name after its FROM, INTO or UPDATE keyword, without the index of a
+// "table"."index" path. It works on the tokens of the statement, so that
+// string literals and quoted names are never taken for keywords.
+func StatementTable(statement string) (string, error) {
+	lexer := parser.NewPartiQLLexer(antlr.NewInputStream(statement))
+	lexer.RemoveErrorListeners()
+	afterKeyword := false
+	for token := lexer.NextToken(); token.GetTokenType() != antlr.TokenEOF; token = lexer.NextToken() {
+		if token.GetChannel() != antlr.TokenDefaultChannel {
+			continue
+		}
+		switch token.GetTokenType() {
+		case parser.PartiQLLexerFROM, parser.PartiQLLexerINTO, parser.PartiQLLexerUPDATE:
+			afterKeyword = true
+			continue
+		case parser.PartiQLLexerIDENTIFIER:
+			if afterKeyword {
+				return token.GetText(), nil
+			}
+		case parser.PartiQLLexerIDENTIFIER_QUOTED:
+			if afterKeyword {
+				text := token.GetText()
+				return strings.ReplaceAll(text[1:len(text)-1], `""`, `"`), nil
+			}
+		}
+		afterKeyword = false
+	}
+	return "", fmt.Errorf("statement names no table")
+}
+
 func trimSingleQuotes(s string) string {
 	// Check if the string starts and ends with single quotes
 	if strings.HasPrefix(s, "'") && strings.HasSuffix(s, "'") {
@@ -44,17 +76,33 @@ func trimSingleQuotes(s string) string {
 func formSpannerSelectQuery(selectQueryMap *SelectQueryMap, whereConditions []Condition) (string, error) {
 	spannerQuery := "SELECT "
 
-	// Construct projection columns or use * if empty
-	if len(selectQueryMap.ProjectionColumns) == 0 {
+	// Construct projection columns or use * if empty. Nested attributes are
+	// read from their columns.
+	columns := slices.Clone(selectQueryMap.ProjectionColumns)
+	for _, path := range selectQueryMap.ProjectionPaths {
+		column := "`" + path.Column + "`"
+		if !slices.Contains(columns, path.Column) && !slices.Contains(columns, column) {
+			columns = append(columns, column)
+		}
+	}
+	for _, aggregate := range selectQueryMap.Aggregates {
+		columns = append(columns, aggregate.SQL()+" AS `"+aggregate.Name+"`")
+	}
+	if len(columns) == 0 {
 		spannerQuery += "* "
 	} else {
-		spannerQuery += strings.Join(selectQueryMap.ProjectionColumns, ", ") + " "
+		spannerQuery += strings.Join(columns, ", ") + " "
 	}
 
 	spannerQuery += "FROM " + selectQueryMap.Table
+	if selectQueryMap.Index != "" {
+		spannerQuery += "@{FORCE_INDEX=" + strings.ReplaceAll(selectQueryMap.Index, "-", "_") + "}"
+	}
 
 	// Construct WHERE clause
-	if len(whereConditions) > 0 {
+	if selectQueryMap.WhereClause != "" {
+		spannerQuery += " WHERE " + selectQueryMap.WhereClause
+	} else if len(whereConditions) > 0 {
 		var whereClauses []string
 		for i, cond := range whereConditions {
 			if cond.Value == questionMarkLiteral {
@@ -110,3 +158,17 @@ func formSpannerSelectQuery(selectQueryMap *SelectQueryMap, whereConditions []Co
 
 	return spannerQuery + ";", nil
 }
+
+// returningValues returns the item values a RETURNING clause returns, like
+// ALL OLD. Like DynamoDB, it must return every attribute of the item.
+func returningValues(ctx *parser.ReturningClauseContext) (string, error) {
+	columns := ctx.AllReturningColumn()
+	if len(columns) != 1 {
+		return "", fmt.Errorf("unsupported RETURNING clause: %s", treeText(ctx))
+	}
+	column := columns[0].(*parser.ReturningColumnContext)
+	if column.ASTERISK() == nil {
+		return "", fmt.Errorf("unsupported RETURNING clause: %s", treeText(ctx))
+	}
+	return strings.ToUpper(column.GetStatus().GetText() + " " + column.GetAge().GetText()), nil
+}
@@ -120,3 +120,23 @@ func TestFormSpannerSelectQuery(t *testing.T) {
 		})
 	}
 }
+
+func TestStatementTable(t *testing.T) {
+	for statement, table := range map[string]string{
+		`SELECT * FROM orders WHERE id = 1`:                                 "orders",
+		`select * from "orders"."by_status" where status = 'x'`:             "orders",
+		`SELECT * FROM orders.by_status WHERE status = 'x'`:                 "orders",
+		`SELECT * FROM "dev_my-orders" WHERE id = 1`:                        "dev_my-orders",
+		`INSERT INTO "orders" VALUE {'id': 1, 'note': 'moved from legacy'}`: "orders",
+		`UPDATE orders SET note = 'copied from legacy' WHERE id = 1`:        "orders",
+		`DELETE FROM "orders" WHERE id = 1`:                                 "orders",
+		`SELECT * FROM "say ""hi""" WHERE id = 1`:                           `say "hi"`,
+	} {
+		got, err := StatementTable(statement)
+		assert.NoError(t, err, statement)
+		assert.Equal(t, table, got, statement)
+	}
+
+	_, err := StatementTable(`SELECT 'from orders'`)
+	assert.Error(t, err)
+}
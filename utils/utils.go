@@ -19,12 +19,15 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"reflect"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 
 	"cloud.google.com/go/spanner"
 	"github.com/antonmedv/expr"
+	"github.com/antonmedv/expr/vm"
 	"github.com/cloudspannerecosystem/dynamodb-adapter/models"
 	"github.com/cloudspannerecosystem/dynamodb-adapter/pkg/errors"
 	"github.com/cloudspannerecosystem/dynamodb-adapter/pkg/logger"
@@ -74,6 +77,7 @@ func CreateConditionExpression(condtionExpression string, expressionAttr map[str
 	evalTokens := []string{}
 	cols := []string{}
 	ts := []string{}
+	numbers := map[string]interface{}{}
 	var err error
 	for i := 0; i < len(tokens); i++ {
 		if i%2 == 0 {
@@ -87,7 +91,11 @@ func CreateConditionExpression(condtionExpression string, expressionAttr map[str
 				if ok {
 					str = "\"" + str + "\""
 				}
-				switch v.(type) {
+				switch v := v.(type) {
+				case models.Number:
+					// numbers are compared exactly, as values of the condition
+					str = "NUMBER" + strconv.Itoa(i)
+					numbers[str] = v
 				case float64:
 					str = fmt.Sprintf("%f", v)
 				case int64:
@@ -125,17 +133,84 @@ func CreateConditionExpression(condtionExpression string, expressionAttr map[str
 	str = strings.ReplaceAll(str, " and ", " && ")
 	str = strings.ReplaceAll(str, " AND ", " && ")
 	str = strings.ReplaceAll(str, " <> ", " != ")
-	e.Cond, err = expr.Compile(str)
+	e.Cond, err = compileCondition(str)
 	if err != nil {
 		return nil, errors.New("ConditionalCheckFailedException", err.Error(), str)
 	}
 	e.Attributes = evalTokens
 	e.Cols = cols
 	e.Tokens = ts
-	e.ValueMap = make(map[string]interface{}, len(evalTokens))
+	e.ValueMap = make(map[string]interface{}, len(evalTokens)+len(numbers))
+	for name, v := range numbers {
+		e.ValueMap[name] = v
+	}
 	return e, nil
 }
 
+// conditionOperators are the comparisons of conditions, which are run by the
+// functions of the same name in conditionFunctions
+var conditionOperators = map[string]string{
+	"==": "equal", "!=": "notEqual", "<": "less", "<=": "lessOrEqual", ">": "greater", ">=": "greaterOrEqual",
+}
+
+// conditionFunctions compare the values of conditions. Numbers are compared
+// exactly, whether they are read as Numbers, Go numbers or NUMERIC values.
+var conditionFunctions = map[string]interface{}{
+	"equal":    func(a, b interface{}) bool { return conditionEqual(a, b) },
+	"notEqual": func(a, b interface{}) bool { return !conditionEqual(a, b) },
+	"less": func(a, b interface{}) bool {
+		c, ok := conditionCompare(a, b)
+		return ok && c < 0
+	},
+	"lessOrEqual": func(a, b interface{}) bool {
+		c, ok := conditionCompare(a, b)
+		return ok && c <= 0
+	},
+	"greater": func(a, b interface{}) bool {
+		c, ok := conditionCompare(a, b)
+		return ok && c > 0
+	},
+	"greaterOrEqual": func(a, b interface{}) bool {
+		c, ok := conditionCompare(a, b)
+		return ok && c >= 0
+	},
+}
+
+// compileCondition compiles a condition whose comparisons are run by
+// conditionFunctions
+func compileCondition(condition string) (*vm.Program, error) {
+	options := []expr.Option{expr.Env(conditionFunctions), expr.AllowUndefinedVariables()}
+	for op, fn := range conditionOperators {
+		options = append(options, expr.Operator(op, fn))
+	}
+	return expr.Compile(condition, options...)
+}
+
+func conditionEqual(a, b interface{}) bool {
+	if c, ok := conditionCompare(a, b); ok {
+		return c == 0
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// conditionCompare compares two numbers or two strings. It reports false for
+// values of other types, which are not ordered.
+func conditionCompare(a, b interface{}) (int, bool) {
+	if x, ok := models.ToNumber(a); ok {
+		y, ok := models.ToNumber(b)
+		if !ok {
+			return 0, false
+		}
+		return x.Cmp(y), true
+	}
+	x, ok := a.(string)
+	y, isString := b.(string)
+	if !ok || !isString {
+		return 0, false
+	}
+	return strings.Compare(x, y), true
+}
+
 // EvaluateExpression - evalute expression
 func EvaluateExpression(expression *models.Eval) (bool, error) {
 	if expression == nil || expression.Cond == nil {
@@ -145,7 +220,14 @@ func EvaluateExpression(expression *models.Eval) (bool, error) {
 		return false, nil
 	}
 
-	val, err := expr.Run(expression.Cond, expression.ValueMap)
+	env := make(map[string]interface{}, len(conditionFunctions)+len(expression.ValueMap))
+	for name, fn := range conditionFunctions {
+		env[name] = fn
+	}
+	for k, v := range expression.ValueMap {
+		env[k] = v
+	}
+	val, err := expr.Run(expression.Cond, env)
 	if err != nil {
 		return false, errors.New("ConditionalCheckFailedException", err.Error())
 	}
@@ -191,13 +273,50 @@ func ChangeTableNameForSpanner(tableName string) string {
 	return tableName
 }
 
+// GenerateIndexDDL - builds the CREATE INDEX statement for a secondary index.
+// Indexes are NULL_FILTERED so that items missing an index key are left out of
+// the index, and the attributes of an INCLUDE or ALL projection are kept in a
+// STORING clause. columns lists every column of the base table.
+func GenerateIndexDDL(tableConf, indexConf models.TableConfig, columns []string) string {
+	keys := []string{indexConf.PartitionKey}
+	if indexConf.SortKey != "" {
+		keys = append(keys, indexConf.SortKey)
+	}
+	isKey := func(col string) bool {
+		return col == tableConf.PartitionKey || col == tableConf.SortKey || col == indexConf.PartitionKey || col == indexConf.SortKey
+	}
+
+	var storing []string
+	switch indexConf.ProjectionType {
+	case models.ProjectionTypeAll:
+		for _, col := range columns {
+			if !isKey(col) && col != "commit_timestamp" {
+				storing = append(storing, col)
+			}
+		}
+	case models.ProjectionTypeInclude:
+		for _, col := range indexConf.NonKeyAttributes {
+			if !isKey(col) && slices.Contains(columns, col) {
+				storing = append(storing, col)
+			}
+		}
+	}
+
+	ddl := fmt.Sprintf("CREATE NULL_FILTERED INDEX %s ON %s (%s)",
+		ChangeTableNameForSpanner(indexConf.SpannerIndexName), ChangeTableNameForSpanner(tableConf.ActualTable), strings.Join(keys, ", "))
+	if len(storing) > 0 {
+		ddl += " STORING (" + strings.Join(storing, ", ") + ")"
+	}
+	return ddl
+}
+
 // Convert DynamoDB data types to equivalent Spanner types
 func ConvertDynamoTypeToSpannerType(dynamoType string) string {
 	switch dynamoType {
 	case "S":
 		return "STRING(MAX)"
 	case "N":
-		return "FLOAT64"
+		return "NUMERIC"
 	case "B":
 		return "BYTES(MAX)"
 	case "BOOL":
@@ -207,7 +326,7 @@ func ConvertDynamoTypeToSpannerType(dynamoType string) string {
 	case "SS":
 		return "ARRAY<STRING(MAX)>"
 	case "NS":
-		return "ARRAY<FLOAT64>"
+		return "ARRAY<NUMERIC>"
 	case "BS":
 		return "ARRAY<BYTES(MAX)>"
 	case "M":
@@ -247,6 +366,21 @@ func RemoveDuplicatesFloat(input []float64) []float64 {
 	return result
 }
 
+// RemoveDuplicatesNumber removes duplicates from a []models.Number. Numbers
+// are canonical, so equal numbers are equal strings.
+func RemoveDuplicatesNumber(input []models.Number) []models.Number {
+	seen := make(map[models.Number]struct{})
+	var result []models.Number
+
+	for _, val := range input {
+		if _, exists := seen[val]; !exists {
+			seen[val] = struct{}{}
+			result = append(result, val)
+		}
+	}
+	return result
+}
+
 // RemoveDuplicatesByteSlice removes duplicates from a [][]byte
 func RemoveDuplicatesByteSlice(input [][]byte) [][]byte {
 	seen := make(map[string]struct{})
@@ -357,6 +491,7 @@ func ParseBytes(r *spanner.Row, i int, k string) (map[string]interface{}, error)
 	return singleRowImg, err
 }
 
+// ParseNestedJSON converts a Map attribute stored as plain JSON to its value
 func ParseNestedJSON(value interface{}) interface{} {
 	switch v := value.(type) {
 	case map[string]interface{}:
@@ -364,7 +499,7 @@ func ParseNestedJSON(value interface{}) interface{} {
 		for key, val := range v {
 			m[key] = ParseNestedJSON(val)
 		}
-		return map[string]interface{}{"M": m} // Wrap around with "M"
+		return m
 	case []interface{}:
 		for i, item := range v {
 			v[i] = ParseNestedJSON(item)
@@ -379,7 +514,10 @@ func ParseNestedJSON(value interface{}) interface{} {
 			}
 		}
 		return v // Keep string as is
-	case float64:
+	case float64, json.Number:
+		if n, ok := models.ToNumber(v); ok {
+			return n
+		}
 		return v
 	default:
 		return v
@@ -16,12 +16,12 @@ package utils
 
 import (
 	"bytes"
+	"math/big"
 	"reflect"
 	"testing"
 
 	"github.com/tj/assert"
 
-	"github.com/antonmedv/expr"
 	"github.com/cloudspannerecosystem/dynamodb-adapter/models"
 )
 
@@ -59,7 +59,7 @@ func TestGetFieldNameFromConditionalExpression(t *testing.T) {
 }
 
 func TestCreateConditionExpression(t *testing.T) {
-	cond1, _ := expr.Compile(`TOKEN0 > "20" && TOKEN4 `)
+	cond1, _ := compileCondition(`TOKEN0 > "20" && TOKEN4 `)
 
 	tests := []struct {
 		testName            string
@@ -100,7 +100,7 @@ func TestCreateConditionExpression(t *testing.T) {
 }
 
 func TestEvaluateExpression(t *testing.T) {
-	cond1, _ := expr.Compile(`TOKEN0 > "20" && TOKEN4 `)
+	cond1, _ := compileCondition(`TOKEN0 > "20" && TOKEN4 `)
 	tests := []struct {
 		testName string
 		input    *models.Eval
@@ -156,6 +156,37 @@ func TestEvaluateExpression(t *testing.T) {
 	}
 }
 
+func TestEvaluateExpressionNumbers(t *testing.T) {
+	numeric, _ := new(big.Rat).SetString("12345678901234567890.5")
+	for condition, want := range map[string]bool{
+		"total = :v":                     true,
+		"total > :v":                     false,
+		"total < :bigger":                true,
+		"count = :count":                 true,
+		"numeric = :v":                   true,
+		"name > :v":                      false,
+		"total <> :bigger":               true,
+		"total >= :v AND count = :count": true,
+	} {
+		e, err := CreateConditionExpression(condition, map[string]interface{}{
+			":v":      models.Number("12345678901234567890.5"),
+			":bigger": models.Number("12345678901234567890.50000001"),
+			":count":  models.Number("3"),
+		})
+		assert.NoError(t, err, condition)
+		for i, col := range e.Cols {
+			e.ValueMap[e.Tokens[i]] = map[string]interface{}{
+				"total":   models.Number("12345678901234567890.5"),
+				"count":   int64(3),
+				"numeric": numeric,
+				"name":    "x",
+			}[col]
+		}
+		got, _ := EvaluateExpression(e)
+		assert.Equal(t, want, got, condition)
+	}
+}
+
 func TestParseBeginsWith(t *testing.T) {
 	tests := []struct {
 		testName, rangeExpression string
@@ -250,6 +281,37 @@ func TestChangeTableNameForSpanner(t *testing.T) {
 	}
 }
 
+func TestGenerateIndexDDL(t *testing.T) {
+	tableConf := models.TableConfig{PartitionKey: "id", SortKey: "ts", ActualTable: "order-items"}
+	columns := []string{"id", "ts", "customer", "status", "total", "commit_timestamp"}
+	tests := []struct {
+		testName  string
+		indexConf models.TableConfig
+		want      string
+	}{
+		{
+			"keys only projection",
+			models.TableConfig{SpannerIndexName: "by-customer", PartitionKey: "customer", ProjectionType: models.ProjectionTypeKeysOnly},
+			"CREATE NULL_FILTERED INDEX by_customer ON order_items (customer)",
+		},
+		{
+			"include projection skips keys and unknown columns",
+			models.TableConfig{SpannerIndexName: "by_status", PartitionKey: "status", SortKey: "ts", ProjectionType: models.ProjectionTypeInclude, NonKeyAttributes: []string{"total", "id", "missing"}},
+			"CREATE NULL_FILTERED INDEX by_status ON order_items (status, ts) STORING (total)",
+		},
+		{
+			"all projection stores every non-key column",
+			models.TableConfig{SpannerIndexName: "by_customer", PartitionKey: "customer", SortKey: "total", ProjectionType: models.ProjectionTypeAll},
+			"CREATE NULL_FILTERED INDEX by_customer ON order_items (customer, total) STORING (status)",
+		},
+	}
+
+	for _, tc := range tests {
+		got := GenerateIndexDDL(tableConf, tc.indexConf, columns)
+		assert.Equal(t, tc.want, got, tc.testName)
+	}
+}
+
 func TestRemoveDuplicatesString(t *testing.T) {
 	tests := []struct {
 		input    []string